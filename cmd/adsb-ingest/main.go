@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/boshu2/lattice-lab/internal/adsb"
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+)
+
+func main() {
+	cfg := adsb.DefaultConfig()
+	var healthAddr, metricsAddr string
+
+	fs := config.NewSet("adsb-ingest")
+	fs.String(&cfg.StoreAddr, "store", "STORE_ADDR", "entity-store address")
+	fs.Func("source", "ADSB_SOURCE", "feed: sbs or opensky (default sbs)", func(v string) error {
+		src, err := adsb.ParseSource(v)
+		cfg.Source = src
+		return err
+	})
+	fs.String(&cfg.SBSAddr, "sbs-addr", "SBS_ADDR", "dump1090 SBS output address")
+	fs.String(&cfg.OpenSkyURL, "opensky-url", "OPENSKY_URL", "OpenSky API base URL")
+	fs.Duration(&cfg.Interval, "interval", "INTERVAL", "publish (and OpenSky poll) interval")
+	fs.Duration(&cfg.StaleAfter, "stale-after", "STALE_AFTER", "delete aircraft not heard from for this long")
+	fs.String(&cfg.SensorID, "sensor-id", "SENSOR_ID", "reporting sensor ID")
+	fs.Float(&cfg.BBox.MinLat, "bbox-min-lat", "BBOX_MIN_LAT", "bounding box south edge")
+	fs.Float(&cfg.BBox.MaxLat, "bbox-max-lat", "BBOX_MAX_LAT", "bounding box north edge")
+	fs.Float(&cfg.BBox.MinLon, "bbox-min-lon", "BBOX_MIN_LON", "bounding box west edge")
+	fs.Float(&cfg.BBox.MaxLon, "bbox-max-lon", "BBOX_MAX_LON", "bounding box east edge")
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+	fs.String(&metricsAddr, "metrics-listen", "METRICS_LISTEN", "HTTP address for Prometheus /metrics (empty disables)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	if err := health.Serve(ctx, healthAddr, health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, metricsAddr); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
+	in := adsb.New(cfg)
+	if err := in.Run(ctx); err != nil {
+		slog.Error("adsb-ingest failed", "error", err)
+		os.Exit(1)
+	}
+}
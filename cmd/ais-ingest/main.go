@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/boshu2/lattice-lab/internal/ais"
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+)
+
+func main() {
+	cfg := ais.DefaultConfig()
+	var healthAddr, metricsAddr string
+
+	fs := config.NewSet("ais-ingest")
+	fs.String(&cfg.StoreAddr, "store", "STORE_ADDR", "entity-store address")
+	fs.String(&cfg.FeedAddr, "ais-addr", "AIS_ADDR", "TCP feed of AIVDM sentences")
+	fs.Duration(&cfg.Interval, "interval", "INTERVAL", "publish interval")
+	fs.Duration(&cfg.StaleAfter, "stale-after", "STALE_AFTER", "delete vessels not heard from for this long")
+	fs.String(&cfg.SensorID, "sensor-id", "SENSOR_ID", "reporting sensor ID")
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+	fs.String(&metricsAddr, "metrics-listen", "METRICS_LISTEN", "HTTP address for Prometheus /metrics (empty disables)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	if err := health.Serve(ctx, healthAddr, health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, metricsAddr); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
+	in := ais.New(cfg)
+	if err := in.Run(ctx); err != nil {
+		slog.Error("ais-ingest failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"syscall"
 
 	"github.com/boshu2/lattice-lab/internal/classifier"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
 )
 
 func main() {
@@ -28,6 +30,18 @@ func main() {
 		cancel()
 	}()
 
+	// HEALTH_LISTEN serves /healthz and /readyz: not ready until the watch
+	// is open, and unhealthy if classifying one track hangs.
+	cfg.Health = health.New()
+	if err := health.Serve(ctx, os.Getenv("HEALTH_LISTEN"), cfg.Health); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, os.Getenv("METRICS_LISTEN")); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
 	cl := classifier.New(cfg)
 	if err := cl.Run(ctx); err != nil {
 		slog.Error("classifier failed", "error", err)
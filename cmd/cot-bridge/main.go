@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/cot"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+)
+
+func main() {
+	cfg := cot.DefaultConfig()
+	var healthAddr, metricsAddr string
+
+	fs := config.NewSet("cot-bridge")
+	fs.String(&cfg.StoreAddr, "store", "STORE_ADDR", "entity-store address")
+	fs.String(&cfg.Endpoint, "endpoint", "COT_ENDPOINT", "TAK endpoint to push CoT to, udp://host:port or tcp://host:port")
+	fs.String(&cfg.Listen, "listen", "COT_LISTEN", "UDP address to ingest CoT from as tracks (default off)")
+	fs.Duration(&cfg.Stale, "stale", "COT_STALE", "how long each pushed event stays valid; events are refreshed at half this")
+	fs.String(&cfg.IDPrefix, "id-prefix", "ID_PREFIX", "entity ID prefix for ingested CoT")
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+	fs.String(&metricsAddr, "metrics-listen", "METRICS_LISTEN", "HTTP address for Prometheus /metrics (empty disables)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	if err := health.Serve(ctx, healthAddr, health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, metricsAddr); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
+	if err := cot.New(cfg).Run(ctx); err != nil {
+		slog.Error("cot-bridge failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/divergence"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/notify"
+)
+
+func main() {
+	cfg := divergence.DefaultConfig()
+	var webhook, slack string
+	var healthAddr string
+
+	fs := config.NewSet("divergence-monitor")
+	fs.Func("nodes", "STORE_NODES", "comma-separated store addresses to compare (at least two)", func(v string) error {
+		cfg.Nodes = strings.Split(v, ",")
+		return nil
+	})
+	fs.Duration(&cfg.Interval, "interval", "SAMPLE_INTERVAL", "how often the nodes are sampled")
+	fs.Duration(&cfg.Grace, "grace", "DIVERGENCE_GRACE", "how long an entity may stay diverged before alerting")
+	fs.Duration(&cfg.Timeout, "timeout", "SAMPLE_TIMEOUT", "per-node ListEntities deadline")
+	fs.String(&cfg.Listen, "listen", "METRICS_LISTEN", "HTTP address for /metrics and /divergence (empty disables)")
+	fs.String(&webhook, "webhook", "NOTIFY_WEBHOOK", "URL POSTed with each alert as JSON")
+	fs.String(&slack, "slack", "SLACK_WEBHOOK", "Slack incoming webhook URL for alerts")
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if webhook != "" {
+		cfg.Sinks = append(cfg.Sinks, notify.Webhook{URL: webhook})
+	}
+	if slack != "" {
+		cfg.Sinks = append(cfg.Sinks, notify.Slack{URL: slack})
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	if err := health.Serve(ctx, healthAddr, health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+
+	if err := divergence.New(cfg).Run(ctx); err != nil {
+		slog.Error("divergence-monitor failed", "error", err)
+		os.Exit(1)
+	}
+}
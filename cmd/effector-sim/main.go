@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/effector"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+)
+
+func main() {
+	cfg := effector.DefaultConfig()
+	var healthAddr, metricsAddr string
+
+	fs := config.NewSet("effector-sim")
+	fs.String(&cfg.StoreAddr, "store", "STORE_ADDR", "entity-store address")
+	fs.Duration(&cfg.Interval, "interval", "INTERVAL", "flight update interval")
+	fs.Int(&cfg.NumAssets, "num-assets", "NUM_ASSETS", "number of interceptor assets")
+	fs.Float(&cfg.SpeedKnots, "speed-kts", "ASSET_SPEED_KTS", "asset cruise speed, knots")
+	fs.Float(&cfg.InterceptRange, "intercept-range-m", "INTERCEPT_RANGE_M", "closing inside this range completes the task, meters")
+	fs.Duration(&cfg.MissionTimeout, "mission-timeout", "MISSION_TIMEOUT", "report failure after this long")
+	fs.Float(&cfg.BaseLat, "base-lat", "BASE_LAT", "asset base latitude")
+	fs.Float(&cfg.BaseLon, "base-lon", "BASE_LON", "asset base longitude")
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+	fs.String(&metricsAddr, "metrics-listen", "METRICS_LISTEN", "HTTP address for Prometheus /metrics (empty disables)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	if err := health.Serve(ctx, healthAddr, health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, metricsAddr); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
+	eff := effector.New(cfg)
+	if err := eff.Run(ctx); err != nil {
+		slog.Error("effector-sim failed", "error", err)
+		os.Exit(1)
+	}
+}
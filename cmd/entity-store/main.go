@@ -1,17 +1,36 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
+	registryv1 "github.com/boshu2/lattice-lab/gen/registry/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	taskv1 "github.com/boshu2/lattice-lab/gen/task/v1"
+	"github.com/boshu2/lattice-lab/internal/audit"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/crdt"
+	"github.com/boshu2/lattice-lab/internal/export"
+	"github.com/boshu2/lattice-lab/internal/gateway"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"github.com/boshu2/lattice-lab/internal/registry"
 	"github.com/boshu2/lattice-lab/internal/server"
 	"github.com/boshu2/lattice-lab/internal/store"
+	"github.com/boshu2/lattice-lab/internal/task"
 	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/reflection"
 )
 
@@ -27,10 +46,204 @@ func main() {
 		os.Exit(1)
 	}
 
-	s := store.New()
-	grpcServer := grpc.NewServer()
-	storev1.RegisterEntityStoreServiceServer(grpcServer, server.New(s))
+	// Each entity's last HISTORY_DEPTH versions are kept for
+	// GetEntityHistory; 0 disables it.
+	depth := 16
+	if v := os.Getenv("HISTORY_DEPTH"); v != "" {
+		if depth, err = strconv.Atoi(v); err != nil || depth < 0 {
+			slog.Error("invalid HISTORY_DEPTH", "value", v)
+			os.Exit(1)
+		}
+	}
+	// Deletes leave tombstones for TOMBSTONE_TTL, so stale copies relayed
+	// from a partitioned peer are refused; 0 keeps them for good.
+	tombstoneTTL := store.DefaultTombstoneTTL
+	if v := os.Getenv("TOMBSTONE_TTL"); v != "" {
+		if tombstoneTTL, err = time.ParseDuration(v); err != nil || tombstoneTTL < 0 {
+			slog.Error("invalid TOMBSTONE_TTL", "value", v)
+			os.Exit(1)
+		}
+	}
+	// Removed components leave tombstones in their entities for
+	// COMPONENT_TOMBSTONE_TTL, so replicas still holding them do not bring
+	// them back; 0 keeps them for good.
+	componentTombstoneTTL := store.DefaultTombstoneTTL
+	if v := os.Getenv("COMPONENT_TOMBSTONE_TTL"); v != "" {
+		if componentTombstoneTTL, err = time.ParseDuration(v); err != nil || componentTombstoneTTL < 0 {
+			slog.Error("invalid COMPONENT_TOMBSTONE_TTL", "value", v)
+			os.Exit(1)
+		}
+	}
+	// Deleted and expired entities stay listable with ListArchivedEntities
+	// for ARCHIVE_RETENTION; 0 disables the archive.
+	archiveRetention := store.DefaultArchiveRetention
+	if v := os.Getenv("ARCHIVE_RETENTION"); v != "" {
+		if archiveRetention, err = time.ParseDuration(v); err != nil || archiveRetention < 0 {
+			slog.Error("invalid ARCHIVE_RETENTION", "value", v)
+			os.Exit(1)
+		}
+	}
+	// Entities past their ttl are removed on the next REAPER_INTERVAL tick,
+	// so an expiry lands up to one interval late.
+	reaperInterval := time.Second
+	if v := os.Getenv("REAPER_INTERVAL"); v != "" {
+		if reaperInterval, err = time.ParseDuration(v); err != nil || reaperInterval <= 0 {
+			slog.Error("invalid REAPER_INTERVAL", "value", v)
+			os.Exit(1)
+		}
+	}
+	// Writes the clock would stamp more than HLC_MAX_DRIFT ahead of the
+	// wall clock, or past HLC_MAX_LOGICAL on its logical counter, fail with
+	// UNAVAILABLE, as do copies and calls from peers whose clocks run that
+	// far ahead; 0 leaves either unbounded.
+	maxDrift := store.DefaultMaxDrift
+	if v := os.Getenv("HLC_MAX_DRIFT"); v != "" {
+		if maxDrift, err = time.ParseDuration(v); err != nil || maxDrift < 0 {
+			slog.Error("invalid HLC_MAX_DRIFT", "value", v)
+			os.Exit(1)
+		}
+	}
+	var maxLogical uint64
+	if v := os.Getenv("HLC_MAX_LOGICAL"); v != "" {
+		if maxLogical, err = strconv.ParseUint(v, 10, 32); err != nil {
+			slog.Error("invalid HLC_MAX_LOGICAL", "value", v)
+			os.Exit(1)
+		}
+	}
+	// ListEntities filters on the INDEXES fields without a full scan.
+	indexes := store.DefaultIndexes
+	if v, ok := os.LookupEnv("INDEXES"); ok {
+		indexes = v
+	}
+	keys, err := store.ParseIndexes(indexes)
+	if err != nil {
+		slog.Error("invalid INDEXES", "value", indexes, "error", err)
+		os.Exit(1)
+	}
+	// QUOTAS caps the entities of each type, e.g. "track=5000,geo=200";
+	// creates past a cap fail with RESOURCE_EXHAUSTED.
+	quotas, err := store.ParseQuotas(os.Getenv("QUOTAS"))
+	if err != nil {
+		slog.Error("invalid QUOTAS", "error", err)
+		os.Exit(1)
+	}
+	// MERGE_STRATEGIES assigns component keys merge strategies, e.g.
+	// "threat=max-wins,fusion=set-union", for copies relays replicate in;
+	// every store and relay in a mesh needs the same.
+	strategies, err := crdt.ParseRegistry(os.Getenv("MERGE_STRATEGIES"))
+	if err != nil {
+		slog.Error("invalid MERGE_STRATEGIES", "error", err)
+		os.Exit(1)
+	}
+	opts := []store.Option{store.WithHistory(depth), store.WithTombstoneTTL(tombstoneTTL), store.WithComponentTombstoneTTL(componentTombstoneTTL), store.WithArchiveRetention(archiveRetention), store.WithIndex(keys...), store.WithQuotas(quotas), store.WithStrategies(strategies), store.WithMaxDrift(maxDrift, uint32(maxLogical))}
+
+	// With WAL_PATH set, writes are logged and replayed on restart, so a
+	// killed store comes back with every acknowledged write.
+	var s *store.Store
+	if path := os.Getenv("WAL_PATH"); path != "" {
+		if sync, _ := strconv.ParseBool(os.Getenv("WAL_SYNC")); sync {
+			opts = append(opts, store.WithSync())
+		}
+		s, err = store.Open(path, opts...)
+		if err != nil {
+			slog.Error("failed to open wal", "path", path, "error", err)
+			os.Exit(1)
+		}
+		defer s.Close()
+	} else {
+		s = store.New(opts...)
+	}
+	// With HLC_STATE set, the clock is saved to that file and resumed from
+	// it, so a store restarted with its wall clock set back still stamps
+	// writes after the ones it made before.
+	if path := os.Getenv("HLC_STATE"); path != "" {
+		if err := s.PersistClock(path); err != nil {
+			slog.Error("failed to load clock state", "path", path, "error", err)
+			os.Exit(1)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.StartReaper(ctx, reaperInterval)
+
+	reg := registry.New()
+	// With AUTH_TOKENS set, e.g. "s3cret=operator,radar1=sensor", every call
+	// needs one of the tokens, and sensor tokens may only write tracks.
+	srvOpts := []server.Option{server.WithRegistry(reg)}
+	var auth server.Authenticator
+	if v := os.Getenv("AUTH_TOKENS"); v != "" {
+		tokens, err := server.ParseTokens(v)
+		if err != nil {
+			slog.Error("invalid AUTH_TOKENS", "error", err)
+			os.Exit(1)
+		}
+		auth = tokens
+		srvOpts = append(srvOpts, server.WithAuth(tokens))
+	}
+	// The last AUDIT_SIZE mutating calls are kept for GetAuditLog; 0
+	// disables the audit log.
+	auditSize := audit.DefaultSize
+	if v := os.Getenv("AUDIT_SIZE"); v != "" {
+		if auditSize, err = strconv.Atoi(v); err != nil || auditSize < 0 {
+			slog.Error("invalid AUDIT_SIZE", "value", v)
+			os.Exit(1)
+		}
+	}
+	if auditSize > 0 {
+		srvOpts = append(srvOpts, server.WithAudit(audit.New(auditSize)))
+	}
+	// With TASK_MANAGER_ADDR set, ApproveAction and DenyAction are
+	// forwarded to the task-manager there; otherwise they are UNIMPLEMENTED.
+	if v := os.Getenv("TASK_MANAGER_ADDR"); v != "" {
+		conn, err := client.Dial(v)
+		if err != nil {
+			slog.Error("invalid TASK_MANAGER_ADDR", "value", v, "error", err)
+			os.Exit(1)
+		}
+		defer conn.Close()
+		srvOpts = append(srvOpts, server.WithApprovals(task.NewRemote(taskv1.NewTaskManagerServiceClient(conn))))
+	}
+	srv := server.New(s, srvOpts...)
+	grpcServer := grpc.NewServer(metrics.ServerOption(), client.ServerOption(), grpc.UnaryInterceptor(srv.UnaryInterceptor()), grpc.StreamInterceptor(srv.StreamInterceptor()))
+	storev1.RegisterEntityStoreServiceServer(grpcServer, srv)
+	registryv1.RegisterSchemaRegistryServiceServer(grpcServer, registry.NewService(reg))
 	reflection.Register(grpcServer)
+	// grpc.health.v1 for Kubernetes gRPC probes: SERVING once the log is
+	// replayed, which it is by now, until shutdown begins.
+	healthServer := grpchealth.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	probe := health.New()
+
+	// GeoJSON/KML export of the picture, for QGIS and Google Earth,
+	// Prometheus metrics on /metrics (the store's, then gRPC latency and
+	// streams), and the store as REST+JSON on /v1/.
+	var httpServer *http.Server
+	if httpPort := os.Getenv("HTTP_PORT"); httpPort != "" {
+		httpLis, err := net.Listen("tcp", fmt.Sprintf(":%s", httpPort))
+		if err != nil {
+			slog.Error("failed to listen", "error", err)
+			os.Exit(1)
+		}
+		// The gateway calls the gRPC server rather than the store, so its
+		// requests are authenticated and audited like any other.
+		conn, err := client.Dial("localhost:" + port)
+		if err != nil {
+			slog.Error("failed to dial gateway backend", "error", err)
+			os.Exit(1)
+		}
+		defer conn.Close()
+		// The export and metrics read the store directly, so with
+		// AUTH_TOKENS set they need an operator token of their own.
+		mux := http.NewServeMux()
+		mux.Handle("/", server.RequireOperator(auth, export.Handler(s.List)))
+		mux.Handle("GET /metrics", server.RequireOperator(auth, metrics.Default.Handler(func(w io.Writer) { s.Stats().WriteMetrics(w) })))
+		mux.Handle("GET /healthz", probe.Handler())
+		mux.Handle("GET /readyz", probe.Handler())
+		mux.Handle("/v1/", gateway.Handler(storev1.NewEntityStoreServiceClient(conn), reg))
+		httpServer = &http.Server{Handler: mux}
+		go httpServer.Serve(httpLis) //nolint:errcheck
+		slog.Info("entity-store export listening", "port", httpPort)
+	}
 
 	// Graceful shutdown on SIGINT/SIGTERM.
 	go func() {
@@ -38,6 +251,11 @@ func main() {
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 		<-sigCh
 		slog.Info("shutting down")
+		healthServer.Shutdown()
+		probe.SetReady("store", errors.New("shutting down"))
+		if httpServer != nil {
+			httpServer.Close()
+		}
 		grpcServer.GracefulStop()
 	}()
 
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/eventbridge"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+)
+
+func main() {
+	cfg := eventbridge.DefaultConfig()
+	var healthAddr, metricsAddr string
+
+	fs := config.NewSet("event-bridge")
+	fs.String(&cfg.StoreAddr, "store", "STORE_ADDR", "entity-store address")
+	fs.Func("broker", "BROKER", "nats or kafka (default nats)", func(v string) error {
+		cfg.Broker = eventbridge.Broker(v)
+		return nil
+	})
+	fs.String(&cfg.URL, "url", "BROKER_URL", "nats://host:port, or comma-separated Kafka seed brokers")
+	fs.String(&cfg.Out, "out", "BRIDGE_OUT", "subject or topic to publish entity events to")
+	fs.String(&cfg.In, "in", "BRIDGE_IN", "subject or topic to apply external entity events from (default off)")
+	fs.String(&cfg.Group, "group", "KAFKA_GROUP", "Kafka consumer group for the inbound topic")
+	fs.Func("format", "BRIDGE_FORMAT", "payload encoding, protojson or protobuf (default protojson)", func(v string) error {
+		cfg.Format = eventbridge.Format(v)
+		return nil
+	})
+	fs.Func("type", "BRIDGE_TYPE", "publish only this entity type: track, asset, geo, or system (default all)", func(v string) error {
+		switch v {
+		case "track":
+			cfg.TypeFilter = entityv1.EntityType_ENTITY_TYPE_TRACK
+		case "asset":
+			cfg.TypeFilter = entityv1.EntityType_ENTITY_TYPE_ASSET
+		case "geo":
+			cfg.TypeFilter = entityv1.EntityType_ENTITY_TYPE_GEO
+		case "system":
+			cfg.TypeFilter = entityv1.EntityType_ENTITY_TYPE_SYSTEM
+		default:
+			return fmt.Errorf("unknown entity type %q", v)
+		}
+		return nil
+	})
+	fs.String(&cfg.NodeID, "node-id", "NODE_ID", "origin stamped on published events (default event-bridge-<hostname>)")
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+	fs.String(&metricsAddr, "metrics-listen", "METRICS_LISTEN", "HTTP address for Prometheus /metrics (empty disables)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	if err := health.Serve(ctx, healthAddr, health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, metricsAddr); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
+	if err := eventbridge.New(cfg).Run(ctx); err != nil {
+		slog.Error("event-bridge failed", "error", err)
+		os.Exit(1)
+	}
+}
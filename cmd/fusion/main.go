@@ -9,6 +9,8 @@ import (
 	"syscall"
 
 	"github.com/boshu2/lattice-lab/internal/fusion"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
 )
 
 func main() {
@@ -17,13 +19,31 @@ func main() {
 	if v := os.Getenv("STORE_ADDR"); v != "" {
 		cfg.StoreAddr = v
 	}
-	if v := os.Getenv("DIST_THRESHOLD"); v != "" {
-		d, err := strconv.ParseFloat(v, 64)
+	for _, env := range []struct {
+		name string
+		dst  *float64
+	}{
+		{"DIST_THRESHOLD", &cfg.DistThreshold},
+		{"ALT_THRESHOLD", &cfg.AltThreshold},
+		{"SPEED_THRESHOLD", &cfg.SpeedThreshold},
+		{"HEADING_THRESHOLD", &cfg.HeadingThreshold},
+	} {
+		if v := os.Getenv(env.name); v != "" {
+			d, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				slog.Error("invalid "+env.name, "value", v, "error", err)
+				os.Exit(1)
+			}
+			*env.dst = d
+		}
+	}
+	if v := os.Getenv("DIST_FLAT_EARTH"); v != "" {
+		b, err := strconv.ParseBool(v)
 		if err != nil {
-			slog.Error("invalid DIST_THRESHOLD", "value", v, "error", err)
+			slog.Error("invalid DIST_FLAT_EARTH", "value", v, "error", err)
 			os.Exit(1)
 		}
-		cfg.DistThreshold = d
+		cfg.FlatEarth = b
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -37,6 +57,15 @@ func main() {
 		cancel()
 	}()
 
+	if err := health.Serve(ctx, os.Getenv("HEALTH_LISTEN"), health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, os.Getenv("METRICS_LISTEN")); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
 	f := fusion.New(cfg)
 	if err := f.Run(ctx); err != nil {
 		slog.Error("fusion service failed", "error", err)
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/boshu2/lattice-lab/internal/bench"
+	"github.com/boshu2/lattice-lab/internal/config"
+)
+
+func main() {
+	cfg := bench.DefaultConfig()
+	output := "-"
+
+	fs := config.NewSet("lattice-bench")
+	fs.String(&cfg.StoreAddr, "store", "STORE_ADDR", "entity-store address to write to")
+	fs.Func("peers", "MESH_PEERS", "comma-separated mesh peer stores to measure convergence on", func(v string) error {
+		cfg.Peers = strings.Split(v, ",")
+		return nil
+	})
+	fs.Int(&cfg.Entities, "entities", "BENCH_ENTITIES", "entities created in the create phase")
+	fs.Int(&cfg.Updates, "updates", "BENCH_UPDATES", "update rounds over every entity")
+	fs.Int(&cfg.Workers, "workers", "WORKERS", "concurrent writers")
+	fs.Int(&cfg.Watchers, "watchers", "BENCH_WATCHERS", "concurrent watch streams on the store")
+	fs.Duration(&cfg.Timeout, "timeout", "BENCH_TIMEOUT", "how long to wait for watchers and peers to catch up")
+	fs.String(&cfg.IDPrefix, "id-prefix", "ID_PREFIX", "entity ID prefix")
+	fs.Bool(&cfg.Cleanup, "cleanup", "BENCH_CLEANUP", "delete the benchmark entities afterwards")
+	fs.String(&cfg.AuthToken, "auth-token", "AUTH_TOKEN", "bearer token for an entity-store with AUTH_TOKENS set")
+	fs.String(&output, "o", "BENCH_OUTPUT", "JSON report path, - for stdout")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	report, err := bench.New(cfg).Run(ctx)
+	if err != nil {
+		slog.Error("lattice-bench failed", "error", err)
+		os.Exit(1)
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "-" {
+		f, err := os.Create(output)
+		if err != nil {
+			slog.Error("create report", "error", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := report.Write(w); err != nil {
+		slog.Error("write report", "error", err)
+		os.Exit(1)
+	}
+
+	// A peer that never converged fails the run, so CI can gate on it.
+	for _, p := range report.Convergence {
+		if !p.Converged {
+			slog.Error("peer did not converge", "peer", p.Store, "timeout", cfg.Timeout)
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+func auditCmd() *cobra.Command {
+	var (
+		entityID string
+		limit    uint32
+		asJSON   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Show the store's audit log of mutating calls, oldest first",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dial()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			resp, err := client.GetAuditLog(context.Background(), &storev1.GetAuditLogRequest{EntityId: entityID, Limit: limit})
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				for _, e := range resp.Entries {
+					line, err := protojson.Marshal(e)
+					if err != nil {
+						return err
+					}
+					fmt.Printf("%s\n", line)
+				}
+				return nil
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tHLC\tMETHOD\tPEER\tROLE\tTOKEN\tTARGETS\tRESULT")
+			for _, e := range resp.Entries {
+				var targets []string
+				for _, t := range e.Targets {
+					if len(t.ComponentKeys) > 0 {
+						targets = append(targets, t.EntityId+"("+strings.Join(t.ComponentKeys, ",")+")")
+					} else {
+						targets = append(targets, t.EntityId)
+					}
+				}
+				method := e.Method[strings.LastIndex(e.Method, "/")+1:]
+				ts := hlc.Timestamp{Physical: e.Hlc.GetPhysical(), Logical: e.Hlc.GetLogical(), Node: e.Hlc.GetNode()}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Time.AsTime().Local().Format("15:04:05.000"), ts, method,
+					e.Peer, e.Role, e.TokenId, strings.Join(targets, " "), codes.Code(e.Code))
+				// A relay's merge, one decision per line beneath its call.
+				for _, d := range e.Merge {
+					why := d.Strategy
+					if d.Removed {
+						why = strings.TrimPrefix(why+", removed", ", ")
+					}
+					if why != "" {
+						why = " (" + why + ")"
+					}
+					fmt.Fprintf(w, "\t\t\t\t\t\t  %s: kept %s%s\t\n", d.Key, d.Kept, why)
+				}
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&entityID, "entity", "", "only calls that touched this entity")
+	cmd.Flags().Uint32Var(&limit, "limit", 0, "only the most recent N calls (default: all kept)")
+	cmd.Flags().BoolVar(&asJSON, "json", false, "print NDJSON, one protojson AuditEntry per line")
+	return cmd
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// Change records are NDJSON, one protojson ChangeRecord per line, for
+// loading into an analytics pipeline.
+
+func cdcCmd() *cobra.Command {
+	var (
+		since uint64
+		out   string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "cdc",
+		Short: "Export every committed store mutation as NDJSON change records",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dial()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			w := os.Stdout
+			if out != "" && out != "-" {
+				f, err := os.Create(out)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			opts := protojson.MarshalOptions{Resolver: fetchSchemas()}
+			n := 0
+			fmt.Fprintln(os.Stderr, "Exporting change records (Ctrl+C to stop)...")
+			for {
+				stream, err := client.StreamChanges(ctx, &storev1.StreamChangesRequest{SinceSequence: since})
+				if err != nil {
+					return err
+				}
+				for {
+					r, err := stream.Recv()
+					if ctx.Err() != nil {
+						fmt.Fprintf(os.Stderr, "Exported %d change records\n", n)
+						return nil
+					}
+					if status.Code(err) == codes.ResourceExhausted {
+						break // fell behind; resume from since
+					}
+					if err != nil {
+						return err
+					}
+					line, err := opts.Marshal(r)
+					if err != nil {
+						return err
+					}
+					if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
+						return err
+					}
+					since = r.Sequence
+					n++
+				}
+			}
+		},
+	}
+
+	cmd.Flags().Uint64Var(&since, "since", 0, "resume after the record with this sequence (default: from the next write)")
+	cmd.Flags().StringVarP(&out, "out", "o", "-", "output file (- for stdout)")
+	return cmd
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// printError writes err to w, followed by any google.rpc details the
+// server attached to it, such as the HLC an entity is at after a
+// conflicting update.
+func printError(w io.Writer, err error) {
+	st, ok := status.FromError(err)
+	if !ok {
+		fmt.Fprintf(w, "Error: %v\n", err)
+		return
+	}
+	fmt.Fprintf(w, "Error: %s: %s\n", st.Code(), st.Message())
+	for _, d := range st.Details() {
+		switch d := d.(type) {
+		case *errdetails.ErrorInfo:
+			fmt.Fprintf(w, "  reason:    %s (%s)\n", d.Reason, d.Domain)
+			for _, k := range slices.Sorted(maps.Keys(d.Metadata)) {
+				fmt.Fprintf(w, "    %s: %s\n", k, d.Metadata[k])
+			}
+		case *errdetails.PreconditionFailure:
+			for _, v := range d.Violations {
+				fmt.Fprintf(w, "  violation: %s %s: %s\n", v.Type, v.Subject, v.Description)
+			}
+		case *errdetails.ResourceInfo:
+			fmt.Fprintf(w, "  resource:  %s %s\n", d.ResourceType, d.ResourceName)
+		case *errdetails.QuotaFailure:
+			for _, v := range d.Violations {
+				fmt.Fprintf(w, "  quota:     %s: %s\n", v.Subject, v.Description)
+			}
+		case *errdetails.BadRequest:
+			for _, v := range d.FieldViolations {
+				fmt.Fprintf(w, "  field:     %s: %s\n", v.Field, v.Description)
+			}
+		default:
+			// Another detail type, or an error if it could not be decoded.
+			fmt.Fprintf(w, "  detail:    %v\n", d)
+		}
+	}
+}
@@ -3,35 +3,85 @@ package main
 import (
 	"context"
 	"fmt"
+	"maps"
 	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
 	"text/tabwriter"
+	"time"
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	taskv1 "github.com/boshu2/lattice-lab/gen/task/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/labels"
+	"github.com/boshu2/lattice-lab/internal/sensor"
 	"github.com/spf13/cobra"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
 )
 
-var storeAddr string
+var (
+	storeAddr       string
+	taskManagerAddr string
+	relayAddr       string
+	token           string
+	useTLS          bool
+	caFile          string
+	timeout         time.Duration
+	compression     string
+)
 
 func main() {
 	root := &cobra.Command{
 		Use:   "lattice-cli",
 		Short: "Operator interface for Lattice Lab",
+		// main prints errors itself, with their details.
+		SilenceErrors: true,
 	}
 
 	root.PersistentFlags().StringVar(&storeAddr, "store", "localhost:50051", "entity-store address")
+	root.PersistentFlags().StringVar(&taskManagerAddr, "task-manager", "localhost:50052", "task-manager address")
+	root.PersistentFlags().StringVar(&relayAddr, "relay", "localhost:50053", "mesh relay admin address, for peers and mesh")
+	root.PersistentFlags().StringVar(&token, "token", os.Getenv("LATTICE_TOKEN"), "entity-store bearer token, if it requires one (default $LATTICE_TOKEN)")
+	root.PersistentFlags().BoolVar(&useTLS, "tls", false, "connect over TLS, e.g. through an ingress that terminates it")
+	root.PersistentFlags().StringVar(&caFile, "ca", "", "PEM CA certificates to verify the server with under --tls (default the system roots)")
+	root.PersistentFlags().StringVar(&compression, "compression", "", "compress requests with gzip or snappy, e.g. a restore over a slow link")
+	root.PersistentFlags().DurationVar(&timeout, "timeout", client.DefaultTimeout, "deadline of each call; watches are not limited")
 
-	root.AddCommand(listCmd(), getCmd(), watchCmd(), approveCmd(), denyCmd())
+	root.AddCommand(listCmd(), getCmd(), watchCmd(), recordCmd(), approveCmd(), denyCmd(), statsCmd(), historyCmd(), schemaCmd(), snapshotCmd(), restoreCmd(), versionsCmd(), linksCmd(), labelCmd(), cdcCmd(), archivedCmd(), auditCmd(), peersCmd(), meshCmd())
 
 	if err := root.Execute(); err != nil {
+		printError(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
+// connect dials addr with the --tls, --ca, --timeout, and --compression
+// flags.
+func connect(addr string, opts ...client.Option) (*grpc.ClientConn, error) {
+	opts = append(opts, client.WithTimeout(timeout), client.WithCompression(compression))
+	if useTLS {
+		cfg, err := client.LoadTLS(caFile)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, client.WithTLS(cfg))
+	}
+	return client.Dial(addr, opts...)
+}
+
+// storeConn connects to the entity-store, sending --token if given.
+func storeConn() (*grpc.ClientConn, error) {
+	return connect(storeAddr, client.WithToken(token))
+}
+
 func dial() (storev1.EntityStoreServiceClient, func(), error) {
-	conn, err := grpc.NewClient(storeAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := storeConn()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -39,8 +89,18 @@ func dial() (storev1.EntityStoreServiceClient, func(), error) {
 	return client, func() { conn.Close() }, nil
 }
 
+func dialTaskManager() (taskv1.TaskManagerServiceClient, func(), error) {
+	conn, err := connect(taskManagerAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := taskv1.NewTaskManagerServiceClient(conn)
+	return client, func() { conn.Close() }, nil
+}
+
 func listCmd() *cobra.Command {
-	var typeFilter string
+	var typeFilter, bbox, selector string
+	var where []string
 
 	cmd := &cobra.Command{
 		Use:   "list",
@@ -52,42 +112,172 @@ func listCmd() *cobra.Command {
 			}
 			defer cleanup()
 
-			filter := entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED
-			switch typeFilter {
-			case "track":
-				filter = entityv1.EntityType_ENTITY_TYPE_TRACK
-			case "asset":
-				filter = entityv1.EntityType_ENTITY_TYPE_ASSET
-			case "geo":
-				filter = entityv1.EntityType_ENTITY_TYPE_GEO
+			filters := make([]*storev1.ComponentFilter, len(where))
+			for i, w := range where {
+				if filters[i], err = parseWhere(w); err != nil {
+					return err
+				}
 			}
-
-			resp, err := client.ListEntities(context.Background(), &storev1.ListEntitiesRequest{
-				TypeFilter: filter,
-			})
+			if bbox != "" && len(filters) > 0 {
+				return fmt.Errorf("--where cannot be combined with --bbox")
+			}
+			sel, err := labels.Parse(selector)
 			if err != nil {
 				return err
 			}
 
+			var entities []*entityv1.Entity
+			if bbox != "" {
+				req, err := parseBBox(bbox)
+				if err != nil {
+					return err
+				}
+				req.TypeFilter = entityTypeFilter(typeFilter)
+				resp, err := client.QueryEntitiesByBBox(context.Background(), req)
+				if err != nil {
+					return err
+				}
+				for _, e := range resp.Entities {
+					if sel.Matches(e.Labels) {
+						entities = append(entities, e)
+					}
+				}
+			} else {
+				resp, err := client.ListEntities(context.Background(), &storev1.ListEntitiesRequest{
+					TypeFilter:    entityTypeFilter(typeFilter),
+					Filters:       filters,
+					LabelSelector: selector,
+				})
+				if err != nil {
+					return err
+				}
+				entities = resp.Entities
+			}
+
 			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-			fmt.Fprintln(w, "ID\tTYPE\tCOMPONENTS\tUPDATED")
-			for _, e := range resp.Entities {
+			fmt.Fprintln(w, "ID\tTYPE\tCOMPONENTS\tLABELS\tUPDATED")
+			for _, e := range entities {
 				comps := componentNames(e)
 				updated := ""
 				if e.UpdatedAt != nil {
 					updated = e.UpdatedAt.AsTime().Format("15:04:05")
 				}
-				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", e.Id, e.Type, comps, updated)
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", e.Id, e.Type, comps, labels.Format(e.Labels), updated)
 			}
 			w.Flush()
 			return nil
 		},
 	}
 
-	cmd.Flags().StringVarP(&typeFilter, "type", "t", "", "filter by type (track, asset, geo)")
+	cmd.Flags().StringVarP(&typeFilter, "type", "t", "", "filter by type (track, asset, geo, system)")
+	cmd.Flags().StringVar(&bbox, "bbox", "", "only entities positioned inside min_lat,min_lon,max_lat,max_lon")
+	cmd.Flags().StringArrayVar(&where, "where", nil, "only entities matching component.field<op>value, e.g. threat.level>=HIGH (repeatable)")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "only entities whose labels match, e.g. exercise=bravo,side!=red")
 	return cmd
 }
 
+func archivedCmd() *cobra.Command {
+	var typeFilter, selector string
+
+	cmd := &cobra.Command{
+		Use:   "archived",
+		Short: "List recently deleted and expired entities",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dial()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			resp, err := client.ListArchivedEntities(context.Background(), &storev1.ListArchivedEntitiesRequest{
+				TypeFilter:    entityTypeFilter(typeFilter),
+				LabelSelector: selector,
+			})
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tTYPE\tCOMPONENTS\tLABELS\tREASON\tARCHIVED")
+			for _, a := range resp.Entities {
+				e := a.Entity
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Id, e.Type, componentNames(e), labels.Format(e.Labels),
+					strings.TrimPrefix(a.Reason.String(), "EVENT_TYPE_"), a.ArchivedAt.AsTime().Local().Format("15:04:05"))
+			}
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().StringVarP(&typeFilter, "type", "t", "", "filter by type (track, asset, geo, system)")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "only entities whose labels match, e.g. exercise=bravo,side!=red")
+	return cmd
+}
+
+// whereOps maps --where operators to filter ops, longest first so ">="
+// is not read as ">".
+var whereOps = []struct {
+	token string
+	op    storev1.FilterOp
+}{
+	{">=", storev1.FilterOp_FILTER_OP_GE},
+	{"<=", storev1.FilterOp_FILTER_OP_LE},
+	{"!=", storev1.FilterOp_FILTER_OP_NE},
+	{"==", storev1.FilterOp_FILTER_OP_EQ},
+	{"=", storev1.FilterOp_FILTER_OP_EQ},
+	{">", storev1.FilterOp_FILTER_OP_GT},
+	{"<", storev1.FilterOp_FILTER_OP_LT},
+}
+
+// parseWhere parses a --where flag value; the store checks the field and
+// value against the component's type.
+func parseWhere(s string) (*storev1.ComponentFilter, error) {
+	for _, w := range whereOps {
+		key, value, ok := strings.Cut(s, w.token)
+		if !ok {
+			continue
+		}
+		component, field, ok := strings.Cut(strings.TrimSpace(key), ".")
+		if !ok || component == "" || field == "" {
+			break
+		}
+		return &storev1.ComponentFilter{Component: component, Field: field, Op: w.op, Value: strings.Trim(strings.TrimSpace(value), `"`)}, nil
+	}
+	return nil, fmt.Errorf("where %q: want component.field<op>value with op one of = != < <= > >=", s)
+}
+
+// parseBBox parses a --bbox flag value; the store checks the bounds.
+func parseBBox(s string) (*storev1.QueryEntitiesByBBoxRequest, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return nil, fmt.Errorf("bbox %q: want min_lat,min_lon,max_lat,max_lon", s)
+	}
+	var v [4]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("bbox %q: %w", s, err)
+		}
+		v[i] = f
+	}
+	return &storev1.QueryEntitiesByBBoxRequest{MinLat: v[0], MinLon: v[1], MaxLat: v[2], MaxLon: v[3]}, nil
+}
+
+// entityTypeFilter maps a --type flag value to an entity type; anything else
+// matches all types.
+func entityTypeFilter(typeFilter string) entityv1.EntityType {
+	switch typeFilter {
+	case "track":
+		return entityv1.EntityType_ENTITY_TYPE_TRACK
+	case "asset":
+		return entityv1.EntityType_ENTITY_TYPE_ASSET
+	case "geo":
+		return entityv1.EntityType_ENTITY_TYPE_GEO
+	case "system":
+		return entityv1.EntityType_ENTITY_TYPE_SYSTEM
+	}
+	return entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED
+}
+
 func getCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "get <id>",
@@ -109,9 +299,17 @@ func getCmd() *cobra.Command {
 			fmt.Printf("Type:    %s\n", e.Type)
 			fmt.Printf("Created: %s\n", e.CreatedAt.AsTime().Format("2006-01-02 15:04:05"))
 			fmt.Printf("Updated: %s\n", e.UpdatedAt.AsTime().Format("2006-01-02 15:04:05"))
+			if len(e.Labels) > 0 {
+				fmt.Printf("Labels:  %s\n", labels.Format(e.Labels))
+			}
 			fmt.Printf("Components:\n")
-			for name, comp := range e.Components {
+			reg := fetchSchemas()
+			for _, name := range sortedKeys(e.Components) {
+				comp := e.Components[name]
 				fmt.Printf("  %s: %s\n", name, comp.TypeUrl)
+				if text, err := formatComponent(reg, comp); err == nil {
+					fmt.Printf("    %s\n", strings.ReplaceAll(text, "\n", "\n    "))
+				}
 			}
 			return nil
 		},
@@ -119,7 +317,10 @@ func getCmd() *cobra.Command {
 }
 
 func watchCmd() *cobra.Command {
-	return &cobra.Command{
+	var selector, bbox string
+	var has []string
+	var initial bool
+	cmd := &cobra.Command{
 		Use:   "watch",
 		Short: "Watch entity events in real-time",
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -129,9 +330,20 @@ func watchCmd() *cobra.Command {
 			}
 			defer cleanup()
 
-			stream, err := client.WatchEntities(cmd.Context(), &storev1.WatchEntitiesRequest{
-				TypeFilter: entityv1.EntityType_ENTITY_TYPE_TRACK,
-			})
+			req := &storev1.WatchEntitiesRequest{
+				TypeFilter:    entityv1.EntityType_ENTITY_TYPE_TRACK,
+				LabelSelector: selector,
+				Components:    has,
+				InitialState:  initial,
+			}
+			if bbox != "" {
+				b, err := parseBBox(bbox)
+				if err != nil {
+					return err
+				}
+				req.Bbox = &storev1.BoundingBox{MinLat: b.MinLat, MaxLat: b.MaxLat, MinLon: b.MinLon, MaxLon: b.MaxLon}
+			}
+			stream, err := client.WatchEntities(cmd.Context(), req)
 			if err != nil {
 				return err
 			}
@@ -147,10 +359,146 @@ func watchCmd() *cobra.Command {
 			}
 		},
 	}
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "only tracks whose labels match, e.g. exercise=bravo")
+	cmd.Flags().StringSliceVar(&has, "has", nil, "only tracks with these components, e.g. threat")
+	cmd.Flags().StringVar(&bbox, "bbox", "", "only tracks positioned inside min_lat,min_lon,max_lat,max_lon")
+	cmd.Flags().BoolVar(&initial, "initial", false, "start with a CREATED event for every current track")
+	return cmd
 }
 
-func approveCmd() *cobra.Command {
+func labelCmd() *cobra.Command {
 	return &cobra.Command{
+		Use:   "label <entity-id> key=value... key-...",
+		Short: "Set labels on an entity, or remove them with key-, keeping its others",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var terms, remove []string
+			for _, arg := range args[1:] {
+				if key, ok := strings.CutSuffix(arg, "-"); ok && !strings.Contains(arg, "=") {
+					remove = append(remove, key)
+				} else {
+					terms = append(terms, arg)
+				}
+			}
+			set := map[string]string{}
+			if len(terms) > 0 {
+				var err error
+				if set, err = labels.ParseSet(strings.Join(terms, ",")); err != nil {
+					return err
+				}
+			}
+
+			client, cleanup, err := dial()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			e, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: args[0]})
+			if err != nil {
+				return err
+			}
+			merged := make(map[string]string, len(e.Labels)+len(set))
+			maps.Copy(merged, e.Labels)
+			maps.Copy(merged, set)
+			for _, key := range remove {
+				delete(merged, key)
+			}
+			// The read labels' stamp goes along, so removing the last one
+			// clears them rather than leaving them as they are.
+			labelsHLC := e.LabelsHlc
+			if labelsHLC == nil {
+				labelsHLC = &entityv1.HLCTimestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
+			}
+
+			// Conditional on the copy read, so a concurrent relabel is not
+			// silently overwritten.
+			updated, err := client.UpdateEntity(context.Background(), &storev1.UpdateEntityRequest{
+				Entity: &entityv1.Entity{
+					Id: e.Id, Type: e.Type, Labels: merged, LabelsHlc: labelsHLC,
+					HlcPhysical: e.HlcPhysical, HlcLogical: e.HlcLogical, HlcNode: e.HlcNode,
+				},
+				ExpectedHlc: &entityv1.HLCTimestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode},
+			})
+			if err != nil {
+				return fmt.Errorf("label %s: %w", args[0], err)
+			}
+			fmt.Printf("%s: %s\n", updated.Id, labels.Format(updated.Labels))
+			return nil
+		},
+	}
+}
+
+func recordCmd() *cobra.Command {
+	var (
+		typeFilter string
+		selector   string
+		out        string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "record",
+		Short: "Record entity events as NDJSON for sensor-sim replay",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dial()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			w := os.Stdout
+			if out != "" && out != "-" {
+				f, err := os.Create(out)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				w = f
+			}
+
+			// Stop cleanly on Ctrl+C so the file ends on a whole line.
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{
+				TypeFilter:    entityTypeFilter(typeFilter),
+				LabelSelector: selector,
+			})
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(os.Stderr, "Recording entity events (Ctrl+C to stop)...")
+			for n := 0; ; n++ {
+				event, err := stream.Recv()
+				if err != nil {
+					if ctx.Err() != nil {
+						fmt.Fprintf(os.Stderr, "Recorded %d events\n", n)
+						return nil
+					}
+					return err
+				}
+				line, err := sensor.MarshalRecordedEvent(sensor.RecordedEvent{Time: time.Now(), Event: event})
+				if err != nil {
+					return err
+				}
+				if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
+					return err
+				}
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&typeFilter, "type", "t", "track", "record only this type (track, asset, geo, system, all)")
+	cmd.Flags().StringVarP(&selector, "selector", "l", "", "record only entities whose labels match, e.g. exercise=bravo")
+	cmd.Flags().StringVarP(&out, "out", "o", "-", "output file (- for stdout)")
+	return cmd
+}
+
+func approveCmd() *cobra.Command {
+	var operator string
+
+	cmd := &cobra.Command{
 		Use:   "approve <entity-id>",
 		Short: "Approve a pending intercept action",
 		Args:  cobra.ExactArgs(1),
@@ -163,6 +511,7 @@ func approveCmd() *cobra.Command {
 
 			e, err := client.ApproveAction(context.Background(), &storev1.ApproveActionRequest{
 				EntityId: args[0],
+				Operator: operator,
 			})
 			if err != nil {
 				return fmt.Errorf("approve %s: %w", args[0], err)
@@ -172,10 +521,15 @@ func approveCmd() *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&operator, "operator", os.Getenv("USER"), "operator recorded for the decision (default $USER)")
+	return cmd
 }
 
 func denyCmd() *cobra.Command {
-	return &cobra.Command{
+	var operator string
+
+	cmd := &cobra.Command{
 		Use:   "deny <entity-id>",
 		Short: "Deny a pending intercept action",
 		Args:  cobra.ExactArgs(1),
@@ -188,6 +542,7 @@ func denyCmd() *cobra.Command {
 
 			e, err := client.DenyAction(context.Background(), &storev1.DenyActionRequest{
 				EntityId: args[0],
+				Operator: operator,
 			})
 			if err != nil {
 				return fmt.Errorf("deny %s: %w", args[0], err)
@@ -197,6 +552,170 @@ func denyCmd() *cobra.Command {
 			return nil
 		},
 	}
+
+	cmd.Flags().StringVar(&operator, "operator", os.Getenv("USER"), "operator recorded for the decision (default $USER)")
+	return cmd
+}
+
+func statsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stats",
+		Short: "Show task-manager approval and tasking metrics",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dialTaskManager()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			st, err := client.GetStats(context.Background(), &taskv1.GetStatsRequest{})
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Pending:          %d\n", st.Pending)
+			fmt.Printf("Approvals:        %d (mean wait %s)\n", st.Approvals, st.MeanTimeToApproval.AsDuration())
+			fmt.Printf("Auto-approvals:   %d\n", st.AutoApprovals)
+			fmt.Printf("Denials:          %d\n", st.Denials)
+			fmt.Printf("Timeouts:         %d\n", st.Timeouts)
+			fmt.Printf("Escalations:      %d\n", st.Escalations)
+			fmt.Printf("Assets:           %d busy / %d total, %d queued\n", st.AssetsBusy, st.AssetsTotal, st.TasksQueued)
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "\nSTATE\tACTIVE")
+			for _, k := range sortedKeys(st.ActiveByState) {
+				fmt.Fprintf(w, "%s\t%d\n", k, st.ActiveByState[k])
+			}
+			fmt.Fprintln(w, "\nOPERATOR\tAPPROVALS")
+			for _, k := range sortedKeys(st.ApprovalsByOperator) {
+				fmt.Fprintf(w, "%s\t%d\n", k, st.ApprovalsByOperator[k])
+			}
+			w.Flush()
+			return nil
+		},
+	}
+}
+
+func historyCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "history <entity-id>",
+		Short: "Show the task and approval history of an entity",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dialTaskManager()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			resp, err := client.GetTaskHistory(context.Background(), &taskv1.GetTaskHistoryRequest{
+				EntityId: args[0],
+			})
+			if err != nil {
+				return fmt.Errorf("history %s: %w", args[0], err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tSTAGE\tACTOR\tDETAIL")
+			for _, t := range resp.Transitions {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+					t.Time.AsTime().Local().Format("15:04:05.000"), t.Stage, t.Actor, t.Detail)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func linksCmd() *cobra.Command {
+	var relation string
+	cmd := &cobra.Command{
+		Use:   "links <entity-id>",
+		Short: "Show the links from and to an entity",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dial()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			resp, err := client.ListLinks(context.Background(), &storev1.ListLinksRequest{Id: args[0], Relation: relation})
+			if err != nil {
+				return fmt.Errorf("links %s: %w", args[0], err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "FROM\tRELATION\tTO\tCASCADE")
+			for _, l := range resp.Links {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%t\n", l.FromId, l.Relation, l.ToId, l.Cascade)
+			}
+			return w.Flush()
+		},
+	}
+	cmd.Flags().StringVar(&relation, "relation", "", "only links with this relation, e.g. fused_from")
+	return cmd
+}
+
+func versionsCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "versions <entity-id>",
+		Short: "Show the entity-store's recorded versions of an entity",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dial()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			resp, err := client.GetEntityHistory(context.Background(), &storev1.GetEntityHistoryRequest{Id: args[0]})
+			if err != nil {
+				return fmt.Errorf("versions %s: %w", args[0], err)
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "HLC\tTIME\tEVENT\tCHANGED")
+			var prev *entityv1.Entity
+			for _, v := range resp.Versions {
+				e := v.Entity
+				ts := hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ts,
+					time.Unix(0, int64(e.HlcPhysical)).Local().Format("15:04:05.000"),
+					strings.TrimPrefix(v.Type.String(), "EVENT_TYPE_"), changedComponents(prev, e))
+				prev = e
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// changedComponents lists the component keys added, changed, or removed
+// between two versions of an entity.
+func changedComponents(prev, e *entityv1.Entity) string {
+	var changed []string
+	for _, key := range sortedKeys(e.Components) {
+		if old, ok := prev.GetComponents()[key]; !ok || !proto.Equal(old, e.Components[key]) {
+			changed = append(changed, key)
+		}
+	}
+	for _, key := range sortedKeys(prev.GetComponents()) {
+		if _, ok := e.Components[key]; !ok {
+			changed = append(changed, "-"+key)
+		}
+	}
+	if len(changed) == 0 {
+		return "-"
+	}
+	return strings.Join(changed, ",")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func componentNames(e *entityv1.Entity) string {
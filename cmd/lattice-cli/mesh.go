@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	meshv1 "github.com/boshu2/lattice-lab/gen/mesh/v1"
+	"github.com/spf13/cobra"
+)
+
+func meshCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mesh",
+		Short: "Inspect a running mesh relay (--relay localhost:50053)",
+	}
+	cmd.AddCommand(meshStatusCmd())
+	return cmd
+}
+
+func meshStatusCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "status",
+		Short: "Show the relay's counters, and each peer's connection, queue, traffic, bandwidth budget, divergence, and clock offset",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dialRelay()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			st, err := client.GetStatus(context.Background(), &meshv1.GetStatusRequest{})
+			if err != nil {
+				return err
+			}
+
+			if st.NodeId != "" {
+				fmt.Printf("Node:       %s\n", st.NodeId)
+			}
+			fmt.Printf("Forwarded:  %d (%d merged)\n", st.Stats.GetForwarded(), st.Stats.GetMerged())
+			fmt.Printf("Dropped:    %d\n", st.Stats.GetDropped())
+			fmt.Printf("Filtered:   %d\n", st.Stats.GetFiltered())
+			fmt.Printf("Evicted:    %d\n", st.Stats.GetEvicted())
+			fmt.Printf("Repaired:   %d\n", st.Stats.GetRepaired())
+			fmt.Printf("Diverged:   %d (conflicts resolved: %d lww, %d max-wins, %d other)\n",
+				st.Stats.GetDiverged(), st.Stats.GetResolvedLww(), st.Stats.GetResolvedMaxWins(), st.Stats.GetResolvedOther())
+			fmt.Printf("Errors:     %d\n", st.Stats.GetErrors())
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "\nPEER\tLINK\tSTATE\tQUEUED\tFORWARDED\tDROPPED\tSENT\tBUDGET\tDIVERGED\tCLOCK")
+			for _, p := range st.Peers {
+				link := p.Link
+				if link == "" {
+					link = "-"
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n",
+					p.Addr, link, p.State, p.Queued, p.Forwarded, p.Dropped, formatBytes(float64(p.SentBytes)), formatBudget(p), formatDiverged(p), formatClock(p))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+// formatBudget renders a peer's bandwidth budget and what is left of it.
+func formatBudget(p *meshv1.Peer) string {
+	if p.BudgetBps == 0 {
+		return "unlimited"
+	}
+	s := fmt.Sprintf("%s/s, %s free", formatBytes(p.BudgetBps), formatBytes(p.BudgetAvailable))
+	if p.SharedBudget {
+		s += " (shared)"
+	}
+	return s
+}
+
+// formatDiverged renders what the last anti-entropy pass with a peer
+// found, or "-" before the first.
+func formatDiverged(p *meshv1.Peer) string {
+	if p.ReconciledAt == nil {
+		return "-"
+	}
+	s := fmt.Sprintf("%d (%d lww, %d max-wins", p.Diverged, p.ResolvedLww, p.ResolvedMaxWins)
+	if p.ResolvedOther > 0 {
+		s += fmt.Sprintf(", %d other", p.ResolvedOther)
+	}
+	return s + ")"
+}
+
+// formatClock renders how far a peer's clock is ahead of the local
+// store's, marked if skewed, or "-" before the first heartbeat measures it.
+func formatClock(p *meshv1.Peer) string {
+	if p.ClockOffset == nil {
+		return "-"
+	}
+	s := fmt.Sprintf("%+v", p.ClockOffset.AsDuration().Round(time.Millisecond))
+	if p.ClockSkewed {
+		s += " (skewed)"
+	}
+	return s
+}
+
+// formatBytes renders n bytes in B, KB, MB, or GB.
+func formatBytes(n float64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%.0fB", n)
+	}
+	for _, suffix := range []string{"KB", "MB"} {
+		n /= unit
+		if n < unit {
+			return fmt.Sprintf("%.1f%s", n, suffix)
+		}
+	}
+	return fmt.Sprintf("%.1fGB", n/unit)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	meshv1 "github.com/boshu2/lattice-lab/gen/mesh/v1"
+	"github.com/spf13/cobra"
+)
+
+// The relay admin service is served by lattice-lab alongside its relay.
+func dialRelay() (meshv1.RelayAdminServiceClient, func(), error) {
+	conn, err := connect(relayAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := meshv1.NewRelayAdminServiceClient(conn)
+	return client, func() { conn.Close() }, nil
+}
+
+func peersCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "peers",
+		Short: "List or change a running mesh relay's peers (--relay localhost:50053)",
+	}
+	cmd.AddCommand(peersListCmd(), peersAddCmd(), peersRemoveCmd())
+	return cmd
+}
+
+func peersListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the relay's peers, the state of its connection to each, and their queued events",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dialRelay()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			resp, err := client.ListPeers(context.Background(), &meshv1.ListPeersRequest{})
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PEER\tSTATE\tQUEUED")
+			for _, p := range resp.Peers {
+				fmt.Fprintf(w, "%s\t%s\t%d\n", p.Addr, p.State, p.Queued)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func peersAddCmd() *cobra.Command {
+	var sync bool
+
+	cmd := &cobra.Command{
+		Use:   "add <addr>",
+		Short: "Start relaying to a peer store",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dialRelay()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			if _, err := client.AddPeer(context.Background(), &meshv1.AddPeerRequest{Addr: args[0], Sync: sync}); err != nil {
+				return err
+			}
+			fmt.Printf("added peer %s\n", args[0])
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&sync, "sync", false, "first send the peer a snapshot of the relay's store")
+	return cmd
+}
+
+func peersRemoveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <addr>",
+		Short: "Stop relaying to a peer store",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dialRelay()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			if _, err := client.RemovePeer(context.Background(), &meshv1.RemovePeerRequest{Addr: args[0]}); err != nil {
+				return err
+			}
+			fmt.Printf("removed peer %s\n", args[0])
+			return nil
+		},
+	}
+}
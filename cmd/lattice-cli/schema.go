@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	registryv1 "github.com/boshu2/lattice-lab/gen/registry/v1"
+	"github.com/boshu2/lattice-lab/internal/registry"
+	"github.com/spf13/cobra"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// The schema registry is served by the entity-store.
+func dialRegistry() (registryv1.SchemaRegistryServiceClient, func(), error) {
+	conn, err := storeConn()
+	if err != nil {
+		return nil, nil, err
+	}
+	client := registryv1.NewSchemaRegistryServiceClient(conn)
+	return client, func() { conn.Close() }, nil
+}
+
+// typeURL accepts a full type URL or a bare message name.
+func typeURL(s string) string {
+	if strings.Contains(s, "/") {
+		return s
+	}
+	return "type.googleapis.com/" + s
+}
+
+// fetchSchemas loads the store's registered schemas so third-party
+// components can be decoded. Failures leave only the compiled-in types.
+func fetchSchemas() *registry.Registry {
+	reg := registry.New()
+	client, cleanup, err := dialRegistry()
+	if err != nil {
+		return reg
+	}
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := client.ListSchemas(ctx, &registryv1.ListSchemasRequest{})
+	if err != nil {
+		return reg
+	}
+	for _, s := range resp.Schemas {
+		reg.Register(s) //nolint:errcheck
+	}
+	return reg
+}
+
+// formatComponent renders a component as indented JSON.
+func formatComponent(reg *registry.Registry, c *anypb.Any) (string, error) {
+	m, err := reg.Decode(c)
+	if err != nil {
+		return "", err
+	}
+	b, err := protojson.MarshalOptions{Multiline: true, Indent: "  ", Resolver: reg}.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func schemaCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Manage component schemas in the store's registry",
+	}
+	cmd.AddCommand(schemaListCmd(), schemaGetCmd(), schemaRegisterCmd(), schemaDeleteCmd())
+	return cmd
+}
+
+func schemaListCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List registered schemas",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dialRegistry()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			resp, err := client.ListSchemas(context.Background(), &registryv1.ListSchemasRequest{})
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TYPE URL\tFILES\tRULES")
+			for _, s := range resp.Schemas {
+				fmt.Fprintf(w, "%s\t%d\t%d\n", s.TypeUrl, len(s.GetDescriptors().GetFile()), len(s.Rules))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func schemaGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <type-url>",
+		Short: "Show a schema's fields and rules",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dialRegistry()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			s, err := client.GetSchema(context.Background(), &registryv1.GetSchemaRequest{TypeUrl: typeURL(args[0])})
+			if err != nil {
+				return err
+			}
+			reg := registry.New()
+			if err := reg.Register(s); err != nil {
+				return err
+			}
+			mt, err := reg.FindMessageByURL(s.TypeUrl)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Type URL: %s\n", s.TypeUrl)
+			fmt.Printf("Message:  %s\n", mt.Descriptor().FullName())
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "\nFIELD\tTYPE\tRULE")
+			fields := mt.Descriptor().Fields()
+			for i := 0; i < fields.Len(); i++ {
+				fd := fields.Get(i)
+				fmt.Fprintf(w, "%s\t%s\t%s\n", fd.Name(), fieldType(fd), describeRules(s.Rules, string(fd.Name())))
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func fieldType(fd protoreflect.FieldDescriptor) string {
+	t := fd.Kind().String()
+	switch {
+	case fd.IsMap():
+		return "map"
+	case fd.Message() != nil:
+		t = string(fd.Message().FullName())
+	case fd.Enum() != nil:
+		t = string(fd.Enum().FullName())
+	}
+	if fd.IsList() {
+		return "repeated " + t
+	}
+	return t
+}
+
+func describeRules(rules []*registryv1.FieldRule, field string) string {
+	var parts []string
+	for _, r := range rules {
+		if r.Field != field {
+			continue
+		}
+		if r.Required {
+			parts = append(parts, "required")
+		}
+		if r.Min != nil {
+			parts = append(parts, fmt.Sprintf(">= %v", *r.Min))
+		}
+		if r.Max != nil {
+			parts = append(parts, fmt.Sprintf("<= %v", *r.Max))
+		}
+		if r.Pattern != "" {
+			parts = append(parts, "~ "+r.Pattern)
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func schemaRegisterCmd() *cobra.Command {
+	var (
+		descriptors string
+		required    []string
+		ranges      []string
+		patterns    []string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "register <type-url>",
+		Short: "Register or replace a component schema",
+		Long: `Register a component type with the store's schema registry. Third-party
+types need --descriptors: a FileDescriptorSet including imports, from
+"protoc --include_imports --descriptor_set_out=FILE" or "buf build -o FILE".
+Types compiled into the store, such as entity.v1.PositionComponent, need only rules.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			rules, err := registry.ParseRules(required, ranges, patterns)
+			if err != nil {
+				return err
+			}
+			s := &registryv1.Schema{TypeUrl: typeURL(args[0]), Rules: rules}
+			if descriptors != "" {
+				b, err := os.ReadFile(descriptors)
+				if err != nil {
+					return err
+				}
+				s.Descriptors = &descriptorpb.FileDescriptorSet{}
+				if err := proto.Unmarshal(b, s.Descriptors); err != nil {
+					return fmt.Errorf("%s: not a FileDescriptorSet: %w", descriptors, err)
+				}
+			}
+
+			client, cleanup, err := dialRegistry()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			if _, err := client.RegisterSchema(context.Background(), &registryv1.RegisterSchemaRequest{Schema: s}); err != nil {
+				return fmt.Errorf("register %s: %w", s.TypeUrl, err)
+			}
+			fmt.Printf("Registered: %s (%d rules)\n", s.TypeUrl, len(rules))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&descriptors, "descriptors", "d", "", "FileDescriptorSet file for third-party types")
+	cmd.Flags().StringSliceVar(&required, "require", nil, "fields that must be set")
+	cmd.Flags().StringArrayVar(&ranges, "range", nil, "numeric bounds as field=min:max (repeatable)")
+	cmd.Flags().StringArrayVar(&patterns, "pattern", nil, "string field regexp as field=regexp (repeatable)")
+	return cmd
+}
+
+func schemaDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <type-url>",
+		Short: "Remove a component schema",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			client, cleanup, err := dialRegistry()
+			if err != nil {
+				return err
+			}
+			defer cleanup()
+
+			url := typeURL(args[0])
+			if _, err := client.DeleteSchema(context.Background(), &registryv1.DeleteSchemaRequest{TypeUrl: url}); err != nil {
+				return fmt.Errorf("delete %s: %w", url, err)
+			}
+			fmt.Printf("Deleted: %s\n", url)
+			return nil
+		},
+	}
+}
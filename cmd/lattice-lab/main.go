@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/crdt"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/lab"
+	"github.com/boshu2/lattice-lab/internal/mesh"
+	"github.com/boshu2/lattice-lab/internal/store"
+)
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "up" {
+		fmt.Fprintln(os.Stderr, "usage: lattice-lab up [flags]")
+		os.Exit(2)
+	}
+
+	cfg := lab.DefaultConfig()
+	var healthAddr string
+
+	fs := config.NewSet("lattice-lab up")
+	fs.String(&cfg.Listen, "listen", "LAB_LISTEN", "entity-store listen address")
+	fs.String(&cfg.TaskListen, "task-listen", "LAB_TASK_LISTEN", "task-manager service listen address (empty disables)")
+	fs.String(&cfg.RelayListen, "relay-listen", "LAB_RELAY_LISTEN", "relay admin service listen address, served while the relay runs (empty disables)")
+	fs.String(&cfg.HTTPListen, "http-listen", "LAB_HTTP_LISTEN", "GeoJSON/KML export listen address (empty disables)")
+	fs.Func("components", "LAB_COMPONENTS", "comma-separated components to run (default all)", func(v string) error {
+		c, err := lab.ParseComponents(v)
+		cfg.Components = c
+		return err
+	})
+	fs.Int(&cfg.History, "history-depth", "HISTORY_DEPTH", "versions kept per entity for GetEntityHistory (0 disables)")
+	fs.Duration(&cfg.TombstoneTTL, "tombstone-ttl", "TOMBSTONE_TTL", "how long deleted entities are remembered against stale copies (0 keeps them)")
+	fs.Duration(&cfg.ComponentTombstoneTTL, "component-tombstone-ttl", "COMPONENT_TOMBSTONE_TTL", "how long removed components are remembered against stale copies (0 keeps them)")
+	fs.Duration(&cfg.ArchiveRetention, "archive-retention", "ARCHIVE_RETENTION", "how long deleted and expired entities stay listable (0 disables)")
+	fs.Duration(&cfg.ReaperInterval, "reaper-interval", "REAPER_INTERVAL", "how often entities past their ttl are removed")
+	fs.String(&cfg.ClockState, "hlc-state", "HLC_STATE", "file the store's clock is saved to and resumed from, so a restart with the wall clock set back cannot stamp writes before earlier ones (empty disables)")
+	fs.Duration(&cfg.MaxDrift, "hlc-max-drift", "HLC_MAX_DRIFT", "fail writes the clock would stamp further than this ahead of the wall clock (0 is unbounded)")
+	fs.Func("hlc-max-logical", "HLC_MAX_LOGICAL", "fail writes the clock would stamp past this logical counter (0 is unbounded)", func(v string) error {
+		n, err := strconv.ParseUint(v, 10, 32)
+		cfg.MaxLogical = uint32(n)
+		return err
+	})
+	fs.Func("indexes", "INDEXES", "comma-separated component.field names to index for ListEntities filters", func(v string) error {
+		keys, err := store.ParseIndexes(v)
+		cfg.Indexes = keys
+		return err
+	})
+	fs.Func("quotas", "QUOTAS", "comma-separated type=limit entity caps, e.g. track=5000,geo=200", func(v string) error {
+		quotas, err := store.ParseQuotas(v)
+		cfg.Quotas = quotas
+		return err
+	})
+	fs.Int(&cfg.Sensor.NumTracks, "num-tracks", "NUM_TRACKS", "sensor-sim tracks")
+	fs.Int(&cfg.Radar.NumTracks, "radar-tracks", "RADAR_TRACKS", "radar-sim tracks")
+	fs.Int(&cfg.Effector.NumAssets, "num-assets", "NUM_ASSETS", "effector-sim interceptor assets")
+	fs.Uint64(&cfg.Sensor.Seed, "seed", "SEED", "random seed for the simulators (default random)")
+	fs.Bool(&cfg.Task.ManualMode, "manual-mode", "MANUAL_MODE", "start with auto-approval disabled")
+	fs.Bool(&cfg.Task.DryRun, "dry-run", "DRY_RUN", "log intercepts and assignments instead of writing them")
+	fs.Func("peers", "MESH_PEERS", "comma-separated peer stores to relay to (enables the relay)", func(v string) error {
+		cfg.Relay.Peers = strings.Split(v, ",")
+		return nil
+	})
+	fs.Func("mesh-discovery", "MESH_DISCOVERY", "find peers to relay to: dns:<SRV name>, mdns:<service>, or file:<path> (enables the relay)", func(v string) error {
+		d, err := mesh.ParseDiscovery(v)
+		cfg.Relay.Discovery = d
+		return err
+	})
+	fs.Duration(&cfg.Relay.DiscoveryInterval, "mesh-discovery-interval", "MESH_DISCOVERY_INTERVAL", "how often the relay looks for peers")
+	fs.String(&cfg.Advertise, "mesh-advertise", "MESH_ADVERTISE", "advertise the store over mDNS under this service, e.g. _lattice._tcp (empty disables)")
+	fs.String(&cfg.Relay.NodeID, "node-id", "NODE_ID", "relay node ID for echo suppression")
+	fs.Bool(&cfg.Relay.InitialSync, "mesh-initial-sync", "MESH_INITIAL_SYNC", "restore a snapshot of the store to each peer when the relay starts")
+	fs.Duration(&cfg.Relay.AntiEntropy, "mesh-anti-entropy", "MESH_ANTI_ENTROPY", "how often the relay reconciles the store with each peer (0 disables)")
+	fs.Int(&cfg.Relay.GossipFanout, "mesh-gossip-fanout", "MESH_GOSSIP_FANOUT", "send each event to this many random peers, which pass it on, instead of to all (0 sends to all)")
+	fs.Int(&cfg.Relay.GossipHops, "mesh-gossip-hops", "MESH_GOSSIP_HOPS", "relays a gossiped event travels at most (0 = 6)")
+	fs.Int(&cfg.Relay.OutboxSize, "mesh-outbox-size", "MESH_OUTBOX_SIZE", "entities whose events are queued for an unreachable peer (0 disables the outbox)")
+	fs.String(&cfg.Relay.Checkpoint, "mesh-checkpoint", "MESH_CHECKPOINT", "file the relay saves each peer's progress in, so a restart resumes where it left off (empty disables)")
+	fs.Duration(&cfg.Relay.FlushInterval, "mesh-flush-interval", "MESH_FLUSH_INTERVAL", "batch each peer's events, the latest per entity, and send them this often (0 sends each at once)")
+	fs.Duration(&cfg.Relay.Heartbeat, "mesh-heartbeat", "MESH_HEARTBEAT", "how often the relay pings each peer to detect partitions (0 disables)")
+	fs.Int(&cfg.Relay.HeartbeatMisses, "mesh-heartbeat-misses", "MESH_HEARTBEAT_MISSES", "heartbeats a peer misses in a row before it counts as partitioned (0 = 3)")
+	fs.Duration(&cfg.Relay.MaxSkew, "mesh-max-skew", "MESH_MAX_SKEW", "how far a peer's wall clock may be from the local store's before it is reported skewed (0 disables)")
+	fs.Func("merge-strategies", "MERGE_STRATEGIES", "comma-separated key=strategy merge strategies for components, e.g. speed=min-wins (default threat=max-wins,fusion=set-union,detections_count=pn-counter, the rest lww)", func(v string) error {
+		r, err := crdt.ParseRegistry(v)
+		cfg.Strategies = r
+		return err
+	})
+	fs.Func("mesh-policy", "MESH_POLICY", "replicate only what matches, e.g. track>=medium;asset;-task_catalog (default everything)", func(v string) error {
+		policy, err := mesh.ParsePolicy(v)
+		cfg.Relay.Policy = policy
+		return err
+	})
+	fs.Func("mesh-links", "MESH_LINKS", "per-peer links, addr=class[:bandwidth_bps[:burst_bytes]];... with class lan, wan, or satcom", func(v string) error {
+		links, err := mesh.ParseLinks(v)
+		cfg.Relay.Links = links
+		return err
+	})
+	fs.Func("mesh-impair", "MESH_IMPAIR", "emulate degraded links to peers, addr=latency:600ms,jitter:200ms,loss:0.05,bandwidth:2400;... (testing and demos)", func(v string) error {
+		imps, err := mesh.ParseImpairments(v)
+		cfg.Relay.Impairments = imps
+		return err
+	})
+	fs.Func("mesh-compression", "MESH_COMPRESSION", "compress relay messages to peers: gzip or snappy (default none)", func(v string) error {
+		cfg.Relay.Compression = v
+		return client.CheckCompression(v)
+	})
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+
+	if err := fs.Parse(os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if cfg.Sensor.Seed != 0 {
+		cfg.Radar.Seed = cfg.Sensor.Seed + 1
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	// The watching components share one probe, each under its own name.
+	probe := health.New()
+	cfg.Classifier.Health, cfg.Task.Health, cfg.Relay.Health = probe, probe, probe
+	if err := health.Serve(ctx, healthAddr, probe); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+
+	if err := lab.New(cfg).Run(ctx); err != nil {
+		slog.Error("lattice-lab failed", "error", err)
+		os.Exit(1)
+	}
+}
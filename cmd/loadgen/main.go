@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/loadgen"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+)
+
+func main() {
+	cfg := loadgen.DefaultConfig()
+	var healthAddr, metricsAddr string
+
+	fs := config.NewSet("loadgen")
+	fs.String(&cfg.StoreAddr, "store", "STORE_ADDR", "entity-store address")
+	fs.Int(&cfg.Tracks, "num-tracks", "NUM_TRACKS", "number of synthetic tracks")
+	fs.Float(&cfg.Rate, "rate", "RATE", "aggregate update rate, updates/s")
+	fs.Func("ramp", "RAMP", "rate profile, duration:rate,... (overrides rate)", func(v string) error {
+		steps, err := loadgen.ParseRamp(v)
+		cfg.Ramp = steps
+		return err
+	})
+	fs.Duration(&cfg.Duration, "duration", "DURATION", "stop after this long; 0 runs until interrupted")
+	fs.Int(&cfg.Workers, "workers", "WORKERS", "concurrent RPCs")
+	fs.Duration(&cfg.ReportEvery, "report-every", "REPORT_EVERY", "interval between latency reports")
+	fs.String(&cfg.IDPrefix, "id-prefix", "ID_PREFIX", "entity ID prefix")
+	fs.Duration(&cfg.TTL, "ttl", "TTL", "store expiry for tracks, refreshed each write; 0 disables")
+	fs.String(&cfg.AuthToken, "auth-token", "AUTH_TOKEN", "bearer token for an entity-store with AUTH_TOKENS set")
+	fs.Uint64(&cfg.Seed, "seed", "SEED", "random seed for reproducible runs (default random)")
+	fs.Float(&cfg.BBox.MinLat, "bbox-min-lat", "BBOX_MIN_LAT", "bounding box south edge")
+	fs.Float(&cfg.BBox.MaxLat, "bbox-max-lat", "BBOX_MAX_LAT", "bounding box north edge")
+	fs.Float(&cfg.BBox.MinLon, "bbox-min-lon", "BBOX_MIN_LON", "bounding box west edge")
+	fs.Float(&cfg.BBox.MaxLon, "bbox-max-lon", "BBOX_MAX_LON", "bounding box east edge")
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+	fs.String(&metricsAddr, "metrics-listen", "METRICS_LISTEN", "HTTP address for Prometheus /metrics (empty disables)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	if err := health.Serve(ctx, healthAddr, health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, metricsAddr); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
+	if _, err := loadgen.New(cfg).Run(ctx); err != nil {
+		slog.Error("loadgen failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"github.com/boshu2/lattice-lab/internal/mqttbridge"
+)
+
+func main() {
+	cfg := mqttbridge.DefaultConfig()
+	var healthAddr, metricsAddr string
+
+	fs := config.NewSet("mqtt-bridge")
+	fs.String(&cfg.StoreAddr, "store", "STORE_ADDR", "entity-store address")
+	fs.String(&cfg.BrokerURL, "broker", "MQTT_BROKER", "MQTT broker, tcp://host:port")
+	fs.String(&cfg.ClientID, "client-id", "MQTT_CLIENT_ID", "MQTT client ID")
+	fs.String(&cfg.TopicPrefix, "topic-prefix", "MQTT_TOPIC_PREFIX", "publish to <prefix>/entities/<id>, read <prefix>/reports/<device>")
+	fs.String(&cfg.IDPrefix, "id-prefix", "ID_PREFIX", "prefix for entities created from device reports")
+	fs.Duration(&cfg.ReportTTL, "report-ttl", "REPORT_TTL", "expiry of device entities without a fresh report (0 = never)")
+	fs.Float(&cfg.BandwidthBPS, "bandwidth", "BANDWIDTH_BPS", "outbound byte budget per second (0 = unlimited)")
+	fs.Float(&cfg.BurstBytes, "burst", "BURST_BYTES", "outbound burst in bytes (default the bandwidth)")
+	fs.Duration(&cfg.Flush, "flush", "FLUSH_INTERVAL", "how often updates held back by the budget are retried")
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+	fs.String(&metricsAddr, "metrics-listen", "METRICS_LISTEN", "HTTP address for Prometheus /metrics (empty disables)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	if err := health.Serve(ctx, healthAddr, health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, metricsAddr); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
+	if err := mqttbridge.New(cfg).Run(ctx); err != nil {
+		slog.Error("mqtt-bridge failed", "error", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"github.com/boshu2/lattice-lab/internal/notify"
+)
+
+func main() {
+	cfg := notify.DefaultConfig()
+	var (
+		webhook, slack string
+		email          notify.Email
+	)
+	var healthAddr, metricsAddr string
+
+	fs := config.NewSet("notifier")
+	fs.String(&cfg.StoreAddr, "store", "STORE_ADDR", "entity-store address")
+	fs.Func("on", "NOTIFY_ON", "comma-separated kinds: threat_high, approval_pending, peer_partition, fused_created (default all)", func(v string) error {
+		kinds, err := notify.ParseKinds(v)
+		cfg.Kinds = kinds
+		return err
+	})
+	fs.String(&webhook, "webhook", "NOTIFY_WEBHOOK", "URL POSTed with each notification as JSON")
+	fs.String(&slack, "slack", "SLACK_WEBHOOK", "Slack incoming webhook URL")
+	fs.String(&email.Addr, "smtp", "SMTP_ADDR", "SMTP relay host:port for email")
+	fs.String(&email.From, "mail-from", "MAIL_FROM", "email sender address")
+	fs.Func("mail-to", "MAIL_TO", "comma-separated email recipients", func(v string) error {
+		email.To = strings.Split(v, ",")
+		return nil
+	})
+	fs.String(&email.Username, "smtp-user", "SMTP_USER", "SMTP username (default no auth)")
+	fs.String(&email.Password, "smtp-password", "SMTP_PASSWORD", "SMTP password")
+	fs.Func("peers", "MESH_PEERS", "comma-separated mesh peer stores to probe for partitions", func(v string) error {
+		cfg.Peers = strings.Split(v, ",")
+		return nil
+	})
+	fs.Duration(&cfg.ProbeInterval, "probe-interval", "PROBE_INTERVAL", "how often mesh peers are probed")
+	fs.Int(&cfg.PeerFailures, "peer-failures", "PEER_FAILURES", "failed probes in a row before a peer counts as partitioned")
+	fs.Duration(&cfg.Dedup, "dedup", "NOTIFY_DEDUP", "window in which repeats of a notification are dropped")
+	fs.Float(&cfg.RatePerMin, "rate", "NOTIFY_RATE", "sustained notifications per minute")
+	fs.Int(&cfg.Burst, "burst", "NOTIFY_BURST", "notifications that may be sent back to back")
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+	fs.String(&metricsAddr, "metrics-listen", "METRICS_LISTEN", "HTTP address for Prometheus /metrics (empty disables)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if webhook != "" {
+		cfg.Sinks = append(cfg.Sinks, notify.Webhook{URL: webhook})
+	}
+	if slack != "" {
+		cfg.Sinks = append(cfg.Sinks, notify.Slack{URL: slack})
+	}
+	if email.Addr != "" {
+		if email.From == "" || len(email.To) == 0 {
+			slog.Error("invalid configuration", "error", "email needs MAIL_FROM and MAIL_TO")
+			os.Exit(1)
+		}
+		cfg.Sinks = append(cfg.Sinks, email)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	if err := health.Serve(ctx, healthAddr, health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, metricsAddr); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
+	if err := notify.New(cfg).Run(ctx); err != nil {
+		slog.Error("notifier failed", "error", err)
+		os.Exit(1)
+	}
+}
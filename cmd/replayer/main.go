@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"github.com/boshu2/lattice-lab/internal/sensor"
+)
+
+func main() {
+	cfg := sensor.DefaultConfig()
+	replay := &sensor.Replay{Timing: sensor.TimingOriginal}
+	var path, idMap, components string
+	var healthAddr, metricsAddr string
+
+	fs := config.NewSet("replayer")
+	fs.String(&cfg.StoreAddr, "store", "STORE_ADDR", "target entity-store address")
+	fs.String(&path, "file", "REPLAY", "NDJSON recording from lattice-cli record")
+	fs.Func("timing", "REPLAY_TIMING", "original, scaled (by speed), or stepped (fixed step apart) (default original)", func(v string) error {
+		t, err := sensor.ParseTiming(v)
+		replay.Timing = t
+		return err
+	})
+	fs.Float(&replay.Speed, "speed", "REPLAY_SPEED", "time scale for scaled timing")
+	fs.Duration(&replay.Step, "step", "REPLAY_STEP", "gap between events for stepped timing; 0 plays them back to back")
+	fs.String(&replay.IDPrefix, "id-prefix", "REPLAY_ID_PREFIX", "prefix for replayed entity IDs")
+	fs.String(&idMap, "id-map", "REPLAY_ID_MAP", "rename replayed IDs, old=new,...")
+	fs.String(&components, "components", "REPLAY_COMPONENTS", "replay only these components, comma-separated")
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+	fs.String(&metricsAddr, "metrics-listen", "METRICS_LISTEN", "HTTP address for Prometheus /metrics (empty disables)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	if path == "" {
+		slog.Error("invalid configuration", "error", "a recording is required (REPLAY)")
+		os.Exit(1)
+	}
+	events, err := sensor.LoadRecording(path)
+	if err == nil && len(events) == 0 {
+		err = errors.New("recording has no events")
+	}
+	if err != nil {
+		slog.Error("invalid replay", "path", path, "error", err)
+		os.Exit(1)
+	}
+	replay.Events = events
+	if replay.IDMap, err = sensor.ParseIDMap(idMap); err != nil {
+		slog.Error("invalid id-map", "value", idMap, "error", err)
+		os.Exit(1)
+	}
+	if components != "" {
+		replay.Components = strings.Split(components, ",")
+	}
+	cfg.Replay = replay
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		slog.Info("shutting down")
+		cancel()
+	}()
+
+	if err := health.Serve(ctx, healthAddr, health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, metricsAddr); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
+	if err := sensor.New(cfg).Run(ctx); err != nil {
+		slog.Error("replayer failed", "error", err)
+		os.Exit(1)
+	}
+}
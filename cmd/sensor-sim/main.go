@@ -2,49 +2,126 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"log/slog"
 	"os"
 	"os/signal"
-	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/boshu2/lattice-lab/internal/config"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/labels"
+	"github.com/boshu2/lattice-lab/internal/metrics"
 	"github.com/boshu2/lattice-lab/internal/sensor"
 )
 
 func main() {
 	cfg := sensor.DefaultConfig()
+	var (
+		scenario, replay          string
+		replaySpeed               = 1.0
+		replayTiming              sensor.ReplayTiming
+		replayStep                time.Duration
+		replayPrefix, replayIDMap string
+		replayComponents          string
+	)
+	var healthAddr, metricsAddr string
 
-	if v := os.Getenv("STORE_ADDR"); v != "" {
-		cfg.StoreAddr = v
+	fs := config.NewSet("sensor-sim")
+	fs.String(&cfg.StoreAddr, "store", "STORE_ADDR", "entity-store address")
+	fs.String(&cfg.AuthToken, "auth-token", "AUTH_TOKEN", "bearer token for an entity-store with AUTH_TOKENS set")
+	fs.Bool(&cfg.Publish, "publish-stream", "PUBLISH_STREAM", "send reports over one PublishEntities stream instead of a batch per tick")
+	fs.Duration(&cfg.Interval, "interval", "INTERVAL", "update interval")
+	fs.Int(&cfg.NumTracks, "num-tracks", "NUM_TRACKS", "number of random tracks")
+	fs.Duration(&cfg.TTL, "ttl", "TTL", "store expiry for tracks, refreshed each report; 0 disables")
+	fs.Func("labels", "LABELS", "labels for every track, key=value,...", func(v string) error {
+		set, err := labels.ParseSet(v)
+		cfg.Labels = set
+		return err
+	})
+	fs.Uint64(&cfg.Seed, "seed", "SEED", "random seed for reproducible runs (default random)")
+	fs.Float(&cfg.BBox.MinLat, "bbox-min-lat", "BBOX_MIN_LAT", "bounding box south edge")
+	fs.Float(&cfg.BBox.MaxLat, "bbox-max-lat", "BBOX_MAX_LAT", "bounding box north edge")
+	fs.Float(&cfg.BBox.MinLon, "bbox-min-lon", "BBOX_MIN_LON", "bounding box west edge")
+	fs.Float(&cfg.BBox.MaxLon, "bbox-max-lon", "BBOX_MAX_LON", "bounding box east edge")
+	fs.Func("maneuver", "MANEUVER", "random-track behavior: straight, bounce, or orbit", func(v string) error {
+		b, err := sensor.ParseBehavior(v)
+		cfg.Maneuver.Behavior = b
+		return err
+	})
+	fs.Float(&cfg.Maneuver.TurnRate, "turn-rate", "TURN_RATE", "max turn rate, deg/s")
+	fs.Float(&cfg.Maneuver.JinkProb, "jink-prob", "JINK_PROB", "chance per second of a random heading change")
+	fs.Float(&cfg.Maneuver.OrbitRadiusM, "orbit-radius-m", "ORBIT_RADIUS_M", "orbit radius for the orbit behavior, meters")
+	fs.String(&cfg.Sensor.ID, "sensor-id", "SENSOR_ID", "reporting sensor ID")
+	fs.String(&cfg.Sensor.Type, "sensor-type", "SENSOR_TYPE", "reporting sensor type")
+	fs.Func("coverage", "COVERAGE", "field of view, lat,lon,range_m[,azimuth,beamwidth]", func(v string) error {
+		c, err := sensor.ParseCoverage(v)
+		cfg.Sensor.Coverage = &c
+		return err
+	})
+	fs.Float(&cfg.Sensor.DetectionProb, "detection-prob", "DETECTION_PROB", "chance per update of reporting a covered track")
+	fs.Float(&cfg.Sensor.NoiseM, "noise-m", "NOISE_M", "1-sigma position noise, meters")
+	fs.Func("sensors", "SENSORS", "emulate several sensors, id:type[:pd[:noise_m[:coverage]]];...", func(v string) error {
+		specs, err := sensor.ParseSensors(v)
+		cfg.Sensors = specs
+		return err
+	})
+	fs.String(&scenario, "scenario", "SCENARIO", "YAML scenario of scripted tracks")
+	fs.String(&replay, "replay", "REPLAY", "NDJSON recording to re-publish instead of simulating")
+	fs.Func("replay-timing", "REPLAY_TIMING", "original, scaled (by replay-speed), or stepped (replay-step apart) (default scaled)", func(v string) error {
+		t, err := sensor.ParseTiming(v)
+		replayTiming = t
+		return err
+	})
+	fs.Float(&replaySpeed, "replay-speed", "REPLAY_SPEED", "replay time scale")
+	fs.Duration(&replayStep, "replay-step", "REPLAY_STEP", "gap between events for stepped timing; 0 plays them back to back")
+	fs.String(&replayPrefix, "replay-id-prefix", "REPLAY_ID_PREFIX", "prefix for replayed entity IDs")
+	fs.String(&replayIDMap, "replay-id-map", "REPLAY_ID_MAP", "rename replayed IDs, old=new,...")
+	fs.String(&replayComponents, "replay-components", "REPLAY_COMPONENTS", "replay only these components, comma-separated")
+	fs.String(&healthAddr, "health-listen", "HEALTH_LISTEN", "HTTP address for /healthz and /readyz (empty disables)")
+	fs.String(&metricsAddr, "metrics-listen", "METRICS_LISTEN", "HTTP address for Prometheus /metrics (empty disables)")
+
+	if err := fs.Parse(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
 	}
-	if v := os.Getenv("INTERVAL"); v != "" {
-		d, err := time.ParseDuration(v)
+
+	if scenario != "" {
+		sc, err := sensor.LoadScenario(scenario)
 		if err != nil {
-			slog.Error("invalid INTERVAL", "value", v, "error", err)
+			slog.Error("invalid scenario", "path", scenario, "error", err)
 			os.Exit(1)
 		}
-		cfg.Interval = d
+		cfg.Scenario = sc
 	}
-	if v := os.Getenv("NUM_TRACKS"); v != "" {
-		n, err := strconv.Atoi(v)
+	if replay != "" {
+		events, err := sensor.LoadRecording(replay)
+		if err == nil && len(events) == 0 {
+			err = errors.New("recording has no events")
+		}
 		if err != nil {
-			slog.Error("invalid NUM_TRACKS", "value", v, "error", err)
+			slog.Error("invalid replay", "path", replay, "error", err)
 			os.Exit(1)
 		}
-		cfg.NumTracks = n
-	}
-	if v := os.Getenv("BBOX_MIN_LAT"); v != "" {
-		cfg.BBox.MinLat, _ = strconv.ParseFloat(v, 64)
-	}
-	if v := os.Getenv("BBOX_MAX_LAT"); v != "" {
-		cfg.BBox.MaxLat, _ = strconv.ParseFloat(v, 64)
-	}
-	if v := os.Getenv("BBOX_MIN_LON"); v != "" {
-		cfg.BBox.MinLon, _ = strconv.ParseFloat(v, 64)
+		ids, err := sensor.ParseIDMap(replayIDMap)
+		if err != nil {
+			slog.Error("invalid replay-id-map", "value", replayIDMap, "error", err)
+			os.Exit(1)
+		}
+		cfg.Replay = &sensor.Replay{Events: events, Timing: replayTiming, Speed: replaySpeed, Step: replayStep, IDPrefix: replayPrefix, IDMap: ids, Labels: cfg.Labels}
+		if replayComponents != "" {
+			cfg.Replay.Components = strings.Split(replayComponents, ",")
+		}
 	}
-	if v := os.Getenv("BBOX_MAX_LON"); v != "" {
-		cfg.BBox.MaxLon, _ = strconv.ParseFloat(v, 64)
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
@@ -58,6 +135,15 @@ func main() {
 		cancel()
 	}()
 
+	if err := health.Serve(ctx, healthAddr, health.New()); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, metricsAddr); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
 	sim := sensor.New(cfg)
 	if err := sim.Run(ctx); err != nil {
 		slog.Error("sensor-sim failed", "error", err)
@@ -2,20 +2,91 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"net"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
+	"time"
 
+	taskv1 "github.com/boshu2/lattice-lab/gen/task/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"github.com/boshu2/lattice-lab/internal/server"
 	"github.com/boshu2/lattice-lab/internal/task"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
 	cfg := task.DefaultConfig()
 
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "50052"
+	}
+
 	if v := os.Getenv("STORE_ADDR"); v != "" {
 		cfg.StoreAddr = v
 	}
+	if v := os.Getenv("ROE_ZONES"); v != "" {
+		zones, err := task.ParseZones(v)
+		if err != nil {
+			slog.Error("invalid ROE_ZONES", "value", v, "error", err)
+			os.Exit(1)
+		}
+		policy := task.Policy{Name: "roe", Zones: zones, RequireHostile: true}
+		if v := os.Getenv("ROE_REQUIRE_HOSTILE"); v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				slog.Error("invalid ROE_REQUIRE_HOSTILE", "value", v, "error", err)
+				os.Exit(1)
+			}
+			policy.RequireHostile = b
+		}
+		cfg.Policies = append(cfg.Policies, policy)
+	}
+	if v := os.Getenv("MANUAL_MODE"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			slog.Error("invalid MANUAL_MODE", "value", v, "error", err)
+			os.Exit(1)
+		}
+		cfg.ManualMode = b
+	}
+	if v := os.Getenv("DRY_RUN"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			slog.Error("invalid DRY_RUN", "value", v, "error", err)
+			os.Exit(1)
+		}
+		cfg.DryRun = b
+	}
+	if v := os.Getenv("APPROVAL_TIMEOUT_ACTION"); v != "" {
+		a, err := task.ParseTimeoutAction(v)
+		if err != nil {
+			slog.Error("invalid APPROVAL_TIMEOUT_ACTION", "value", v, "error", err)
+			os.Exit(1)
+		}
+		cfg.TimeoutAction = a
+	}
+	if v := os.Getenv("ESCALATE_TO"); v != "" {
+		cfg.EscalateTo = v
+	}
+	if v := os.Getenv("ESCALATION_WEBHOOK"); v != "" {
+		cfg.EscalationWebhook = v
+	}
+	if v := os.Getenv("ESCALATION_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			slog.Error("invalid ESCALATION_TIMEOUT", "value", v, "error", err)
+			os.Exit(1)
+		}
+		cfg.EscalationTimeout = d
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -28,7 +99,37 @@ func main() {
 		cancel()
 	}()
 
+	// HEALTH_LISTEN serves /healthz and /readyz: not ready until the watch
+	// is open, and unhealthy if handling one event hangs.
+	cfg.Health = health.New()
+	if err := health.Serve(ctx, os.Getenv("HEALTH_LISTEN"), cfg.Health); err != nil {
+		slog.Error("health probes failed", "error", err)
+		os.Exit(1)
+	}
+	if err := metrics.Serve(ctx, os.Getenv("METRICS_LISTEN")); err != nil {
+		slog.Error("metrics failed", "error", err)
+		os.Exit(1)
+	}
+
 	mgr := task.New(cfg)
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%s", port))
+	if err != nil {
+		slog.Error("failed to listen", "error", err)
+		os.Exit(1)
+	}
+	grpcServer := grpc.NewServer(metrics.ServerOption(), client.ServerOption(),
+		grpc.ChainUnaryInterceptor(server.UnaryServerHLC(client.ProcessClock)), grpc.ChainStreamInterceptor(server.StreamServerHLC(client.ProcessClock)))
+	taskv1.RegisterTaskManagerServiceServer(grpcServer, task.NewService(mgr))
+	reflection.Register(grpcServer)
+	go func() {
+		slog.Info("task-manager service listening", "port", port)
+		if err := grpcServer.Serve(lis); err != nil {
+			slog.Error("failed to serve", "error", err)
+		}
+	}()
+	defer grpcServer.GracefulStop()
+
 	if err := mgr.Run(ctx); err != nil {
 		slog.Error("task-manager failed", "error", err)
 		os.Exit(1)
@@ -30,6 +30,7 @@ const (
 	EntityType_ENTITY_TYPE_ASSET       EntityType = 1
 	EntityType_ENTITY_TYPE_TRACK       EntityType = 2
 	EntityType_ENTITY_TYPE_GEO         EntityType = 3
+	EntityType_ENTITY_TYPE_SYSTEM      EntityType = 4 // the lattice's own state, such as a relay's view of the mesh
 )
 
 // Enum value maps for EntityType.
@@ -39,12 +40,14 @@ var (
 		1: "ENTITY_TYPE_ASSET",
 		2: "ENTITY_TYPE_TRACK",
 		3: "ENTITY_TYPE_GEO",
+		4: "ENTITY_TYPE_SYSTEM",
 	}
 	EntityType_value = map[string]int32{
 		"ENTITY_TYPE_UNSPECIFIED": 0,
 		"ENTITY_TYPE_ASSET":       1,
 		"ENTITY_TYPE_TRACK":       2,
 		"ENTITY_TYPE_GEO":         3,
+		"ENTITY_TYPE_SYSTEM":      4,
 	}
 )
 
@@ -188,18 +191,312 @@ func (ApprovalState) EnumDescriptor() ([]byte, []int) {
 	return file_entity_v1_entity_proto_rawDescGZIP(), []int{2}
 }
 
+// Domain is the physical domain a track moves in. Unspecified is treated as
+// air, which every simulator produces.
+type Domain int32
+
+const (
+	Domain_DOMAIN_UNSPECIFIED Domain = 0
+	Domain_DOMAIN_AIR         Domain = 1
+	Domain_DOMAIN_SURFACE     Domain = 2
+	Domain_DOMAIN_LAND        Domain = 3
+)
+
+// Enum value maps for Domain.
+var (
+	Domain_name = map[int32]string{
+		0: "DOMAIN_UNSPECIFIED",
+		1: "DOMAIN_AIR",
+		2: "DOMAIN_SURFACE",
+		3: "DOMAIN_LAND",
+	}
+	Domain_value = map[string]int32{
+		"DOMAIN_UNSPECIFIED": 0,
+		"DOMAIN_AIR":         1,
+		"DOMAIN_SURFACE":     2,
+		"DOMAIN_LAND":        3,
+	}
+)
+
+func (x Domain) Enum() *Domain {
+	p := new(Domain)
+	*p = x
+	return p
+}
+
+func (x Domain) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Domain) Descriptor() protoreflect.EnumDescriptor {
+	return file_entity_v1_entity_proto_enumTypes[3].Descriptor()
+}
+
+func (Domain) Type() protoreflect.EnumType {
+	return &file_entity_v1_entity_proto_enumTypes[3]
+}
+
+func (x Domain) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Domain.Descriptor instead.
+func (Domain) EnumDescriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{3}
+}
+
+type TaskStatus int32
+
+const (
+	TaskStatus_TASK_STATUS_UNSPECIFIED TaskStatus = 0
+	TaskStatus_TASK_STATUS_ASSIGNED    TaskStatus = 1
+	TaskStatus_TASK_STATUS_IN_PROGRESS TaskStatus = 2
+	TaskStatus_TASK_STATUS_COMPLETED   TaskStatus = 3
+	TaskStatus_TASK_STATUS_FAILED      TaskStatus = 4
+	TaskStatus_TASK_STATUS_QUEUED      TaskStatus = 5
+)
+
+// Enum value maps for TaskStatus.
+var (
+	TaskStatus_name = map[int32]string{
+		0: "TASK_STATUS_UNSPECIFIED",
+		1: "TASK_STATUS_ASSIGNED",
+		2: "TASK_STATUS_IN_PROGRESS",
+		3: "TASK_STATUS_COMPLETED",
+		4: "TASK_STATUS_FAILED",
+		5: "TASK_STATUS_QUEUED",
+	}
+	TaskStatus_value = map[string]int32{
+		"TASK_STATUS_UNSPECIFIED": 0,
+		"TASK_STATUS_ASSIGNED":    1,
+		"TASK_STATUS_IN_PROGRESS": 2,
+		"TASK_STATUS_COMPLETED":   3,
+		"TASK_STATUS_FAILED":      4,
+		"TASK_STATUS_QUEUED":      5,
+	}
+)
+
+func (x TaskStatus) Enum() *TaskStatus {
+	p := new(TaskStatus)
+	*p = x
+	return p
+}
+
+func (x TaskStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (TaskStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_entity_v1_entity_proto_enumTypes[4].Descriptor()
+}
+
+func (TaskStatus) Type() protoreflect.EnumType {
+	return &file_entity_v1_entity_proto_enumTypes[4]
+}
+
+func (x TaskStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use TaskStatus.Descriptor instead.
+func (TaskStatus) EnumDescriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{4}
+}
+
+type AssetAvailability int32
+
+const (
+	AssetAvailability_ASSET_AVAILABILITY_UNSPECIFIED AssetAvailability = 0
+	AssetAvailability_ASSET_AVAILABILITY_FREE        AssetAvailability = 1
+	AssetAvailability_ASSET_AVAILABILITY_BUSY        AssetAvailability = 2
+)
+
+// Enum value maps for AssetAvailability.
+var (
+	AssetAvailability_name = map[int32]string{
+		0: "ASSET_AVAILABILITY_UNSPECIFIED",
+		1: "ASSET_AVAILABILITY_FREE",
+		2: "ASSET_AVAILABILITY_BUSY",
+	}
+	AssetAvailability_value = map[string]int32{
+		"ASSET_AVAILABILITY_UNSPECIFIED": 0,
+		"ASSET_AVAILABILITY_FREE":        1,
+		"ASSET_AVAILABILITY_BUSY":        2,
+	}
+)
+
+func (x AssetAvailability) Enum() *AssetAvailability {
+	p := new(AssetAvailability)
+	*p = x
+	return p
+}
+
+func (x AssetAvailability) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AssetAvailability) Descriptor() protoreflect.EnumDescriptor {
+	return file_entity_v1_entity_proto_enumTypes[5].Descriptor()
+}
+
+func (AssetAvailability) Type() protoreflect.EnumType {
+	return &file_entity_v1_entity_proto_enumTypes[5]
+}
+
+func (x AssetAvailability) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AssetAvailability.Descriptor instead.
+func (AssetAvailability) EnumDescriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{5}
+}
+
+type IFFStatus int32
+
+const (
+	IFFStatus_IFF_STATUS_UNSPECIFIED IFFStatus = 0
+	IFFStatus_IFF_STATUS_UNKNOWN     IFFStatus = 1
+	IFFStatus_IFF_STATUS_FRIEND      IFFStatus = 2
+	IFFStatus_IFF_STATUS_NEUTRAL     IFFStatus = 3
+	IFFStatus_IFF_STATUS_HOSTILE     IFFStatus = 4
+)
+
+// Enum value maps for IFFStatus.
+var (
+	IFFStatus_name = map[int32]string{
+		0: "IFF_STATUS_UNSPECIFIED",
+		1: "IFF_STATUS_UNKNOWN",
+		2: "IFF_STATUS_FRIEND",
+		3: "IFF_STATUS_NEUTRAL",
+		4: "IFF_STATUS_HOSTILE",
+	}
+	IFFStatus_value = map[string]int32{
+		"IFF_STATUS_UNSPECIFIED": 0,
+		"IFF_STATUS_UNKNOWN":     1,
+		"IFF_STATUS_FRIEND":      2,
+		"IFF_STATUS_NEUTRAL":     3,
+		"IFF_STATUS_HOSTILE":     4,
+	}
+)
+
+func (x IFFStatus) Enum() *IFFStatus {
+	p := new(IFFStatus)
+	*p = x
+	return p
+}
+
+func (x IFFStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (IFFStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_entity_v1_entity_proto_enumTypes[6].Descriptor()
+}
+
+func (IFFStatus) Type() protoreflect.EnumType {
+	return &file_entity_v1_entity_proto_enumTypes[6]
+}
+
+func (x IFFStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use IFFStatus.Descriptor instead.
+func (IFFStatus) EnumDescriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{6}
+}
+
+// GeoKind is what a GEO entity's area means to consumers.
+type GeoKind int32
+
+const (
+	GeoKind_GEO_KIND_UNSPECIFIED GeoKind = 0
+	GeoKind_GEO_KIND_RESTRICTED  GeoKind = 1 // no-fly / keep-out zone
+	GeoKind_GEO_KIND_ENGAGEMENT  GeoKind = 2 // weapons may be employed inside
+	GeoKind_GEO_KIND_COVERAGE    GeoKind = 3 // a sensor's field of view
+)
+
+// Enum value maps for GeoKind.
+var (
+	GeoKind_name = map[int32]string{
+		0: "GEO_KIND_UNSPECIFIED",
+		1: "GEO_KIND_RESTRICTED",
+		2: "GEO_KIND_ENGAGEMENT",
+		3: "GEO_KIND_COVERAGE",
+	}
+	GeoKind_value = map[string]int32{
+		"GEO_KIND_UNSPECIFIED": 0,
+		"GEO_KIND_RESTRICTED":  1,
+		"GEO_KIND_ENGAGEMENT":  2,
+		"GEO_KIND_COVERAGE":    3,
+	}
+)
+
+func (x GeoKind) Enum() *GeoKind {
+	p := new(GeoKind)
+	*p = x
+	return p
+}
+
+func (x GeoKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GeoKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_entity_v1_entity_proto_enumTypes[7].Descriptor()
+}
+
+func (GeoKind) Type() protoreflect.EnumType {
+	return &file_entity_v1_entity_proto_enumTypes[7]
+}
+
+func (x GeoKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GeoKind.Descriptor instead.
+func (GeoKind) EnumDescriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{7}
+}
+
 type Entity struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
-	Type          EntityType             `protobuf:"varint,2,opt,name=type,proto3,enum=entity.v1.EntityType" json:"type,omitempty"`
-	Components    map[string]*anypb.Any  `protobuf:"bytes,3,rep,name=components,proto3" json:"components,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
-	HlcPhysical   uint64                 `protobuf:"varint,6,opt,name=hlc_physical,json=hlcPhysical,proto3" json:"hlc_physical,omitempty"`
-	HlcLogical    uint32                 `protobuf:"varint,7,opt,name=hlc_logical,json=hlcLogical,proto3" json:"hlc_logical,omitempty"`
-	HlcNode       string                 `protobuf:"bytes,8,opt,name=hlc_node,json=hlcNode,proto3" json:"hlc_node,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Id          string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type        EntityType             `protobuf:"varint,2,opt,name=type,proto3,enum=entity.v1.EntityType" json:"type,omitempty"`
+	Components  map[string]*anypb.Any  `protobuf:"bytes,3,rep,name=components,proto3" json:"components,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	CreatedAt   *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt   *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	HlcPhysical uint64                 `protobuf:"varint,6,opt,name=hlc_physical,json=hlcPhysical,proto3" json:"hlc_physical,omitempty"`
+	HlcLogical  uint32                 `protobuf:"varint,7,opt,name=hlc_logical,json=hlcLogical,proto3" json:"hlc_logical,omitempty"`
+	HlcNode     string                 `protobuf:"bytes,8,opt,name=hlc_node,json=hlcNode,proto3" json:"hlc_node,omitempty"`
+	// The HLC of the write that last set each component, by component key.
+	// Merges compare these rather than the entity HLC, so a write to one
+	// component does not make concurrent writes to others look stale.
+	ComponentHlc map[string]*HLCTimestamp `protobuf:"bytes,9,rep,name=component_hlc,json=componentHlc,proto3" json:"component_hlc,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Free-form labels, e.g. exercise=bravo, that list and watch requests
+	// select on, so several scenarios can share one store. An update that
+	// carries labels replaces them; one that carries labels_hlc but no labels
+	// clears them; one with neither leaves them as they are.
+	Labels map[string]string `protobuf:"bytes,10,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// The HLC of the write that removed each component, by component key.
+	// A merge drops a component set no later than its removal, so a replica
+	// that still holds it cannot bring it back; the store forgets a removal
+	// once it is older than its component tombstone TTL.
+	RemovedComponents map[string]*HLCTimestamp `protobuf:"bytes,11,rep,name=removed_components,json=removedComponents,proto3" json:"removed_components,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// The HLC of the write that last set the labels, as component_hlc is
+	// for components; an entity without one falls back to its own HLC.
+	LabelsHlc *HLCTimestamp `protobuf:"bytes,12,opt,name=labels_hlc,json=labelsHlc,proto3" json:"labels_hlc,omitempty"`
+	// The writes each component's value was resolved from, by component
+	// key, where no one write set it: for keys merged other than LWW, such
+	// as threat max-wins, the writes a removal could drop some but not all
+	// of. A merge that drops some resolves the value again from the rest, so
+	// a removal drops the same writes whatever order replicas merge in. A
+	// component without an entry was set by the write its component_hlc
+	// names alone.
+	ComponentWrites map[string]*ComponentWrites `protobuf:"bytes,13,rep,name=component_writes,json=componentWrites,proto3" json:"component_writes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *Entity) Reset() {
@@ -288,30 +585,660 @@ func (x *Entity) GetHlcNode() string {
 	return ""
 }
 
-type PositionComponent struct {
+func (x *Entity) GetComponentHlc() map[string]*HLCTimestamp {
+	if x != nil {
+		return x.ComponentHlc
+	}
+	return nil
+}
+
+func (x *Entity) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Entity) GetRemovedComponents() map[string]*HLCTimestamp {
+	if x != nil {
+		return x.RemovedComponents
+	}
+	return nil
+}
+
+func (x *Entity) GetLabelsHlc() *HLCTimestamp {
+	if x != nil {
+		return x.LabelsHlc
+	}
+	return nil
+}
+
+func (x *Entity) GetComponentWrites() map[string]*ComponentWrites {
+	if x != nil {
+		return x.ComponentWrites
+	}
+	return nil
+}
+
+type HLCTimestamp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Physical      uint64                 `protobuf:"varint,1,opt,name=physical,proto3" json:"physical,omitempty"`
+	Logical       uint32                 `protobuf:"varint,2,opt,name=logical,proto3" json:"logical,omitempty"`
+	Node          string                 `protobuf:"bytes,3,opt,name=node,proto3" json:"node,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HLCTimestamp) Reset() {
+	*x = HLCTimestamp{}
+	mi := &file_entity_v1_entity_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HLCTimestamp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HLCTimestamp) ProtoMessage() {}
+
+func (x *HLCTimestamp) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HLCTimestamp.ProtoReflect.Descriptor instead.
+func (*HLCTimestamp) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HLCTimestamp) GetPhysical() uint64 {
+	if x != nil {
+		return x.Physical
+	}
+	return 0
+}
+
+func (x *HLCTimestamp) GetLogical() uint32 {
+	if x != nil {
+		return x.Logical
+	}
+	return 0
+}
+
+func (x *HLCTimestamp) GetNode() string {
+	if x != nil {
+		return x.Node
+	}
+	return ""
+}
+
+// ComponentWrite is one write to a component: the value it set and when.
+type ComponentWrite struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         *anypb.Any             `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Hlc           *HLCTimestamp          `protobuf:"bytes,2,opt,name=hlc,proto3" json:"hlc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComponentWrite) Reset() {
+	*x = ComponentWrite{}
+	mi := &file_entity_v1_entity_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComponentWrite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentWrite) ProtoMessage() {}
+
+func (x *ComponentWrite) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentWrite.ProtoReflect.Descriptor instead.
+func (*ComponentWrite) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ComponentWrite) GetValue() *anypb.Any {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+func (x *ComponentWrite) GetHlc() *HLCTimestamp {
+	if x != nil {
+		return x.Hlc
+	}
+	return nil
+}
+
+// ComponentWrites are the writes a component's value was resolved from,
+// oldest first.
+type ComponentWrites struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Writes        []*ComponentWrite      `protobuf:"bytes,1,rep,name=writes,proto3" json:"writes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComponentWrites) Reset() {
+	*x = ComponentWrites{}
+	mi := &file_entity_v1_entity_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComponentWrites) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentWrites) ProtoMessage() {}
+
+func (x *ComponentWrites) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentWrites.ProtoReflect.Descriptor instead.
+func (*ComponentWrites) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ComponentWrites) GetWrites() []*ComponentWrite {
+	if x != nil {
+		return x.Writes
+	}
+	return nil
+}
+
+type PositionComponent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Lat           float64                `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon           float64                `protobuf:"fixed64,2,opt,name=lon,proto3" json:"lon,omitempty"`
+	Alt           float64                `protobuf:"fixed64,3,opt,name=alt,proto3" json:"alt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PositionComponent) Reset() {
+	*x = PositionComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PositionComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PositionComponent) ProtoMessage() {}
+
+func (x *PositionComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PositionComponent.ProtoReflect.Descriptor instead.
+func (*PositionComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PositionComponent) GetLat() float64 {
+	if x != nil {
+		return x.Lat
+	}
+	return 0
+}
+
+func (x *PositionComponent) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
+}
+
+func (x *PositionComponent) GetAlt() float64 {
+	if x != nil {
+		return x.Alt
+	}
+	return 0
+}
+
+type VelocityComponent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Speed         float64                `protobuf:"fixed64,1,opt,name=speed,proto3" json:"speed,omitempty"`
+	Heading       float64                `protobuf:"fixed64,2,opt,name=heading,proto3" json:"heading,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *VelocityComponent) Reset() {
+	*x = VelocityComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VelocityComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VelocityComponent) ProtoMessage() {}
+
+func (x *VelocityComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VelocityComponent.ProtoReflect.Descriptor instead.
+func (*VelocityComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *VelocityComponent) GetSpeed() float64 {
+	if x != nil {
+		return x.Speed
+	}
+	return 0
+}
+
+func (x *VelocityComponent) GetHeading() float64 {
+	if x != nil {
+		return x.Heading
+	}
+	return 0
+}
+
+type ClassificationComponent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Label         string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
+	Confidence    float32                `protobuf:"fixed32,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClassificationComponent) Reset() {
+	*x = ClassificationComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClassificationComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClassificationComponent) ProtoMessage() {}
+
+func (x *ClassificationComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClassificationComponent.ProtoReflect.Descriptor instead.
+func (*ClassificationComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ClassificationComponent) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+func (x *ClassificationComponent) GetConfidence() float32 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+type TaskCatalogComponent struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	AvailableTasks []string               `protobuf:"bytes,1,rep,name=available_tasks,json=availableTasks,proto3" json:"available_tasks,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *TaskCatalogComponent) Reset() {
+	*x = TaskCatalogComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskCatalogComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskCatalogComponent) ProtoMessage() {}
+
+func (x *TaskCatalogComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskCatalogComponent.ProtoReflect.Descriptor instead.
+func (*TaskCatalogComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TaskCatalogComponent) GetAvailableTasks() []string {
+	if x != nil {
+		return x.AvailableTasks
+	}
+	return nil
+}
+
+type ThreatComponent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Level         ThreatLevel            `protobuf:"varint,1,opt,name=level,proto3,enum=entity.v1.ThreatLevel" json:"level,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ThreatComponent) Reset() {
+	*x = ThreatComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ThreatComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ThreatComponent) ProtoMessage() {}
+
+func (x *ThreatComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ThreatComponent.ProtoReflect.Descriptor instead.
+func (*ThreatComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ThreatComponent) GetLevel() ThreatLevel {
+	if x != nil {
+		return x.Level
+	}
+	return ThreatLevel_THREAT_LEVEL_UNSPECIFIED
+}
+
+type ApprovalComponent struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	State          ApprovalState          `protobuf:"varint,1,opt,name=state,proto3,enum=entity.v1.ApprovalState" json:"state,omitempty"`
+	TimeoutSeconds int64                  `protobuf:"varint,2,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"`
+	RequestedAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=requested_at,json=requestedAt,proto3" json:"requested_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *ApprovalComponent) Reset() {
+	*x = ApprovalComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApprovalComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApprovalComponent) ProtoMessage() {}
+
+func (x *ApprovalComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApprovalComponent.ProtoReflect.Descriptor instead.
+func (*ApprovalComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ApprovalComponent) GetState() ApprovalState {
+	if x != nil {
+		return x.State
+	}
+	return ApprovalState_APPROVAL_STATE_UNSPECIFIED
+}
+
+func (x *ApprovalComponent) GetTimeoutSeconds() int64 {
+	if x != nil {
+		return x.TimeoutSeconds
+	}
+	return 0
+}
+
+func (x *ApprovalComponent) GetRequestedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RequestedAt
+	}
+	return nil
+}
+
+// CounterComponent is a PN-counter: every store that counted keeps its own
+// running totals, by HLC node, so counts made on different nodes, even
+// across a partition, add up when merged instead of one overwriting the
+// other. Its value is the sum of increments less the sum of decrements.
+type CounterComponent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Increments    map[string]uint64      `protobuf:"bytes,1,rep,name=increments,proto3" json:"increments,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	Decrements    map[string]uint64      `protobuf:"bytes,2,rep,name=decrements,proto3" json:"decrements,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"varint,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CounterComponent) Reset() {
+	*x = CounterComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CounterComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CounterComponent) ProtoMessage() {}
+
+func (x *CounterComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CounterComponent.ProtoReflect.Descriptor instead.
+func (*CounterComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CounterComponent) GetIncrements() map[string]uint64 {
+	if x != nil {
+		return x.Increments
+	}
+	return nil
+}
+
+func (x *CounterComponent) GetDecrements() map[string]uint64 {
+	if x != nil {
+		return x.Decrements
+	}
+	return nil
+}
+
+type FusionComponent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SourceIds     []string               `protobuf:"bytes,1,rep,name=source_ids,json=sourceIds,proto3" json:"source_ids,omitempty"`
+	FusedLat      float64                `protobuf:"fixed64,2,opt,name=fused_lat,json=fusedLat,proto3" json:"fused_lat,omitempty"`
+	FusedLon      float64                `protobuf:"fixed64,3,opt,name=fused_lon,json=fusedLon,proto3" json:"fused_lon,omitempty"`
+	Confidence    float32                `protobuf:"fixed32,4,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FusionComponent) Reset() {
+	*x = FusionComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FusionComponent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FusionComponent) ProtoMessage() {}
+
+func (x *FusionComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FusionComponent.ProtoReflect.Descriptor instead.
+func (*FusionComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *FusionComponent) GetSourceIds() []string {
+	if x != nil {
+		return x.SourceIds
+	}
+	return nil
+}
+
+func (x *FusionComponent) GetFusedLat() float64 {
+	if x != nil {
+		return x.FusedLat
+	}
+	return 0
+}
+
+func (x *FusionComponent) GetFusedLon() float64 {
+	if x != nil {
+		return x.FusedLon
+	}
+	return 0
+}
+
+func (x *FusionComponent) GetConfidence() float32 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+type SourceComponent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Lat           float64                `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
-	Lon           float64                `protobuf:"fixed64,2,opt,name=lon,proto3" json:"lon,omitempty"`
-	Alt           float64                `protobuf:"fixed64,3,opt,name=alt,proto3" json:"alt,omitempty"`
+	SensorId      string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
+	SensorType    string                 `protobuf:"bytes,2,opt,name=sensor_type,json=sensorType,proto3" json:"sensor_type,omitempty"`
+	Domain        Domain                 `protobuf:"varint,3,opt,name=domain,proto3,enum=entity.v1.Domain" json:"domain,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *PositionComponent) Reset() {
-	*x = PositionComponent{}
-	mi := &file_entity_v1_entity_proto_msgTypes[1]
+func (x *SourceComponent) Reset() {
+	*x = SourceComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *PositionComponent) String() string {
+func (x *SourceComponent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*PositionComponent) ProtoMessage() {}
+func (*SourceComponent) ProtoMessage() {}
 
-func (x *PositionComponent) ProtoReflect() protoreflect.Message {
-	mi := &file_entity_v1_entity_proto_msgTypes[1]
+func (x *SourceComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -322,55 +1249,57 @@ func (x *PositionComponent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use PositionComponent.ProtoReflect.Descriptor instead.
-func (*PositionComponent) Descriptor() ([]byte, []int) {
-	return file_entity_v1_entity_proto_rawDescGZIP(), []int{1}
+// Deprecated: Use SourceComponent.ProtoReflect.Descriptor instead.
+func (*SourceComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{12}
 }
 
-func (x *PositionComponent) GetLat() float64 {
+func (x *SourceComponent) GetSensorId() string {
 	if x != nil {
-		return x.Lat
+		return x.SensorId
 	}
-	return 0
+	return ""
 }
 
-func (x *PositionComponent) GetLon() float64 {
+func (x *SourceComponent) GetSensorType() string {
 	if x != nil {
-		return x.Lon
+		return x.SensorType
 	}
-	return 0
+	return ""
 }
 
-func (x *PositionComponent) GetAlt() float64 {
+func (x *SourceComponent) GetDomain() Domain {
 	if x != nil {
-		return x.Alt
+		return x.Domain
 	}
-	return 0
+	return Domain_DOMAIN_UNSPECIFIED
 }
 
-type VelocityComponent struct {
+type AssignmentComponent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Speed         float64                `protobuf:"fixed64,1,opt,name=speed,proto3" json:"speed,omitempty"`
-	Heading       float64                `protobuf:"fixed64,2,opt,name=heading,proto3" json:"heading,omitempty"`
+	Task          string                 `protobuf:"bytes,1,opt,name=task,proto3" json:"task,omitempty"`
+	AssetId       string                 `protobuf:"bytes,2,opt,name=asset_id,json=assetId,proto3" json:"asset_id,omitempty"`
+	Status        TaskStatus             `protobuf:"varint,3,opt,name=status,proto3,enum=entity.v1.TaskStatus" json:"status,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *VelocityComponent) Reset() {
-	*x = VelocityComponent{}
-	mi := &file_entity_v1_entity_proto_msgTypes[2]
+func (x *AssignmentComponent) Reset() {
+	*x = AssignmentComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *VelocityComponent) String() string {
+func (x *AssignmentComponent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*VelocityComponent) ProtoMessage() {}
+func (*AssignmentComponent) ProtoMessage() {}
 
-func (x *VelocityComponent) ProtoReflect() protoreflect.Message {
-	mi := &file_entity_v1_entity_proto_msgTypes[2]
+func (x *AssignmentComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -381,48 +1310,63 @@ func (x *VelocityComponent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use VelocityComponent.ProtoReflect.Descriptor instead.
-func (*VelocityComponent) Descriptor() ([]byte, []int) {
-	return file_entity_v1_entity_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use AssignmentComponent.ProtoReflect.Descriptor instead.
+func (*AssignmentComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *VelocityComponent) GetSpeed() float64 {
+func (x *AssignmentComponent) GetTask() string {
 	if x != nil {
-		return x.Speed
+		return x.Task
 	}
-	return 0
+	return ""
 }
 
-func (x *VelocityComponent) GetHeading() float64 {
+func (x *AssignmentComponent) GetAssetId() string {
 	if x != nil {
-		return x.Heading
+		return x.AssetId
 	}
-	return 0
+	return ""
 }
 
-type ClassificationComponent struct {
+func (x *AssignmentComponent) GetStatus() TaskStatus {
+	if x != nil {
+		return x.Status
+	}
+	return TaskStatus_TASK_STATUS_UNSPECIFIED
+}
+
+func (x *AssignmentComponent) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type AvailabilityComponent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Label         string                 `protobuf:"bytes,1,opt,name=label,proto3" json:"label,omitempty"`
-	Confidence    float32                `protobuf:"fixed32,2,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	State         AssetAvailability      `protobuf:"varint,1,opt,name=state,proto3,enum=entity.v1.AssetAvailability" json:"state,omitempty"`
+	Task          string                 `protobuf:"bytes,2,opt,name=task,proto3" json:"task,omitempty"`
+	TargetId      string                 `protobuf:"bytes,3,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ClassificationComponent) Reset() {
-	*x = ClassificationComponent{}
-	mi := &file_entity_v1_entity_proto_msgTypes[3]
+func (x *AvailabilityComponent) Reset() {
+	*x = AvailabilityComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ClassificationComponent) String() string {
+func (x *AvailabilityComponent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ClassificationComponent) ProtoMessage() {}
+func (*AvailabilityComponent) ProtoMessage() {}
 
-func (x *ClassificationComponent) ProtoReflect() protoreflect.Message {
-	mi := &file_entity_v1_entity_proto_msgTypes[3]
+func (x *AvailabilityComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -433,47 +1377,54 @@ func (x *ClassificationComponent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ClassificationComponent.ProtoReflect.Descriptor instead.
-func (*ClassificationComponent) Descriptor() ([]byte, []int) {
-	return file_entity_v1_entity_proto_rawDescGZIP(), []int{3}
+// Deprecated: Use AvailabilityComponent.ProtoReflect.Descriptor instead.
+func (*AvailabilityComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{14}
 }
 
-func (x *ClassificationComponent) GetLabel() string {
+func (x *AvailabilityComponent) GetState() AssetAvailability {
 	if x != nil {
-		return x.Label
+		return x.State
+	}
+	return AssetAvailability_ASSET_AVAILABILITY_UNSPECIFIED
+}
+
+func (x *AvailabilityComponent) GetTask() string {
+	if x != nil {
+		return x.Task
 	}
 	return ""
 }
 
-func (x *ClassificationComponent) GetConfidence() float32 {
+func (x *AvailabilityComponent) GetTargetId() string {
 	if x != nil {
-		return x.Confidence
+		return x.TargetId
 	}
-	return 0
+	return ""
 }
 
-type TaskCatalogComponent struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	AvailableTasks []string               `protobuf:"bytes,1,rep,name=available_tasks,json=availableTasks,proto3" json:"available_tasks,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+type IFFComponent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        IFFStatus              `protobuf:"varint,1,opt,name=status,proto3,enum=entity.v1.IFFStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *TaskCatalogComponent) Reset() {
-	*x = TaskCatalogComponent{}
-	mi := &file_entity_v1_entity_proto_msgTypes[4]
+func (x *IFFComponent) Reset() {
+	*x = IFFComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *TaskCatalogComponent) String() string {
+func (x *IFFComponent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*TaskCatalogComponent) ProtoMessage() {}
+func (*IFFComponent) ProtoMessage() {}
 
-func (x *TaskCatalogComponent) ProtoReflect() protoreflect.Message {
-	mi := &file_entity_v1_entity_proto_msgTypes[4]
+func (x *IFFComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -484,40 +1435,41 @@ func (x *TaskCatalogComponent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use TaskCatalogComponent.ProtoReflect.Descriptor instead.
-func (*TaskCatalogComponent) Descriptor() ([]byte, []int) {
-	return file_entity_v1_entity_proto_rawDescGZIP(), []int{4}
+// Deprecated: Use IFFComponent.ProtoReflect.Descriptor instead.
+func (*IFFComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *TaskCatalogComponent) GetAvailableTasks() []string {
+func (x *IFFComponent) GetStatus() IFFStatus {
 	if x != nil {
-		return x.AvailableTasks
+		return x.Status
 	}
-	return nil
+	return IFFStatus_IFF_STATUS_UNSPECIFIED
 }
 
-type ThreatComponent struct {
+type GeoPoint struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Level         ThreatLevel            `protobuf:"varint,1,opt,name=level,proto3,enum=entity.v1.ThreatLevel" json:"level,omitempty"`
+	Lat           float64                `protobuf:"fixed64,1,opt,name=lat,proto3" json:"lat,omitempty"`
+	Lon           float64                `protobuf:"fixed64,2,opt,name=lon,proto3" json:"lon,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ThreatComponent) Reset() {
-	*x = ThreatComponent{}
-	mi := &file_entity_v1_entity_proto_msgTypes[5]
+func (x *GeoPoint) Reset() {
+	*x = GeoPoint{}
+	mi := &file_entity_v1_entity_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ThreatComponent) String() string {
+func (x *GeoPoint) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ThreatComponent) ProtoMessage() {}
+func (*GeoPoint) ProtoMessage() {}
 
-func (x *ThreatComponent) ProtoReflect() protoreflect.Message {
-	mi := &file_entity_v1_entity_proto_msgTypes[5]
+func (x *GeoPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -528,42 +1480,54 @@ func (x *ThreatComponent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ThreatComponent.ProtoReflect.Descriptor instead.
-func (*ThreatComponent) Descriptor() ([]byte, []int) {
-	return file_entity_v1_entity_proto_rawDescGZIP(), []int{5}
+// Deprecated: Use GeoPoint.ProtoReflect.Descriptor instead.
+func (*GeoPoint) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *ThreatComponent) GetLevel() ThreatLevel {
+func (x *GeoPoint) GetLat() float64 {
 	if x != nil {
-		return x.Level
+		return x.Lat
 	}
-	return ThreatLevel_THREAT_LEVEL_UNSPECIFIED
+	return 0
 }
 
-type ApprovalComponent struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	State          ApprovalState          `protobuf:"varint,1,opt,name=state,proto3,enum=entity.v1.ApprovalState" json:"state,omitempty"`
-	TimeoutSeconds int64                  `protobuf:"varint,2,opt,name=timeout_seconds,json=timeoutSeconds,proto3" json:"timeout_seconds,omitempty"`
-	RequestedAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=requested_at,json=requestedAt,proto3" json:"requested_at,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+func (x *GeoPoint) GetLon() float64 {
+	if x != nil {
+		return x.Lon
+	}
+	return 0
 }
 
-func (x *ApprovalComponent) Reset() {
-	*x = ApprovalComponent{}
-	mi := &file_entity_v1_entity_proto_msgTypes[6]
+// GeoComponent is the area of a GEO entity: a polygon of points, or a circle
+// of radius_m about a single point. Altitudes of 0 leave that bound open.
+type GeoComponent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Kind          GeoKind                `protobuf:"varint,2,opt,name=kind,proto3,enum=entity.v1.GeoKind" json:"kind,omitempty"`
+	Points        []*GeoPoint            `protobuf:"bytes,3,rep,name=points,proto3" json:"points,omitempty"`
+	RadiusM       float64                `protobuf:"fixed64,4,opt,name=radius_m,json=radiusM,proto3" json:"radius_m,omitempty"`
+	MinAlt        float64                `protobuf:"fixed64,5,opt,name=min_alt,json=minAlt,proto3" json:"min_alt,omitempty"`
+	MaxAlt        float64                `protobuf:"fixed64,6,opt,name=max_alt,json=maxAlt,proto3" json:"max_alt,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeoComponent) Reset() {
+	*x = GeoComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ApprovalComponent) String() string {
+func (x *GeoComponent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ApprovalComponent) ProtoMessage() {}
+func (*GeoComponent) ProtoMessage() {}
 
-func (x *ApprovalComponent) ProtoReflect() protoreflect.Message {
-	mi := &file_entity_v1_entity_proto_msgTypes[6]
+func (x *GeoComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -574,57 +1538,80 @@ func (x *ApprovalComponent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ApprovalComponent.ProtoReflect.Descriptor instead.
-func (*ApprovalComponent) Descriptor() ([]byte, []int) {
-	return file_entity_v1_entity_proto_rawDescGZIP(), []int{6}
+// Deprecated: Use GeoComponent.ProtoReflect.Descriptor instead.
+func (*GeoComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{17}
 }
 
-func (x *ApprovalComponent) GetState() ApprovalState {
+func (x *GeoComponent) GetName() string {
 	if x != nil {
-		return x.State
+		return x.Name
 	}
-	return ApprovalState_APPROVAL_STATE_UNSPECIFIED
+	return ""
 }
 
-func (x *ApprovalComponent) GetTimeoutSeconds() int64 {
+func (x *GeoComponent) GetKind() GeoKind {
 	if x != nil {
-		return x.TimeoutSeconds
+		return x.Kind
 	}
-	return 0
+	return GeoKind_GEO_KIND_UNSPECIFIED
 }
 
-func (x *ApprovalComponent) GetRequestedAt() *timestamppb.Timestamp {
+func (x *GeoComponent) GetPoints() []*GeoPoint {
 	if x != nil {
-		return x.RequestedAt
+		return x.Points
 	}
 	return nil
 }
 
-type FusionComponent struct {
+func (x *GeoComponent) GetRadiusM() float64 {
+	if x != nil {
+		return x.RadiusM
+	}
+	return 0
+}
+
+func (x *GeoComponent) GetMinAlt() float64 {
+	if x != nil {
+		return x.MinAlt
+	}
+	return 0
+}
+
+func (x *GeoComponent) GetMaxAlt() float64 {
+	if x != nil {
+		return x.MaxAlt
+	}
+	return 0
+}
+
+// AssetComponent describes what an ASSET is and which tasks it can take.
+type AssetComponent struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	SourceIds     []string               `protobuf:"bytes,1,rep,name=source_ids,json=sourceIds,proto3" json:"source_ids,omitempty"`
-	FusedLat      float64                `protobuf:"fixed64,2,opt,name=fused_lat,json=fusedLat,proto3" json:"fused_lat,omitempty"`
-	FusedLon      float64                `protobuf:"fixed64,3,opt,name=fused_lon,json=fusedLon,proto3" json:"fused_lon,omitempty"`
-	Confidence    float32                `protobuf:"fixed32,4,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Kind          string                 `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`                 // e.g. "patrol", "interceptor"
+	Capabilities  []string               `protobuf:"bytes,2,rep,name=capabilities,proto3" json:"capabilities,omitempty"` // task names it accepts, e.g. "intercept"
+	MaxSpeedKts   float64                `protobuf:"fixed64,3,opt,name=max_speed_kts,json=maxSpeedKts,proto3" json:"max_speed_kts,omitempty"`
+	StationLat    float64                `protobuf:"fixed64,4,opt,name=station_lat,json=stationLat,proto3" json:"station_lat,omitempty"` // where it loiters when free
+	StationLon    float64                `protobuf:"fixed64,5,opt,name=station_lon,json=stationLon,proto3" json:"station_lon,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *FusionComponent) Reset() {
-	*x = FusionComponent{}
-	mi := &file_entity_v1_entity_proto_msgTypes[7]
+func (x *AssetComponent) Reset() {
+	*x = AssetComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *FusionComponent) String() string {
+func (x *AssetComponent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*FusionComponent) ProtoMessage() {}
+func (*AssetComponent) ProtoMessage() {}
 
-func (x *FusionComponent) ProtoReflect() protoreflect.Message {
-	mi := &file_entity_v1_entity_proto_msgTypes[7]
+func (x *AssetComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -635,62 +1622,80 @@ func (x *FusionComponent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use FusionComponent.ProtoReflect.Descriptor instead.
-func (*FusionComponent) Descriptor() ([]byte, []int) {
-	return file_entity_v1_entity_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use AssetComponent.ProtoReflect.Descriptor instead.
+func (*AssetComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{18}
 }
 
-func (x *FusionComponent) GetSourceIds() []string {
+func (x *AssetComponent) GetKind() string {
 	if x != nil {
-		return x.SourceIds
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *AssetComponent) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
 	}
 	return nil
 }
 
-func (x *FusionComponent) GetFusedLat() float64 {
+func (x *AssetComponent) GetMaxSpeedKts() float64 {
 	if x != nil {
-		return x.FusedLat
+		return x.MaxSpeedKts
 	}
 	return 0
 }
 
-func (x *FusionComponent) GetFusedLon() float64 {
+func (x *AssetComponent) GetStationLat() float64 {
 	if x != nil {
-		return x.FusedLon
+		return x.StationLat
 	}
 	return 0
 }
 
-func (x *FusionComponent) GetConfidence() float32 {
+func (x *AssetComponent) GetStationLon() float64 {
 	if x != nil {
-		return x.Confidence
+		return x.StationLon
 	}
 	return 0
 }
 
-type SourceComponent struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	SensorId      string                 `protobuf:"bytes,1,opt,name=sensor_id,json=sensorId,proto3" json:"sensor_id,omitempty"`
-	SensorType    string                 `protobuf:"bytes,2,opt,name=sensor_type,json=sensorType,proto3" json:"sensor_type,omitempty"`
+// MeshHealthComponent is a node's view of the mesh, written by its relay
+// as the "mesh_health" component of SYSTEM entity mesh-health/<node>.
+// While degraded, the node's picture lacks what the partitioned peers
+// write, and what it writes does not reach them.
+type MeshHealthComponent struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	NodeId      string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Degraded    bool                   `protobuf:"varint,2,opt,name=degraded,proto3" json:"degraded,omitempty"`      // some peer is partitioned
+	Partitioned []string               `protobuf:"bytes,3,rep,name=partitioned,proto3" json:"partitioned,omitempty"` // peers that missed their heartbeats, by address
+	Reachable   []string               `protobuf:"bytes,4,rep,name=reachable,proto3" json:"reachable,omitempty"`     // by address
+	Since       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=since,proto3" json:"since,omitempty"`             // when the view last changed
+	// Reachable peers whose wall clocks are further from this node's than
+	// the relay's max skew, by address. HLCs stay ordered, but writes from a
+	// fast node win last-writer-wins merges they should lose.
+	Skewed        []string `protobuf:"bytes,6,rep,name=skewed,proto3" json:"skewed,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SourceComponent) Reset() {
-	*x = SourceComponent{}
-	mi := &file_entity_v1_entity_proto_msgTypes[8]
+func (x *MeshHealthComponent) Reset() {
+	*x = MeshHealthComponent{}
+	mi := &file_entity_v1_entity_proto_msgTypes[19]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SourceComponent) String() string {
+func (x *MeshHealthComponent) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SourceComponent) ProtoMessage() {}
+func (*MeshHealthComponent) ProtoMessage() {}
 
-func (x *SourceComponent) ProtoReflect() protoreflect.Message {
-	mi := &file_entity_v1_entity_proto_msgTypes[8]
+func (x *MeshHealthComponent) ProtoReflect() protoreflect.Message {
+	mi := &file_entity_v1_entity_proto_msgTypes[19]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -701,30 +1706,58 @@ func (x *SourceComponent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SourceComponent.ProtoReflect.Descriptor instead.
-func (*SourceComponent) Descriptor() ([]byte, []int) {
-	return file_entity_v1_entity_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use MeshHealthComponent.ProtoReflect.Descriptor instead.
+func (*MeshHealthComponent) Descriptor() ([]byte, []int) {
+	return file_entity_v1_entity_proto_rawDescGZIP(), []int{19}
 }
 
-func (x *SourceComponent) GetSensorId() string {
+func (x *MeshHealthComponent) GetNodeId() string {
 	if x != nil {
-		return x.SensorId
+		return x.NodeId
 	}
 	return ""
 }
 
-func (x *SourceComponent) GetSensorType() string {
+func (x *MeshHealthComponent) GetDegraded() bool {
 	if x != nil {
-		return x.SensorType
+		return x.Degraded
 	}
-	return ""
+	return false
+}
+
+func (x *MeshHealthComponent) GetPartitioned() []string {
+	if x != nil {
+		return x.Partitioned
+	}
+	return nil
+}
+
+func (x *MeshHealthComponent) GetReachable() []string {
+	if x != nil {
+		return x.Reachable
+	}
+	return nil
+}
+
+func (x *MeshHealthComponent) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *MeshHealthComponent) GetSkewed() []string {
+	if x != nil {
+		return x.Skewed
+	}
+	return nil
 }
 
 var File_entity_v1_entity_proto protoreflect.FileDescriptor
 
 const file_entity_v1_entity_proto_rawDesc = "" +
 	"\n" +
-	"\x16entity/v1/entity.proto\x12\tentity.v1\x1a\x19google/protobuf/any.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xb0\x03\n" +
+	"\x16entity/v1/entity.proto\x12\tentity.v1\x1a\x19google/protobuf/any.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe9\b\n" +
 	"\x06Entity\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12)\n" +
 	"\x04type\x18\x02 \x01(\x0e2\x15.entity.v1.EntityTypeR\x04type\x12A\n" +
@@ -738,10 +1771,38 @@ const file_entity_v1_entity_proto_rawDesc = "" +
 	"\fhlc_physical\x18\x06 \x01(\x04R\vhlcPhysical\x12\x1f\n" +
 	"\vhlc_logical\x18\a \x01(\rR\n" +
 	"hlcLogical\x12\x19\n" +
-	"\bhlc_node\x18\b \x01(\tR\ahlcNode\x1aS\n" +
+	"\bhlc_node\x18\b \x01(\tR\ahlcNode\x12H\n" +
+	"\rcomponent_hlc\x18\t \x03(\v2#.entity.v1.Entity.ComponentHlcEntryR\fcomponentHlc\x125\n" +
+	"\x06labels\x18\n" +
+	" \x03(\v2\x1d.entity.v1.Entity.LabelsEntryR\x06labels\x12W\n" +
+	"\x12removed_components\x18\v \x03(\v2(.entity.v1.Entity.RemovedComponentsEntryR\x11removedComponents\x126\n" +
+	"\n" +
+	"labels_hlc\x18\f \x01(\v2\x17.entity.v1.HLCTimestampR\tlabelsHlc\x12Q\n" +
+	"\x10component_writes\x18\r \x03(\v2&.entity.v1.Entity.ComponentWritesEntryR\x0fcomponentWrites\x1aS\n" +
 	"\x0fComponentsEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12*\n" +
-	"\x05value\x18\x02 \x01(\v2\x14.google.protobuf.AnyR\x05value:\x028\x01\"I\n" +
+	"\x05value\x18\x02 \x01(\v2\x14.google.protobuf.AnyR\x05value:\x028\x01\x1aX\n" +
+	"\x11ComponentHlcEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12-\n" +
+	"\x05value\x18\x02 \x01(\v2\x17.entity.v1.HLCTimestampR\x05value:\x028\x01\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a]\n" +
+	"\x16RemovedComponentsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12-\n" +
+	"\x05value\x18\x02 \x01(\v2\x17.entity.v1.HLCTimestampR\x05value:\x028\x01\x1a^\n" +
+	"\x14ComponentWritesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x120\n" +
+	"\x05value\x18\x02 \x01(\v2\x1a.entity.v1.ComponentWritesR\x05value:\x028\x01\"X\n" +
+	"\fHLCTimestamp\x12\x1a\n" +
+	"\bphysical\x18\x01 \x01(\x04R\bphysical\x12\x18\n" +
+	"\alogical\x18\x02 \x01(\rR\alogical\x12\x12\n" +
+	"\x04node\x18\x03 \x01(\tR\x04node\"g\n" +
+	"\x0eComponentWrite\x12*\n" +
+	"\x05value\x18\x01 \x01(\v2\x14.google.protobuf.AnyR\x05value\x12)\n" +
+	"\x03hlc\x18\x02 \x01(\v2\x17.entity.v1.HLCTimestampR\x03hlc\"D\n" +
+	"\x0fComponentWrites\x121\n" +
+	"\x06writes\x18\x01 \x03(\v2\x19.entity.v1.ComponentWriteR\x06writes\"I\n" +
 	"\x11PositionComponent\x12\x10\n" +
 	"\x03lat\x18\x01 \x01(\x01R\x03lat\x12\x10\n" +
 	"\x03lon\x18\x02 \x01(\x01R\x03lon\x12\x10\n" +
@@ -761,7 +1822,20 @@ const file_entity_v1_entity_proto_rawDesc = "" +
 	"\x11ApprovalComponent\x12.\n" +
 	"\x05state\x18\x01 \x01(\x0e2\x18.entity.v1.ApprovalStateR\x05state\x12'\n" +
 	"\x0ftimeout_seconds\x18\x02 \x01(\x03R\x0etimeoutSeconds\x12=\n" +
-	"\frequested_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vrequestedAt\"\x8a\x01\n" +
+	"\frequested_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\vrequestedAt\"\xaa\x02\n" +
+	"\x10CounterComponent\x12K\n" +
+	"\n" +
+	"increments\x18\x01 \x03(\v2+.entity.v1.CounterComponent.IncrementsEntryR\n" +
+	"increments\x12K\n" +
+	"\n" +
+	"decrements\x18\x02 \x03(\v2+.entity.v1.CounterComponent.DecrementsEntryR\n" +
+	"decrements\x1a=\n" +
+	"\x0fIncrementsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\x1a=\n" +
+	"\x0fDecrementsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x04R\x05value:\x028\x01\"\x8a\x01\n" +
 	"\x0fFusionComponent\x12\x1d\n" +
 	"\n" +
 	"source_ids\x18\x01 \x03(\tR\tsourceIds\x12\x1b\n" +
@@ -769,17 +1843,56 @@ const file_entity_v1_entity_proto_rawDesc = "" +
 	"\tfused_lon\x18\x03 \x01(\x01R\bfusedLon\x12\x1e\n" +
 	"\n" +
 	"confidence\x18\x04 \x01(\x02R\n" +
-	"confidence\"O\n" +
+	"confidence\"z\n" +
 	"\x0fSourceComponent\x12\x1b\n" +
 	"\tsensor_id\x18\x01 \x01(\tR\bsensorId\x12\x1f\n" +
 	"\vsensor_type\x18\x02 \x01(\tR\n" +
-	"sensorType*l\n" +
+	"sensorType\x12)\n" +
+	"\x06domain\x18\x03 \x01(\x0e2\x11.entity.v1.DomainR\x06domain\"\xae\x01\n" +
+	"\x13AssignmentComponent\x12\x12\n" +
+	"\x04task\x18\x01 \x01(\tR\x04task\x12\x19\n" +
+	"\basset_id\x18\x02 \x01(\tR\aassetId\x12-\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x15.entity.v1.TaskStatusR\x06status\x129\n" +
+	"\n" +
+	"updated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"|\n" +
+	"\x15AvailabilityComponent\x122\n" +
+	"\x05state\x18\x01 \x01(\x0e2\x1c.entity.v1.AssetAvailabilityR\x05state\x12\x12\n" +
+	"\x04task\x18\x02 \x01(\tR\x04task\x12\x1b\n" +
+	"\ttarget_id\x18\x03 \x01(\tR\btargetId\"<\n" +
+	"\fIFFComponent\x12,\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x14.entity.v1.IFFStatusR\x06status\".\n" +
+	"\bGeoPoint\x12\x10\n" +
+	"\x03lat\x18\x01 \x01(\x01R\x03lat\x12\x10\n" +
+	"\x03lon\x18\x02 \x01(\x01R\x03lon\"\xc4\x01\n" +
+	"\fGeoComponent\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12&\n" +
+	"\x04kind\x18\x02 \x01(\x0e2\x12.entity.v1.GeoKindR\x04kind\x12+\n" +
+	"\x06points\x18\x03 \x03(\v2\x13.entity.v1.GeoPointR\x06points\x12\x19\n" +
+	"\bradius_m\x18\x04 \x01(\x01R\aradiusM\x12\x17\n" +
+	"\amin_alt\x18\x05 \x01(\x01R\x06minAlt\x12\x17\n" +
+	"\amax_alt\x18\x06 \x01(\x01R\x06maxAlt\"\xae\x01\n" +
+	"\x0eAssetComponent\x12\x12\n" +
+	"\x04kind\x18\x01 \x01(\tR\x04kind\x12\"\n" +
+	"\fcapabilities\x18\x02 \x03(\tR\fcapabilities\x12\"\n" +
+	"\rmax_speed_kts\x18\x03 \x01(\x01R\vmaxSpeedKts\x12\x1f\n" +
+	"\vstation_lat\x18\x04 \x01(\x01R\n" +
+	"stationLat\x12\x1f\n" +
+	"\vstation_lon\x18\x05 \x01(\x01R\n" +
+	"stationLon\"\xd4\x01\n" +
+	"\x13MeshHealthComponent\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12\x1a\n" +
+	"\bdegraded\x18\x02 \x01(\bR\bdegraded\x12 \n" +
+	"\vpartitioned\x18\x03 \x03(\tR\vpartitioned\x12\x1c\n" +
+	"\treachable\x18\x04 \x03(\tR\treachable\x120\n" +
+	"\x05since\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\x16\n" +
+	"\x06skewed\x18\x06 \x03(\tR\x06skewed*\x84\x01\n" +
 	"\n" +
 	"EntityType\x12\x1b\n" +
 	"\x17ENTITY_TYPE_UNSPECIFIED\x10\x00\x12\x15\n" +
 	"\x11ENTITY_TYPE_ASSET\x10\x01\x12\x15\n" +
 	"\x11ENTITY_TYPE_TRACK\x10\x02\x12\x13\n" +
-	"\x0fENTITY_TYPE_GEO\x10\x03*\x88\x01\n" +
+	"\x0fENTITY_TYPE_GEO\x10\x03\x12\x16\n" +
+	"\x12ENTITY_TYPE_SYSTEM\x10\x04*\x88\x01\n" +
 	"\vThreatLevel\x12\x1c\n" +
 	"\x18THREAT_LEVEL_UNSPECIFIED\x10\x00\x12\x15\n" +
 	"\x11THREAT_LEVEL_NONE\x10\x01\x12\x14\n" +
@@ -792,7 +1905,36 @@ const file_entity_v1_entity_proto_rawDesc = "" +
 	"\x16APPROVAL_STATE_PENDING\x10\x02\x12\x1b\n" +
 	"\x17APPROVAL_STATE_APPROVED\x10\x03\x12\x19\n" +
 	"\x15APPROVAL_STATE_DENIED\x10\x04\x12\x1c\n" +
-	"\x18APPROVAL_STATE_TIMED_OUT\x10\x05B6Z4github.com/boshu2/lattice-lab/gen/entity/v1;entityv1b\x06proto3"
+	"\x18APPROVAL_STATE_TIMED_OUT\x10\x05*U\n" +
+	"\x06Domain\x12\x16\n" +
+	"\x12DOMAIN_UNSPECIFIED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"DOMAIN_AIR\x10\x01\x12\x12\n" +
+	"\x0eDOMAIN_SURFACE\x10\x02\x12\x0f\n" +
+	"\vDOMAIN_LAND\x10\x03*\xab\x01\n" +
+	"\n" +
+	"TaskStatus\x12\x1b\n" +
+	"\x17TASK_STATUS_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14TASK_STATUS_ASSIGNED\x10\x01\x12\x1b\n" +
+	"\x17TASK_STATUS_IN_PROGRESS\x10\x02\x12\x19\n" +
+	"\x15TASK_STATUS_COMPLETED\x10\x03\x12\x16\n" +
+	"\x12TASK_STATUS_FAILED\x10\x04\x12\x16\n" +
+	"\x12TASK_STATUS_QUEUED\x10\x05*q\n" +
+	"\x11AssetAvailability\x12\"\n" +
+	"\x1eASSET_AVAILABILITY_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17ASSET_AVAILABILITY_FREE\x10\x01\x12\x1b\n" +
+	"\x17ASSET_AVAILABILITY_BUSY\x10\x02*\x86\x01\n" +
+	"\tIFFStatus\x12\x1a\n" +
+	"\x16IFF_STATUS_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12IFF_STATUS_UNKNOWN\x10\x01\x12\x15\n" +
+	"\x11IFF_STATUS_FRIEND\x10\x02\x12\x16\n" +
+	"\x12IFF_STATUS_NEUTRAL\x10\x03\x12\x16\n" +
+	"\x12IFF_STATUS_HOSTILE\x10\x04*l\n" +
+	"\aGeoKind\x12\x18\n" +
+	"\x14GEO_KIND_UNSPECIFIED\x10\x00\x12\x17\n" +
+	"\x13GEO_KIND_RESTRICTED\x10\x01\x12\x17\n" +
+	"\x13GEO_KIND_ENGAGEMENT\x10\x02\x12\x15\n" +
+	"\x11GEO_KIND_COVERAGE\x10\x03B6Z4github.com/boshu2/lattice-lab/gen/entity/v1;entityv1b\x06proto3"
 
 var (
 	file_entity_v1_entity_proto_rawDescOnce sync.Once
@@ -806,39 +1948,82 @@ func file_entity_v1_entity_proto_rawDescGZIP() []byte {
 	return file_entity_v1_entity_proto_rawDescData
 }
 
-var file_entity_v1_entity_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
-var file_entity_v1_entity_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_entity_v1_entity_proto_enumTypes = make([]protoimpl.EnumInfo, 8)
+var file_entity_v1_entity_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_entity_v1_entity_proto_goTypes = []any{
 	(EntityType)(0),                 // 0: entity.v1.EntityType
 	(ThreatLevel)(0),                // 1: entity.v1.ThreatLevel
 	(ApprovalState)(0),              // 2: entity.v1.ApprovalState
-	(*Entity)(nil),                  // 3: entity.v1.Entity
-	(*PositionComponent)(nil),       // 4: entity.v1.PositionComponent
-	(*VelocityComponent)(nil),       // 5: entity.v1.VelocityComponent
-	(*ClassificationComponent)(nil), // 6: entity.v1.ClassificationComponent
-	(*TaskCatalogComponent)(nil),    // 7: entity.v1.TaskCatalogComponent
-	(*ThreatComponent)(nil),         // 8: entity.v1.ThreatComponent
-	(*ApprovalComponent)(nil),       // 9: entity.v1.ApprovalComponent
-	(*FusionComponent)(nil),         // 10: entity.v1.FusionComponent
-	(*SourceComponent)(nil),         // 11: entity.v1.SourceComponent
-	nil,                             // 12: entity.v1.Entity.ComponentsEntry
-	(*timestamppb.Timestamp)(nil),   // 13: google.protobuf.Timestamp
-	(*anypb.Any)(nil),               // 14: google.protobuf.Any
+	(Domain)(0),                     // 3: entity.v1.Domain
+	(TaskStatus)(0),                 // 4: entity.v1.TaskStatus
+	(AssetAvailability)(0),          // 5: entity.v1.AssetAvailability
+	(IFFStatus)(0),                  // 6: entity.v1.IFFStatus
+	(GeoKind)(0),                    // 7: entity.v1.GeoKind
+	(*Entity)(nil),                  // 8: entity.v1.Entity
+	(*HLCTimestamp)(nil),            // 9: entity.v1.HLCTimestamp
+	(*ComponentWrite)(nil),          // 10: entity.v1.ComponentWrite
+	(*ComponentWrites)(nil),         // 11: entity.v1.ComponentWrites
+	(*PositionComponent)(nil),       // 12: entity.v1.PositionComponent
+	(*VelocityComponent)(nil),       // 13: entity.v1.VelocityComponent
+	(*ClassificationComponent)(nil), // 14: entity.v1.ClassificationComponent
+	(*TaskCatalogComponent)(nil),    // 15: entity.v1.TaskCatalogComponent
+	(*ThreatComponent)(nil),         // 16: entity.v1.ThreatComponent
+	(*ApprovalComponent)(nil),       // 17: entity.v1.ApprovalComponent
+	(*CounterComponent)(nil),        // 18: entity.v1.CounterComponent
+	(*FusionComponent)(nil),         // 19: entity.v1.FusionComponent
+	(*SourceComponent)(nil),         // 20: entity.v1.SourceComponent
+	(*AssignmentComponent)(nil),     // 21: entity.v1.AssignmentComponent
+	(*AvailabilityComponent)(nil),   // 22: entity.v1.AvailabilityComponent
+	(*IFFComponent)(nil),            // 23: entity.v1.IFFComponent
+	(*GeoPoint)(nil),                // 24: entity.v1.GeoPoint
+	(*GeoComponent)(nil),            // 25: entity.v1.GeoComponent
+	(*AssetComponent)(nil),          // 26: entity.v1.AssetComponent
+	(*MeshHealthComponent)(nil),     // 27: entity.v1.MeshHealthComponent
+	nil,                             // 28: entity.v1.Entity.ComponentsEntry
+	nil,                             // 29: entity.v1.Entity.ComponentHlcEntry
+	nil,                             // 30: entity.v1.Entity.LabelsEntry
+	nil,                             // 31: entity.v1.Entity.RemovedComponentsEntry
+	nil,                             // 32: entity.v1.Entity.ComponentWritesEntry
+	nil,                             // 33: entity.v1.CounterComponent.IncrementsEntry
+	nil,                             // 34: entity.v1.CounterComponent.DecrementsEntry
+	(*timestamppb.Timestamp)(nil),   // 35: google.protobuf.Timestamp
+	(*anypb.Any)(nil),               // 36: google.protobuf.Any
 }
 var file_entity_v1_entity_proto_depIdxs = []int32{
 	0,  // 0: entity.v1.Entity.type:type_name -> entity.v1.EntityType
-	12, // 1: entity.v1.Entity.components:type_name -> entity.v1.Entity.ComponentsEntry
-	13, // 2: entity.v1.Entity.created_at:type_name -> google.protobuf.Timestamp
-	13, // 3: entity.v1.Entity.updated_at:type_name -> google.protobuf.Timestamp
-	1,  // 4: entity.v1.ThreatComponent.level:type_name -> entity.v1.ThreatLevel
-	2,  // 5: entity.v1.ApprovalComponent.state:type_name -> entity.v1.ApprovalState
-	13, // 6: entity.v1.ApprovalComponent.requested_at:type_name -> google.protobuf.Timestamp
-	14, // 7: entity.v1.Entity.ComponentsEntry.value:type_name -> google.protobuf.Any
-	8,  // [8:8] is the sub-list for method output_type
-	8,  // [8:8] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	28, // 1: entity.v1.Entity.components:type_name -> entity.v1.Entity.ComponentsEntry
+	35, // 2: entity.v1.Entity.created_at:type_name -> google.protobuf.Timestamp
+	35, // 3: entity.v1.Entity.updated_at:type_name -> google.protobuf.Timestamp
+	29, // 4: entity.v1.Entity.component_hlc:type_name -> entity.v1.Entity.ComponentHlcEntry
+	30, // 5: entity.v1.Entity.labels:type_name -> entity.v1.Entity.LabelsEntry
+	31, // 6: entity.v1.Entity.removed_components:type_name -> entity.v1.Entity.RemovedComponentsEntry
+	9,  // 7: entity.v1.Entity.labels_hlc:type_name -> entity.v1.HLCTimestamp
+	32, // 8: entity.v1.Entity.component_writes:type_name -> entity.v1.Entity.ComponentWritesEntry
+	36, // 9: entity.v1.ComponentWrite.value:type_name -> google.protobuf.Any
+	9,  // 10: entity.v1.ComponentWrite.hlc:type_name -> entity.v1.HLCTimestamp
+	10, // 11: entity.v1.ComponentWrites.writes:type_name -> entity.v1.ComponentWrite
+	1,  // 12: entity.v1.ThreatComponent.level:type_name -> entity.v1.ThreatLevel
+	2,  // 13: entity.v1.ApprovalComponent.state:type_name -> entity.v1.ApprovalState
+	35, // 14: entity.v1.ApprovalComponent.requested_at:type_name -> google.protobuf.Timestamp
+	33, // 15: entity.v1.CounterComponent.increments:type_name -> entity.v1.CounterComponent.IncrementsEntry
+	34, // 16: entity.v1.CounterComponent.decrements:type_name -> entity.v1.CounterComponent.DecrementsEntry
+	3,  // 17: entity.v1.SourceComponent.domain:type_name -> entity.v1.Domain
+	4,  // 18: entity.v1.AssignmentComponent.status:type_name -> entity.v1.TaskStatus
+	35, // 19: entity.v1.AssignmentComponent.updated_at:type_name -> google.protobuf.Timestamp
+	5,  // 20: entity.v1.AvailabilityComponent.state:type_name -> entity.v1.AssetAvailability
+	6,  // 21: entity.v1.IFFComponent.status:type_name -> entity.v1.IFFStatus
+	7,  // 22: entity.v1.GeoComponent.kind:type_name -> entity.v1.GeoKind
+	24, // 23: entity.v1.GeoComponent.points:type_name -> entity.v1.GeoPoint
+	35, // 24: entity.v1.MeshHealthComponent.since:type_name -> google.protobuf.Timestamp
+	36, // 25: entity.v1.Entity.ComponentsEntry.value:type_name -> google.protobuf.Any
+	9,  // 26: entity.v1.Entity.ComponentHlcEntry.value:type_name -> entity.v1.HLCTimestamp
+	9,  // 27: entity.v1.Entity.RemovedComponentsEntry.value:type_name -> entity.v1.HLCTimestamp
+	11, // 28: entity.v1.Entity.ComponentWritesEntry.value:type_name -> entity.v1.ComponentWrites
+	29, // [29:29] is the sub-list for method output_type
+	29, // [29:29] is the sub-list for method input_type
+	29, // [29:29] is the sub-list for extension type_name
+	29, // [29:29] is the sub-list for extension extendee
+	0,  // [0:29] is the sub-list for field type_name
 }
 
 func init() { file_entity_v1_entity_proto_init() }
@@ -851,8 +2036,8 @@ func file_entity_v1_entity_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_entity_v1_entity_proto_rawDesc), len(file_entity_v1_entity_proto_rawDesc)),
-			NumEnums:      3,
-			NumMessages:   10,
+			NumEnums:      8,
+			NumMessages:   27,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -0,0 +1,755 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: mesh/v1/mesh.proto
+
+package meshv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type AddPeerRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Addr  string                 `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	// If set, restore a snapshot of the local store to the peer once the
+	// relay is watching for it, so it starts with the full entity set.
+	Sync          bool `protobuf:"varint,2,opt,name=sync,proto3" json:"sync,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddPeerRequest) Reset() {
+	*x = AddPeerRequest{}
+	mi := &file_mesh_v1_mesh_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddPeerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddPeerRequest) ProtoMessage() {}
+
+func (x *AddPeerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mesh_v1_mesh_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddPeerRequest.ProtoReflect.Descriptor instead.
+func (*AddPeerRequest) Descriptor() ([]byte, []int) {
+	return file_mesh_v1_mesh_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *AddPeerRequest) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *AddPeerRequest) GetSync() bool {
+	if x != nil {
+		return x.Sync
+	}
+	return false
+}
+
+type RemovePeerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Addr          string                 `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemovePeerRequest) Reset() {
+	*x = RemovePeerRequest{}
+	mi := &file_mesh_v1_mesh_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemovePeerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemovePeerRequest) ProtoMessage() {}
+
+func (x *RemovePeerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mesh_v1_mesh_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemovePeerRequest.ProtoReflect.Descriptor instead.
+func (*RemovePeerRequest) Descriptor() ([]byte, []int) {
+	return file_mesh_v1_mesh_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RemovePeerRequest) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+type ListPeersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPeersRequest) Reset() {
+	*x = ListPeersRequest{}
+	mi := &file_mesh_v1_mesh_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPeersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPeersRequest) ProtoMessage() {}
+
+func (x *ListPeersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mesh_v1_mesh_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPeersRequest.ProtoReflect.Descriptor instead.
+func (*ListPeersRequest) Descriptor() ([]byte, []int) {
+	return file_mesh_v1_mesh_proto_rawDescGZIP(), []int{2}
+}
+
+type ListPeersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Peers         []*Peer                `protobuf:"bytes,1,rep,name=peers,proto3" json:"peers,omitempty"` // by address
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListPeersResponse) Reset() {
+	*x = ListPeersResponse{}
+	mi := &file_mesh_v1_mesh_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListPeersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListPeersResponse) ProtoMessage() {}
+
+func (x *ListPeersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mesh_v1_mesh_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListPeersResponse.ProtoReflect.Descriptor instead.
+func (*ListPeersResponse) Descriptor() ([]byte, []int) {
+	return file_mesh_v1_mesh_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListPeersResponse) GetPeers() []*Peer {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+type Peer struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Addr  string                 `protobuf:"bytes,1,opt,name=addr,proto3" json:"addr,omitempty"`
+	// The connection's state: IDLE, CONNECTING, READY, TRANSIENT_FAILURE, or
+	// SHUTDOWN; empty before the relay runs.
+	State string `protobuf:"bytes,2,opt,name=state,proto3" json:"state,omitempty"`
+	// Entities whose events wait in the peer's outbox.
+	Queued int32 `protobuf:"varint,3,opt,name=queued,proto3" json:"queued,omitempty"`
+	// The class of the peer's configured link, lan, wan, or satcom; empty if
+	// it has none.
+	Link      string `protobuf:"bytes,4,opt,name=link,proto3" json:"link,omitempty"`
+	Forwarded int64  `protobuf:"varint,5,opt,name=forwarded,proto3" json:"forwarded,omitempty"`
+	// Events dropped by the peer's bandwidth budget.
+	Dropped int64 `protobuf:"varint,6,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	// Forwarded entities' size on the wire, compressed if the relay
+	// compresses.
+	SentBytes int64 `protobuf:"varint,7,opt,name=sent_bytes,json=sentBytes,proto3" json:"sent_bytes,omitempty"`
+	// The peer's bandwidth budget in bytes per second, 0 if unlimited, and
+	// the bytes it can take now. shared_budget is set if the budget is the
+	// relay's, shared by every peer without a link of its own.
+	BudgetBps       float64 `protobuf:"fixed64,8,opt,name=budget_bps,json=budgetBps,proto3" json:"budget_bps,omitempty"`
+	BudgetAvailable float64 `protobuf:"fixed64,9,opt,name=budget_available,json=budgetAvailable,proto3" json:"budget_available,omitempty"`
+	SharedBudget    bool    `protobuf:"varint,10,opt,name=shared_budget,json=sharedBudget,proto3" json:"shared_budget,omitempty"`
+	// What the last anti-entropy pass with the peer found: entities only one
+	// store had or whose copies differed, and the component conflicts among
+	// them, by how they were resolved. All zero once converged.
+	Diverged        int32 `protobuf:"varint,11,opt,name=diverged,proto3" json:"diverged,omitempty"`
+	ResolvedLww     int32 `protobuf:"varint,12,opt,name=resolved_lww,json=resolvedLww,proto3" json:"resolved_lww,omitempty"`
+	ResolvedMaxWins int32 `protobuf:"varint,13,opt,name=resolved_max_wins,json=resolvedMaxWins,proto3" json:"resolved_max_wins,omitempty"`
+	// When that pass finished; unset before the first.
+	ReconciledAt *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=reconciled_at,json=reconciledAt,proto3" json:"reconciled_at,omitempty"`
+	// Conflicts resolved by strategies other than lww and max-wins.
+	ResolvedOther int32 `protobuf:"varint,15,opt,name=resolved_other,json=resolvedOther,proto3" json:"resolved_other,omitempty"`
+	// How far the peer's wall clock is ahead of the local store's, negative
+	// if behind, as of the last heartbeat it answered; unset before the
+	// first. clock_skewed is set if that is further than the relay's max
+	// skew.
+	ClockOffset   *durationpb.Duration `protobuf:"bytes,16,opt,name=clock_offset,json=clockOffset,proto3" json:"clock_offset,omitempty"`
+	ClockSkewed   bool                 `protobuf:"varint,17,opt,name=clock_skewed,json=clockSkewed,proto3" json:"clock_skewed,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Peer) Reset() {
+	*x = Peer{}
+	mi := &file_mesh_v1_mesh_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Peer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Peer) ProtoMessage() {}
+
+func (x *Peer) ProtoReflect() protoreflect.Message {
+	mi := &file_mesh_v1_mesh_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Peer.ProtoReflect.Descriptor instead.
+func (*Peer) Descriptor() ([]byte, []int) {
+	return file_mesh_v1_mesh_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Peer) GetAddr() string {
+	if x != nil {
+		return x.Addr
+	}
+	return ""
+}
+
+func (x *Peer) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *Peer) GetQueued() int32 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+func (x *Peer) GetLink() string {
+	if x != nil {
+		return x.Link
+	}
+	return ""
+}
+
+func (x *Peer) GetForwarded() int64 {
+	if x != nil {
+		return x.Forwarded
+	}
+	return 0
+}
+
+func (x *Peer) GetDropped() int64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *Peer) GetSentBytes() int64 {
+	if x != nil {
+		return x.SentBytes
+	}
+	return 0
+}
+
+func (x *Peer) GetBudgetBps() float64 {
+	if x != nil {
+		return x.BudgetBps
+	}
+	return 0
+}
+
+func (x *Peer) GetBudgetAvailable() float64 {
+	if x != nil {
+		return x.BudgetAvailable
+	}
+	return 0
+}
+
+func (x *Peer) GetSharedBudget() bool {
+	if x != nil {
+		return x.SharedBudget
+	}
+	return false
+}
+
+func (x *Peer) GetDiverged() int32 {
+	if x != nil {
+		return x.Diverged
+	}
+	return 0
+}
+
+func (x *Peer) GetResolvedLww() int32 {
+	if x != nil {
+		return x.ResolvedLww
+	}
+	return 0
+}
+
+func (x *Peer) GetResolvedMaxWins() int32 {
+	if x != nil {
+		return x.ResolvedMaxWins
+	}
+	return 0
+}
+
+func (x *Peer) GetReconciledAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ReconciledAt
+	}
+	return nil
+}
+
+func (x *Peer) GetResolvedOther() int32 {
+	if x != nil {
+		return x.ResolvedOther
+	}
+	return 0
+}
+
+func (x *Peer) GetClockOffset() *durationpb.Duration {
+	if x != nil {
+		return x.ClockOffset
+	}
+	return nil
+}
+
+func (x *Peer) GetClockSkewed() bool {
+	if x != nil {
+		return x.ClockSkewed
+	}
+	return false
+}
+
+type GetStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_mesh_v1_mesh_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_mesh_v1_mesh_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_mesh_v1_mesh_proto_rawDescGZIP(), []int{5}
+}
+
+type GetStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	NodeId        string                 `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	Stats         *RelayStats            `protobuf:"bytes,2,opt,name=stats,proto3" json:"stats,omitempty"`
+	Peers         []*Peer                `protobuf:"bytes,3,rep,name=peers,proto3" json:"peers,omitempty"` // by address
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusResponse) Reset() {
+	*x = GetStatusResponse{}
+	mi := &file_mesh_v1_mesh_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusResponse) ProtoMessage() {}
+
+func (x *GetStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_mesh_v1_mesh_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetStatusResponse) Descriptor() ([]byte, []int) {
+	return file_mesh_v1_mesh_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *GetStatusResponse) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *GetStatusResponse) GetStats() *RelayStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+func (x *GetStatusResponse) GetPeers() []*Peer {
+	if x != nil {
+		return x.Peers
+	}
+	return nil
+}
+
+// RelayStats are a relay's counters since it started, over every peer.
+type RelayStats struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Forwarded int64                  `protobuf:"varint,1,opt,name=forwarded,proto3" json:"forwarded,omitempty"`
+	Errors    int64                  `protobuf:"varint,2,opt,name=errors,proto3" json:"errors,omitempty"`
+	// Forwarded entities CRDT-merged with the peer's copy.
+	Merged int64 `protobuf:"varint,3,opt,name=merged,proto3" json:"merged,omitempty"`
+	// Events dropped by bandwidth budgets.
+	Dropped int64 `protobuf:"varint,4,opt,name=dropped,proto3" json:"dropped,omitempty"`
+	// Entities anti-entropy wrote to the local store or a peer.
+	Repaired int64 `protobuf:"varint,5,opt,name=repaired,proto3" json:"repaired,omitempty"`
+	// Events evicted from full peer outboxes.
+	Evicted int64 `protobuf:"varint,6,opt,name=evicted,proto3" json:"evicted,omitempty"`
+	// Events the replication policy kept from peers.
+	Filtered int64 `protobuf:"varint,7,opt,name=filtered,proto3" json:"filtered,omitempty"`
+	// Anti-entropy's findings summed over its passes with every peer:
+	// entities that had diverged, and component conflicts resolved
+	// last-writer-wins, max-wins, and by any other strategy.
+	Diverged        int64 `protobuf:"varint,8,opt,name=diverged,proto3" json:"diverged,omitempty"`
+	ResolvedLww     int64 `protobuf:"varint,9,opt,name=resolved_lww,json=resolvedLww,proto3" json:"resolved_lww,omitempty"`
+	ResolvedMaxWins int64 `protobuf:"varint,10,opt,name=resolved_max_wins,json=resolvedMaxWins,proto3" json:"resolved_max_wins,omitempty"`
+	ResolvedOther   int64 `protobuf:"varint,11,opt,name=resolved_other,json=resolvedOther,proto3" json:"resolved_other,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RelayStats) Reset() {
+	*x = RelayStats{}
+	mi := &file_mesh_v1_mesh_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelayStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelayStats) ProtoMessage() {}
+
+func (x *RelayStats) ProtoReflect() protoreflect.Message {
+	mi := &file_mesh_v1_mesh_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelayStats.ProtoReflect.Descriptor instead.
+func (*RelayStats) Descriptor() ([]byte, []int) {
+	return file_mesh_v1_mesh_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RelayStats) GetForwarded() int64 {
+	if x != nil {
+		return x.Forwarded
+	}
+	return 0
+}
+
+func (x *RelayStats) GetErrors() int64 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *RelayStats) GetMerged() int64 {
+	if x != nil {
+		return x.Merged
+	}
+	return 0
+}
+
+func (x *RelayStats) GetDropped() int64 {
+	if x != nil {
+		return x.Dropped
+	}
+	return 0
+}
+
+func (x *RelayStats) GetRepaired() int64 {
+	if x != nil {
+		return x.Repaired
+	}
+	return 0
+}
+
+func (x *RelayStats) GetEvicted() int64 {
+	if x != nil {
+		return x.Evicted
+	}
+	return 0
+}
+
+func (x *RelayStats) GetFiltered() int64 {
+	if x != nil {
+		return x.Filtered
+	}
+	return 0
+}
+
+func (x *RelayStats) GetDiverged() int64 {
+	if x != nil {
+		return x.Diverged
+	}
+	return 0
+}
+
+func (x *RelayStats) GetResolvedLww() int64 {
+	if x != nil {
+		return x.ResolvedLww
+	}
+	return 0
+}
+
+func (x *RelayStats) GetResolvedMaxWins() int64 {
+	if x != nil {
+		return x.ResolvedMaxWins
+	}
+	return 0
+}
+
+func (x *RelayStats) GetResolvedOther() int64 {
+	if x != nil {
+		return x.ResolvedOther
+	}
+	return 0
+}
+
+var File_mesh_v1_mesh_proto protoreflect.FileDescriptor
+
+const file_mesh_v1_mesh_proto_rawDesc = "" +
+	"\n" +
+	"\x12mesh/v1/mesh.proto\x12\amesh.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"8\n" +
+	"\x0eAddPeerRequest\x12\x12\n" +
+	"\x04addr\x18\x01 \x01(\tR\x04addr\x12\x12\n" +
+	"\x04sync\x18\x02 \x01(\bR\x04sync\"'\n" +
+	"\x11RemovePeerRequest\x12\x12\n" +
+	"\x04addr\x18\x01 \x01(\tR\x04addr\"\x12\n" +
+	"\x10ListPeersRequest\"8\n" +
+	"\x11ListPeersResponse\x12#\n" +
+	"\x05peers\x18\x01 \x03(\v2\r.mesh.v1.PeerR\x05peers\"\xd6\x04\n" +
+	"\x04Peer\x12\x12\n" +
+	"\x04addr\x18\x01 \x01(\tR\x04addr\x12\x14\n" +
+	"\x05state\x18\x02 \x01(\tR\x05state\x12\x16\n" +
+	"\x06queued\x18\x03 \x01(\x05R\x06queued\x12\x12\n" +
+	"\x04link\x18\x04 \x01(\tR\x04link\x12\x1c\n" +
+	"\tforwarded\x18\x05 \x01(\x03R\tforwarded\x12\x18\n" +
+	"\adropped\x18\x06 \x01(\x03R\adropped\x12\x1d\n" +
+	"\n" +
+	"sent_bytes\x18\a \x01(\x03R\tsentBytes\x12\x1d\n" +
+	"\n" +
+	"budget_bps\x18\b \x01(\x01R\tbudgetBps\x12)\n" +
+	"\x10budget_available\x18\t \x01(\x01R\x0fbudgetAvailable\x12#\n" +
+	"\rshared_budget\x18\n" +
+	" \x01(\bR\fsharedBudget\x12\x1a\n" +
+	"\bdiverged\x18\v \x01(\x05R\bdiverged\x12!\n" +
+	"\fresolved_lww\x18\f \x01(\x05R\vresolvedLww\x12*\n" +
+	"\x11resolved_max_wins\x18\r \x01(\x05R\x0fresolvedMaxWins\x12?\n" +
+	"\rreconciled_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\freconciledAt\x12%\n" +
+	"\x0eresolved_other\x18\x0f \x01(\x05R\rresolvedOther\x12<\n" +
+	"\fclock_offset\x18\x10 \x01(\v2\x19.google.protobuf.DurationR\vclockOffset\x12!\n" +
+	"\fclock_skewed\x18\x11 \x01(\bR\vclockSkewed\"\x12\n" +
+	"\x10GetStatusRequest\"|\n" +
+	"\x11GetStatusResponse\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12)\n" +
+	"\x05stats\x18\x02 \x01(\v2\x13.mesh.v1.RelayStatsR\x05stats\x12#\n" +
+	"\x05peers\x18\x03 \x03(\v2\r.mesh.v1.PeerR\x05peers\"\xd8\x02\n" +
+	"\n" +
+	"RelayStats\x12\x1c\n" +
+	"\tforwarded\x18\x01 \x01(\x03R\tforwarded\x12\x16\n" +
+	"\x06errors\x18\x02 \x01(\x03R\x06errors\x12\x16\n" +
+	"\x06merged\x18\x03 \x01(\x03R\x06merged\x12\x18\n" +
+	"\adropped\x18\x04 \x01(\x03R\adropped\x12\x1a\n" +
+	"\brepaired\x18\x05 \x01(\x03R\brepaired\x12\x18\n" +
+	"\aevicted\x18\x06 \x01(\x03R\aevicted\x12\x1a\n" +
+	"\bfiltered\x18\a \x01(\x03R\bfiltered\x12\x1a\n" +
+	"\bdiverged\x18\b \x01(\x03R\bdiverged\x12!\n" +
+	"\fresolved_lww\x18\t \x01(\x03R\vresolvedLww\x12*\n" +
+	"\x11resolved_max_wins\x18\n" +
+	" \x01(\x03R\x0fresolvedMaxWins\x12%\n" +
+	"\x0eresolved_other\x18\v \x01(\x03R\rresolvedOther2\x99\x02\n" +
+	"\x11RelayAdminService\x12:\n" +
+	"\aAddPeer\x12\x17.mesh.v1.AddPeerRequest\x1a\x16.google.protobuf.Empty\x12@\n" +
+	"\n" +
+	"RemovePeer\x12\x1a.mesh.v1.RemovePeerRequest\x1a\x16.google.protobuf.Empty\x12B\n" +
+	"\tListPeers\x12\x19.mesh.v1.ListPeersRequest\x1a\x1a.mesh.v1.ListPeersResponse\x12B\n" +
+	"\tGetStatus\x12\x19.mesh.v1.GetStatusRequest\x1a\x1a.mesh.v1.GetStatusResponseB2Z0github.com/boshu2/lattice-lab/gen/mesh/v1;meshv1b\x06proto3"
+
+var (
+	file_mesh_v1_mesh_proto_rawDescOnce sync.Once
+	file_mesh_v1_mesh_proto_rawDescData []byte
+)
+
+func file_mesh_v1_mesh_proto_rawDescGZIP() []byte {
+	file_mesh_v1_mesh_proto_rawDescOnce.Do(func() {
+		file_mesh_v1_mesh_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_mesh_v1_mesh_proto_rawDesc), len(file_mesh_v1_mesh_proto_rawDesc)))
+	})
+	return file_mesh_v1_mesh_proto_rawDescData
+}
+
+var file_mesh_v1_mesh_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_mesh_v1_mesh_proto_goTypes = []any{
+	(*AddPeerRequest)(nil),        // 0: mesh.v1.AddPeerRequest
+	(*RemovePeerRequest)(nil),     // 1: mesh.v1.RemovePeerRequest
+	(*ListPeersRequest)(nil),      // 2: mesh.v1.ListPeersRequest
+	(*ListPeersResponse)(nil),     // 3: mesh.v1.ListPeersResponse
+	(*Peer)(nil),                  // 4: mesh.v1.Peer
+	(*GetStatusRequest)(nil),      // 5: mesh.v1.GetStatusRequest
+	(*GetStatusResponse)(nil),     // 6: mesh.v1.GetStatusResponse
+	(*RelayStats)(nil),            // 7: mesh.v1.RelayStats
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 9: google.protobuf.Duration
+	(*emptypb.Empty)(nil),         // 10: google.protobuf.Empty
+}
+var file_mesh_v1_mesh_proto_depIdxs = []int32{
+	4,  // 0: mesh.v1.ListPeersResponse.peers:type_name -> mesh.v1.Peer
+	8,  // 1: mesh.v1.Peer.reconciled_at:type_name -> google.protobuf.Timestamp
+	9,  // 2: mesh.v1.Peer.clock_offset:type_name -> google.protobuf.Duration
+	7,  // 3: mesh.v1.GetStatusResponse.stats:type_name -> mesh.v1.RelayStats
+	4,  // 4: mesh.v1.GetStatusResponse.peers:type_name -> mesh.v1.Peer
+	0,  // 5: mesh.v1.RelayAdminService.AddPeer:input_type -> mesh.v1.AddPeerRequest
+	1,  // 6: mesh.v1.RelayAdminService.RemovePeer:input_type -> mesh.v1.RemovePeerRequest
+	2,  // 7: mesh.v1.RelayAdminService.ListPeers:input_type -> mesh.v1.ListPeersRequest
+	5,  // 8: mesh.v1.RelayAdminService.GetStatus:input_type -> mesh.v1.GetStatusRequest
+	10, // 9: mesh.v1.RelayAdminService.AddPeer:output_type -> google.protobuf.Empty
+	10, // 10: mesh.v1.RelayAdminService.RemovePeer:output_type -> google.protobuf.Empty
+	3,  // 11: mesh.v1.RelayAdminService.ListPeers:output_type -> mesh.v1.ListPeersResponse
+	6,  // 12: mesh.v1.RelayAdminService.GetStatus:output_type -> mesh.v1.GetStatusResponse
+	9,  // [9:13] is the sub-list for method output_type
+	5,  // [5:9] is the sub-list for method input_type
+	5,  // [5:5] is the sub-list for extension type_name
+	5,  // [5:5] is the sub-list for extension extendee
+	0,  // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_mesh_v1_mesh_proto_init() }
+func file_mesh_v1_mesh_proto_init() {
+	if File_mesh_v1_mesh_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_mesh_v1_mesh_proto_rawDesc), len(file_mesh_v1_mesh_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_mesh_v1_mesh_proto_goTypes,
+		DependencyIndexes: file_mesh_v1_mesh_proto_depIdxs,
+		MessageInfos:      file_mesh_v1_mesh_proto_msgTypes,
+	}.Build()
+	File_mesh_v1_mesh_proto = out.File
+	file_mesh_v1_mesh_proto_goTypes = nil
+	file_mesh_v1_mesh_proto_depIdxs = nil
+}
@@ -0,0 +1,484 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: registry/v1/registry.proto
+
+package registryv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	descriptorpb "google.golang.org/protobuf/types/descriptorpb"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Schema describes one component type.
+type Schema struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// e.g. "type.googleapis.com/acme.v1.Widget"; the message name is the part
+	// after the last "/".
+	TypeUrl string `protobuf:"bytes,1,opt,name=type_url,json=typeUrl,proto3" json:"type_url,omitempty"`
+	// The message's file and its imports, as written by
+	// `protoc --include_imports --descriptor_set_out` or `buf build`. May be
+	// empty for types compiled into the store, such as the entity.v1 components.
+	Descriptors   *descriptorpb.FileDescriptorSet `protobuf:"bytes,2,opt,name=descriptors,proto3" json:"descriptors,omitempty"`
+	Rules         []*FieldRule                    `protobuf:"bytes,3,rep,name=rules,proto3" json:"rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Schema) Reset() {
+	*x = Schema{}
+	mi := &file_registry_v1_registry_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Schema) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Schema) ProtoMessage() {}
+
+func (x *Schema) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_v1_registry_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Schema.ProtoReflect.Descriptor instead.
+func (*Schema) Descriptor() ([]byte, []int) {
+	return file_registry_v1_registry_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Schema) GetTypeUrl() string {
+	if x != nil {
+		return x.TypeUrl
+	}
+	return ""
+}
+
+func (x *Schema) GetDescriptors() *descriptorpb.FileDescriptorSet {
+	if x != nil {
+		return x.Descriptors
+	}
+	return nil
+}
+
+func (x *Schema) GetRules() []*FieldRule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+// FieldRule constrains one top-level field of the message.
+type FieldRule struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Field string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	// The field must be set: non-zero for scalars, non-empty for lists and
+	// maps.
+	Required bool `protobuf:"varint,2,opt,name=required,proto3" json:"required,omitempty"`
+	// Inclusive bounds for numeric fields.
+	Min *float64 `protobuf:"fixed64,3,opt,name=min,proto3,oneof" json:"min,omitempty"`
+	Max *float64 `protobuf:"fixed64,4,opt,name=max,proto3,oneof" json:"max,omitempty"`
+	// Regular expression string fields must match.
+	Pattern       string `protobuf:"bytes,5,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldRule) Reset() {
+	*x = FieldRule{}
+	mi := &file_registry_v1_registry_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldRule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldRule) ProtoMessage() {}
+
+func (x *FieldRule) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_v1_registry_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldRule.ProtoReflect.Descriptor instead.
+func (*FieldRule) Descriptor() ([]byte, []int) {
+	return file_registry_v1_registry_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *FieldRule) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FieldRule) GetRequired() bool {
+	if x != nil {
+		return x.Required
+	}
+	return false
+}
+
+func (x *FieldRule) GetMin() float64 {
+	if x != nil && x.Min != nil {
+		return *x.Min
+	}
+	return 0
+}
+
+func (x *FieldRule) GetMax() float64 {
+	if x != nil && x.Max != nil {
+		return *x.Max
+	}
+	return 0
+}
+
+func (x *FieldRule) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+type RegisterSchemaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schema        *Schema                `protobuf:"bytes,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RegisterSchemaRequest) Reset() {
+	*x = RegisterSchemaRequest{}
+	mi := &file_registry_v1_registry_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RegisterSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RegisterSchemaRequest) ProtoMessage() {}
+
+func (x *RegisterSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_v1_registry_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RegisterSchemaRequest.ProtoReflect.Descriptor instead.
+func (*RegisterSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_registry_v1_registry_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RegisterSchemaRequest) GetSchema() *Schema {
+	if x != nil {
+		return x.Schema
+	}
+	return nil
+}
+
+type GetSchemaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TypeUrl       string                 `protobuf:"bytes,1,opt,name=type_url,json=typeUrl,proto3" json:"type_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetSchemaRequest) Reset() {
+	*x = GetSchemaRequest{}
+	mi := &file_registry_v1_registry_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetSchemaRequest) ProtoMessage() {}
+
+func (x *GetSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_v1_registry_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetSchemaRequest.ProtoReflect.Descriptor instead.
+func (*GetSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_registry_v1_registry_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetSchemaRequest) GetTypeUrl() string {
+	if x != nil {
+		return x.TypeUrl
+	}
+	return ""
+}
+
+type ListSchemasRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSchemasRequest) Reset() {
+	*x = ListSchemasRequest{}
+	mi := &file_registry_v1_registry_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSchemasRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSchemasRequest) ProtoMessage() {}
+
+func (x *ListSchemasRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_v1_registry_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSchemasRequest.ProtoReflect.Descriptor instead.
+func (*ListSchemasRequest) Descriptor() ([]byte, []int) {
+	return file_registry_v1_registry_proto_rawDescGZIP(), []int{4}
+}
+
+type ListSchemasResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Schemas       []*Schema              `protobuf:"bytes,1,rep,name=schemas,proto3" json:"schemas,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSchemasResponse) Reset() {
+	*x = ListSchemasResponse{}
+	mi := &file_registry_v1_registry_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSchemasResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSchemasResponse) ProtoMessage() {}
+
+func (x *ListSchemasResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_v1_registry_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSchemasResponse.ProtoReflect.Descriptor instead.
+func (*ListSchemasResponse) Descriptor() ([]byte, []int) {
+	return file_registry_v1_registry_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListSchemasResponse) GetSchemas() []*Schema {
+	if x != nil {
+		return x.Schemas
+	}
+	return nil
+}
+
+type DeleteSchemaRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TypeUrl       string                 `protobuf:"bytes,1,opt,name=type_url,json=typeUrl,proto3" json:"type_url,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteSchemaRequest) Reset() {
+	*x = DeleteSchemaRequest{}
+	mi := &file_registry_v1_registry_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteSchemaRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteSchemaRequest) ProtoMessage() {}
+
+func (x *DeleteSchemaRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_registry_v1_registry_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteSchemaRequest.ProtoReflect.Descriptor instead.
+func (*DeleteSchemaRequest) Descriptor() ([]byte, []int) {
+	return file_registry_v1_registry_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteSchemaRequest) GetTypeUrl() string {
+	if x != nil {
+		return x.TypeUrl
+	}
+	return ""
+}
+
+var File_registry_v1_registry_proto protoreflect.FileDescriptor
+
+const file_registry_v1_registry_proto_rawDesc = "" +
+	"\n" +
+	"\x1aregistry/v1/registry.proto\x12\vregistry.v1\x1a google/protobuf/descriptor.proto\x1a\x1bgoogle/protobuf/empty.proto\"\x97\x01\n" +
+	"\x06Schema\x12\x19\n" +
+	"\btype_url\x18\x01 \x01(\tR\atypeUrl\x12D\n" +
+	"\vdescriptors\x18\x02 \x01(\v2\".google.protobuf.FileDescriptorSetR\vdescriptors\x12,\n" +
+	"\x05rules\x18\x03 \x03(\v2\x16.registry.v1.FieldRuleR\x05rules\"\x95\x01\n" +
+	"\tFieldRule\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x1a\n" +
+	"\brequired\x18\x02 \x01(\bR\brequired\x12\x15\n" +
+	"\x03min\x18\x03 \x01(\x01H\x00R\x03min\x88\x01\x01\x12\x15\n" +
+	"\x03max\x18\x04 \x01(\x01H\x01R\x03max\x88\x01\x01\x12\x18\n" +
+	"\apattern\x18\x05 \x01(\tR\apatternB\x06\n" +
+	"\x04_minB\x06\n" +
+	"\x04_max\"D\n" +
+	"\x15RegisterSchemaRequest\x12+\n" +
+	"\x06schema\x18\x01 \x01(\v2\x13.registry.v1.SchemaR\x06schema\"-\n" +
+	"\x10GetSchemaRequest\x12\x19\n" +
+	"\btype_url\x18\x01 \x01(\tR\atypeUrl\"\x14\n" +
+	"\x12ListSchemasRequest\"D\n" +
+	"\x13ListSchemasResponse\x12-\n" +
+	"\aschemas\x18\x01 \x03(\v2\x13.registry.v1.SchemaR\aschemas\"0\n" +
+	"\x13DeleteSchemaRequest\x12\x19\n" +
+	"\btype_url\x18\x01 \x01(\tR\atypeUrl2\xbf\x02\n" +
+	"\x15SchemaRegistryService\x12I\n" +
+	"\x0eRegisterSchema\x12\".registry.v1.RegisterSchemaRequest\x1a\x13.registry.v1.Schema\x12?\n" +
+	"\tGetSchema\x12\x1d.registry.v1.GetSchemaRequest\x1a\x13.registry.v1.Schema\x12P\n" +
+	"\vListSchemas\x12\x1f.registry.v1.ListSchemasRequest\x1a .registry.v1.ListSchemasResponse\x12H\n" +
+	"\fDeleteSchema\x12 .registry.v1.DeleteSchemaRequest\x1a\x16.google.protobuf.EmptyB:Z8github.com/boshu2/lattice-lab/gen/registry/v1;registryv1b\x06proto3"
+
+var (
+	file_registry_v1_registry_proto_rawDescOnce sync.Once
+	file_registry_v1_registry_proto_rawDescData []byte
+)
+
+func file_registry_v1_registry_proto_rawDescGZIP() []byte {
+	file_registry_v1_registry_proto_rawDescOnce.Do(func() {
+		file_registry_v1_registry_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_registry_v1_registry_proto_rawDesc), len(file_registry_v1_registry_proto_rawDesc)))
+	})
+	return file_registry_v1_registry_proto_rawDescData
+}
+
+var file_registry_v1_registry_proto_msgTypes = make([]protoimpl.MessageInfo, 7)
+var file_registry_v1_registry_proto_goTypes = []any{
+	(*Schema)(nil),                         // 0: registry.v1.Schema
+	(*FieldRule)(nil),                      // 1: registry.v1.FieldRule
+	(*RegisterSchemaRequest)(nil),          // 2: registry.v1.RegisterSchemaRequest
+	(*GetSchemaRequest)(nil),               // 3: registry.v1.GetSchemaRequest
+	(*ListSchemasRequest)(nil),             // 4: registry.v1.ListSchemasRequest
+	(*ListSchemasResponse)(nil),            // 5: registry.v1.ListSchemasResponse
+	(*DeleteSchemaRequest)(nil),            // 6: registry.v1.DeleteSchemaRequest
+	(*descriptorpb.FileDescriptorSet)(nil), // 7: google.protobuf.FileDescriptorSet
+	(*emptypb.Empty)(nil),                  // 8: google.protobuf.Empty
+}
+var file_registry_v1_registry_proto_depIdxs = []int32{
+	7, // 0: registry.v1.Schema.descriptors:type_name -> google.protobuf.FileDescriptorSet
+	1, // 1: registry.v1.Schema.rules:type_name -> registry.v1.FieldRule
+	0, // 2: registry.v1.RegisterSchemaRequest.schema:type_name -> registry.v1.Schema
+	0, // 3: registry.v1.ListSchemasResponse.schemas:type_name -> registry.v1.Schema
+	2, // 4: registry.v1.SchemaRegistryService.RegisterSchema:input_type -> registry.v1.RegisterSchemaRequest
+	3, // 5: registry.v1.SchemaRegistryService.GetSchema:input_type -> registry.v1.GetSchemaRequest
+	4, // 6: registry.v1.SchemaRegistryService.ListSchemas:input_type -> registry.v1.ListSchemasRequest
+	6, // 7: registry.v1.SchemaRegistryService.DeleteSchema:input_type -> registry.v1.DeleteSchemaRequest
+	0, // 8: registry.v1.SchemaRegistryService.RegisterSchema:output_type -> registry.v1.Schema
+	0, // 9: registry.v1.SchemaRegistryService.GetSchema:output_type -> registry.v1.Schema
+	5, // 10: registry.v1.SchemaRegistryService.ListSchemas:output_type -> registry.v1.ListSchemasResponse
+	8, // 11: registry.v1.SchemaRegistryService.DeleteSchema:output_type -> google.protobuf.Empty
+	8, // [8:12] is the sub-list for method output_type
+	4, // [4:8] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_registry_v1_registry_proto_init() }
+func file_registry_v1_registry_proto_init() {
+	if File_registry_v1_registry_proto != nil {
+		return
+	}
+	file_registry_v1_registry_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_registry_v1_registry_proto_rawDesc), len(file_registry_v1_registry_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   7,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_registry_v1_registry_proto_goTypes,
+		DependencyIndexes: file_registry_v1_registry_proto_depIdxs,
+		MessageInfos:      file_registry_v1_registry_proto_msgTypes,
+	}.Build()
+	File_registry_v1_registry_proto = out.File
+	file_registry_v1_registry_proto_goTypes = nil
+	file_registry_v1_registry_proto_depIdxs = nil
+}
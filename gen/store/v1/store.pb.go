@@ -10,7 +10,10 @@ import (
 	v1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	anypb "google.golang.org/protobuf/types/known/anypb"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -23,6 +26,67 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type FilterOp int32
+
+const (
+	FilterOp_FILTER_OP_UNSPECIFIED FilterOp = 0
+	FilterOp_FILTER_OP_EQ          FilterOp = 1
+	FilterOp_FILTER_OP_NE          FilterOp = 2
+	FilterOp_FILTER_OP_LT          FilterOp = 3
+	FilterOp_FILTER_OP_LE          FilterOp = 4
+	FilterOp_FILTER_OP_GT          FilterOp = 5
+	FilterOp_FILTER_OP_GE          FilterOp = 6
+)
+
+// Enum value maps for FilterOp.
+var (
+	FilterOp_name = map[int32]string{
+		0: "FILTER_OP_UNSPECIFIED",
+		1: "FILTER_OP_EQ",
+		2: "FILTER_OP_NE",
+		3: "FILTER_OP_LT",
+		4: "FILTER_OP_LE",
+		5: "FILTER_OP_GT",
+		6: "FILTER_OP_GE",
+	}
+	FilterOp_value = map[string]int32{
+		"FILTER_OP_UNSPECIFIED": 0,
+		"FILTER_OP_EQ":          1,
+		"FILTER_OP_NE":          2,
+		"FILTER_OP_LT":          3,
+		"FILTER_OP_LE":          4,
+		"FILTER_OP_GT":          5,
+		"FILTER_OP_GE":          6,
+	}
+)
+
+func (x FilterOp) Enum() *FilterOp {
+	p := new(FilterOp)
+	*p = x
+	return p
+}
+
+func (x FilterOp) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (FilterOp) Descriptor() protoreflect.EnumDescriptor {
+	return file_store_v1_store_proto_enumTypes[0].Descriptor()
+}
+
+func (FilterOp) Type() protoreflect.EnumType {
+	return &file_store_v1_store_proto_enumTypes[0]
+}
+
+func (x FilterOp) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use FilterOp.Descriptor instead.
+func (FilterOp) EnumDescriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{0}
+}
+
 type EventType int32
 
 const (
@@ -30,6 +94,9 @@ const (
 	EventType_EVENT_TYPE_CREATED     EventType = 1
 	EventType_EVENT_TYPE_UPDATED     EventType = 2
 	EventType_EVENT_TYPE_DELETED     EventType = 3
+	// The entity was removed because its ttl ran out, not by a delete. It is
+	// gone all the same: consumers that track entities treat it as DELETED.
+	EventType_EVENT_TYPE_EXPIRED EventType = 4
 )
 
 // Enum value maps for EventType.
@@ -39,12 +106,14 @@ var (
 		1: "EVENT_TYPE_CREATED",
 		2: "EVENT_TYPE_UPDATED",
 		3: "EVENT_TYPE_DELETED",
+		4: "EVENT_TYPE_EXPIRED",
 	}
 	EventType_value = map[string]int32{
 		"EVENT_TYPE_UNSPECIFIED": 0,
 		"EVENT_TYPE_CREATED":     1,
 		"EVENT_TYPE_UPDATED":     2,
 		"EVENT_TYPE_DELETED":     3,
+		"EVENT_TYPE_EXPIRED":     4,
 	}
 )
 
@@ -59,11 +128,11 @@ func (x EventType) String() string {
 }
 
 func (EventType) Descriptor() protoreflect.EnumDescriptor {
-	return file_store_v1_store_proto_enumTypes[0].Descriptor()
+	return file_store_v1_store_proto_enumTypes[1].Descriptor()
 }
 
 func (EventType) Type() protoreflect.EnumType {
-	return &file_store_v1_store_proto_enumTypes[0]
+	return &file_store_v1_store_proto_enumTypes[1]
 }
 
 func (x EventType) Number() protoreflect.EnumNumber {
@@ -72,12 +141,64 @@ func (x EventType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use EventType.Descriptor instead.
 func (EventType) EnumDescriptor() ([]byte, []int) {
-	return file_store_v1_store_proto_rawDescGZIP(), []int{0}
+	return file_store_v1_store_proto_rawDescGZIP(), []int{1}
+}
+
+type LinkDirection int32
+
+const (
+	LinkDirection_LINK_DIRECTION_UNSPECIFIED LinkDirection = 0 // both
+	LinkDirection_LINK_DIRECTION_OUTGOING    LinkDirection = 1 // links from the entity
+	LinkDirection_LINK_DIRECTION_INCOMING    LinkDirection = 2 // links to the entity
+)
+
+// Enum value maps for LinkDirection.
+var (
+	LinkDirection_name = map[int32]string{
+		0: "LINK_DIRECTION_UNSPECIFIED",
+		1: "LINK_DIRECTION_OUTGOING",
+		2: "LINK_DIRECTION_INCOMING",
+	}
+	LinkDirection_value = map[string]int32{
+		"LINK_DIRECTION_UNSPECIFIED": 0,
+		"LINK_DIRECTION_OUTGOING":    1,
+		"LINK_DIRECTION_INCOMING":    2,
+	}
+)
+
+func (x LinkDirection) Enum() *LinkDirection {
+	p := new(LinkDirection)
+	*p = x
+	return p
+}
+
+func (x LinkDirection) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (LinkDirection) Descriptor() protoreflect.EnumDescriptor {
+	return file_store_v1_store_proto_enumTypes[2].Descriptor()
+}
+
+func (LinkDirection) Type() protoreflect.EnumType {
+	return &file_store_v1_store_proto_enumTypes[2]
+}
+
+func (x LinkDirection) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use LinkDirection.Descriptor instead.
+func (LinkDirection) EnumDescriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{2}
 }
 
 type CreateEntityRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Entity        *v1.Entity             `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Entity *v1.Entity             `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	// If set, the store deletes the entity unless it is written again within
+	// ttl. Each write with a ttl restarts the countdown.
+	Ttl           *durationpb.Duration `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -119,6 +240,13 @@ func (x *CreateEntityRequest) GetEntity() *v1.Entity {
 	return nil
 }
 
+func (x *CreateEntityRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
 type GetEntityRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -164,8 +292,14 @@ func (x *GetEntityRequest) GetId() string {
 }
 
 type ListEntitiesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TypeFilter    v1.EntityType          `protobuf:"varint,1,opt,name=type_filter,json=typeFilter,proto3,enum=entity.v1.EntityType" json:"type_filter,omitempty"`
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	TypeFilter v1.EntityType          `protobuf:"varint,1,opt,name=type_filter,json=typeFilter,proto3,enum=entity.v1.EntityType" json:"type_filter,omitempty"`
+	// If set, only entities matching every filter are returned. Filters on
+	// fields the store indexes are answered without scanning every entity.
+	Filters []*ComponentFilter `protobuf:"bytes,2,rep,name=filters,proto3" json:"filters,omitempty"`
+	// If set, only entities whose labels match, e.g. "exercise=bravo,side!=red".
+	// Terms are key=value, key!=value, key (set), or !key (not set).
+	LabelSelector string `protobuf:"bytes,3,opt,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -207,27 +341,50 @@ func (x *ListEntitiesRequest) GetTypeFilter() v1.EntityType {
 	return v1.EntityType(0)
 }
 
-type ListEntitiesResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Entities      []*v1.Entity           `protobuf:"bytes,1,rep,name=entities,proto3" json:"entities,omitempty"`
+func (x *ListEntitiesRequest) GetFilters() []*ComponentFilter {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *ListEntitiesRequest) GetLabelSelector() string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return ""
+}
+
+// ComponentFilter compares a scalar field of one component's message with
+// a value, e.g. threat.level >= HIGH or source.sensor_id == "radar-1".
+// Entities without the component never match.
+type ComponentFilter struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Component string                 `protobuf:"bytes,1,opt,name=component,proto3" json:"component,omitempty"` // component key, e.g. "threat"
+	Field     string                 `protobuf:"bytes,2,opt,name=field,proto3" json:"field,omitempty"`         // field of its message, e.g. "level"
+	Op        FilterOp               `protobuf:"varint,3,opt,name=op,proto3,enum=store.v1.FilterOp" json:"op,omitempty"`
+	// Parsed as the field's type: a number, true/false, a string, or an enum
+	// value by name (THREAT_LEVEL_HIGH, or just HIGH) or number. Enums
+	// compare by number.
+	Value         string `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListEntitiesResponse) Reset() {
-	*x = ListEntitiesResponse{}
+func (x *ComponentFilter) Reset() {
+	*x = ComponentFilter{}
 	mi := &file_store_v1_store_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListEntitiesResponse) String() string {
+func (x *ComponentFilter) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListEntitiesResponse) ProtoMessage() {}
+func (*ComponentFilter) ProtoMessage() {}
 
-func (x *ListEntitiesResponse) ProtoReflect() protoreflect.Message {
+func (x *ComponentFilter) ProtoReflect() protoreflect.Message {
 	mi := &file_store_v1_store_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -239,39 +396,60 @@ func (x *ListEntitiesResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListEntitiesResponse.ProtoReflect.Descriptor instead.
-func (*ListEntitiesResponse) Descriptor() ([]byte, []int) {
+// Deprecated: Use ComponentFilter.ProtoReflect.Descriptor instead.
+func (*ComponentFilter) Descriptor() ([]byte, []int) {
 	return file_store_v1_store_proto_rawDescGZIP(), []int{3}
 }
 
-func (x *ListEntitiesResponse) GetEntities() []*v1.Entity {
+func (x *ComponentFilter) GetComponent() string {
 	if x != nil {
-		return x.Entities
+		return x.Component
 	}
-	return nil
+	return ""
 }
 
-type UpdateEntityRequest struct {
+func (x *ComponentFilter) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *ComponentFilter) GetOp() FilterOp {
+	if x != nil {
+		return x.Op
+	}
+	return FilterOp_FILTER_OP_UNSPECIFIED
+}
+
+func (x *ComponentFilter) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type ListEntitiesResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Entity        *v1.Entity             `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	Entities      []*v1.Entity           `protobuf:"bytes,1,rep,name=entities,proto3" json:"entities,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateEntityRequest) Reset() {
-	*x = UpdateEntityRequest{}
+func (x *ListEntitiesResponse) Reset() {
+	*x = ListEntitiesResponse{}
 	mi := &file_store_v1_store_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateEntityRequest) String() string {
+func (x *ListEntitiesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateEntityRequest) ProtoMessage() {}
+func (*ListEntitiesResponse) ProtoMessage() {}
 
-func (x *UpdateEntityRequest) ProtoReflect() protoreflect.Message {
+func (x *ListEntitiesResponse) ProtoReflect() protoreflect.Message {
 	mi := &file_store_v1_store_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -283,39 +461,49 @@ func (x *UpdateEntityRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateEntityRequest.ProtoReflect.Descriptor instead.
-func (*UpdateEntityRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use ListEntitiesResponse.ProtoReflect.Descriptor instead.
+func (*ListEntitiesResponse) Descriptor() ([]byte, []int) {
 	return file_store_v1_store_proto_rawDescGZIP(), []int{4}
 }
 
-func (x *UpdateEntityRequest) GetEntity() *v1.Entity {
+func (x *ListEntitiesResponse) GetEntities() []*v1.Entity {
 	if x != nil {
-		return x.Entity
+		return x.Entities
 	}
 	return nil
 }
 
-type DeleteEntityRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+type UpdateEntityRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Entity *v1.Entity             `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	// If set, restarts the entity's expiry countdown; see CreateEntityRequest.
+	Ttl *durationpb.Duration `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	// If set, the update is applied only if the stored entity's HLC still
+	// equals this one, the HLC of the copy the caller read. Otherwise it
+	// fails with FAILED_PRECONDITION and the caller should read it again.
+	ExpectedHlc *v1.HLCTimestamp `protobuf:"bytes,3,opt,name=expected_hlc,json=expectedHlc,proto3" json:"expected_hlc,omitempty"`
+	// If the entity is a relay's merge of the stored copy and another, how
+	// the merge resolved each component key the two differed on. Recorded
+	// in the audit log; the store does not act on it.
+	Merge         []*MergeDecision `protobuf:"bytes,4,rep,name=merge,proto3" json:"merge,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *DeleteEntityRequest) Reset() {
-	*x = DeleteEntityRequest{}
+func (x *UpdateEntityRequest) Reset() {
+	*x = UpdateEntityRequest{}
 	mi := &file_store_v1_store_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DeleteEntityRequest) String() string {
+func (x *UpdateEntityRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DeleteEntityRequest) ProtoMessage() {}
+func (*UpdateEntityRequest) ProtoMessage() {}
 
-func (x *DeleteEntityRequest) ProtoReflect() protoreflect.Message {
+func (x *UpdateEntityRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_store_v1_store_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -327,39 +515,69 @@ func (x *DeleteEntityRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DeleteEntityRequest.ProtoReflect.Descriptor instead.
-func (*DeleteEntityRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use UpdateEntityRequest.ProtoReflect.Descriptor instead.
+func (*UpdateEntityRequest) Descriptor() ([]byte, []int) {
 	return file_store_v1_store_proto_rawDescGZIP(), []int{5}
 }
 
-func (x *DeleteEntityRequest) GetId() string {
+func (x *UpdateEntityRequest) GetEntity() *v1.Entity {
 	if x != nil {
-		return x.Id
+		return x.Entity
 	}
-	return ""
+	return nil
 }
 
-type WatchEntitiesRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	TypeFilter    v1.EntityType          `protobuf:"varint,1,opt,name=type_filter,json=typeFilter,proto3,enum=entity.v1.EntityType" json:"type_filter,omitempty"`
+func (x *UpdateEntityRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+func (x *UpdateEntityRequest) GetExpectedHlc() *v1.HLCTimestamp {
+	if x != nil {
+		return x.ExpectedHlc
+	}
+	return nil
+}
+
+func (x *UpdateEntityRequest) GetMerge() []*MergeDecision {
+	if x != nil {
+		return x.Merge
+	}
+	return nil
+}
+
+// MergeDecision is how a merge resolved one component key.
+type MergeDecision struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Which value the merge kept: stored (the copy the written store held),
+	// incoming, both (they were equal), merged (made from both), or none
+	// (removed).
+	Kept string `protobuf:"bytes,2,opt,name=kept,proto3" json:"kept,omitempty"`
+	// The strategy that chose between two different values, such as lww or
+	// max-wins; empty if only one side held a value.
+	Strategy      string `protobuf:"bytes,3,opt,name=strategy,proto3" json:"strategy,omitempty"`
+	Removed       bool   `protobuf:"varint,4,opt,name=removed,proto3" json:"removed,omitempty"` // a tombstone dropped either side's value
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WatchEntitiesRequest) Reset() {
-	*x = WatchEntitiesRequest{}
+func (x *MergeDecision) Reset() {
+	*x = MergeDecision{}
 	mi := &file_store_v1_store_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *WatchEntitiesRequest) String() string {
+func (x *MergeDecision) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WatchEntitiesRequest) ProtoMessage() {}
+func (*MergeDecision) ProtoMessage() {}
 
-func (x *WatchEntitiesRequest) ProtoReflect() protoreflect.Message {
+func (x *MergeDecision) ProtoReflect() protoreflect.Message {
 	mi := &file_store_v1_store_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -371,41 +589,64 @@ func (x *WatchEntitiesRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WatchEntitiesRequest.ProtoReflect.Descriptor instead.
-func (*WatchEntitiesRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use MergeDecision.ProtoReflect.Descriptor instead.
+func (*MergeDecision) Descriptor() ([]byte, []int) {
 	return file_store_v1_store_proto_rawDescGZIP(), []int{6}
 }
 
-func (x *WatchEntitiesRequest) GetTypeFilter() v1.EntityType {
+func (x *MergeDecision) GetKey() string {
 	if x != nil {
-		return x.TypeFilter
+		return x.Key
 	}
-	return v1.EntityType(0)
+	return ""
 }
 
-type EntityEvent struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=store.v1.EventType" json:"type,omitempty"`
-	Entity        *v1.Entity             `protobuf:"bytes,2,opt,name=entity,proto3" json:"entity,omitempty"`
-	OriginNode    string                 `protobuf:"bytes,3,opt,name=origin_node,json=originNode,proto3" json:"origin_node,omitempty"`
+func (x *MergeDecision) GetKept() string {
+	if x != nil {
+		return x.Kept
+	}
+	return ""
+}
+
+func (x *MergeDecision) GetStrategy() string {
+	if x != nil {
+		return x.Strategy
+	}
+	return ""
+}
+
+func (x *MergeDecision) GetRemoved() bool {
+	if x != nil {
+		return x.Removed
+	}
+	return false
+}
+
+type DeleteEntityRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// If set, the delete is replicated from another node and keeps this HLC:
+	// it is skipped if the entity was written since, and recorded as a
+	// tombstone even if the entity is already gone.
+	Hlc           *v1.HLCTimestamp `protobuf:"bytes,2,opt,name=hlc,proto3" json:"hlc,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *EntityEvent) Reset() {
-	*x = EntityEvent{}
+func (x *DeleteEntityRequest) Reset() {
+	*x = DeleteEntityRequest{}
 	mi := &file_store_v1_store_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *EntityEvent) String() string {
+func (x *DeleteEntityRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*EntityEvent) ProtoMessage() {}
+func (*DeleteEntityRequest) ProtoMessage() {}
 
-func (x *EntityEvent) ProtoReflect() protoreflect.Message {
+func (x *DeleteEntityRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_store_v1_store_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -417,53 +658,67 @@ func (x *EntityEvent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use EntityEvent.ProtoReflect.Descriptor instead.
-func (*EntityEvent) Descriptor() ([]byte, []int) {
+// Deprecated: Use DeleteEntityRequest.ProtoReflect.Descriptor instead.
+func (*DeleteEntityRequest) Descriptor() ([]byte, []int) {
 	return file_store_v1_store_proto_rawDescGZIP(), []int{7}
 }
 
-func (x *EntityEvent) GetType() EventType {
+func (x *DeleteEntityRequest) GetId() string {
 	if x != nil {
-		return x.Type
+		return x.Id
 	}
-	return EventType_EVENT_TYPE_UNSPECIFIED
+	return ""
 }
 
-func (x *EntityEvent) GetEntity() *v1.Entity {
+func (x *DeleteEntityRequest) GetHlc() *v1.HLCTimestamp {
 	if x != nil {
-		return x.Entity
+		return x.Hlc
 	}
 	return nil
 }
 
-func (x *EntityEvent) GetOriginNode() string {
-	if x != nil {
-		return x.OriginNode
-	}
-	return ""
-}
-
-type ApproveActionRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	EntityId      string                 `protobuf:"bytes,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+type WatchEntitiesRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	TypeFilter v1.EntityType          `protobuf:"varint,1,opt,name=type_filter,json=typeFilter,proto3,enum=entity.v1.EntityType" json:"type_filter,omitempty"`
+	// If set, resume after the event with this sequence: events missed since
+	// are sent first. Fails with OUT_OF_RANGE if the store no longer holds
+	// them, after which the caller should reload and watch afresh.
+	SinceSequence uint64 `protobuf:"varint,2,opt,name=since_sequence,json=sinceSequence,proto3" json:"since_sequence,omitempty"`
+	// If set, only events for entities whose labels match; see
+	// ListEntitiesRequest.
+	LabelSelector string `protobuf:"bytes,3,opt,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty"`
+	// If set, only events for entities that have every one of these
+	// components, e.g. "threat".
+	Components []string `protobuf:"bytes,4,rep,name=components,proto3" json:"components,omitempty"`
+	// If set, only events for entities positioned inside the box, plus the
+	// one event that takes an entity sent earlier out of it (an update that
+	// moves it outside, or its removal).
+	Bbox *BoundingBox `protobuf:"bytes,5,opt,name=bbox,proto3" json:"bbox,omitempty"`
+	// If set, the stream starts with a CREATED event for every entity the
+	// store holds that matches the filters, then continues with live events:
+	// list and watch in one call, with nothing written in between missed or
+	// repeated. The snapshot's events all carry the sequence of the last
+	// event before it, so resuming from one skips nothing written after the
+	// snapshot. Cannot be combined with since_sequence.
+	InitialState  bool `protobuf:"varint,6,opt,name=initial_state,json=initialState,proto3" json:"initial_state,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ApproveActionRequest) Reset() {
-	*x = ApproveActionRequest{}
+func (x *WatchEntitiesRequest) Reset() {
+	*x = WatchEntitiesRequest{}
 	mi := &file_store_v1_store_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ApproveActionRequest) String() string {
+func (x *WatchEntitiesRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ApproveActionRequest) ProtoMessage() {}
+func (*WatchEntitiesRequest) ProtoMessage() {}
 
-func (x *ApproveActionRequest) ProtoReflect() protoreflect.Message {
+func (x *WatchEntitiesRequest) ProtoReflect() protoreflect.Message {
 	mi := &file_store_v1_store_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -475,40 +730,2596 @@ func (x *ApproveActionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ApproveActionRequest.ProtoReflect.Descriptor instead.
-func (*ApproveActionRequest) Descriptor() ([]byte, []int) {
+// Deprecated: Use WatchEntitiesRequest.ProtoReflect.Descriptor instead.
+func (*WatchEntitiesRequest) Descriptor() ([]byte, []int) {
 	return file_store_v1_store_proto_rawDescGZIP(), []int{8}
 }
 
-func (x *ApproveActionRequest) GetEntityId() string {
+func (x *WatchEntitiesRequest) GetTypeFilter() v1.EntityType {
 	if x != nil {
-		return x.EntityId
+		return x.TypeFilter
 	}
-	return ""
+	return v1.EntityType(0)
 }
 
-type DenyActionRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	EntityId      string                 `protobuf:"bytes,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
+func (x *WatchEntitiesRequest) GetSinceSequence() uint64 {
+	if x != nil {
+		return x.SinceSequence
+	}
+	return 0
+}
+
+func (x *WatchEntitiesRequest) GetLabelSelector() string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return ""
+}
+
+func (x *WatchEntitiesRequest) GetComponents() []string {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+func (x *WatchEntitiesRequest) GetBbox() *BoundingBox {
+	if x != nil {
+		return x.Bbox
+	}
+	return nil
+}
+
+func (x *WatchEntitiesRequest) GetInitialState() bool {
+	if x != nil {
+		return x.InitialState
+	}
+	return false
+}
+
+// BoundingBox is a latitude/longitude box, edges included.
+type BoundingBox struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinLat        float64                `protobuf:"fixed64,1,opt,name=min_lat,json=minLat,proto3" json:"min_lat,omitempty"`
+	MaxLat        float64                `protobuf:"fixed64,2,opt,name=max_lat,json=maxLat,proto3" json:"max_lat,omitempty"`
+	MinLon        float64                `protobuf:"fixed64,3,opt,name=min_lon,json=minLon,proto3" json:"min_lon,omitempty"`
+	MaxLon        float64                `protobuf:"fixed64,4,opt,name=max_lon,json=maxLon,proto3" json:"max_lon,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BoundingBox) Reset() {
+	*x = BoundingBox{}
+	mi := &file_store_v1_store_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BoundingBox) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BoundingBox) ProtoMessage() {}
+
+func (x *BoundingBox) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BoundingBox.ProtoReflect.Descriptor instead.
+func (*BoundingBox) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *BoundingBox) GetMinLat() float64 {
+	if x != nil {
+		return x.MinLat
+	}
+	return 0
+}
+
+func (x *BoundingBox) GetMaxLat() float64 {
+	if x != nil {
+		return x.MaxLat
+	}
+	return 0
+}
+
+func (x *BoundingBox) GetMinLon() float64 {
+	if x != nil {
+		return x.MinLon
+	}
+	return 0
+}
+
+func (x *BoundingBox) GetMaxLon() float64 {
+	if x != nil {
+		return x.MaxLon
+	}
+	return 0
+}
+
+type EntityEvent struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Type       EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=store.v1.EventType" json:"type,omitempty"`
+	Entity     *v1.Entity             `protobuf:"bytes,2,opt,name=entity,proto3" json:"entity,omitempty"`
+	OriginNode string                 `protobuf:"bytes,3,opt,name=origin_node,json=originNode,proto3" json:"origin_node,omitempty"`
+	// Increases by one with every event the store emits; pass the last one
+	// seen as since_sequence to resume a watch.
+	Sequence uint64 `protobuf:"varint,4,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	// How many relays the write passed through to reach this store: 0 for a
+	// write made here, 1 for one relayed from the node it was made on.
+	Hops uint32 `protobuf:"varint,5,opt,name=hops,proto3" json:"hops,omitempty"`
+	// The nodes whose relays passed the write on to reach this store, the
+	// node it was made on first; empty for a write made here. A relay does
+	// not pass on a write it already has, so writes do not loop round a
+	// mesh.
+	Path          []string `protobuf:"bytes,6,rep,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EntityEvent) Reset() {
+	*x = EntityEvent{}
+	mi := &file_store_v1_store_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntityEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntityEvent) ProtoMessage() {}
+
+func (x *EntityEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntityEvent.ProtoReflect.Descriptor instead.
+func (*EntityEvent) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EntityEvent) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *EntityEvent) GetEntity() *v1.Entity {
+	if x != nil {
+		return x.Entity
+	}
+	return nil
+}
+
+func (x *EntityEvent) GetOriginNode() string {
+	if x != nil {
+		return x.OriginNode
+	}
+	return ""
+}
+
+func (x *EntityEvent) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *EntityEvent) GetHops() uint32 {
+	if x != nil {
+		return x.Hops
+	}
+	return 0
+}
+
+func (x *EntityEvent) GetPath() []string {
+	if x != nil {
+		return x.Path
+	}
+	return nil
+}
+
+type TransactRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Reads []*TransactRead        `protobuf:"bytes,1,rep,name=reads,proto3" json:"reads,omitempty"`
+	// Applied in order. Deletes with an hlc (replicated deletes) are not
+	// allowed.
+	Ops           []*WriteOp `protobuf:"bytes,2,rep,name=ops,proto3" json:"ops,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransactRequest) Reset() {
+	*x = TransactRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactRequest) ProtoMessage() {}
+
+func (x *TransactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactRequest.ProtoReflect.Descriptor instead.
+func (*TransactRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TransactRequest) GetReads() []*TransactRead {
+	if x != nil {
+		return x.Reads
+	}
+	return nil
+}
+
+func (x *TransactRequest) GetOps() []*WriteOp {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+// TransactRead reads an entity, which must exist. With expected_hlc set,
+// the transaction aborts with FAILED_PRECONDITION unless the entity is
+// still at that version.
+type TransactRead struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ExpectedHlc   *v1.HLCTimestamp       `protobuf:"bytes,2,opt,name=expected_hlc,json=expectedHlc,proto3" json:"expected_hlc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransactRead) Reset() {
+	*x = TransactRead{}
+	mi := &file_store_v1_store_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransactRead) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactRead) ProtoMessage() {}
+
+func (x *TransactRead) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactRead.ProtoReflect.Descriptor instead.
+func (*TransactRead) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *TransactRead) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *TransactRead) GetExpectedHlc() *v1.HLCTimestamp {
+	if x != nil {
+		return x.ExpectedHlc
+	}
+	return nil
+}
+
+type TransactResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The entities read, in order, as they were before the writes.
+	Reads []*v1.Entity `protobuf:"bytes,1,rep,name=reads,proto3" json:"reads,omitempty"`
+	// One per op, in order, all successful.
+	Results       []*WriteResult `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TransactResponse) Reset() {
+	*x = TransactResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TransactResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TransactResponse) ProtoMessage() {}
+
+func (x *TransactResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TransactResponse.ProtoReflect.Descriptor instead.
+func (*TransactResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *TransactResponse) GetReads() []*v1.Entity {
+	if x != nil {
+		return x.Reads
+	}
+	return nil
+}
+
+func (x *TransactResponse) GetResults() []*WriteResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type ListArchivedEntitiesRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	TypeFilter v1.EntityType          `protobuf:"varint,1,opt,name=type_filter,json=typeFilter,proto3,enum=entity.v1.EntityType" json:"type_filter,omitempty"`
+	// As in ListEntitiesRequest.
+	LabelSelector string `protobuf:"bytes,2,opt,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListArchivedEntitiesRequest) Reset() {
+	*x = ListArchivedEntitiesRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListArchivedEntitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArchivedEntitiesRequest) ProtoMessage() {}
+
+func (x *ListArchivedEntitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArchivedEntitiesRequest.ProtoReflect.Descriptor instead.
+func (*ListArchivedEntitiesRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListArchivedEntitiesRequest) GetTypeFilter() v1.EntityType {
+	if x != nil {
+		return x.TypeFilter
+	}
+	return v1.EntityType(0)
+}
+
+func (x *ListArchivedEntitiesRequest) GetLabelSelector() string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return ""
+}
+
+// ArchivedEntity is an entity as it stood before it was removed.
+type ArchivedEntity struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entity        *v1.Entity             `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	Reason        EventType              `protobuf:"varint,2,opt,name=reason,proto3,enum=store.v1.EventType" json:"reason,omitempty"` // DELETED or EXPIRED
+	ArchivedAt    *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=archived_at,json=archivedAt,proto3" json:"archived_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArchivedEntity) Reset() {
+	*x = ArchivedEntity{}
+	mi := &file_store_v1_store_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArchivedEntity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArchivedEntity) ProtoMessage() {}
+
+func (x *ArchivedEntity) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArchivedEntity.ProtoReflect.Descriptor instead.
+func (*ArchivedEntity) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ArchivedEntity) GetEntity() *v1.Entity {
+	if x != nil {
+		return x.Entity
+	}
+	return nil
+}
+
+func (x *ArchivedEntity) GetReason() EventType {
+	if x != nil {
+		return x.Reason
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *ArchivedEntity) GetArchivedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ArchivedAt
+	}
+	return nil
+}
+
+type ListArchivedEntitiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entities      []*ArchivedEntity      `protobuf:"bytes,1,rep,name=entities,proto3" json:"entities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListArchivedEntitiesResponse) Reset() {
+	*x = ListArchivedEntitiesResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListArchivedEntitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListArchivedEntitiesResponse) ProtoMessage() {}
+
+func (x *ListArchivedEntitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListArchivedEntitiesResponse.ProtoReflect.Descriptor instead.
+func (*ListArchivedEntitiesResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ListArchivedEntitiesResponse) GetEntities() []*ArchivedEntity {
+	if x != nil {
+		return x.Entities
+	}
+	return nil
+}
+
+type GetAuditLogRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// If set, only entries that touched this entity.
+	EntityId string `protobuf:"bytes,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	// If set, only the most recent limit entries.
+	Limit         uint32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAuditLogRequest) Reset() {
+	*x = GetAuditLogRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAuditLogRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAuditLogRequest) ProtoMessage() {}
+
+func (x *GetAuditLogRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAuditLogRequest.ProtoReflect.Descriptor instead.
+func (*GetAuditLogRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *GetAuditLogRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *GetAuditLogRequest) GetLimit() uint32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+// AuditEntry is one call to a mutating RPC, successful or not.
+type AuditEntry struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	// The store's HLC when the call finished.
+	Hlc    *v1.HLCTimestamp `protobuf:"bytes,2,opt,name=hlc,proto3" json:"hlc,omitempty"`
+	Method string           `protobuf:"bytes,3,opt,name=method,proto3" json:"method,omitempty"` // full gRPC method name
+	Peer   string           `protobuf:"bytes,4,opt,name=peer,proto3" json:"peer,omitempty"`     // the caller's address
+	// The caller's role and the first 8 bytes of its token's SHA-256, in
+	// hex; empty on a store without auth. The token itself is never kept.
+	Role    string         `protobuf:"bytes,5,opt,name=role,proto3" json:"role,omitempty"`
+	TokenId string         `protobuf:"bytes,6,opt,name=token_id,json=tokenId,proto3" json:"token_id,omitempty"`
+	Targets []*AuditTarget `protobuf:"bytes,7,rep,name=targets,proto3" json:"targets,omitempty"`
+	Code    int32          `protobuf:"varint,8,opt,name=code,proto3" json:"code,omitempty"` // the call's gRPC status code
+	Message string         `protobuf:"bytes,9,opt,name=message,proto3" json:"message,omitempty"`
+	// For an update a relay merged, how it resolved each component key.
+	Merge         []*MergeDecision `protobuf:"bytes,10,rep,name=merge,proto3" json:"merge,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditEntry) Reset() {
+	*x = AuditEntry{}
+	mi := &file_store_v1_store_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditEntry) ProtoMessage() {}
+
+func (x *AuditEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditEntry.ProtoReflect.Descriptor instead.
+func (*AuditEntry) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *AuditEntry) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *AuditEntry) GetHlc() *v1.HLCTimestamp {
+	if x != nil {
+		return x.Hlc
+	}
+	return nil
+}
+
+func (x *AuditEntry) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetPeer() string {
+	if x != nil {
+		return x.Peer
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetTokenId() string {
+	if x != nil {
+		return x.TokenId
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetTargets() []*AuditTarget {
+	if x != nil {
+		return x.Targets
+	}
+	return nil
+}
+
+func (x *AuditEntry) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *AuditEntry) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *AuditEntry) GetMerge() []*MergeDecision {
+	if x != nil {
+		return x.Merge
+	}
+	return nil
+}
+
+// AuditTarget is an entity a call wrote and the component keys it carried;
+// none for deletes and approvals.
+type AuditTarget struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EntityId      string                 `protobuf:"bytes,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	ComponentKeys []string               `protobuf:"bytes,2,rep,name=component_keys,json=componentKeys,proto3" json:"component_keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AuditTarget) Reset() {
+	*x = AuditTarget{}
+	mi := &file_store_v1_store_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AuditTarget) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AuditTarget) ProtoMessage() {}
+
+func (x *AuditTarget) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AuditTarget.ProtoReflect.Descriptor instead.
+func (*AuditTarget) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *AuditTarget) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *AuditTarget) GetComponentKeys() []string {
+	if x != nil {
+		return x.ComponentKeys
+	}
+	return nil
+}
+
+type GetAuditLogResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*AuditEntry          `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetAuditLogResponse) Reset() {
+	*x = GetAuditLogResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetAuditLogResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetAuditLogResponse) ProtoMessage() {}
+
+func (x *GetAuditLogResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetAuditLogResponse.ProtoReflect.Descriptor instead.
+func (*GetAuditLogResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetAuditLogResponse) GetEntries() []*AuditEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+// The digest tree buckets entities by a hash of their ID into 4096 leaves,
+// under a root and two levels of 16-way nodes: node i at one level has
+// children 16i to 16i+15 at the next. A node's hash covers the type,
+// components, and labels of every entity below it, but not their HLCs,
+// which each store stamps for itself, so two stores holding the same
+// entities have the same hashes.
+type DigestEntitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Level         uint32                 `protobuf:"varint,1,opt,name=level,proto3" json:"level,omitempty"`        // 0 (the root) to 3 (the leaves)
+	Nodes         []uint32               `protobuf:"varint,2,rep,packed,name=nodes,proto3" json:"nodes,omitempty"` // indexes at that level
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DigestEntitiesRequest) Reset() {
+	*x = DigestEntitiesRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DigestEntitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DigestEntitiesRequest) ProtoMessage() {}
+
+func (x *DigestEntitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DigestEntitiesRequest.ProtoReflect.Descriptor instead.
+func (*DigestEntitiesRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DigestEntitiesRequest) GetLevel() uint32 {
+	if x != nil {
+		return x.Level
+	}
+	return 0
+}
+
+func (x *DigestEntitiesRequest) GetNodes() []uint32 {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type DigestEntitiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nodes         []*DigestNode          `protobuf:"bytes,1,rep,name=nodes,proto3" json:"nodes,omitempty"` // in request order
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DigestEntitiesResponse) Reset() {
+	*x = DigestEntitiesResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DigestEntitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DigestEntitiesResponse) ProtoMessage() {}
+
+func (x *DigestEntitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DigestEntitiesResponse.ProtoReflect.Descriptor instead.
+func (*DigestEntitiesResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DigestEntitiesResponse) GetNodes() []*DigestNode {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+type DigestNode struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Index uint32                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Hash  []byte                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"` // empty if no entity is below the node
+	// At the leaves, each entity in the bucket, by ID.
+	Entities      []*EntityDigest `protobuf:"bytes,3,rep,name=entities,proto3" json:"entities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DigestNode) Reset() {
+	*x = DigestNode{}
+	mi := &file_store_v1_store_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DigestNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DigestNode) ProtoMessage() {}
+
+func (x *DigestNode) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DigestNode.ProtoReflect.Descriptor instead.
+func (*DigestNode) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *DigestNode) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *DigestNode) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *DigestNode) GetEntities() []*EntityDigest {
+	if x != nil {
+		return x.Entities
+	}
+	return nil
+}
+
+type EntityDigest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Hash          []byte                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EntityDigest) Reset() {
+	*x = EntityDigest{}
+	mi := &file_store_v1_store_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EntityDigest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EntityDigest) ProtoMessage() {}
+
+func (x *EntityDigest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EntityDigest.ProtoReflect.Descriptor instead.
+func (*EntityDigest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *EntityDigest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *EntityDigest) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+type ReplicateBatchRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Events []*EntityEvent         `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"` // applied in order
+	// If set, a merge that would not change the store's copy is not
+	// written, so under gossip an event the store already has spreads no
+	// further from it.
+	SkipUnchanged bool `protobuf:"varint,2,opt,name=skip_unchanged,json=skipUnchanged,proto3" json:"skip_unchanged,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicateBatchRequest) Reset() {
+	*x = ReplicateBatchRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicateBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicateBatchRequest) ProtoMessage() {}
+
+func (x *ReplicateBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicateBatchRequest.ProtoReflect.Descriptor instead.
+func (*ReplicateBatchRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ReplicateBatchRequest) GetEvents() []*EntityEvent {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *ReplicateBatchRequest) GetSkipUnchanged() bool {
+	if x != nil {
+		return x.SkipUnchanged
+	}
+	return false
+}
+
+type ReplicateBatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One per event, in order; a merge skipped as unchanged returns the copy
+	// as stored. An entity the
+	// store deleted after the event's HLC is refused with FAILED_PRECONDITION,
+	// and a delete without an HLC of one it does not have with NOT_FOUND.
+	Results []*WriteResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	// Events merged with a copy the store already had, and written.
+	Merged        uint32 `protobuf:"varint,2,opt,name=merged,proto3" json:"merged,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReplicateBatchResponse) Reset() {
+	*x = ReplicateBatchResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReplicateBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReplicateBatchResponse) ProtoMessage() {}
+
+func (x *ReplicateBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReplicateBatchResponse.ProtoReflect.Descriptor instead.
+func (*ReplicateBatchResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *ReplicateBatchResponse) GetResults() []*WriteResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+func (x *ReplicateBatchResponse) GetMerged() uint32 {
+	if x != nil {
+		return x.Merged
+	}
+	return 0
+}
+
+type StreamChangesRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// If set, resume after the record with this sequence; see
+	// WatchEntitiesRequest.since_sequence. Zero starts with the next write.
+	SinceSequence uint64 `protobuf:"varint,1,opt,name=since_sequence,json=sinceSequence,proto3" json:"since_sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StreamChangesRequest) Reset() {
+	*x = StreamChangesRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StreamChangesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamChangesRequest) ProtoMessage() {}
+
+func (x *StreamChangesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamChangesRequest.ProtoReflect.Descriptor instead.
+func (*StreamChangesRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *StreamChangesRequest) GetSinceSequence() uint64 {
+	if x != nil {
+		return x.SinceSequence
+	}
+	return 0
+}
+
+// ChangeRecord is one committed mutation of one entity. Its fields are only
+// ever added to, so exported records stay readable.
+type ChangeRecord struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The sequence of the EntityEvent the same write emitted.
+	Sequence   uint64        `protobuf:"varint,1,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	Type       EventType     `protobuf:"varint,2,opt,name=type,proto3,enum=store.v1.EventType" json:"type,omitempty"`
+	EntityId   string        `protobuf:"bytes,3,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	EntityType v1.EntityType `protobuf:"varint,4,opt,name=entity_type,json=entityType,proto3,enum=entity.v1.EntityType" json:"entity_type,omitempty"`
+	// The node whose clock stamped the write: this store's node for local
+	// writes, the deleting node for replicated deletes.
+	OriginNode string                 `protobuf:"bytes,5,opt,name=origin_node,json=originNode,proto3" json:"origin_node,omitempty"`
+	Hlc        *v1.HLCTimestamp       `protobuf:"bytes,6,opt,name=hlc,proto3" json:"hlc,omitempty"`
+	CommitTime *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=commit_time,json=commitTime,proto3" json:"commit_time,omitempty"`
+	// The components the write set or removed, ordered by key. A delete
+	// removes them all.
+	Components    []*ComponentChange `protobuf:"bytes,8,rep,name=components,proto3" json:"components,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeRecord) Reset() {
+	*x = ChangeRecord{}
+	mi := &file_store_v1_store_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeRecord) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeRecord) ProtoMessage() {}
+
+func (x *ChangeRecord) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeRecord.ProtoReflect.Descriptor instead.
+func (*ChangeRecord) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ChangeRecord) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *ChangeRecord) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *ChangeRecord) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *ChangeRecord) GetEntityType() v1.EntityType {
+	if x != nil {
+		return x.EntityType
+	}
+	return v1.EntityType(0)
+}
+
+func (x *ChangeRecord) GetOriginNode() string {
+	if x != nil {
+		return x.OriginNode
+	}
+	return ""
+}
+
+func (x *ChangeRecord) GetHlc() *v1.HLCTimestamp {
+	if x != nil {
+		return x.Hlc
+	}
+	return nil
+}
+
+func (x *ChangeRecord) GetCommitTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CommitTime
+	}
+	return nil
+}
+
+func (x *ChangeRecord) GetComponents() []*ComponentChange {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+type ComponentChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Key   string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	// Unset if the write added the component.
+	OldValue *anypb.Any `protobuf:"bytes,2,opt,name=old_value,json=oldValue,proto3" json:"old_value,omitempty"`
+	// Unset if the write removed the component.
+	NewValue      *anypb.Any `protobuf:"bytes,3,opt,name=new_value,json=newValue,proto3" json:"new_value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ComponentChange) Reset() {
+	*x = ComponentChange{}
+	mi := &file_store_v1_store_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ComponentChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ComponentChange) ProtoMessage() {}
+
+func (x *ComponentChange) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ComponentChange.ProtoReflect.Descriptor instead.
+func (*ComponentChange) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ComponentChange) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ComponentChange) GetOldValue() *anypb.Any {
+	if x != nil {
+		return x.OldValue
+	}
+	return nil
+}
+
+func (x *ComponentChange) GetNewValue() *anypb.Any {
+	if x != nil {
+		return x.NewValue
+	}
+	return nil
+}
+
+type ApproveActionRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	EntityId string                 `protobuf:"bytes,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	// Who decided, for the task-manager's audit trail; optional.
+	Operator      string `protobuf:"bytes,2,opt,name=operator,proto3" json:"operator,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ApproveActionRequest) Reset() {
+	*x = ApproveActionRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ApproveActionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ApproveActionRequest) ProtoMessage() {}
+
+func (x *ApproveActionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ApproveActionRequest.ProtoReflect.Descriptor instead.
+func (*ApproveActionRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ApproveActionRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *ApproveActionRequest) GetOperator() string {
+	if x != nil {
+		return x.Operator
+	}
+	return ""
+}
+
+type DenyActionRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	EntityId string                 `protobuf:"bytes,1,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	// Who decided, for the task-manager's audit trail; optional.
+	Operator      string `protobuf:"bytes,2,opt,name=operator,proto3" json:"operator,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DenyActionRequest) Reset() {
+	*x = DenyActionRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DenyActionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DenyActionRequest) ProtoMessage() {}
+
+func (x *DenyActionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DenyActionRequest.ProtoReflect.Descriptor instead.
+func (*DenyActionRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *DenyActionRequest) GetEntityId() string {
+	if x != nil {
+		return x.EntityId
+	}
+	return ""
+}
+
+func (x *DenyActionRequest) GetOperator() string {
+	if x != nil {
+		return x.Operator
+	}
+	return ""
+}
+
+type SnapshotEntitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TypeFilter    v1.EntityType          `protobuf:"varint,1,opt,name=type_filter,json=typeFilter,proto3,enum=entity.v1.EntityType" json:"type_filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SnapshotEntitiesRequest) Reset() {
+	*x = SnapshotEntitiesRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SnapshotEntitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SnapshotEntitiesRequest) ProtoMessage() {}
+
+func (x *SnapshotEntitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SnapshotEntitiesRequest.ProtoReflect.Descriptor instead.
+func (*SnapshotEntitiesRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *SnapshotEntitiesRequest) GetTypeFilter() v1.EntityType {
+	if x != nil {
+		return x.TypeFilter
+	}
+	return v1.EntityType(0)
+}
+
+type RestoreEntitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entity        *v1.Entity             `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreEntitiesRequest) Reset() {
+	*x = RestoreEntitiesRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreEntitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreEntitiesRequest) ProtoMessage() {}
+
+func (x *RestoreEntitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreEntitiesRequest.ProtoReflect.Descriptor instead.
+func (*RestoreEntitiesRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *RestoreEntitiesRequest) GetEntity() *v1.Entity {
+	if x != nil {
+		return x.Entity
+	}
+	return nil
+}
+
+type RestoreEntitiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Created       int32                  `protobuf:"varint,1,opt,name=created,proto3" json:"created,omitempty"`   // entities the store did not have
+	Replaced      int32                  `protobuf:"varint,2,opt,name=replaced,proto3" json:"replaced,omitempty"` // existing entities the snapshot's copy superseded
+	Skipped       int32                  `protobuf:"varint,3,opt,name=skipped,proto3" json:"skipped,omitempty"`   // existing entities at least as new as the snapshot's
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RestoreEntitiesResponse) Reset() {
+	*x = RestoreEntitiesResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RestoreEntitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RestoreEntitiesResponse) ProtoMessage() {}
+
+func (x *RestoreEntitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RestoreEntitiesResponse.ProtoReflect.Descriptor instead.
+func (*RestoreEntitiesResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *RestoreEntitiesResponse) GetCreated() int32 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *RestoreEntitiesResponse) GetReplaced() int32 {
+	if x != nil {
+		return x.Replaced
+	}
+	return 0
+}
+
+func (x *RestoreEntitiesResponse) GetSkipped() int32 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+type GetComponentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetComponentRequest) Reset() {
+	*x = GetComponentRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetComponentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetComponentRequest) ProtoMessage() {}
+
+func (x *GetComponentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetComponentRequest.ProtoReflect.Descriptor instead.
+func (*GetComponentRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *GetComponentRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetComponentRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type GetComponentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Component     *anypb.Any             `protobuf:"bytes,1,opt,name=component,proto3" json:"component,omitempty"`
+	Hlc           *v1.HLCTimestamp       `protobuf:"bytes,2,opt,name=hlc,proto3" json:"hlc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetComponentResponse) Reset() {
+	*x = GetComponentResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetComponentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetComponentResponse) ProtoMessage() {}
+
+func (x *GetComponentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetComponentResponse.ProtoReflect.Descriptor instead.
+func (*GetComponentResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *GetComponentResponse) GetComponent() *anypb.Any {
+	if x != nil {
+		return x.Component
+	}
+	return nil
+}
+
+func (x *GetComponentResponse) GetHlc() *v1.HLCTimestamp {
+	if x != nil {
+		return x.Hlc
+	}
+	return nil
+}
+
+type PatchComponentRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Id         string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Components map[string]*anypb.Any  `protobuf:"bytes,2,rep,name=components,proto3" json:"components,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// If set, restarts the entity's expiry countdown; see CreateEntityRequest.
+	Ttl           *durationpb.Duration `protobuf:"bytes,3,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PatchComponentRequest) Reset() {
+	*x = PatchComponentRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PatchComponentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PatchComponentRequest) ProtoMessage() {}
+
+func (x *PatchComponentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PatchComponentRequest.ProtoReflect.Descriptor instead.
+func (*PatchComponentRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *PatchComponentRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PatchComponentRequest) GetComponents() map[string]*anypb.Any {
+	if x != nil {
+		return x.Components
+	}
+	return nil
+}
+
+func (x *PatchComponentRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+type PatchComponentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hlc           *v1.HLCTimestamp       `protobuf:"bytes,1,opt,name=hlc,proto3" json:"hlc,omitempty"` // the stamp every patched component got
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PatchComponentResponse) Reset() {
+	*x = PatchComponentResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PatchComponentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PatchComponentResponse) ProtoMessage() {}
+
+func (x *PatchComponentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PatchComponentResponse.ProtoReflect.Descriptor instead.
+func (*PatchComponentResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *PatchComponentResponse) GetHlc() *v1.HLCTimestamp {
+	if x != nil {
+		return x.Hlc
+	}
+	return nil
+}
+
+type RemoveComponentRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Keys          []string               `protobuf:"bytes,2,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveComponentRequest) Reset() {
+	*x = RemoveComponentRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveComponentRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveComponentRequest) ProtoMessage() {}
+
+func (x *RemoveComponentRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveComponentRequest.ProtoReflect.Descriptor instead.
+func (*RemoveComponentRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *RemoveComponentRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *RemoveComponentRequest) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type RemoveComponentResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hlc           *v1.HLCTimestamp       `protobuf:"bytes,1,opt,name=hlc,proto3" json:"hlc,omitempty"` // the stamp every removal's tombstone got
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveComponentResponse) Reset() {
+	*x = RemoveComponentResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveComponentResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveComponentResponse) ProtoMessage() {}
+
+func (x *RemoveComponentResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveComponentResponse.ProtoReflect.Descriptor instead.
+func (*RemoveComponentResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *RemoveComponentResponse) GetHlc() *v1.HLCTimestamp {
+	if x != nil {
+		return x.Hlc
+	}
+	return nil
+}
+
+type IncrementCounterRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Key           string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Delta         int64                  `protobuf:"varint,3,opt,name=delta,proto3" json:"delta,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IncrementCounterRequest) Reset() {
+	*x = IncrementCounterRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IncrementCounterRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IncrementCounterRequest) ProtoMessage() {}
+
+func (x *IncrementCounterRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IncrementCounterRequest.ProtoReflect.Descriptor instead.
+func (*IncrementCounterRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *IncrementCounterRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *IncrementCounterRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *IncrementCounterRequest) GetDelta() int64 {
+	if x != nil {
+		return x.Delta
+	}
+	return 0
+}
+
+type IncrementCounterResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         int64                  `protobuf:"varint,1,opt,name=value,proto3" json:"value,omitempty"` // the counter's value after the increment
+	Hlc           *v1.HLCTimestamp       `protobuf:"bytes,2,opt,name=hlc,proto3" json:"hlc,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IncrementCounterResponse) Reset() {
+	*x = IncrementCounterResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IncrementCounterResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IncrementCounterResponse) ProtoMessage() {}
+
+func (x *IncrementCounterResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IncrementCounterResponse.ProtoReflect.Descriptor instead.
+func (*IncrementCounterResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *IncrementCounterResponse) GetValue() int64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *IncrementCounterResponse) GetHlc() *v1.HLCTimestamp {
+	if x != nil {
+		return x.Hlc
+	}
+	return nil
+}
+
+type QueryEntitiesByBBoxRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MinLat        float64                `protobuf:"fixed64,1,opt,name=min_lat,json=minLat,proto3" json:"min_lat,omitempty"`
+	MaxLat        float64                `protobuf:"fixed64,2,opt,name=max_lat,json=maxLat,proto3" json:"max_lat,omitempty"`
+	MinLon        float64                `protobuf:"fixed64,3,opt,name=min_lon,json=minLon,proto3" json:"min_lon,omitempty"`
+	MaxLon        float64                `protobuf:"fixed64,4,opt,name=max_lon,json=maxLon,proto3" json:"max_lon,omitempty"`
+	TypeFilter    v1.EntityType          `protobuf:"varint,5,opt,name=type_filter,json=typeFilter,proto3,enum=entity.v1.EntityType" json:"type_filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryEntitiesByBBoxRequest) Reset() {
+	*x = QueryEntitiesByBBoxRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryEntitiesByBBoxRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryEntitiesByBBoxRequest) ProtoMessage() {}
+
+func (x *QueryEntitiesByBBoxRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryEntitiesByBBoxRequest.ProtoReflect.Descriptor instead.
+func (*QueryEntitiesByBBoxRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *QueryEntitiesByBBoxRequest) GetMinLat() float64 {
+	if x != nil {
+		return x.MinLat
+	}
+	return 0
+}
+
+func (x *QueryEntitiesByBBoxRequest) GetMaxLat() float64 {
+	if x != nil {
+		return x.MaxLat
+	}
+	return 0
+}
+
+func (x *QueryEntitiesByBBoxRequest) GetMinLon() float64 {
+	if x != nil {
+		return x.MinLon
+	}
+	return 0
+}
+
+func (x *QueryEntitiesByBBoxRequest) GetMaxLon() float64 {
+	if x != nil {
+		return x.MaxLon
+	}
+	return 0
+}
+
+func (x *QueryEntitiesByBBoxRequest) GetTypeFilter() v1.EntityType {
+	if x != nil {
+		return x.TypeFilter
+	}
+	return v1.EntityType(0)
+}
+
+type QueryEntitiesByBBoxResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entities      []*v1.Entity           `protobuf:"bytes,1,rep,name=entities,proto3" json:"entities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryEntitiesByBBoxResponse) Reset() {
+	*x = QueryEntitiesByBBoxResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryEntitiesByBBoxResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryEntitiesByBBoxResponse) ProtoMessage() {}
+
+func (x *QueryEntitiesByBBoxResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryEntitiesByBBoxResponse.ProtoReflect.Descriptor instead.
+func (*QueryEntitiesByBBoxResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *QueryEntitiesByBBoxResponse) GetEntities() []*v1.Entity {
+	if x != nil {
+		return x.Entities
+	}
+	return nil
+}
+
+type GetEntityHistoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEntityHistoryRequest) Reset() {
+	*x = GetEntityHistoryRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEntityHistoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEntityHistoryRequest) ProtoMessage() {}
+
+func (x *GetEntityHistoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEntityHistoryRequest.ProtoReflect.Descriptor instead.
+func (*GetEntityHistoryRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *GetEntityHistoryRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type GetEntityHistoryResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Each write's event type and the entity as it stood after it; a
+	// deletion carries the entity as it stood before, with the delete's HLC.
+	Versions      []*EntityEvent `protobuf:"bytes,2,rep,name=versions,proto3" json:"versions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetEntityHistoryResponse) Reset() {
+	*x = GetEntityHistoryResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetEntityHistoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetEntityHistoryResponse) ProtoMessage() {}
+
+func (x *GetEntityHistoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetEntityHistoryResponse.ProtoReflect.Descriptor instead.
+func (*GetEntityHistoryResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *GetEntityHistoryResponse) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetEntityHistoryResponse) GetVersions() []*EntityEvent {
+	if x != nil {
+		return x.Versions
+	}
+	return nil
+}
+
+type WriteOp struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Op:
+	//
+	//	*WriteOp_Create
+	//	*WriteOp_Update
+	//	*WriteOp_Patch
+	//	*WriteOp_Delete
+	//	*WriteOp_Increment
+	Op            isWriteOp_Op `protobuf_oneof:"op"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteOp) Reset() {
+	*x = WriteOp{}
+	mi := &file_store_v1_store_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteOp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteOp) ProtoMessage() {}
+
+func (x *WriteOp) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteOp.ProtoReflect.Descriptor instead.
+func (*WriteOp) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *WriteOp) GetOp() isWriteOp_Op {
+	if x != nil {
+		return x.Op
+	}
+	return nil
+}
+
+func (x *WriteOp) GetCreate() *CreateEntityRequest {
+	if x != nil {
+		if x, ok := x.Op.(*WriteOp_Create); ok {
+			return x.Create
+		}
+	}
+	return nil
+}
+
+func (x *WriteOp) GetUpdate() *UpdateEntityRequest {
+	if x != nil {
+		if x, ok := x.Op.(*WriteOp_Update); ok {
+			return x.Update
+		}
+	}
+	return nil
+}
+
+func (x *WriteOp) GetPatch() *PatchComponentRequest {
+	if x != nil {
+		if x, ok := x.Op.(*WriteOp_Patch); ok {
+			return x.Patch
+		}
+	}
+	return nil
+}
+
+func (x *WriteOp) GetDelete() *DeleteEntityRequest {
+	if x != nil {
+		if x, ok := x.Op.(*WriteOp_Delete); ok {
+			return x.Delete
+		}
+	}
+	return nil
+}
+
+func (x *WriteOp) GetIncrement() *IncrementCounterRequest {
+	if x != nil {
+		if x, ok := x.Op.(*WriteOp_Increment); ok {
+			return x.Increment
+		}
+	}
+	return nil
+}
+
+type isWriteOp_Op interface {
+	isWriteOp_Op()
+}
+
+type WriteOp_Create struct {
+	Create *CreateEntityRequest `protobuf:"bytes,1,opt,name=create,proto3,oneof"`
+}
+
+type WriteOp_Update struct {
+	Update *UpdateEntityRequest `protobuf:"bytes,2,opt,name=update,proto3,oneof"`
+}
+
+type WriteOp_Patch struct {
+	Patch *PatchComponentRequest `protobuf:"bytes,3,opt,name=patch,proto3,oneof"`
+}
+
+type WriteOp_Delete struct {
+	Delete *DeleteEntityRequest `protobuf:"bytes,4,opt,name=delete,proto3,oneof"`
+}
+
+type WriteOp_Increment struct {
+	Increment *IncrementCounterRequest `protobuf:"bytes,5,opt,name=increment,proto3,oneof"`
+}
+
+func (*WriteOp_Create) isWriteOp_Op() {}
+
+func (*WriteOp_Update) isWriteOp_Op() {}
+
+func (*WriteOp_Patch) isWriteOp_Op() {}
+
+func (*WriteOp_Delete) isWriteOp_Op() {}
+
+func (*WriteOp_Increment) isWriteOp_Op() {}
+
+type BatchWriteEntitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ops           []*WriteOp             `protobuf:"bytes,1,rep,name=ops,proto3" json:"ops,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchWriteEntitiesRequest) Reset() {
+	*x = BatchWriteEntitiesRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchWriteEntitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchWriteEntitiesRequest) ProtoMessage() {}
+
+func (x *BatchWriteEntitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchWriteEntitiesRequest.ProtoReflect.Descriptor instead.
+func (*BatchWriteEntitiesRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *BatchWriteEntitiesRequest) GetOps() []*WriteOp {
+	if x != nil {
+		return x.Ops
+	}
+	return nil
+}
+
+type WriteResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The gRPC status code and message the single-write RPC would have
+	// returned; OK (0) on success.
+	Code          int32      `protobuf:"varint,1,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string     `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Entity        *v1.Entity `protobuf:"bytes,3,opt,name=entity,proto3" json:"entity,omitempty"` // the entity as stored; unset for deletes and failures
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WriteResult) Reset() {
+	*x = WriteResult{}
+	mi := &file_store_v1_store_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WriteResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WriteResult) ProtoMessage() {}
+
+func (x *WriteResult) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WriteResult.ProtoReflect.Descriptor instead.
+func (*WriteResult) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *WriteResult) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *WriteResult) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *WriteResult) GetEntity() *v1.Entity {
+	if x != nil {
+		return x.Entity
+	}
+	return nil
+}
+
+type BatchWriteEntitiesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*WriteResult         `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"` // one per op, in order
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchWriteEntitiesResponse) Reset() {
+	*x = BatchWriteEntitiesResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchWriteEntitiesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchWriteEntitiesResponse) ProtoMessage() {}
+
+func (x *BatchWriteEntitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchWriteEntitiesResponse.ProtoReflect.Descriptor instead.
+func (*BatchWriteEntitiesResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *BatchWriteEntitiesResponse) GetResults() []*WriteResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type PublishEntitiesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entity        *v1.Entity             `protobuf:"bytes,1,opt,name=entity,proto3" json:"entity,omitempty"`
+	Ttl           *durationpb.Duration   `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"` // optional: expire the entity this long after the report
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishEntitiesRequest) Reset() {
+	*x = PublishEntitiesRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishEntitiesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishEntitiesRequest) ProtoMessage() {}
+
+func (x *PublishEntitiesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishEntitiesRequest.ProtoReflect.Descriptor instead.
+func (*PublishEntitiesRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *PublishEntitiesRequest) GetEntity() *v1.Entity {
+	if x != nil {
+		return x.Entity
+	}
+	return nil
+}
+
+func (x *PublishEntitiesRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+type PublishEntitiesResponse struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Accepted uint64                 `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected uint64                 `protobuf:"varint,2,opt,name=rejected,proto3" json:"rejected,omitempty"`
+	// The first refused reports, up to 1000, in order.
+	Failures      []*PublishFailure `protobuf:"bytes,3,rep,name=failures,proto3" json:"failures,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-func (x *DenyActionRequest) Reset() {
-	*x = DenyActionRequest{}
-	mi := &file_store_v1_store_proto_msgTypes[9]
+func (x *PublishEntitiesResponse) Reset() {
+	*x = PublishEntitiesResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *DenyActionRequest) String() string {
+func (x *PublishEntitiesResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*DenyActionRequest) ProtoMessage() {}
+func (*PublishEntitiesResponse) ProtoMessage() {}
 
-func (x *DenyActionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_store_v1_store_proto_msgTypes[9]
+func (x *PublishEntitiesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -519,53 +3330,638 @@ func (x *DenyActionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use DenyActionRequest.ProtoReflect.Descriptor instead.
-func (*DenyActionRequest) Descriptor() ([]byte, []int) {
-	return file_store_v1_store_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use PublishEntitiesResponse.ProtoReflect.Descriptor instead.
+func (*PublishEntitiesResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{52}
 }
 
-func (x *DenyActionRequest) GetEntityId() string {
+func (x *PublishEntitiesResponse) GetAccepted() uint64 {
+	if x != nil {
+		return x.Accepted
+	}
+	return 0
+}
+
+func (x *PublishEntitiesResponse) GetRejected() uint64 {
+	if x != nil {
+		return x.Rejected
+	}
+	return 0
+}
+
+func (x *PublishEntitiesResponse) GetFailures() []*PublishFailure {
+	if x != nil {
+		return x.Failures
+	}
+	return nil
+}
+
+type PublishFailure struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Index    uint64                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"` // the report's position in the stream, from 0
+	EntityId string                 `protobuf:"bytes,2,opt,name=entity_id,json=entityId,proto3" json:"entity_id,omitempty"`
+	// The gRPC status code and message the write failed with.
+	Code          int32  `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PublishFailure) Reset() {
+	*x = PublishFailure{}
+	mi := &file_store_v1_store_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PublishFailure) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PublishFailure) ProtoMessage() {}
+
+func (x *PublishFailure) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PublishFailure.ProtoReflect.Descriptor instead.
+func (*PublishFailure) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *PublishFailure) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *PublishFailure) GetEntityId() string {
 	if x != nil {
 		return x.EntityId
 	}
 	return ""
 }
 
+func (x *PublishFailure) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *PublishFailure) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+// Link relates two entities, e.g. a fused track to each source track it
+// was fused from, or an asset to the track it is assigned.
+type Link struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	FromId   string                 `protobuf:"bytes,1,opt,name=from_id,json=fromId,proto3" json:"from_id,omitempty"`
+	ToId     string                 `protobuf:"bytes,2,opt,name=to_id,json=toId,proto3" json:"to_id,omitempty"`
+	Relation string                 `protobuf:"bytes,3,opt,name=relation,proto3" json:"relation,omitempty"` // e.g. "fused_from"
+	// If set, from_id depends on to_id: deleting to_id deletes from_id too.
+	Cascade       bool `protobuf:"varint,4,opt,name=cascade,proto3" json:"cascade,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Link) Reset() {
+	*x = Link{}
+	mi := &file_store_v1_store_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Link) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Link) ProtoMessage() {}
+
+func (x *Link) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Link.ProtoReflect.Descriptor instead.
+func (*Link) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *Link) GetFromId() string {
+	if x != nil {
+		return x.FromId
+	}
+	return ""
+}
+
+func (x *Link) GetToId() string {
+	if x != nil {
+		return x.ToId
+	}
+	return ""
+}
+
+func (x *Link) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+func (x *Link) GetCascade() bool {
+	if x != nil {
+		return x.Cascade
+	}
+	return false
+}
+
+type AddLinkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Link          *Link                  `protobuf:"bytes,1,opt,name=link,proto3" json:"link,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddLinkRequest) Reset() {
+	*x = AddLinkRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddLinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddLinkRequest) ProtoMessage() {}
+
+func (x *AddLinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddLinkRequest.ProtoReflect.Descriptor instead.
+func (*AddLinkRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *AddLinkRequest) GetLink() *Link {
+	if x != nil {
+		return x.Link
+	}
+	return nil
+}
+
+type RemoveLinkRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FromId        string                 `protobuf:"bytes,1,opt,name=from_id,json=fromId,proto3" json:"from_id,omitempty"`
+	ToId          string                 `protobuf:"bytes,2,opt,name=to_id,json=toId,proto3" json:"to_id,omitempty"`
+	Relation      string                 `protobuf:"bytes,3,opt,name=relation,proto3" json:"relation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RemoveLinkRequest) Reset() {
+	*x = RemoveLinkRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[56]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RemoveLinkRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RemoveLinkRequest) ProtoMessage() {}
+
+func (x *RemoveLinkRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[56]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RemoveLinkRequest.ProtoReflect.Descriptor instead.
+func (*RemoveLinkRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{56}
+}
+
+func (x *RemoveLinkRequest) GetFromId() string {
+	if x != nil {
+		return x.FromId
+	}
+	return ""
+}
+
+func (x *RemoveLinkRequest) GetToId() string {
+	if x != nil {
+		return x.ToId
+	}
+	return ""
+}
+
+func (x *RemoveLinkRequest) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+type ListLinksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Relation      string                 `protobuf:"bytes,2,opt,name=relation,proto3" json:"relation,omitempty"` // if set, only links with this relation
+	Direction     LinkDirection          `protobuf:"varint,3,opt,name=direction,proto3,enum=store.v1.LinkDirection" json:"direction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLinksRequest) Reset() {
+	*x = ListLinksRequest{}
+	mi := &file_store_v1_store_proto_msgTypes[57]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLinksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLinksRequest) ProtoMessage() {}
+
+func (x *ListLinksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[57]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLinksRequest.ProtoReflect.Descriptor instead.
+func (*ListLinksRequest) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{57}
+}
+
+func (x *ListLinksRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ListLinksRequest) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+func (x *ListLinksRequest) GetDirection() LinkDirection {
+	if x != nil {
+		return x.Direction
+	}
+	return LinkDirection_LINK_DIRECTION_UNSPECIFIED
+}
+
+type ListLinksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Links         []*Link                `protobuf:"bytes,1,rep,name=links,proto3" json:"links,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLinksResponse) Reset() {
+	*x = ListLinksResponse{}
+	mi := &file_store_v1_store_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLinksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLinksResponse) ProtoMessage() {}
+
+func (x *ListLinksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_store_v1_store_proto_msgTypes[58]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLinksResponse.ProtoReflect.Descriptor instead.
+func (*ListLinksResponse) Descriptor() ([]byte, []int) {
+	return file_store_v1_store_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *ListLinksResponse) GetLinks() []*Link {
+	if x != nil {
+		return x.Links
+	}
+	return nil
+}
+
 var File_store_v1_store_proto protoreflect.FileDescriptor
 
 const file_store_v1_store_proto_rawDesc = "" +
 	"\n" +
-	"\x14store/v1/store.proto\x12\bstore.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x16entity/v1/entity.proto\"@\n" +
+	"\x14store/v1/store.proto\x12\bstore.v1\x1a\x19google/protobuf/any.proto\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\x16entity/v1/entity.proto\"m\n" +
 	"\x13CreateEntityRequest\x12)\n" +
-	"\x06entity\x18\x01 \x01(\v2\x11.entity.v1.EntityR\x06entity\"\"\n" +
+	"\x06entity\x18\x01 \x01(\v2\x11.entity.v1.EntityR\x06entity\x12+\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\"\"\n" +
 	"\x10GetEntityRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"M\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xa9\x01\n" +
 	"\x13ListEntitiesRequest\x126\n" +
 	"\vtype_filter\x18\x01 \x01(\x0e2\x15.entity.v1.EntityTypeR\n" +
-	"typeFilter\"E\n" +
+	"typeFilter\x123\n" +
+	"\afilters\x18\x02 \x03(\v2\x19.store.v1.ComponentFilterR\afilters\x12%\n" +
+	"\x0elabel_selector\x18\x03 \x01(\tR\rlabelSelector\"\x7f\n" +
+	"\x0fComponentFilter\x12\x1c\n" +
+	"\tcomponent\x18\x01 \x01(\tR\tcomponent\x12\x14\n" +
+	"\x05field\x18\x02 \x01(\tR\x05field\x12\"\n" +
+	"\x02op\x18\x03 \x01(\x0e2\x12.store.v1.FilterOpR\x02op\x12\x14\n" +
+	"\x05value\x18\x04 \x01(\tR\x05value\"E\n" +
 	"\x14ListEntitiesResponse\x12-\n" +
-	"\bentities\x18\x01 \x03(\v2\x11.entity.v1.EntityR\bentities\"@\n" +
+	"\bentities\x18\x01 \x03(\v2\x11.entity.v1.EntityR\bentities\"\xd8\x01\n" +
 	"\x13UpdateEntityRequest\x12)\n" +
-	"\x06entity\x18\x01 \x01(\v2\x11.entity.v1.EntityR\x06entity\"%\n" +
+	"\x06entity\x18\x01 \x01(\v2\x11.entity.v1.EntityR\x06entity\x12+\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\x12:\n" +
+	"\fexpected_hlc\x18\x03 \x01(\v2\x17.entity.v1.HLCTimestampR\vexpectedHlc\x12-\n" +
+	"\x05merge\x18\x04 \x03(\v2\x17.store.v1.MergeDecisionR\x05merge\"k\n" +
+	"\rMergeDecision\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x12\n" +
+	"\x04kept\x18\x02 \x01(\tR\x04kept\x12\x1a\n" +
+	"\bstrategy\x18\x03 \x01(\tR\bstrategy\x12\x18\n" +
+	"\aremoved\x18\x04 \x01(\bR\aremoved\"P\n" +
 	"\x13DeleteEntityRequest\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\"N\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12)\n" +
+	"\x03hlc\x18\x02 \x01(\v2\x17.entity.v1.HLCTimestampR\x03hlc\"\x8c\x02\n" +
 	"\x14WatchEntitiesRequest\x126\n" +
 	"\vtype_filter\x18\x01 \x01(\x0e2\x15.entity.v1.EntityTypeR\n" +
-	"typeFilter\"\x82\x01\n" +
+	"typeFilter\x12%\n" +
+	"\x0esince_sequence\x18\x02 \x01(\x04R\rsinceSequence\x12%\n" +
+	"\x0elabel_selector\x18\x03 \x01(\tR\rlabelSelector\x12\x1e\n" +
+	"\n" +
+	"components\x18\x04 \x03(\tR\n" +
+	"components\x12)\n" +
+	"\x04bbox\x18\x05 \x01(\v2\x15.store.v1.BoundingBoxR\x04bbox\x12#\n" +
+	"\rinitial_state\x18\x06 \x01(\bR\finitialState\"q\n" +
+	"\vBoundingBox\x12\x17\n" +
+	"\amin_lat\x18\x01 \x01(\x01R\x06minLat\x12\x17\n" +
+	"\amax_lat\x18\x02 \x01(\x01R\x06maxLat\x12\x17\n" +
+	"\amin_lon\x18\x03 \x01(\x01R\x06minLon\x12\x17\n" +
+	"\amax_lon\x18\x04 \x01(\x01R\x06maxLon\"\xc6\x01\n" +
 	"\vEntityEvent\x12'\n" +
 	"\x04type\x18\x01 \x01(\x0e2\x13.store.v1.EventTypeR\x04type\x12)\n" +
 	"\x06entity\x18\x02 \x01(\v2\x11.entity.v1.EntityR\x06entity\x12\x1f\n" +
 	"\vorigin_node\x18\x03 \x01(\tR\n" +
-	"originNode\"3\n" +
+	"originNode\x12\x1a\n" +
+	"\bsequence\x18\x04 \x01(\x04R\bsequence\x12\x12\n" +
+	"\x04hops\x18\x05 \x01(\rR\x04hops\x12\x12\n" +
+	"\x04path\x18\x06 \x03(\tR\x04path\"d\n" +
+	"\x0fTransactRequest\x12,\n" +
+	"\x05reads\x18\x01 \x03(\v2\x16.store.v1.TransactReadR\x05reads\x12#\n" +
+	"\x03ops\x18\x02 \x03(\v2\x11.store.v1.WriteOpR\x03ops\"Z\n" +
+	"\fTransactRead\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12:\n" +
+	"\fexpected_hlc\x18\x02 \x01(\v2\x17.entity.v1.HLCTimestampR\vexpectedHlc\"l\n" +
+	"\x10TransactResponse\x12'\n" +
+	"\x05reads\x18\x01 \x03(\v2\x11.entity.v1.EntityR\x05reads\x12/\n" +
+	"\aresults\x18\x02 \x03(\v2\x15.store.v1.WriteResultR\aresults\"|\n" +
+	"\x1bListArchivedEntitiesRequest\x126\n" +
+	"\vtype_filter\x18\x01 \x01(\x0e2\x15.entity.v1.EntityTypeR\n" +
+	"typeFilter\x12%\n" +
+	"\x0elabel_selector\x18\x02 \x01(\tR\rlabelSelector\"\xa5\x01\n" +
+	"\x0eArchivedEntity\x12)\n" +
+	"\x06entity\x18\x01 \x01(\v2\x11.entity.v1.EntityR\x06entity\x12+\n" +
+	"\x06reason\x18\x02 \x01(\x0e2\x13.store.v1.EventTypeR\x06reason\x12;\n" +
+	"\varchived_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"archivedAt\"T\n" +
+	"\x1cListArchivedEntitiesResponse\x124\n" +
+	"\bentities\x18\x01 \x03(\v2\x18.store.v1.ArchivedEntityR\bentities\"G\n" +
+	"\x12GetAuditLogRequest\x12\x1b\n" +
+	"\tentity_id\x18\x01 \x01(\tR\bentityId\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\rR\x05limit\"\xd0\x02\n" +
+	"\n" +
+	"AuditEntry\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12)\n" +
+	"\x03hlc\x18\x02 \x01(\v2\x17.entity.v1.HLCTimestampR\x03hlc\x12\x16\n" +
+	"\x06method\x18\x03 \x01(\tR\x06method\x12\x12\n" +
+	"\x04peer\x18\x04 \x01(\tR\x04peer\x12\x12\n" +
+	"\x04role\x18\x05 \x01(\tR\x04role\x12\x19\n" +
+	"\btoken_id\x18\x06 \x01(\tR\atokenId\x12/\n" +
+	"\atargets\x18\a \x03(\v2\x15.store.v1.AuditTargetR\atargets\x12\x12\n" +
+	"\x04code\x18\b \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\t \x01(\tR\amessage\x12-\n" +
+	"\x05merge\x18\n" +
+	" \x03(\v2\x17.store.v1.MergeDecisionR\x05merge\"Q\n" +
+	"\vAuditTarget\x12\x1b\n" +
+	"\tentity_id\x18\x01 \x01(\tR\bentityId\x12%\n" +
+	"\x0ecomponent_keys\x18\x02 \x03(\tR\rcomponentKeys\"E\n" +
+	"\x13GetAuditLogResponse\x12.\n" +
+	"\aentries\x18\x01 \x03(\v2\x14.store.v1.AuditEntryR\aentries\"C\n" +
+	"\x15DigestEntitiesRequest\x12\x14\n" +
+	"\x05level\x18\x01 \x01(\rR\x05level\x12\x14\n" +
+	"\x05nodes\x18\x02 \x03(\rR\x05nodes\"D\n" +
+	"\x16DigestEntitiesResponse\x12*\n" +
+	"\x05nodes\x18\x01 \x03(\v2\x14.store.v1.DigestNodeR\x05nodes\"j\n" +
+	"\n" +
+	"DigestNode\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\rR\x05index\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\fR\x04hash\x122\n" +
+	"\bentities\x18\x03 \x03(\v2\x16.store.v1.EntityDigestR\bentities\"2\n" +
+	"\fEntityDigest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\fR\x04hash\"m\n" +
+	"\x15ReplicateBatchRequest\x12-\n" +
+	"\x06events\x18\x01 \x03(\v2\x15.store.v1.EntityEventR\x06events\x12%\n" +
+	"\x0eskip_unchanged\x18\x02 \x01(\bR\rskipUnchanged\"a\n" +
+	"\x16ReplicateBatchResponse\x12/\n" +
+	"\aresults\x18\x01 \x03(\v2\x15.store.v1.WriteResultR\aresults\x12\x16\n" +
+	"\x06merged\x18\x02 \x01(\rR\x06merged\"=\n" +
+	"\x14StreamChangesRequest\x12%\n" +
+	"\x0esince_sequence\x18\x01 \x01(\x04R\rsinceSequence\"\xec\x02\n" +
+	"\fChangeRecord\x12\x1a\n" +
+	"\bsequence\x18\x01 \x01(\x04R\bsequence\x12'\n" +
+	"\x04type\x18\x02 \x01(\x0e2\x13.store.v1.EventTypeR\x04type\x12\x1b\n" +
+	"\tentity_id\x18\x03 \x01(\tR\bentityId\x126\n" +
+	"\ventity_type\x18\x04 \x01(\x0e2\x15.entity.v1.EntityTypeR\n" +
+	"entityType\x12\x1f\n" +
+	"\vorigin_node\x18\x05 \x01(\tR\n" +
+	"originNode\x12)\n" +
+	"\x03hlc\x18\x06 \x01(\v2\x17.entity.v1.HLCTimestampR\x03hlc\x12;\n" +
+	"\vcommit_time\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"commitTime\x129\n" +
+	"\n" +
+	"components\x18\b \x03(\v2\x19.store.v1.ComponentChangeR\n" +
+	"components\"\x89\x01\n" +
+	"\x0fComponentChange\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x121\n" +
+	"\told_value\x18\x02 \x01(\v2\x14.google.protobuf.AnyR\boldValue\x121\n" +
+	"\tnew_value\x18\x03 \x01(\v2\x14.google.protobuf.AnyR\bnewValue\"O\n" +
 	"\x14ApproveActionRequest\x12\x1b\n" +
-	"\tentity_id\x18\x01 \x01(\tR\bentityId\"0\n" +
+	"\tentity_id\x18\x01 \x01(\tR\bentityId\x12\x1a\n" +
+	"\boperator\x18\x02 \x01(\tR\boperator\"L\n" +
 	"\x11DenyActionRequest\x12\x1b\n" +
-	"\tentity_id\x18\x01 \x01(\tR\bentityId*o\n" +
+	"\tentity_id\x18\x01 \x01(\tR\bentityId\x12\x1a\n" +
+	"\boperator\x18\x02 \x01(\tR\boperator\"Q\n" +
+	"\x17SnapshotEntitiesRequest\x126\n" +
+	"\vtype_filter\x18\x01 \x01(\x0e2\x15.entity.v1.EntityTypeR\n" +
+	"typeFilter\"C\n" +
+	"\x16RestoreEntitiesRequest\x12)\n" +
+	"\x06entity\x18\x01 \x01(\v2\x11.entity.v1.EntityR\x06entity\"i\n" +
+	"\x17RestoreEntitiesResponse\x12\x18\n" +
+	"\acreated\x18\x01 \x01(\x05R\acreated\x12\x1a\n" +
+	"\breplaced\x18\x02 \x01(\x05R\breplaced\x12\x18\n" +
+	"\askipped\x18\x03 \x01(\x05R\askipped\"7\n" +
+	"\x13GetComponentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\"u\n" +
+	"\x14GetComponentResponse\x122\n" +
+	"\tcomponent\x18\x01 \x01(\v2\x14.google.protobuf.AnyR\tcomponent\x12)\n" +
+	"\x03hlc\x18\x02 \x01(\v2\x17.entity.v1.HLCTimestampR\x03hlc\"\xfa\x01\n" +
+	"\x15PatchComponentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12O\n" +
+	"\n" +
+	"components\x18\x02 \x03(\v2/.store.v1.PatchComponentRequest.ComponentsEntryR\n" +
+	"components\x12+\n" +
+	"\x03ttl\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\x1aS\n" +
+	"\x0fComponentsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12*\n" +
+	"\x05value\x18\x02 \x01(\v2\x14.google.protobuf.AnyR\x05value:\x028\x01\"C\n" +
+	"\x16PatchComponentResponse\x12)\n" +
+	"\x03hlc\x18\x01 \x01(\v2\x17.entity.v1.HLCTimestampR\x03hlc\"<\n" +
+	"\x16RemoveComponentRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04keys\x18\x02 \x03(\tR\x04keys\"D\n" +
+	"\x17RemoveComponentResponse\x12)\n" +
+	"\x03hlc\x18\x01 \x01(\v2\x17.entity.v1.HLCTimestampR\x03hlc\"Q\n" +
+	"\x17IncrementCounterRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12\x14\n" +
+	"\x05delta\x18\x03 \x01(\x03R\x05delta\"[\n" +
+	"\x18IncrementCounterResponse\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\x03R\x05value\x12)\n" +
+	"\x03hlc\x18\x02 \x01(\v2\x17.entity.v1.HLCTimestampR\x03hlc\"\xb8\x01\n" +
+	"\x1aQueryEntitiesByBBoxRequest\x12\x17\n" +
+	"\amin_lat\x18\x01 \x01(\x01R\x06minLat\x12\x17\n" +
+	"\amax_lat\x18\x02 \x01(\x01R\x06maxLat\x12\x17\n" +
+	"\amin_lon\x18\x03 \x01(\x01R\x06minLon\x12\x17\n" +
+	"\amax_lon\x18\x04 \x01(\x01R\x06maxLon\x126\n" +
+	"\vtype_filter\x18\x05 \x01(\x0e2\x15.entity.v1.EntityTypeR\n" +
+	"typeFilter\"L\n" +
+	"\x1bQueryEntitiesByBBoxResponse\x12-\n" +
+	"\bentities\x18\x01 \x03(\v2\x11.entity.v1.EntityR\bentities\")\n" +
+	"\x17GetEntityHistoryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"]\n" +
+	"\x18GetEntityHistoryResponse\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x121\n" +
+	"\bversions\x18\x02 \x03(\v2\x15.store.v1.EntityEventR\bversions\"\xb6\x02\n" +
+	"\aWriteOp\x127\n" +
+	"\x06create\x18\x01 \x01(\v2\x1d.store.v1.CreateEntityRequestH\x00R\x06create\x127\n" +
+	"\x06update\x18\x02 \x01(\v2\x1d.store.v1.UpdateEntityRequestH\x00R\x06update\x127\n" +
+	"\x05patch\x18\x03 \x01(\v2\x1f.store.v1.PatchComponentRequestH\x00R\x05patch\x127\n" +
+	"\x06delete\x18\x04 \x01(\v2\x1d.store.v1.DeleteEntityRequestH\x00R\x06delete\x12A\n" +
+	"\tincrement\x18\x05 \x01(\v2!.store.v1.IncrementCounterRequestH\x00R\tincrementB\x04\n" +
+	"\x02op\"@\n" +
+	"\x19BatchWriteEntitiesRequest\x12#\n" +
+	"\x03ops\x18\x01 \x03(\v2\x11.store.v1.WriteOpR\x03ops\"f\n" +
+	"\vWriteResult\x12\x12\n" +
+	"\x04code\x18\x01 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12)\n" +
+	"\x06entity\x18\x03 \x01(\v2\x11.entity.v1.EntityR\x06entity\"M\n" +
+	"\x1aBatchWriteEntitiesResponse\x12/\n" +
+	"\aresults\x18\x01 \x03(\v2\x15.store.v1.WriteResultR\aresults\"p\n" +
+	"\x16PublishEntitiesRequest\x12)\n" +
+	"\x06entity\x18\x01 \x01(\v2\x11.entity.v1.EntityR\x06entity\x12+\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\"\x87\x01\n" +
+	"\x17PublishEntitiesResponse\x12\x1a\n" +
+	"\baccepted\x18\x01 \x01(\x04R\baccepted\x12\x1a\n" +
+	"\brejected\x18\x02 \x01(\x04R\brejected\x124\n" +
+	"\bfailures\x18\x03 \x03(\v2\x18.store.v1.PublishFailureR\bfailures\"q\n" +
+	"\x0ePublishFailure\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x04R\x05index\x12\x1b\n" +
+	"\tentity_id\x18\x02 \x01(\tR\bentityId\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"j\n" +
+	"\x04Link\x12\x17\n" +
+	"\afrom_id\x18\x01 \x01(\tR\x06fromId\x12\x13\n" +
+	"\x05to_id\x18\x02 \x01(\tR\x04toId\x12\x1a\n" +
+	"\brelation\x18\x03 \x01(\tR\brelation\x12\x18\n" +
+	"\acascade\x18\x04 \x01(\bR\acascade\"4\n" +
+	"\x0eAddLinkRequest\x12\"\n" +
+	"\x04link\x18\x01 \x01(\v2\x0e.store.v1.LinkR\x04link\"]\n" +
+	"\x11RemoveLinkRequest\x12\x17\n" +
+	"\afrom_id\x18\x01 \x01(\tR\x06fromId\x12\x13\n" +
+	"\x05to_id\x18\x02 \x01(\tR\x04toId\x12\x1a\n" +
+	"\brelation\x18\x03 \x01(\tR\brelation\"u\n" +
+	"\x10ListLinksRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\brelation\x18\x02 \x01(\tR\brelation\x125\n" +
+	"\tdirection\x18\x03 \x01(\x0e2\x17.store.v1.LinkDirectionR\tdirection\"9\n" +
+	"\x11ListLinksResponse\x12$\n" +
+	"\x05links\x18\x01 \x03(\v2\x0e.store.v1.LinkR\x05links*\x91\x01\n" +
+	"\bFilterOp\x12\x19\n" +
+	"\x15FILTER_OP_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fFILTER_OP_EQ\x10\x01\x12\x10\n" +
+	"\fFILTER_OP_NE\x10\x02\x12\x10\n" +
+	"\fFILTER_OP_LT\x10\x03\x12\x10\n" +
+	"\fFILTER_OP_LE\x10\x04\x12\x10\n" +
+	"\fFILTER_OP_GT\x10\x05\x12\x10\n" +
+	"\fFILTER_OP_GE\x10\x06*\x87\x01\n" +
 	"\tEventType\x12\x1a\n" +
 	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x16\n" +
 	"\x12EVENT_TYPE_CREATED\x10\x01\x12\x16\n" +
 	"\x12EVENT_TYPE_UPDATED\x10\x02\x12\x16\n" +
-	"\x12EVENT_TYPE_DELETED\x10\x032\xb6\x04\n" +
+	"\x12EVENT_TYPE_DELETED\x10\x03\x12\x16\n" +
+	"\x12EVENT_TYPE_EXPIRED\x10\x04*i\n" +
+	"\rLinkDirection\x12\x1e\n" +
+	"\x1aLINK_DIRECTION_UNSPECIFIED\x10\x00\x12\x1b\n" +
+	"\x17LINK_DIRECTION_OUTGOING\x10\x01\x12\x1b\n" +
+	"\x17LINK_DIRECTION_INCOMING\x10\x022\xd6\x10\n" +
 	"\x12EntityStoreService\x12@\n" +
 	"\fCreateEntity\x12\x1d.store.v1.CreateEntityRequest\x1a\x11.entity.v1.Entity\x12:\n" +
 	"\tGetEntity\x12\x1a.store.v1.GetEntityRequest\x1a\x11.entity.v1.Entity\x12M\n" +
@@ -575,7 +3971,27 @@ const file_store_v1_store_proto_rawDesc = "" +
 	"\rWatchEntities\x12\x1e.store.v1.WatchEntitiesRequest\x1a\x15.store.v1.EntityEvent0\x01\x12B\n" +
 	"\rApproveAction\x12\x1e.store.v1.ApproveActionRequest\x1a\x11.entity.v1.Entity\x12<\n" +
 	"\n" +
-	"DenyAction\x12\x1b.store.v1.DenyActionRequest\x1a\x11.entity.v1.EntityB4Z2github.com/boshu2/lattice-lab/gen/store/v1;storev1b\x06proto3"
+	"DenyAction\x12\x1b.store.v1.DenyActionRequest\x1a\x11.entity.v1.Entity\x12J\n" +
+	"\x10SnapshotEntities\x12!.store.v1.SnapshotEntitiesRequest\x1a\x11.entity.v1.Entity0\x01\x12X\n" +
+	"\x0fRestoreEntities\x12 .store.v1.RestoreEntitiesRequest\x1a!.store.v1.RestoreEntitiesResponse(\x01\x12M\n" +
+	"\fGetComponent\x12\x1d.store.v1.GetComponentRequest\x1a\x1e.store.v1.GetComponentResponse\x12S\n" +
+	"\x0ePatchComponent\x12\x1f.store.v1.PatchComponentRequest\x1a .store.v1.PatchComponentResponse\x12V\n" +
+	"\x0fRemoveComponent\x12 .store.v1.RemoveComponentRequest\x1a!.store.v1.RemoveComponentResponse\x12Y\n" +
+	"\x10IncrementCounter\x12!.store.v1.IncrementCounterRequest\x1a\".store.v1.IncrementCounterResponse\x12b\n" +
+	"\x13QueryEntitiesByBBox\x12$.store.v1.QueryEntitiesByBBoxRequest\x1a%.store.v1.QueryEntitiesByBBoxResponse\x12Y\n" +
+	"\x10GetEntityHistory\x12!.store.v1.GetEntityHistoryRequest\x1a\".store.v1.GetEntityHistoryResponse\x12_\n" +
+	"\x12BatchWriteEntities\x12#.store.v1.BatchWriteEntitiesRequest\x1a$.store.v1.BatchWriteEntitiesResponse\x12X\n" +
+	"\x0fPublishEntities\x12 .store.v1.PublishEntitiesRequest\x1a!.store.v1.PublishEntitiesResponse(\x01\x123\n" +
+	"\aAddLink\x12\x18.store.v1.AddLinkRequest\x1a\x0e.store.v1.Link\x12A\n" +
+	"\n" +
+	"RemoveLink\x12\x1b.store.v1.RemoveLinkRequest\x1a\x16.google.protobuf.Empty\x12D\n" +
+	"\tListLinks\x12\x1a.store.v1.ListLinksRequest\x1a\x1b.store.v1.ListLinksResponse\x12I\n" +
+	"\rStreamChanges\x12\x1e.store.v1.StreamChangesRequest\x1a\x16.store.v1.ChangeRecord0\x01\x12A\n" +
+	"\bTransact\x12\x19.store.v1.TransactRequest\x1a\x1a.store.v1.TransactResponse\x12e\n" +
+	"\x14ListArchivedEntities\x12%.store.v1.ListArchivedEntitiesRequest\x1a&.store.v1.ListArchivedEntitiesResponse\x12J\n" +
+	"\vGetAuditLog\x12\x1c.store.v1.GetAuditLogRequest\x1a\x1d.store.v1.GetAuditLogResponse\x12S\n" +
+	"\x0eDigestEntities\x12\x1f.store.v1.DigestEntitiesRequest\x1a .store.v1.DigestEntitiesResponse\x12S\n" +
+	"\x0eReplicateBatch\x12\x1f.store.v1.ReplicateBatchRequest\x1a .store.v1.ReplicateBatchResponseB4Z2github.com/boshu2/lattice-lab/gen/store/v1;storev1b\x06proto3"
 
 var (
 	file_store_v1_store_proto_rawDescOnce sync.Once
@@ -589,53 +4005,208 @@ func file_store_v1_store_proto_rawDescGZIP() []byte {
 	return file_store_v1_store_proto_rawDescData
 }
 
-var file_store_v1_store_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_store_v1_store_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_store_v1_store_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_store_v1_store_proto_msgTypes = make([]protoimpl.MessageInfo, 60)
 var file_store_v1_store_proto_goTypes = []any{
-	(EventType)(0),               // 0: store.v1.EventType
-	(*CreateEntityRequest)(nil),  // 1: store.v1.CreateEntityRequest
-	(*GetEntityRequest)(nil),     // 2: store.v1.GetEntityRequest
-	(*ListEntitiesRequest)(nil),  // 3: store.v1.ListEntitiesRequest
-	(*ListEntitiesResponse)(nil), // 4: store.v1.ListEntitiesResponse
-	(*UpdateEntityRequest)(nil),  // 5: store.v1.UpdateEntityRequest
-	(*DeleteEntityRequest)(nil),  // 6: store.v1.DeleteEntityRequest
-	(*WatchEntitiesRequest)(nil), // 7: store.v1.WatchEntitiesRequest
-	(*EntityEvent)(nil),          // 8: store.v1.EntityEvent
-	(*ApproveActionRequest)(nil), // 9: store.v1.ApproveActionRequest
-	(*DenyActionRequest)(nil),    // 10: store.v1.DenyActionRequest
-	(*v1.Entity)(nil),            // 11: entity.v1.Entity
-	(v1.EntityType)(0),           // 12: entity.v1.EntityType
-	(*emptypb.Empty)(nil),        // 13: google.protobuf.Empty
+	(FilterOp)(0),                        // 0: store.v1.FilterOp
+	(EventType)(0),                       // 1: store.v1.EventType
+	(LinkDirection)(0),                   // 2: store.v1.LinkDirection
+	(*CreateEntityRequest)(nil),          // 3: store.v1.CreateEntityRequest
+	(*GetEntityRequest)(nil),             // 4: store.v1.GetEntityRequest
+	(*ListEntitiesRequest)(nil),          // 5: store.v1.ListEntitiesRequest
+	(*ComponentFilter)(nil),              // 6: store.v1.ComponentFilter
+	(*ListEntitiesResponse)(nil),         // 7: store.v1.ListEntitiesResponse
+	(*UpdateEntityRequest)(nil),          // 8: store.v1.UpdateEntityRequest
+	(*MergeDecision)(nil),                // 9: store.v1.MergeDecision
+	(*DeleteEntityRequest)(nil),          // 10: store.v1.DeleteEntityRequest
+	(*WatchEntitiesRequest)(nil),         // 11: store.v1.WatchEntitiesRequest
+	(*BoundingBox)(nil),                  // 12: store.v1.BoundingBox
+	(*EntityEvent)(nil),                  // 13: store.v1.EntityEvent
+	(*TransactRequest)(nil),              // 14: store.v1.TransactRequest
+	(*TransactRead)(nil),                 // 15: store.v1.TransactRead
+	(*TransactResponse)(nil),             // 16: store.v1.TransactResponse
+	(*ListArchivedEntitiesRequest)(nil),  // 17: store.v1.ListArchivedEntitiesRequest
+	(*ArchivedEntity)(nil),               // 18: store.v1.ArchivedEntity
+	(*ListArchivedEntitiesResponse)(nil), // 19: store.v1.ListArchivedEntitiesResponse
+	(*GetAuditLogRequest)(nil),           // 20: store.v1.GetAuditLogRequest
+	(*AuditEntry)(nil),                   // 21: store.v1.AuditEntry
+	(*AuditTarget)(nil),                  // 22: store.v1.AuditTarget
+	(*GetAuditLogResponse)(nil),          // 23: store.v1.GetAuditLogResponse
+	(*DigestEntitiesRequest)(nil),        // 24: store.v1.DigestEntitiesRequest
+	(*DigestEntitiesResponse)(nil),       // 25: store.v1.DigestEntitiesResponse
+	(*DigestNode)(nil),                   // 26: store.v1.DigestNode
+	(*EntityDigest)(nil),                 // 27: store.v1.EntityDigest
+	(*ReplicateBatchRequest)(nil),        // 28: store.v1.ReplicateBatchRequest
+	(*ReplicateBatchResponse)(nil),       // 29: store.v1.ReplicateBatchResponse
+	(*StreamChangesRequest)(nil),         // 30: store.v1.StreamChangesRequest
+	(*ChangeRecord)(nil),                 // 31: store.v1.ChangeRecord
+	(*ComponentChange)(nil),              // 32: store.v1.ComponentChange
+	(*ApproveActionRequest)(nil),         // 33: store.v1.ApproveActionRequest
+	(*DenyActionRequest)(nil),            // 34: store.v1.DenyActionRequest
+	(*SnapshotEntitiesRequest)(nil),      // 35: store.v1.SnapshotEntitiesRequest
+	(*RestoreEntitiesRequest)(nil),       // 36: store.v1.RestoreEntitiesRequest
+	(*RestoreEntitiesResponse)(nil),      // 37: store.v1.RestoreEntitiesResponse
+	(*GetComponentRequest)(nil),          // 38: store.v1.GetComponentRequest
+	(*GetComponentResponse)(nil),         // 39: store.v1.GetComponentResponse
+	(*PatchComponentRequest)(nil),        // 40: store.v1.PatchComponentRequest
+	(*PatchComponentResponse)(nil),       // 41: store.v1.PatchComponentResponse
+	(*RemoveComponentRequest)(nil),       // 42: store.v1.RemoveComponentRequest
+	(*RemoveComponentResponse)(nil),      // 43: store.v1.RemoveComponentResponse
+	(*IncrementCounterRequest)(nil),      // 44: store.v1.IncrementCounterRequest
+	(*IncrementCounterResponse)(nil),     // 45: store.v1.IncrementCounterResponse
+	(*QueryEntitiesByBBoxRequest)(nil),   // 46: store.v1.QueryEntitiesByBBoxRequest
+	(*QueryEntitiesByBBoxResponse)(nil),  // 47: store.v1.QueryEntitiesByBBoxResponse
+	(*GetEntityHistoryRequest)(nil),      // 48: store.v1.GetEntityHistoryRequest
+	(*GetEntityHistoryResponse)(nil),     // 49: store.v1.GetEntityHistoryResponse
+	(*WriteOp)(nil),                      // 50: store.v1.WriteOp
+	(*BatchWriteEntitiesRequest)(nil),    // 51: store.v1.BatchWriteEntitiesRequest
+	(*WriteResult)(nil),                  // 52: store.v1.WriteResult
+	(*BatchWriteEntitiesResponse)(nil),   // 53: store.v1.BatchWriteEntitiesResponse
+	(*PublishEntitiesRequest)(nil),       // 54: store.v1.PublishEntitiesRequest
+	(*PublishEntitiesResponse)(nil),      // 55: store.v1.PublishEntitiesResponse
+	(*PublishFailure)(nil),               // 56: store.v1.PublishFailure
+	(*Link)(nil),                         // 57: store.v1.Link
+	(*AddLinkRequest)(nil),               // 58: store.v1.AddLinkRequest
+	(*RemoveLinkRequest)(nil),            // 59: store.v1.RemoveLinkRequest
+	(*ListLinksRequest)(nil),             // 60: store.v1.ListLinksRequest
+	(*ListLinksResponse)(nil),            // 61: store.v1.ListLinksResponse
+	nil,                                  // 62: store.v1.PatchComponentRequest.ComponentsEntry
+	(*v1.Entity)(nil),                    // 63: entity.v1.Entity
+	(*durationpb.Duration)(nil),          // 64: google.protobuf.Duration
+	(v1.EntityType)(0),                   // 65: entity.v1.EntityType
+	(*v1.HLCTimestamp)(nil),              // 66: entity.v1.HLCTimestamp
+	(*timestamppb.Timestamp)(nil),        // 67: google.protobuf.Timestamp
+	(*anypb.Any)(nil),                    // 68: google.protobuf.Any
+	(*emptypb.Empty)(nil),                // 69: google.protobuf.Empty
 }
 var file_store_v1_store_proto_depIdxs = []int32{
-	11, // 0: store.v1.CreateEntityRequest.entity:type_name -> entity.v1.Entity
-	12, // 1: store.v1.ListEntitiesRequest.type_filter:type_name -> entity.v1.EntityType
-	11, // 2: store.v1.ListEntitiesResponse.entities:type_name -> entity.v1.Entity
-	11, // 3: store.v1.UpdateEntityRequest.entity:type_name -> entity.v1.Entity
-	12, // 4: store.v1.WatchEntitiesRequest.type_filter:type_name -> entity.v1.EntityType
-	0,  // 5: store.v1.EntityEvent.type:type_name -> store.v1.EventType
-	11, // 6: store.v1.EntityEvent.entity:type_name -> entity.v1.Entity
-	1,  // 7: store.v1.EntityStoreService.CreateEntity:input_type -> store.v1.CreateEntityRequest
-	2,  // 8: store.v1.EntityStoreService.GetEntity:input_type -> store.v1.GetEntityRequest
-	3,  // 9: store.v1.EntityStoreService.ListEntities:input_type -> store.v1.ListEntitiesRequest
-	5,  // 10: store.v1.EntityStoreService.UpdateEntity:input_type -> store.v1.UpdateEntityRequest
-	6,  // 11: store.v1.EntityStoreService.DeleteEntity:input_type -> store.v1.DeleteEntityRequest
-	7,  // 12: store.v1.EntityStoreService.WatchEntities:input_type -> store.v1.WatchEntitiesRequest
-	9,  // 13: store.v1.EntityStoreService.ApproveAction:input_type -> store.v1.ApproveActionRequest
-	10, // 14: store.v1.EntityStoreService.DenyAction:input_type -> store.v1.DenyActionRequest
-	11, // 15: store.v1.EntityStoreService.CreateEntity:output_type -> entity.v1.Entity
-	11, // 16: store.v1.EntityStoreService.GetEntity:output_type -> entity.v1.Entity
-	4,  // 17: store.v1.EntityStoreService.ListEntities:output_type -> store.v1.ListEntitiesResponse
-	11, // 18: store.v1.EntityStoreService.UpdateEntity:output_type -> entity.v1.Entity
-	13, // 19: store.v1.EntityStoreService.DeleteEntity:output_type -> google.protobuf.Empty
-	8,  // 20: store.v1.EntityStoreService.WatchEntities:output_type -> store.v1.EntityEvent
-	11, // 21: store.v1.EntityStoreService.ApproveAction:output_type -> entity.v1.Entity
-	11, // 22: store.v1.EntityStoreService.DenyAction:output_type -> entity.v1.Entity
-	15, // [15:23] is the sub-list for method output_type
-	7,  // [7:15] is the sub-list for method input_type
-	7,  // [7:7] is the sub-list for extension type_name
-	7,  // [7:7] is the sub-list for extension extendee
-	0,  // [0:7] is the sub-list for field type_name
+	63, // 0: store.v1.CreateEntityRequest.entity:type_name -> entity.v1.Entity
+	64, // 1: store.v1.CreateEntityRequest.ttl:type_name -> google.protobuf.Duration
+	65, // 2: store.v1.ListEntitiesRequest.type_filter:type_name -> entity.v1.EntityType
+	6,  // 3: store.v1.ListEntitiesRequest.filters:type_name -> store.v1.ComponentFilter
+	0,  // 4: store.v1.ComponentFilter.op:type_name -> store.v1.FilterOp
+	63, // 5: store.v1.ListEntitiesResponse.entities:type_name -> entity.v1.Entity
+	63, // 6: store.v1.UpdateEntityRequest.entity:type_name -> entity.v1.Entity
+	64, // 7: store.v1.UpdateEntityRequest.ttl:type_name -> google.protobuf.Duration
+	66, // 8: store.v1.UpdateEntityRequest.expected_hlc:type_name -> entity.v1.HLCTimestamp
+	9,  // 9: store.v1.UpdateEntityRequest.merge:type_name -> store.v1.MergeDecision
+	66, // 10: store.v1.DeleteEntityRequest.hlc:type_name -> entity.v1.HLCTimestamp
+	65, // 11: store.v1.WatchEntitiesRequest.type_filter:type_name -> entity.v1.EntityType
+	12, // 12: store.v1.WatchEntitiesRequest.bbox:type_name -> store.v1.BoundingBox
+	1,  // 13: store.v1.EntityEvent.type:type_name -> store.v1.EventType
+	63, // 14: store.v1.EntityEvent.entity:type_name -> entity.v1.Entity
+	15, // 15: store.v1.TransactRequest.reads:type_name -> store.v1.TransactRead
+	50, // 16: store.v1.TransactRequest.ops:type_name -> store.v1.WriteOp
+	66, // 17: store.v1.TransactRead.expected_hlc:type_name -> entity.v1.HLCTimestamp
+	63, // 18: store.v1.TransactResponse.reads:type_name -> entity.v1.Entity
+	52, // 19: store.v1.TransactResponse.results:type_name -> store.v1.WriteResult
+	65, // 20: store.v1.ListArchivedEntitiesRequest.type_filter:type_name -> entity.v1.EntityType
+	63, // 21: store.v1.ArchivedEntity.entity:type_name -> entity.v1.Entity
+	1,  // 22: store.v1.ArchivedEntity.reason:type_name -> store.v1.EventType
+	67, // 23: store.v1.ArchivedEntity.archived_at:type_name -> google.protobuf.Timestamp
+	18, // 24: store.v1.ListArchivedEntitiesResponse.entities:type_name -> store.v1.ArchivedEntity
+	67, // 25: store.v1.AuditEntry.time:type_name -> google.protobuf.Timestamp
+	66, // 26: store.v1.AuditEntry.hlc:type_name -> entity.v1.HLCTimestamp
+	22, // 27: store.v1.AuditEntry.targets:type_name -> store.v1.AuditTarget
+	9,  // 28: store.v1.AuditEntry.merge:type_name -> store.v1.MergeDecision
+	21, // 29: store.v1.GetAuditLogResponse.entries:type_name -> store.v1.AuditEntry
+	26, // 30: store.v1.DigestEntitiesResponse.nodes:type_name -> store.v1.DigestNode
+	27, // 31: store.v1.DigestNode.entities:type_name -> store.v1.EntityDigest
+	13, // 32: store.v1.ReplicateBatchRequest.events:type_name -> store.v1.EntityEvent
+	52, // 33: store.v1.ReplicateBatchResponse.results:type_name -> store.v1.WriteResult
+	1,  // 34: store.v1.ChangeRecord.type:type_name -> store.v1.EventType
+	65, // 35: store.v1.ChangeRecord.entity_type:type_name -> entity.v1.EntityType
+	66, // 36: store.v1.ChangeRecord.hlc:type_name -> entity.v1.HLCTimestamp
+	67, // 37: store.v1.ChangeRecord.commit_time:type_name -> google.protobuf.Timestamp
+	32, // 38: store.v1.ChangeRecord.components:type_name -> store.v1.ComponentChange
+	68, // 39: store.v1.ComponentChange.old_value:type_name -> google.protobuf.Any
+	68, // 40: store.v1.ComponentChange.new_value:type_name -> google.protobuf.Any
+	65, // 41: store.v1.SnapshotEntitiesRequest.type_filter:type_name -> entity.v1.EntityType
+	63, // 42: store.v1.RestoreEntitiesRequest.entity:type_name -> entity.v1.Entity
+	68, // 43: store.v1.GetComponentResponse.component:type_name -> google.protobuf.Any
+	66, // 44: store.v1.GetComponentResponse.hlc:type_name -> entity.v1.HLCTimestamp
+	62, // 45: store.v1.PatchComponentRequest.components:type_name -> store.v1.PatchComponentRequest.ComponentsEntry
+	64, // 46: store.v1.PatchComponentRequest.ttl:type_name -> google.protobuf.Duration
+	66, // 47: store.v1.PatchComponentResponse.hlc:type_name -> entity.v1.HLCTimestamp
+	66, // 48: store.v1.RemoveComponentResponse.hlc:type_name -> entity.v1.HLCTimestamp
+	66, // 49: store.v1.IncrementCounterResponse.hlc:type_name -> entity.v1.HLCTimestamp
+	65, // 50: store.v1.QueryEntitiesByBBoxRequest.type_filter:type_name -> entity.v1.EntityType
+	63, // 51: store.v1.QueryEntitiesByBBoxResponse.entities:type_name -> entity.v1.Entity
+	13, // 52: store.v1.GetEntityHistoryResponse.versions:type_name -> store.v1.EntityEvent
+	3,  // 53: store.v1.WriteOp.create:type_name -> store.v1.CreateEntityRequest
+	8,  // 54: store.v1.WriteOp.update:type_name -> store.v1.UpdateEntityRequest
+	40, // 55: store.v1.WriteOp.patch:type_name -> store.v1.PatchComponentRequest
+	10, // 56: store.v1.WriteOp.delete:type_name -> store.v1.DeleteEntityRequest
+	44, // 57: store.v1.WriteOp.increment:type_name -> store.v1.IncrementCounterRequest
+	50, // 58: store.v1.BatchWriteEntitiesRequest.ops:type_name -> store.v1.WriteOp
+	63, // 59: store.v1.WriteResult.entity:type_name -> entity.v1.Entity
+	52, // 60: store.v1.BatchWriteEntitiesResponse.results:type_name -> store.v1.WriteResult
+	63, // 61: store.v1.PublishEntitiesRequest.entity:type_name -> entity.v1.Entity
+	64, // 62: store.v1.PublishEntitiesRequest.ttl:type_name -> google.protobuf.Duration
+	56, // 63: store.v1.PublishEntitiesResponse.failures:type_name -> store.v1.PublishFailure
+	57, // 64: store.v1.AddLinkRequest.link:type_name -> store.v1.Link
+	2,  // 65: store.v1.ListLinksRequest.direction:type_name -> store.v1.LinkDirection
+	57, // 66: store.v1.ListLinksResponse.links:type_name -> store.v1.Link
+	68, // 67: store.v1.PatchComponentRequest.ComponentsEntry.value:type_name -> google.protobuf.Any
+	3,  // 68: store.v1.EntityStoreService.CreateEntity:input_type -> store.v1.CreateEntityRequest
+	4,  // 69: store.v1.EntityStoreService.GetEntity:input_type -> store.v1.GetEntityRequest
+	5,  // 70: store.v1.EntityStoreService.ListEntities:input_type -> store.v1.ListEntitiesRequest
+	8,  // 71: store.v1.EntityStoreService.UpdateEntity:input_type -> store.v1.UpdateEntityRequest
+	10, // 72: store.v1.EntityStoreService.DeleteEntity:input_type -> store.v1.DeleteEntityRequest
+	11, // 73: store.v1.EntityStoreService.WatchEntities:input_type -> store.v1.WatchEntitiesRequest
+	33, // 74: store.v1.EntityStoreService.ApproveAction:input_type -> store.v1.ApproveActionRequest
+	34, // 75: store.v1.EntityStoreService.DenyAction:input_type -> store.v1.DenyActionRequest
+	35, // 76: store.v1.EntityStoreService.SnapshotEntities:input_type -> store.v1.SnapshotEntitiesRequest
+	36, // 77: store.v1.EntityStoreService.RestoreEntities:input_type -> store.v1.RestoreEntitiesRequest
+	38, // 78: store.v1.EntityStoreService.GetComponent:input_type -> store.v1.GetComponentRequest
+	40, // 79: store.v1.EntityStoreService.PatchComponent:input_type -> store.v1.PatchComponentRequest
+	42, // 80: store.v1.EntityStoreService.RemoveComponent:input_type -> store.v1.RemoveComponentRequest
+	44, // 81: store.v1.EntityStoreService.IncrementCounter:input_type -> store.v1.IncrementCounterRequest
+	46, // 82: store.v1.EntityStoreService.QueryEntitiesByBBox:input_type -> store.v1.QueryEntitiesByBBoxRequest
+	48, // 83: store.v1.EntityStoreService.GetEntityHistory:input_type -> store.v1.GetEntityHistoryRequest
+	51, // 84: store.v1.EntityStoreService.BatchWriteEntities:input_type -> store.v1.BatchWriteEntitiesRequest
+	54, // 85: store.v1.EntityStoreService.PublishEntities:input_type -> store.v1.PublishEntitiesRequest
+	58, // 86: store.v1.EntityStoreService.AddLink:input_type -> store.v1.AddLinkRequest
+	59, // 87: store.v1.EntityStoreService.RemoveLink:input_type -> store.v1.RemoveLinkRequest
+	60, // 88: store.v1.EntityStoreService.ListLinks:input_type -> store.v1.ListLinksRequest
+	30, // 89: store.v1.EntityStoreService.StreamChanges:input_type -> store.v1.StreamChangesRequest
+	14, // 90: store.v1.EntityStoreService.Transact:input_type -> store.v1.TransactRequest
+	17, // 91: store.v1.EntityStoreService.ListArchivedEntities:input_type -> store.v1.ListArchivedEntitiesRequest
+	20, // 92: store.v1.EntityStoreService.GetAuditLog:input_type -> store.v1.GetAuditLogRequest
+	24, // 93: store.v1.EntityStoreService.DigestEntities:input_type -> store.v1.DigestEntitiesRequest
+	28, // 94: store.v1.EntityStoreService.ReplicateBatch:input_type -> store.v1.ReplicateBatchRequest
+	63, // 95: store.v1.EntityStoreService.CreateEntity:output_type -> entity.v1.Entity
+	63, // 96: store.v1.EntityStoreService.GetEntity:output_type -> entity.v1.Entity
+	7,  // 97: store.v1.EntityStoreService.ListEntities:output_type -> store.v1.ListEntitiesResponse
+	63, // 98: store.v1.EntityStoreService.UpdateEntity:output_type -> entity.v1.Entity
+	69, // 99: store.v1.EntityStoreService.DeleteEntity:output_type -> google.protobuf.Empty
+	13, // 100: store.v1.EntityStoreService.WatchEntities:output_type -> store.v1.EntityEvent
+	63, // 101: store.v1.EntityStoreService.ApproveAction:output_type -> entity.v1.Entity
+	63, // 102: store.v1.EntityStoreService.DenyAction:output_type -> entity.v1.Entity
+	63, // 103: store.v1.EntityStoreService.SnapshotEntities:output_type -> entity.v1.Entity
+	37, // 104: store.v1.EntityStoreService.RestoreEntities:output_type -> store.v1.RestoreEntitiesResponse
+	39, // 105: store.v1.EntityStoreService.GetComponent:output_type -> store.v1.GetComponentResponse
+	41, // 106: store.v1.EntityStoreService.PatchComponent:output_type -> store.v1.PatchComponentResponse
+	43, // 107: store.v1.EntityStoreService.RemoveComponent:output_type -> store.v1.RemoveComponentResponse
+	45, // 108: store.v1.EntityStoreService.IncrementCounter:output_type -> store.v1.IncrementCounterResponse
+	47, // 109: store.v1.EntityStoreService.QueryEntitiesByBBox:output_type -> store.v1.QueryEntitiesByBBoxResponse
+	49, // 110: store.v1.EntityStoreService.GetEntityHistory:output_type -> store.v1.GetEntityHistoryResponse
+	53, // 111: store.v1.EntityStoreService.BatchWriteEntities:output_type -> store.v1.BatchWriteEntitiesResponse
+	55, // 112: store.v1.EntityStoreService.PublishEntities:output_type -> store.v1.PublishEntitiesResponse
+	57, // 113: store.v1.EntityStoreService.AddLink:output_type -> store.v1.Link
+	69, // 114: store.v1.EntityStoreService.RemoveLink:output_type -> google.protobuf.Empty
+	61, // 115: store.v1.EntityStoreService.ListLinks:output_type -> store.v1.ListLinksResponse
+	31, // 116: store.v1.EntityStoreService.StreamChanges:output_type -> store.v1.ChangeRecord
+	16, // 117: store.v1.EntityStoreService.Transact:output_type -> store.v1.TransactResponse
+	19, // 118: store.v1.EntityStoreService.ListArchivedEntities:output_type -> store.v1.ListArchivedEntitiesResponse
+	23, // 119: store.v1.EntityStoreService.GetAuditLog:output_type -> store.v1.GetAuditLogResponse
+	25, // 120: store.v1.EntityStoreService.DigestEntities:output_type -> store.v1.DigestEntitiesResponse
+	29, // 121: store.v1.EntityStoreService.ReplicateBatch:output_type -> store.v1.ReplicateBatchResponse
+	95, // [95:122] is the sub-list for method output_type
+	68, // [68:95] is the sub-list for method input_type
+	68, // [68:68] is the sub-list for extension type_name
+	68, // [68:68] is the sub-list for extension extendee
+	0,  // [0:68] is the sub-list for field type_name
 }
 
 func init() { file_store_v1_store_proto_init() }
@@ -643,13 +4214,20 @@ func file_store_v1_store_proto_init() {
 	if File_store_v1_store_proto != nil {
 		return
 	}
+	file_store_v1_store_proto_msgTypes[47].OneofWrappers = []any{
+		(*WriteOp_Create)(nil),
+		(*WriteOp_Update)(nil),
+		(*WriteOp_Patch)(nil),
+		(*WriteOp_Delete)(nil),
+		(*WriteOp_Increment)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_store_v1_store_proto_rawDesc), len(file_store_v1_store_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   10,
+			NumEnums:      3,
+			NumMessages:   60,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
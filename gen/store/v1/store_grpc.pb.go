@@ -21,28 +21,125 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	EntityStoreService_CreateEntity_FullMethodName  = "/store.v1.EntityStoreService/CreateEntity"
-	EntityStoreService_GetEntity_FullMethodName     = "/store.v1.EntityStoreService/GetEntity"
-	EntityStoreService_ListEntities_FullMethodName  = "/store.v1.EntityStoreService/ListEntities"
-	EntityStoreService_UpdateEntity_FullMethodName  = "/store.v1.EntityStoreService/UpdateEntity"
-	EntityStoreService_DeleteEntity_FullMethodName  = "/store.v1.EntityStoreService/DeleteEntity"
-	EntityStoreService_WatchEntities_FullMethodName = "/store.v1.EntityStoreService/WatchEntities"
-	EntityStoreService_ApproveAction_FullMethodName = "/store.v1.EntityStoreService/ApproveAction"
-	EntityStoreService_DenyAction_FullMethodName    = "/store.v1.EntityStoreService/DenyAction"
+	EntityStoreService_CreateEntity_FullMethodName         = "/store.v1.EntityStoreService/CreateEntity"
+	EntityStoreService_GetEntity_FullMethodName            = "/store.v1.EntityStoreService/GetEntity"
+	EntityStoreService_ListEntities_FullMethodName         = "/store.v1.EntityStoreService/ListEntities"
+	EntityStoreService_UpdateEntity_FullMethodName         = "/store.v1.EntityStoreService/UpdateEntity"
+	EntityStoreService_DeleteEntity_FullMethodName         = "/store.v1.EntityStoreService/DeleteEntity"
+	EntityStoreService_WatchEntities_FullMethodName        = "/store.v1.EntityStoreService/WatchEntities"
+	EntityStoreService_ApproveAction_FullMethodName        = "/store.v1.EntityStoreService/ApproveAction"
+	EntityStoreService_DenyAction_FullMethodName           = "/store.v1.EntityStoreService/DenyAction"
+	EntityStoreService_SnapshotEntities_FullMethodName     = "/store.v1.EntityStoreService/SnapshotEntities"
+	EntityStoreService_RestoreEntities_FullMethodName      = "/store.v1.EntityStoreService/RestoreEntities"
+	EntityStoreService_GetComponent_FullMethodName         = "/store.v1.EntityStoreService/GetComponent"
+	EntityStoreService_PatchComponent_FullMethodName       = "/store.v1.EntityStoreService/PatchComponent"
+	EntityStoreService_RemoveComponent_FullMethodName      = "/store.v1.EntityStoreService/RemoveComponent"
+	EntityStoreService_IncrementCounter_FullMethodName     = "/store.v1.EntityStoreService/IncrementCounter"
+	EntityStoreService_QueryEntitiesByBBox_FullMethodName  = "/store.v1.EntityStoreService/QueryEntitiesByBBox"
+	EntityStoreService_GetEntityHistory_FullMethodName     = "/store.v1.EntityStoreService/GetEntityHistory"
+	EntityStoreService_BatchWriteEntities_FullMethodName   = "/store.v1.EntityStoreService/BatchWriteEntities"
+	EntityStoreService_PublishEntities_FullMethodName      = "/store.v1.EntityStoreService/PublishEntities"
+	EntityStoreService_AddLink_FullMethodName              = "/store.v1.EntityStoreService/AddLink"
+	EntityStoreService_RemoveLink_FullMethodName           = "/store.v1.EntityStoreService/RemoveLink"
+	EntityStoreService_ListLinks_FullMethodName            = "/store.v1.EntityStoreService/ListLinks"
+	EntityStoreService_StreamChanges_FullMethodName        = "/store.v1.EntityStoreService/StreamChanges"
+	EntityStoreService_Transact_FullMethodName             = "/store.v1.EntityStoreService/Transact"
+	EntityStoreService_ListArchivedEntities_FullMethodName = "/store.v1.EntityStoreService/ListArchivedEntities"
+	EntityStoreService_GetAuditLog_FullMethodName          = "/store.v1.EntityStoreService/GetAuditLog"
+	EntityStoreService_DigestEntities_FullMethodName       = "/store.v1.EntityStoreService/DigestEntities"
+	EntityStoreService_ReplicateBatch_FullMethodName       = "/store.v1.EntityStoreService/ReplicateBatch"
 )
 
 // EntityStoreServiceClient is the client API for EntityStoreService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type EntityStoreServiceClient interface {
+	// CreateEntity adds an entity. One carrying an HLC from before the
+	// entity was deleted fails with FAILED_PRECONDITION, so a stale copy
+	// relayed from a partitioned peer cannot bring it back.
 	CreateEntity(ctx context.Context, in *CreateEntityRequest, opts ...grpc.CallOption) (*v1.Entity, error)
 	GetEntity(ctx context.Context, in *GetEntityRequest, opts ...grpc.CallOption) (*v1.Entity, error)
 	ListEntities(ctx context.Context, in *ListEntitiesRequest, opts ...grpc.CallOption) (*ListEntitiesResponse, error)
 	UpdateEntity(ctx context.Context, in *UpdateEntityRequest, opts ...grpc.CallOption) (*v1.Entity, error)
 	DeleteEntity(ctx context.Context, in *DeleteEntityRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	WatchEntities(ctx context.Context, in *WatchEntitiesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[EntityEvent], error)
+	// ApproveAction and DenyAction decide a pending intercept through the
+	// store's approval backend, the task-manager, and return the entity.
+	// UNIMPLEMENTED if the store has no backend.
 	ApproveAction(ctx context.Context, in *ApproveActionRequest, opts ...grpc.CallOption) (*v1.Entity, error)
 	DenyAction(ctx context.Context, in *DenyActionRequest, opts ...grpc.CallOption) (*v1.Entity, error)
+	// SnapshotEntities streams every entity as stored, HLC and timestamps
+	// included, as of one instant.
+	SnapshotEntities(ctx context.Context, in *SnapshotEntitiesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[v1.Entity], error)
+	// RestoreEntities loads a snapshot. Entities keep their HLC and
+	// timestamps; one that already exists is replaced only if the snapshot's
+	// copy has the later HLC, and one deleted since the snapshot stays deleted.
+	RestoreEntities(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreEntitiesRequest, RestoreEntitiesResponse], error)
+	// GetComponent returns one component of an entity and its HLC.
+	GetComponent(ctx context.Context, in *GetComponentRequest, opts ...grpc.CallOption) (*GetComponentResponse, error)
+	// PatchComponent sets the given components of an existing entity, leaving
+	// the others untouched, and stamps them with the write's HLC.
+	PatchComponent(ctx context.Context, in *PatchComponentRequest, opts ...grpc.CallOption) (*PatchComponentResponse, error)
+	// RemoveComponent removes the given components of an existing entity,
+	// leaving a tombstone for each stamped with the write's HLC, so merges
+	// with replicas that still hold them do not bring them back.
+	RemoveComponent(ctx context.Context, in *RemoveComponentRequest, opts ...grpc.CallOption) (*RemoveComponentResponse, error)
+	// IncrementCounter adds delta, which may be negative, to a counter
+	// component (entity.v1.CounterComponent) of an existing entity, under
+	// the store's own node, creating the component at zero if absent.
+	IncrementCounter(ctx context.Context, in *IncrementCounterRequest, opts ...grpc.CallOption) (*IncrementCounterResponse, error)
+	// QueryEntitiesByBBox returns the entities whose position component lies
+	// inside the box, edges included.
+	QueryEntitiesByBBox(ctx context.Context, in *QueryEntitiesByBBoxRequest, opts ...grpc.CallOption) (*QueryEntitiesByBBoxResponse, error)
+	// GetEntityHistory returns the entity's last versions, oldest first by
+	// HLC, including its deletion. The store keeps none unless configured to.
+	GetEntityHistory(ctx context.Context, in *GetEntityHistoryRequest, opts ...grpc.CallOption) (*GetEntityHistoryResponse, error)
+	// BatchWriteEntities applies many creates, updates, patches, and deletes
+	// in one call, in order, with no other write interleaved. Each op
+	// succeeds or fails on its own, as the single-write RPC would.
+	BatchWriteEntities(ctx context.Context, in *BatchWriteEntitiesRequest, opts ...grpc.CallOption) (*BatchWriteEntitiesResponse, error)
+	// PublishEntities takes a long-lived stream of entity reports, such as a
+	// sensor's tracks every tick, and applies each as it arrives: created if
+	// the store lacks it, else its components patched, through the same
+	// write path as BatchWriteEntities. A refused report does not end the
+	// stream; the response, once the client closes it, says how many were
+	// applied and why the others were not.
+	PublishEntities(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PublishEntitiesRequest, PublishEntitiesResponse], error)
+	// AddLink records a directed relationship between two existing entities.
+	// Adding a link that exists updates its cascade flag. Deleting either
+	// entity removes its links.
+	AddLink(ctx context.Context, in *AddLinkRequest, opts ...grpc.CallOption) (*Link, error)
+	RemoveLink(ctx context.Context, in *RemoveLinkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	// ListLinks returns an entity's links, for traversing relationships.
+	ListLinks(ctx context.Context, in *ListLinksRequest, opts ...grpc.CallOption) (*ListLinksResponse, error)
+	// StreamChanges sends a change record for every committed mutation, in
+	// commit order, for export to systems outside the mesh. A consumer that
+	// falls behind is disconnected with RESOURCE_EXHAUSTED and should resume
+	// from the last sequence it received.
+	StreamChanges(ctx context.Context, in *StreamChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeRecord], error)
+	// Transact checks a set of reads and writes and, only if none would
+	// fail, applies the writes, all under one store lock. Unlike
+	// BatchWriteEntities it is all or nothing: a failed read or write aborts
+	// the call with that op's error and writes nothing.
+	Transact(ctx context.Context, in *TransactRequest, opts ...grpc.CallOption) (*TransactResponse, error)
+	// ListArchivedEntities returns entities deleted or expired within the
+	// store's archive retention, most recently removed first.
+	ListArchivedEntities(ctx context.Context, in *ListArchivedEntitiesRequest, opts ...grpc.CallOption) (*ListArchivedEntitiesResponse, error)
+	// GetAuditLog returns the store's record of mutating calls: who made
+	// each, what it touched, when, and how it ended, oldest first. The store
+	// keeps a bounded number of entries in memory.
+	GetAuditLog(ctx context.Context, in *GetAuditLogRequest, opts ...grpc.CallOption) (*GetAuditLogResponse, error)
+	// DigestEntities returns nodes of the store's hash tree over its
+	// entities, so two stores can find the entities they disagree on by
+	// comparing hashes a level at a time rather than listing everything.
+	DigestEntities(ctx context.Context, in *DigestEntitiesRequest, opts ...grpc.CallOption) (*DigestEntitiesResponse, error)
+	// ReplicateBatch applies events a relay passes on from another node's
+	// store, in one call and under one store lock: each created or updated
+	// entity is CRDT-merged with the store's copy, or created if it has
+	// none, and each delete applied at its HLC. Each event carries its own
+	// origin_node, hops, and path, which the writes it makes are stamped
+	// with, rather than the call's metadata.
+	ReplicateBatch(ctx context.Context, in *ReplicateBatchRequest, opts ...grpc.CallOption) (*ReplicateBatchResponse, error)
 }
 
 type entityStoreServiceClient struct {
@@ -142,18 +239,310 @@ func (c *entityStoreServiceClient) DenyAction(ctx context.Context, in *DenyActio
 	return out, nil
 }
 
+func (c *entityStoreServiceClient) SnapshotEntities(ctx context.Context, in *SnapshotEntitiesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[v1.Entity], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EntityStoreService_ServiceDesc.Streams[1], EntityStoreService_SnapshotEntities_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SnapshotEntitiesRequest, v1.Entity]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EntityStoreService_SnapshotEntitiesClient = grpc.ServerStreamingClient[v1.Entity]
+
+func (c *entityStoreServiceClient) RestoreEntities(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[RestoreEntitiesRequest, RestoreEntitiesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EntityStoreService_ServiceDesc.Streams[2], EntityStoreService_RestoreEntities_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[RestoreEntitiesRequest, RestoreEntitiesResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EntityStoreService_RestoreEntitiesClient = grpc.ClientStreamingClient[RestoreEntitiesRequest, RestoreEntitiesResponse]
+
+func (c *entityStoreServiceClient) GetComponent(ctx context.Context, in *GetComponentRequest, opts ...grpc.CallOption) (*GetComponentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetComponentResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_GetComponent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) PatchComponent(ctx context.Context, in *PatchComponentRequest, opts ...grpc.CallOption) (*PatchComponentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PatchComponentResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_PatchComponent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) RemoveComponent(ctx context.Context, in *RemoveComponentRequest, opts ...grpc.CallOption) (*RemoveComponentResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveComponentResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_RemoveComponent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) IncrementCounter(ctx context.Context, in *IncrementCounterRequest, opts ...grpc.CallOption) (*IncrementCounterResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IncrementCounterResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_IncrementCounter_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) QueryEntitiesByBBox(ctx context.Context, in *QueryEntitiesByBBoxRequest, opts ...grpc.CallOption) (*QueryEntitiesByBBoxResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(QueryEntitiesByBBoxResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_QueryEntitiesByBBox_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) GetEntityHistory(ctx context.Context, in *GetEntityHistoryRequest, opts ...grpc.CallOption) (*GetEntityHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetEntityHistoryResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_GetEntityHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) BatchWriteEntities(ctx context.Context, in *BatchWriteEntitiesRequest, opts ...grpc.CallOption) (*BatchWriteEntitiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchWriteEntitiesResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_BatchWriteEntities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) PublishEntities(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[PublishEntitiesRequest, PublishEntitiesResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EntityStoreService_ServiceDesc.Streams[3], EntityStoreService_PublishEntities_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PublishEntitiesRequest, PublishEntitiesResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EntityStoreService_PublishEntitiesClient = grpc.ClientStreamingClient[PublishEntitiesRequest, PublishEntitiesResponse]
+
+func (c *entityStoreServiceClient) AddLink(ctx context.Context, in *AddLinkRequest, opts ...grpc.CallOption) (*Link, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Link)
+	err := c.cc.Invoke(ctx, EntityStoreService_AddLink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) RemoveLink(ctx context.Context, in *RemoveLinkRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, EntityStoreService_RemoveLink_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) ListLinks(ctx context.Context, in *ListLinksRequest, opts ...grpc.CallOption) (*ListLinksResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListLinksResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_ListLinks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) StreamChanges(ctx context.Context, in *StreamChangesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeRecord], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &EntityStoreService_ServiceDesc.Streams[4], EntityStoreService_StreamChanges_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamChangesRequest, ChangeRecord]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EntityStoreService_StreamChangesClient = grpc.ServerStreamingClient[ChangeRecord]
+
+func (c *entityStoreServiceClient) Transact(ctx context.Context, in *TransactRequest, opts ...grpc.CallOption) (*TransactResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TransactResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_Transact_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) ListArchivedEntities(ctx context.Context, in *ListArchivedEntitiesRequest, opts ...grpc.CallOption) (*ListArchivedEntitiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListArchivedEntitiesResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_ListArchivedEntities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) GetAuditLog(ctx context.Context, in *GetAuditLogRequest, opts ...grpc.CallOption) (*GetAuditLogResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetAuditLogResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_GetAuditLog_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) DigestEntities(ctx context.Context, in *DigestEntitiesRequest, opts ...grpc.CallOption) (*DigestEntitiesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DigestEntitiesResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_DigestEntities_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *entityStoreServiceClient) ReplicateBatch(ctx context.Context, in *ReplicateBatchRequest, opts ...grpc.CallOption) (*ReplicateBatchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReplicateBatchResponse)
+	err := c.cc.Invoke(ctx, EntityStoreService_ReplicateBatch_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EntityStoreServiceServer is the server API for EntityStoreService service.
 // All implementations must embed UnimplementedEntityStoreServiceServer
 // for forward compatibility.
 type EntityStoreServiceServer interface {
+	// CreateEntity adds an entity. One carrying an HLC from before the
+	// entity was deleted fails with FAILED_PRECONDITION, so a stale copy
+	// relayed from a partitioned peer cannot bring it back.
 	CreateEntity(context.Context, *CreateEntityRequest) (*v1.Entity, error)
 	GetEntity(context.Context, *GetEntityRequest) (*v1.Entity, error)
 	ListEntities(context.Context, *ListEntitiesRequest) (*ListEntitiesResponse, error)
 	UpdateEntity(context.Context, *UpdateEntityRequest) (*v1.Entity, error)
 	DeleteEntity(context.Context, *DeleteEntityRequest) (*emptypb.Empty, error)
 	WatchEntities(*WatchEntitiesRequest, grpc.ServerStreamingServer[EntityEvent]) error
+	// ApproveAction and DenyAction decide a pending intercept through the
+	// store's approval backend, the task-manager, and return the entity.
+	// UNIMPLEMENTED if the store has no backend.
 	ApproveAction(context.Context, *ApproveActionRequest) (*v1.Entity, error)
 	DenyAction(context.Context, *DenyActionRequest) (*v1.Entity, error)
+	// SnapshotEntities streams every entity as stored, HLC and timestamps
+	// included, as of one instant.
+	SnapshotEntities(*SnapshotEntitiesRequest, grpc.ServerStreamingServer[v1.Entity]) error
+	// RestoreEntities loads a snapshot. Entities keep their HLC and
+	// timestamps; one that already exists is replaced only if the snapshot's
+	// copy has the later HLC, and one deleted since the snapshot stays deleted.
+	RestoreEntities(grpc.ClientStreamingServer[RestoreEntitiesRequest, RestoreEntitiesResponse]) error
+	// GetComponent returns one component of an entity and its HLC.
+	GetComponent(context.Context, *GetComponentRequest) (*GetComponentResponse, error)
+	// PatchComponent sets the given components of an existing entity, leaving
+	// the others untouched, and stamps them with the write's HLC.
+	PatchComponent(context.Context, *PatchComponentRequest) (*PatchComponentResponse, error)
+	// RemoveComponent removes the given components of an existing entity,
+	// leaving a tombstone for each stamped with the write's HLC, so merges
+	// with replicas that still hold them do not bring them back.
+	RemoveComponent(context.Context, *RemoveComponentRequest) (*RemoveComponentResponse, error)
+	// IncrementCounter adds delta, which may be negative, to a counter
+	// component (entity.v1.CounterComponent) of an existing entity, under
+	// the store's own node, creating the component at zero if absent.
+	IncrementCounter(context.Context, *IncrementCounterRequest) (*IncrementCounterResponse, error)
+	// QueryEntitiesByBBox returns the entities whose position component lies
+	// inside the box, edges included.
+	QueryEntitiesByBBox(context.Context, *QueryEntitiesByBBoxRequest) (*QueryEntitiesByBBoxResponse, error)
+	// GetEntityHistory returns the entity's last versions, oldest first by
+	// HLC, including its deletion. The store keeps none unless configured to.
+	GetEntityHistory(context.Context, *GetEntityHistoryRequest) (*GetEntityHistoryResponse, error)
+	// BatchWriteEntities applies many creates, updates, patches, and deletes
+	// in one call, in order, with no other write interleaved. Each op
+	// succeeds or fails on its own, as the single-write RPC would.
+	BatchWriteEntities(context.Context, *BatchWriteEntitiesRequest) (*BatchWriteEntitiesResponse, error)
+	// PublishEntities takes a long-lived stream of entity reports, such as a
+	// sensor's tracks every tick, and applies each as it arrives: created if
+	// the store lacks it, else its components patched, through the same
+	// write path as BatchWriteEntities. A refused report does not end the
+	// stream; the response, once the client closes it, says how many were
+	// applied and why the others were not.
+	PublishEntities(grpc.ClientStreamingServer[PublishEntitiesRequest, PublishEntitiesResponse]) error
+	// AddLink records a directed relationship between two existing entities.
+	// Adding a link that exists updates its cascade flag. Deleting either
+	// entity removes its links.
+	AddLink(context.Context, *AddLinkRequest) (*Link, error)
+	RemoveLink(context.Context, *RemoveLinkRequest) (*emptypb.Empty, error)
+	// ListLinks returns an entity's links, for traversing relationships.
+	ListLinks(context.Context, *ListLinksRequest) (*ListLinksResponse, error)
+	// StreamChanges sends a change record for every committed mutation, in
+	// commit order, for export to systems outside the mesh. A consumer that
+	// falls behind is disconnected with RESOURCE_EXHAUSTED and should resume
+	// from the last sequence it received.
+	StreamChanges(*StreamChangesRequest, grpc.ServerStreamingServer[ChangeRecord]) error
+	// Transact checks a set of reads and writes and, only if none would
+	// fail, applies the writes, all under one store lock. Unlike
+	// BatchWriteEntities it is all or nothing: a failed read or write aborts
+	// the call with that op's error and writes nothing.
+	Transact(context.Context, *TransactRequest) (*TransactResponse, error)
+	// ListArchivedEntities returns entities deleted or expired within the
+	// store's archive retention, most recently removed first.
+	ListArchivedEntities(context.Context, *ListArchivedEntitiesRequest) (*ListArchivedEntitiesResponse, error)
+	// GetAuditLog returns the store's record of mutating calls: who made
+	// each, what it touched, when, and how it ended, oldest first. The store
+	// keeps a bounded number of entries in memory.
+	GetAuditLog(context.Context, *GetAuditLogRequest) (*GetAuditLogResponse, error)
+	// DigestEntities returns nodes of the store's hash tree over its
+	// entities, so two stores can find the entities they disagree on by
+	// comparing hashes a level at a time rather than listing everything.
+	DigestEntities(context.Context, *DigestEntitiesRequest) (*DigestEntitiesResponse, error)
+	// ReplicateBatch applies events a relay passes on from another node's
+	// store, in one call and under one store lock: each created or updated
+	// entity is CRDT-merged with the store's copy, or created if it has
+	// none, and each delete applied at its HLC. Each event carries its own
+	// origin_node, hops, and path, which the writes it makes are stamped
+	// with, rather than the call's metadata.
+	ReplicateBatch(context.Context, *ReplicateBatchRequest) (*ReplicateBatchResponse, error)
 	mustEmbedUnimplementedEntityStoreServiceServer()
 }
 
@@ -188,6 +577,63 @@ func (UnimplementedEntityStoreServiceServer) ApproveAction(context.Context, *App
 func (UnimplementedEntityStoreServiceServer) DenyAction(context.Context, *DenyActionRequest) (*v1.Entity, error) {
 	return nil, status.Error(codes.Unimplemented, "method DenyAction not implemented")
 }
+func (UnimplementedEntityStoreServiceServer) SnapshotEntities(*SnapshotEntitiesRequest, grpc.ServerStreamingServer[v1.Entity]) error {
+	return status.Error(codes.Unimplemented, "method SnapshotEntities not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) RestoreEntities(grpc.ClientStreamingServer[RestoreEntitiesRequest, RestoreEntitiesResponse]) error {
+	return status.Error(codes.Unimplemented, "method RestoreEntities not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) GetComponent(context.Context, *GetComponentRequest) (*GetComponentResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetComponent not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) PatchComponent(context.Context, *PatchComponentRequest) (*PatchComponentResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method PatchComponent not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) RemoveComponent(context.Context, *RemoveComponentRequest) (*RemoveComponentResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RemoveComponent not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) IncrementCounter(context.Context, *IncrementCounterRequest) (*IncrementCounterResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method IncrementCounter not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) QueryEntitiesByBBox(context.Context, *QueryEntitiesByBBoxRequest) (*QueryEntitiesByBBoxResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method QueryEntitiesByBBox not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) GetEntityHistory(context.Context, *GetEntityHistoryRequest) (*GetEntityHistoryResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetEntityHistory not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) BatchWriteEntities(context.Context, *BatchWriteEntitiesRequest) (*BatchWriteEntitiesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method BatchWriteEntities not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) PublishEntities(grpc.ClientStreamingServer[PublishEntitiesRequest, PublishEntitiesResponse]) error {
+	return status.Error(codes.Unimplemented, "method PublishEntities not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) AddLink(context.Context, *AddLinkRequest) (*Link, error) {
+	return nil, status.Error(codes.Unimplemented, "method AddLink not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) RemoveLink(context.Context, *RemoveLinkRequest) (*emptypb.Empty, error) {
+	return nil, status.Error(codes.Unimplemented, "method RemoveLink not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) ListLinks(context.Context, *ListLinksRequest) (*ListLinksResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListLinks not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) StreamChanges(*StreamChangesRequest, grpc.ServerStreamingServer[ChangeRecord]) error {
+	return status.Error(codes.Unimplemented, "method StreamChanges not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) Transact(context.Context, *TransactRequest) (*TransactResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Transact not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) ListArchivedEntities(context.Context, *ListArchivedEntitiesRequest) (*ListArchivedEntitiesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListArchivedEntities not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) GetAuditLog(context.Context, *GetAuditLogRequest) (*GetAuditLogResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetAuditLog not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) DigestEntities(context.Context, *DigestEntitiesRequest) (*DigestEntitiesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method DigestEntities not implemented")
+}
+func (UnimplementedEntityStoreServiceServer) ReplicateBatch(context.Context, *ReplicateBatchRequest) (*ReplicateBatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReplicateBatch not implemented")
+}
 func (UnimplementedEntityStoreServiceServer) mustEmbedUnimplementedEntityStoreServiceServer() {}
 func (UnimplementedEntityStoreServiceServer) testEmbeddedByValue()                            {}
 
@@ -346,6 +792,312 @@ func _EntityStoreService_DenyAction_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EntityStoreService_SnapshotEntities_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SnapshotEntitiesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EntityStoreServiceServer).SnapshotEntities(m, &grpc.GenericServerStream[SnapshotEntitiesRequest, v1.Entity]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EntityStoreService_SnapshotEntitiesServer = grpc.ServerStreamingServer[v1.Entity]
+
+func _EntityStoreService_RestoreEntities_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EntityStoreServiceServer).RestoreEntities(&grpc.GenericServerStream[RestoreEntitiesRequest, RestoreEntitiesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EntityStoreService_RestoreEntitiesServer = grpc.ClientStreamingServer[RestoreEntitiesRequest, RestoreEntitiesResponse]
+
+func _EntityStoreService_GetComponent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetComponentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).GetComponent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_GetComponent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).GetComponent(ctx, req.(*GetComponentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_PatchComponent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PatchComponentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).PatchComponent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_PatchComponent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).PatchComponent(ctx, req.(*PatchComponentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_RemoveComponent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveComponentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).RemoveComponent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_RemoveComponent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).RemoveComponent(ctx, req.(*RemoveComponentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_IncrementCounter_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IncrementCounterRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).IncrementCounter(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_IncrementCounter_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).IncrementCounter(ctx, req.(*IncrementCounterRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_QueryEntitiesByBBox_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryEntitiesByBBoxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).QueryEntitiesByBBox(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_QueryEntitiesByBBox_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).QueryEntitiesByBBox(ctx, req.(*QueryEntitiesByBBoxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_GetEntityHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetEntityHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).GetEntityHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_GetEntityHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).GetEntityHistory(ctx, req.(*GetEntityHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_BatchWriteEntities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchWriteEntitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).BatchWriteEntities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_BatchWriteEntities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).BatchWriteEntities(ctx, req.(*BatchWriteEntitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_PublishEntities_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EntityStoreServiceServer).PublishEntities(&grpc.GenericServerStream[PublishEntitiesRequest, PublishEntitiesResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EntityStoreService_PublishEntitiesServer = grpc.ClientStreamingServer[PublishEntitiesRequest, PublishEntitiesResponse]
+
+func _EntityStoreService_AddLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).AddLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_AddLink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).AddLink(ctx, req.(*AddLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_RemoveLink_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveLinkRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).RemoveLink(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_RemoveLink_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).RemoveLink(ctx, req.(*RemoveLinkRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_ListLinks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListLinksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).ListLinks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_ListLinks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).ListLinks(ctx, req.(*ListLinksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_StreamChanges_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EntityStoreServiceServer).StreamChanges(m, &grpc.GenericServerStream[StreamChangesRequest, ChangeRecord]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type EntityStoreService_StreamChangesServer = grpc.ServerStreamingServer[ChangeRecord]
+
+func _EntityStoreService_Transact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).Transact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_Transact_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).Transact(ctx, req.(*TransactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_ListArchivedEntities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListArchivedEntitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).ListArchivedEntities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_ListArchivedEntities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).ListArchivedEntities(ctx, req.(*ListArchivedEntitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_GetAuditLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAuditLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).GetAuditLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_GetAuditLog_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).GetAuditLog(ctx, req.(*GetAuditLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_DigestEntities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DigestEntitiesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).DigestEntities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_DigestEntities_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).DigestEntities(ctx, req.(*DigestEntitiesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EntityStoreService_ReplicateBatch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReplicateBatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EntityStoreServiceServer).ReplicateBatch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: EntityStoreService_ReplicateBatch_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EntityStoreServiceServer).ReplicateBatch(ctx, req.(*ReplicateBatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EntityStoreService_ServiceDesc is the grpc.ServiceDesc for EntityStoreService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -381,6 +1133,66 @@ var EntityStoreService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DenyAction",
 			Handler:    _EntityStoreService_DenyAction_Handler,
 		},
+		{
+			MethodName: "GetComponent",
+			Handler:    _EntityStoreService_GetComponent_Handler,
+		},
+		{
+			MethodName: "PatchComponent",
+			Handler:    _EntityStoreService_PatchComponent_Handler,
+		},
+		{
+			MethodName: "RemoveComponent",
+			Handler:    _EntityStoreService_RemoveComponent_Handler,
+		},
+		{
+			MethodName: "IncrementCounter",
+			Handler:    _EntityStoreService_IncrementCounter_Handler,
+		},
+		{
+			MethodName: "QueryEntitiesByBBox",
+			Handler:    _EntityStoreService_QueryEntitiesByBBox_Handler,
+		},
+		{
+			MethodName: "GetEntityHistory",
+			Handler:    _EntityStoreService_GetEntityHistory_Handler,
+		},
+		{
+			MethodName: "BatchWriteEntities",
+			Handler:    _EntityStoreService_BatchWriteEntities_Handler,
+		},
+		{
+			MethodName: "AddLink",
+			Handler:    _EntityStoreService_AddLink_Handler,
+		},
+		{
+			MethodName: "RemoveLink",
+			Handler:    _EntityStoreService_RemoveLink_Handler,
+		},
+		{
+			MethodName: "ListLinks",
+			Handler:    _EntityStoreService_ListLinks_Handler,
+		},
+		{
+			MethodName: "Transact",
+			Handler:    _EntityStoreService_Transact_Handler,
+		},
+		{
+			MethodName: "ListArchivedEntities",
+			Handler:    _EntityStoreService_ListArchivedEntities_Handler,
+		},
+		{
+			MethodName: "GetAuditLog",
+			Handler:    _EntityStoreService_GetAuditLog_Handler,
+		},
+		{
+			MethodName: "DigestEntities",
+			Handler:    _EntityStoreService_DigestEntities_Handler,
+		},
+		{
+			MethodName: "ReplicateBatch",
+			Handler:    _EntityStoreService_ReplicateBatch_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -388,6 +1200,26 @@ var EntityStoreService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _EntityStoreService_WatchEntities_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "SnapshotEntities",
+			Handler:       _EntityStoreService_SnapshotEntities_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "RestoreEntities",
+			Handler:       _EntityStoreService_RestoreEntities_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "PublishEntities",
+			Handler:       _EntityStoreService_PublishEntities_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "StreamChanges",
+			Handler:       _EntityStoreService_StreamChanges_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "store/v1/store.proto",
 }
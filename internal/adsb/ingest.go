@@ -0,0 +1,336 @@
+// Package adsb ingests live ADS-B traffic from a dump1090 SBS feed or the
+// OpenSky Network and publishes each aircraft as a TRACK entity.
+package adsb
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/sensor"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Source selects the feed the ingester reads.
+type Source string
+
+const (
+	SourceSBS     Source = "sbs"     // dump1090 BaseStation output over TCP
+	SourceOpenSky Source = "opensky" // OpenSky Network REST API
+)
+
+// ParseSource validates a source name. Empty means sbs.
+func ParseSource(s string) (Source, error) {
+	switch src := Source(s); src {
+	case "":
+		return SourceSBS, nil
+	case SourceSBS, SourceOpenSky:
+		return src, nil
+	default:
+		return "", fmt.Errorf("unknown source %q (want sbs or opensky)", s)
+	}
+}
+
+// Config controls the ADS-B ingester.
+type Config struct {
+	StoreAddr  string
+	Source     Source
+	SBSAddr    string        // host:port of the SBS feed
+	OpenSkyURL string        // OpenSky API base URL
+	BBox       sensor.BBox   // OpenSky query area; SBS aircraft outside it are dropped unless zero
+	Interval   time.Duration // how often to publish (and poll OpenSky)
+	StaleAfter time.Duration // delete aircraft not heard from for this long
+	SensorID   string
+}
+
+// DefaultConfig returns a config reading dump1090 on localhost over the DC
+// metro area.
+func DefaultConfig() Config {
+	return Config{
+		StoreAddr:  "localhost:50051",
+		Source:     SourceSBS,
+		SBSAddr:    "localhost:30003",
+		OpenSkyURL: "https://opensky-network.org",
+		BBox: sensor.BBox{
+			MinLat: 38.8, MaxLat: 39.0,
+			MinLon: -77.2, MaxLon: -76.9,
+		},
+		Interval:   time.Second,
+		StaleAfter: time.Minute,
+		SensorID:   "adsb-1",
+	}
+}
+
+// Validate checks the config is runnable. A zero bbox accepts everywhere.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.Interval <= 0:
+		return fmt.Errorf("interval must be positive")
+	case cfg.StaleAfter <= 0:
+		return fmt.Errorf("stale_after must be positive")
+	}
+	if cfg.BBox != (sensor.BBox{}) {
+		return cfg.BBox.Validate()
+	}
+	return nil
+}
+
+// aircraft is the merged state of one ICAO address. SBS splits position,
+// velocity, and identity across message types, so reports are folded in as
+// they arrive.
+type aircraft struct {
+	Report
+	published bool // created in the store
+}
+
+// Ingester merges ADS-B reports and publishes them to an entity store.
+type Ingester struct {
+	cfg    Config
+	client *http.Client
+
+	mu       sync.Mutex
+	aircraft map[string]*aircraft
+}
+
+// New creates an ingester with the given config.
+func New(cfg Config) *Ingester {
+	return &Ingester{
+		cfg:      cfg,
+		client:   &http.Client{Timeout: 10 * time.Second},
+		aircraft: make(map[string]*aircraft),
+	}
+}
+
+// Run connects to the entity store and the feed and publishes aircraft until
+// ctx is cancelled.
+func (in *Ingester) Run(ctx context.Context) error {
+	conn, err := client.Dial(in.cfg.StoreAddr)
+	if err != nil {
+		return fmt.Errorf("connect to store: %w", err)
+	}
+	defer conn.Close()
+
+	client := storev1.NewEntityStoreServiceClient(conn)
+	ticker := time.NewTicker(in.cfg.Interval)
+	defer ticker.Stop()
+
+	slog.Info("adsb-ingest started", "source", in.cfg.Source, "interval", in.cfg.Interval, "store_addr", in.cfg.StoreAddr)
+
+	if in.cfg.Source == SourceSBS {
+		go in.readSBSLoop(ctx)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if in.cfg.Source == SourceOpenSky {
+				reports, err := FetchOpenSky(ctx, in.client, in.cfg.OpenSkyURL, in.cfg.BBox)
+				if err != nil {
+					slog.Error("opensky poll failed", "error", err)
+				}
+				for _, r := range reports {
+					in.observe(r)
+				}
+			}
+			if err := in.publish(ctx, client, time.Now()); err != nil {
+				slog.Error("publish failed", "error", err)
+			}
+		}
+	}
+}
+
+// readSBSLoop reads the SBS feed, reconnecting after errors until ctx is
+// cancelled.
+func (in *Ingester) readSBSLoop(ctx context.Context) {
+	for {
+		err := in.readSBS(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("sbs feed lost, reconnecting", "addr", in.cfg.SBSAddr, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func (in *Ingester) readSBS(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", in.cfg.SBSAddr)
+	if err != nil {
+		return fmt.Errorf("dial sbs: %w", err)
+	}
+	defer conn.Close()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	slog.Info("sbs feed connected", "addr", in.cfg.SBSAddr)
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		r, ok, err := ParseSBS(sc.Text(), time.Now())
+		if err != nil {
+			slog.Debug("skipping sbs line", "error", err)
+			continue
+		}
+		if ok {
+			in.observe(r)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return errors.New("feed closed")
+}
+
+// observe folds a report into the aircraft's merged state.
+func (in *Ingester) observe(r Report) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	a, ok := in.aircraft[r.ICAO]
+	if !ok {
+		a = &aircraft{Report: Report{ICAO: r.ICAO}}
+		in.aircraft[r.ICAO] = a
+	}
+	a.Time = r.Time
+	if r.Callsign != "" {
+		a.Callsign = r.Callsign
+	}
+	if r.HasPosition {
+		a.Lat, a.Lon, a.HasPosition = r.Lat, r.Lon, true
+	}
+	if r.HasAltitude {
+		a.AltM, a.HasAltitude = r.AltM, true
+	}
+	if r.HasVelocity {
+		a.SpeedKnots, a.Heading, a.HasVelocity = r.SpeedKnots, r.Heading, true
+	}
+}
+
+// publish creates or updates every positioned aircraft inside the bbox and
+// deletes those not heard from within StaleAfter.
+func (in *Ingester) publish(ctx context.Context, client storev1.EntityStoreServiceClient, now time.Time) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	var errs []error
+	for icao, a := range in.aircraft {
+		id := EntityID(icao)
+		if now.Sub(a.Time) > in.cfg.StaleAfter {
+			delete(in.aircraft, icao)
+			if !a.published {
+				continue
+			}
+			if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: id}); err != nil {
+				errs = append(errs, fmt.Errorf("delete %s: %w", id, err))
+				continue
+			}
+			slog.Info("aircraft stale", "track_id", id, "callsign", a.Callsign)
+			continue
+		}
+		if !a.HasPosition || !in.inBBox(a.Lat, a.Lon) {
+			continue
+		}
+
+		entity, err := in.buildEntity(a)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !a.published {
+			if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity}); err != nil {
+				errs = append(errs, fmt.Errorf("create %s: %w", id, err))
+				continue
+			}
+			a.published = true
+			slog.Info("aircraft acquired", "track_id", id, "callsign", a.Callsign, "lat", a.Lat, "lon", a.Lon)
+			continue
+		}
+		if err := update(ctx, client, entity); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (in *Ingester) inBBox(lat, lon float64) bool {
+	b := in.cfg.BBox
+	if b == (sensor.BBox{}) {
+		return true
+	}
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// update writes entity over the stored copy. It carries the stored HLC so the
+// store's merge accepts the new position and velocity.
+func update(ctx context.Context, client storev1.EntityStoreServiceClient, entity *entityv1.Entity) error {
+	existing, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: entity.Id})
+	if err != nil {
+		return fmt.Errorf("get %s: %w", entity.Id, err)
+	}
+	entity.HlcPhysical, entity.HlcLogical, entity.HlcNode = existing.HlcPhysical, existing.HlcLogical, existing.HlcNode
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity}); err != nil {
+		return fmt.Errorf("update %s: %w", entity.Id, err)
+	}
+	return nil
+}
+
+// EntityID is the store ID for an ICAO address.
+func EntityID(icao string) string {
+	return "adsb-" + icao
+}
+
+func (in *Ingester) buildEntity(a *aircraft) (*entityv1.Entity, error) {
+	pos, err := anypb.New(&entityv1.PositionComponent{
+		Lat: a.Lat,
+		Lon: a.Lon,
+		Alt: a.AltM,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pack position: %w", err)
+	}
+	src, err := anypb.New(&entityv1.SourceComponent{
+		SensorId:   in.cfg.SensorID,
+		SensorType: "adsb",
+		Domain:     entityv1.Domain_DOMAIN_AIR,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pack source: %w", err)
+	}
+
+	components := map[string]*anypb.Any{
+		"position": pos,
+		"source":   src,
+	}
+	if a.HasVelocity {
+		vel, err := anypb.New(&entityv1.VelocityComponent{
+			Speed:   a.SpeedKnots,
+			Heading: a.Heading,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("pack velocity: %w", err)
+		}
+		components["velocity"] = vel
+	}
+
+	return &entityv1.Entity{
+		Id:         EntityID(a.ICAO),
+		Type:       entityv1.EntityType_ENTITY_TYPE_TRACK,
+		Components: components,
+	}, nil
+}
@@ -0,0 +1,155 @@
+package adsb
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func startTestServer(t *testing.T) (string, func()) {
+	t.Helper()
+
+	s := store.New()
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go srv.Serve(lis) //nolint:errcheck
+
+	cleanup := func() {
+		srv.Stop()
+	}
+	return lis.Addr().String(), cleanup
+}
+
+// startSBSFeed serves lines to the first client that connects.
+func startSBSFeed(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, l := range lines {
+			fmt.Fprintf(conn, "%s\r\n", l)
+		}
+		time.Sleep(time.Second)
+	}()
+	return lis.Addr().String()
+}
+
+func TestIngester_SBSIntegration(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	cfg.SBSAddr = startSBSFeed(t, sbsIdent, sbsPosition, sbsVelocity)
+	cfg.Interval = 50 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	if err := New(cfg).Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	e, err := storev1.NewEntityStoreServiceClient(conn).GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "adsb-A1B2C3"})
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if e.Type != entityv1.EntityType_ENTITY_TYPE_TRACK {
+		t.Fatalf("expected TRACK, got %v", e.Type)
+	}
+	for _, key := range []string{"position", "velocity", "source"} {
+		if _, ok := e.Components[key]; !ok {
+			t.Fatalf("missing %s component", key)
+		}
+	}
+	var src entityv1.SourceComponent
+	if err := e.Components["source"].UnmarshalTo(&src); err != nil {
+		t.Fatalf("unmarshal source: %v", err)
+	}
+	if src.SensorType != "adsb" || src.SensorId != "adsb-1" {
+		t.Fatalf("unexpected source %+v", &src)
+	}
+}
+
+func TestIngester_PublishDropsStaleAndOutOfArea(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	in := New(cfg)
+	now := time.Now()
+	in.observe(Report{ICAO: "AAAAAA", Time: now, HasPosition: true, Lat: 38.9, Lon: -77.0})
+	in.observe(Report{ICAO: "BBBBBB", Time: now, HasPosition: true, Lat: 40.7, Lon: -74.0})
+	ctx := context.Background()
+	if err := in.publish(ctx, client, now); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "adsb-AAAAAA"}); err != nil {
+		t.Fatalf("expected in-area aircraft published: %v", err)
+	}
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "adsb-BBBBBB"}); err == nil {
+		t.Fatal("expected out-of-area aircraft to be skipped")
+	}
+
+	in.observe(Report{ICAO: "AAAAAA", Time: now, HasPosition: true, Lat: 38.95, Lon: -77.0})
+	if err := in.publish(ctx, client, now); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	e, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "adsb-AAAAAA"})
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	var pos entityv1.PositionComponent
+	if err := e.Components["position"].UnmarshalTo(&pos); err != nil || pos.Lat != 38.95 {
+		t.Fatalf("expected updated lat 38.95, got %v (%v)", pos.Lat, err)
+	}
+
+	if err := in.publish(ctx, client, now.Add(2*cfg.StaleAfter)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "adsb-AAAAAA"}); err == nil {
+		t.Fatal("expected stale aircraft to be deleted")
+	}
+	if len(in.aircraft) != 0 {
+		t.Fatalf("expected stale aircraft forgotten, have %d", len(in.aircraft))
+	}
+}
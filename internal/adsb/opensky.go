@@ -0,0 +1,89 @@
+package adsb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boshu2/lattice-lab/internal/sensor"
+)
+
+const mpsToKnots = 1 / 0.514444
+
+// openSkyStates is the /api/states/all response. Each state vector is a
+// positional JSON array; see the OpenSky REST API documentation.
+type openSkyStates struct {
+	Time   int64   `json:"time"`
+	States [][]any `json:"states"`
+}
+
+// FetchOpenSky polls the OpenSky Network states endpoint for aircraft inside
+// bbox and returns one report per state vector.
+func FetchOpenSky(ctx context.Context, client *http.Client, baseURL string, bbox sensor.BBox) ([]Report, error) {
+	q := url.Values{}
+	q.Set("lamin", strconv.FormatFloat(bbox.MinLat, 'f', -1, 64))
+	q.Set("lamax", strconv.FormatFloat(bbox.MaxLat, 'f', -1, 64))
+	q.Set("lomin", strconv.FormatFloat(bbox.MinLon, 'f', -1, 64))
+	q.Set("lomax", strconv.FormatFloat(bbox.MaxLon, 'f', -1, 64))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(baseURL, "/")+"/api/states/all?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("build request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("fetch states: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch states: %s", resp.Status)
+	}
+
+	var body openSkyStates
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode states: %w", err)
+	}
+	return parseOpenSkyStates(body), nil
+}
+
+// parseOpenSkyStates converts state vectors to reports, skipping malformed
+// vectors.
+func parseOpenSkyStates(body openSkyStates) []Report {
+	var out []Report
+	for _, sv := range body.States {
+		if len(sv) < 11 {
+			continue
+		}
+		icao, _ := sv[0].(string)
+		if icao == "" {
+			continue
+		}
+		r := Report{ICAO: strings.ToUpper(icao), Time: time.Unix(body.Time, 0)}
+		if cs, ok := sv[1].(string); ok {
+			r.Callsign = strings.TrimSpace(cs)
+		}
+		if ts, ok := sv[4].(float64); ok {
+			r.Time = time.Unix(int64(ts), 0)
+		}
+		lon, okLon := sv[5].(float64)
+		lat, okLat := sv[6].(float64)
+		if okLat && okLon {
+			r.Lat, r.Lon, r.HasPosition = lat, lon, true
+		}
+		if alt, ok := sv[7].(float64); ok { // barometric altitude, meters
+			r.AltM, r.HasAltitude = alt, true
+		}
+		spd, okSpd := sv[9].(float64) // m/s
+		hdg, okHdg := sv[10].(float64)
+		if okSpd && okHdg {
+			r.SpeedKnots, r.Heading, r.HasVelocity = spd*mpsToKnots, hdg, true
+		}
+		out = append(out, r)
+	}
+	return out
+}
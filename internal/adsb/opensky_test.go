@@ -0,0 +1,56 @@
+package adsb
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boshu2/lattice-lab/internal/sensor"
+)
+
+func TestParseOpenSkyStates(t *testing.T) {
+	reports := parseOpenSkyStates(openSkyStates{
+		Time: 1700000000,
+		States: [][]any{
+			{"a1b2c3", "UAL123  ", "United States", 1699999990.0, 1699999995.0, -77.05, 38.9, 3000.0, false, 200.0, 45.0, 0.0, nil, 3100.0, "1200", false, 0.0},
+			{"ffffff", nil, "United States", nil, nil, nil, nil, nil, true, nil, nil, nil, nil, nil, nil, false, 0.0},
+			{"short"},
+		},
+	})
+	if len(reports) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(reports))
+	}
+	r := reports[0]
+	if r.ICAO != "A1B2C3" || r.Callsign != "UAL123" || r.Lat != 38.9 || r.Lon != -77.05 || r.AltM != 3000 {
+		t.Fatalf("unexpected report: %+v", r)
+	}
+	if math.Abs(r.SpeedKnots-388.77) > 0.1 || r.Heading != 45 {
+		t.Fatalf("expected 200 m/s = ~388.8 kts at 45°, got %v at %v", r.SpeedKnots, r.Heading)
+	}
+	if reports[1].HasPosition || reports[1].HasVelocity {
+		t.Fatalf("expected no position or velocity for null state, got %+v", reports[1])
+	}
+}
+
+func TestFetchOpenSky(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		fmt.Fprint(w, `{"time":1700000000,"states":[["a1b2c3","UAL123",null,null,null,-77.05,38.9,3000.0,false,200.0,45.0]]}`)
+	}))
+	defer srv.Close()
+
+	reports, err := FetchOpenSky(context.Background(), srv.Client(), srv.URL, sensor.BBox{MinLat: 38.8, MaxLat: 39, MinLon: -77.2, MaxLon: -76.9})
+	if err != nil {
+		t.Fatalf("FetchOpenSky: %v", err)
+	}
+	if len(reports) != 1 || reports[0].ICAO != "A1B2C3" {
+		t.Fatalf("unexpected reports: %+v", reports)
+	}
+	if query != "lamax=39&lamin=38.8&lomax=-76.9&lomin=-77.2" {
+		t.Fatalf("unexpected query %q", query)
+	}
+}
@@ -0,0 +1,76 @@
+package adsb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const feetToMeters = 0.3048
+
+// Report is one decoded observation of an aircraft. Only the fields the
+// source message carried are set; the Has* flags say which.
+type Report struct {
+	ICAO     string // 24-bit address, upper-case hex
+	Callsign string
+	Time     time.Time
+
+	HasPosition bool
+	Lat, Lon    float64
+	HasAltitude bool
+	AltM        float64
+
+	HasVelocity bool
+	SpeedKnots  float64
+	Heading     float64 // degrees true
+}
+
+// ParseSBS decodes one line of BaseStation (SBS-1) output as served by
+// dump1090 on port 30003. Lines that carry nothing useful, such as MSG,8
+// all-call replies, return ok=false without error.
+func ParseSBS(line string, now time.Time) (Report, bool, error) {
+	f := strings.Split(strings.TrimSpace(line), ",")
+	if len(f) < 22 || f[0] != "MSG" {
+		return Report{}, false, fmt.Errorf("not an SBS MSG line")
+	}
+	icao := strings.ToUpper(strings.TrimSpace(f[4]))
+	if icao == "" {
+		return Report{}, false, fmt.Errorf("missing hex ident")
+	}
+
+	r := Report{ICAO: icao, Callsign: strings.TrimSpace(f[10]), Time: now}
+	var err error
+	if f[11] != "" {
+		alt, perr := strconv.ParseFloat(f[11], 64)
+		err = firstErr(err, perr)
+		r.AltM, r.HasAltitude = alt*feetToMeters, perr == nil
+	}
+	if f[14] != "" && f[15] != "" {
+		lat, perr1 := strconv.ParseFloat(f[14], 64)
+		lon, perr2 := strconv.ParseFloat(f[15], 64)
+		err = firstErr(err, perr1, perr2)
+		r.Lat, r.Lon, r.HasPosition = lat, lon, perr1 == nil && perr2 == nil
+	}
+	if f[12] != "" && f[13] != "" {
+		spd, perr1 := strconv.ParseFloat(f[12], 64)
+		hdg, perr2 := strconv.ParseFloat(f[13], 64)
+		err = firstErr(err, perr1, perr2)
+		r.SpeedKnots, r.Heading, r.HasVelocity = spd, hdg, perr1 == nil && perr2 == nil
+	}
+	if err != nil {
+		return Report{}, false, fmt.Errorf("%s: %w", icao, err)
+	}
+
+	useful := r.Callsign != "" || r.HasPosition || r.HasAltitude || r.HasVelocity
+	return r, useful, nil
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package adsb
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+const (
+	sbsIdent    = "MSG,1,1,1,A1B2C3,1,2026/10/16,12:00:00.000,2026/10/16,12:00:00.000,UAL123  ,,,,,,,,,,,0"
+	sbsPosition = "MSG,3,1,1,A1B2C3,1,2026/10/16,12:00:00.000,2026/10/16,12:00:00.000,,10000,,,38.9,-77.05,,,0,0,0,0"
+	sbsVelocity = "MSG,4,1,1,a1b2c3,1,2026/10/16,12:00:00.000,2026/10/16,12:00:00.000,,,420,90,,,-640,,,,,0"
+)
+
+func TestParseSBS(t *testing.T) {
+	now := time.Now()
+
+	r, ok, err := ParseSBS(sbsPosition, now)
+	if err != nil || !ok {
+		t.Fatalf("ParseSBS position: ok=%v err=%v", ok, err)
+	}
+	if r.ICAO != "A1B2C3" || !r.HasPosition || r.Lat != 38.9 || r.Lon != -77.05 {
+		t.Fatalf("unexpected position report: %+v", r)
+	}
+	if !r.HasAltitude || math.Abs(r.AltM-3048) > 0.01 {
+		t.Fatalf("expected 10000ft = 3048m, got %v", r.AltM)
+	}
+
+	r, _, _ = ParseSBS(sbsVelocity, now)
+	if r.ICAO != "A1B2C3" || !r.HasVelocity || r.SpeedKnots != 420 || r.Heading != 90 || r.HasPosition {
+		t.Fatalf("unexpected velocity report: %+v", r)
+	}
+
+	r, _, _ = ParseSBS(sbsIdent, now)
+	if r.Callsign != "UAL123" {
+		t.Fatalf("expected callsign UAL123, got %q", r.Callsign)
+	}
+
+	if _, ok, err := ParseSBS("MSG,8,1,1,A1B2C3,1,,,,,,,,,,,,,,,,0", now); err != nil || ok {
+		t.Fatalf("expected all-call to be skipped, ok=%v err=%v", ok, err)
+	}
+	for _, bad := range []string{"", "SEL,1,2", "MSG,3,1,1,,1,,,,,,,,,,,,,,,,0", "MSG,3,1,1,A1,1,,,,,,x,,,,,,,,,,0"} {
+		if _, _, err := ParseSBS(bad, now); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package ais
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Sentinel raw values meaning "not available".
+const (
+	sogNA = 1023
+	lonNA = 181 * 600000
+	latNA = 91 * 600000
+	cogNA = 3600
+)
+
+// Message is the part of a decoded AIS message the ingester uses. Only the
+// fields the message type carries are set; the Has* flags say which.
+type Message struct {
+	Type int
+	MMSI uint32
+
+	HasPosition bool
+	Lat, Lon    float64
+
+	HasVelocity bool
+	SpeedKnots  float64
+	Course      float64 // course over ground, degrees true
+
+	Name     string // vessel name, static messages only
+	ShipType int    // ITU ship and cargo type; 0 = not reported
+}
+
+// payload is a de-armored AIS payload, one bit per byte.
+type payload []byte
+
+// dearmor unpacks the 6-bit ASCII armoring into bits, dropping fill bits.
+func dearmor(s string, fillBits int) (payload, error) {
+	bits := make(payload, 0, len(s)*6)
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 48 || c > 119 || (c > 87 && c < 96) {
+			return nil, fmt.Errorf("invalid payload character %q", c)
+		}
+		v := c - 48
+		if v > 40 {
+			v -= 8
+		}
+		for b := 5; b >= 0; b-- {
+			bits = append(bits, (v>>b)&1)
+		}
+	}
+	if fillBits > len(bits) {
+		return nil, fmt.Errorf("fill bits exceed payload")
+	}
+	return bits[:len(bits)-fillBits], nil
+}
+
+func (p payload) uint(start, n int) uint32 {
+	var v uint32
+	for _, b := range p[start : start+n] {
+		v = v<<1 | uint32(b)
+	}
+	return v
+}
+
+func (p payload) int(start, n int) int32 {
+	v := p.uint(start, n)
+	if p[start] == 1 {
+		return int32(v) - int32(1<<n) // sign-extend two's complement
+	}
+	return int32(v)
+}
+
+// text decodes n 6-bit characters, trimming the "@" padding and spaces.
+func (p payload) text(start, n int) string {
+	const sixbit = "@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_ !\"#$%&'()*+,-./0123456789:;<=>?"
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteByte(sixbit[p.uint(start+i*6, 6)])
+	}
+	return strings.TrimSpace(strings.TrimRight(b.String(), "@"))
+}
+
+// decode decodes position reports (types 1-3 and 18) and static data
+// (types 5 and 24). Other types return ok=false without error.
+func decode(armored string, fillBits int) (Message, bool, error) {
+	p, err := dearmor(armored, fillBits)
+	if err != nil {
+		return Message{}, false, err
+	}
+	if len(p) < 38 {
+		return Message{}, false, fmt.Errorf("payload too short: %d bits", len(p))
+	}
+
+	m := Message{Type: int(p.uint(0, 6)), MMSI: p.uint(8, 30)}
+	need := map[int]int{1: 137, 2: 137, 3: 137, 18: 133, 5: 240, 24: 40}[m.Type]
+	if need == 0 {
+		return Message{}, false, nil
+	}
+	if len(p) < need {
+		return Message{}, false, fmt.Errorf("type %d payload too short: %d bits", m.Type, len(p))
+	}
+
+	switch m.Type {
+	case 1, 2, 3:
+		m.setPosition(p.int(61, 28), p.int(89, 27))
+		m.setVelocity(p.uint(50, 10), p.uint(116, 12))
+	case 18:
+		m.setPosition(p.int(57, 28), p.int(85, 27))
+		m.setVelocity(p.uint(46, 10), p.uint(112, 12))
+	case 5:
+		m.Name = p.text(112, 20)
+		m.ShipType = int(p.uint(232, 8))
+	case 24:
+		switch part := p.uint(38, 2); {
+		case part == 0 && len(p) >= 160:
+			m.Name = p.text(40, 20)
+		case part == 1 && len(p) >= 48:
+			m.ShipType = int(p.uint(40, 8))
+		}
+	}
+	return m, true, nil
+}
+
+// setPosition converts raw 1/10000-minute coordinates.
+func (m *Message) setPosition(lon, lat int32) {
+	if lon == lonNA || lat == latNA {
+		return
+	}
+	m.Lat, m.Lon, m.HasPosition = float64(lat)/600000, float64(lon)/600000, true
+}
+
+// setVelocity converts raw 1/10-knot speed and 1/10-degree course.
+func (m *Message) setVelocity(sog, cog uint32) {
+	if sog == sogNA || cog >= cogNA {
+		return
+	}
+	m.SpeedKnots, m.Course, m.HasVelocity = float64(sog)/10, float64(cog)/10, true
+}
@@ -0,0 +1,283 @@
+// Package ais ingests AIS NMEA streams and publishes vessels as surface TRACK
+// entities.
+package ais
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Config controls the AIS ingester.
+type Config struct {
+	StoreAddr  string
+	FeedAddr   string        // host:port serving AIVDM sentences over TCP
+	Interval   time.Duration // how often to publish
+	StaleAfter time.Duration // delete vessels not heard from for this long
+	SensorID   string
+}
+
+// DefaultConfig returns a config reading an NMEA feed on localhost. Anchored
+// class A vessels report only every few minutes, so vessels go stale slowly.
+func DefaultConfig() Config {
+	return Config{
+		StoreAddr:  "localhost:50051",
+		FeedAddr:   "localhost:10110",
+		Interval:   5 * time.Second,
+		StaleAfter: 10 * time.Minute,
+		SensorID:   "ais-1",
+	}
+}
+
+// Validate checks the config is runnable.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.Interval <= 0:
+		return fmt.Errorf("interval must be positive")
+	case cfg.StaleAfter <= 0:
+		return fmt.Errorf("stale_after must be positive")
+	}
+	return nil
+}
+
+// vessel is the merged state of one MMSI. Position and static reports arrive
+// in separate messages.
+type vessel struct {
+	mmsi        uint32
+	seen        time.Time
+	name        string
+	shipType    int
+	hasPosition bool
+	lat, lon    float64
+	speedKnots  float64
+	course      float64
+	published   bool // created in the store
+}
+
+// Ingester merges AIS messages and publishes vessels to an entity store.
+type Ingester struct {
+	cfg Config
+
+	mu      sync.Mutex
+	vessels map[uint32]*vessel
+}
+
+// New creates an ingester with the given config.
+func New(cfg Config) *Ingester {
+	return &Ingester{cfg: cfg, vessels: make(map[uint32]*vessel)}
+}
+
+// Run connects to the entity store and the feed and publishes vessels until
+// ctx is cancelled.
+func (in *Ingester) Run(ctx context.Context) error {
+	conn, err := client.Dial(in.cfg.StoreAddr)
+	if err != nil {
+		return fmt.Errorf("connect to store: %w", err)
+	}
+	defer conn.Close()
+
+	client := storev1.NewEntityStoreServiceClient(conn)
+	ticker := time.NewTicker(in.cfg.Interval)
+	defer ticker.Stop()
+
+	slog.Info("ais-ingest started", "feed_addr", in.cfg.FeedAddr, "interval", in.cfg.Interval, "store_addr", in.cfg.StoreAddr)
+
+	go in.readFeedLoop(ctx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := in.publish(ctx, client, time.Now()); err != nil {
+				slog.Error("publish failed", "error", err)
+			}
+		}
+	}
+}
+
+// readFeedLoop reads the NMEA feed, reconnecting after errors until ctx is
+// cancelled.
+func (in *Ingester) readFeedLoop(ctx context.Context) {
+	for {
+		err := in.readFeed(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("ais feed lost, reconnecting", "addr", in.cfg.FeedAddr, "error", err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(2 * time.Second):
+		}
+	}
+}
+
+func (in *Ingester) readFeed(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", in.cfg.FeedAddr)
+	if err != nil {
+		return fmt.Errorf("dial feed: %w", err)
+	}
+	defer conn.Close()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	slog.Info("ais feed connected", "addr", in.cfg.FeedAddr)
+	asm := NewAssembler()
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		m, ok, err := asm.Feed(sc.Text())
+		if err != nil {
+			slog.Debug("skipping nmea line", "error", err)
+			continue
+		}
+		if ok {
+			in.observe(m, time.Now())
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return errors.New("feed closed")
+}
+
+// observe folds a message into the vessel's merged state.
+func (in *Ingester) observe(m Message, now time.Time) {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	v, ok := in.vessels[m.MMSI]
+	if !ok {
+		v = &vessel{mmsi: m.MMSI}
+		in.vessels[m.MMSI] = v
+	}
+	v.seen = now
+	if m.Name != "" {
+		v.name = m.Name
+	}
+	if m.ShipType != 0 {
+		v.shipType = m.ShipType
+	}
+	if m.HasPosition {
+		v.lat, v.lon, v.hasPosition = m.Lat, m.Lon, true
+	}
+	if m.HasVelocity {
+		v.speedKnots, v.course = m.SpeedKnots, m.Course
+	}
+}
+
+// publish creates or updates every positioned vessel and deletes those not
+// heard from within StaleAfter.
+func (in *Ingester) publish(ctx context.Context, client storev1.EntityStoreServiceClient, now time.Time) error {
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	var errs []error
+	for mmsi, v := range in.vessels {
+		id := EntityID(mmsi)
+		if now.Sub(v.seen) > in.cfg.StaleAfter {
+			delete(in.vessels, mmsi)
+			if !v.published {
+				continue
+			}
+			if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: id}); err != nil {
+				errs = append(errs, fmt.Errorf("delete %s: %w", id, err))
+				continue
+			}
+			slog.Info("vessel stale", "track_id", id, "name", v.name)
+			continue
+		}
+		if !v.hasPosition {
+			continue
+		}
+
+		entity, err := in.buildEntity(v)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !v.published {
+			if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity}); err != nil {
+				errs = append(errs, fmt.Errorf("create %s: %w", id, err))
+				continue
+			}
+			v.published = true
+			slog.Info("vessel acquired", "track_id", id, "name", v.name, "ship_type", v.shipType, "lat", v.lat, "lon", v.lon)
+			continue
+		}
+		if err := update(ctx, client, entity); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// update writes entity over the stored copy. It carries the stored HLC so the
+// store's merge accepts the new position and velocity.
+func update(ctx context.Context, client storev1.EntityStoreServiceClient, entity *entityv1.Entity) error {
+	existing, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: entity.Id})
+	if err != nil {
+		return fmt.Errorf("get %s: %w", entity.Id, err)
+	}
+	entity.HlcPhysical, entity.HlcLogical, entity.HlcNode = existing.HlcPhysical, existing.HlcLogical, existing.HlcNode
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity}); err != nil {
+		return fmt.Errorf("update %s: %w", entity.Id, err)
+	}
+	return nil
+}
+
+// EntityID is the store ID for an MMSI.
+func EntityID(mmsi uint32) string {
+	return "ais-" + strconv.FormatUint(uint64(mmsi), 10)
+}
+
+func (in *Ingester) buildEntity(v *vessel) (*entityv1.Entity, error) {
+	pos, err := anypb.New(&entityv1.PositionComponent{
+		Lat: v.lat,
+		Lon: v.lon,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pack position: %w", err)
+	}
+
+	vel, err := anypb.New(&entityv1.VelocityComponent{
+		Speed:   v.speedKnots,
+		Heading: v.course,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pack velocity: %w", err)
+	}
+
+	src, err := anypb.New(&entityv1.SourceComponent{
+		SensorId:   in.cfg.SensorID,
+		SensorType: "ais",
+		Domain:     entityv1.Domain_DOMAIN_SURFACE,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("pack source: %w", err)
+	}
+
+	return &entityv1.Entity{
+		Id:   EntityID(v.mmsi),
+		Type: entityv1.EntityType_ENTITY_TYPE_TRACK,
+		Components: map[string]*anypb.Any{
+			"position": pos,
+			"velocity": vel,
+			"source":   src,
+		},
+	}, nil
+}
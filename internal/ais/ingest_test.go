@@ -0,0 +1,151 @@
+package ais
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func startTestServer(t *testing.T) (string, func()) {
+	t.Helper()
+
+	s := store.New()
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go srv.Serve(lis) //nolint:errcheck
+
+	cleanup := func() {
+		srv.Stop()
+	}
+	return lis.Addr().String(), cleanup
+}
+
+// startFeed serves lines to the first client that connects.
+func startFeed(t *testing.T, lines ...string) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { lis.Close() })
+
+	go func() {
+		conn, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		for _, l := range lines {
+			fmt.Fprintf(conn, "%s\r\n", l)
+		}
+		time.Sleep(time.Second)
+	}()
+	return lis.Addr().String()
+}
+
+func TestIngesterIntegration(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	cfg.FeedAddr = startFeed(t, aisClassA, aisStatic1, aisStatic2, aisClassB)
+	cfg.Interval = 50 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	if err := New(cfg).Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	e, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "ais-477553000"})
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if e.Type != entityv1.EntityType_ENTITY_TYPE_TRACK {
+		t.Fatalf("expected TRACK, got %v", e.Type)
+	}
+	var src entityv1.SourceComponent
+	if err := e.Components["source"].UnmarshalTo(&src); err != nil {
+		t.Fatalf("unmarshal source: %v", err)
+	}
+	if src.Domain != entityv1.Domain_DOMAIN_SURFACE || src.SensorType != "ais" {
+		t.Fatalf("unexpected source %+v", &src)
+	}
+
+	if _, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "ais-338087471"}); err != nil {
+		t.Fatalf("expected class B vessel published: %v", err)
+	}
+	// Static data alone has no position to publish.
+	if _, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "ais-351759000"}); err == nil {
+		t.Fatal("expected vessel without position to be skipped")
+	}
+}
+
+func TestIngester_PublishUpdatesAndDeletesStale(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	in := New(cfg)
+	now := time.Now()
+	in.observe(Message{MMSI: 123456789, HasPosition: true, Lat: 38.85, Lon: -77.03}, now)
+	ctx := context.Background()
+	if err := in.publish(ctx, client, now); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "ais-123456789"}); err != nil {
+		t.Fatalf("expected vessel published: %v", err)
+	}
+
+	in.observe(Message{MMSI: 123456789, HasPosition: true, Lat: 38.86, Lon: -77.03}, now)
+	if err := in.publish(ctx, client, now); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	e, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "ais-123456789"})
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	var pos entityv1.PositionComponent
+	if err := e.Components["position"].UnmarshalTo(&pos); err != nil || pos.Lat != 38.86 {
+		t.Fatalf("expected updated lat 38.86, got %v (%v)", pos.Lat, err)
+	}
+
+	if err := in.publish(ctx, client, now.Add(2*cfg.StaleAfter)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "ais-123456789"}); err == nil {
+		t.Fatal("expected stale vessel to be deleted")
+	}
+}
@@ -0,0 +1,106 @@
+package ais
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sentence is one !AIVDM/!AIVDO line: a fragment of an armored payload.
+type sentence struct {
+	count, num int    // fragment count and this fragment's 1-based number
+	seqID      string // ties the fragments of one multi-sentence message
+	channel    string
+	payload    string
+	fillBits   int
+}
+
+// parseSentence splits and checksums an AIVDM/AIVDO sentence.
+func parseSentence(line string) (sentence, error) {
+	line = strings.TrimSpace(line)
+	// Some feeds prefix NMEA 4.10 tag blocks: \s:station,c:time*hh\!AIVDM...
+	if i := strings.LastIndexByte(line, '!'); i > 0 {
+		line = line[i:]
+	}
+	if !strings.HasPrefix(line, "!AIVDM") && !strings.HasPrefix(line, "!AIVDO") {
+		return sentence{}, fmt.Errorf("not an AIVDM sentence")
+	}
+
+	star := strings.LastIndexByte(line, '*')
+	if star < 0 || len(line) < star+3 {
+		return sentence{}, fmt.Errorf("missing checksum")
+	}
+	want, err := strconv.ParseUint(line[star+1:star+3], 16, 8)
+	if err != nil {
+		return sentence{}, fmt.Errorf("bad checksum: %w", err)
+	}
+	var sum byte
+	for i := 1; i < star; i++ {
+		sum ^= line[i]
+	}
+	if sum != byte(want) {
+		return sentence{}, fmt.Errorf("checksum mismatch: got %02X, want %02X", sum, want)
+	}
+
+	f := strings.Split(line[1:star], ",")
+	if len(f) != 7 {
+		return sentence{}, fmt.Errorf("want 7 fields, got %d", len(f))
+	}
+	s := sentence{seqID: f[3], channel: f[4], payload: f[5]}
+	if s.count, err = strconv.Atoi(f[1]); err != nil || s.count < 1 {
+		return sentence{}, fmt.Errorf("bad fragment count %q", f[1])
+	}
+	if s.num, err = strconv.Atoi(f[2]); err != nil || s.num < 1 || s.num > s.count {
+		return sentence{}, fmt.Errorf("bad fragment number %q", f[2])
+	}
+	if s.fillBits, err = strconv.Atoi(f[6]); err != nil || s.fillBits < 0 || s.fillBits > 5 {
+		return sentence{}, fmt.Errorf("bad fill bits %q", f[6])
+	}
+	return s, nil
+}
+
+// Assembler joins multi-sentence messages and decodes complete payloads.
+// It is not safe for concurrent use.
+type Assembler struct {
+	pending map[string][]sentence // by channel+seqID
+}
+
+// NewAssembler returns an empty assembler.
+func NewAssembler() *Assembler {
+	return &Assembler{pending: make(map[string][]sentence)}
+}
+
+// Feed takes one NMEA line. It returns ok=true with the decoded message once
+// a message is complete; fragments and unsupported message types return
+// ok=false without error.
+func (a *Assembler) Feed(line string) (Message, bool, error) {
+	s, err := parseSentence(line)
+	if err != nil {
+		return Message{}, false, err
+	}
+	if s.count == 1 {
+		return decode(s.payload, s.fillBits)
+	}
+
+	key := s.channel + "/" + s.seqID
+	frags := a.pending[key]
+	if s.num == 1 {
+		frags = nil // a new message restarts the sequence
+	}
+	if len(frags) != s.num-1 {
+		delete(a.pending, key)
+		return Message{}, false, fmt.Errorf("fragment %d/%d out of order", s.num, s.count)
+	}
+	frags = append(frags, s)
+	if s.num < s.count {
+		a.pending[key] = frags
+		return Message{}, false, nil
+	}
+
+	delete(a.pending, key)
+	var payload strings.Builder
+	for _, f := range frags {
+		payload.WriteString(f.payload)
+	}
+	return decode(payload.String(), s.fillBits)
+}
@@ -0,0 +1,73 @@
+package ais
+
+import (
+	"math"
+	"testing"
+)
+
+const (
+	aisClassA   = "!AIVDM,1,1,,B,177KQJ5000G?tO`K>RA1wUbN0TKH,0*5C"
+	aisStatic1  = "!AIVDM,2,1,1,A,55?MbV02;H;s<HtKR20EHE:0@T4@Dn2222222216L961O5Gf0NSQEp6ClRp8,0*1C"
+	aisStatic2  = "!AIVDM,2,2,1,A,88888888880,2*25"
+	aisClassB   = "!AIVDM,1,1,,A,B52K>;h00Fc>jpUlNV@ikwpUoP06,0*4C"
+	aisBadCheck = "!AIVDM,1,1,,B,177KQJ5000G?tO`K>RA1wUbN0TKH,0*5D"
+)
+
+func TestAssembler_ClassAPosition(t *testing.T) {
+	m, ok, err := NewAssembler().Feed(aisClassA)
+	if err != nil || !ok {
+		t.Fatalf("Feed: ok=%v err=%v", ok, err)
+	}
+	if m.Type != 1 || m.MMSI != 477553000 {
+		t.Fatalf("unexpected header: %+v", m)
+	}
+	if !m.HasPosition || math.Abs(m.Lat-47.582833) > 1e-5 || math.Abs(m.Lon+122.345833) > 1e-5 {
+		t.Fatalf("unexpected position: %v, %v", m.Lat, m.Lon)
+	}
+	if !m.HasVelocity || m.SpeedKnots != 0 || m.Course != 51 {
+		t.Fatalf("unexpected velocity: %v kts at %v", m.SpeedKnots, m.Course)
+	}
+}
+
+func TestAssembler_ClassBPosition(t *testing.T) {
+	m, ok, err := NewAssembler().Feed(aisClassB)
+	if err != nil || !ok {
+		t.Fatalf("Feed: ok=%v err=%v", ok, err)
+	}
+	if m.Type != 18 || m.MMSI != 338087471 || math.Abs(m.Lat-40.68454) > 1e-5 || m.SpeedKnots != 0.1 || m.Course != 79.6 {
+		t.Fatalf("unexpected class B report: %+v", m)
+	}
+}
+
+func TestAssembler_MultiSentenceStatic(t *testing.T) {
+	a := NewAssembler()
+	if _, ok, err := a.Feed(aisStatic1); err != nil || ok {
+		t.Fatalf("first fragment: ok=%v err=%v", ok, err)
+	}
+	m, ok, err := a.Feed(aisStatic2)
+	if err != nil || !ok {
+		t.Fatalf("second fragment: ok=%v err=%v", ok, err)
+	}
+	if m.Type != 5 || m.MMSI != 351759000 || m.Name != "EVER DIADEM" || m.ShipType != 70 {
+		t.Fatalf("unexpected static report: %+v", m)
+	}
+
+	// A second fragment with no first is dropped.
+	if _, _, err := a.Feed(aisStatic2); err == nil {
+		t.Fatal("expected error for orphan fragment")
+	}
+}
+
+func TestAssembler_Rejects(t *testing.T) {
+	a := NewAssembler()
+	for _, bad := range []string{"", "$GPGGA,1,2*00", aisBadCheck, "!AIVDM,1,1,,B,177K*00"} {
+		if _, _, err := a.Feed(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+
+	// Tag-block prefixes are stripped.
+	if _, ok, err := a.Feed(`\s:rORBCOMM,c:1700000000*4A\` + aisClassA); err != nil || !ok {
+		t.Fatalf("tag block: ok=%v err=%v", ok, err)
+	}
+}
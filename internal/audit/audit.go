@@ -0,0 +1,52 @@
+// Package audit keeps a bounded in-memory record of the mutating calls
+// made to an entity-store: who made each, what it touched, and when.
+package audit
+
+import (
+	"slices"
+	"sync"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+)
+
+// DefaultSize is how many entries a Log keeps unless told otherwise.
+const DefaultSize = 4096
+
+// Log is a ring of audit entries, safe for concurrent use.
+type Log struct {
+	mu      sync.Mutex
+	entries []*storev1.AuditEntry // oldest first
+	size    int
+}
+
+// New returns a log keeping the last size entries.
+func New(size int) *Log {
+	return &Log{size: max(size, 1)}
+}
+
+// Record appends e, dropping the oldest entry if the log is full.
+func (l *Log) Record(e *storev1.AuditEntry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.entries) == l.size {
+		l.entries = l.entries[1:]
+	}
+	l.entries = append(l.entries, e)
+}
+
+// Entries returns the entries that touched entityID, or all of them if it
+// is empty, oldest first. A positive limit keeps only the most recent.
+func (l *Log) Entries(entityID string, limit int) []*storev1.AuditEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var out []*storev1.AuditEntry
+	for _, e := range l.entries {
+		if entityID == "" || slices.ContainsFunc(e.Targets, func(t *storev1.AuditTarget) bool { return t.EntityId == entityID }) {
+			out = append(out, e)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out
+}
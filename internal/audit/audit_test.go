@@ -0,0 +1,25 @@
+package audit
+
+import (
+	"testing"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+)
+
+func TestLog(t *testing.T) {
+	l := New(3)
+	for _, id := range []string{"t1", "t2", "t1", "t3"} {
+		l.Record(&storev1.AuditEntry{Method: "create " + id, Targets: []*storev1.AuditTarget{{EntityId: id}}})
+	}
+
+	all := l.Entries("", 0)
+	if len(all) != 3 || all[0].Method != "create t2" || all[2].Method != "create t3" {
+		t.Fatalf("expected the last 3 entries oldest first, got %v", all)
+	}
+	if got := l.Entries("t1", 0); len(got) != 1 {
+		t.Fatalf("expected 1 entry for t1 after the oldest was dropped, got %d", len(got))
+	}
+	if got := l.Entries("", 2); len(got) != 2 || got[1].Method != "create t3" {
+		t.Fatalf("expected the 2 most recent entries, got %v", got)
+	}
+}
@@ -0,0 +1,416 @@
+// Package bench runs a fixed create/update/watch workload against an entity
+// store, optionally with mesh peers, and reports throughput, latency
+// percentiles, watch fan-out lag, and relay convergence time as JSON.
+//
+// Unlike loadgen, which holds a target rate for soak testing, bench runs a
+// fixed amount of work as fast as the workers allow, so reports from
+// different builds are comparable.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/watch"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// sentKey is the component carrying each write's send time, which watchers
+// subtract from their receive time.
+const sentKey = "bench_sent"
+
+// Config controls a benchmark run.
+type Config struct {
+	StoreAddr string
+	Peers     []string // mesh peer stores to measure convergence on; the relays must already be running
+	Entities  int
+	Updates   int           // update rounds over every entity after the create phase
+	Workers   int           // concurrent writers
+	Watchers  int           // concurrent watch streams on the target store
+	Timeout   time.Duration // how long to wait for watchers and peers to catch up
+	IDPrefix  string
+	Cleanup   bool   // delete the benchmark entities afterwards
+	AuthToken string // bearer token, for a store with AUTH_TOKENS set; cleanup needs an operator's
+}
+
+// DefaultConfig returns a config for a thousand entities, five update rounds,
+// and four watchers.
+func DefaultConfig() Config {
+	return Config{
+		StoreAddr: "localhost:50051",
+		Entities:  1000,
+		Updates:   5,
+		Workers:   16,
+		Watchers:  4,
+		Timeout:   30 * time.Second,
+		IDPrefix:  "bench-",
+		Cleanup:   true,
+	}
+}
+
+// Validate checks the config is runnable.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.StoreAddr == "":
+		return fmt.Errorf("store address is required")
+	case cfg.Entities <= 0 || cfg.Workers <= 0:
+		return fmt.Errorf("entities and workers must be positive")
+	case cfg.Updates < 0 || cfg.Watchers < 0:
+		return fmt.Errorf("updates and watchers must not be negative")
+	case cfg.Timeout <= 0:
+		return fmt.Errorf("timeout must be positive")
+	case cfg.IDPrefix == "":
+		return fmt.Errorf("id prefix is required")
+	}
+	return nil
+}
+
+// Bench runs one benchmark.
+type Bench struct {
+	cfg Config
+}
+
+// New creates a benchmark with the given config.
+func New(cfg Config) *Bench {
+	return &Bench{cfg: cfg}
+}
+
+// dial connects to a store without retries: a retried call would count
+// its backoff as latency.
+func (b *Bench) dial(addr string) (*grpc.ClientConn, error) {
+	return client.Dial(addr, client.WithToken(b.cfg.AuthToken), client.WithoutRetries())
+}
+
+// watcher follows one store and records the lag of every benchmark event,
+// and the newest send time it has seen per entity.
+type watcher struct {
+	addr string
+	lag  samples
+
+	mu      sync.Mutex
+	ready   bool // the probe entity has arrived
+	events  int
+	latest  map[string]time.Time
+	updated time.Time // when latest last advanced
+}
+
+// Run executes the create phase, the update rounds, and the catch-up wait,
+// and returns the report.
+func (b *Bench) Run(ctx context.Context) (Report, error) {
+	report := Report{Started: time.Now().UTC(), Store: b.cfg.StoreAddr, Peers: b.cfg.Peers, Entities: b.cfg.Entities, Workers: b.cfg.Workers}
+
+	conn, err := b.dial(b.cfg.StoreAddr)
+	if err != nil {
+		return report, fmt.Errorf("connect to store: %w", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+
+	var targets, peers []*watcher
+	for range b.cfg.Watchers {
+		w, err := b.watch(watchCtx, client, b.cfg.StoreAddr)
+		if err != nil {
+			return report, err
+		}
+		targets = append(targets, w)
+	}
+	for _, addr := range b.cfg.Peers {
+		pc, err := b.dial(addr)
+		if err != nil {
+			return report, fmt.Errorf("connect to peer %s: %w", addr, err)
+		}
+		defer pc.Close()
+		w, err := b.watch(watchCtx, storev1.NewEntityStoreServiceClient(pc), addr)
+		if err != nil {
+			return report, err
+		}
+		peers = append(peers, w)
+	}
+	all := append(append([]*watcher(nil), targets...), peers...)
+	if err := b.probe(ctx, client, all); err != nil {
+		return report, err
+	}
+
+	slog.Info("bench started", "entities", b.cfg.Entities, "updates", b.cfg.Updates, "workers", b.cfg.Workers, "watchers", b.cfg.Watchers, "peers", b.cfg.Peers, "store_addr", b.cfg.StoreAddr)
+	start := time.Now()
+
+	final := make([]time.Time, b.cfg.Entities) // send time of each entity's last successful write
+	ok := 0
+	create := b.phase(ctx, "create", b.cfg.Entities, func(ctx context.Context, i int) error {
+		return b.write(ctx, client, i, true, final)
+	})
+	report.Phases = append(report.Phases, create)
+	ok += create.Ops - errCount(create)
+	if b.cfg.Updates > 0 {
+		update := b.phase(ctx, "update", b.cfg.Entities*b.cfg.Updates, func(ctx context.Context, i int) error {
+			return b.write(ctx, client, i%b.cfg.Entities, false, final)
+		})
+		report.Phases = append(report.Phases, update)
+		ok += update.Ops - errCount(update)
+	}
+	lastWrite := time.Now()
+
+	// Wait for every target watcher to see every successful write, then for
+	// each peer to hold every entity's final version.
+	deadline := time.Now().Add(b.cfg.Timeout)
+	for _, w := range targets {
+		waitUntil(ctx, deadline, func() bool { return w.count() >= ok })
+	}
+	report.Watch = WatchReport{Watchers: len(targets)}
+	var lag samples
+	for _, w := range targets {
+		w.mu.Lock()
+		report.Watch.Events += w.events
+		w.mu.Unlock()
+		lag.merge(&w.lag)
+	}
+	report.Watch.Lag = lag.latency()
+
+	report.Convergence = make([]PeerReport, len(peers))
+	var wg sync.WaitGroup
+	for i, w := range peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			converged := waitUntil(ctx, deadline, func() bool { return w.holds(b.cfg.IDPrefix, final) })
+			pr := PeerReport{Store: w.addr, Converged: converged, Events: w.count(), Lag: w.lag.latency()}
+			if converged {
+				// The peer converged when it received the last final version,
+				// not when this loop noticed.
+				w.mu.Lock()
+				pr.Time = Millis(max(w.updated.Sub(lastWrite), 0))
+				w.mu.Unlock()
+			}
+			report.Convergence[i] = pr
+		}()
+	}
+	wg.Wait()
+	report.Elapsed = Millis(time.Since(start))
+	stopWatch()
+
+	if b.cfg.Cleanup {
+		b.cleanup(context.WithoutCancel(ctx), client)
+	}
+	return report, nil
+}
+
+// phase runs one write per op across the workers and summarises it. Ops are
+// split by entity, so each entity's writes stay in order.
+func (b *Bench) phase(ctx context.Context, name string, n int, op func(context.Context, int) error) PhaseReport {
+	var (
+		lat    samples
+		mu     sync.Mutex
+		errors = make(map[string]int)
+		wg     sync.WaitGroup
+	)
+	start := time.Now()
+	for w := range b.cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range n {
+				if i%b.cfg.Entities%b.cfg.Workers != w || ctx.Err() != nil {
+					continue
+				}
+				t := time.Now()
+				if err := op(ctx, i); err != nil {
+					mu.Lock()
+					errors[status.Code(err).String()]++
+					mu.Unlock()
+					continue
+				}
+				lat.add(time.Since(t))
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	r := PhaseReport{Name: name, Ops: n, Elapsed: Millis(elapsed), Latency: lat.latency()}
+	if len(errors) > 0 {
+		r.Errors = errors
+	}
+	r.Throughput = float64(lat.len()) / elapsed.Seconds()
+	slog.Info("bench phase done", "phase", name, "ops", n, "errors", errCount(r), "throughput", r.Throughput, "p99", time.Duration(r.Latency.P99))
+	return r
+}
+
+func errCount(r PhaseReport) int {
+	n := 0
+	for _, c := range r.Errors {
+		n += c
+	}
+	return n
+}
+
+// write creates or updates entity i, stamped with its send time. Updates
+// carry the wall clock as HLC, as loadgen does, to skip a Get round trip.
+func (b *Bench) write(ctx context.Context, client storev1.EntityStoreServiceClient, i int, create bool, final []time.Time) error {
+	now := time.Now()
+	entity, err := b.entity(fmt.Sprintf("%s%d", b.cfg.IDPrefix, i), now)
+	if err != nil {
+		return err
+	}
+	if create {
+		_, err = client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity})
+	} else {
+		entity.HlcPhysical = uint64(now.UnixNano())
+		_, err = client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity})
+	}
+	if err == nil {
+		final[i] = now
+	}
+	return err
+}
+
+func (b *Bench) entity(id string, sent time.Time) (*entityv1.Entity, error) {
+	pos, err := anypb.New(&entityv1.PositionComponent{Lat: 38.9, Lon: -77.0})
+	if err != nil {
+		return nil, fmt.Errorf("pack position: %w", err)
+	}
+	ts, err := anypb.New(timestamppb.New(sent))
+	if err != nil {
+		return nil, fmt.Errorf("pack send time: %w", err)
+	}
+	return &entityv1.Entity{
+		Id:         id,
+		Type:       entityv1.EntityType_ENTITY_TYPE_TRACK,
+		Components: map[string]*anypb.Any{"position": pos, sentKey: ts},
+	}, nil
+}
+
+// watch opens a watch stream on client and records benchmark events until
+// ctx is cancelled.
+func (b *Bench) watch(ctx context.Context, client storev1.EntityStoreServiceClient, addr string) (*watcher, error) {
+	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{TypeFilter: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	if err != nil {
+		return nil, fmt.Errorf("watch %s: %w", addr, err)
+	}
+	w := &watcher{addr: addr, latest: make(map[string]time.Time)}
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			id := event.Entity.GetId()
+			c, ok := event.Entity.GetComponents()[sentKey]
+			if !ok || !strings.HasPrefix(id, b.cfg.IDPrefix) || watch.Removed(event) {
+				continue
+			}
+			if id == b.probeID() {
+				w.mu.Lock()
+				w.ready = true
+				w.mu.Unlock()
+				continue
+			}
+			ts := &timestamppb.Timestamp{}
+			if c.UnmarshalTo(ts) != nil {
+				continue
+			}
+			sent := ts.AsTime()
+			w.lag.add(time.Since(sent))
+			w.mu.Lock()
+			w.events++
+			if sent.After(w.latest[id]) {
+				w.latest[id] = sent
+				w.updated = time.Now()
+			}
+			w.mu.Unlock()
+		}
+	}()
+	return w, nil
+}
+
+func (w *watcher) count() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.events
+}
+
+// holds reports whether w has seen every entity's final write.
+func (w *watcher) holds(prefix string, final []time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for i, sent := range final {
+		if sent.IsZero() {
+			continue // never written
+		}
+		if w.latest[fmt.Sprintf("%s%d", prefix, i)].Before(sent) {
+			return false
+		}
+	}
+	return true
+}
+
+// probe writes a marker entity, rewriting it until every watcher has seen
+// it, so measurement starts with all streams live. Marker events are not
+// counted.
+func (b *Bench) probe(ctx context.Context, client storev1.EntityStoreServiceClient, watchers []*watcher) error {
+	id := b.probeID()
+	entity, err := b.entity(id, time.Now())
+	if err != nil {
+		return err
+	}
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity}); err != nil {
+		return fmt.Errorf("create probe: %w", err)
+	}
+	defer client.DeleteEntity(context.WithoutCancel(ctx), &storev1.DeleteEntityRequest{Id: id}) //nolint:errcheck
+
+	deadline := time.Now().Add(b.cfg.Timeout)
+	for _, w := range watchers {
+		live := waitUntil(ctx, deadline, func() bool {
+			if w.isReady() {
+				return true
+			}
+			entity.HlcPhysical = uint64(time.Now().UnixNano())
+			client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity}) //nolint:errcheck
+			time.Sleep(20 * time.Millisecond)
+			return w.isReady()
+		})
+		if !live {
+			return fmt.Errorf("watch on %s did not see the probe entity within %v", w.addr, b.cfg.Timeout)
+		}
+	}
+	return nil
+}
+
+func (b *Bench) probeID() string { return b.cfg.IDPrefix + "probe" }
+
+func (w *watcher) isReady() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.ready
+}
+
+func (b *Bench) cleanup(ctx context.Context, client storev1.EntityStoreServiceClient) {
+	for i := range b.cfg.Entities {
+		client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: fmt.Sprintf("%s%d", b.cfg.IDPrefix, i)}) //nolint:errcheck
+	}
+}
+
+// waitUntil polls cond until it holds, ctx ends, or deadline passes.
+func waitUntil(ctx context.Context, deadline time.Time, cond func() bool) bool {
+	for {
+		if cond() {
+			return true
+		}
+		if ctx.Err() != nil || time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
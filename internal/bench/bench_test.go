@@ -0,0 +1,141 @@
+package bench
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/mesh"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+)
+
+func startTestServer(t *testing.T) (string, *store.Store, func()) {
+	t.Helper()
+
+	s := store.New()
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+
+	return lis.Addr().String(), s, func() { srv.Stop() }
+}
+
+func TestBench_Run(t *testing.T) {
+	addr, s, cleanup := startTestServer(t)
+	defer cleanup()
+
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	cfg.Entities = 20
+	cfg.Updates = 3
+	cfg.Workers = 4
+	cfg.Watchers = 2
+	cfg.Timeout = 5 * time.Second
+	cfg.Cleanup = false
+
+	report, err := New(cfg).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(report.Phases) != 2 || report.Phases[0].Ops != 20 || report.Phases[1].Ops != 60 {
+		t.Fatalf("unexpected phases %+v", report.Phases)
+	}
+	for _, p := range report.Phases {
+		if p.Errors != nil || p.Throughput <= 0 || p.Latency.P50 <= 0 || p.Latency.Max < p.Latency.P99 {
+			t.Fatalf("unexpected phase %+v", p)
+		}
+	}
+	if report.Watch.Events != 2*80 || report.Watch.Lag.P50 <= 0 {
+		t.Fatalf("expected every write seen by both watchers, got %+v", report.Watch)
+	}
+	if n := len(s.List(0)); n != 20 {
+		t.Fatalf("expected 20 entities without cleanup, got %d", n)
+	}
+
+	var buf bytes.Buffer
+	if err := report.Write(&buf); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	var back Report
+	if err := json.Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if back.Phases[1].Ops != 60 || back.Watch.Lag.P50 <= 0 {
+		t.Fatalf("report did not round-trip: %+v", back)
+	}
+}
+
+func TestBench_Convergence(t *testing.T) {
+	addr, _, cleanup := startTestServer(t)
+	defer cleanup()
+	peerAddr, peer, cleanupPeer := startTestServer(t)
+	defer cleanupPeer()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	relayCfg := mesh.DefaultConfig()
+	relayCfg.LocalAddr = addr
+	relayCfg.Peers = []string{peerAddr}
+	relayCfg.Heartbeat = 0         // its mesh-health entity would replicate too
+	go mesh.New(relayCfg).Run(ctx) //nolint:errcheck
+
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	cfg.Peers = []string{peerAddr}
+	cfg.Entities = 20
+	cfg.Updates = 0
+	cfg.Watchers = 1
+	cfg.Timeout = 5 * time.Second
+	cfg.Cleanup = false
+
+	report, err := New(cfg).Run(ctx)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(report.Convergence) != 1 || !report.Convergence[0].Converged || report.Convergence[0].Events != 20 {
+		t.Fatalf("expected the peer to converge, got %+v", report.Convergence)
+	}
+	if n := len(peer.List(0)); n != 20 {
+		t.Fatalf("expected 20 entities replicated, got %d", n)
+	}
+}
+
+func TestBench_Cleanup(t *testing.T) {
+	addr, s, cleanup := startTestServer(t)
+	defer cleanup()
+
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	cfg.Entities = 5
+	cfg.Updates = 0
+	cfg.Watchers = 1
+	report, err := New(cfg).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(report.Phases) != 1 || report.Watch.Events != 5 {
+		t.Fatalf("unexpected report %+v", report)
+	}
+	if n := len(s.List(0)); n != 0 {
+		t.Fatalf("expected cleanup to delete every entity, %d left", n)
+	}
+}
+
+func TestMillis_JSON(t *testing.T) {
+	data, err := json.Marshal(Millis(1500 * time.Microsecond))
+	if err != nil || string(data) != "1.500" {
+		t.Fatalf("got %s, %v", data, err)
+	}
+}
@@ -0,0 +1,139 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Report is the JSON result of one benchmark run, stable enough to diff
+// between runs for regression tracking.
+type Report struct {
+	Started     time.Time     `json:"started"`
+	Store       string        `json:"store"`
+	Peers       []string      `json:"peers,omitempty"`
+	Entities    int           `json:"entities"`
+	Workers     int           `json:"workers"`
+	Phases      []PhaseReport `json:"phases"`
+	Watch       WatchReport   `json:"watch"`
+	Convergence []PeerReport  `json:"convergence,omitempty"`
+	Elapsed     Millis        `json:"elapsed_ms"`
+}
+
+// PhaseReport summarises one write phase.
+type PhaseReport struct {
+	Name       string         `json:"name"`
+	Ops        int            `json:"ops"`
+	Errors     map[string]int `json:"errors,omitempty"` // by gRPC status code
+	Elapsed    Millis         `json:"elapsed_ms"`
+	Throughput float64        `json:"throughput"` // successful ops/s
+	Latency    Latency        `json:"latency_ms"`
+}
+
+// WatchReport summarises fan-out lag on the target store: time from a write
+// being sent to each watcher receiving its event.
+type WatchReport struct {
+	Watchers int     `json:"watchers"`
+	Events   int     `json:"events"`
+	Lag      Latency `json:"lag_ms"`
+}
+
+// PeerReport summarises replication to one mesh peer.
+type PeerReport struct {
+	Store     string  `json:"store"`
+	Converged bool    `json:"converged"`
+	Time      Millis  `json:"time_ms"` // from the last write to the peer holding every final version
+	Events    int     `json:"events"`
+	Lag       Latency `json:"lag_ms"` // per event, from write sent to peer event
+}
+
+// Latency holds percentiles of a sample set.
+type Latency struct {
+	P50  Millis `json:"p50"`
+	P95  Millis `json:"p95"`
+	P99  Millis `json:"p99"`
+	Max  Millis `json:"max"`
+	Mean Millis `json:"mean"`
+}
+
+// Millis is a duration encoded as fractional milliseconds.
+type Millis time.Duration
+
+func (m Millis) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf("%.3f", float64(m)/float64(time.Millisecond))), nil
+}
+
+func (m *Millis) UnmarshalJSON(b []byte) error {
+	var f float64
+	if err := json.Unmarshal(b, &f); err != nil {
+		return err
+	}
+	*m = Millis(f * float64(time.Millisecond))
+	return nil
+}
+
+// Write encodes r as indented JSON.
+func (r Report) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// samples collects durations from concurrent goroutines.
+type samples struct {
+	mu sync.Mutex
+	d  []time.Duration
+}
+
+func (s *samples) add(d time.Duration) {
+	s.mu.Lock()
+	s.d = append(s.d, d)
+	s.mu.Unlock()
+}
+
+func (s *samples) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.d)
+}
+
+func (s *samples) reset() {
+	s.mu.Lock()
+	s.d = nil
+	s.mu.Unlock()
+}
+
+// merge appends o's samples to s.
+func (s *samples) merge(o *samples) {
+	o.mu.Lock()
+	d := slices.Clone(o.d)
+	o.mu.Unlock()
+	s.mu.Lock()
+	s.d = append(s.d, d...)
+	s.mu.Unlock()
+}
+
+func (s *samples) latency() Latency {
+	s.mu.Lock()
+	sorted := slices.Clone(s.d)
+	s.mu.Unlock()
+	if len(sorted) == 0 {
+		return Latency{}
+	}
+	slices.Sort(sorted)
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+	at := func(q float64) Millis { return Millis(sorted[int(q*float64(len(sorted)-1))]) }
+	return Latency{
+		P50:  at(0.50),
+		P95:  at(0.95),
+		P99:  at(0.99),
+		Max:  Millis(sorted[len(sorted)-1]),
+		Mean: Millis(sum / time.Duration(len(sorted))),
+	}
+}
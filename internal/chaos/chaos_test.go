@@ -0,0 +1,223 @@
+package chaos
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+)
+
+func TestListener_PartitionAndLatency(t *testing.T) {
+	lis, err := Listen("localhost:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				buf := make([]byte, 1)
+				for {
+					if _, err := conn.Read(buf); err != nil {
+						return
+					}
+					conn.Write(buf) //nolint:errcheck
+				}
+			}()
+		}
+	}()
+
+	echo := func() (time.Duration, error) {
+		conn, err := net.Dial("tcp", lis.Addr().String())
+		if err != nil {
+			return 0, err
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(time.Second)) //nolint:errcheck
+		start := time.Now()
+		if _, err := conn.Write([]byte{1}); err != nil {
+			return 0, err
+		}
+		_, err = conn.Read(make([]byte, 1))
+		return time.Since(start), err
+	}
+
+	if _, err := echo(); err != nil {
+		t.Fatalf("echo: %v", err)
+	}
+	lis.SetLatency(100 * time.Millisecond)
+	if d, err := echo(); err != nil || d < 100*time.Millisecond {
+		t.Fatalf("expected a delayed echo, got %v, %v", d, err)
+	}
+	lis.SetLatency(0)
+
+	lis.Partition()
+	if _, err := echo(); err == nil {
+		t.Fatal("expected connections refused while partitioned")
+	}
+	lis.Heal()
+	if _, err := echo(); err != nil {
+		t.Fatalf("echo after heal: %v", err)
+	}
+}
+
+func TestCluster_PartitionedNodeKeepsLocalWrites(t *testing.T) {
+	c, err := Start(2)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer c.Close()
+
+	c.Partition(1)
+	if err := c.Create(1, &entityv1.Entity{Id: "island", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}); err != nil {
+		t.Fatalf("Create on partitioned node: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if _, err := c.Get(0, "island"); err == nil {
+		t.Fatal("partition breach: node-0 has node-1's write before heal")
+	}
+
+	if err := c.Heal(1); err != nil {
+		t.Fatalf("Heal: %v", err)
+	}
+	if err := c.Update(1, &entityv1.Entity{Id: "island", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.WaitConverged(ctx, "island"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCluster_CrashLosesState(t *testing.T) {
+	c, err := Start(2)
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer c.Close()
+
+	if err := c.Create(1, &entityv1.Entity{Id: "doomed"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := c.Crash(1); err != nil {
+		t.Fatalf("Crash: %v", err)
+	}
+	if _, err := c.Get(1, "doomed"); err == nil || !strings.Contains(err.Error(), "down") {
+		t.Fatalf("expected node-1 down, got %v", err)
+	}
+	if err := c.Crash(1); err == nil {
+		t.Fatal("expected error crashing a crashed node")
+	}
+	addr, old := c.Nodes[1].Addr, c.Nodes[1].Store
+	if err := c.Restart(1); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	if c.Nodes[1].Addr != addr {
+		t.Fatalf("expected restart on %s, got %s", addr, c.Nodes[1].Addr)
+	}
+	if c.Nodes[1].Store == old {
+		t.Fatal("expected a fresh store after restart")
+	}
+	if err := c.Create(1, &entityv1.Entity{Id: "reborn"}); err != nil {
+		t.Fatalf("Create after restart: %v", err)
+	}
+}
+
+func TestCluster_WALSurvivesCrash(t *testing.T) {
+	c, err := Start(2, WithWAL(t.TempDir()))
+	if err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer c.Close()
+
+	c.Partition(1) // so only the log can bring it back
+	if err := c.Create(1, &entityv1.Entity{Id: "kept"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := c.Crash(1); err != nil {
+		t.Fatalf("Crash: %v", err)
+	}
+	if err := c.Restart(1); err != nil {
+		t.Fatalf("Restart: %v", err)
+	}
+	if _, err := c.Get(1, "kept"); err != nil {
+		t.Fatalf("expected acknowledged write recovered: %v", err)
+	}
+}
+
+func TestParsePlan_Invalid(t *testing.T) {
+	for name, doc := range map[string]string{
+		"no steps":     "nodes: 3",
+		"one node":     "nodes: 1\nsteps: [{op: sleep, duration: 1s}]",
+		"unknown op":   "steps: [{op: explode, node: 0}]",
+		"node range":   "steps: [{op: partition, node: 3}]",
+		"nodes range":  "steps: [{op: wait, nodes: [0, 5], entity: a}]",
+		"no entity":    "steps: [{op: create, node: 0}]",
+		"threat":       "steps: [{op: update, node: 0, entity: a, threat: severe}]",
+		"sleep":        "steps: [{op: sleep}]",
+		"latency":      "steps: [{op: latency, node: 0, duration: -1s}]",
+		"impair loss":  "steps: [{op: impair, node: 0, loss: 2}]",
+		"impair jit":   "steps: [{op: impair, node: 0, jitter: -1s}]",
+		"absent+label": "steps: [{op: expect, entity: a, absent: true, label: x}]",
+	} {
+		if _, err := ParsePlan([]byte(doc)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+
+	p, err := ParsePlan([]byte("steps: [{op: create, node: 2, entity: t, count: 2, threat: high}]"))
+	if err != nil {
+		t.Fatalf("ParsePlan: %v", err)
+	}
+	if p.Nodes != 3 {
+		t.Fatalf("expected default 3 nodes, got %d", p.Nodes)
+	}
+	if ids := p.Steps[0].ids(); len(ids) != 2 || ids[1] != "t-1" {
+		t.Fatalf("unexpected ids %v", ids)
+	}
+}
+
+func TestExecute_FailingExpectation(t *testing.T) {
+	p, err := ParsePlan([]byte(`
+nodes: 2
+steps:
+  - {op: create, node: 0, entity: a, threat: low}
+  - {op: wait, entity: a, timeout: 5s}
+  - {op: expect, entity: a, threat: high}
+`))
+	if err != nil {
+		t.Fatalf("ParsePlan: %v", err)
+	}
+	err = Run(context.Background(), p)
+	if err == nil || !strings.Contains(err.Error(), "step 3 (expect)") || !strings.Contains(err.Error(), "expected threat high, got low") {
+		t.Fatalf("expected step 3 to fail on threat, got %v", err)
+	}
+}
+
+// TestPlans runs every plan in deploy/chaos.
+func TestPlans(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "deploy", "chaos", "*.yaml"))
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no plans found: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			p, err := LoadPlan(path)
+			if err != nil {
+				t.Fatalf("LoadPlan: %v", err)
+			}
+			if err := Run(context.Background(), p); err != nil {
+				t.Fatalf("%s: %v", p.Name, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,444 @@
+// Package chaos runs multi-node entity-store clusters on localhost and
+// injects faults into them — partitions, latency, impaired links, clock
+// skew, and node crashes — for Jepsen-style tests of mesh replication. Faults are driven
+// from Go through a Cluster, or scheduled by a Plan loaded from YAML.
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/divergence"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/mesh"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+)
+
+const (
+	opTimeout    = 2 * time.Second        // per read or write
+	pollInterval = 100 * time.Millisecond // between waiter checks
+	// settle is how long relays get to establish their watch streams
+	// after starting; the relay has no readiness signal.
+	settle = 200 * time.Millisecond
+	// antiEntropy is the relays' reconcile interval, short so healed
+	// partitions converge within a test's wait.
+	antiEntropy = 250 * time.Millisecond
+)
+
+// Node is one store in a cluster with its relay.
+type Node struct {
+	ID       string
+	Addr     string
+	Store    *store.Store // replaced on Restart
+	WAL      string       // write-ahead log path; empty for an in-memory store
+	Clock    *hlc.Clock   // survives restarts, so skew does too
+	Listener *Listener
+
+	server      *grpc.Server
+	conn        *grpc.ClientConn
+	client      storev1.EntityStoreServiceClient
+	relayCancel context.CancelFunc
+	relayDone   chan struct{}
+	down        bool
+	impairments map[string]mesh.Impairment // of its relay's links, by peer address
+}
+
+// Cluster is a full mesh of n nodes, each relaying to every other. Node
+// methods take the node's index in Nodes. A Cluster is not safe for
+// concurrent fault injection.
+type Cluster struct {
+	Nodes []*Node
+
+	walDir string
+}
+
+// Option configures a Cluster.
+type Option func(*Cluster)
+
+// WithWAL backs each node's store with a write-ahead log in dir, so a
+// crashed node restarts with every write it acknowledged.
+func WithWAL(dir string) Option {
+	return func(c *Cluster) { c.walDir = dir }
+}
+
+// Start brings up an n-node cluster on localhost and waits for the relays
+// to connect.
+func Start(n int, opts ...Option) (*Cluster, error) {
+	if n < 2 {
+		return nil, fmt.Errorf("a cluster needs at least 2 nodes, got %d", n)
+	}
+	c := &Cluster{}
+	for _, opt := range opts {
+		opt(c)
+	}
+	for i := range n {
+		id := fmt.Sprintf("node-%d", i)
+		nd := &Node{ID: id, Clock: hlc.NewClock(id)}
+		if c.walDir != "" {
+			nd.WAL = filepath.Join(c.walDir, id+".wal")
+		}
+		if err := nd.start("localhost:0"); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("start %s: %w", id, err)
+		}
+		c.Nodes = append(c.Nodes, nd)
+	}
+	c.RestartRelays()
+	return c, nil
+}
+
+// historyDepth is the versions each node's store keeps per entity, so a
+// failed convergence can be traced through Node.Store.History.
+const historyDepth = 32
+
+// start serves a store on addr and dials it. The store is fresh, or
+// recovered from the node's log if it has one.
+func (n *Node) start(addr string) error {
+	opts := []store.Option{store.WithClock(n.Clock), store.WithHistory(historyDepth)}
+	s := store.New(opts...)
+	if n.WAL != "" {
+		var err error
+		if s, err = store.Open(n.WAL, opts...); err != nil {
+			return err
+		}
+	}
+	lis, err := Listen(addr)
+	if err != nil {
+		s.Close()
+		return err
+	}
+	n.Listener, n.Addr, n.Store = lis, lis.Addr().String(), s
+	srv := server.New(n.Store)
+	n.server = grpc.NewServer(grpc.UnaryInterceptor(srv.UnaryInterceptor()), grpc.StreamInterceptor(srv.StreamInterceptor()))
+	storev1.RegisterEntityStoreServiceServer(n.server, srv)
+	go n.server.Serve(lis) //nolint:errcheck
+	n.down = false
+	return n.redial()
+}
+
+// redial replaces the node's client connection, so callers need not wait
+// out gRPC's reconnect backoff after a partition or crash. The connection
+// carries the node's own clock, so a skewed node's HLC does not reach the
+// others through the test's calls, and does not retry, so a call to a
+// crashed node fails at once.
+func (n *Node) redial() error {
+	if n.conn != nil {
+		n.conn.Close()
+	}
+	conn, err := client.Dial(n.Addr, client.WithClock(n.Clock), client.WithoutRetries())
+	if err != nil {
+		return err
+	}
+	n.conn, n.client = conn, storev1.NewEntityStoreServiceClient(conn)
+	return nil
+}
+
+func (n *Node) stopRelay() {
+	if n.relayCancel != nil {
+		n.relayCancel()
+		<-n.relayDone
+		n.relayCancel = nil
+	}
+}
+
+// Close stops every node.
+func (c *Cluster) Close() {
+	for _, nd := range c.Nodes {
+		nd.stopRelay()
+		if !nd.down {
+			nd.server.Stop()
+			nd.conn.Close()
+			nd.Store.Close()
+			nd.down = true
+		}
+	}
+}
+
+// RestartRelays replaces every running node's relay and waits for the new
+// ones to connect. Relays reconnect to peers and resume their watches on
+// their own after faults; this starts them afresh.
+func (c *Cluster) RestartRelays() {
+	for _, nd := range c.Nodes {
+		nd.stopRelay()
+		if !nd.down {
+			c.startRelay(nd)
+		}
+	}
+	time.Sleep(settle)
+}
+
+// startRelay runs a relay from nd to every other node.
+func (c *Cluster) startRelay(nd *Node) {
+	var peers []string
+	for _, other := range c.Nodes {
+		if other != nd {
+			peers = append(peers, other.Addr)
+		}
+	}
+	relay := mesh.New(mesh.Config{LocalAddr: nd.Addr, Peers: peers, NodeID: nd.ID, AntiEntropy: antiEntropy, Impairments: nd.impairments})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	nd.relayCancel, nd.relayDone = cancel, done
+	go func() {
+		defer close(done)
+		relay.Run(ctx) //nolint:errcheck
+	}()
+}
+
+// Partition cuts node i off: its connections close and new ones are
+// refused until Heal.
+func (c *Cluster) Partition(i int) {
+	c.Nodes[i].Listener.Partition()
+}
+
+// Heal reconnects node i. The relays find their way back by themselves.
+func (c *Cluster) Heal(i int) error {
+	nd := c.Nodes[i]
+	nd.Listener.Heal()
+	return nd.redial()
+}
+
+// SetLatency delays everything node i sends by d.
+func (c *Cluster) SetLatency(i int, d time.Duration) {
+	c.Nodes[i].Listener.SetLatency(d)
+}
+
+// Impair degrades the links from node i's relay to the peers, every
+// other node if none are given, as imp describes; the zero Impairment
+// restores them. Unlike SetLatency it acts on one direction of each link,
+// so a mesh can mix a satcom link with LAN ones. Node i's relay is
+// restarted to take it up.
+func (c *Cluster) Impair(i int, imp mesh.Impairment, peers ...int) {
+	nd := c.Nodes[i]
+	if nd.impairments == nil {
+		nd.impairments = make(map[string]mesh.Impairment)
+	}
+	for j, other := range c.Nodes {
+		if j == i || len(peers) > 0 && !slices.Contains(peers, j) {
+			continue
+		}
+		if imp == (mesh.Impairment{}) {
+			delete(nd.impairments, other.Addr)
+		} else {
+			nd.impairments[other.Addr] = imp
+		}
+	}
+	if !nd.down {
+		nd.stopRelay()
+		c.startRelay(nd)
+		time.Sleep(settle)
+	}
+}
+
+// SetSkew sets node i's HLC wall clock d ahead of real time (behind if
+// negative), so its writes win or lose last-writer-wins merges unfairly.
+func (c *Cluster) SetSkew(i int, d time.Duration) {
+	c.Nodes[i].Clock.SetOffset(d)
+}
+
+// Crash stops node i abruptly. Its in-memory store is lost; its log, if
+// any, is closed without further writes, as a killed process leaves it.
+func (c *Cluster) Crash(i int) error {
+	nd := c.Nodes[i]
+	if nd.down {
+		return fmt.Errorf("%s is already down", nd.ID)
+	}
+	nd.stopRelay()
+	nd.server.Stop()
+	nd.conn.Close()
+	nd.Store.Close()
+	nd.down = true
+	return nil
+}
+
+// Restart brings crashed node i back on its old address with a new relay;
+// the other nodes' relays reconnect to it by themselves. The store is
+// empty, or replayed from its log under WithWAL.
+func (c *Cluster) Restart(i int) error {
+	nd := c.Nodes[i]
+	if !nd.down {
+		return fmt.Errorf("%s is not down", nd.ID)
+	}
+	if err := nd.start(nd.Addr); err != nil {
+		return fmt.Errorf("restart %s: %w", nd.ID, err)
+	}
+	c.startRelay(nd)
+	time.Sleep(settle)
+	return nil
+}
+
+// node returns node i, or an error if it is down.
+func (c *Cluster) node(i int) (*Node, error) {
+	nd := c.Nodes[i]
+	if nd.down {
+		return nil, fmt.Errorf("%s is down", nd.ID)
+	}
+	return nd, nil
+}
+
+// Create writes e to node i. Reads and writes go through the node's gRPC
+// API, or straight to its store while it is partitioned, as a client on
+// its side of the partition would see it.
+func (c *Cluster) Create(i int, e *entityv1.Entity) error {
+	nd, err := c.node(i)
+	if err != nil {
+		return err
+	}
+	if nd.Listener.Partitioned() {
+		_, err = nd.Store.Create(e)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	_, err = nd.client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: e})
+	return err
+}
+
+// Update merges e into the entity on node i.
+func (c *Cluster) Update(i int, e *entityv1.Entity) error {
+	nd, err := c.node(i)
+	if err != nil {
+		return err
+	}
+	if nd.Listener.Partitioned() {
+		_, err = nd.Store.Update(e)
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	_, err = nd.client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: e})
+	return err
+}
+
+// Delete removes an entity from node i.
+func (c *Cluster) Delete(i int, id string) error {
+	nd, err := c.node(i)
+	if err != nil {
+		return err
+	}
+	if nd.Listener.Partitioned() {
+		return nd.Store.Delete(id)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	_, err = nd.client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: id})
+	return err
+}
+
+// Get reads an entity from node i.
+func (c *Cluster) Get(i int, id string) (*entityv1.Entity, error) {
+	nd, err := c.node(i)
+	if err != nil {
+		return nil, err
+	}
+	if nd.Listener.Partitioned() {
+		return nd.Store.Get(id)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	return nd.client.GetEntity(ctx, &storev1.GetEntityRequest{Id: id})
+}
+
+// List reads every entity on node i.
+func (c *Cluster) List(i int) ([]*entityv1.Entity, error) {
+	nd, err := c.node(i)
+	if err != nil {
+		return nil, err
+	}
+	if nd.Listener.Partitioned() {
+		return nd.Store.List(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED), nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), opTimeout)
+	defer cancel()
+	resp, err := nd.client.ListEntities(ctx, &storev1.ListEntitiesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Entities, nil
+}
+
+// poll calls cond until it reports true or ctx is done.
+func poll(ctx context.Context, cond func() bool) error {
+	for {
+		if cond() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// WaitForEntity waits until node i holds the entity.
+func (c *Cluster) WaitForEntity(ctx context.Context, i int, id string) error {
+	err := poll(ctx, func() bool {
+		_, err := c.Get(i, id)
+		return err == nil
+	})
+	if err != nil {
+		return fmt.Errorf("entity %s did not appear on %s: %w", id, c.Nodes[i].ID, err)
+	}
+	return nil
+}
+
+// WaitForCount waits until node i holds at least count entities.
+func (c *Cluster) WaitForCount(ctx context.Context, i, count int) error {
+	err := poll(ctx, func() bool {
+		entities, err := c.List(i)
+		return err == nil && len(entities) >= count
+	})
+	if err != nil {
+		return fmt.Errorf("%s did not reach %d entities: %w", c.Nodes[i].ID, count, err)
+	}
+	return nil
+}
+
+// WaitConverged waits until every running node holds the given entities
+// and they agree on them, as divergence.Compare judges it. Without ids it
+// waits until the nodes agree on everything.
+func (c *Cluster) WaitConverged(ctx context.Context, ids ...string) error {
+	var last []divergence.Divergence
+	err := poll(ctx, func() bool {
+		snapshot := make(map[string][]*entityv1.Entity)
+		for i, nd := range c.Nodes {
+			if nd.down {
+				continue
+			}
+			entities, err := c.List(i)
+			if err != nil {
+				return false
+			}
+			if len(ids) > 0 {
+				entities = slices.DeleteFunc(entities, func(e *entityv1.Entity) bool { return !slices.Contains(ids, e.Id) })
+				if len(entities) != len(ids) {
+					return false
+				}
+			}
+			snapshot[nd.ID] = entities
+		}
+		last = divergence.Compare(snapshot)
+		return len(last) == 0
+	})
+	if err != nil {
+		var diverged []string
+		for _, d := range last {
+			diverged = append(diverged, fmt.Sprintf("%s %v", d.ID, d.Reasons))
+		}
+		if len(diverged) == 0 {
+			return fmt.Errorf("nodes did not converge: %w", err)
+		}
+		return fmt.Errorf("nodes did not converge (%s): %w", strings.Join(diverged, ", "), err)
+	}
+	return nil
+}
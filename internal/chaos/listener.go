@@ -0,0 +1,100 @@
+package chaos
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// Listener wraps a net.Listener so a node can be cut off from the network
+// or slowed down. Every connection to a node, including its own relay's
+// watch of the local store, goes through its Listener.
+type Listener struct {
+	net.Listener
+
+	mu          sync.RWMutex
+	partitioned bool
+	latency     time.Duration
+	conns       []net.Conn
+}
+
+// Listen listens on addr.
+func Listen(addr string) (*Listener, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Listener{Listener: lis}, nil
+}
+
+// Accept returns the next connection, refusing any made while partitioned.
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+		l.mu.Lock()
+		if l.partitioned {
+			l.mu.Unlock()
+			conn.Close() // refuse connection during partition
+			continue
+		}
+		l.conns = append(l.conns, conn)
+		l.mu.Unlock()
+		return &slowConn{Conn: conn, lis: l}, nil
+	}
+}
+
+// Partition isolates the node by closing its connections and refusing new
+// ones until Heal.
+func (l *Listener) Partition() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.partitioned = true
+	for _, c := range l.conns {
+		c.Close()
+	}
+	l.conns = nil
+}
+
+// Heal accepts connections again.
+func (l *Listener) Heal() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.partitioned = false
+}
+
+// Partitioned reports whether the node is cut off.
+func (l *Listener) Partitioned() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.partitioned
+}
+
+// SetLatency delays everything the node sends by d; 0 removes the delay.
+// It applies to existing connections as well as new ones.
+func (l *Listener) SetLatency(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.latency = d
+}
+
+func (l *Listener) delay() time.Duration {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.latency
+}
+
+// slowConn delays each write by the listener's current latency.
+type slowConn struct {
+	net.Conn
+	lis *Listener
+}
+
+func (c *slowConn) Write(b []byte) (int, error) {
+	if d := c.lis.delay(); d > 0 {
+		time.Sleep(d)
+	}
+	return c.Conn.Write(b)
+}
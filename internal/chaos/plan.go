@@ -0,0 +1,358 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"github.com/boshu2/lattice-lab/internal/mesh"
+	"go.yaml.in/yaml/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Step operations.
+const (
+	OpPartition = "partition" // cut node off until heal
+	OpHeal      = "heal"      // reconnect node, restart relays
+	OpLatency   = "latency"   // delay node's traffic by duration (0 removes)
+	OpImpair    = "impair"    // degrade node's relay links to nodes: latency (duration), jitter, loss, bandwidth
+	OpSkew      = "skew"      // set node's clock duration ahead (negative: behind)
+	OpCrash     = "crash"     // stop node, losing its store unless wal
+	OpRestart   = "restart"   // bring a crashed node back, empty or replayed
+	OpCreate    = "create"    // create entity on node
+	OpUpdate    = "update"    // update entity on node
+	OpDelete    = "delete"    // delete entity on node
+	OpSleep     = "sleep"     // pause for duration
+	OpWait      = "wait"      // wait until nodes hold entity
+	OpConverge  = "converge"  // wait until running nodes agree
+	OpExpect    = "expect"    // check entity on nodes now
+)
+
+var (
+	nodeOps   = []string{OpPartition, OpHeal, OpLatency, OpImpair, OpSkew, OpCrash, OpRestart, OpCreate, OpUpdate, OpDelete}
+	entityOps = []string{OpCreate, OpUpdate, OpDelete, OpWait, OpExpect}
+)
+
+// defaultTimeout bounds wait and converge steps without a timeout.
+const defaultTimeout = 10 * time.Second
+
+// Plan is a fault scenario: a cluster size and the steps to run on it.
+type Plan struct {
+	Name  string `yaml:"name"`
+	Nodes int    `yaml:"nodes"` // default 3
+	WAL   bool   `yaml:"wal"`   // back stores with write-ahead logs, so crashes keep writes
+	Steps []Step `yaml:"steps"`
+}
+
+// Step is one fault, write, or check. Steps run in order; At, when set,
+// also holds a step back until that long after the plan started.
+type Step struct {
+	At       time.Duration `yaml:"at"`
+	Op       string        `yaml:"op"`
+	Node     int           `yaml:"node"`     // faults and writes
+	Nodes    []int         `yaml:"nodes"`    // wait, expect; default every running node; impair: peers, default every other node
+	Entity   string        `yaml:"entity"`   // entity ID
+	Count    int           `yaml:"count"`    // act on <entity>-0 … <entity>-<count-1> instead
+	Entities []string      `yaml:"entities"` // converge; default everything
+	Threat   string        `yaml:"threat"`   // create, update, expect: none, low, medium, high
+	Label    string        `yaml:"label"`    // create, update, expect: classification label
+	Absent   bool          `yaml:"absent"`   // expect: the entity must not exist
+	Duration time.Duration `yaml:"duration"` // latency, impair (its latency), skew, sleep
+	Timeout  time.Duration `yaml:"timeout"`  // wait, converge; default 10s
+
+	// impair: the rest of the links' impairment; all zero with no
+	// duration restores them.
+	Jitter    time.Duration `yaml:"jitter"`
+	Loss      float64       `yaml:"loss"`      // chance a call is lost, 0 to 1
+	Bandwidth float64       `yaml:"bandwidth"` // bytes per second
+}
+
+// LoadPlan reads and validates a plan file.
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read plan: %w", err)
+	}
+	return ParsePlan(data)
+}
+
+// ParsePlan decodes and validates plan YAML.
+func ParsePlan(data []byte) (*Plan, error) {
+	p := Plan{Nodes: 3}
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("parse plan: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks every step is well formed and names a node in the
+// cluster.
+func (p *Plan) Validate() error {
+	if p.Nodes < 2 {
+		return fmt.Errorf("nodes must be at least 2")
+	}
+	if len(p.Steps) == 0 {
+		return fmt.Errorf("plan has no steps")
+	}
+	for i, s := range p.Steps {
+		if err := s.validate(p.Nodes); err != nil {
+			return fmt.Errorf("step %d (%s): %w", i+1, s.Op, err)
+		}
+	}
+	return nil
+}
+
+func (s Step) validate(nodes int) error {
+	known := []string{OpPartition, OpHeal, OpLatency, OpImpair, OpSkew, OpCrash, OpRestart,
+		OpCreate, OpUpdate, OpDelete, OpSleep, OpWait, OpConverge, OpExpect}
+	if !slices.Contains(known, s.Op) {
+		return fmt.Errorf("unknown op (want %s)", strings.Join(known, ", "))
+	}
+	if slices.Contains(nodeOps, s.Op) && (s.Node < 0 || s.Node >= nodes) {
+		return fmt.Errorf("node %d out of range 0-%d", s.Node, nodes-1)
+	}
+	for _, n := range s.Nodes {
+		if n < 0 || n >= nodes {
+			return fmt.Errorf("node %d out of range 0-%d", n, nodes-1)
+		}
+	}
+	if slices.Contains(entityOps, s.Op) && s.Entity == "" {
+		return fmt.Errorf("entity is required")
+	}
+	if _, err := parseThreat(s.Threat); err != nil {
+		return err
+	}
+	switch {
+	case s.At < 0 || s.Timeout < 0 || s.Count < 0:
+		return fmt.Errorf("at, timeout, and count must not be negative")
+	case s.Op == OpSleep && s.Duration <= 0:
+		return fmt.Errorf("sleep requires a positive duration")
+	case s.Op == OpLatency && s.Duration < 0:
+		return fmt.Errorf("latency must not be negative")
+	case s.Op == OpImpair && (s.Duration < 0 || s.Jitter < 0 || s.Bandwidth < 0):
+		return fmt.Errorf("impair duration, jitter, and bandwidth must not be negative")
+	case s.Loss < 0 || s.Loss > 1:
+		return fmt.Errorf("loss must be from 0 to 1")
+	case s.Absent && (s.Threat != "" || s.Label != ""):
+		return fmt.Errorf("absent excludes threat and label")
+	}
+	return nil
+}
+
+// parseThreat maps "high" to THREAT_LEVEL_HIGH; "" is UNSPECIFIED.
+func parseThreat(s string) (entityv1.ThreatLevel, error) {
+	if s == "" {
+		return entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED, nil
+	}
+	v, ok := entityv1.ThreatLevel_value["THREAT_LEVEL_"+strings.ToUpper(s)]
+	if !ok || v == 0 {
+		return 0, fmt.Errorf("unknown threat %q (want none, low, medium, or high)", s)
+	}
+	return entityv1.ThreatLevel(v), nil
+}
+
+// ids returns the entity IDs the step acts on.
+func (s Step) ids() []string {
+	if s.Count == 0 {
+		return []string{s.Entity}
+	}
+	ids := make([]string, s.Count)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("%s-%d", s.Entity, i)
+	}
+	return ids
+}
+
+// entity builds the TRACK a create or update step writes.
+func (s Step) entity(id string) (*entityv1.Entity, error) {
+	e := &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{}}
+	if s.Threat != "" {
+		level, _ := parseThreat(s.Threat)
+		a, err := anypb.New(&entityv1.ThreatComponent{Level: level})
+		if err != nil {
+			return nil, err
+		}
+		e.Components["threat"] = a
+	}
+	if s.Label != "" {
+		a, err := anypb.New(&entityv1.ClassificationComponent{Label: s.Label})
+		if err != nil {
+			return nil, err
+		}
+		e.Components["classification"] = a
+	}
+	return e, nil
+}
+
+// Run starts a cluster for p, runs its steps, and stops the cluster. Logs
+// for a WAL plan go in a temporary directory removed afterwards.
+func Run(ctx context.Context, p *Plan) error {
+	var opts []Option
+	if p.WAL {
+		dir, err := os.MkdirTemp("", "chaos-wal-")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+		opts = append(opts, WithWAL(dir))
+	}
+	c, err := Start(p.Nodes, opts...)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return c.Execute(ctx, p)
+}
+
+// Execute runs p's steps on c in order, stopping at the first failure.
+func (c *Cluster) Execute(ctx context.Context, p *Plan) error {
+	start := time.Now()
+	for i, s := range p.Steps {
+		if s.At > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Until(start.Add(s.At))):
+			}
+		}
+		if err := c.Do(ctx, s); err != nil {
+			return fmt.Errorf("step %d (%s): %w", i+1, s.Op, err)
+		}
+	}
+	return nil
+}
+
+// Do runs one step, ignoring At.
+func (c *Cluster) Do(ctx context.Context, s Step) error {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	switch s.Op {
+	case OpPartition:
+		c.Partition(s.Node)
+	case OpHeal:
+		return c.Heal(s.Node)
+	case OpLatency:
+		c.SetLatency(s.Node, s.Duration)
+	case OpImpair:
+		imp := mesh.Impairment{Latency: s.Duration, Jitter: s.Jitter, Loss: s.Loss, BandwidthBPS: s.Bandwidth}
+		c.Impair(s.Node, imp, s.Nodes...)
+	case OpSkew:
+		c.SetSkew(s.Node, s.Duration)
+	case OpCrash:
+		return c.Crash(s.Node)
+	case OpRestart:
+		return c.Restart(s.Node)
+	case OpCreate, OpUpdate:
+		for _, id := range s.ids() {
+			e, err := s.entity(id)
+			if err != nil {
+				return err
+			}
+			write := c.Create
+			if s.Op == OpUpdate {
+				write = c.Update
+			}
+			if err := write(s.Node, e); err != nil {
+				return fmt.Errorf("%s %s on %s: %w", s.Op, id, c.Nodes[s.Node].ID, err)
+			}
+		}
+	case OpDelete:
+		for _, id := range s.ids() {
+			if err := c.Delete(s.Node, id); err != nil {
+				return fmt.Errorf("delete %s on %s: %w", id, c.Nodes[s.Node].ID, err)
+			}
+		}
+	case OpSleep:
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(s.Duration):
+		}
+	case OpWait:
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		for _, n := range c.targets(s) {
+			for _, id := range s.ids() {
+				if err := c.WaitForEntity(ctx, n, id); err != nil {
+					return err
+				}
+			}
+		}
+	case OpConverge:
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return c.WaitConverged(ctx, s.Entities...)
+	case OpExpect:
+		for _, n := range c.targets(s) {
+			for _, id := range s.ids() {
+				if err := c.expect(n, id, s); err != nil {
+					return fmt.Errorf("%s on %s: %w", id, c.Nodes[n].ID, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// targets returns the step's nodes, or every running node.
+func (c *Cluster) targets(s Step) []int {
+	if len(s.Nodes) > 0 {
+		return s.Nodes
+	}
+	var out []int
+	for i, nd := range c.Nodes {
+		if !nd.down {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// expect checks an entity on node n against the step's expectations.
+func (c *Cluster) expect(n int, id string, s Step) error {
+	e, err := c.Get(n, id)
+	if s.Absent {
+		if err == nil {
+			return fmt.Errorf("expected absent, found it")
+		}
+		if c.Nodes[n].Listener.Partitioned() || status.Code(err) == codes.NotFound {
+			return nil
+		}
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	if s.Threat != "" {
+		want, _ := parseThreat(s.Threat)
+		got := &entityv1.ThreatComponent{}
+		if a, ok := e.Components["threat"]; ok {
+			a.UnmarshalTo(got) //nolint:errcheck
+		}
+		if got.Level != want {
+			return fmt.Errorf("expected threat %s, got %s", s.Threat, strings.ToLower(strings.TrimPrefix(got.Level.String(), "THREAT_LEVEL_")))
+		}
+	}
+	if s.Label != "" {
+		got := &entityv1.ClassificationComponent{}
+		if a, ok := e.Components["classification"]; ok {
+			a.UnmarshalTo(got) //nolint:errcheck
+		}
+		if got.Label != s.Label {
+			return fmt.Errorf("expected label %q, got %q", s.Label, got.Label)
+		}
+	}
+	return nil
+}
@@ -4,17 +4,33 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"github.com/boshu2/lattice-lab/internal/watch"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
+var (
+	classified = metrics.NewCounter("lattice_classifier_classified_total",
+		"Tracks whose classification or threat the classifier wrote, by label.", "label")
+	unchanged = metrics.NewCounter("lattice_classifier_unchanged_total",
+		"Track events that left the classification as it was, its own updates included.")
+	failed = metrics.NewCounter("lattice_classifier_failed_total",
+		"Track events the classifier could not classify or write.")
+	classifySeconds = metrics.NewHistogram("lattice_classifier_classify_seconds",
+		"Time to classify one track event, the store write included.", nil)
+)
+
 // Config controls the classifier service.
 type Config struct {
 	StoreAddr string
+	Health    *health.Probe // optional; ready while watching, wedged if classifying one track hangs
 }
 
 // DefaultConfig returns classifier defaults.
@@ -53,6 +69,41 @@ func Classify(speedKnots float64) Classification {
 	}
 }
 
+// ClassifySurface returns a classification for a surface vessel based on
+// speed (in knots). Few vessels exceed 30 knots; those that do are worth a
+// look but never trigger an intercept on speed alone.
+func ClassifySurface(speedKnots float64) Classification {
+	switch {
+	case speedKnots < 30:
+		return Classification{
+			Label:      "vessel",
+			Confidence: 0.85,
+			Threat:     entityv1.ThreatLevel_THREAT_LEVEL_NONE,
+		}
+	case speedKnots <= 45:
+		return Classification{
+			Label:      "fast vessel",
+			Confidence: 0.70,
+			Threat:     entityv1.ThreatLevel_THREAT_LEVEL_LOW,
+		}
+	default:
+		return Classification{
+			Label:      "fast attack craft",
+			Confidence: 0.60,
+			Threat:     entityv1.ThreatLevel_THREAT_LEVEL_MEDIUM,
+		}
+	}
+}
+
+// ClassifyDomain applies the speed rules for the track's domain. Tracks with
+// no domain are treated as air.
+func ClassifyDomain(domain entityv1.Domain, speedKnots float64) Classification {
+	if domain == entityv1.Domain_DOMAIN_SURFACE {
+		return ClassifySurface(speedKnots)
+	}
+	return Classify(speedKnots)
+}
+
 // Classifier watches Track entities and adds classification + threat components.
 type Classifier struct {
 	cfg Config
@@ -65,21 +116,30 @@ func New(cfg Config) *Classifier {
 
 // Run connects to the store, watches Tracks, and classifies them until ctx is cancelled.
 func (c *Classifier) Run(ctx context.Context) error {
-	conn, err := grpc.NewClient(c.cfg.StoreAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := client.Dial(c.cfg.StoreAddr)
 	if err != nil {
 		return fmt.Errorf("connect to store: %w", err)
 	}
 	defer conn.Close()
 
 	client := storev1.NewEntityStoreServiceClient(conn)
+	c.cfg.Health.SetReady("classifier", health.ErrStarting)
+	dog := c.cfg.Health.Watchdog("classifier", health.DefaultStall)
 
+	// Tracks without a velocity cannot be classified; the store keeps them
+	// off the stream.
 	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{
 		TypeFilter: entityv1.EntityType_ENTITY_TYPE_TRACK,
+		Components: []string{"velocity"},
 	})
 	if err != nil {
 		return fmt.Errorf("watch entities: %w", err)
 	}
 
+	if _, err := stream.Header(); err != nil {
+		return fmt.Errorf("watch entities: %w", err)
+	}
+	c.cfg.Health.SetReady("classifier", nil)
 	slog.Info("classifier watching tracks", "store_addr", c.cfg.StoreAddr)
 
 	for {
@@ -88,16 +148,22 @@ func (c *Classifier) Run(ctx context.Context) error {
 			if ctx.Err() != nil {
 				return nil
 			}
+			c.cfg.Health.SetReady("classifier", err)
 			return fmt.Errorf("recv: %w", err)
 		}
 
-		if event.Type == storev1.EventType_EVENT_TYPE_DELETED {
+		if watch.Removed(event) {
 			continue
 		}
 
+		dog.Begin()
+		start := time.Now()
 		if err := c.classifyEntity(ctx, client, event.Entity); err != nil {
+			failed.Inc()
 			slog.Error("classify failed", "entity_id", event.Entity.Id, "error", err)
 		}
+		classifySeconds.Observe(time.Since(start).Seconds())
+		dog.End()
 	}
 }
 
@@ -107,7 +173,8 @@ func (c *Classifier) classifyEntity(ctx context.Context, client storev1.EntitySt
 		return err
 	}
 
-	cl := Classify(speed)
+	domain := extractDomain(entity)
+	cl := ClassifyDomain(domain, speed)
 
 	clComp, err := anypb.New(&entityv1.ClassificationComponent{
 		Label:      cl.Label,
@@ -124,14 +191,23 @@ func (c *Classifier) classifyEntity(ctx context.Context, client storev1.EntitySt
 		return fmt.Errorf("pack threat: %w", err)
 	}
 
-	entity.Components["classification"] = clComp
-	entity.Components["threat"] = threatComp
+	// Our own update comes back on the watch; writing it again would loop.
+	if proto.Equal(entity.Components["classification"], clComp) && proto.Equal(entity.Components["threat"], threatComp) {
+		unchanged.Inc()
+		return nil
+	}
 
-	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity}); err != nil {
-		return fmt.Errorf("update %s: %w", entity.Id, err)
+	// Patch only what we own, so a position written since this event is
+	// not sent back stale.
+	if _, err := client.PatchComponent(ctx, &storev1.PatchComponentRequest{
+		Id:         entity.Id,
+		Components: map[string]*anypb.Any{"classification": clComp, "threat": threatComp},
+	}); err != nil {
+		return fmt.Errorf("patch %s: %w", entity.Id, err)
 	}
+	classified.Inc(cl.Label)
 
-	slog.Info("classified entity", "entity_id", entity.Id, "label", cl.Label, "confidence_pct", cl.Confidence*100, "threat", cl.Threat.String(), "speed_kts", speed)
+	slog.Info("classified entity", "entity_id", entity.Id, "label", cl.Label, "confidence_pct", cl.Confidence*100, "threat", cl.Threat.String(), "speed_kts", speed, "domain", domain.String())
 	return nil
 }
 
@@ -148,3 +224,17 @@ func extractSpeed(entity *entityv1.Entity) (float64, error) {
 
 	return vel.Speed, nil
 }
+
+// extractDomain returns the domain from the source component, or unspecified
+// if the entity has none.
+func extractDomain(entity *entityv1.Entity) entityv1.Domain {
+	srcAny, ok := entity.Components["source"]
+	if !ok {
+		return entityv1.Domain_DOMAIN_UNSPECIFIED
+	}
+	src := &entityv1.SourceComponent{}
+	if err := srcAny.UnmarshalTo(src); err != nil {
+		return entityv1.Domain_DOMAIN_UNSPECIFIED
+	}
+	return src.Domain
+}
@@ -65,6 +65,43 @@ func TestClassifyBoundaries(t *testing.T) {
 	}
 }
 
+func TestClassifySurface(t *testing.T) {
+	for _, tc := range []struct {
+		speed  float64
+		label  string
+		threat entityv1.ThreatLevel
+	}{
+		{12, "vessel", entityv1.ThreatLevel_THREAT_LEVEL_NONE},
+		{30, "fast vessel", entityv1.ThreatLevel_THREAT_LEVEL_LOW},
+		{45, "fast vessel", entityv1.ThreatLevel_THREAT_LEVEL_LOW},
+		{50, "fast attack craft", entityv1.ThreatLevel_THREAT_LEVEL_MEDIUM},
+	} {
+		cl := ClassifyDomain(entityv1.Domain_DOMAIN_SURFACE, tc.speed)
+		if cl.Label != tc.label || cl.Threat != tc.threat {
+			t.Fatalf("at %v kts expected %s/%v, got %s/%v", tc.speed, tc.label, tc.threat, cl.Label, cl.Threat)
+		}
+	}
+
+	// Unspecified and air domains keep the air rules.
+	if cl := ClassifyDomain(entityv1.Domain_DOMAIN_UNSPECIFIED, 100); cl.Label != "civilian" {
+		t.Fatalf("expected civilian for unspecified domain, got %s", cl.Label)
+	}
+	if cl := ClassifyDomain(entityv1.Domain_DOMAIN_AIR, 500); cl.Label != "military" {
+		t.Fatalf("expected military for air domain, got %s", cl.Label)
+	}
+}
+
+func TestExtractDomain(t *testing.T) {
+	src, _ := anypb.New(&entityv1.SourceComponent{SensorId: "ais-1", SensorType: "ais", Domain: entityv1.Domain_DOMAIN_SURFACE})
+	e := &entityv1.Entity{Components: map[string]*anypb.Any{"source": src}}
+	if d := extractDomain(e); d != entityv1.Domain_DOMAIN_SURFACE {
+		t.Fatalf("expected SURFACE, got %v", d)
+	}
+	if d := extractDomain(&entityv1.Entity{}); d != entityv1.Domain_DOMAIN_UNSPECIFIED {
+		t.Fatalf("expected UNSPECIFIED without source, got %v", d)
+	}
+}
+
 func startTestServer(t *testing.T) (string, func()) {
 	t.Helper()
 
@@ -160,6 +197,55 @@ func TestClassifierIntegration(t *testing.T) {
 	}
 }
 
+func TestClassifierIgnoresOwnUpdate(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	cl := New(Config{StoreAddr: addr})
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	go cl.Run(ctx) //nolint:errcheck
+	time.Sleep(100 * time.Millisecond)
+
+	conn, _ := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{})
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	vel, _ := anypb.New(&entityv1.VelocityComponent{Speed: 400, Heading: 90})
+	_, _ = client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{
+			Id:         "track-once",
+			Type:       entityv1.EntityType_ENTITY_TYPE_TRACK,
+			Components: map[string]*anypb.Any{"velocity": vel},
+		},
+	})
+
+	// The create, then the classification; the classifier sees its own
+	// update come back and must not write it again.
+	updates := 0
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		cancel()
+	}()
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		if event.Type == storev1.EventType_EVENT_TYPE_UPDATED {
+			updates++
+		}
+	}
+	if updates != 1 {
+		t.Fatalf("expected 1 classification update, got %d", updates)
+	}
+}
+
 func TestClassifierSkipsDeleteEvents(t *testing.T) {
 	addr, cleanup := startTestServer(t)
 	defer cleanup()
@@ -0,0 +1,183 @@
+// Package client dials the lattice gRPC services with the defaults every
+// service shares, so no caller has to remember them:
+//
+//   - plaintext, or TLS with WithTLS
+//   - keepalive pings while streams are open, so a watch on a peer that
+//     vanished fails instead of hanging
+//   - retries with exponential backoff of calls failing UNAVAILABLE
+//   - a DefaultTimeout deadline on unary calls made without one
+//   - the gRPC client metrics of the metrics package
+//   - message compression, with WithCompression
+//   - the process's HLC on every call, kept in step with the server's; see
+//     ProcessClock
+//
+// Streams get no default deadline: watches are meant to stay open.
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// DefaultTimeout is the deadline of a unary call whose context has none.
+const DefaultTimeout = 30 * time.Second
+
+// KeepaliveTime is how long a connection with open streams may go quiet
+// before the client pings it. Servers must permit pings this often; see
+// ServerOption.
+const KeepaliveTime = time.Minute
+
+// retryPolicy retries every method, unary and streaming alike until the
+// stream's first response, on UNAVAILABLE: the server was unreachable or
+// shutting down, so the call was not applied.
+const retryPolicy = `{"methodConfig": [{
+	"name": [{}],
+	"retryPolicy": {
+		"maxAttempts": 4,
+		"initialBackoff": "0.1s",
+		"maxBackoff": "2s",
+		"backoffMultiplier": 2,
+		"retryableStatusCodes": ["UNAVAILABLE"]
+	}
+}]}`
+
+// ProcessClock is the HLC the clients of this process carry unless dialed
+// WithClock. Every call sends it in server.HLCHeader and advances it past
+// the server's, so causality holds across services: a call made after
+// reading from one store carries an HLC past what was read, and the store
+// it goes to stamps its writes after that.
+var ProcessClock = hlc.NewClock(processNode())
+
+// processNode names ProcessClock's node after the host and process.
+func processNode() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+type options struct {
+	creds      credentials.TransportCredentials
+	token      string
+	timeout    time.Duration
+	noRetry    bool
+	compressor string
+	clock      server.Clock
+	extra      []grpc.DialOption
+}
+
+// Option configures Dial.
+type Option func(*options)
+
+// WithTLS dials over TLS with cfg instead of plaintext.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) { o.creds = credentials.NewTLS(cfg) }
+}
+
+// WithToken sends token as a bearer token on every call. An empty token
+// sends none.
+func WithToken(token string) Option {
+	return func(o *options) { o.token = token }
+}
+
+// WithTimeout replaces DefaultTimeout. Zero leaves unary calls without a
+// deadline unless their context sets one.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) { o.timeout = d }
+}
+
+// WithoutRetries turns retries off, for callers to whom an unreachable
+// server is news rather than a blip: the mesh relay forwarding to peers,
+// which must not hold up the rest while one is partitioned, and probes
+// counting failures.
+func WithoutRetries() Option {
+	return func(o *options) { o.noRetry = true }
+}
+
+// WithClock carries c's HLC on calls instead of ProcessClock's.
+func WithClock(c server.Clock) Option {
+	return func(o *options) { o.clock = c }
+}
+
+// WithDialOptions adds grpc dial options after the package's own.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) { o.extra = append(o.extra, opts...) }
+}
+
+// Dial returns a connection to addr. Like grpc.NewClient, it does not
+// connect until the first call.
+func Dial(addr string, opts ...Option) (*grpc.ClientConn, error) {
+	o := options{creds: insecure.NewCredentials(), timeout: DefaultTimeout, clock: ProcessClock}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := CheckCompression(o.compressor); err != nil {
+		return nil, err
+	}
+	dialOpts := []grpc.DialOption{
+		grpc.WithTransportCredentials(o.creds),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{Time: KeepaliveTime, Timeout: 20 * time.Second}),
+		grpc.WithDefaultServiceConfig(retryPolicy),
+		grpc.WithChainUnaryInterceptor(deadline(o.timeout), server.UnaryClientHLC(o.clock)),
+		grpc.WithChainStreamInterceptor(server.StreamClientHLC(o.clock)),
+		metrics.DialOption(),
+	}
+	if o.noRetry {
+		dialOpts = append(dialOpts, grpc.WithDisableRetry())
+	}
+	if o.compressor != "" {
+		dialOpts = append(dialOpts, grpc.WithDefaultCallOptions(grpc.UseCompressor(o.compressor)))
+	}
+	if o.token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(server.Token(o.token)))
+	}
+	return grpc.NewClient(addr, append(dialOpts, o.extra...)...)
+}
+
+// deadline gives unary calls without a deadline one of d.
+func deadline(d time.Duration) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if _, ok := ctx.Deadline(); !ok && d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// LoadTLS returns a TLS config trusting the PEM certificates in caFile, or
+// the system roots if caFile is empty.
+func LoadTLS(caFile string) (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile == "" {
+		return cfg, nil
+	}
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("read CA: %w", err)
+	}
+	cfg.RootCAs = x509.NewCertPool()
+	if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+		return nil, errors.New("read CA: no certificates in " + caFile)
+	}
+	return cfg, nil
+}
+
+// ServerOption lets clients dialed by Dial ping as often as they do. A
+// server without it treats their keepalives as abuse and closes the
+// connection.
+func ServerOption() grpc.ServerOption {
+	return grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{MinTime: KeepaliveTime / 2})
+}
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// flakyHealth fails its first failures checks UNAVAILABLE, and reports
+// whether each call arrived with a deadline.
+type flakyHealth struct {
+	healthpb.UnimplementedHealthServer
+	failures int32
+	calls    atomic.Int32
+	deadline atomic.Bool
+}
+
+func (h *flakyHealth) Check(ctx context.Context, _ *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	_, ok := ctx.Deadline()
+	h.deadline.Store(ok)
+	if h.calls.Add(1) <= h.failures {
+		return nil, status.Error(codes.Unavailable, "not yet")
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func serve(t *testing.T, h *flakyHealth) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer(ServerOption())
+	healthpb.RegisterHealthServer(srv, h)
+	go srv.Serve(lis) //nolint:errcheck
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestDial_RetriesUnavailable(t *testing.T) {
+	h := &flakyHealth{failures: 2}
+	conn, err := Dial(serve(t, h))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("expected the call to succeed on retry, got %v", err)
+	}
+	if got := h.calls.Load(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+}
+
+func TestDial_GivesUpAfterMaxAttempts(t *testing.T) {
+	h := &flakyHealth{failures: 100}
+	conn, err := Dial(serve(t, h))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable, got %v", err)
+	}
+	if got := h.calls.Load(); got != 4 {
+		t.Fatalf("expected 4 attempts, got %d", got)
+	}
+}
+
+func TestDial_DefaultDeadline(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want bool
+	}{
+		{"default", nil, true},
+		{"disabled", []Option{WithTimeout(0)}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := &flakyHealth{}
+			conn, err := Dial(serve(t, h), tc.opts...)
+			if err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			defer conn.Close()
+			if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{}); err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+			if h.deadline.Load() != tc.want {
+				t.Fatalf("expected deadline %v", tc.want)
+			}
+		})
+	}
+}
+
+func TestDial_CallerDeadlineKept(t *testing.T) {
+	h := &flakyHealth{}
+	conn, err := Dial(serve(t, h), WithTimeout(time.Hour))
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	time.Sleep(2 * time.Millisecond)
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("expected the caller's deadline to apply, got %v", err)
+	}
+}
+
+func TestLoadTLS(t *testing.T) {
+	if cfg, err := LoadTLS(""); err != nil || cfg.RootCAs != nil {
+		t.Fatalf("expected system roots, got %v, %v", cfg, err)
+	}
+	if _, err := LoadTLS("/nonexistent/ca.pem"); err == nil {
+		t.Fatal("expected an error for a missing CA file")
+	}
+}
+
+func TestDial_WithoutRetries(t *testing.T) {
+	h := &flakyHealth{failures: 1}
+	conn, err := Dial(serve(t, h), WithoutRetries())
+	if err != nil {
+		t.Fatalf("Dial: %v", err)
+	}
+	defer conn.Close()
+
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	if status.Code(err) != codes.Unavailable || h.calls.Load() != 1 {
+		t.Fatalf("expected one Unavailable attempt, got %v after %d", err, h.calls.Load())
+	}
+}
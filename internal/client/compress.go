@@ -0,0 +1,91 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/snappy"
+	"google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip" // registers gzip
+)
+
+// Compressors are the message compressors a client may ask for with
+// WithCompression. Any process importing this package, and so every
+// server here, accepts all of them and answers in the one the client
+// used.
+var Compressors = []string{"gzip", snappyName}
+
+const snappyName = "snappy"
+
+func init() {
+	c := &snappyCompressor{}
+	c.writers.New = func() any { return &snappyWriter{Writer: snappy.NewBufferedWriter(io.Discard), pool: &c.writers} }
+	encoding.RegisterCompressor(c)
+}
+
+// WithCompression compresses every message sent with the named
+// compressor, one of Compressors; empty sends them uncompressed. Entities
+// heavy with positions shrink to well under half, which matters on a
+// constrained link.
+func WithCompression(name string) Option {
+	return func(o *options) { o.compressor = name }
+}
+
+// CheckCompression returns an error unless name is empty or one of
+// Compressors.
+func CheckCompression(name string) error {
+	if name != "" && encoding.GetCompressor(name) == nil {
+		return fmt.Errorf("unknown compressor %q, want one of %v", name, Compressors)
+	}
+	return nil
+}
+
+// CompressedSize returns how many bytes b takes on the wire compressed
+// with the named compressor, or len(b) if name is empty or unknown.
+func CompressedSize(name string, b []byte) int {
+	c := encoding.GetCompressor(name)
+	if name == "" || c == nil {
+		return len(b)
+	}
+	var buf bytes.Buffer
+	w, err := c.Compress(&buf)
+	if err != nil {
+		return len(b)
+	}
+	if _, err := w.Write(b); err != nil {
+		return len(b)
+	}
+	if err := w.Close(); err != nil {
+		return len(b)
+	}
+	return buf.Len()
+}
+
+// snappyCompressor is the snappy framing format as a gRPC compressor.
+type snappyCompressor struct {
+	writers sync.Pool
+}
+
+type snappyWriter struct {
+	*snappy.Writer
+	pool *sync.Pool
+}
+
+func (c *snappyCompressor) Name() string { return snappyName }
+
+func (c *snappyCompressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	z := c.writers.Get().(*snappyWriter)
+	z.Reset(w)
+	return z, nil
+}
+
+func (z *snappyWriter) Close() error {
+	defer z.pool.Put(z)
+	return z.Writer.Close()
+}
+
+func (c *snappyCompressor) Decompress(r io.Reader) (io.Reader, error) {
+	return snappy.NewReader(r), nil
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"google.golang.org/grpc/encoding"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestCompressors_RoundTrip(t *testing.T) {
+	msg := bytes.Repeat([]byte("lat=38.8977 lon=-77.0365 "), 200)
+	for _, name := range Compressors {
+		t.Run(name, func(t *testing.T) {
+			c := encoding.GetCompressor(name)
+			if c == nil {
+				t.Fatalf("%s not registered", name)
+			}
+			var buf bytes.Buffer
+			w, err := c.Compress(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := w.Write(msg); err != nil {
+				t.Fatal(err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+			if got := CompressedSize(name, msg); got != buf.Len() || got >= len(msg)/2 {
+				t.Fatalf("expected CompressedSize %d, well under %d, got %d", buf.Len(), len(msg), got)
+			}
+
+			r, err := c.Decompress(&buf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, msg) {
+				t.Fatal("round trip changed the message")
+			}
+		})
+	}
+}
+
+func TestDial_Compression(t *testing.T) {
+	for _, name := range Compressors {
+		t.Run(name, func(t *testing.T) {
+			conn, err := Dial(serve(t, &flakyHealth{}), WithCompression(name))
+			if err != nil {
+				t.Fatalf("Dial: %v", err)
+			}
+			defer conn.Close()
+			if _, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "store"}); err != nil {
+				t.Fatalf("Check: %v", err)
+			}
+		})
+	}
+
+	if _, err := Dial("localhost:0", WithCompression("lz4")); err == nil {
+		t.Fatal("expected an unknown compressor to be refused")
+	}
+}
+
+func TestCompressedSize_Uncompressed(t *testing.T) {
+	if got := CompressedSize("", []byte("abc")); got != 3 {
+		t.Fatalf("expected 3, got %d", got)
+	}
+}
@@ -0,0 +1,94 @@
+// Package config defines command-line flags that fall back to environment
+// variables, so a service takes either `-interval 500ms` or `INTERVAL=500ms`.
+// Precedence is flag, then environment, then the default already in the
+// target variable. Malformed values from either source fail Parse.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Set is a flag set whose flags each have an environment fallback.
+type Set struct {
+	fs   *flag.FlagSet
+	envs []binding
+}
+
+// binding ties a flag to its environment variable.
+type binding struct {
+	flag string
+	env  string
+}
+
+// NewSet returns an empty set for the named command.
+func NewSet(name string) *Set {
+	return &Set{fs: flag.NewFlagSet(name, flag.ContinueOnError)}
+}
+
+// String defines a string flag. The current value of *p is the default.
+func (s *Set) String(p *string, name, env, usage string) {
+	s.fs.StringVar(p, name, *p, s.bind(name, env, usage))
+}
+
+// Int defines an int flag. The current value of *p is the default.
+func (s *Set) Int(p *int, name, env, usage string) {
+	s.fs.IntVar(p, name, *p, s.bind(name, env, usage))
+}
+
+// Uint64 defines a uint64 flag. The current value of *p is the default.
+func (s *Set) Uint64(p *uint64, name, env, usage string) {
+	s.fs.Uint64Var(p, name, *p, s.bind(name, env, usage))
+}
+
+// Float defines a float64 flag. The current value of *p is the default.
+func (s *Set) Float(p *float64, name, env, usage string) {
+	s.fs.Float64Var(p, name, *p, s.bind(name, env, usage))
+}
+
+// Bool defines a bool flag. The current value of *p is the default.
+func (s *Set) Bool(p *bool, name, env, usage string) {
+	s.fs.BoolVar(p, name, *p, s.bind(name, env, usage))
+}
+
+// Duration defines a duration flag. The current value of *p is the default.
+func (s *Set) Duration(p *time.Duration, name, env, usage string) {
+	s.fs.DurationVar(p, name, *p, s.bind(name, env, usage))
+}
+
+// Func defines a flag parsed by fn, for values such as coverage specs that
+// need their own parser. fn is called only when the flag or env var is set.
+func (s *Set) Func(name, env, usage string, fn func(string) error) {
+	s.fs.Func(name, s.bind(name, env, usage), fn)
+}
+
+func (s *Set) bind(name, env, usage string) string {
+	if env == "" {
+		return usage
+	}
+	s.envs = append(s.envs, binding{flag: name, env: env})
+	return fmt.Sprintf("%s (env %s)", usage, env)
+}
+
+// Parse applies set environment variables, then the command-line args.
+// Every malformed environment value is reported, not just the first. -h
+// prints usage and returns flag.ErrHelp.
+func (s *Set) Parse(args []string) error {
+	var errs []error
+	for _, b := range s.envs {
+		v, ok := os.LookupEnv(b.env)
+		if !ok || v == "" {
+			continue
+		}
+		if err := s.fs.Set(b.flag, v); err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s %q: %w", b.env, v, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return s.fs.Parse(args)
+}
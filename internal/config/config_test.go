@@ -0,0 +1,87 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	addr     string
+	interval time.Duration
+	count    int
+	ratio    float64
+	verbose  bool
+	mode     string
+}
+
+func newTestSet(cfg *testConfig) *Set {
+	s := NewSet("test")
+	s.String(&cfg.addr, "addr", "TEST_ADDR", "address")
+	s.Duration(&cfg.interval, "interval", "TEST_INTERVAL", "interval")
+	s.Int(&cfg.count, "count", "TEST_COUNT", "count")
+	s.Float(&cfg.ratio, "ratio", "TEST_RATIO", "ratio")
+	s.Bool(&cfg.verbose, "verbose", "TEST_VERBOSE", "verbose")
+	s.Func("mode", "TEST_MODE", "mode", func(v string) error {
+		if v != "fast" && v != "slow" {
+			return errors.New("want fast or slow")
+		}
+		cfg.mode = v
+		return nil
+	})
+	return s
+}
+
+func TestParse_Precedence(t *testing.T) {
+	t.Setenv("TEST_INTERVAL", "2s")
+	t.Setenv("TEST_COUNT", "7")
+	t.Setenv("TEST_MODE", "slow")
+
+	cfg := testConfig{addr: "localhost:1", interval: time.Second, count: 1, ratio: 0.5}
+	if err := newTestSet(&cfg).Parse([]string{"-count", "9", "-verbose"}); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if cfg.addr != "localhost:1" {
+		t.Fatalf("expected default addr kept, got %q", cfg.addr)
+	}
+	if cfg.interval != 2*time.Second {
+		t.Fatalf("expected env interval 2s, got %v", cfg.interval)
+	}
+	if cfg.count != 9 {
+		t.Fatalf("expected flag to beat env, got count %d", cfg.count)
+	}
+	if !cfg.verbose || cfg.mode != "slow" || cfg.ratio != 0.5 {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
+
+func TestParse_MalformedEnvFails(t *testing.T) {
+	t.Setenv("TEST_RATIO", "abc")
+	t.Setenv("TEST_MODE", "sideways")
+
+	var cfg testConfig
+	err := newTestSet(&cfg).Parse(nil)
+	if err == nil {
+		t.Fatal("expected malformed env values to fail")
+	}
+	for _, want := range []string{"TEST_RATIO", "TEST_MODE"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected error to name %s, got %v", want, err)
+		}
+	}
+}
+
+func TestParse_MalformedFlagFails(t *testing.T) {
+	var cfg testConfig
+	s := newTestSet(&cfg)
+	s.fs.SetOutput(new(strings.Builder))
+	if err := s.Parse([]string{"-interval", "soon"}); err == nil {
+		t.Fatal("expected malformed flag to fail")
+	}
+	if err := s.Parse([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("expected ErrHelp, got %v", err)
+	}
+}
@@ -0,0 +1,291 @@
+package cot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/watch"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Config controls the CoT bridge.
+type Config struct {
+	StoreAddr string
+	Endpoint  string        // udp://host:port or tcp://host:port to push events to
+	Listen    string        // UDP address to ingest CoT from; empty disables ingest
+	Stale     time.Duration // how long a pushed event stays valid
+	IDPrefix  string        // prefix for entities ingested from CoT
+}
+
+// DefaultConfig returns a config pushing to the standard TAK SA multicast
+// group, without ingest.
+func DefaultConfig() Config {
+	return Config{
+		StoreAddr: "localhost:50051",
+		Endpoint:  "udp://239.2.3.1:6969",
+		Stale:     30 * time.Second,
+		IDPrefix:  "cot-",
+	}
+}
+
+// Validate checks the config is runnable.
+func (cfg Config) Validate() error {
+	if _, _, err := parseEndpoint(cfg.Endpoint); err != nil {
+		return err
+	}
+	switch {
+	case cfg.Stale <= 0:
+		return fmt.Errorf("stale must be positive")
+	case cfg.Listen != "" && cfg.IDPrefix == "":
+		return fmt.Errorf("id prefix is required with ingest, to tell ingested entities apart")
+	}
+	return nil
+}
+
+// parseEndpoint splits "udp://host:port" into network and address.
+func parseEndpoint(s string) (string, string, error) {
+	network, addr, ok := strings.Cut(s, "://")
+	if !ok || (network != "udp" && network != "tcp") || addr == "" {
+		return "", "", fmt.Errorf("endpoint %q: want udp://host:port or tcp://host:port", s)
+	}
+	return network, addr, nil
+}
+
+// Bridge pushes entity events to a CoT endpoint and optionally ingests CoT
+// back as TRACK entities. Entities it ingested are not pushed back out, and
+// CoT events for entities it pushed are not ingested, so a TAK server that
+// echoes traffic does not loop.
+type Bridge struct {
+	cfg  Config
+	out  *sender
+	mu   sync.Mutex
+	sent map[string]*entityv1.Entity // pushed entity ID → latest copy, for refresh
+}
+
+// New creates a bridge with the given config.
+func New(cfg Config) *Bridge {
+	network, addr, _ := parseEndpoint(cfg.Endpoint)
+	return &Bridge{cfg: cfg, out: &sender{network: network, addr: addr}, sent: make(map[string]*entityv1.Entity)}
+}
+
+// Run pushes the current picture, then every entity event, until ctx is
+// cancelled. Events are re-sent every half stale period so quiet entities do
+// not time out on TAK clients.
+func (b *Bridge) Run(ctx context.Context) error {
+	conn, err := client.Dial(b.cfg.StoreAddr)
+	if err != nil {
+		return fmt.Errorf("connect to store: %w", err)
+	}
+	defer conn.Close()
+	defer b.out.close()
+
+	client := storev1.NewEntityStoreServiceClient(conn)
+	// The stream opens with the current picture, one CREATED per entity.
+	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{InitialState: true})
+	if err != nil {
+		return fmt.Errorf("watch entities: %w", err)
+	}
+
+	if b.cfg.Listen != "" {
+		pc, err := net.ListenPacket("udp", b.cfg.Listen)
+		if err != nil {
+			return fmt.Errorf("listen cot: %w", err)
+		}
+		defer pc.Close()
+		go b.ingest(ctx, client, pc)
+	}
+
+	events := make(chan *storev1.EntityEvent)
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(b.cfg.Stale / 2)
+	defer ticker.Stop()
+
+	slog.Info("cot-bridge started", "endpoint", b.cfg.Endpoint, "listen", b.cfg.Listen, "stale", b.cfg.Stale, "store_addr", b.cfg.StoreAddr)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("recv: %w", err)
+		case event := <-events:
+			if watch.Removed(event) {
+				b.remove(event.Entity, time.Now())
+				continue
+			}
+			b.push(event.Entity, time.Now())
+		case now := <-ticker.C:
+			b.mu.Lock()
+			entities := make([]*entityv1.Entity, 0, len(b.sent))
+			for _, e := range b.sent {
+				entities = append(entities, e)
+			}
+			b.mu.Unlock()
+			for _, e := range entities {
+				b.push(e, now)
+			}
+		}
+	}
+}
+
+// push sends entity as a CoT event, if CoT can show it and it did not come
+// from CoT.
+func (b *Bridge) push(entity *entityv1.Entity, now time.Time) {
+	if b.ingested(entity.Id) {
+		return
+	}
+	ev, ok := FromEntity(entity, now, b.cfg.Stale)
+	if !ok {
+		return
+	}
+	b.mu.Lock()
+	b.sent[entity.Id] = entity
+	b.mu.Unlock()
+	b.send(ev)
+}
+
+// remove expires a deleted entity on TAK clients.
+func (b *Bridge) remove(entity *entityv1.Entity, now time.Time) {
+	b.mu.Lock()
+	last, ok := b.sent[entity.Id]
+	delete(b.sent, entity.Id)
+	b.mu.Unlock()
+	if ok {
+		b.send(Removal(entity.Id, Type(last), now))
+	}
+}
+
+func (b *Bridge) send(ev *Event) {
+	data, err := ev.Marshal()
+	if err == nil {
+		err = b.out.send(data)
+	}
+	if err != nil {
+		slog.Warn("cot send failed", "uid", ev.UID, "endpoint", b.cfg.Endpoint, "error", err)
+	}
+}
+
+// ingested reports whether id names an entity the bridge ingested from CoT.
+func (b *Bridge) ingested(id string) bool {
+	return b.cfg.Listen != "" && strings.HasPrefix(id, b.cfg.IDPrefix)
+}
+
+// ingest reads CoT datagrams and upserts atoms as TRACK entities named
+// IDPrefix+uid, expiring with the event's stale time.
+func (b *Bridge) ingest(ctx context.Context, client storev1.EntityStoreServiceClient, pc net.PacketConn) {
+	go func() {
+		<-ctx.Done()
+		pc.Close()
+	}()
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, from, err := pc.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("cot ingest stopped", "listen", b.cfg.Listen, "error", err)
+			}
+			return
+		}
+		ev, err := Parse(buf[:n])
+		if err != nil {
+			slog.Debug("skipping cot datagram", "from", from, "error", err)
+			continue
+		}
+		b.mu.Lock()
+		_, echo := b.sent[ev.UID]
+		b.mu.Unlock()
+		if echo {
+			continue
+		}
+		if err := b.apply(ctx, client, ev, time.Now()); err != nil {
+			slog.Error("cot ingest failed", "uid", ev.UID, "error", err)
+		}
+	}
+}
+
+// apply upserts one inbound event.
+func (b *Bridge) apply(ctx context.Context, client storev1.EntityStoreServiceClient, ev *Event, now time.Time) error {
+	id := b.cfg.IDPrefix + ev.UID
+	entity, ttl, ok, err := ToEntity(ev, id, now)
+	if err != nil || !ok {
+		return err
+	}
+	if ttl <= 0 {
+		return nil // already stale
+	}
+
+	existing, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: id})
+	if status.Code(err) == codes.NotFound {
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity, Ttl: durationpb.New(ttl)}); err != nil {
+			return fmt.Errorf("create %s: %w", id, err)
+		}
+		slog.Info("cot track acquired", "track_id", id, "type", ev.Type)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get %s: %w", id, err)
+	}
+	entity.HlcPhysical, entity.HlcLogical, entity.HlcNode = existing.HlcPhysical, existing.HlcLogical, existing.HlcNode
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity, Ttl: durationpb.New(ttl)}); err != nil {
+		return fmt.Errorf("update %s: %w", id, err)
+	}
+	return nil
+}
+
+// sender writes events to the endpoint: one datagram each over UDP, or a
+// stream over TCP that is redialled after a failure.
+type sender struct {
+	network, addr string
+	conn          net.Conn
+}
+
+func (s *sender) send(data []byte) error {
+	if s.conn == nil {
+		conn, err := net.DialTimeout(s.network, s.addr, 5*time.Second)
+		if err != nil {
+			return fmt.Errorf("dial %s: %w", s.addr, err)
+		}
+		s.conn = conn
+	}
+	if _, err := s.conn.Write(data); err != nil {
+		s.close()
+		return fmt.Errorf("write %s: %w", s.addr, err)
+	}
+	return nil
+}
+
+func (s *sender) close() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
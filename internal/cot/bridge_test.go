@@ -0,0 +1,148 @@
+package cot
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func startTestServer(t *testing.T) (string, *store.Store, func()) {
+	t.Helper()
+
+	s := store.New()
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+
+	return lis.Addr().String(), s, func() { srv.Stop() }
+}
+
+// readEvent reads one CoT datagram from pc.
+func readEvent(t *testing.T, pc net.PacketConn) *Event {
+	t.Helper()
+	buf := make([]byte, 64*1024)
+	pc.SetReadDeadline(time.Now().Add(2 * time.Second)) //nolint:errcheck
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read cot: %v", err)
+	}
+	ev, err := Parse(buf[:n])
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return ev
+}
+
+func TestBridge_PushesEntities(t *testing.T) {
+	addr, s, cleanup := startTestServer(t)
+	defer cleanup()
+
+	tak, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	defer tak.Close()
+
+	if _, err := s.Create(track(t, "track-0", &entityv1.PositionComponent{Lat: 38.9, Lon: -77.0})); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	cfg.Endpoint = "udp://" + tak.LocalAddr().String()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go New(cfg).Run(ctx) //nolint:errcheck
+
+	if ev := readEvent(t, tak); ev.UID != "track-0" {
+		t.Fatalf("expected existing track-0 pushed at startup, got %s", ev.UID)
+	}
+
+	time.Sleep(100 * time.Millisecond) // let the watch start
+	e := track(t, "track-1", &entityv1.PositionComponent{Lat: 38.8, Lon: -77.1}, &entityv1.IFFComponent{Status: entityv1.IFFStatus_IFF_STATUS_HOSTILE})
+	if _, err := s.Create(e); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if ev := readEvent(t, tak); ev.UID != "track-1" || ev.Type != "a-h-A" {
+		t.Fatalf("expected hostile track-1, got %s %s", ev.UID, ev.Type)
+	}
+
+	if err := s.Delete("track-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	ev := readEvent(t, tak)
+	if ev.UID != "track-1" || ev.Stale != ev.Time {
+		t.Fatalf("expected track-1 expired on delete, got %+v", ev)
+	}
+}
+
+func TestBridge_Ingest(t *testing.T) {
+	addr, s, cleanup := startTestServer(t)
+	defer cleanup()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen udp: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.Listen = pc.LocalAddr().String()
+	b := New(cfg)
+	b.sent["track-7"] = &entityv1.Entity{Id: "track-7"} // pushed by this bridge
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go b.ingest(ctx, client, pc)
+
+	out, err := net.Dial("udp", pc.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("dial udp: %v", err)
+	}
+	defer out.Close()
+	now := time.Now()
+	for _, ev := range []*Event{
+		newEvent("ANDROID-1", "a-f-G-U-C", now, time.Minute),
+		newEvent("track-7", "a-h-A", now, time.Minute), // our own echo
+	} {
+		ev.Point.Lat, ev.Point.Lon = 38.9, -77.0
+		data, _ := ev.Marshal()
+		if _, err := out.Write(data); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	e, err := s.Get("cot-ANDROID-1")
+	if err != nil {
+		t.Fatalf("expected cot-ANDROID-1 ingested: %v", err)
+	}
+	iff := &entityv1.IFFComponent{}
+	if err := e.Components["iff"].UnmarshalTo(iff); err != nil || iff.Status != entityv1.IFFStatus_IFF_STATUS_FRIEND {
+		t.Fatalf("expected friendly ingest, got %v", iff)
+	}
+	if _, err := s.Get("cot-track-7"); err == nil {
+		t.Fatal("expected the bridge's own echo to be ignored")
+	}
+	if !b.ingested("cot-ANDROID-1") {
+		t.Fatal("expected ingested entities not to be pushed back out")
+	}
+}
@@ -0,0 +1,215 @@
+// Package cot converts entities to and from Cursor-on-Target (CoT) XML
+// events and bridges an entity store to TAK-compatible endpoints.
+package cot
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	knotsToMps = 0.514444
+	timeLayout = "2006-01-02T15:04:05.000Z"
+	unknownErr = 9999999.0 // CoT's "unknown" circular/linear error
+)
+
+// Event is a CoT 2.0 event.
+type Event struct {
+	XMLName xml.Name `xml:"event"`
+	Version string   `xml:"version,attr"`
+	UID     string   `xml:"uid,attr"`
+	Type    string   `xml:"type,attr"`
+	How     string   `xml:"how,attr"`
+	Time    string   `xml:"time,attr"`
+	Start   string   `xml:"start,attr"`
+	Stale   string   `xml:"stale,attr"`
+	Point   Point    `xml:"point"`
+	Detail  *Detail  `xml:"detail,omitempty"`
+}
+
+// Point is the event's location; hae is height above the ellipsoid in meters.
+type Point struct {
+	Lat float64 `xml:"lat,attr"`
+	Lon float64 `xml:"lon,attr"`
+	Hae float64 `xml:"hae,attr"`
+	CE  float64 `xml:"ce,attr"`
+	LE  float64 `xml:"le,attr"`
+}
+
+// Detail carries the optional sub-schemas the bridge reads and writes.
+type Detail struct {
+	Contact *Contact `xml:"contact,omitempty"`
+	Track   *Track   `xml:"track,omitempty"`
+	Remarks string   `xml:"remarks,omitempty"`
+}
+
+// Contact names the event on a map.
+type Contact struct {
+	Callsign string `xml:"callsign,attr"`
+}
+
+// Track is course in degrees true and speed in m/s.
+type Track struct {
+	Course float64 `xml:"course,attr"`
+	Speed  float64 `xml:"speed,attr"`
+}
+
+// Marshal encodes e as XML with the standard declaration.
+func (e *Event) Marshal() ([]byte, error) {
+	b, err := xml.Marshal(e)
+	if err != nil {
+		return nil, fmt.Errorf("marshal cot: %w", err)
+	}
+	return append([]byte(xml.Header), b...), nil
+}
+
+// Parse decodes one CoT event.
+func Parse(data []byte) (*Event, error) {
+	var e Event
+	if err := xml.Unmarshal(data, &e); err != nil {
+		return nil, fmt.Errorf("parse cot: %w", err)
+	}
+	if e.UID == "" || e.Type == "" {
+		return nil, fmt.Errorf("parse cot: event needs uid and type")
+	}
+	return &e, nil
+}
+
+// Type returns the CoT atom type for an entity, e.g. a-h-A for a hostile
+// air track. Affiliation comes from the IFF component (assets are friendly)
+// and the battle dimension from the source domain.
+func Type(entity *entityv1.Entity) string {
+	aff := "u"
+	if entity.Type == entityv1.EntityType_ENTITY_TYPE_ASSET {
+		aff = "f"
+	}
+	iff := &entityv1.IFFComponent{}
+	if c, ok := entity.Components["iff"]; ok && c.UnmarshalTo(iff) == nil {
+		switch iff.Status {
+		case entityv1.IFFStatus_IFF_STATUS_FRIEND:
+			aff = "f"
+		case entityv1.IFFStatus_IFF_STATUS_HOSTILE:
+			aff = "h"
+		case entityv1.IFFStatus_IFF_STATUS_NEUTRAL:
+			aff = "n"
+		}
+	}
+	dim := "A"
+	src := &entityv1.SourceComponent{}
+	if c, ok := entity.Components["source"]; ok && c.UnmarshalTo(src) == nil {
+		switch src.Domain {
+		case entityv1.Domain_DOMAIN_SURFACE:
+			dim = "S"
+		case entityv1.Domain_DOMAIN_LAND:
+			dim = "G"
+		}
+	}
+	return "a-" + aff + "-" + dim
+}
+
+// FromEntity converts a TRACK or ASSET with a position to a CoT event valid
+// until now+stale. It reports false for entities CoT cannot place.
+func FromEntity(entity *entityv1.Entity, now time.Time, stale time.Duration) (*Event, bool) {
+	if entity.Type != entityv1.EntityType_ENTITY_TYPE_TRACK && entity.Type != entityv1.EntityType_ENTITY_TYPE_ASSET {
+		return nil, false
+	}
+	pos := &entityv1.PositionComponent{}
+	if c, ok := entity.Components["position"]; !ok || c.UnmarshalTo(pos) != nil {
+		return nil, false
+	}
+
+	ev := newEvent(entity.Id, Type(entity), now, stale)
+	ev.Point = Point{Lat: pos.Lat, Lon: pos.Lon, Hae: pos.Alt, CE: unknownErr, LE: unknownErr}
+	ev.Detail = &Detail{Contact: &Contact{Callsign: entity.Id}}
+	vel := &entityv1.VelocityComponent{}
+	if c, ok := entity.Components["velocity"]; ok && c.UnmarshalTo(vel) == nil {
+		ev.Detail.Track = &Track{Course: vel.Heading, Speed: vel.Speed * knotsToMps}
+	}
+	cl := &entityv1.ClassificationComponent{}
+	if c, ok := entity.Components["classification"]; ok && c.UnmarshalTo(cl) == nil && cl.Label != "" {
+		ev.Detail.Remarks = fmt.Sprintf("%s (%.0f%%)", cl.Label, cl.Confidence*100)
+	}
+	return ev, true
+}
+
+// Removal returns an event that expires uid immediately, which TAK clients
+// treat as a delete.
+func Removal(uid, typ string, now time.Time) *Event {
+	return newEvent(uid, typ, now, 0)
+}
+
+func newEvent(uid, typ string, now time.Time, stale time.Duration) *Event {
+	ts := now.UTC().Format(timeLayout)
+	return &Event{
+		Version: "2.0",
+		UID:     uid,
+		Type:    typ,
+		How:     "m-g",
+		Time:    ts,
+		Start:   ts,
+		Stale:   now.Add(stale).UTC().Format(timeLayout),
+		Point:   Point{CE: unknownErr, LE: unknownErr},
+	}
+}
+
+// ToEntity converts an inbound atom event to a TRACK under id, and returns
+// how long it stays valid. Non-atom events (drawings, chat) report false.
+func ToEntity(ev *Event, id string, now time.Time) (*entityv1.Entity, time.Duration, bool, error) {
+	parts := strings.Split(ev.Type, "-")
+	if len(parts) < 3 || parts[0] != "a" {
+		return nil, 0, false, nil
+	}
+
+	comps := make(map[string]*anypb.Any)
+	pos, err := anypb.New(&entityv1.PositionComponent{Lat: ev.Point.Lat, Lon: ev.Point.Lon, Alt: ev.Point.Hae})
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("pack position: %w", err)
+	}
+	comps["position"] = pos
+	if ev.Detail != nil && ev.Detail.Track != nil {
+		vel, err := anypb.New(&entityv1.VelocityComponent{Speed: ev.Detail.Track.Speed / knotsToMps, Heading: ev.Detail.Track.Course})
+		if err != nil {
+			return nil, 0, false, fmt.Errorf("pack velocity: %w", err)
+		}
+		comps["velocity"] = vel
+	}
+
+	iff := entityv1.IFFStatus_IFF_STATUS_UNKNOWN
+	switch parts[1] {
+	case "f", "a":
+		iff = entityv1.IFFStatus_IFF_STATUS_FRIEND
+	case "h", "s", "j", "k":
+		iff = entityv1.IFFStatus_IFF_STATUS_HOSTILE
+	case "n":
+		iff = entityv1.IFFStatus_IFF_STATUS_NEUTRAL
+	}
+	iffAny, err := anypb.New(&entityv1.IFFComponent{Status: iff})
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("pack iff: %w", err)
+	}
+	comps["iff"] = iffAny
+
+	domain := entityv1.Domain_DOMAIN_AIR
+	switch parts[2] {
+	case "S", "U":
+		domain = entityv1.Domain_DOMAIN_SURFACE
+	case "G":
+		domain = entityv1.Domain_DOMAIN_LAND
+	}
+	src, err := anypb.New(&entityv1.SourceComponent{SensorId: "cot", SensorType: "cot", Domain: domain})
+	if err != nil {
+		return nil, 0, false, fmt.Errorf("pack source: %w", err)
+	}
+	comps["source"] = src
+
+	var ttl time.Duration
+	if stale, err := time.Parse(time.RFC3339, ev.Stale); err == nil {
+		ttl = stale.Sub(now)
+	}
+	return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: comps}, ttl, true, nil
+}
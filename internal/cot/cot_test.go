@@ -0,0 +1,118 @@
+package cot
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func track(t *testing.T, id string, comps ...proto.Message) *entityv1.Entity {
+	t.Helper()
+	e := &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{}}
+	keys := map[string]string{
+		"entity.v1.PositionComponent":       "position",
+		"entity.v1.VelocityComponent":       "velocity",
+		"entity.v1.IFFComponent":            "iff",
+		"entity.v1.SourceComponent":         "source",
+		"entity.v1.ClassificationComponent": "classification",
+	}
+	for _, c := range comps {
+		a, err := anypb.New(c)
+		if err != nil {
+			t.Fatalf("pack: %v", err)
+		}
+		e.Components[keys[string(c.ProtoReflect().Descriptor().FullName())]] = a
+	}
+	return e
+}
+
+func TestFromEntity(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	e := track(t, "track-1",
+		&entityv1.PositionComponent{Lat: 38.9, Lon: -77.0, Alt: 3000},
+		&entityv1.VelocityComponent{Speed: 400, Heading: 90},
+		&entityv1.IFFComponent{Status: entityv1.IFFStatus_IFF_STATUS_HOSTILE},
+		&entityv1.ClassificationComponent{Label: "military", Confidence: 0.9},
+	)
+	ev, ok := FromEntity(e, now, 30*time.Second)
+	if !ok {
+		t.Fatal("expected a CoT event")
+	}
+	if ev.UID != "track-1" || ev.Type != "a-h-A" || ev.Stale != "2026-10-16T12:00:30.000Z" {
+		t.Fatalf("unexpected event header %+v", ev)
+	}
+	if ev.Point.Hae != 3000 || ev.Detail.Track.Course != 90 || ev.Detail.Track.Speed < 205 || ev.Detail.Track.Speed > 206 {
+		t.Fatalf("unexpected point/track %+v %+v", ev.Point, ev.Detail.Track)
+	}
+	if ev.Detail.Remarks != "military (90%)" {
+		t.Fatalf("unexpected remarks %q", ev.Detail.Remarks)
+	}
+
+	if _, ok := FromEntity(track(t, "no-pos"), now, time.Second); ok {
+		t.Fatal("expected no event for an entity without position")
+	}
+	vessel := track(t, "ais-1", &entityv1.PositionComponent{}, &entityv1.SourceComponent{Domain: entityv1.Domain_DOMAIN_SURFACE})
+	if typ := Type(vessel); typ != "a-u-S" {
+		t.Fatalf("expected a-u-S for an unknown vessel, got %s", typ)
+	}
+}
+
+func TestMarshalParse_RoundTrip(t *testing.T) {
+	now := time.Now()
+	e := track(t, "track-1", &entityv1.PositionComponent{Lat: 38.9, Lon: -77.0}, &entityv1.VelocityComponent{Speed: 100, Heading: 45})
+	ev, _ := FromEntity(e, now, time.Minute)
+	data, err := ev.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "<?xml") || !strings.Contains(string(data), `<event version="2.0" uid="track-1" type="a-u-A"`) {
+		t.Fatalf("unexpected xml %s", data)
+	}
+
+	back, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	got, ttl, ok, err := ToEntity(back, "cot-track-1", now)
+	if err != nil || !ok {
+		t.Fatalf("ToEntity: %v %v", ok, err)
+	}
+	if ttl < 59*time.Second || ttl > time.Minute {
+		t.Fatalf("expected ~1m ttl from stale, got %v", ttl)
+	}
+	vel := &entityv1.VelocityComponent{}
+	if err := got.Components["velocity"].UnmarshalTo(vel); err != nil {
+		t.Fatalf("unmarshal velocity: %v", err)
+	}
+	if vel.Heading != 45 || vel.Speed < 99.99 || vel.Speed > 100.01 {
+		t.Fatalf("expected velocity to survive the round trip, got %v", vel)
+	}
+}
+
+func TestToEntity_Affiliation(t *testing.T) {
+	for typ, want := range map[string]entityv1.IFFStatus{
+		"a-f-G-U-C": entityv1.IFFStatus_IFF_STATUS_FRIEND,
+		"a-h-A":     entityv1.IFFStatus_IFF_STATUS_HOSTILE,
+		"a-n-S":     entityv1.IFFStatus_IFF_STATUS_NEUTRAL,
+		"a-u-A":     entityv1.IFFStatus_IFF_STATUS_UNKNOWN,
+	} {
+		e, _, ok, err := ToEntity(&Event{UID: "x", Type: typ}, "cot-x", time.Now())
+		if err != nil || !ok {
+			t.Fatalf("%s: ToEntity: %v %v", typ, ok, err)
+		}
+		iff := &entityv1.IFFComponent{}
+		if err := e.Components["iff"].UnmarshalTo(iff); err != nil || iff.Status != want {
+			t.Fatalf("%s: expected %s, got %s", typ, want, iff.Status)
+		}
+	}
+	if _, _, ok, _ := ToEntity(&Event{UID: "x", Type: "b-t-f"}, "cot-x", time.Now()); ok {
+		t.Fatal("expected non-atom events to be skipped")
+	}
+	if _, err := Parse([]byte(`<event version="2.0"/>`)); err == nil {
+		t.Fatal("expected error for an event without uid and type")
+	}
+}
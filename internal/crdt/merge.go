@@ -1,18 +1,84 @@
 // Package crdt provides CRDT merge strategies for lattice-lab entities.
 // It implements an LWW-Element-Map where each component key is a register
-// with per-key merge strategies (LWW by default, max-wins for threat).
+// with per-key merge strategies, looked up in a Registry: LWW by default,
+// max-wins for threat.
+// Removed components leave HLC-stamped tombstones, observed-remove style: a
+// write to a component made no later than its key's tombstone is dropped by
+// the merge, whatever its strategy, so a removal on one replica survives
+// the copy another still holds. A value resolved from several writes
+// records them, so it is resolved again from those the removal leaves,
+// whatever order replicas merge in.
 package crdt
 
 import (
+	"bytes"
+	"slices"
+	"strings"
+
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// Conflicts is Default.Conflicts.
+func Conflicts(a, b *entityv1.Entity) map[string]Strategy {
+	return Default.Conflicts(a, b)
+}
+
+// Conflicts returns the strategy resolving each component key a and b
+// both hold with different values, by key: the conflicts MergeEntity
+// settles. Components only one side holds are not conflicts; the merge
+// keeps them as they are.
+func (r *Registry) Conflicts(a, b *entityv1.Entity) map[string]Strategy {
+	out := make(map[string]Strategy)
+	for key, compA := range a.Components {
+		if compB, ok := b.Components[key]; ok && !proto.Equal(compA, compB) {
+			out[key] = r.StrategyFor(key)
+		}
+	}
+	return out
+}
+
+// MergeEntity is Default.MergeEntity.
+func MergeEntity(a, b *entityv1.Entity) *entityv1.Entity {
+	return Default.MergeEntity(a, b)
+}
+
+// MergeEntityReport is Default.MergeEntityReport.
+func MergeEntityReport(a, b *entityv1.Entity) (*entityv1.Entity, *MergeReport) {
+	return Default.MergeEntityReport(a, b)
+}
+
 // MergeEntity merges two entities into one using LWW-Element-Map semantics.
 // The result gets the higher entity-level HLC. For each component key present
-// in either entity, a per-key merge strategy is applied.
-func MergeEntity(a, b *entityv1.Entity) *entityv1.Entity {
+// in either entity, the strategy r assigns the key is applied, comparing the
+// component's own HLC where the entity carries one and the entity HLC where
+// it does not. The result carries the winning component's HLC for each key.
+// Labels are taken whole from the side that set them later, by their own
+// HLC, where the entity carries one. The result was created at the earlier
+// of the two creation times, min-wins, and updated at the later of the two
+// update times, max-wins, so replicas agree on both whatever order they
+// merge in.
+// A component removed on either side keeps only the writes made after the
+// later removal, its value resolved from them; the result carries the
+// later tombstone for each removed key.
+func (r *Registry) MergeEntity(a, b *entityv1.Entity) *entityv1.Entity {
+	return r.merge(a, b, nil)
+}
+
+// MergeEntityReport merges a and b as MergeEntity does, and reports how it
+// resolved each component key on which they differ.
+func (r *Registry) MergeEntityReport(a, b *entityv1.Entity) (*entityv1.Entity, *MergeReport) {
+	report := &MergeReport{}
+	merged := r.merge(a, b, report)
+	slices.SortFunc(report.Decisions, func(x, y Decision) int { return strings.Compare(x.Key, y.Key) })
+	return merged, report
+}
+
+// merge is MergeEntity, recording its decisions in report if not nil.
+func (r *Registry) merge(a, b *entityv1.Entity, report *MergeReport) *entityv1.Entity {
 	hlcA := entityHLC(a)
 	hlcB := entityHLC(b)
 
@@ -23,17 +89,18 @@ func MergeEntity(a, b *entityv1.Entity) *entityv1.Entity {
 	}
 
 	result := &entityv1.Entity{
-		Id:          a.Id,
-		Type:        a.Type,
-		Components:  make(map[string]*anypb.Any),
-		CreatedAt:   a.CreatedAt,
-		UpdatedAt:   a.UpdatedAt,
-		HlcPhysical: winHLC.Physical,
-		HlcLogical:  winHLC.Logical,
-		HlcNode:     winHLC.Node,
+		Id:           a.Id,
+		Type:         a.Type,
+		Components:   make(map[string]*anypb.Any),
+		ComponentHlc: make(map[string]*entityv1.HLCTimestamp),
+		CreatedAt:    earliest(a.CreatedAt, b.CreatedAt),
+		UpdatedAt:    latest(a.UpdatedAt, b.UpdatedAt),
+		HlcPhysical:  winHLC.Physical,
+		HlcLogical:   winHLC.Logical,
+		HlcNode:      winHLC.Node,
 	}
 
-	// Collect all component keys from both entities.
+	// Collect all component keys from both entities, removed ones included.
 	keys := make(map[string]struct{})
 	for k := range a.Components {
 		keys[k] = struct{}{}
@@ -41,63 +108,258 @@ func MergeEntity(a, b *entityv1.Entity) *entityv1.Entity {
 	for k := range b.Components {
 		keys[k] = struct{}{}
 	}
+	for k := range a.RemovedComponents {
+		keys[k] = struct{}{}
+	}
+	for k := range b.RemovedComponents {
+		keys[k] = struct{}{}
+	}
 
 	for key := range keys {
 		compA, inA := a.Components[key]
 		compB, inB := b.Components[key]
+		writesA, writesB := writes(a, key), writes(b, key)
+
+		d := Decision{Key: key, Kept: SideNone}
+		differ := inA != inB || inA && !proto.Equal(compA, compB) ||
+			!proto.Equal(a.RemovedComponents[key], b.RemovedComponents[key])
 
+		// The later removal of key, if either side removed it; a write
+		// made no later than it is gone.
+		if tomb, removed := removal(a, b, key); removed {
+			if result.RemovedComponents == nil {
+				result.RemovedComponents = make(map[string]*entityv1.HLCTimestamp)
+			}
+			result.RemovedComponents[key] = stamp(tomb)
+			n := len(writesA) + len(writesB)
+			writesA, writesB = since(writesA, tomb), since(writesB, tomb)
+			d.Removed = len(writesA)+len(writesB) < n
+		}
+
+		// Each side's value is resolved from its writes the removal left,
+		// as is the result's from both sides' together, rather than from
+		// the values: a value resolved from writes on both sides of the
+		// removal is not one the removal leaves.
+		sideA, _ := r.fold(key, writesA)
+		sideB, _ := r.fold(key, writesB)
+		merged, ok := r.put(result, key, slices.Concat(writesA, writesB))
 		switch {
-		case inA && !inB:
-			result.Components[key] = compA
-		case !inA && inB:
-			result.Components[key] = compB
+		case !ok:
+		case len(writesB) == 0:
+			d.Kept = SideA
+		case len(writesA) == 0:
+			d.Kept = SideB
+		case proto.Equal(sideA.value, sideB.value):
+			d.Kept = SideBoth
 		default:
-			result.Components[key] = mergeComponent(key, compA, compB, hlcA, hlcB)
+			d.Strategy = r.StrategyFor(key)
+			switch {
+			case merged.is(sideA):
+				d.Kept = SideA
+			case merged.is(sideB):
+				d.Kept = SideB
+			default:
+				d.Kept = SideMerged
+			}
+		}
+		if report != nil && differ {
+			report.Decisions = append(report.Decisions, d)
 		}
 	}
 
+	// Labels are one LWW value, stamped by the write that set them: the
+	// later set, even if it is empty, so a clear replicates. A side with
+	// neither labels nor a stamp for them never set any.
+	labelsA, labelsB := labelsHLC(a), labelsHLC(b)
+	setA, setB := hasLabels(a), hasLabels(b)
+	switch {
+	case setB && (!setA || labelsB.After(labelsA)):
+		result.Labels, result.LabelsHlc = b.Labels, stamp(labelsB)
+	case setA:
+		result.Labels, result.LabelsHlc = a.Labels, stamp(labelsA)
+	}
+
 	return result
 }
 
-// mergeComponent dispatches to the appropriate merge strategy based on key.
-func mergeComponent(key string, compA, compB *anypb.Any, hlcA, hlcB hlc.Timestamp) *anypb.Any {
-	switch key {
-	case "threat":
-		return mergeThreat(compA, compB, hlcA, hlcB)
-	default:
-		// LWW: higher HLC wins. On tie, b wins (arbitrary but deterministic
-		// since HLC includes node for total ordering).
-		if hlcA.After(hlcB) {
-			return compA
+// removal returns the later of a's and b's tombstones for component key,
+// and whether either has one.
+func removal(a, b *entityv1.Entity, key string) (hlc.Timestamp, bool) {
+	tombA, inA := a.RemovedComponents[key]
+	tombB, inB := b.RemovedComponents[key]
+	switch {
+	case inA && inB:
+		ta, tb := stampOf(tombA), stampOf(tombB)
+		if tb.After(ta) {
+			return tb, true
 		}
-		return compB
+		return ta, true
+	case inA:
+		return stampOf(tombA), true
+	case inB:
+		return stampOf(tombB), true
 	}
+	return hlc.Timestamp{}, false
 }
 
-// mergeThreat implements max-wins semantics for threat components.
-// The higher threat level always wins. If levels are equal, the component
-// with the higher HLC wins.
-func mergeThreat(a, b *anypb.Any, hlcA, hlcB hlc.Timestamp) *anypb.Any {
-	var threatA, threatB entityv1.ThreatComponent
-	if err := a.UnmarshalTo(&threatA); err != nil {
-		return b
+// Prune drops from e, in place, each write to a component no later than
+// its key's tombstone: the component, if every write that set it is, or
+// else those writes, resolving its value again from the rest.
+func (r *Registry) Prune(e *entityv1.Entity) {
+	for key, tomb := range e.RemovedComponents {
+		ws := writes(e, key)
+		if kept := since(ws, stampOf(tomb)); len(kept) < len(ws) {
+			r.put(e, key, kept)
+		}
+	}
+}
+
+// write is one write to a component: the value it set, and its HLC.
+type write struct {
+	value *anypb.Any
+	hlc   hlc.Timestamp
+}
+
+// is reports whether w and o set the same value at the same HLC.
+func (w write) is(o write) bool {
+	return w.hlc == o.hlc && proto.Equal(w.value, o.value)
+}
+
+// writes returns the writes e's component key was resolved from, oldest
+// first: those it records, or else the one its value and HLC name.
+func writes(e *entityv1.Entity, key string) []write {
+	c, ok := e.Components[key]
+	if !ok {
+		return nil
+	}
+	recorded := e.ComponentWrites[key].GetWrites()
+	if len(recorded) == 0 {
+		return []write{{c, componentHLC(e, key)}}
+	}
+	out := make([]write, len(recorded))
+	for i, w := range recorded {
+		out[i] = write{w.Value, stampOf(w.GetHlc())}
+	}
+	return out
+}
+
+// since returns the writes in ws made after tomb.
+func since(ws []write, tomb hlc.Timestamp) []write {
+	return slices.DeleteFunc(slices.Clone(ws), func(w write) bool { return !w.hlc.After(tomb) })
+}
+
+// put sets e's component key to the value resolved from ws, recording the
+// writes it was resolved from if there is more than one, or removes it if
+// ws is empty. It returns the resolved write and whether there is one.
+func (r *Registry) put(e *entityv1.Entity, key string, ws []write) (write, bool) {
+	w, kept := r.fold(key, ws)
+	delete(e.ComponentWrites, key)
+	if len(kept) == 0 {
+		delete(e.Components, key)
+		delete(e.ComponentHlc, key)
+		return w, false
+	}
+	if e.Components == nil {
+		e.Components = make(map[string]*anypb.Any)
+	}
+	if e.ComponentHlc == nil {
+		e.ComponentHlc = make(map[string]*entityv1.HLCTimestamp)
+	}
+	e.Components[key], e.ComponentHlc[key] = w.value, stamp(w.hlc)
+	if len(kept) > 1 {
+		if e.ComponentWrites == nil {
+			e.ComponentWrites = make(map[string]*entityv1.ComponentWrites)
+		}
+		recorded := &entityv1.ComponentWrites{Writes: make([]*entityv1.ComponentWrite, len(kept))}
+		for i, w := range kept {
+			recorded.Writes[i] = &entityv1.ComponentWrite{Value: w.value, Hlc: stamp(w.hlc)}
+		}
+		e.ComponentWrites[key] = recorded
+	}
+	return w, true
+}
+
+// fold resolves ws, writes to component key, into one with key's
+// strategy, and returns it with the writes it needs, oldest first. A write
+// is needed unless the writes after it resolve to a value it does not
+// change: those alone are what any removal could leave of the others, so
+// the rest need not be kept. ws is not modified.
+func (r *Registry) fold(key string, ws []write) (write, []write) {
+	ws = slices.Clone(ws)
+	slices.SortFunc(ws, func(x, y write) int {
+		if c := hlc.Compare(x.hlc, y.hlc); c != 0 {
+			return c
+		}
+		if c := strings.Compare(x.value.GetTypeUrl(), y.value.GetTypeUrl()); c != 0 {
+			return c
+		}
+		return bytes.Compare(x.value.GetValue(), y.value.GetValue())
+	})
+	var acc write
+	var kept []write
+	for i := len(ws) - 1; i >= 0; i-- {
+		w := ws[i]
+		if len(kept) == 0 {
+			acc, kept = w, append(kept, w)
+			continue
+		}
+		next := r.combine(key, w, acc)
+		if next.is(acc) {
+			continue
+		}
+		acc, kept = next, append(kept, w)
 	}
-	if err := b.UnmarshalTo(&threatB); err != nil {
-		return a
+	slices.Reverse(kept)
+	return acc, kept
+}
+
+// combine resolves two writes to component key into one: the value key's
+// strategy keeps, with the HLC of the write it kept, or, for a new value
+// such as a union, the later of the two it was made from.
+func (r *Registry) combine(key string, x, y write) write {
+	c := r.resolve(key, x.value, y.value, x.hlc, y.hlc)
+	switch c {
+	case x.value:
+		return write{c, x.hlc}
+	case y.value:
+		return write{c, y.hlc}
 	}
+	return write{c, later(x.hlc, y.hlc)}
+}
 
-	if threatA.Level > threatB.Level {
-		return a
+// MergeTombstone resolves an entity against a delete stamped tomb, the
+// counterpart of MergeEntity for deletes. The delete wins unless the entity
+// was written after it, so a stale copy relayed from a partitioned peer
+// cannot resurrect a deleted entity. Returns nil if the delete wins.
+func MergeTombstone(e *entityv1.Entity, tomb hlc.Timestamp) *entityv1.Entity {
+	if entityHLC(e).After(tomb) {
+		return e
 	}
-	if threatB.Level > threatA.Level {
+	return nil
+}
+
+// later returns the later of a and b.
+func later(a, b hlc.Timestamp) hlc.Timestamp {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// earliest returns the earlier of a and b, or whichever is set.
+func earliest(a, b *timestamppb.Timestamp) *timestamppb.Timestamp {
+	if a == nil || b != nil && b.AsTime().Before(a.AsTime()) {
 		return b
 	}
+	return a
+}
 
-	// Same level: fall back to HLC.
-	if hlcA.After(hlcB) {
-		return a
+// latest returns the later of a and b, or whichever is set.
+func latest(a, b *timestamppb.Timestamp) *timestamppb.Timestamp {
+	if a == nil || b != nil && b.AsTime().After(a.AsTime()) {
+		return b
 	}
-	return b
+	return a
 }
 
 // entityHLC extracts the HLC timestamp from an entity's fields.
@@ -108,3 +370,34 @@ func entityHLC(e *entityv1.Entity) hlc.Timestamp {
 		Node:     e.HlcNode,
 	}
 }
+
+// componentHLC returns a component's own HLC, or the entity's if it has none.
+func componentHLC(e *entityv1.Entity, key string) hlc.Timestamp {
+	if c, ok := e.ComponentHlc[key]; ok {
+		return hlc.Timestamp{Physical: c.Physical, Logical: c.Logical, Node: c.Node}
+	}
+	return entityHLC(e)
+}
+
+// hasLabels reports whether e's labels were ever set: it holds some, or
+// the stamp of the write that set them, empty or not.
+func hasLabels(e *entityv1.Entity) bool {
+	return len(e.Labels) > 0 || e.LabelsHlc != nil
+}
+
+// labelsHLC returns the HLC of the write that set e's labels, or the
+// entity's if it has none.
+func labelsHLC(e *entityv1.Entity) hlc.Timestamp {
+	if e.LabelsHlc != nil {
+		return stampOf(e.LabelsHlc)
+	}
+	return entityHLC(e)
+}
+
+func stampOf(ts *entityv1.HLCTimestamp) hlc.Timestamp {
+	return hlc.Timestamp{Physical: ts.GetPhysical(), Logical: ts.GetLogical(), Node: ts.GetNode()}
+}
+
+func stamp(ts hlc.Timestamp) *entityv1.HLCTimestamp {
+	return &entityv1.HLCTimestamp{Physical: ts.Physical, Logical: ts.Logical, Node: ts.Node}
+}
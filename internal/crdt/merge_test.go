@@ -1,12 +1,15 @@
 package crdt
 
 import (
+	"math/rand/v2"
+	"slices"
 	"testing"
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	"github.com/boshu2/lattice-lab/internal/hlc"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // makeEntity creates a test entity with the given HLC and component map.
@@ -245,3 +248,290 @@ func TestMergeEntity_ResultHLC(t *testing.T) {
 		t.Errorf("result HLC node: expected nodeB, got %s", result.HlcNode)
 	}
 }
+
+func TestMergeEntity_ComponentHLC(t *testing.T) {
+	// B is the later write overall, but only touched threat; A set position
+	// after B last did.
+	a := makeEntity("e1", hlcTS(200, 0, "nodeA"), map[string]proto.Message{
+		"position": &entityv1.PositionComponent{Lat: 1.0},
+	})
+	b := makeEntity("e1", hlcTS(300, 0, "nodeB"), map[string]proto.Message{
+		"position": &entityv1.PositionComponent{Lat: 2.0},
+		"threat":   &entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_LOW},
+	})
+	b.ComponentHlc = map[string]*entityv1.HLCTimestamp{
+		"position": {Physical: 100, Node: "nodeB"},
+		"threat":   {Physical: 300, Node: "nodeB"},
+	}
+
+	for _, result := range []*entityv1.Entity{MergeEntity(a, b), MergeEntity(b, a)} {
+		var pos entityv1.PositionComponent
+		if err := result.Components["position"].UnmarshalTo(&pos); err != nil {
+			t.Fatal(err)
+		}
+		if pos.Lat != 1.0 {
+			t.Fatalf("expected A's later position to win, got lat %v", pos.Lat)
+		}
+		if got := result.ComponentHlc["position"]; got.GetPhysical() != 200 || got.GetNode() != "nodeA" {
+			t.Fatalf("expected position stamped with A's HLC, got %v", got)
+		}
+		if got := result.ComponentHlc["threat"]; got.GetPhysical() != 300 {
+			t.Fatalf("expected threat to keep B's stamp, got %v", got)
+		}
+	}
+}
+
+func TestMergeEntity_RemovedComponents(t *testing.T) {
+	// B removed position and threat at 200; A still holds both, position
+	// set before the removal and threat, at a higher level, too.
+	a := makeEntity("e1", hlcTS(100, 0, "nodeA"), map[string]proto.Message{
+		"position": &entityv1.PositionComponent{Lat: 1.0},
+		"threat":   &entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH},
+	})
+	b := makeEntity("e1", hlcTS(200, 0, "nodeB"), nil)
+	b.RemovedComponents = map[string]*entityv1.HLCTimestamp{
+		"position": {Physical: 200, Node: "nodeB"},
+		"threat":   {Physical: 200, Node: "nodeB"},
+	}
+
+	for _, result := range []*entityv1.Entity{MergeEntity(a, b), MergeEntity(b, a)} {
+		if len(result.Components) != 0 {
+			t.Fatalf("expected the removals to win, got %v", componentKeys(result))
+		}
+		if got := result.RemovedComponents["threat"]; got.GetPhysical() != 200 {
+			t.Fatalf("expected the tombstone kept, got %v", got)
+		}
+	}
+
+	// A sets position again after the removal: the re-add wins, and the
+	// tombstone stays.
+	a.HlcPhysical = 300
+	for _, result := range []*entityv1.Entity{MergeEntity(a, b), MergeEntity(b, a)} {
+		if _, ok := result.Components["position"]; !ok {
+			t.Fatalf("expected position set after the removal, got %v", componentKeys(result))
+		}
+		if got := result.ComponentHlc["position"]; got.GetPhysical() != 300 {
+			t.Fatalf("expected position stamped with A's HLC, got %v", got)
+		}
+		if _, ok := result.RemovedComponents["position"]; !ok {
+			t.Fatal("expected position's tombstone kept")
+		}
+	}
+}
+
+func TestMergeEntity_RemovalBetweenMergedWrites(t *testing.T) {
+	// A raised threat to HIGH at 100, B removed it at 200, and C, not yet
+	// having seen the removal, set LOW at 300. Max-wins resolves A's and
+	// C's to HIGH, but only C's write outlives the removal, so whichever
+	// copies merge first, LOW stamped 300 is what is left.
+	a := makeEntity("e1", hlcTS(100, 0, "nodeA"), map[string]proto.Message{
+		"threat": &entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH},
+	})
+	b := makeEntity("e1", hlcTS(200, 0, "nodeB"), nil)
+	b.RemovedComponents = map[string]*entityv1.HLCTimestamp{"threat": {Physical: 200, Node: "nodeB"}}
+	c := makeEntity("e1", hlcTS(300, 0, "nodeC"), map[string]proto.Message{
+		"threat": &entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_LOW},
+	})
+
+	ac := MergeEntity(a, c)
+	if got := len(ac.ComponentWrites["threat"].GetWrites()); got != 2 {
+		t.Fatalf("expected both writes to threat recorded, got %d", got)
+	}
+	for name, result := range map[string]*entityv1.Entity{
+		"(a+c)+b": MergeEntity(ac, b),
+		"(a+b)+c": MergeEntity(MergeEntity(a, b), c),
+		"a+(c+b)": MergeEntity(a, MergeEntity(c, b)),
+	} {
+		var threat entityv1.ThreatComponent
+		if err := result.Components["threat"].UnmarshalTo(&threat); err != nil {
+			t.Fatalf("%s: expected threat kept, got %v", name, componentKeys(result))
+		}
+		if threat.Level != entityv1.ThreatLevel_THREAT_LEVEL_LOW || result.ComponentHlc["threat"].GetPhysical() != 300 {
+			t.Errorf("%s: expected LOW at 300, got %v at %v", name, threat.Level, result.ComponentHlc["threat"])
+		}
+		if _, ok := result.ComponentWrites["threat"]; ok {
+			t.Errorf("%s: expected no writes recorded for a value one write set", name)
+		}
+	}
+
+	// Pruning a copy in place does the same.
+	ac.RemovedComponents = b.RemovedComponents
+	Default.Prune(ac)
+	if !proto.Equal(ac.Components["threat"], c.Components["threat"]) {
+		t.Fatalf("expected Prune to leave C's LOW, got %v", ac.Components["threat"])
+	}
+}
+
+func TestMergeEntityReport(t *testing.T) {
+	a := makeEntity("e1", hlcTS(100, 0, "nodeA"), map[string]proto.Message{
+		"position": &entityv1.PositionComponent{Lat: 1},
+		"threat":   &entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH},
+		"velocity": &entityv1.VelocityComponent{Speed: 5},
+		"class":    &entityv1.ClassificationComponent{Label: "aircraft"},
+	})
+	b := makeEntity("e1", hlcTS(200, 0, "nodeB"), map[string]proto.Message{
+		"position": &entityv1.PositionComponent{Lat: 2},
+		"threat":   &entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_LOW},
+		"class":    &entityv1.ClassificationComponent{Label: "aircraft"},
+	})
+	b.RemovedComponents = map[string]*entityv1.HLCTimestamp{"velocity": {Physical: 150, Node: "nodeB"}}
+
+	merged, report := MergeEntityReport(a, b)
+	if !proto.Equal(merged, MergeEntity(a, b)) {
+		t.Fatal("expected the reported merge to match MergeEntity")
+	}
+	// The equal class is not reported.
+	want := []Decision{
+		{Key: "position", Kept: SideB, Strategy: LWW},
+		{Key: "threat", Kept: SideA, Strategy: MaxWins},
+		{Key: "velocity", Kept: SideNone, Removed: true},
+	}
+	if !slices.Equal(report.Decisions, want) {
+		t.Fatalf("got %v, want %v", report.Decisions, want)
+	}
+	if got := report.String(); got != "position: kept b by lww; threat: kept a by max-wins; velocity: removed" {
+		t.Fatalf("unexpected description %q", got)
+	}
+
+	// Merged the other way, the sides swap.
+	_, report = MergeEntityReport(b, a)
+	if d := report.Decisions[0]; d.Key != "position" || d.Kept != SideA {
+		t.Fatalf("expected b's position kept as side a, got %v", d)
+	}
+}
+
+func TestMergeEntity_Timestamps(t *testing.T) {
+	at := func(sec int64) *timestamppb.Timestamp { return &timestamppb.Timestamp{Seconds: sec} }
+	a := makeEntity("e1", hlcTS(100, 0, "nodeA"), nil)
+	a.CreatedAt, a.UpdatedAt = at(20), at(50)
+	b := makeEntity("e1", hlcTS(200, 0, "nodeB"), nil)
+	b.CreatedAt, b.UpdatedAt = at(10), at(40)
+
+	for _, result := range []*entityv1.Entity{MergeEntity(a, b), MergeEntity(b, a)} {
+		if got := result.CreatedAt.GetSeconds(); got != 10 {
+			t.Fatalf("expected the earlier creation, 10, got %d", got)
+		}
+		if got := result.UpdatedAt.GetSeconds(); got != 50 {
+			t.Fatalf("expected the later update, 50, got %d", got)
+		}
+	}
+	// A copy without the times takes the other's.
+	c := makeEntity("e1", hlcTS(300, 0, "nodeC"), nil)
+	if result := MergeEntity(c, a); !proto.Equal(result.CreatedAt, a.CreatedAt) || !proto.Equal(result.UpdatedAt, a.UpdatedAt) {
+		t.Fatalf("expected a's times, got %v and %v", result.CreatedAt, result.UpdatedAt)
+	}
+}
+
+// TestMergeEntity_TimestampsProperties checks, over random triples of
+// copies, that the merged creation and update times do not depend on the
+// order or grouping of the merges.
+func TestMergeEntity_TimestampsProperties(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	random := func(node string) *entityv1.Entity {
+		e := makeEntity("e1", hlcTS(rng.Uint64N(1000), rng.Uint32N(3), node), nil)
+		// Some copies lack one or both, as entities from older stores do.
+		if rng.IntN(4) > 0 {
+			e.CreatedAt = &timestamppb.Timestamp{Seconds: rng.Int64N(100), Nanos: rng.Int32N(1000)}
+		}
+		if rng.IntN(4) > 0 {
+			e.UpdatedAt = &timestamppb.Timestamp{Seconds: rng.Int64N(100), Nanos: rng.Int32N(1000)}
+		}
+		return e
+	}
+	same := func(x, y *entityv1.Entity) bool {
+		return proto.Equal(x.CreatedAt, y.CreatedAt) && proto.Equal(x.UpdatedAt, y.UpdatedAt)
+	}
+	for i := range 1000 {
+		a, b, c := random("nodeA"), random("nodeB"), random("nodeC")
+		if ab, ba := MergeEntity(a, b), MergeEntity(b, a); !same(ab, ba) {
+			t.Fatalf("case %d: not commutative: %v/%v vs %v/%v", i, ab.CreatedAt, ab.UpdatedAt, ba.CreatedAt, ba.UpdatedAt)
+		}
+		left, right := MergeEntity(MergeEntity(a, b), c), MergeEntity(a, MergeEntity(b, c))
+		if !same(left, right) {
+			t.Fatalf("case %d: not associative: %v/%v vs %v/%v", i, left.CreatedAt, left.UpdatedAt, right.CreatedAt, right.UpdatedAt)
+		}
+		if aa := MergeEntity(a, a); !same(aa, a) {
+			t.Fatalf("case %d: not idempotent", i)
+		}
+	}
+}
+
+func TestMergeEntity_Labels(t *testing.T) {
+	a := makeEntity("e1", hlcTS(100, 0, "nodeA"), nil)
+	a.Labels = map[string]string{"exercise": "alpha"}
+	b := makeEntity("e1", hlcTS(200, 0, "nodeB"), nil)
+	b.Labels = map[string]string{"exercise": "bravo"}
+
+	for _, result := range []*entityv1.Entity{MergeEntity(a, b), MergeEntity(b, a)} {
+		if got := result.Labels["exercise"]; got != "bravo" {
+			t.Fatalf("expected the newer labels to win, got %v", result.Labels)
+		}
+	}
+
+	b.Labels = nil
+	for _, result := range []*entityv1.Entity{MergeEntity(a, b), MergeEntity(b, a)} {
+		if got := result.Labels["exercise"]; got != "alpha" {
+			t.Fatalf("expected the older labels when the newer has none, got %v", result.Labels)
+		}
+	}
+
+	// B has since been written, but set its labels before A did: A's
+	// stand, whatever the entity HLCs.
+	b.Labels = map[string]string{"exercise": "bravo"}
+	b.LabelsHlc = &entityv1.HLCTimestamp{Physical: 50, Node: "nodeB"}
+	for _, result := range []*entityv1.Entity{MergeEntity(a, b), MergeEntity(b, a)} {
+		if got := result.Labels["exercise"]; got != "alpha" || result.LabelsHlc.GetPhysical() != 100 {
+			t.Fatalf("expected the labels set later, at 100, got %v at %v", result.Labels, result.LabelsHlc)
+		}
+	}
+
+	// B cleared its labels after A set them: the clear wins, and carries
+	// its stamp on so it replicates.
+	b.Labels = nil
+	b.LabelsHlc = &entityv1.HLCTimestamp{Physical: 150, Node: "nodeB"}
+	for _, result := range []*entityv1.Entity{MergeEntity(a, b), MergeEntity(b, a)} {
+		if len(result.Labels) != 0 || result.LabelsHlc.GetPhysical() != 150 {
+			t.Fatalf("expected the labels cleared at 150, got %v at %v", result.Labels, result.LabelsHlc)
+		}
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	a := makeEntity("e1", hlcTS(100, 0, "node1"), map[string]proto.Message{
+		"position":       &entityv1.PositionComponent{Lat: 1},
+		"threat":         &entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_LOW},
+		"velocity":       &entityv1.VelocityComponent{Speed: 5},
+		"classification": &entityv1.ClassificationComponent{Label: "ship"},
+	})
+	b := makeEntity("e1", hlcTS(200, 0, "node2"), map[string]proto.Message{
+		"position": &entityv1.PositionComponent{Lat: 2},
+		"threat":   &entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH},
+		"velocity": &entityv1.VelocityComponent{Speed: 5},
+	})
+
+	// velocity agrees, and classification is a's alone: neither conflicts.
+	got := Conflicts(a, b)
+	if len(got) != 2 || got["position"] != LWW || got["threat"] != MaxWins {
+		t.Fatalf("expected position lww and threat max-wins, got %v", got)
+	}
+	if back := Conflicts(b, a); len(back) != len(got) {
+		t.Fatalf("expected the same conflicts either way round, got %v and %v", got, back)
+	}
+}
+
+func TestMergeTombstone(t *testing.T) {
+	tomb := hlcTS(200, 0, "nodeA")
+
+	stale := makeEntity("e1", hlcTS(150, 0, "nodeB"), nil)
+	if got := MergeTombstone(stale, tomb); got != nil {
+		t.Fatalf("expected the delete to win over an older write, got %v", got)
+	}
+	if got := MergeTombstone(makeEntity("e1", tomb, nil), tomb); got != nil {
+		t.Fatalf("expected the delete to win a tie, got %v", got)
+	}
+
+	later := makeEntity("e1", hlcTS(200, 1, "nodeB"), nil)
+	if got := MergeTombstone(later, tomb); got != later {
+		t.Fatalf("expected a write after the delete to survive, got %v", got)
+	}
+}
@@ -0,0 +1,261 @@
+package crdt
+
+import (
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/encoding/prototext"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+var (
+	mergeSeed  = flag.Uint64("merge.seed", 0, "replay only the merge property case with this seed")
+	mergeCases = flag.Int("merge.cases", 2000, "merge property cases to run, seeded 1 to N")
+)
+
+// TestMergeProperties checks that merging is a join: over random triples
+// of replicas of one entity, MergeEntity is commutative, associative, and
+// idempotent, so replicas that receive the same copies in any order and
+// any grouping converge. Each case is generated from its seed alone; a
+// failure names it, and -merge.seed replays just that case.
+func TestMergeProperties(t *testing.T) {
+	if *mergeSeed != 0 {
+		checkMerge(t, *mergeSeed)
+		return
+	}
+	for seed := uint64(1); seed <= uint64(*mergeCases); seed++ {
+		checkMerge(t, seed)
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+// FuzzMergeEntity checks the same properties on seeds the fuzzer picks.
+func FuzzMergeEntity(f *testing.F) {
+	for _, seed := range []uint64{1, 2, 3, 42} {
+		f.Add(seed)
+	}
+	f.Fuzz(checkMerge)
+}
+
+// checkMerge generates the case for seed and checks every property on it.
+func checkMerge(t *testing.T, seed uint64) {
+	t.Helper()
+	r := propertyRegistry(t)
+	g := newReplicaGen(seed)
+	a, b, c := g.replica(), g.replica(), g.replica()
+
+	fail := func(property string, got, want *entityv1.Entity) {
+		t.Helper()
+		t.Errorf("seed %d: merge not %s (replay with -run TestMergeProperties -merge.seed=%d)\n"+
+			"a: %v\nb: %v\nc: %v\ngot:  %v\nwant: %v",
+			seed, property, seed, text(a), text(b), text(c), text(got), text(want))
+	}
+	if ab, ba := r.MergeEntity(a, b), r.MergeEntity(b, a); !proto.Equal(ab, ba) {
+		fail("commutative", ab, ba)
+	}
+	if left, right := r.MergeEntity(r.MergeEntity(a, b), c), r.MergeEntity(a, r.MergeEntity(b, c)); !proto.Equal(left, right) {
+		fail("associative", left, right)
+	}
+	if aa := r.MergeEntity(a, a); !proto.Equal(normalize(aa), normalize(a)) {
+		fail("idempotent", aa, a)
+	}
+	// Merging in a copy already merged in changes nothing.
+	if ab := r.MergeEntity(a, b); !proto.Equal(r.MergeEntity(ab, a), ab) {
+		fail("absorbing", r.MergeEntity(ab, a), ab)
+	}
+}
+
+// propertyRegistry returns the default registry with a min-wins key too,
+// so every built-in strategy is exercised.
+func propertyRegistry(t *testing.T) *Registry {
+	t.Helper()
+	r := NewRegistry()
+	if err := r.Assign("speed", MinWins); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// propertyKeys are the component keys replicas are generated with, one
+// per strategy and two LWW.
+var propertyKeys = []string{"position", "classification", "threat", "speed", "fusion", "detections_count"}
+
+// removable are the keys replicas are generated with tombstones for: one
+// per strategy, so a removal is checked against values merged from writes
+// on both sides of it, not only against LWW ones.
+var removable = map[string]bool{"position": true, "classification": true, "threat": true, "speed": true, "fusion": true, "detections_count": true}
+
+// replicaGen generates replicas of one entity as stores would hold them
+// after some subset of its writes. Writes come from a small pool of HLCs
+// across three nodes, so replicas often share and tie on them, and a
+// write's value is a function of its key and HLC alone, as a real write's
+// is.
+type replicaGen struct {
+	rng    *rand.Rand
+	stamps []hlc.Timestamp
+}
+
+func newReplicaGen(seed uint64) *replicaGen {
+	g := &replicaGen{rng: rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))}
+	nodes := []string{"nodeA", "nodeB", "nodeC"}
+	for range 6 {
+		g.stamps = append(g.stamps, hlc.Timestamp{
+			Physical: 100 + g.rng.Uint64N(5),
+			Logical:  g.rng.Uint32N(2),
+			Node:     nodes[g.rng.IntN(len(nodes))],
+		})
+	}
+	return g
+}
+
+func (g *replicaGen) stamp() hlc.Timestamp {
+	return g.stamps[g.rng.IntN(len(g.stamps))]
+}
+
+// replica returns one replica. Some are as a store from before
+// per-component HLCs holds them, every component written at the entity's
+// HLC and no ComponentHlc; some have nil maps throughout.
+func (g *replicaGen) replica() *entityv1.Entity {
+	e := &entityv1.Entity{Id: "e1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}
+	if g.rng.IntN(3) > 0 {
+		e.CreatedAt = &timestamppb.Timestamp{Seconds: g.rng.Int64N(10), Nanos: g.rng.Int32N(3)}
+	}
+	if g.rng.IntN(3) > 0 {
+		e.UpdatedAt = &timestamppb.Timestamp{Seconds: g.rng.Int64N(10), Nanos: g.rng.Int32N(3)}
+	}
+
+	switch g.rng.IntN(6) {
+	case 0: // never written: every map nil
+		setHLC(e, g.stamp())
+	case 1: // legacy: one write, stamped by the entity's HLC alone
+		ts := g.stamp()
+		setHLC(e, ts)
+		for _, key := range propertyKeys {
+			if g.rng.IntN(2) == 0 {
+				if e.Components == nil {
+					e.Components = make(map[string]*anypb.Any)
+				}
+				e.Components[key] = writeValue(key, ts)
+			}
+		}
+	default:
+		latest := g.stamp()
+		for _, key := range propertyKeys {
+			var tomb *hlc.Timestamp
+			if removable[key] && g.rng.IntN(4) == 0 {
+				// Never the HLC of a write, as no event is both.
+				ts := g.stamp()
+				ts.Logical += 2
+				tomb = &ts
+				if e.RemovedComponents == nil {
+					e.RemovedComponents = make(map[string]*entityv1.HLCTimestamp)
+				}
+				e.RemovedComponents[key] = stamp(ts)
+				latest = later(latest, ts)
+			}
+			// A store drops a component its tombstone postdates.
+			ts := g.stamp()
+			if g.rng.IntN(3) == 0 || tomb != nil && !ts.After(*tomb) {
+				continue
+			}
+			if e.Components == nil {
+				e.Components = make(map[string]*anypb.Any)
+				e.ComponentHlc = make(map[string]*entityv1.HLCTimestamp)
+			}
+			e.Components[key], e.ComponentHlc[key] = writeValue(key, ts), stamp(ts)
+			latest = later(latest, ts)
+		}
+		setHLC(e, latest)
+	}
+	// Labels are a function of the HLC of the write that set them: the
+	// entity's, for a legacy replica, else one of their own, and some of
+	// those writes cleared them.
+	if g.rng.IntN(3) > 0 {
+		ts := entityHLC(e)
+		if len(e.ComponentHlc) > 0 || e.RemovedComponents != nil {
+			ts = g.stamp()
+			e.LabelsHlc = stamp(ts)
+			setHLC(e, later(entityHLC(e), ts))
+		}
+		// A legacy replica cannot hold a clear: it holds nothing.
+		if h := hash("labels", ts); h%5 > 0 {
+			e.Labels = map[string]string{"exercise": fmt.Sprint("ex-", h%4)}
+		}
+	}
+	return e
+}
+
+func setHLC(e *entityv1.Entity, ts hlc.Timestamp) {
+	e.HlcPhysical, e.HlcLogical, e.HlcNode = ts.Physical, ts.Logical, ts.Node
+}
+
+// writeValue returns the value the write of key at ts set.
+func writeValue(key string, ts hlc.Timestamp) *anypb.Any {
+	h := hash(key, ts)
+	var m proto.Message
+	switch key {
+	case "threat":
+		m = &entityv1.ThreatComponent{Level: entityv1.ThreatLevel(h % 5)}
+	case "speed":
+		m = wrapperspb.Double(float64(h % 4))
+	case "fusion":
+		// Unsorted, as a fusion writes its sources.
+		sources := []string{"radar-1", "ais-7", "adsb-3", "eo-2"}
+		rng := rand.New(rand.NewPCG(h, h))
+		rng.Shuffle(len(sources), func(i, j int) { sources[i], sources[j] = sources[j], sources[i] })
+		m = &entityv1.FusionComponent{SourceIds: sources[:1+h%3], FusedLat: float64(h % 10)}
+	case "detections_count":
+		m = &entityv1.CounterComponent{
+			Increments: map[string]uint64{ts.Node: h % 8, "nodeA": h % 3},
+			Decrements: map[string]uint64{ts.Node: h % 2},
+		}
+	case "classification":
+		m = &entityv1.ClassificationComponent{Label: fmt.Sprint("c", h%3)}
+	default:
+		m = &entityv1.PositionComponent{Lat: float64(h % 90), Lon: float64(h % 180)}
+	}
+	a := &anypb.Any{}
+	if err := anypb.MarshalFrom(a, m, proto.MarshalOptions{Deterministic: true}); err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func hash(key string, ts hlc.Timestamp) uint64 {
+	h := fnv.New64a()
+	fmt.Fprint(h, key, ts.Physical, ts.Logical, ts.Node)
+	return h.Sum64()
+}
+
+// normalize returns e with each component's HLC, and its labels', explicit,
+// as a merge makes it, so a legacy replica compares equal to its merge
+// with itself.
+func normalize(e *entityv1.Entity) *entityv1.Entity {
+	out := proto.Clone(e).(*entityv1.Entity)
+	if len(out.Labels) > 0 && out.LabelsHlc == nil {
+		out.LabelsHlc = stamp(entityHLC(out))
+	}
+	for key := range out.Components {
+		if out.ComponentHlc == nil {
+			out.ComponentHlc = make(map[string]*entityv1.HLCTimestamp)
+		}
+		if _, ok := out.ComponentHlc[key]; !ok {
+			out.ComponentHlc[key] = stamp(entityHLC(out))
+		}
+	}
+	return out
+}
+
+func text(e *entityv1.Entity) string {
+	return prototext.MarshalOptions{}.Format(e)
+}
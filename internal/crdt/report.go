@@ -0,0 +1,60 @@
+package crdt
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Side is which of the two entities passed to MergeEntityReport a merged
+// component came from.
+type Side string
+
+const (
+	SideA      Side = "a"      // the first entity's value
+	SideB      Side = "b"      // the second entity's value
+	SideBoth   Side = "both"   // both held the same value
+	SideMerged Side = "merged" // a new value made from both, such as a union
+	SideNone   Side = "none"   // neither: the component is removed
+)
+
+// Decision is how a merge resolved one component key.
+type Decision struct {
+	Key  string
+	Kept Side
+	// Strategy is the strategy that chose between two different values;
+	// empty if only one side held a value.
+	Strategy Strategy
+	// Removed is whether a tombstone dropped either side's value.
+	Removed bool
+}
+
+// String describes d for logs, such as "threat: kept b by max-wins".
+func (d Decision) String() string {
+	switch {
+	case d.Strategy != "":
+		return fmt.Sprintf("%s: kept %s by %s", d.Key, d.Kept, d.Strategy)
+	case d.Kept == SideNone:
+		return d.Key + ": removed"
+	case d.Removed:
+		return fmt.Sprintf("%s: kept %s, set since the other's was removed", d.Key, d.Kept)
+	case d.Kept == SideBoth:
+		return fmt.Sprintf("%s: kept %s, with the later tombstone", d.Key, d.Kept)
+	}
+	return fmt.Sprintf("%s: kept %s, the only value", d.Key, d.Kept)
+}
+
+// MergeReport records the decisions a merge made, for forensics after a
+// partition: for each component key on which the two entities differed,
+// in value or in tombstone, what the merged entity kept and why.
+type MergeReport struct {
+	Decisions []Decision // by key
+}
+
+// String describes every decision, separated by semicolons.
+func (m *MergeReport) String() string {
+	parts := make([]string, len(m.Decisions))
+	for i, d := range m.Decisions {
+		parts[i] = d.String()
+	}
+	return strings.Join(parts, "; ")
+}
@@ -0,0 +1,411 @@
+package crdt
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Strategy names how MergeEntity resolves a component key both entities
+// hold.
+type Strategy string
+
+const (
+	// LWW keeps the component with the higher HLC.
+	LWW Strategy = "lww"
+	// MaxWins keeps the component with the higher value, whatever its HLC;
+	// threat's level.
+	MaxWins Strategy = "max-wins"
+	// MinWins keeps the component with the lower value, whatever its HLC.
+	MinWins Strategy = "min-wins"
+	// SetUnion keeps the later component, by HLC, with the elements of
+	// the other's repeated fields it lacks added to its own: a grow-only
+	// set, fusion's source IDs.
+	SetUnion Strategy = "set-union"
+	// PNCounter adds up the counts each node made: for a CounterComponent,
+	// each node's increments and decrements are the larger of the two
+	// sides', since a node's own totals only grow.
+	PNCounter Strategy = "pn-counter"
+)
+
+// Resolver merges a and b, the values two entities hold for one component
+// key, set at hlcA and hlcB, into the value the merge keeps: a, b, or a
+// new value. It must give the same result whichever order it is passed
+// them in, or replicas will not converge.
+type Resolver func(a, b *anypb.Any, hlcA, hlcB hlc.Timestamp) *anypb.Any
+
+// Registry maps component keys to the strategies that resolve them, and
+// strategies to their resolvers. Keys not assigned one are resolved LWW.
+// Every store and relay in a mesh must use the same assignments, or their
+// merges will disagree. Safe for concurrent use.
+type Registry struct {
+	mu         sync.RWMutex
+	resolvers  map[Strategy]Resolver
+	strategies map[string]Strategy // by component key
+}
+
+// Default is the registry the package-level functions use: the built-in
+// strategies, with threat resolved max-wins, fusion set-union, and
+// detections_count pn-counter.
+var Default = NewRegistry()
+
+// NewRegistry returns a registry with the built-in strategies, LWW,
+// MaxWins, MinWins, SetUnion, and PNCounter; threat assigned MaxWins, so a
+// raised level is not lost to a stale copy; fusion SetUnion, so a fused
+// track's sources are the union of those any replica fused it from; and
+// detections_count PNCounter, so no node's detections are lost.
+func NewRegistry() *Registry {
+	return &Registry{
+		resolvers: map[Strategy]Resolver{
+			LWW:       resolveLWW,
+			MaxWins:   resolveMaxWins,
+			MinWins:   resolveMinWins,
+			SetUnion:  resolveSetUnion,
+			PNCounter: resolveCounter,
+		},
+		strategies: map[string]Strategy{"threat": MaxWins, "fusion": SetUnion, "detections_count": PNCounter},
+	}
+}
+
+// ParseRegistry returns a new registry with the assignments in spec,
+// comma-separated key=strategy pairs such as
+// "threat=max-wins,fusion=set-union", made on top of NewRegistry's.
+func ParseRegistry(spec string) (*Registry, error) {
+	r := NewRegistry()
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		key, name, ok := strings.Cut(part, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("merge strategy %q: want key=strategy", part)
+		}
+		if err := r.Assign(key, Strategy(name)); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Register adds a custom strategy, resolved by fn, for Assign to give
+// component keys. A name already registered is refused.
+func (r *Registry) Register(name Strategy, fn Resolver) error {
+	if name == "" || fn == nil {
+		return fmt.Errorf("register merge strategy %q: name and resolver are required", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.resolvers[name]; ok {
+		return fmt.Errorf("merge strategy %q is already registered", name)
+	}
+	r.resolvers[name] = fn
+	return nil
+}
+
+// Assign resolves component key with the registered strategy name from
+// now on.
+func (r *Registry) Assign(key string, name Strategy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.resolvers[name]; !ok {
+		return fmt.Errorf("merge strategy %q for %q: unknown strategy (have %s)", name, key, strings.Join(r.namesLocked(), ", "))
+	}
+	r.strategies[key] = name
+	return nil
+}
+
+func (r *Registry) namesLocked() []string {
+	names := make([]string, 0, len(r.resolvers))
+	for name := range r.resolvers {
+		names = append(names, string(name))
+	}
+	slices.Sort(names)
+	return names
+}
+
+// StrategyFor returns the strategy that resolves component key.
+func (r *Registry) StrategyFor(key string) Strategy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name, ok := r.strategies[key]; ok {
+		return name
+	}
+	return LWW
+}
+
+// resolve merges two values of component key with its strategy.
+func (r *Registry) resolve(key string, a, b *anypb.Any, hlcA, hlcB hlc.Timestamp) *anypb.Any {
+	r.mu.RLock()
+	fn := r.resolvers[r.strategies[key]]
+	r.mu.RUnlock()
+	if fn == nil {
+		fn = resolveLWW
+	}
+	return fn(a, b, hlcA, hlcB)
+}
+
+// StrategyFor returns the strategy that resolves component key in the
+// Default registry.
+func StrategyFor(key string) Strategy {
+	return Default.StrategyFor(key)
+}
+
+// resolveLWW keeps the value with the higher HLC. On a tie b wins,
+// arbitrary but deterministic since the HLC includes the node for total
+// ordering.
+func resolveLWW(a, b *anypb.Any, hlcA, hlcB hlc.Timestamp) *anypb.Any {
+	if hlcA.After(hlcB) {
+		return a
+	}
+	return b
+}
+
+func resolveMaxWins(a, b *anypb.Any, hlcA, hlcB hlc.Timestamp) *anypb.Any {
+	return resolveRanked(a, b, hlcA, hlcB, 1)
+}
+
+func resolveMinWins(a, b *anypb.Any, hlcA, hlcB hlc.Timestamp) *anypb.Any {
+	return resolveRanked(a, b, hlcA, hlcB, -1)
+}
+
+// resolveRanked keeps the value whose rank, times sign, is higher; on
+// equal ranks, the one with the higher HLC. A value that cannot be ranked
+// loses to one that can.
+func resolveRanked(a, b *anypb.Any, hlcA, hlcB hlc.Timestamp, sign float64) *anypb.Any {
+	rankA, okA := rank(a)
+	rankB, okB := rank(b)
+	switch {
+	case !okA && !okB:
+		return resolveLWW(a, b, hlcA, hlcB)
+	case !okA:
+		return b
+	case !okB:
+		return a
+	case rankA*sign > rankB*sign:
+		return a
+	case rankB*sign > rankA*sign:
+		return b
+	}
+	return resolveLWW(a, b, hlcA, hlcB)
+}
+
+// rank returns the value max-wins and min-wins compare a component by: its
+// first field, by field number, if that is a number or an enum, as with
+// ThreatComponent's level or a wrapped number. Reports false if the
+// component has none or cannot be decoded.
+func rank(c *anypb.Any) (float64, bool) {
+	m, err := c.UnmarshalNew()
+	if err != nil {
+		return 0, false
+	}
+	msg := m.ProtoReflect()
+	fields := msg.Descriptor().Fields()
+	if fields.Len() == 0 {
+		return 0, false
+	}
+	first := fields.Get(0)
+	for i := 1; i < fields.Len(); i++ {
+		if f := fields.Get(i); f.Number() < first.Number() {
+			first = f
+		}
+	}
+	if first.IsList() || first.IsMap() {
+		return 0, false
+	}
+	v := msg.Get(first)
+	switch first.Kind() {
+	case protoreflect.EnumKind:
+		return float64(v.Enum()), true
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return float64(v.Int()), true
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return float64(v.Uint()), true
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// resolveSetUnion keeps the LWW winner with the elements of the loser's
+// repeated fields it lacks added to its own, so neither side's additions
+// are lost. Lists it adds to are sorted, so replicas that merged the same
+// copies in a different order hold the same bytes. Elements are never
+// removed: to reset the set, remove the component. Values of different
+// types, or that cannot be decoded, are resolved LWW.
+func resolveSetUnion(a, b *anypb.Any, hlcA, hlcB hlc.Timestamp) *anypb.Any {
+	win, lose := b, a
+	if hlcA.After(hlcB) {
+		win, lose = a, b
+	}
+	if win.GetTypeUrl() != lose.GetTypeUrl() {
+		return win
+	}
+	w, err := win.UnmarshalNew()
+	if err != nil {
+		return win
+	}
+	l, err := lose.UnmarshalNew()
+	if err != nil {
+		return win
+	}
+	if !union(w.ProtoReflect(), l.ProtoReflect()) {
+		return win
+	}
+	out, err := anypb.New(w)
+	if err != nil {
+		return win
+	}
+	return out
+}
+
+// union adds to each repeated field of dst the elements of src's it
+// lacks, sorting the fields it adds to, and reports whether it added any.
+func union(dst, src protoreflect.Message) bool {
+	added := false
+	fields := dst.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		if !f.IsList() {
+			continue
+		}
+		have, more := dst.Mutable(f).List(), src.Get(f).List()
+		grew := false
+		for j := 0; j < more.Len(); j++ {
+			v := more.Get(j)
+			if !listHas(have, v, f.Kind()) {
+				have.Append(v)
+				grew = true
+			}
+		}
+		if grew {
+			sortList(have, f.Kind())
+			added = true
+		}
+	}
+	return added
+}
+
+// sortList sorts l in place: scalars by value, messages by their
+// deterministic encoding.
+func sortList(l protoreflect.List, kind protoreflect.Kind) {
+	values := make([]protoreflect.Value, l.Len())
+	for i := range values {
+		values[i] = l.Get(i)
+	}
+	slices.SortStableFunc(values, func(a, b protoreflect.Value) int {
+		return compareValues(a, b, kind)
+	})
+	for i, v := range values {
+		l.Set(i, v)
+	}
+}
+
+func compareValues(a, b protoreflect.Value, kind protoreflect.Kind) int {
+	switch kind {
+	case protoreflect.StringKind:
+		return cmp.Compare(a.String(), b.String())
+	case protoreflect.BytesKind:
+		return bytes.Compare(a.Bytes(), b.Bytes())
+	case protoreflect.BoolKind:
+		return cmp.Compare(boolRank(a.Bool()), boolRank(b.Bool()))
+	case protoreflect.EnumKind:
+		return cmp.Compare(a.Enum(), b.Enum())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return cmp.Compare(a.Int(), b.Int())
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return cmp.Compare(a.Uint(), b.Uint())
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return cmp.Compare(a.Float(), b.Float())
+	}
+	opts := proto.MarshalOptions{Deterministic: true}
+	x, _ := opts.Marshal(a.Message().Interface())
+	y, _ := opts.Marshal(b.Message().Interface())
+	return bytes.Compare(x, y)
+}
+
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func listHas(l protoreflect.List, v protoreflect.Value, kind protoreflect.Kind) bool {
+	for i := 0; i < l.Len(); i++ {
+		if kind == protoreflect.MessageKind || kind == protoreflect.GroupKind {
+			if proto.Equal(l.Get(i).Message().Interface(), v.Message().Interface()) {
+				return true
+			}
+		} else if l.Get(i).Equal(v) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveCounter merges two CounterComponents, taking each node's larger
+// increments and decrements. Values that are not both counters are
+// resolved LWW.
+func resolveCounter(a, b *anypb.Any, hlcA, hlcB hlc.Timestamp) *anypb.Any {
+	var ca, cb entityv1.CounterComponent
+	if a.UnmarshalTo(&ca) != nil || b.UnmarshalTo(&cb) != nil {
+		return resolveLWW(a, b, hlcA, hlcB)
+	}
+	merged := &entityv1.CounterComponent{
+		Increments: maxCounts(ca.Increments, cb.Increments),
+		Decrements: maxCounts(ca.Decrements, cb.Decrements),
+	}
+	// A side that already holds the merged counts is kept only if it is
+	// also the later, as a new value gets the later stamp: the stamp must
+	// not depend on which copies were merged first.
+	switch {
+	case !hlcA.After(hlcB) && proto.Equal(merged, &cb):
+		return b
+	case hlcA.After(hlcB) && proto.Equal(merged, &ca):
+		return a
+	}
+	// Encoded deterministically, as maps otherwise are not, so replicas
+	// that merged the same counts hold the same bytes.
+	out := &anypb.Any{}
+	if err := anypb.MarshalFrom(out, merged, proto.MarshalOptions{Deterministic: true}); err != nil {
+		return resolveLWW(a, b, hlcA, hlcB)
+	}
+	return out
+}
+
+// maxCounts returns each node's larger count in a and b.
+func maxCounts(a, b map[string]uint64) map[string]uint64 {
+	out := make(map[string]uint64, max(len(a), len(b)))
+	for node, n := range a {
+		out[node] = n
+	}
+	for node, n := range b {
+		out[node] = max(out[node], n)
+	}
+	return out
+}
+
+// CounterValue returns a counter's value: its increments less its
+// decrements, summed over every node.
+func CounterValue(c *entityv1.CounterComponent) int64 {
+	var v int64
+	for _, n := range c.GetIncrements() {
+		v += int64(n)
+	}
+	for _, n := range c.GetDecrements() {
+		v -= int64(n)
+	}
+	return v
+}
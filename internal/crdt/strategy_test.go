@@ -0,0 +1,195 @@
+package crdt
+
+import (
+	"slices"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestParseRegistry(t *testing.T) {
+	r, err := ParseRegistry("speed=min-wins, catalog=set-union,threat=lww")
+	if err != nil {
+		t.Fatalf("ParseRegistry: %v", err)
+	}
+	for key, want := range map[string]Strategy{"speed": MinWins, "catalog": SetUnion, "threat": LWW, "position": LWW} {
+		if got := r.StrategyFor(key); got != want {
+			t.Errorf("%s: got %s, want %s", key, got, want)
+		}
+	}
+	if got := NewRegistry().StrategyFor("threat"); got != MaxWins {
+		t.Errorf("default threat: got %s, want max-wins", got)
+	}
+	if got := NewRegistry().StrategyFor("fusion"); got != SetUnion {
+		t.Errorf("default fusion: got %s, want set-union", got)
+	}
+	for _, bad := range []string{"speed", "=lww", "speed=median"} {
+		if _, err := ParseRegistry(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestRegistry_MinWins(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Assign("speed", MinWins); err != nil {
+		t.Fatal(err)
+	}
+	a := makeEntity("e1", hlcTS(100, 0, "nodeA"), map[string]proto.Message{"speed": wrapperspb.Double(3)})
+	b := makeEntity("e1", hlcTS(200, 0, "nodeB"), map[string]proto.Message{"speed": wrapperspb.Double(7)})
+
+	for _, result := range []*entityv1.Entity{r.MergeEntity(a, b), r.MergeEntity(b, a)} {
+		var got wrapperspb.DoubleValue
+		if err := result.Components["speed"].UnmarshalTo(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Value != 3 {
+			t.Fatalf("expected the lower speed to win despite its older HLC, got %v", got.Value)
+		}
+	}
+	// The package-level merge still resolves speed LWW.
+	var got wrapperspb.DoubleValue
+	MergeEntity(a, b).Components["speed"].UnmarshalTo(&got) //nolint:errcheck
+	if got.Value != 7 {
+		t.Fatalf("expected Default to resolve speed LWW, got %v", got.Value)
+	}
+}
+
+func TestRegistry_SetUnion(t *testing.T) {
+	r := NewRegistry()
+	if err := r.Assign("catalog", SetUnion); err != nil {
+		t.Fatal(err)
+	}
+	a := makeEntity("e1", hlcTS(100, 0, "nodeA"), map[string]proto.Message{
+		"catalog": &entityv1.TaskCatalogComponent{AvailableTasks: []string{"track", "jam"}},
+	})
+	b := makeEntity("e1", hlcTS(200, 0, "nodeB"), map[string]proto.Message{
+		"catalog": &entityv1.TaskCatalogComponent{AvailableTasks: []string{"track", "strike"}},
+	})
+
+	for _, result := range []*entityv1.Entity{r.MergeEntity(a, b), r.MergeEntity(b, a)} {
+		var got entityv1.TaskCatalogComponent
+		if err := result.Components["catalog"].UnmarshalTo(&got); err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{"jam", "strike", "track"}; !slices.Equal(got.AvailableTasks, want) {
+			t.Fatalf("expected the union %v, got %v", want, got.AvailableTasks)
+		}
+		if got := result.ComponentHlc["catalog"]; got.GetPhysical() != 200 {
+			t.Fatalf("expected the union stamped with the later HLC, got %v", got)
+		}
+	}
+}
+
+func TestMergeEntity_FusionSourcesUnion(t *testing.T) {
+	// Three nodes fused the same track from different sources.
+	fused := func(ts hlc.Timestamp, lat float64, sources ...string) *entityv1.Entity {
+		return makeEntity("fused-1", ts, map[string]proto.Message{
+			"fusion": &entityv1.FusionComponent{SourceIds: sources, FusedLat: lat},
+		})
+	}
+	a := fused(hlcTS(100, 0, "nodeA"), 1, "radar-1", "ais-7")
+	b := fused(hlcTS(200, 0, "nodeB"), 2, "radar-1", "adsb-3")
+	c := fused(hlcTS(300, 0, "nodeC"), 3, "eo-2")
+
+	// Merged in any order, every replica ends up with the same bytes: the
+	// union of the sources, sorted, and the latest fusion's position.
+	var want *anypb.Any
+	for i, result := range []*entityv1.Entity{
+		MergeEntity(MergeEntity(a, b), c),
+		MergeEntity(a, MergeEntity(b, c)),
+		MergeEntity(MergeEntity(c, a), b),
+		MergeEntity(b, MergeEntity(c, a)),
+	} {
+		got := result.Components["fusion"]
+		if want == nil {
+			want = got
+			var fc entityv1.FusionComponent
+			if err := got.UnmarshalTo(&fc); err != nil {
+				t.Fatal(err)
+			}
+			if sources := []string{"adsb-3", "ais-7", "eo-2", "radar-1"}; !slices.Equal(fc.SourceIds, sources) || fc.FusedLat != 3 {
+				t.Fatalf("expected sources %v at lat 3, got %v at %v", sources, fc.SourceIds, fc.FusedLat)
+			}
+			continue
+		}
+		if !proto.Equal(got, want) {
+			t.Fatalf("order %d: got %v, want %v", i, got, want)
+		}
+	}
+}
+
+func TestRegistry_Register(t *testing.T) {
+	r := NewRegistry()
+	// Concatenates the two strings in order, so either argument order
+	// gives the same result.
+	concat := func(a, b *anypb.Any, _, _ hlc.Timestamp) *anypb.Any {
+		var x, y wrapperspb.StringValue
+		a.UnmarshalTo(&x) //nolint:errcheck
+		b.UnmarshalTo(&y) //nolint:errcheck
+		lo, hi := min(x.Value, y.Value), max(x.Value, y.Value)
+		out, _ := anypb.New(wrapperspb.String(lo + hi))
+		return out
+	}
+	if err := r.Assign("note", "concat"); err == nil {
+		t.Fatal("expected an unregistered strategy refused")
+	}
+	if err := r.Register("concat", concat); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Register(LWW, concat); err == nil {
+		t.Fatal("expected a built-in strategy not to be replaced")
+	}
+	if err := r.Assign("note", "concat"); err != nil {
+		t.Fatal(err)
+	}
+
+	a := makeEntity("e1", hlcTS(100, 0, "nodeA"), map[string]proto.Message{"note": wrapperspb.String("b")})
+	b := makeEntity("e1", hlcTS(200, 0, "nodeB"), map[string]proto.Message{"note": wrapperspb.String("a")})
+	var got wrapperspb.StringValue
+	r.MergeEntity(a, b).Components["note"].UnmarshalTo(&got) //nolint:errcheck
+	if got.Value != "ab" {
+		t.Fatalf("expected the custom resolver's value, got %q", got.Value)
+	}
+	if got := r.Conflicts(a, b)["note"]; got != "concat" {
+		t.Fatalf("expected the conflict classified concat, got %q", got)
+	}
+}
+
+func TestRegistry_PNCounter(t *testing.T) {
+	counter := func(inc, dec map[string]uint64) *entityv1.CounterComponent {
+		return &entityv1.CounterComponent{Increments: inc, Decrements: dec}
+	}
+	// A and B each counted detections of their own, and A has seen an
+	// older copy of B's count.
+	a := makeEntity("e1", hlcTS(100, 0, "nodeA"), map[string]proto.Message{
+		"detections_count": counter(map[string]uint64{"nodeA": 5, "nodeB": 1}, map[string]uint64{"nodeA": 1}),
+	})
+	b := makeEntity("e1", hlcTS(200, 0, "nodeB"), map[string]proto.Message{
+		"detections_count": counter(map[string]uint64{"nodeB": 3}, nil),
+	})
+
+	var want *anypb.Any
+	for _, result := range []*entityv1.Entity{MergeEntity(a, b), MergeEntity(b, a)} {
+		got := result.Components["detections_count"]
+		var c entityv1.CounterComponent
+		if err := got.UnmarshalTo(&c); err != nil {
+			t.Fatal(err)
+		}
+		if v := CounterValue(&c); v != 7 {
+			t.Fatalf("expected 5+3-1 = 7, got %d from %v", v, &c)
+		}
+		if want != nil && !proto.Equal(got, want) {
+			t.Fatalf("expected either order to give the same counter, got %v and %v", got, want)
+		}
+		want = got
+	}
+	// Merging a counter with itself changes nothing.
+	if got := MergeEntity(a, a).Components["detections_count"]; !proto.Equal(got, a.Components["detections_count"]) {
+		t.Fatalf("expected an idempotent merge, got %v", got)
+	}
+}
@@ -0,0 +1,135 @@
+// Package divergence samples several entity-store nodes and reports where
+// they disagree: entities missing from some nodes, differing threat levels,
+// and differing components. It turns the convergence checks of the mesh
+// partition tests into an operational monitor.
+package divergence
+
+import (
+	"sort"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// Reason is one way an entity differs between nodes.
+type Reason string
+
+const (
+	ReasonMissing    Reason = "missing"    // absent from some nodes
+	ReasonThreat     Reason = "threat"     // threat levels differ
+	ReasonComponents Reason = "components" // component keys or values differ
+)
+
+// AllReasons lists every reason, in the order they are reported.
+var AllReasons = []Reason{ReasonMissing, ReasonThreat, ReasonComponents}
+
+// Divergence describes one entity the sampled nodes disagree on.
+type Divergence struct {
+	ID      string                          `json:"id"`
+	Reasons []Reason                        `json:"reasons"`
+	Missing []string                        `json:"missing,omitempty"` // nodes without the entity
+	Threat  map[string]entityv1.ThreatLevel `json:"threat,omitempty"`  // per node, when levels differ
+	// HLCSkew is the spread of the entity's HLC physical time across the
+	// nodes that hold it: how far the most stale copy trails the newest.
+	HLCSkew time.Duration `json:"hlc_skew"`
+	Since   time.Time     `json:"since"` // first sample it was seen diverged in
+}
+
+// Compare returns the entities the nodes disagree on, sorted by ID. The
+// snapshot maps node address to that node's entities. Since is left zero.
+func Compare(snapshot map[string][]*entityv1.Entity) []Divergence {
+	nodes := make([]string, 0, len(snapshot))
+	byNode := make(map[string]map[string]*entityv1.Entity, len(snapshot))
+	ids := map[string]bool{}
+	for node, entities := range snapshot {
+		nodes = append(nodes, node)
+		m := make(map[string]*entityv1.Entity, len(entities))
+		for _, e := range entities {
+			m[e.Id] = e
+			ids[e.Id] = true
+		}
+		byNode[node] = m
+	}
+	sort.Strings(nodes)
+
+	var out []Divergence
+	for id := range ids {
+		d := Divergence{ID: id}
+		var held []*entityv1.Entity
+		var holders []string
+		for _, node := range nodes {
+			if e, ok := byNode[node][id]; ok {
+				held = append(held, e)
+				holders = append(holders, node)
+			} else {
+				d.Missing = append(d.Missing, node)
+			}
+		}
+		if len(d.Missing) > 0 {
+			d.Reasons = append(d.Reasons, ReasonMissing)
+		}
+		if !sameThreat(held) {
+			d.Reasons = append(d.Reasons, ReasonThreat)
+			d.Threat = make(map[string]entityv1.ThreatLevel, len(held))
+			for i, e := range held {
+				d.Threat[holders[i]] = threatLevel(e)
+			}
+		}
+		if !sameComponents(held) {
+			d.Reasons = append(d.Reasons, ReasonComponents)
+		}
+		if len(d.Reasons) == 0 {
+			continue
+		}
+		d.HLCSkew = hlcSkew(held)
+		out = append(out, d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func sameThreat(entities []*entityv1.Entity) bool {
+	for _, e := range entities[1:] {
+		if threatLevel(e) != threatLevel(entities[0]) {
+			return false
+		}
+	}
+	return true
+}
+
+func sameComponents(entities []*entityv1.Entity) bool {
+	ref := entities[0].Components
+	for _, e := range entities[1:] {
+		if len(e.Components) != len(ref) {
+			return false
+		}
+		for key, c := range e.Components {
+			if !proto.Equal(c, ref[key]) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func hlcSkew(entities []*entityv1.Entity) time.Duration {
+	lo, hi := entities[0].HlcPhysical, entities[0].HlcPhysical
+	for _, e := range entities[1:] {
+		lo, hi = min(lo, e.HlcPhysical), max(hi, e.HlcPhysical)
+	}
+	return time.Duration(hi - lo)
+}
+
+// threatLevel returns an entity's threat level, or UNSPECIFIED without one.
+func threatLevel(e *entityv1.Entity) entityv1.ThreatLevel {
+	c, ok := e.Components["threat"]
+	if !ok {
+		return entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED
+	}
+	var tc entityv1.ThreatComponent
+	if err := c.UnmarshalTo(&tc); err != nil {
+		return entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED
+	}
+	return tc.Level
+}
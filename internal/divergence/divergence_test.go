@@ -0,0 +1,59 @@
+package divergence
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func track(id string, hlc time.Duration, level entityv1.ThreatLevel, lat float64) *entityv1.Entity {
+	threat, _ := anypb.New(&entityv1.ThreatComponent{Level: level})
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: lat})
+	return &entityv1.Entity{
+		Id:          id,
+		Type:        entityv1.EntityType_ENTITY_TYPE_TRACK,
+		HlcPhysical: uint64(hlc),
+		Components:  map[string]*anypb.Any{"threat": threat, "position": pos},
+	}
+}
+
+func TestCompare(t *testing.T) {
+	low, high := entityv1.ThreatLevel_THREAT_LEVEL_LOW, entityv1.ThreatLevel_THREAT_LEVEL_HIGH
+	got := Compare(map[string][]*entityv1.Entity{
+		"a": {track("same", 1*time.Second, low, 1), track("threat", 1*time.Second, low, 1), track("pos", 1*time.Second, low, 1), track("only-a", 0, low, 1)},
+		"b": {track("same", 5*time.Second, low, 1), track("threat", 3*time.Second, high, 1), track("pos", 1*time.Second, low, 2)},
+		"c": {track("same", 2*time.Second, low, 1), track("threat", 2*time.Second, low, 1), track("pos", 1*time.Second, low, 1)},
+	})
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 diverged entities (differing HLCs alone do not count), got %+v", got)
+	}
+	only, pos, threat := got[0], got[1], got[2]
+	if only.ID != "only-a" || !slices.Equal(only.Reasons, []Reason{ReasonMissing}) || !slices.Equal(only.Missing, []string{"b", "c"}) {
+		t.Fatalf("unexpected missing divergence %+v", only)
+	}
+	if pos.ID != "pos" || !slices.Equal(pos.Reasons, []Reason{ReasonComponents}) || pos.Threat != nil {
+		t.Fatalf("unexpected component divergence %+v", pos)
+	}
+	if threat.ID != "threat" || !slices.Equal(threat.Reasons, []Reason{ReasonThreat, ReasonComponents}) ||
+		threat.Threat["b"] != high || threat.Threat["a"] != low {
+		t.Fatalf("unexpected threat divergence %+v", threat)
+	}
+	if threat.HLCSkew != 2*time.Second {
+		t.Fatalf("expected 2s HLC skew, got %s", threat.HLCSkew)
+	}
+}
+
+func TestCompare_Converged(t *testing.T) {
+	low := entityv1.ThreatLevel_THREAT_LEVEL_LOW
+	got := Compare(map[string][]*entityv1.Entity{
+		"a": {track("t", 0, low, 1)},
+		"b": {track("t", time.Second, low, 1)},
+	})
+	if len(got) != 0 {
+		t.Fatalf("expected no divergence, got %+v", got)
+	}
+}
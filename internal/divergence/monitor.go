@@ -0,0 +1,324 @@
+package divergence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"github.com/boshu2/lattice-lab/internal/notify"
+)
+
+// Config controls the monitor.
+type Config struct {
+	Nodes    []string      // store addresses to compare
+	Interval time.Duration // between samples
+	Grace    time.Duration // how long an entity may stay diverged before it alerts
+	Timeout  time.Duration // per-node ListEntities deadline
+	Listen   string        // HTTP address for /metrics and /divergence; empty disables
+	Sinks    []notify.Sink // alert destinations; alerts are always logged
+}
+
+// DefaultConfig returns a config sampling every 5s with a 30s grace period.
+func DefaultConfig() Config {
+	return Config{
+		Interval: 5 * time.Second,
+		Grace:    30 * time.Second,
+		Timeout:  5 * time.Second,
+		Listen:   ":9464",
+	}
+}
+
+// Validate checks the config is runnable.
+func (cfg Config) Validate() error {
+	switch {
+	case len(cfg.Nodes) < 2:
+		return fmt.Errorf("at least two store nodes are required")
+	case cfg.Interval <= 0 || cfg.Timeout <= 0:
+		return fmt.Errorf("interval and timeout must be positive")
+	case cfg.Grace < 0:
+		return fmt.Errorf("grace must not be negative")
+	}
+	return nil
+}
+
+// NodeReport is the outcome of sampling one node.
+type NodeReport struct {
+	Addr     string `json:"addr"`
+	Up       bool   `json:"up"`
+	Entities int    `json:"entities"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Report is the result of the latest sample.
+type Report struct {
+	Time       time.Time    `json:"time"`
+	Nodes      []NodeReport `json:"nodes"`
+	Compared   bool         `json:"compared"` // false when fewer than two nodes answered
+	Diverged   []Divergence `json:"diverged"`
+	Persistent int          `json:"persistent"` // diverged for longer than the grace period
+	Samples    int          `json:"samples"`
+	Alerts     int          `json:"alerts"`
+}
+
+// Monitor periodically samples the nodes and tracks how long each entity
+// has been diverged.
+type Monitor struct {
+	cfg Config
+
+	mu       sync.Mutex
+	since    map[string]time.Time // entity ID → first sample it was diverged in
+	alerting bool                 // an unresolved divergence alert was sent
+	report   Report
+}
+
+// New creates a monitor with the given config.
+func New(cfg Config) *Monitor {
+	return &Monitor{cfg: cfg, since: make(map[string]time.Time)}
+}
+
+// Report returns the latest sample's report.
+func (m *Monitor) Report() Report {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.report
+}
+
+// Run samples the nodes every interval and serves metrics until ctx is
+// cancelled.
+func (m *Monitor) Run(ctx context.Context) error {
+	clients := make(map[string]storev1.EntityStoreServiceClient, len(m.cfg.Nodes))
+	for _, addr := range m.cfg.Nodes {
+		conn, err := client.Dial(addr, client.WithoutRetries())
+		if err != nil {
+			return fmt.Errorf("connect to %s: %w", addr, err)
+		}
+		defer conn.Close()
+		clients[addr] = storev1.NewEntityStoreServiceClient(conn)
+	}
+
+	if m.cfg.Listen != "" {
+		lis, err := net.Listen("tcp", m.cfg.Listen)
+		if err != nil {
+			return fmt.Errorf("listen metrics: %w", err)
+		}
+		srv := &http.Server{Handler: m.Handler()}
+		go srv.Serve(lis) //nolint:errcheck
+		defer srv.Close()
+		slog.Info("divergence-monitor serving metrics", "addr", lis.Addr().String())
+	}
+
+	slog.Info("divergence-monitor started", "nodes", m.cfg.Nodes, "interval", m.cfg.Interval, "grace", m.cfg.Grace)
+
+	ticker := time.NewTicker(m.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		m.sample(ctx, clients, time.Now())
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// sample lists every node, compares them, and alerts on divergence that
+// outlived the grace period.
+func (m *Monitor) sample(ctx context.Context, clients map[string]storev1.EntityStoreServiceClient, now time.Time) {
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		snapshot = make(map[string][]*entityv1.Entity, len(clients))
+		nodes    = make([]NodeReport, len(m.cfg.Nodes))
+	)
+	for i, addr := range m.cfg.Nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reqCtx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+			defer cancel()
+			resp, err := clients[addr].ListEntities(reqCtx, &storev1.ListEntitiesRequest{})
+			if err != nil {
+				nodes[i] = NodeReport{Addr: addr, Error: err.Error()}
+				return
+			}
+			nodes[i] = NodeReport{Addr: addr, Up: true, Entities: len(resp.Entities)}
+			mu.Lock()
+			snapshot[addr] = resp.Entities
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	prev := m.report
+	m.report = Report{Time: now, Nodes: nodes, Samples: prev.Samples + 1, Alerts: prev.Alerts}
+	if len(snapshot) < 2 {
+		// Nothing to compare; keep tracking state until the nodes are back.
+		slog.Warn("divergence sample skipped, too few nodes answered", "up", len(snapshot))
+		return
+	}
+
+	diverged := Compare(snapshot)
+	seen := make(map[string]bool, len(diverged))
+	var persistent []Divergence
+	for i := range diverged {
+		d := &diverged[i]
+		seen[d.ID] = true
+		since, ok := m.since[d.ID]
+		if !ok {
+			since = now
+			m.since[d.ID] = now
+		}
+		d.Since = since
+		if now.Sub(since) >= m.cfg.Grace {
+			persistent = append(persistent, *d)
+		}
+	}
+	for id := range m.since {
+		if !seen[id] {
+			delete(m.since, id)
+		}
+	}
+	m.report.Compared = true
+	m.report.Diverged = diverged
+	m.report.Persistent = len(persistent)
+
+	switch {
+	case len(persistent) > 0 && !m.alerting:
+		m.alerting = true
+		m.report.Alerts++
+		m.alert(ctx, notify.Notification{
+			Kind:    notify.KindDivergence,
+			Subject: strings.Join(m.cfg.Nodes, ","),
+			Title:   fmt.Sprintf("%d entities diverged across store nodes", len(persistent)),
+			Detail:  describe(persistent, m.cfg.Grace),
+			Time:    now,
+		})
+	case len(persistent) == 0 && m.alerting:
+		m.alerting = false
+		m.report.Alerts++
+		m.alert(ctx, notify.Notification{
+			Kind:    notify.KindDivergence,
+			Subject: strings.Join(m.cfg.Nodes, ","),
+			Title:   "Store nodes converged",
+			Time:    now,
+		})
+	}
+}
+
+// describe summarises persistent divergences for an alert, listing the
+// first few entities.
+func describe(persistent []Divergence, grace time.Duration) string {
+	const maxListed = 5
+	var b strings.Builder
+	fmt.Fprintf(&b, "Diverged for over %s:", grace)
+	for i, d := range persistent {
+		if i == maxListed {
+			fmt.Fprintf(&b, "\n... and %d more", len(persistent)-maxListed)
+			break
+		}
+		var reasons []string
+		for _, r := range d.Reasons {
+			reasons = append(reasons, string(r))
+		}
+		fmt.Fprintf(&b, "\n%s (%s", d.ID, strings.Join(reasons, ", "))
+		if len(d.Missing) > 0 {
+			fmt.Fprintf(&b, "; missing on %s", strings.Join(d.Missing, ", "))
+		}
+		fmt.Fprintf(&b, "; HLC skew %s)", d.HLCSkew.Round(time.Millisecond))
+	}
+	return b.String()
+}
+
+// alert logs n and sends it to every sink. Sends happen in the
+// background so a slow sink does not delay sampling.
+func (m *Monitor) alert(ctx context.Context, n notify.Notification) {
+	slog.Warn("divergence alert", "title", n.Title, "detail", n.Detail)
+	for _, sink := range m.cfg.Sinks {
+		go func() {
+			sendCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+			defer cancel()
+			if err := sink.Send(sendCtx, n); err != nil && !errors.Is(err, context.Canceled) {
+				slog.Error("divergence alert failed", "sink", sink.Name(), "error", err)
+			}
+		}()
+	}
+}
+
+// Handler serves Prometheus text metrics, the monitor's and then the
+// process's, on /metrics and the latest report as JSON on /divergence.
+func (m *Monitor) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeMetrics(w, m.Report())
+		metrics.Default.Write(w)
+	})
+	mux.HandleFunc("/divergence", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(m.Report()) //nolint:errcheck
+	})
+	return mux
+}
+
+func writeMetrics(w http.ResponseWriter, r Report) {
+	metric := func(name, typ, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+
+	metric("lattice_divergence_node_up", "gauge", "Whether the node answered the last sample.")
+	for _, n := range r.Nodes {
+		fmt.Fprintf(w, "lattice_divergence_node_up{node=%q} %d\n", n.Addr, btoi(n.Up))
+	}
+	metric("lattice_divergence_node_entities", "gauge", "Entities the node held at the last sample.")
+	for _, n := range r.Nodes {
+		if n.Up {
+			fmt.Fprintf(w, "lattice_divergence_node_entities{node=%q} %d\n", n.Addr, n.Entities)
+		}
+	}
+
+	counts := make(map[Reason]int)
+	var skew time.Duration
+	for _, d := range r.Diverged {
+		for _, reason := range d.Reasons {
+			counts[reason]++
+		}
+		skew = max(skew, d.HLCSkew)
+	}
+	metric("lattice_divergence_entities", "gauge", "Entities the nodes disagree on, by reason.")
+	for _, reason := range AllReasons {
+		fmt.Fprintf(w, "lattice_divergence_entities{reason=%q} %d\n", reason, counts[reason])
+	}
+	metric("lattice_divergence_persistent_entities", "gauge", "Entities diverged for longer than the grace period.")
+	fmt.Fprintf(w, "lattice_divergence_persistent_entities %d\n", r.Persistent)
+	metric("lattice_divergence_max_hlc_skew_seconds", "gauge", "Largest HLC physical time spread of a diverged entity.")
+	fmt.Fprintf(w, "lattice_divergence_max_hlc_skew_seconds %g\n", skew.Seconds())
+	metric("lattice_divergence_samples_total", "counter", "Samples taken.")
+	fmt.Fprintf(w, "lattice_divergence_samples_total %d\n", r.Samples)
+	metric("lattice_divergence_alerts_total", "counter", "Divergence and convergence alerts raised.")
+	fmt.Fprintf(w, "lattice_divergence_alerts_total %d\n", r.Alerts)
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,175 @@
+package divergence
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/notify"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func startTestServer(t *testing.T) (string, *store.Store, func()) {
+	t.Helper()
+
+	s := store.New()
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+
+	return lis.Addr().String(), s, func() { srv.Stop() }
+}
+
+type memSink struct {
+	mu   sync.Mutex
+	sent []notify.Notification
+}
+
+func (m *memSink) Name() string { return "mem" }
+
+func (m *memSink) Send(_ context.Context, n notify.Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, n)
+	return nil
+}
+
+func (m *memSink) titles() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []string
+	for _, n := range m.sent {
+		out = append(out, n.Title)
+	}
+	return out
+}
+
+func dialAll(t *testing.T, addrs ...string) map[string]storev1.EntityStoreServiceClient {
+	t.Helper()
+	clients := make(map[string]storev1.EntityStoreServiceClient)
+	for _, addr := range addrs {
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		clients[addr] = storev1.NewEntityStoreServiceClient(conn)
+	}
+	return clients
+}
+
+func TestMonitor_GraceAlertAndResolve(t *testing.T) {
+	addrA, a, cleanupA := startTestServer(t)
+	defer cleanupA()
+	addrB, b, cleanupB := startTestServer(t)
+	defer cleanupB()
+	clients := dialAll(t, addrA, addrB)
+
+	sink := &memSink{}
+	cfg := DefaultConfig()
+	cfg.Nodes = []string{addrA, addrB}
+	cfg.Grace = 10 * time.Second
+	cfg.Sinks = []notify.Sink{sink}
+	m := New(cfg)
+	ctx := context.Background()
+	t0 := time.Now()
+
+	a.Create(&entityv1.Entity{Id: "t-1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}) //nolint:errcheck
+	m.sample(ctx, clients, t0)
+	r := m.Report()
+	if !r.Compared || len(r.Diverged) != 1 || r.Persistent != 0 || !r.Diverged[0].Since.Equal(t0) {
+		t.Fatalf("expected one fresh divergence, got %+v", r)
+	}
+
+	m.sample(ctx, clients, t0.Add(11*time.Second))
+	r = m.Report()
+	if r.Persistent != 1 || r.Alerts != 1 || r.Samples != 2 {
+		t.Fatalf("expected a persistent divergence and an alert, got %+v", r)
+	}
+	// Still diverged: no repeat alert.
+	m.sample(ctx, clients, t0.Add(12*time.Second))
+
+	b.Create(&entityv1.Entity{Id: "t-1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}) //nolint:errcheck
+	m.sample(ctx, clients, t0.Add(13*time.Second))
+	r = m.Report()
+	if len(r.Diverged) != 0 || r.Alerts != 2 {
+		t.Fatalf("expected convergence and a resolve alert, got %+v", r)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	titles := sink.titles()
+	if len(titles) != 2 || !strings.Contains(titles[0], "1 entities diverged") || titles[1] != "Store nodes converged" {
+		t.Fatalf("unexpected alerts %v", titles)
+	}
+}
+
+func TestMonitor_NodeDown(t *testing.T) {
+	addrA, a, cleanupA := startTestServer(t)
+	defer cleanupA()
+	addrB, _, cleanupB := startTestServer(t)
+	clients := dialAll(t, addrA, addrB)
+
+	cfg := DefaultConfig()
+	cfg.Nodes = []string{addrA, addrB}
+	cfg.Timeout = time.Second
+	m := New(cfg)
+	a.Create(&entityv1.Entity{Id: "t-1"}) //nolint:errcheck
+	cleanupB()
+
+	m.sample(context.Background(), clients, time.Now())
+	r := m.Report()
+	if r.Compared || len(r.Diverged) != 0 || r.Nodes[1].Up || r.Nodes[1].Error == "" || !r.Nodes[0].Up {
+		t.Fatalf("expected a down node and no comparison, got %+v", r)
+	}
+}
+
+func TestMonitor_Metrics(t *testing.T) {
+	addrA, a, cleanupA := startTestServer(t)
+	defer cleanupA()
+	addrB, _, cleanupB := startTestServer(t)
+	defer cleanupB()
+
+	cfg := DefaultConfig()
+	cfg.Nodes = []string{addrA, addrB}
+	cfg.Grace = 0
+	m := New(cfg)
+	a.Create(&entityv1.Entity{Id: "t-1"}) //nolint:errcheck
+	m.sample(context.Background(), dialAll(t, addrA, addrB), time.Now())
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		`lattice_divergence_node_up{node="` + addrB + `"} 1`,
+		`lattice_divergence_node_entities{node="` + addrA + `"} 1`,
+		`lattice_divergence_entities{reason="missing"} 1`,
+		`lattice_divergence_entities{reason="threat"} 0`,
+		"lattice_divergence_persistent_entities 1",
+		"lattice_divergence_alerts_total 1",
+		"# TYPE lattice_divergence_samples_total counter",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/divergence", nil))
+	if !strings.Contains(rec.Body.String(), `"id": "t-1"`) {
+		t.Fatalf("unexpected report %s", rec.Body.String())
+	}
+}
@@ -0,0 +1,67 @@
+package e2e
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestScenarios runs every scenario under testdata.
+func TestScenarios(t *testing.T) {
+	paths, err := filepath.Glob("testdata/*.yaml")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no scenarios: %v", err)
+	}
+	for _, path := range paths {
+		t.Run(strings.TrimSuffix(filepath.Base(path), ".yaml"), func(t *testing.T) {
+			sc, err := LoadScenario(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			if err := Run(ctx, sc); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRun_ReportsFailedStep(t *testing.T) {
+	sc, err := ParseScenario([]byte(`
+sims: [{scenario: sims/raid.yaml}]
+steps:
+  - advance: 2s
+  - expect: {entity: airliner-1, label: military}
+`), "testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Run(context.Background(), sc)
+	if err == nil || !strings.Contains(err.Error(), `step 2 at 2s: airliner-1: expected label "military", got "aircraft"`) {
+		t.Fatalf("expected step 2 label mismatch, got %v", err)
+	}
+}
+
+func TestParseScenario_Invalid(t *testing.T) {
+	for name, tc := range map[string]struct{ yaml, want string }{
+		"no sims":       {"steps: [{advance: 1s}]", "no sims"},
+		"no steps":      {"sims: [{scenario: sims/raid.yaml}]", "no steps"},
+		"missing sim":   {"sims: [{scenario: nope.yaml}]\nsteps: [{advance: 1s}]", "read scenario"},
+		"two actions":   {"sims: [{scenario: sims/raid.yaml}]\nsteps: [{advance: 1s, approve: x}]", "exactly one"},
+		"partial tick":  {"sims: [{scenario: sims/raid.yaml}]\nsteps: [{advance: 1500ms}]", "multiple of interval"},
+		"no relay":      {"sims: [{scenario: sims/raid.yaml}]\nsteps: [{expect: {entity: x, node: replica}}]", "needs relay"},
+		"no entity":     {"sims: [{scenario: sims/raid.yaml}]\nsteps: [{expect: {label: military}}]", "entity is required"},
+		"bad threat":    {"sims: [{scenario: sims/raid.yaml}]\nsteps: [{expect: {entity: x, threat: severe}}]", "unknown threat"},
+		"bad task":      {"sims: [{scenario: sims/raid.yaml}]\nsteps: [{expect: {entity: x, task: engage}}]", "unknown task"},
+		"bad approval":  {"sims: [{scenario: sims/raid.yaml}]\nsteps: [{expect: {entity: x, approval: maybe}}]", "unknown approval"},
+		"absent label":  {"sims: [{scenario: sims/raid.yaml}]\nsteps: [{expect: {entity: x, absent: true, label: a}}]", "absent excludes"},
+		"bad roe zones": {"roe_zones: capital\nsims: [{scenario: sims/raid.yaml}]\nsteps: [{advance: 1s}]", "roe_zones"},
+	} {
+		if _, err := ParseScenario([]byte(tc.yaml), "testdata"); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
@@ -0,0 +1,390 @@
+// Package e2e runs the whole pipeline in one process — entity store,
+// classifier, fusion, task manager, an optional mesh relay to a replica,
+// and sensor simulators — and drives it from a scenario file. Simulated
+// time only moves when a scenario advances it: each sim step is followed by
+// waiting until the pipeline goes quiet, so the same scenario sees the same
+// events in the same order on every run, with no sleeps to tune.
+//
+// The effector is not run; it paces engagements on the wall clock.
+// Approval timeouts are an hour, so a pending intercept stays pending until
+// the scenario approves or denies it.
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/classifier"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/fusion"
+	"github.com/boshu2/lattice-lab/internal/mesh"
+	"github.com/boshu2/lattice-lab/internal/sensor"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"github.com/boshu2/lattice-lab/internal/task"
+	"google.golang.org/grpc"
+)
+
+const (
+	// quiet is how long the stores must go without an event before the
+	// pipeline counts as settled. Each hop is a localhost gRPC call, far
+	// quicker than this.
+	quiet = 50 * time.Millisecond
+	// settleTimeout bounds one settle; a pipeline still busy after this is
+	// looping.
+	settleTimeout = 5 * time.Second
+	// readyTimeout bounds waiting for the services to subscribe.
+	readyTimeout = 5 * time.Second
+	// approvalTimeout keeps pending intercepts pending for any scenario.
+	approvalTimeout = time.Hour
+)
+
+// Harness is a running pipeline. It is not safe for concurrent use.
+type Harness struct {
+	Primary *store.Store
+	Replica *store.Store // nil without relay
+	Tasks   *task.Manager
+
+	sc      *Scenario
+	sims    []*sensor.Simulator
+	elapsed time.Duration
+	servers []*grpc.Server
+	conn    *grpc.ClientConn
+	client  storev1.EntityStoreServiceClient
+	unwatch []func()
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	errs    chan error
+
+	lastEvent atomic.Int64 // unix nanos of the latest event on either store
+}
+
+// Run starts a pipeline for sc, runs its steps, and stops it.
+func Run(ctx context.Context, sc *Scenario) error {
+	h, err := Start(ctx, sc)
+	if err != nil {
+		return err
+	}
+	defer h.Close()
+	return h.Execute(ctx)
+}
+
+// Start brings up the pipeline and waits for every service to subscribe
+// to the store.
+func Start(ctx context.Context, sc *Scenario) (*Harness, error) {
+	h := &Harness{sc: sc, errs: make(chan error, 8)}
+	runCtx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+
+	h.Primary = store.New(store.WithNodeID("primary"))
+	primaryAddr, err := h.serve(h.Primary)
+	if err != nil {
+		h.Close()
+		return nil, err
+	}
+	h.watch(h.Primary)
+
+	conn, err := client.Dial(primaryAddr)
+	if err != nil {
+		h.Close()
+		return nil, fmt.Errorf("connect to store: %w", err)
+	}
+	h.conn, h.client = conn, storev1.NewEntityStoreServiceClient(conn)
+
+	zones, _ := task.ParseZones(sc.ROEZones) // checked by Validate
+	tcfg := task.DefaultConfig()
+	tcfg.StoreAddr = primaryAddr
+	tcfg.ApprovalTimeout = approvalTimeout
+	tcfg.ManualMode = sc.ManualMode
+	if len(zones) > 0 {
+		hostile := sc.ROEHostile == nil || *sc.ROEHostile
+		tcfg.Policies = []task.Policy{{Name: "roe", Zones: zones, RequireHostile: hostile}}
+	}
+	h.Tasks = task.New(tcfg)
+
+	ccfg := classifier.DefaultConfig()
+	ccfg.StoreAddr = primaryAddr
+	fcfg := fusion.DefaultConfig()
+	fcfg.StoreAddr = primaryAddr
+	services := map[string]func(context.Context) error{
+		"classifier":   classifier.New(ccfg).Run,
+		"fusion":       fusion.New(fcfg).Run,
+		"task-manager": h.Tasks.Run,
+	}
+	if sc.Relay {
+		h.Replica = store.New(store.WithNodeID("replica"))
+		replicaAddr, err := h.serve(h.Replica)
+		if err != nil {
+			h.Close()
+			return nil, err
+		}
+		h.watch(h.Replica)
+		// One way only: the replica has no relay back, so nothing echoes.
+		services["relay"] = mesh.New(mesh.Config{LocalAddr: primaryAddr, Peers: []string{replicaAddr}, NodeID: "primary"}).Run
+	}
+	for name, run := range services {
+		h.wg.Add(1)
+		go func() {
+			defer h.wg.Done()
+			if err := run(runCtx); err != nil {
+				h.errs <- fmt.Errorf("%s: %w", name, err)
+			}
+		}()
+	}
+
+	for i, sim := range sc.Sims {
+		scfg := sensor.DefaultConfig()
+		scfg.StoreAddr = primaryAddr
+		scfg.Interval = sc.Interval
+		scfg.Scenario = sim.scenario
+		scfg.Seed = sim.Seed
+		scfg.TTL = 0 // expiry runs on the wall clock; despawns still delete
+		if err := scfg.Validate(); err != nil {
+			h.Close()
+			return nil, fmt.Errorf("sim %d: %w", i, err)
+		}
+		h.sims = append(h.sims, sensor.New(scfg))
+	}
+
+	// The harness's own watcher plus one stream per service.
+	if err := h.waitReady(ctx, len(services)+1); err != nil {
+		h.Close()
+		return nil, err
+	}
+	return h, nil
+}
+
+// serve serves s over gRPC on a free localhost port.
+func (h *Harness) serve(s *store.Store) (string, error) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		return "", fmt.Errorf("listen: %w", err)
+	}
+	api := server.New(s)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(api.UnaryInterceptor()), grpc.StreamInterceptor(api.StreamInterceptor()))
+	storev1.RegisterEntityStoreServiceServer(srv, api)
+	go srv.Serve(lis) //nolint:errcheck
+	h.servers = append(h.servers, srv)
+	return lis.Addr().String(), nil
+}
+
+// watch records the time of every event on s, for settle.
+func (h *Harness) watch(s *store.Store) {
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	h.unwatch = append(h.unwatch, func() { s.Unwatch(w) })
+	go func() {
+		for range w.Events {
+			h.lastEvent.Store(time.Now().UnixNano())
+		}
+	}()
+}
+
+// waitReady waits until n watchers are subscribed to the primary.
+func (h *Harness) waitReady(ctx context.Context, n int) error {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+	for h.Primary.WatcherCount() < n {
+		select {
+		case err := <-h.errs:
+			return err
+		case <-ctx.Done():
+			return fmt.Errorf("services did not subscribe: %d of %d watchers: %w", h.Primary.WatcherCount(), n, ctx.Err())
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+	return nil
+}
+
+// Close stops the pipeline.
+func (h *Harness) Close() {
+	h.cancel()
+	if h.conn != nil {
+		h.conn.Close()
+	}
+	for _, srv := range h.servers {
+		srv.Stop()
+	}
+	h.wg.Wait()
+	for _, unwatch := range h.unwatch {
+		unwatch()
+	}
+}
+
+// Elapsed returns the simulated time advanced so far.
+func (h *Harness) Elapsed() time.Duration {
+	return h.elapsed
+}
+
+// Execute runs the scenario's steps in order, stopping at the first
+// failure.
+func (h *Harness) Execute(ctx context.Context) error {
+	for i, s := range h.sc.Steps {
+		if err := h.Do(ctx, s); err != nil {
+			return fmt.Errorf("step %d at %v: %w", i+1, h.elapsed, err)
+		}
+	}
+	return nil
+}
+
+// Do runs one step.
+func (h *Harness) Do(ctx context.Context, s Step) error {
+	switch {
+	case s.Advance > 0:
+		return h.Advance(ctx, s.Advance)
+	case s.Approve != "":
+		if _, err := h.Tasks.Approve(s.Approve); err != nil {
+			return err
+		}
+		return h.Settle(ctx)
+	case s.Deny != "":
+		if err := h.Tasks.Deny(s.Deny); err != nil {
+			return err
+		}
+		return h.Settle(ctx)
+	case s.Expect != nil:
+		return h.Check(*s.Expect)
+	}
+	return nil
+}
+
+// Advance steps every sim through d of simulated time, one interval at a
+// time, settling the pipeline after each.
+func (h *Harness) Advance(ctx context.Context, d time.Duration) error {
+	for range d / h.sc.Interval {
+		for _, sim := range h.sims {
+			sim.Step(ctx, h.client)
+		}
+		h.elapsed += h.sc.Interval
+		if err := h.Settle(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Settle waits until neither store has seen an event for a quiet period.
+// The call itself counts as activity, so work it set off in flight is
+// waited for too.
+func (h *Harness) Settle(ctx context.Context) error {
+	h.lastEvent.Store(time.Now().UnixNano())
+	deadline := time.Now().Add(settleTimeout)
+	for {
+		idle := time.Since(time.Unix(0, h.lastEvent.Load()))
+		if idle >= quiet {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("pipeline did not settle within %v", settleTimeout)
+		}
+		select {
+		case err := <-h.errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(quiet - idle):
+		}
+	}
+}
+
+// Check compares the settled pipeline against e.
+func (h *Harness) Check(e Expect) error {
+	s := h.Primary
+	if e.Node == NodeReplica {
+		s = h.Replica
+	}
+	if e.Tracks != nil {
+		if n := len(s.List(entityv1.EntityType_ENTITY_TYPE_TRACK)); n != *e.Tracks {
+			return fmt.Errorf("expected %d tracks, got %d", *e.Tracks, n)
+		}
+	}
+	if e.Fused != nil {
+		n := 0
+		for _, ent := range s.List(entityv1.EntityType_ENTITY_TYPE_TRACK) {
+			if _, ok := ent.Components["fusion"]; ok {
+				n++
+			}
+		}
+		if n != *e.Fused {
+			return fmt.Errorf("expected %d fused entities, got %d", *e.Fused, n)
+		}
+	}
+	if e.Entity == "" {
+		return nil
+	}
+	if err := h.checkEntity(s, e); err != nil {
+		return fmt.Errorf("%s: %w", e.Entity, err)
+	}
+	return nil
+}
+
+func (h *Harness) checkEntity(s *store.Store, e Expect) error {
+	if e.Task != "" {
+		got := "none"
+		if a, ok := h.Tasks.GetAssignment(e.Entity); ok {
+			got = string(a.State)
+		}
+		if got != e.Task {
+			return fmt.Errorf("expected task %s, got %s", e.Task, got)
+		}
+	}
+	if e.Approval != "" {
+		got := "none"
+		for _, t := range h.Tasks.History(e.Entity) {
+			if slices.Contains(approvalStages, t.Stage) {
+				got = t.Stage
+			}
+		}
+		if got != e.Approval {
+			return fmt.Errorf("expected approval %s, got %s", e.Approval, got)
+		}
+	}
+
+	ent, err := s.Get(e.Entity)
+	if e.Absent {
+		if err == nil {
+			return fmt.Errorf("expected absent, found it")
+		}
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if e.Label != "" {
+		got := &entityv1.ClassificationComponent{}
+		if a, ok := ent.Components["classification"]; ok {
+			a.UnmarshalTo(got) //nolint:errcheck
+		}
+		if got.Label != e.Label {
+			return fmt.Errorf("expected label %q, got %q", e.Label, got.Label)
+		}
+	}
+	if e.Threat != "" {
+		want, _ := parseThreat(e.Threat)
+		got := &entityv1.ThreatComponent{}
+		if a, ok := ent.Components["threat"]; ok {
+			a.UnmarshalTo(got) //nolint:errcheck
+		}
+		if got.Level != want {
+			return fmt.Errorf("expected threat %s, got %s", e.Threat, strings.ToLower(strings.TrimPrefix(got.Level.String(), "THREAT_LEVEL_")))
+		}
+	}
+	if len(e.FusedOf) > 0 {
+		got := &entityv1.FusionComponent{}
+		if a, ok := ent.Components["fusion"]; ok {
+			a.UnmarshalTo(got) //nolint:errcheck
+		}
+		want, have := slices.Sorted(slices.Values(e.FusedOf)), slices.Sorted(slices.Values(got.SourceIds))
+		if !slices.Equal(want, have) {
+			return fmt.Errorf("expected fused from %v, got %v", want, have)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package e2e
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"github.com/boshu2/lattice-lab/internal/sensor"
+	"github.com/boshu2/lattice-lab/internal/task"
+	"go.yaml.in/yaml/v3"
+)
+
+// Nodes an expectation can read from.
+const (
+	NodePrimary = "primary" // the store every service writes to
+	NodeReplica = "replica" // fed from the primary by a mesh relay
+)
+
+// Scenario is a pipeline run and its expected outcomes, loaded from YAML:
+//
+//	name: dc-raid
+//	interval: 1s
+//	relay: true
+//	roe_zones: "capital=38.9,-77.03,20000"
+//	roe_require_hostile: false  # sims report no IFF
+//	sims:
+//	  - scenario: raid.yaml  # sensor scenario, relative to this file
+//	    seed: 7
+//	steps:
+//	  - advance: 30s
+//	  - expect: {entity: radar-1-raider-1, label: military, task: pending_approval}
+//	  - approve: radar-1-raider-1
+//	  - expect: {entity: radar-1-raider-1, task: intercept, approval: approved}
+//	  - expect: {fused: 1, node: replica}
+type Scenario struct {
+	Name       string        `yaml:"name"`
+	Interval   time.Duration `yaml:"interval"`            // simulated time per sim step; default 1s
+	Relay      bool          `yaml:"relay"`               // run a replica fed by a mesh relay
+	ROEZones   string        `yaml:"roe_zones"`           // as ROE_ZONES; auto-approves tracks inside
+	ROEHostile *bool         `yaml:"roe_require_hostile"` // as ROE_REQUIRE_HOSTILE; default true
+	ManualMode bool          `yaml:"manual_mode"`         // start with auto-approval disabled
+	Sims       []Sim         `yaml:"sims"`
+	Steps      []Step        `yaml:"steps"`
+}
+
+// Sim is one sensor simulator fed from a sensor scenario file.
+type Sim struct {
+	Scenario string `yaml:"scenario"` // path, relative to the e2e scenario file
+	Seed     uint64 `yaml:"seed"`     // default 1, so runs repeat
+
+	scenario *sensor.Scenario
+}
+
+// Step is one action or check. Exactly one field is set.
+type Step struct {
+	Advance time.Duration `yaml:"advance"` // step every sim this long, a multiple of interval
+	Approve string        `yaml:"approve"` // approve a pending intercept as the operator
+	Deny    string        `yaml:"deny"`    // deny a pending intercept as the operator
+	Expect  *Expect       `yaml:"expect"`
+}
+
+// Expect checks the pipeline's state once it has settled. Unset fields are
+// not checked.
+type Expect struct {
+	Entity   string   `yaml:"entity"`   // entity ID; optional with only fused or tracks
+	Node     string   `yaml:"node"`     // primary (default) or replica
+	Absent   bool     `yaml:"absent"`   // the entity must not exist
+	Label    string   `yaml:"label"`    // classification label
+	Threat   string   `yaml:"threat"`   // none, low, medium, or high
+	Task     string   `yaml:"task"`     // task-manager state: idle, investigate, track, intercept, pending_approval
+	Approval string   `yaml:"approval"` // latest approval stage: pending_approval, approved, auto_approved, denied, timed_out
+	FusedOf  []string `yaml:"fused_of"` // entity is fused from exactly these tracks
+	Fused    *int     `yaml:"fused"`    // number of fused entities on the node
+	Tracks   *int     `yaml:"tracks"`   // number of TRACK entities on the node
+}
+
+var (
+	taskStates = []string{string(task.StateIdle), string(task.StateInvestigate), string(task.StateTrack),
+		string(task.StateIntercept), string(task.StatePendingApproval)}
+	approvalStages = []string{string(task.StatePendingApproval), task.StageApproved, task.StageAutoApproved,
+		task.StageDenied, task.StageTimedOut}
+)
+
+// LoadScenario reads and validates a scenario file, and the sensor
+// scenarios its sims name.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scenario: %w", err)
+	}
+	return ParseScenario(data, filepath.Dir(path))
+}
+
+// ParseScenario decodes and validates scenario YAML. Sim scenario paths
+// are resolved against dir.
+func ParseScenario(data []byte, dir string) (*Scenario, error) {
+	sc := Scenario{Interval: time.Second}
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parse scenario: %w", err)
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	for i := range sc.Sims {
+		sim := &sc.Sims[i]
+		path := sim.Scenario
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		ss, err := sensor.LoadScenario(path)
+		if err != nil {
+			return nil, fmt.Errorf("sim %d: %w", i, err)
+		}
+		sim.scenario = ss
+		if sim.Seed == 0 {
+			sim.Seed = 1
+		}
+	}
+	return &sc, nil
+}
+
+// Validate checks the scenario is runnable.
+func (sc *Scenario) Validate() error {
+	if sc.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if _, err := task.ParseZones(sc.ROEZones); err != nil {
+		return fmt.Errorf("roe_zones: %w", err)
+	}
+	if len(sc.Sims) == 0 {
+		return fmt.Errorf("scenario has no sims")
+	}
+	for i, sim := range sc.Sims {
+		if sim.Scenario == "" {
+			return fmt.Errorf("sim %d: scenario is required", i)
+		}
+	}
+	if len(sc.Steps) == 0 {
+		return fmt.Errorf("scenario has no steps")
+	}
+	for i, s := range sc.Steps {
+		if err := s.validate(sc); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (s Step) validate(sc *Scenario) error {
+	set := 0
+	for _, ok := range []bool{s.Advance != 0, s.Approve != "", s.Deny != "", s.Expect != nil} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("want exactly one of advance, approve, deny, expect")
+	}
+	if s.Advance != 0 && (s.Advance < 0 || s.Advance%sc.Interval != 0) {
+		return fmt.Errorf("advance %v must be a positive multiple of interval %v", s.Advance, sc.Interval)
+	}
+	if s.Expect != nil {
+		return s.Expect.validate(sc.Relay)
+	}
+	return nil
+}
+
+func (e *Expect) validate(relay bool) error {
+	switch e.Node {
+	case "", NodePrimary:
+	case NodeReplica:
+		if !relay {
+			return fmt.Errorf("node replica needs relay: true")
+		}
+	default:
+		return fmt.Errorf("unknown node %q (want primary or replica)", e.Node)
+	}
+	if _, err := parseThreat(e.Threat); err != nil {
+		return err
+	}
+	if e.Task != "" && !slices.Contains(taskStates, e.Task) {
+		return fmt.Errorf("unknown task %q (want %s)", e.Task, strings.Join(taskStates, ", "))
+	}
+	if e.Approval != "" && !slices.Contains(approvalStages, e.Approval) {
+		return fmt.Errorf("unknown approval %q (want %s)", e.Approval, strings.Join(approvalStages, ", "))
+	}
+	entityChecks := e.Absent || e.Label != "" || e.Threat != "" || e.Task != "" || e.Approval != "" || len(e.FusedOf) > 0
+	switch {
+	case e.Entity == "" && (entityChecks || (e.Fused == nil && e.Tracks == nil)):
+		return fmt.Errorf("entity is required")
+	case e.Absent && (e.Label != "" || e.Threat != "" || len(e.FusedOf) > 0):
+		return fmt.Errorf("absent excludes label, threat, and fused_of")
+	case e.Fused != nil && *e.Fused < 0, e.Tracks != nil && *e.Tracks < 0:
+		return fmt.Errorf("fused and tracks must not be negative")
+	}
+	return nil
+}
+
+// parseThreat maps "high" to THREAT_LEVEL_HIGH; "" is UNSPECIFIED.
+func parseThreat(s string) (entityv1.ThreatLevel, error) {
+	if s == "" {
+		return entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED, nil
+	}
+	v, ok := entityv1.ThreatLevel_value["THREAT_LEVEL_"+strings.ToUpper(s)]
+	if !ok || v == 0 {
+		return 0, fmt.Errorf("unknown threat %q (want none, low, medium, or high)", s)
+	}
+	return entityv1.ThreatLevel(v), nil
+}
@@ -0,0 +1,133 @@
+package effector
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// loiterFraction is the share of dash speed a free asset flies on station.
+const loiterFraction = 0.5
+
+// AssetSpec declares one asset: what it is, what it can be tasked with, and
+// the station it loiters on while free and returns to after a mission.
+type AssetSpec struct {
+	ID           string
+	Kind         string
+	Capabilities []string // tasks it accepts; others are reported FAILED
+	StationLat   float64
+	StationLon   float64
+	OrbitRadiusM float64 // 0 holds at the station
+	SpeedKnots   float64 // dash speed; 0 uses Config.SpeedKnots
+}
+
+// kindPresets are the defaults for each known asset kind.
+var kindPresets = map[string]AssetSpec{
+	"patrol":      {Capabilities: []string{"identify", "shadow"}, OrbitRadiusM: 8000, SpeedKnots: 300},
+	"interceptor": {Capabilities: []string{"intercept", "identify"}, OrbitRadiusM: 4000, SpeedKnots: 600},
+}
+
+// DefaultAssets returns two patrol aircraft and an interceptor around the DC
+// metro area.
+func DefaultAssets() []AssetSpec {
+	assets, _ := ParseAssets("patrol-1:patrol:38.98,-77.15;patrol-2:patrol:38.82,-76.95;interceptor-1:interceptor:38.9,-77.05")
+	return assets
+}
+
+// ParseAssets parses "id:kind:lat,lon[:orbit_radius_m[:speed_kts]];...".
+// Kind is patrol or interceptor and sets the capabilities, orbit, and speed
+// the optional fields override.
+func ParseAssets(s string) ([]AssetSpec, error) {
+	var specs []AssetSpec
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		f := strings.Split(part, ":")
+		if len(f) < 3 || len(f) > 5 {
+			return nil, fmt.Errorf("asset %q: want id:kind:lat,lon[:orbit_radius_m[:speed_kts]]", part)
+		}
+		preset, ok := kindPresets[f[1]]
+		if !ok {
+			return nil, fmt.Errorf("asset %q: unknown kind %q (want patrol or interceptor)", part, f[1])
+		}
+		spec := preset
+		spec.ID, spec.Kind = f[0], f[1]
+		spec.Capabilities = slices.Clone(preset.Capabilities)
+		lat, lon, ok := strings.Cut(f[2], ",")
+		if !ok {
+			return nil, fmt.Errorf("asset %q: station must be lat,lon", part)
+		}
+		var err error
+		if spec.StationLat, err = strconv.ParseFloat(lat, 64); err != nil {
+			return nil, fmt.Errorf("asset %q: station lat: %w", part, err)
+		}
+		if spec.StationLon, err = strconv.ParseFloat(lon, 64); err != nil {
+			return nil, fmt.Errorf("asset %q: station lon: %w", part, err)
+		}
+		if len(f) > 3 {
+			if spec.OrbitRadiusM, err = strconv.ParseFloat(f[3], 64); err != nil {
+				return nil, fmt.Errorf("asset %q: orbit radius: %w", part, err)
+			}
+		}
+		if len(f) > 4 {
+			if spec.SpeedKnots, err = strconv.ParseFloat(f[4], 64); err != nil {
+				return nil, fmt.Errorf("asset %q: speed: %w", part, err)
+			}
+		}
+		if err := spec.Validate(); err != nil {
+			return nil, fmt.Errorf("asset %q: %w", part, err)
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// Validate checks the spec is usable.
+func (s AssetSpec) Validate() error {
+	switch {
+	case s.ID == "":
+		return fmt.Errorf("id is required")
+	case s.OrbitRadiusM < 0:
+		return fmt.Errorf("orbit radius must not be negative")
+	case s.SpeedKnots < 0:
+		return fmt.Errorf("speed must not be negative")
+	}
+	return nil
+}
+
+// canTask reports whether the asset accepts task. An asset without declared
+// capabilities accepts anything.
+func (a *asset) canTask(task string) bool {
+	return len(a.capabilities) == 0 || slices.Contains(a.capabilities, task)
+}
+
+// loiter moves a free asset up to maxDist meters: back toward its station if
+// it is away, then clockwise around the station at its orbit radius.
+func (a *asset) loiter(maxDist float64) bool {
+	d := Distance(a.stationLat, a.stationLon, a.lat, a.lon)
+	if a.orbitRadiusM == 0 || d > a.orbitRadiusM*1.1 {
+		if d == 0 {
+			return false // holding at the station
+		}
+		a.lat, a.lon, a.heading = CloseOn(a.lat, a.lon, a.stationLat, a.stationLon, maxDist)
+		return true
+	}
+
+	// Angle of the asset about the station, measured clockwise from north.
+	cos := math.Cos(a.stationLat * math.Pi / 180)
+	north := (a.lat - a.stationLat) * metersPerDegreeLat
+	east := (a.lon - a.stationLon) * metersPerDegreeLat * cos
+	theta := math.Atan2(east, north)
+	if d < a.orbitRadiusM*0.9 {
+		theta = 0 // inside the orbit, e.g. starting at the station: join it due north
+	}
+	theta += maxDist / a.orbitRadiusM
+	a.lat = a.stationLat + a.orbitRadiusM*math.Cos(theta)/metersPerDegreeLat
+	a.lon = a.stationLon + a.orbitRadiusM*math.Sin(theta)/(metersPerDegreeLat*cos)
+	a.heading = math.Mod(theta*180/math.Pi+90+360, 360)
+	return true
+}
@@ -0,0 +1,85 @@
+package effector
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+)
+
+func TestParseAssets(t *testing.T) {
+	specs, err := ParseAssets("p1:patrol:38.9,-77.1; i1:interceptor:38.8,-77.0:2000:700")
+	if err != nil {
+		t.Fatalf("ParseAssets: %v", err)
+	}
+	if len(specs) != 2 {
+		t.Fatalf("expected 2 assets, got %d", len(specs))
+	}
+	if p := specs[0]; p.Kind != "patrol" || p.OrbitRadiusM != 8000 || p.SpeedKnots != 300 || p.StationLat != 38.9 {
+		t.Fatalf("unexpected patrol spec %+v", p)
+	}
+	if i := specs[1]; i.OrbitRadiusM != 2000 || i.SpeedKnots != 700 || i.Capabilities[0] != "intercept" {
+		t.Fatalf("unexpected interceptor spec %+v", i)
+	}
+	for _, bad := range []string{"p1:patrol", "p1:tanker:1,1", "p1:patrol:1", "p1:patrol:1,1:-5", ":patrol:1,1"} {
+		if _, err := ParseAssets(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+	if len(DefaultAssets()) != 3 {
+		t.Fatal("expected three default assets")
+	}
+}
+
+func TestStep_LoitersOnStation(t *testing.T) {
+	e := New(Config{Assets: []AssetSpec{{ID: "p1", StationLat: 38.9, StationLon: -77.0, OrbitRadiusM: 3000, SpeedKnots: 300}}})
+	a := e.assets[0]
+
+	var headings []float64
+	for range 120 {
+		e.step(time.Second)
+		if d := Distance(38.9, -77.0, a.lat, a.lon); math.Abs(d-3000) > 10 {
+			t.Fatalf("expected asset on its 3km orbit, %.0fm from station", d)
+		}
+		headings = append(headings, a.heading)
+	}
+	if headings[0] == headings[len(headings)-1] {
+		t.Fatal("expected the orbit to turn the asset")
+	}
+}
+
+func TestStep_ReturnsToStation(t *testing.T) {
+	e := New(Config{SpeedKnots: 600, InterceptRange: 500, Assets: []AssetSpec{{ID: "i1", StationLat: 38.9, StationLon: -77.0, OrbitRadiusM: 2000}}})
+	e.handleEvent(makeTrackEvent(t, "track-1", 39.0, -77.0, assignedTo("i1")))
+
+	var done []outcome
+	for i := 0; i < 60 && len(done) == 0; i++ {
+		done = e.step(time.Second)
+	}
+	if len(done) != 1 || done[0].Status != entityv1.TaskStatus_TASK_STATUS_COMPLETED {
+		t.Fatalf("expected mission completed, got %v", done)
+	}
+	a := e.assets[0]
+	if d := Distance(38.9, -77.0, a.lat, a.lon); d < 9000 {
+		t.Fatalf("expected asset out near the target, %.0fm from station", d)
+	}
+	for range 120 {
+		e.step(time.Second)
+	}
+	if d := Distance(38.9, -77.0, a.lat, a.lon); math.Abs(d-2000) > 10 {
+		t.Fatalf("expected asset back on its orbit, %.0fm from station", d)
+	}
+}
+
+func TestHandleEvent_RefusesUncapableTask(t *testing.T) {
+	e := New(Config{Assets: []AssetSpec{{ID: "p1", Capabilities: []string{"identify"}}}})
+
+	c := e.handleEvent(makeTrackEvent(t, "track-1", 38.9, -77.0, assignedTo("p1")))
+	if c == nil || c.Status != entityv1.TaskStatus_TASK_STATUS_FAILED || c.Task != "intercept" {
+		t.Fatalf("expected intercept refused as FAILED, got %+v", c)
+	}
+	if _, ok := e.Mission("p1"); ok {
+		t.Fatal("expected refused asset to stay free")
+	}
+}
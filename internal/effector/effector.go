@@ -0,0 +1,477 @@
+package effector
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/watch"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	metersPerDegreeLat = 111_320.0
+	knotsToMps         = 0.514444
+)
+
+// Config controls the effector simulator.
+type Config struct {
+	StoreAddr      string
+	Interval       time.Duration
+	NumAssets      int
+	SpeedKnots     float64       // asset cruise speed
+	InterceptRange float64       // meters; closing inside this completes the task
+	MissionTimeout time.Duration // give up and report failure after this long
+	BaseLat        float64       // where assets start
+	BaseLon        float64
+	Assets         []AssetSpec // when set, replaces the NumAssets interceptors at the base
+}
+
+// DefaultConfig returns effector defaults, based in the DC metro area.
+func DefaultConfig() Config {
+	return Config{
+		StoreAddr:      "localhost:50051",
+		Interval:       time.Second,
+		NumAssets:      2,
+		SpeedKnots:     600,
+		InterceptRange: 500,
+		MissionTimeout: 5 * time.Minute,
+		BaseLat:        38.9,
+		BaseLon:        -77.05,
+	}
+}
+
+// Validate checks the config is runnable.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.Interval <= 0:
+		return fmt.Errorf("interval must be positive")
+	case cfg.NumAssets < 1 && len(cfg.Assets) == 0:
+		return fmt.Errorf("num_assets must be at least 1")
+	case cfg.SpeedKnots <= 0:
+		return fmt.Errorf("speed must be positive")
+	case cfg.InterceptRange <= 0:
+		return fmt.Errorf("intercept range must be positive")
+	case cfg.MissionTimeout <= 0:
+		return fmt.Errorf("mission timeout must be positive")
+	}
+	seen := make(map[string]bool, len(cfg.Assets))
+	for _, a := range cfg.Assets {
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("asset %s: %w", a.ID, err)
+		}
+		if seen[a.ID] {
+			return fmt.Errorf("asset %s: duplicate id", a.ID)
+		}
+		seen[a.ID] = true
+	}
+	return nil
+}
+
+// asset is a simulated interceptor owned by this effector.
+type asset struct {
+	id           string
+	kind         string
+	capabilities []string
+	speedKnots   float64
+	stationLat   float64
+	stationLon   float64
+	orbitRadiusM float64
+	lat, lon     float64
+	heading      float64
+	target       string // track ID being intercepted; empty when free
+	task         string
+	started      time.Time
+	moving       bool // flew this step, on a mission or on station
+	created      bool
+}
+
+// claim is an assignment this effector has answered: IN_PROGRESS when taken
+// on, FAILED when the asset lacks the capability.
+type claim struct {
+	TrackID string
+	AssetID string
+	Task    string
+	Status  entityv1.TaskStatus
+}
+
+// outcome is a finished mission to report back to the store.
+type outcome struct {
+	TrackID string
+	AssetID string
+	Task    string
+	Status  entityv1.TaskStatus
+}
+
+// Effector publishes simulated assets, flies them at the tracks the task
+// manager assigns them, and reports task status back to the entity store.
+type Effector struct {
+	cfg     Config
+	mu      sync.Mutex
+	assets  []*asset
+	targets map[string]*entityv1.PositionComponent // track ID → last known position
+}
+
+// New creates an effector with an idle asset per cfg.Assets, each on its
+// station, or else cfg.NumAssets interceptors holding at the base.
+func New(cfg Config) *Effector {
+	var assets []*asset
+	for _, spec := range cfg.Assets {
+		speed := spec.SpeedKnots
+		if speed == 0 {
+			speed = cfg.SpeedKnots
+		}
+		assets = append(assets, &asset{
+			id:           spec.ID,
+			kind:         spec.Kind,
+			capabilities: spec.Capabilities,
+			speedKnots:   speed,
+			stationLat:   spec.StationLat,
+			stationLon:   spec.StationLon,
+			orbitRadiusM: spec.OrbitRadiusM,
+			lat:          spec.StationLat,
+			lon:          spec.StationLon,
+		})
+	}
+	if len(cfg.Assets) == 0 {
+		for i := range cfg.NumAssets {
+			assets = append(assets, &asset{
+				id:         fmt.Sprintf("effector-%d", i),
+				kind:       "interceptor",
+				speedKnots: cfg.SpeedKnots,
+				stationLat: cfg.BaseLat,
+				stationLon: cfg.BaseLon,
+				lat:        cfg.BaseLat,
+				lon:        cfg.BaseLon,
+			})
+		}
+	}
+	return &Effector{
+		cfg:     cfg,
+		assets:  assets,
+		targets: make(map[string]*entityv1.PositionComponent),
+	}
+}
+
+// Mission returns the track an asset is currently intercepting, if any.
+func (e *Effector) Mission(assetID string) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, a := range e.assets {
+		if a.id == assetID && a.target != "" {
+			return a.target, true
+		}
+	}
+	return "", false
+}
+
+// Run connects to the store, publishes the asset entities, and services
+// intercept assignments until ctx is cancelled.
+func (e *Effector) Run(ctx context.Context) error {
+	conn, err := client.Dial(e.cfg.StoreAddr)
+	if err != nil {
+		return fmt.Errorf("connect to store: %w", err)
+	}
+	defer conn.Close()
+
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	for _, a := range e.assets {
+		if err := e.publishAsset(ctx, client, a); err != nil {
+			return err
+		}
+	}
+
+	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{
+		TypeFilter: entityv1.EntityType_ENTITY_TYPE_TRACK,
+	})
+	if err != nil {
+		return fmt.Errorf("watch entities: %w", err)
+	}
+
+	events := make(chan *storev1.EntityEvent)
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+
+	slog.Info("effector-sim watching assignments", "num_assets", len(e.assets), "store_addr", e.cfg.StoreAddr)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("recv: %w", err)
+		case event := <-events:
+			if c := e.handleEvent(event); c != nil {
+				slog.Info("effector-sim answered assignment", "track_id", c.TrackID, "asset_id", c.AssetID, "task", c.Task, "status", c.Status.String())
+				if err := writeAssignment(ctx, client, c.TrackID, c.AssetID, c.Task, c.Status); err != nil {
+					slog.Error("claim assignment failed", "track_id", c.TrackID, "error", err)
+				}
+			}
+		case <-ticker.C:
+			for _, o := range e.step(e.cfg.Interval) {
+				slog.Info("effector-sim mission finished", "track_id", o.TrackID, "asset_id", o.AssetID, "status", o.Status.String())
+				if err := writeAssignment(ctx, client, o.TrackID, o.AssetID, o.Task, o.Status); err != nil {
+					slog.Error("report assignment failed", "track_id", o.TrackID, "error", err)
+				}
+			}
+			e.mu.Lock()
+			assets := append([]*asset(nil), e.assets...)
+			e.mu.Unlock()
+			for _, a := range assets {
+				if err := e.publishAsset(ctx, client, a); err != nil {
+					slog.Error("publish asset failed", "asset_id", a.id, "error", err)
+				}
+			}
+		}
+	}
+}
+
+// handleEvent records the target's position and claims assignments
+// addressed to one of this effector's free assets, refusing tasks the asset
+// is not capable of. It returns nil when there is nothing to answer.
+func (e *Effector) handleEvent(event *storev1.EntityEvent) *claim {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	id := event.Entity.Id
+	if watch.Removed(event) {
+		delete(e.targets, id)
+		for _, a := range e.assets {
+			if a.target == id {
+				slog.Info("effector-sim target lost", "track_id", id, "asset_id", a.id)
+				a.target = ""
+			}
+		}
+		return nil
+	}
+
+	if pos, err := extractPosition(event.Entity); err == nil {
+		e.targets[id] = pos
+	}
+
+	assignment, err := extractAssignment(event.Entity)
+	if err != nil || assignment.Status != entityv1.TaskStatus_TASK_STATUS_ASSIGNED {
+		return nil
+	}
+	if _, ok := e.targets[id]; !ok {
+		return nil
+	}
+	for _, a := range e.assets {
+		if a.id != assignment.AssetId {
+			continue
+		}
+		if a.target != "" {
+			if a.target != id {
+				slog.Warn("effector-sim asset already committed", "asset_id", a.id, "track_id", id, "current", a.target)
+			}
+			return nil
+		}
+		if !a.canTask(assignment.Task) {
+			slog.Warn("effector-sim asset cannot take task", "asset_id", a.id, "kind", a.kind, "task", assignment.Task, "track_id", id)
+			return &claim{TrackID: id, AssetID: a.id, Task: assignment.Task, Status: entityv1.TaskStatus_TASK_STATUS_FAILED}
+		}
+		a.target, a.task = id, assignment.Task
+		a.started = time.Now()
+		return &claim{TrackID: id, AssetID: a.id, Task: a.task, Status: entityv1.TaskStatus_TASK_STATUS_IN_PROGRESS}
+	}
+	return nil // addressed to someone else's asset
+}
+
+// step advances every busy asset toward its target, and every free one on
+// or back to its station, and returns the missions that finished during
+// this step.
+func (e *Effector) step(dt time.Duration) []outcome {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var done []outcome
+	for _, a := range e.assets {
+		maxDist := a.speedKnots * knotsToMps * dt.Seconds()
+		if a.target == "" {
+			a.moving = a.loiter(maxDist * loiterFraction)
+			continue
+		}
+		pos, ok := e.targets[a.target]
+		if !ok {
+			a.moving = false
+			continue
+		}
+
+		a.lat, a.lon, a.heading = CloseOn(a.lat, a.lon, pos.Lat, pos.Lon, maxDist)
+		a.moving = true
+
+		switch {
+		case Distance(a.lat, a.lon, pos.Lat, pos.Lon) <= e.cfg.InterceptRange:
+			done = append(done, outcome{TrackID: a.target, AssetID: a.id, Task: a.task, Status: entityv1.TaskStatus_TASK_STATUS_COMPLETED})
+			a.target = ""
+		case e.cfg.MissionTimeout > 0 && time.Since(a.started) > e.cfg.MissionTimeout:
+			done = append(done, outcome{TrackID: a.target, AssetID: a.id, Task: a.task, Status: entityv1.TaskStatus_TASK_STATUS_FAILED})
+			a.target = ""
+		}
+	}
+	return done
+}
+
+// publishAsset creates the asset's entity in the store, or updates its
+// position and velocity on the latest stored copy.
+func (e *Effector) publishAsset(ctx context.Context, client storev1.EntityStoreServiceClient, a *asset) error {
+	e.mu.Lock()
+	pos, err := anypb.New(&entityv1.PositionComponent{Lat: a.lat, Lon: a.lon})
+	if err != nil {
+		e.mu.Unlock()
+		return fmt.Errorf("pack position: %w", err)
+	}
+	speed := 0.0
+	switch {
+	case a.target != "":
+		speed = a.speedKnots
+	case a.moving:
+		speed = a.speedKnots * loiterFraction
+	}
+	vel, err := anypb.New(&entityv1.VelocityComponent{Speed: speed, Heading: a.heading})
+	if err != nil {
+		e.mu.Unlock()
+		return fmt.Errorf("pack velocity: %w", err)
+	}
+	created := a.created
+	e.mu.Unlock()
+
+	if !created {
+		desc, err := anypb.New(&entityv1.AssetComponent{
+			Kind:         a.kind,
+			Capabilities: a.capabilities,
+			MaxSpeedKts:  a.speedKnots,
+			StationLat:   a.stationLat,
+			StationLon:   a.stationLon,
+		})
+		if err != nil {
+			return fmt.Errorf("pack asset: %w", err)
+		}
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+			Id:         a.id,
+			Type:       entityv1.EntityType_ENTITY_TYPE_ASSET,
+			Components: map[string]*anypb.Any{"position": pos, "velocity": vel, "asset": desc},
+		}}); err != nil {
+			return fmt.Errorf("create %s: %w", a.id, err)
+		}
+		e.mu.Lock()
+		a.created = true
+		e.mu.Unlock()
+		return nil
+	}
+
+	// Re-read so components written by others (e.g. availability) do not
+	// make this update stale.
+	entity, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: a.id})
+	if err != nil {
+		return fmt.Errorf("get %s: %w", a.id, err)
+	}
+	entity.Components["position"] = pos
+	entity.Components["velocity"] = vel
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity}); err != nil {
+		return fmt.Errorf("update %s: %w", a.id, err)
+	}
+	return nil
+}
+
+// writeAssignment sets the assignment component on a track.
+func writeAssignment(ctx context.Context, client storev1.EntityStoreServiceClient, trackID, assetID, task string, st entityv1.TaskStatus) error {
+	entity, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: trackID})
+	if err != nil {
+		return fmt.Errorf("get %s: %w", trackID, err)
+	}
+
+	assignment, err := anypb.New(&entityv1.AssignmentComponent{
+		Task:      task,
+		AssetId:   assetID,
+		Status:    st,
+		UpdatedAt: timestamppb.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("pack assignment: %w", err)
+	}
+	if entity.Components == nil {
+		entity.Components = make(map[string]*anypb.Any)
+	}
+	entity.Components["assignment"] = assignment
+
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity}); err != nil {
+		return fmt.Errorf("update %s: %w", trackID, err)
+	}
+	return nil
+}
+
+// CloseOn moves from (lat, lon) toward (tgtLat, tgtLon) by at most maxDist
+// meters, returning the new position and the heading flown.
+func CloseOn(lat, lon, tgtLat, tgtLon, maxDist float64) (newLat, newLon, heading float64) {
+	north := (tgtLat - lat) * metersPerDegreeLat
+	east := (tgtLon - lon) * metersPerDegreeLat * math.Cos(lat*math.Pi/180)
+	dist := math.Hypot(north, east)
+
+	heading = math.Mod(math.Atan2(east, north)*180/math.Pi+360, 360)
+	if dist <= maxDist {
+		return tgtLat, tgtLon, heading
+	}
+
+	frac := maxDist / dist
+	return lat + (tgtLat-lat)*frac, lon + (tgtLon-lon)*frac, heading
+}
+
+// Distance returns the distance in meters between two points
+// (flat-earth approximation, fine at intercept ranges).
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+	north := (lat2 - lat1) * metersPerDegreeLat
+	east := (lon2 - lon1) * metersPerDegreeLat * math.Cos(lat1*math.Pi/180)
+	return math.Hypot(north, east)
+}
+
+func extractPosition(entity *entityv1.Entity) (*entityv1.PositionComponent, error) {
+	posAny, ok := entity.Components["position"]
+	if !ok {
+		return nil, fmt.Errorf("no position component")
+	}
+	pos := &entityv1.PositionComponent{}
+	if err := posAny.UnmarshalTo(pos); err != nil {
+		return nil, fmt.Errorf("unmarshal position: %w", err)
+	}
+	return pos, nil
+}
+
+func extractAssignment(entity *entityv1.Entity) (*entityv1.AssignmentComponent, error) {
+	aAny, ok := entity.Components["assignment"]
+	if !ok {
+		return nil, fmt.Errorf("no assignment component")
+	}
+	a := &entityv1.AssignmentComponent{}
+	if err := aAny.UnmarshalTo(a); err != nil {
+		return nil, fmt.Errorf("unmarshal assignment: %w", err)
+	}
+	return a, nil
+}
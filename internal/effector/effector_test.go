@@ -0,0 +1,246 @@
+package effector
+
+import (
+	"context"
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func makeTrackEvent(t *testing.T, id string, lat, lon float64, assignment *entityv1.AssignmentComponent) *storev1.EntityEvent {
+	t.Helper()
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: lat, Lon: lon})
+	comps := map[string]*anypb.Any{"position": pos}
+	if assignment != nil {
+		a, _ := anypb.New(assignment)
+		comps["assignment"] = a
+	}
+	return &storev1.EntityEvent{
+		Type: storev1.EventType_EVENT_TYPE_UPDATED,
+		Entity: &entityv1.Entity{
+			Id:         id,
+			Type:       entityv1.EntityType_ENTITY_TYPE_TRACK,
+			Components: comps,
+		},
+	}
+}
+
+func assignedTo(assetID string) *entityv1.AssignmentComponent {
+	return &entityv1.AssignmentComponent{Task: "intercept", AssetId: assetID, Status: entityv1.TaskStatus_TASK_STATUS_ASSIGNED}
+}
+
+func TestCloseOn_StepsTowardTarget(t *testing.T) {
+	lat, lon, hdg := CloseOn(38.9, -77.0, 39.0, -77.0, 1000)
+
+	if lon != -77.0 {
+		t.Fatalf("expected lon unchanged flying north, got %f", lon)
+	}
+	moved := (lat - 38.9) * metersPerDegreeLat
+	if math.Abs(moved-1000) > 1 {
+		t.Fatalf("expected to move 1000m, moved %.1fm", moved)
+	}
+	if hdg != 0 {
+		t.Fatalf("expected heading 0 (north), got %.1f", hdg)
+	}
+}
+
+func TestCloseOn_ArrivesWithinStep(t *testing.T) {
+	lat, lon, hdg := CloseOn(38.9, -77.0, 38.9, -76.999, 1000)
+	if lat != 38.9 || lon != -76.999 {
+		t.Fatalf("expected to land on target, got (%f, %f)", lat, lon)
+	}
+	if math.Abs(hdg-90) > 0.01 {
+		t.Fatalf("expected heading 90 (east), got %.1f", hdg)
+	}
+}
+
+func TestDistance(t *testing.T) {
+	d := Distance(38.9, -77.0, 39.0, -77.0)
+	if math.Abs(d-11132) > 1 {
+		t.Fatalf("expected ~11132m for 0.1 deg lat, got %.1f", d)
+	}
+}
+
+func TestHandleEvent_ClaimsAddressedAsset(t *testing.T) {
+	e := New(Config{NumAssets: 2})
+
+	c := e.handleEvent(makeTrackEvent(t, "track-1", 38.91, -77.0, assignedTo("effector-1")))
+	if c == nil {
+		t.Fatal("expected a claim")
+	}
+	if c.AssetID != "effector-1" {
+		t.Fatalf("expected addressed asset effector-1, got %s", c.AssetID)
+	}
+	if target, ok := e.Mission("effector-1"); !ok || target != "track-1" {
+		t.Fatalf("expected effector-1 on track-1, got %q %v", target, ok)
+	}
+	if _, ok := e.Mission("effector-0"); ok {
+		t.Fatal("expected effector-0 to stay free")
+	}
+
+	// Re-delivery of the same assignment must not claim again.
+	if c := e.handleEvent(makeTrackEvent(t, "track-1", 38.91, -77.0, assignedTo("effector-1"))); c != nil {
+		t.Fatalf("expected no second claim, got %+v", c)
+	}
+}
+
+func TestHandleEvent_IgnoresUnaddressed(t *testing.T) {
+	e := New(Config{NumAssets: 1})
+
+	if c := e.handleEvent(makeTrackEvent(t, "track-1", 38.9, -77.0, nil)); c != nil {
+		t.Fatal("expected no claim without assignment")
+	}
+	queued := &entityv1.AssignmentComponent{Task: "intercept", Status: entityv1.TaskStatus_TASK_STATUS_QUEUED}
+	if c := e.handleEvent(makeTrackEvent(t, "track-2", 38.9, -77.0, queued)); c != nil {
+		t.Fatal("expected no claim for a queued assignment")
+	}
+	if c := e.handleEvent(makeTrackEvent(t, "track-3", 38.9, -77.0, assignedTo("someone-else"))); c != nil {
+		t.Fatal("expected no claim for another effector's asset")
+	}
+}
+
+func TestHandleEvent_CommittedAssetNotReused(t *testing.T) {
+	e := New(Config{NumAssets: 1})
+
+	if c := e.handleEvent(makeTrackEvent(t, "track-1", 38.9, -77.0, assignedTo("effector-0"))); c == nil {
+		t.Fatal("expected first claim")
+	}
+	if c := e.handleEvent(makeTrackEvent(t, "track-2", 38.9, -77.0, assignedTo("effector-0"))); c != nil {
+		t.Fatalf("expected no claim while asset is committed, got %+v", c)
+	}
+	if target, _ := e.Mission("effector-0"); target != "track-1" {
+		t.Fatalf("expected effector-0 to stay on track-1, got %q", target)
+	}
+}
+
+func TestHandleEvent_DeleteFreesAsset(t *testing.T) {
+	e := New(Config{NumAssets: 1})
+	e.handleEvent(makeTrackEvent(t, "track-1", 38.9, -77.0, assignedTo("effector-0")))
+
+	e.handleEvent(&storev1.EntityEvent{
+		Type:   storev1.EventType_EVENT_TYPE_DELETED,
+		Entity: &entityv1.Entity{Id: "track-1"},
+	})
+
+	if _, ok := e.Mission("effector-0"); ok {
+		t.Fatal("expected asset freed after target deleted")
+	}
+}
+
+func TestStep_CompletesInsideRange(t *testing.T) {
+	e := New(Config{NumAssets: 1, SpeedKnots: 600, InterceptRange: 500, BaseLat: 38.9, BaseLon: -77.0})
+	e.handleEvent(makeTrackEvent(t, "track-1", 38.92, -77.0, assignedTo("effector-0"))) // ~2.2km north
+
+	var done []outcome
+	for i := 0; i < 10 && len(done) == 0; i++ {
+		done = e.step(time.Second) // ~308m per step
+	}
+	if len(done) != 1 {
+		t.Fatalf("expected mission to complete, got %v", done)
+	}
+	if done[0].Status != entityv1.TaskStatus_TASK_STATUS_COMPLETED {
+		t.Fatalf("expected COMPLETED, got %s", done[0].Status)
+	}
+	if _, ok := e.Mission("effector-0"); ok {
+		t.Fatal("expected asset free after completion")
+	}
+}
+
+func TestStep_TimesOut(t *testing.T) {
+	e := New(Config{NumAssets: 1, SpeedKnots: 1, InterceptRange: 10, MissionTimeout: time.Millisecond, BaseLat: 38.9, BaseLon: -77.0})
+	e.handleEvent(makeTrackEvent(t, "track-1", 39.5, -77.0, assignedTo("effector-0")))
+	time.Sleep(5 * time.Millisecond)
+
+	done := e.step(time.Second)
+	if len(done) != 1 || done[0].Status != entityv1.TaskStatus_TASK_STATUS_FAILED {
+		t.Fatalf("expected FAILED outcome, got %v", done)
+	}
+}
+
+func startTestServer(t *testing.T) (string, func()) {
+	t.Helper()
+
+	s := store.New()
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go srv.Serve(lis) //nolint:errcheck
+
+	return lis.Addr().String(), func() { srv.Stop() }
+}
+
+func TestEffectorIntegration(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	cfg.Interval = 50 * time.Millisecond
+	cfg.NumAssets = 1
+	cfg.BaseLat, cfg.BaseLon = 38.9, -77.0
+
+	eff := New(cfg)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go eff.Run(ctx) //nolint:errcheck
+	time.Sleep(200 * time.Millisecond)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	// Asset entity should be published.
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "effector-0"}); err != nil {
+		t.Fatalf("expected asset entity: %v", err)
+	}
+
+	ev := makeTrackEvent(t, "track-fx", 38.905, -77.0, assignedTo("effector-0"))
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: ev.Entity}); err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		got, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "track-fx"})
+		if err != nil {
+			t.Fatalf("GetEntity: %v", err)
+		}
+		a, err := extractAssignment(got)
+		if err == nil && a.Status == entityv1.TaskStatus_TASK_STATUS_COMPLETED {
+			if a.AssetId != "effector-0" {
+				t.Fatalf("expected effector-0 credited, got %q", a.AssetId)
+			}
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatal("intercept did not complete")
+}
+
+func TestDefaultConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.StoreAddr != "localhost:50051" {
+		t.Fatalf("expected localhost:50051, got %s", cfg.StoreAddr)
+	}
+	if cfg.NumAssets != 2 {
+		t.Fatalf("expected 2 assets, got %d", cfg.NumAssets)
+	}
+}
@@ -0,0 +1,271 @@
+// Package eventbridge publishes entity events to a NATS subject or Kafka
+// topic and optionally applies events consumed from another, so systems
+// outside the lab can integrate without the gRPC API.
+package eventbridge
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/watch"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Broker is the messaging system to bridge to.
+type Broker string
+
+const (
+	BrokerNATS  Broker = "nats"
+	BrokerKafka Broker = "kafka"
+)
+
+// Format is the payload encoding of a storev1.EntityEvent.
+type Format string
+
+const (
+	FormatJSON     Format = "protojson"
+	FormatProtobuf Format = "protobuf"
+)
+
+// Config controls the event bridge.
+type Config struct {
+	StoreAddr  string
+	Broker     Broker
+	URL        string // nats://host:4222, or comma-separated Kafka seed brokers
+	Out        string // subject or topic events are published to
+	In         string // subject or topic to apply events from; empty disables
+	Group      string // Kafka consumer group for In
+	Format     Format
+	TypeFilter entityv1.EntityType // publish only this type; unspecified publishes all
+	NodeID     string              // stamped as origin_node; inbound events with it are skipped
+}
+
+// DefaultConfig returns a config publishing protojson to a local NATS
+// server, without inbound.
+func DefaultConfig() Config {
+	host, _ := os.Hostname()
+	return Config{
+		StoreAddr: "localhost:50051",
+		Broker:    BrokerNATS,
+		URL:       "nats://localhost:4222",
+		Out:       "lattice.entities",
+		Group:     "lattice-event-bridge",
+		Format:    FormatJSON,
+		NodeID:    "event-bridge-" + host,
+	}
+}
+
+// Validate checks the config is runnable.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.Broker != BrokerNATS && cfg.Broker != BrokerKafka:
+		return fmt.Errorf("unknown broker %q (want nats or kafka)", cfg.Broker)
+	case cfg.Format != FormatJSON && cfg.Format != FormatProtobuf:
+		return fmt.Errorf("unknown format %q (want protojson or protobuf)", cfg.Format)
+	case cfg.URL == "" || cfg.Out == "":
+		return fmt.Errorf("broker url and outbound subject/topic are required")
+	case cfg.In != "" && cfg.Broker == BrokerKafka && cfg.Group == "":
+		return fmt.Errorf("kafka inbound requires a consumer group")
+	case cfg.NodeID == "":
+		return fmt.Errorf("node id is required")
+	}
+	return nil
+}
+
+// Bridge copies entity events between a store and a broker.
+type Bridge struct {
+	cfg Config
+
+	mu      sync.Mutex
+	applied map[string]*entityv1.Entity // last inbound write per entity, to suppress its echo
+}
+
+// New creates a bridge with the given config.
+func New(cfg Config) *Bridge {
+	return &Bridge{cfg: cfg, applied: make(map[string]*entityv1.Entity)}
+}
+
+// Run connects to the store and the broker, publishes every watched event,
+// and applies inbound events, until ctx is cancelled.
+func (b *Bridge) Run(ctx context.Context) error {
+	tr, err := Dial(b.cfg)
+	if err != nil {
+		return err
+	}
+	defer tr.Close()
+	return b.run(ctx, tr)
+}
+
+func (b *Bridge) run(ctx context.Context, tr Transport) error {
+	conn, err := client.Dial(b.cfg.StoreAddr)
+	if err != nil {
+		return fmt.Errorf("connect to store: %w", err)
+	}
+	defer conn.Close()
+
+	client := storev1.NewEntityStoreServiceClient(conn)
+	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{TypeFilter: b.cfg.TypeFilter})
+	if err != nil {
+		return fmt.Errorf("watch entities: %w", err)
+	}
+
+	if b.cfg.In != "" {
+		go func() {
+			err := tr.Consume(ctx, func(payload []byte) {
+				if err := b.apply(ctx, client, payload); err != nil {
+					slog.Error("apply inbound event failed", "error", err)
+				}
+			})
+			if err != nil {
+				slog.Error("inbound stopped", "in", b.cfg.In, "error", err)
+			}
+		}()
+	}
+
+	slog.Info("event-bridge started", "broker", b.cfg.Broker, "url", b.cfg.URL, "out", b.cfg.Out, "in", b.cfg.In, "format", b.cfg.Format, "store_addr", b.cfg.StoreAddr)
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("recv: %w", err)
+		}
+		if b.echo(event) {
+			continue
+		}
+		if err := b.publish(ctx, tr, event); err != nil {
+			slog.Error("publish event failed", "entity_id", event.Entity.GetId(), "error", err)
+		}
+	}
+}
+
+// publish encodes event and sends it, stamped with this bridge's node ID
+// unless it already carries an origin.
+func (b *Bridge) publish(ctx context.Context, tr Transport, event *storev1.EntityEvent) error {
+	if event.OriginNode == "" {
+		event = proto.Clone(event).(*storev1.EntityEvent)
+		event.OriginNode = b.cfg.NodeID
+	}
+	payload, err := b.encode(event)
+	if err != nil {
+		return err
+	}
+	return tr.Publish(ctx, event.Entity.GetId(), payload)
+}
+
+// echo reports whether event is the store's notification of an inbound write
+// this bridge just applied, which should not be published back.
+func (b *Bridge) echo(event *storev1.EntityEvent) bool {
+	id := event.Entity.GetId()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	last, ok := b.applied[id]
+	if !ok {
+		return false
+	}
+	delete(b.applied, id)
+	if watch.Removed(event) {
+		return last == nil
+	}
+	return last != nil && proto.Equal(&entityv1.Entity{Components: last.Components}, &entityv1.Entity{Components: event.Entity.Components})
+}
+
+// apply decodes an inbound event and writes it to the store, naming the
+// event's origin as the writes'. Creates and updates are both upserts
+// carrying the stored HLC.
+func (b *Bridge) apply(ctx context.Context, client storev1.EntityStoreServiceClient, payload []byte) error {
+	event, err := b.decode(payload)
+	if err != nil {
+		return err
+	}
+	if event.OriginNode == b.cfg.NodeID || event.Entity.GetId() == "" {
+		return nil // our own publication, or nothing to apply
+	}
+	id := event.Entity.Id
+	ctx = server.ContextWithOrigin(ctx, event.OriginNode)
+
+	if watch.Removed(event) {
+		b.remember(id, nil)
+		if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: id}); err != nil && status.Code(err) != codes.NotFound {
+			b.forget(id)
+			return fmt.Errorf("delete %s: %w", id, err)
+		}
+		return nil
+	}
+
+	entity := event.Entity
+	entity.CreatedAt, entity.UpdatedAt = nil, nil
+	existing, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: id})
+	if status.Code(err) == codes.NotFound {
+		b.remember(id, entity)
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity}); err != nil {
+			b.forget(id)
+			return fmt.Errorf("create %s: %w", id, err)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get %s: %w", id, err)
+	}
+	entity.HlcPhysical, entity.HlcLogical, entity.HlcNode = existing.HlcPhysical, existing.HlcLogical, existing.HlcNode
+	b.remember(id, entity)
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity}); err != nil {
+		b.forget(id)
+		return fmt.Errorf("update %s: %w", id, err)
+	}
+	return nil
+}
+
+func (b *Bridge) remember(id string, entity *entityv1.Entity) {
+	b.mu.Lock()
+	b.applied[id] = entity
+	b.mu.Unlock()
+}
+
+func (b *Bridge) forget(id string) {
+	b.mu.Lock()
+	delete(b.applied, id)
+	b.mu.Unlock()
+}
+
+func (b *Bridge) encode(event *storev1.EntityEvent) ([]byte, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if b.cfg.Format == FormatProtobuf {
+		data, err = proto.Marshal(event)
+	} else {
+		data, err = protojson.Marshal(event)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("encode event: %w", err)
+	}
+	return data, nil
+}
+
+func (b *Bridge) decode(payload []byte) (*storev1.EntityEvent, error) {
+	event := &storev1.EntityEvent{}
+	var err error
+	if b.cfg.Format == FormatProtobuf {
+		err = proto.Unmarshal(payload, event)
+	} else {
+		err = protojson.Unmarshal(payload, event)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decode event: %w", err)
+	}
+	return event, nil
+}
@@ -0,0 +1,182 @@
+package eventbridge
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func startTestServer(t *testing.T) (string, *store.Store, func()) {
+	t.Helper()
+
+	s := store.New()
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+
+	return lis.Addr().String(), s, func() { srv.Stop() }
+}
+
+// memTransport records publications and feeds inbound payloads from a
+// channel.
+type memTransport struct {
+	mu        sync.Mutex
+	published [][]byte
+	keys      []string
+	inbound   chan []byte
+}
+
+func (m *memTransport) Publish(_ context.Context, key string, payload []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.published = append(m.published, payload)
+	m.keys = append(m.keys, key)
+	return nil
+}
+
+func (m *memTransport) Consume(ctx context.Context, handle func([]byte)) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case p := <-m.inbound:
+			handle(p)
+		}
+	}
+}
+
+func (m *memTransport) Close() {}
+
+func (m *memTransport) snapshot() ([][]byte, []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([][]byte(nil), m.published...), append([]string(nil), m.keys...)
+}
+
+func trackEntity(t *testing.T, id string, lat float64) *entityv1.Entity {
+	t.Helper()
+	pos, err := anypb.New(&entityv1.PositionComponent{Lat: lat, Lon: -77.0})
+	if err != nil {
+		t.Fatalf("pack position: %v", err)
+	}
+	return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos}}
+}
+
+func TestBridge_PublishesAndApplies(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatProtobuf} {
+		t.Run(string(format), func(t *testing.T) {
+			addr, s, cleanup := startTestServer(t)
+			defer cleanup()
+
+			cfg := DefaultConfig()
+			cfg.StoreAddr = addr
+			cfg.In = "external.entities"
+			cfg.Format = format
+			cfg.NodeID = "bridge-test"
+			b := New(cfg)
+			tr := &memTransport{inbound: make(chan []byte, 4)}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go b.run(ctx, tr) //nolint:errcheck
+			time.Sleep(100 * time.Millisecond)
+
+			// Local writes are published, keyed by entity ID.
+			if _, err := s.Create(trackEntity(t, "track-0", 38.9)); err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			// Inbound events are applied but not echoed back; our own
+			// publications coming back around are ignored.
+			for _, ev := range []*storev1.EntityEvent{
+				{Type: storev1.EventType_EVENT_TYPE_CREATED, Entity: trackEntity(t, "ext-1", 38.5), OriginNode: "partner"},
+				{Type: storev1.EventType_EVENT_TYPE_UPDATED, Entity: trackEntity(t, "ext-2", 38.6), OriginNode: "bridge-test"},
+			} {
+				payload, err := b.encode(ev)
+				if err != nil {
+					t.Fatalf("encode: %v", err)
+				}
+				tr.inbound <- payload
+			}
+			time.Sleep(200 * time.Millisecond)
+
+			if _, err := s.Get("ext-1"); err != nil {
+				t.Fatalf("expected ext-1 applied: %v", err)
+			}
+			if _, err := s.Get("ext-2"); err == nil {
+				t.Fatal("expected our own echo to be skipped")
+			}
+
+			published, keys := tr.snapshot()
+			if len(published) != 1 || keys[0] != "track-0" {
+				t.Fatalf("expected only track-0 published, got keys %v", keys)
+			}
+			ev, err := b.decode(published[0])
+			if err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if ev.Type != storev1.EventType_EVENT_TYPE_CREATED || ev.OriginNode != "bridge-test" {
+				t.Fatalf("unexpected published event %v", ev)
+			}
+
+			// A later local change to the applied entity is published.
+			e, _ := s.Get("ext-1")
+			e.Components = trackEntity(t, "ext-1", 38.55).Components
+			e.HlcPhysical++
+			if _, err := s.Update(e); err != nil {
+				t.Fatalf("Update: %v", err)
+			}
+			time.Sleep(100 * time.Millisecond)
+			if _, keys := tr.snapshot(); len(keys) != 2 || keys[1] != "ext-1" {
+				t.Fatalf("expected local update to ext-1 published, got %v", keys)
+			}
+		})
+	}
+}
+
+func TestEncode_ProtojsonIsReadable(t *testing.T) {
+	b := New(DefaultConfig())
+	ev := &storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_DELETED, Entity: &entityv1.Entity{Id: "x"}}
+	data, err := b.encode(ev)
+	if err != nil {
+		t.Fatalf("encode: %v", err)
+	}
+	back := &storev1.EntityEvent{}
+	if err := protojson.Unmarshal(data, back); err != nil || !proto.Equal(back, ev) {
+		t.Fatalf("expected plain protojson, got %s (%v)", data, err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("default config: %v", err)
+	}
+	for name, mut := range map[string]func(*Config){
+		"broker":      func(c *Config) { c.Broker = "amqp" },
+		"format":      func(c *Config) { c.Format = "avro" },
+		"no out":      func(c *Config) { c.Out = "" },
+		"kafka group": func(c *Config) { c.Broker, c.In, c.Group = BrokerKafka, "in", "" },
+	} {
+		cfg := DefaultConfig()
+		mut(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
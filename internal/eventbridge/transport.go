@@ -0,0 +1,100 @@
+package eventbridge
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Transport moves encoded events to and from a message broker.
+type Transport interface {
+	// Publish sends one event; key is the entity ID, used for partitioning
+	// where the broker supports it.
+	Publish(ctx context.Context, key string, payload []byte) error
+	// Consume delivers inbound messages to handle until ctx is cancelled.
+	Consume(ctx context.Context, handle func(payload []byte)) error
+	Close()
+}
+
+// Dial connects to the broker named by cfg.Broker.
+func Dial(cfg Config) (Transport, error) {
+	switch cfg.Broker {
+	case BrokerNATS:
+		return dialNATS(cfg)
+	case BrokerKafka:
+		return dialKafka(cfg)
+	}
+	return nil, fmt.Errorf("unknown broker %q", cfg.Broker)
+}
+
+// natsTransport publishes to one subject and subscribes to another.
+type natsTransport struct {
+	conn    *nats.Conn
+	out, in string
+}
+
+func dialNATS(cfg Config) (*natsTransport, error) {
+	conn, err := nats.Connect(cfg.URL, nats.Name("lattice-event-bridge"), nats.MaxReconnects(-1))
+	if err != nil {
+		return nil, fmt.Errorf("connect nats: %w", err)
+	}
+	return &natsTransport{conn: conn, out: cfg.Out, in: cfg.In}, nil
+}
+
+func (t *natsTransport) Publish(_ context.Context, _ string, payload []byte) error {
+	return t.conn.Publish(t.out, payload)
+}
+
+func (t *natsTransport) Consume(ctx context.Context, handle func([]byte)) error {
+	sub, err := t.conn.Subscribe(t.in, func(m *nats.Msg) { handle(m.Data) })
+	if err != nil {
+		return fmt.Errorf("subscribe %s: %w", t.in, err)
+	}
+	<-ctx.Done()
+	return sub.Unsubscribe()
+}
+
+func (t *natsTransport) Close() { t.conn.Close() }
+
+// kafkaTransport produces to one topic and consumes another in a consumer
+// group.
+type kafkaTransport struct {
+	client  *kgo.Client
+	out, in string
+}
+
+func dialKafka(cfg Config) (*kafkaTransport, error) {
+	opts := []kgo.Opt{kgo.SeedBrokers(strings.Split(cfg.URL, ",")...)}
+	if cfg.In != "" {
+		opts = append(opts, kgo.ConsumeTopics(cfg.In), kgo.ConsumerGroup(cfg.Group))
+	}
+	client, err := kgo.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("connect kafka: %w", err)
+	}
+	return &kafkaTransport{client: client, out: cfg.Out, in: cfg.In}, nil
+}
+
+func (t *kafkaTransport) Publish(ctx context.Context, key string, payload []byte) error {
+	rec := &kgo.Record{Topic: t.out, Key: []byte(key), Value: payload}
+	return t.client.ProduceSync(ctx, rec).FirstErr()
+}
+
+func (t *kafkaTransport) Consume(ctx context.Context, handle func([]byte)) error {
+	for {
+		fetches := t.client.PollFetches(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		fetches.EachError(func(topic string, _ int32, err error) {
+			slog.Warn("kafka fetch failed", "topic", topic, "error", err)
+		})
+		fetches.EachRecord(func(r *kgo.Record) { handle(r.Value) })
+	}
+}
+
+func (t *kafkaTransport) Close() { t.client.Close() }
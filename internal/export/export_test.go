@@ -0,0 +1,229 @@
+package export
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func mustAny(t *testing.T, m proto.Message) *anypb.Any {
+	t.Helper()
+	a, err := anypb.New(m)
+	if err != nil {
+		t.Fatalf("anypb.New: %v", err)
+	}
+	return a
+}
+
+func picture(t *testing.T) []*entityv1.Entity {
+	return []*entityv1.Entity{
+		{
+			Id:   "track-1",
+			Type: entityv1.EntityType_ENTITY_TYPE_TRACK,
+			Components: map[string]*anypb.Any{
+				"position": mustAny(t, &entityv1.PositionComponent{Lat: 38.9, Lon: -77.0, Alt: 3000}),
+				"velocity": mustAny(t, &entityv1.VelocityComponent{Speed: 450, Heading: 270}),
+				"threat":   mustAny(t, &entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH}),
+			},
+		},
+		{
+			Id:   "asset-1",
+			Type: entityv1.EntityType_ENTITY_TYPE_ASSET,
+			Components: map[string]*anypb.Any{
+				"position": mustAny(t, &entityv1.PositionComponent{Lat: 38.8, Lon: -77.1}),
+			},
+		},
+		{Id: "track-no-position", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+		{
+			Id:   "zone-1",
+			Type: entityv1.EntityType_ENTITY_TYPE_GEO,
+			Components: map[string]*anypb.Any{
+				"geo": mustAny(t, &entityv1.GeoComponent{
+					Name: "box",
+					Kind: entityv1.GeoKind_GEO_KIND_RESTRICTED,
+					// Clockwise; the export must flip it.
+					Points: []*entityv1.GeoPoint{{Lat: 0, Lon: 0}, {Lat: 1, Lon: 0}, {Lat: 1, Lon: 1}, {Lat: 0, Lon: 1}},
+					MaxAlt: 5000,
+				}),
+			},
+		},
+		{
+			Id:   "zone-2",
+			Type: entityv1.EntityType_ENTITY_TYPE_GEO,
+			Components: map[string]*anypb.Any{
+				"geo": mustAny(t, &entityv1.GeoComponent{
+					Kind:    entityv1.GeoKind_GEO_KIND_COVERAGE,
+					Points:  []*entityv1.GeoPoint{{Lat: 10, Lon: 10}},
+					RadiusM: 1000,
+				}),
+			},
+		},
+	}
+}
+
+func TestPicture(t *testing.T) {
+	points, areas := Picture(picture(t))
+	if len(points) != 2 || points[0].ID != "asset-1" || points[1].ID != "track-1" {
+		t.Fatalf("expected asset-1 and track-1 sorted, got %+v", points)
+	}
+	if p := points[1]; !p.HasVelocity || p.Heading != 270 || p.Threat != entityv1.ThreatLevel_THREAT_LEVEL_HIGH {
+		t.Fatalf("unexpected track point %+v", p)
+	}
+	if len(areas) != 2 {
+		t.Fatalf("expected 2 areas, got %d", len(areas))
+	}
+	box := areas[0].Ring
+	if len(box) != 5 || !proto.Equal(box[0], box[4]) {
+		t.Fatalf("expected closed 5-point ring, got %v", box)
+	}
+	if box[1].Lat != 0 || box[1].Lon != 1 {
+		t.Fatalf("expected ring wound counter-clockwise, got %v", box)
+	}
+	if len(areas[1].Ring) != circleSegments+1 {
+		t.Fatalf("expected circle of %d points, got %d", circleSegments+1, len(areas[1].Ring))
+	}
+}
+
+func TestGeoJSON(t *testing.T) {
+	fc := GeoJSON(picture(t))
+	data, err := json.Marshal(fc)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var got struct {
+		Type     string `json:"type"`
+		Features []struct {
+			ID       string `json:"id"`
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if got.Type != "FeatureCollection" || len(got.Features) != 4 {
+		t.Fatalf("expected 4 features, got %s", data)
+	}
+	track := got.Features[1]
+	if track.ID != "track-1" || track.Geometry.Type != "Point" || string(track.Geometry.Coordinates) != "[-77,38.9,3000]" {
+		t.Fatalf("unexpected track feature %s", data)
+	}
+	if track.Properties["kind"] != "track" || track.Properties["heading"] != 270.0 || track.Properties["threat"] != "high" {
+		t.Fatalf("unexpected track properties %v", track.Properties)
+	}
+	zone := got.Features[2]
+	if zone.Geometry.Type != "Polygon" || zone.Properties["geo"] != "restricted" || zone.Properties["max_alt"] != 5000.0 {
+		t.Fatalf("unexpected zone feature %s", data)
+	}
+}
+
+func TestGeoJSON_Empty(t *testing.T) {
+	data, _ := json.Marshal(GeoJSON(nil))
+	if string(data) != `{"type":"FeatureCollection","features":[]}` {
+		t.Fatalf("expected empty collection, got %s", data)
+	}
+}
+
+func TestKML(t *testing.T) {
+	out, err := KML(picture(t))
+	if err != nil {
+		t.Fatalf("KML: %v", err)
+	}
+	var doc kmlRoot
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, out)
+	}
+	folders := doc.Document.Folders
+	if len(folders) != 3 || len(folders[0].Placemarks) != 1 || len(folders[1].Placemarks) != 1 || len(folders[2].Placemarks) != 2 {
+		t.Fatalf("unexpected folders %+v", folders)
+	}
+	track := folders[0].Placemarks[0]
+	if track.Point == nil || track.Point.Coordinates != "-77,38.9,3000" || track.Point.AltitudeMode != "absolute" {
+		t.Fatalf("unexpected track placemark %+v", track.Point)
+	}
+	if track.Style.IconStyle.Heading != 270 || track.Style.IconStyle.Color != kmlRed {
+		t.Fatalf("expected red icon headed 270, got %+v", track.Style.IconStyle)
+	}
+	if asset := folders[1].Placemarks[0]; asset.Style.IconStyle.Color != kmlBlue || asset.Point.AltitudeMode != "clampToGround" {
+		t.Fatalf("unexpected asset placemark %+v", asset)
+	}
+	zone := folders[2].Placemarks[0]
+	if zone.Name != "box" || zone.StyleURL != "#geo-restricted" || zone.Polygon == nil || zone.Polygon.Extrude != 1 {
+		t.Fatalf("unexpected zone placemark %+v", zone)
+	}
+	if !strings.HasPrefix(zone.Polygon.Outer.Ring.Coordinates, "0,0,5000 1,0,5000 ") {
+		t.Fatalf("expected ring at the ceiling, got %q", zone.Polygon.Outer.Ring.Coordinates)
+	}
+	if circle := folders[2].Placemarks[1]; circle.Name != "zone-2" || circle.Polygon.Tessellate != 1 {
+		t.Fatalf("expected unnamed circle named by ID and draped, got %+v", circle)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	entities := picture(t)
+	var gotType entityv1.EntityType
+	srv := httptest.NewServer(Handler(func(typ entityv1.EntityType) []*entityv1.Entity {
+		gotType = typ
+		return entities
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		path, contentType string
+		typ               entityv1.EntityType
+	}{
+		{"/geojson", "application/geo+json", entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED},
+		{"/geojson?type=geo", "application/geo+json", entityv1.EntityType_ENTITY_TYPE_GEO},
+		{"/kml?type=track", "application/vnd.google-earth.kml+xml", entityv1.EntityType_ENTITY_TYPE_TRACK},
+	} {
+		resp, err := http.Get(srv.URL + tc.path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", tc.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != tc.contentType || gotType != tc.typ {
+			t.Fatalf("GET %s: status %d, type %q, filter %v", tc.path, resp.StatusCode, resp.Header.Get("Content-Type"), gotType)
+		}
+	}
+
+	for _, path := range []string{"/geojson?type=zone", "/kml/live?refresh=0"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Fatalf("GET %s: expected 400, got %d", path, resp.StatusCode)
+		}
+	}
+
+	resp, err := http.Get(srv.URL + "/kml/live?refresh=10")
+	if err != nil {
+		t.Fatalf("GET /kml/live: %v", err)
+	}
+	defer resp.Body.Close()
+	var link struct {
+		NetworkLink struct {
+			Link struct {
+				Href            string `xml:"href"`
+				RefreshInterval int    `xml:"refreshInterval"`
+			} `xml:"Link"`
+		} `xml:"NetworkLink"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&link); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if link.NetworkLink.Link.Href != srv.URL+"/kml" || link.NetworkLink.Link.RefreshInterval != 10 {
+		t.Fatalf("unexpected network link %+v", link.NetworkLink.Link)
+	}
+}
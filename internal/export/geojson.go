@@ -0,0 +1,211 @@
+// Package export renders the common operational picture for GIS tools:
+// tracks and assets as points, GEO areas as polygons, in GeoJSON for QGIS
+// and web maps and in KML for Google Earth.
+package export
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"github.com/boshu2/lattice-lab/internal/geo"
+)
+
+// circleSegments is how many sides approximate a circular area.
+const circleSegments = 64
+
+// FeatureCollection is a GeoJSON (RFC 7946) feature collection.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// Feature is one GeoJSON feature.
+type Feature struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id"`
+	Geometry   Geometry       `json:"geometry"`
+	Properties map[string]any `json:"properties"`
+}
+
+// Geometry is a Point ([lon, lat, alt]) or Polygon (one closed ring of
+// [lon, lat]).
+type Geometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// Point is what the picture shows for a TRACK or ASSET.
+type Point struct {
+	ID             string
+	Asset          bool
+	Lat, Lon, Alt  float64
+	Heading, Speed float64 // degrees, knots
+	HasVelocity    bool
+	Label          string
+	Confidence     float32
+	Threat         entityv1.ThreatLevel
+	IFF            entityv1.IFFStatus
+	Domain         entityv1.Domain
+	Updated        time.Time
+}
+
+// Area is what the picture shows for a GEO entity.
+type Area struct {
+	ID   string
+	Geo  *entityv1.GeoComponent
+	Ring []*entityv1.GeoPoint // closed, counter-clockwise
+}
+
+// Picture splits entities into points and areas, each sorted by ID.
+// Entities without a position or area are left out.
+func Picture(entities []*entityv1.Entity) ([]Point, []Area) {
+	entities = slices.Clone(entities)
+	slices.SortFunc(entities, func(a, b *entityv1.Entity) int { return strings.Compare(a.Id, b.Id) })
+
+	var points []Point
+	var areas []Area
+	for _, e := range entities {
+		switch e.Type {
+		case entityv1.EntityType_ENTITY_TYPE_TRACK, entityv1.EntityType_ENTITY_TYPE_ASSET:
+			if p, ok := point(e); ok {
+				points = append(points, p)
+			}
+		case entityv1.EntityType_ENTITY_TYPE_GEO:
+			g := &entityv1.GeoComponent{}
+			if c, ok := e.Components["geo"]; !ok || c.UnmarshalTo(g) != nil {
+				continue
+			}
+			if ring := geo.Outline(g, circleSegments); len(ring) >= 4 {
+				areas = append(areas, Area{ID: e.Id, Geo: g, Ring: counterClockwise(ring)})
+			}
+		}
+	}
+	return points, areas
+}
+
+// counterClockwise returns ring wound counter-clockwise, as both RFC 7946
+// and KML expect of outer boundaries.
+func counterClockwise(ring []*entityv1.GeoPoint) []*entityv1.GeoPoint {
+	var area float64 // shoelace, in lon/lat; positive is counter-clockwise
+	for i := 1; i < len(ring); i++ {
+		area += ring[i-1].Lon*ring[i].Lat - ring[i].Lon*ring[i-1].Lat
+	}
+	if area >= 0 {
+		return ring
+	}
+	out := make([]*entityv1.GeoPoint, len(ring))
+	for i, p := range ring {
+		out[len(ring)-1-i] = p
+	}
+	return out
+}
+
+func point(e *entityv1.Entity) (Point, bool) {
+	pos := &entityv1.PositionComponent{}
+	if c, ok := e.Components["position"]; !ok || c.UnmarshalTo(pos) != nil {
+		return Point{}, false
+	}
+	p := Point{
+		ID:    e.Id,
+		Asset: e.Type == entityv1.EntityType_ENTITY_TYPE_ASSET,
+		Lat:   pos.Lat,
+		Lon:   pos.Lon,
+		Alt:   pos.Alt,
+	}
+	if e.UpdatedAt != nil {
+		p.Updated = e.UpdatedAt.AsTime()
+	}
+	vel := &entityv1.VelocityComponent{}
+	if c, ok := e.Components["velocity"]; ok && c.UnmarshalTo(vel) == nil {
+		p.Heading, p.Speed, p.HasVelocity = vel.Heading, vel.Speed, true
+	}
+	cl := &entityv1.ClassificationComponent{}
+	if c, ok := e.Components["classification"]; ok && c.UnmarshalTo(cl) == nil {
+		p.Label, p.Confidence = cl.Label, cl.Confidence
+	}
+	threat := &entityv1.ThreatComponent{}
+	if c, ok := e.Components["threat"]; ok && c.UnmarshalTo(threat) == nil {
+		p.Threat = threat.Level
+	}
+	iff := &entityv1.IFFComponent{}
+	if c, ok := e.Components["iff"]; ok && c.UnmarshalTo(iff) == nil {
+		p.IFF = iff.Status
+	}
+	src := &entityv1.SourceComponent{}
+	if c, ok := e.Components["source"]; ok && c.UnmarshalTo(src) == nil {
+		p.Domain = src.Domain
+	}
+	return p, true
+}
+
+// GeoJSON renders the picture as a feature collection. Points carry their
+// kind, heading, speed, classification, threat, IFF, and domain as
+// properties; areas their name, kind, and altitude band.
+func GeoJSON(entities []*entityv1.Entity) FeatureCollection {
+	points, areas := Picture(entities)
+	fc := FeatureCollection{Type: "FeatureCollection", Features: []Feature{}}
+	for _, p := range points {
+		props := map[string]any{"kind": "track"}
+		if p.Asset {
+			props["kind"] = "asset"
+		}
+		if p.HasVelocity {
+			props["heading"] = p.Heading
+			props["speed_kts"] = p.Speed
+		}
+		if p.Label != "" {
+			props["label"] = p.Label
+			props["confidence"] = p.Confidence
+		}
+		if p.Threat != entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED {
+			props["threat"] = enumName(p.Threat.String(), "THREAT_LEVEL_")
+		}
+		if p.IFF != entityv1.IFFStatus_IFF_STATUS_UNSPECIFIED {
+			props["iff"] = enumName(p.IFF.String(), "IFF_STATUS_")
+		}
+		if p.Domain != entityv1.Domain_DOMAIN_UNSPECIFIED {
+			props["domain"] = enumName(p.Domain.String(), "DOMAIN_")
+		}
+		if !p.Updated.IsZero() {
+			props["updated"] = p.Updated.UTC().Format(time.RFC3339)
+		}
+		fc.Features = append(fc.Features, Feature{
+			Type:       "Feature",
+			ID:         p.ID,
+			Geometry:   Geometry{Type: "Point", Coordinates: []float64{p.Lon, p.Lat, p.Alt}},
+			Properties: props,
+		})
+	}
+	for _, a := range areas {
+		ring := make([][]float64, len(a.Ring))
+		for i, pt := range a.Ring {
+			ring[i] = []float64{pt.Lon, pt.Lat}
+		}
+		props := map[string]any{
+			"kind":    "geo",
+			"name":    a.Geo.Name,
+			"geo":     enumName(a.Geo.Kind.String(), "GEO_KIND_"),
+			"min_alt": a.Geo.MinAlt,
+		}
+		if a.Geo.MaxAlt != 0 {
+			props["max_alt"] = a.Geo.MaxAlt
+		}
+		if a.Geo.RadiusM > 0 {
+			props["radius_m"] = a.Geo.RadiusM
+		}
+		fc.Features = append(fc.Features, Feature{
+			Type:       "Feature",
+			ID:         a.ID,
+			Geometry:   Geometry{Type: "Polygon", Coordinates: [][][]float64{ring}},
+			Properties: props,
+		})
+	}
+	return fc
+}
+
+// enumName turns THREAT_LEVEL_HIGH into "high".
+func enumName(s, prefix string) string {
+	return strings.ToLower(strings.TrimPrefix(s, prefix))
+}
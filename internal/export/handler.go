@@ -0,0 +1,80 @@
+package export
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+)
+
+// Handler serves the picture from list:
+//
+//	GET /geojson            FeatureCollection (application/geo+json)
+//	GET /kml                KML document
+//	GET /kml/live?refresh=N NetworkLink reloading /kml every N seconds (default 5)
+//
+// /geojson and /kml accept ?type=track, asset, or geo to export one type.
+func Handler(list func(entityv1.EntityType) []*entityv1.Entity) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /geojson", func(w http.ResponseWriter, r *http.Request) {
+		typ, ok := typeParam(w, r)
+		if !ok {
+			return
+		}
+		w.Header().Set("Content-Type", "application/geo+json")
+		json.NewEncoder(w).Encode(GeoJSON(list(typ))) //nolint:errcheck
+	})
+	mux.HandleFunc("GET /kml", func(w http.ResponseWriter, r *http.Request) {
+		typ, ok := typeParam(w, r)
+		if !ok {
+			return
+		}
+		out, err := KML(list(typ))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.google-earth.kml+xml")
+		w.Write(out) //nolint:errcheck
+	})
+	mux.HandleFunc("GET /kml/live", func(w http.ResponseWriter, r *http.Request) {
+		refresh := 5
+		if v := r.URL.Query().Get("refresh"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 {
+				http.Error(w, "refresh must be a positive number of seconds", http.StatusBadRequest)
+				return
+			}
+			refresh = n
+		}
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		out, err := NetworkLink(scheme+"://"+r.Host+"/kml", refresh)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.google-earth.kml+xml")
+		w.Write(out) //nolint:errcheck
+	})
+	return mux
+}
+
+// typeParam parses ?type=; it writes a 400 and reports false if invalid.
+func typeParam(w http.ResponseWriter, r *http.Request) (entityv1.EntityType, bool) {
+	switch r.URL.Query().Get("type") {
+	case "":
+		return entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED, true
+	case "track":
+		return entityv1.EntityType_ENTITY_TYPE_TRACK, true
+	case "asset":
+		return entityv1.EntityType_ENTITY_TYPE_ASSET, true
+	case "geo":
+		return entityv1.EntityType_ENTITY_TYPE_GEO, true
+	}
+	http.Error(w, "type must be track, asset, or geo", http.StatusBadRequest)
+	return 0, false
+}
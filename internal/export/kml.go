@@ -0,0 +1,251 @@
+package export
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+)
+
+const kmlNS = "http://www.opengis.net/kml/2.2"
+
+type kmlRoot struct {
+	XMLName  xml.Name    `xml:"kml"`
+	NS       string      `xml:"xmlns,attr"`
+	Document kmlDocument `xml:"Document"`
+}
+
+type kmlDocument struct {
+	Name    string      `xml:"name"`
+	Styles  []kmlStyle  `xml:"Style"`
+	Folders []kmlFolder `xml:"Folder"`
+}
+
+type kmlFolder struct {
+	Name       string         `xml:"name"`
+	Placemarks []kmlPlacemark `xml:"Placemark"`
+}
+
+type kmlPlacemark struct {
+	ID          string      `xml:"id,attr"`
+	Name        string      `xml:"name"`
+	Description string      `xml:"description,omitempty"`
+	StyleURL    string      `xml:"styleUrl,omitempty"`
+	Style       *kmlStyle   `xml:"Style,omitempty"`
+	Point       *kmlPoint   `xml:"Point,omitempty"`
+	Polygon     *kmlPolygon `xml:"Polygon,omitempty"`
+}
+
+type kmlStyle struct {
+	ID        string        `xml:"id,attr,omitempty"`
+	IconStyle *kmlIconStyle `xml:"IconStyle,omitempty"`
+	LineStyle *kmlLineStyle `xml:"LineStyle,omitempty"`
+	PolyStyle *kmlPolyStyle `xml:"PolyStyle,omitempty"`
+}
+
+type kmlIconStyle struct {
+	Color   string  `xml:"color"`
+	Heading float64 `xml:"heading"`
+	Icon    kmlIcon `xml:"Icon"`
+}
+
+type kmlIcon struct {
+	Href string `xml:"href"`
+}
+
+type kmlLineStyle struct {
+	Color string  `xml:"color"`
+	Width float64 `xml:"width"`
+}
+
+type kmlPolyStyle struct {
+	Color string `xml:"color"`
+}
+
+type kmlPoint struct {
+	AltitudeMode string `xml:"altitudeMode"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+type kmlPolygon struct {
+	Extrude      int         `xml:"extrude,omitempty"`
+	Tessellate   int         `xml:"tessellate,omitempty"`
+	AltitudeMode string      `xml:"altitudeMode"`
+	Outer        kmlBoundary `xml:"outerBoundaryIs"`
+}
+
+type kmlBoundary struct {
+	Ring struct {
+		Coordinates string `xml:"coordinates"`
+	} `xml:"LinearRing"`
+}
+
+// KML colours are aabbggrr.
+const (
+	kmlRed    = "ff0000ff"
+	kmlOrange = "ff0080ff"
+	kmlYellow = "ff00ffff"
+	kmlGreen  = "ff00ff00"
+	kmlBlue   = "ffff0000"
+	kmlWhite  = "ffffffff"
+)
+
+// areaStyles are the shared styles of GEO areas, by kind: an outline and
+// a translucent fill.
+var areaStyles = []kmlStyle{
+	{ID: "geo-restricted", LineStyle: &kmlLineStyle{Color: kmlRed, Width: 2}, PolyStyle: &kmlPolyStyle{Color: "400000ff"}},
+	{ID: "geo-engagement", LineStyle: &kmlLineStyle{Color: kmlOrange, Width: 2}, PolyStyle: &kmlPolyStyle{Color: "400080ff"}},
+	{ID: "geo-coverage", LineStyle: &kmlLineStyle{Color: kmlGreen, Width: 1}, PolyStyle: &kmlPolyStyle{Color: "2000ff00"}},
+	{ID: "geo-unspecified", LineStyle: &kmlLineStyle{Color: kmlWhite, Width: 1}, PolyStyle: &kmlPolyStyle{Color: "20ffffff"}},
+}
+
+// KML renders the picture as a KML document with a Tracks, an Assets, and
+// an Areas folder. Points are icons rotated to their heading and coloured
+// by threat (assets and friends blue); areas are outlined and filled by
+// kind, extruded to their ceiling when they have one.
+func KML(entities []*entityv1.Entity) ([]byte, error) {
+	points, areas := Picture(entities)
+	tracks := kmlFolder{Name: "Tracks"}
+	assets := kmlFolder{Name: "Assets"}
+	zones := kmlFolder{Name: "Areas"}
+
+	for _, p := range points {
+		mode := "clampToGround"
+		if p.Alt > 0 {
+			mode = "absolute"
+		}
+		pm := kmlPlacemark{
+			ID:          p.ID,
+			Name:        p.ID,
+			Description: describePoint(p),
+			Style: &kmlStyle{IconStyle: &kmlIconStyle{
+				Color:   pointColor(p),
+				Heading: p.Heading,
+				Icon:    kmlIcon{Href: pointIcon(p)},
+			}},
+			Point: &kmlPoint{AltitudeMode: mode, Coordinates: fmt.Sprintf("%g,%g,%g", p.Lon, p.Lat, p.Alt)},
+		}
+		if p.Asset {
+			assets.Placemarks = append(assets.Placemarks, pm)
+		} else {
+			tracks.Placemarks = append(tracks.Placemarks, pm)
+		}
+	}
+
+	for _, a := range areas {
+		poly := &kmlPolygon{Tessellate: 1, AltitudeMode: "clampToGround"}
+		alt := 0.0
+		if a.Geo.MaxAlt > 0 {
+			poly = &kmlPolygon{Extrude: 1, AltitudeMode: "absolute"}
+			alt = a.Geo.MaxAlt
+		}
+		coords := make([]string, len(a.Ring))
+		for i, pt := range a.Ring {
+			coords[i] = fmt.Sprintf("%g,%g,%g", pt.Lon, pt.Lat, alt)
+		}
+		poly.Outer.Ring.Coordinates = strings.Join(coords, " ")
+		name := a.Geo.Name
+		if name == "" {
+			name = a.ID
+		}
+		zones.Placemarks = append(zones.Placemarks, kmlPlacemark{
+			ID:          a.ID,
+			Name:        name,
+			Description: describeArea(a),
+			StyleURL:    "#geo-" + enumName(a.Geo.Kind.String(), "GEO_KIND_"),
+			Polygon:     poly,
+		})
+	}
+
+	doc := kmlRoot{NS: kmlNS, Document: kmlDocument{
+		Name:    "lattice-lab",
+		Styles:  areaStyles,
+		Folders: []kmlFolder{tracks, assets, zones},
+	}}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// NetworkLink returns a KML document that makes Google Earth reload href
+// every refreshSeconds, for a live picture.
+func NetworkLink(href string, refreshSeconds int) ([]byte, error) {
+	type link struct {
+		Href            string `xml:"href"`
+		RefreshMode     string `xml:"refreshMode"`
+		RefreshInterval int    `xml:"refreshInterval"`
+	}
+	doc := struct {
+		XMLName     xml.Name `xml:"kml"`
+		NS          string   `xml:"xmlns,attr"`
+		NetworkLink struct {
+			Name string `xml:"name"`
+			Link link   `xml:"Link"`
+		} `xml:"NetworkLink"`
+	}{NS: kmlNS}
+	doc.NetworkLink.Name = "lattice-lab (live)"
+	doc.NetworkLink.Link = link{Href: href, RefreshMode: "onInterval", RefreshInterval: refreshSeconds}
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+func pointColor(p Point) string {
+	switch {
+	case p.Asset || p.IFF == entityv1.IFFStatus_IFF_STATUS_FRIEND:
+		return kmlBlue
+	case p.IFF == entityv1.IFFStatus_IFF_STATUS_HOSTILE || p.Threat == entityv1.ThreatLevel_THREAT_LEVEL_HIGH:
+		return kmlRed
+	case p.Threat == entityv1.ThreatLevel_THREAT_LEVEL_MEDIUM:
+		return kmlOrange
+	case p.Threat == entityv1.ThreatLevel_THREAT_LEVEL_LOW:
+		return kmlYellow
+	case p.IFF == entityv1.IFFStatus_IFF_STATUS_NEUTRAL:
+		return kmlGreen
+	}
+	return kmlWhite
+}
+
+func pointIcon(p Point) string {
+	switch p.Domain {
+	case entityv1.Domain_DOMAIN_SURFACE:
+		return "https://maps.google.com/mapfiles/kml/shapes/ferry.png"
+	case entityv1.Domain_DOMAIN_LAND:
+		return "https://maps.google.com/mapfiles/kml/shapes/truck.png"
+	}
+	return "https://maps.google.com/mapfiles/kml/shapes/airports.png"
+}
+
+func describePoint(p Point) string {
+	var parts []string
+	if p.Label != "" {
+		parts = append(parts, fmt.Sprintf("%s (%.0f%%)", p.Label, p.Confidence*100))
+	}
+	if p.Threat != entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED {
+		parts = append(parts, "threat "+enumName(p.Threat.String(), "THREAT_LEVEL_"))
+	}
+	if p.IFF != entityv1.IFFStatus_IFF_STATUS_UNSPECIFIED {
+		parts = append(parts, "IFF "+enumName(p.IFF.String(), "IFF_STATUS_"))
+	}
+	if p.HasVelocity {
+		parts = append(parts, fmt.Sprintf("%.0f kts, heading %.0f°", p.Speed, p.Heading))
+	}
+	parts = append(parts, fmt.Sprintf("alt %.0f m", p.Alt))
+	return strings.Join(parts, ", ")
+}
+
+func describeArea(a Area) string {
+	desc := enumName(a.Geo.Kind.String(), "GEO_KIND_")
+	if a.Geo.RadiusM > 0 {
+		desc += fmt.Sprintf(", radius %.0f m", a.Geo.RadiusM)
+	}
+	if a.Geo.MaxAlt > 0 {
+		desc += fmt.Sprintf(", %.0f–%.0f m", a.Geo.MinAlt, a.Geo.MaxAlt)
+	}
+	return desc
+}
@@ -10,30 +10,78 @@ import (
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
+// RelationFusedFrom links a fused entity to each of its source tracks. The
+// links cascade, so deleting a source deletes the fused entity.
+const RelationFusedFrom = "fused_from"
+
+var (
+	correlations = metrics.NewGauge("lattice_fusion_correlations",
+		"Track pairs from different sensors currently correlated.")
+	fusedWrites = metrics.NewCounter("lattice_fusion_fused_writes_total",
+		"Fused entity writes, by op (create, update, delete) and result (ok, error).", "op", "result")
+)
+
 // Config controls the fusion service.
 type Config struct {
 	StoreAddr     string
-	DistThreshold float64 // degrees, default 0.01 (~1.1km)
+	DistThreshold float64 // meters, default 1000
+
+	// FlatEarth measures distance as before: Euclidean in degrees, with
+	// DistThreshold in degrees too. A degree of longitude shrinks toward
+	// the poles, so the old threshold fused tracks further apart north to
+	// south than east to west.
+	FlatEarth bool
+
+	// Tracks close enough to fuse are still kept apart if they disagree on
+	// how high or how fast they are, so a jet passing over a helicopter is
+	// not fused with it. AltThreshold is the most their altitudes, in
+	// meters, may differ; SpeedThreshold their speeds, in knots; and
+	// HeadingThreshold their headings, in degrees, compared only when both
+	// are moving faster than minHeadingSpeed. Altitudes are compared only
+	// between tracks that both report one, and speed and heading only
+	// between tracks that both report a velocity. Zero turns a check off.
+	AltThreshold     float64 // meters, default 300
+	SpeedThreshold   float64 // knots, default 100
+	HeadingThreshold float64 // degrees, default 45
 }
 
 // DefaultConfig returns fusion defaults.
 func DefaultConfig() Config {
 	return Config{
-		StoreAddr:     "localhost:50051",
-		DistThreshold: 0.01,
+		StoreAddr:        "localhost:50051",
+		DistThreshold:    1000,
+		AltThreshold:     300,
+		SpeedThreshold:   100,
+		HeadingThreshold: 45,
 	}
 }
 
-// trackInfo holds extracted position and sensor data for a track entity.
+// earthRadius is the Earth's mean radius in meters.
+const earthRadius = 6371008.8
+
+// minHeadingSpeed is the speed, in knots, below which a track's heading is
+// too noisy to compare: a hovering helicopter or a ship at anchor points
+// anywhere.
+const minHeadingSpeed = 5
+
+// trackInfo holds extracted position, velocity, and sensor data for a
+// track entity.
 type trackInfo struct {
 	entityID string
 	lat, lon float64
+	alt      float64
 	sensorID string
+
+	hasAlt         bool // whether the track reports an altitude; a 2-D sensor's reads 0
+	hasVel         bool // whether the track reports a velocity
+	speed, heading float64
 }
 
 // Correlation represents a pair of tracks from different sensors that are
@@ -86,35 +134,7 @@ func (f *Fusioner) RemoveTrack(entityID string) {
 func (f *Fusioner) Correlations() []Correlation {
 	f.mu.RLock()
 	defer f.mu.RUnlock()
-
-	// Collect tracks into a slice for pairwise comparison.
-	all := make([]*trackInfo, 0, len(f.tracks))
-	for _, ti := range f.tracks {
-		all = append(all, ti)
-	}
-
-	var corrs []Correlation
-	for i := 0; i < len(all); i++ {
-		for j := i + 1; j < len(all); j++ {
-			a, b := all[i], all[j]
-			// Skip same-sensor pairs.
-			if a.sensorID == b.sensorID {
-				continue
-			}
-			if Distance(a.lat, a.lon, b.lat, b.lon) < f.cfg.DistThreshold {
-				// Deterministic fused ID from sorted track IDs.
-				ids := []string{a.entityID, b.entityID}
-				sort.Strings(ids)
-				fusedID := fmt.Sprintf("fused-%s-%s", ids[0], ids[1])
-				corrs = append(corrs, Correlation{
-					TrackA:  a.entityID,
-					TrackB:  b.entityID,
-					FusedID: fusedID,
-				})
-			}
-		}
-	}
-	return corrs
+	return f.correlationsLocked()
 }
 
 // BuildFusedEntities constructs Entity protos for all current correlations.
@@ -133,7 +153,7 @@ func (f *Fusioner) BuildFusedEntities() []*entityv1.Entity {
 		}
 
 		lat, lon := FusedPosition(a, b)
-		dist := Distance(a.lat, a.lon, b.lat, b.lon)
+		dist := f.distance(a, b)
 		// Confidence: inversely proportional to distance, capped at 1.0.
 		confidence := float32(1.0 - (dist / f.cfg.DistThreshold))
 		if confidence < 0.1 {
@@ -181,10 +201,12 @@ func (f *Fusioner) correlationsLocked() []Correlation {
 	for i := 0; i < len(all); i++ {
 		for j := i + 1; j < len(all); j++ {
 			a, b := all[i], all[j]
+			// Skip same-sensor pairs.
 			if a.sensorID == b.sensorID {
 				continue
 			}
-			if Distance(a.lat, a.lon, b.lat, b.lon) < f.cfg.DistThreshold {
+			if f.distance(a, b) < f.cfg.DistThreshold && f.consistent(a, b) {
+				// Deterministic fused ID from sorted track IDs.
 				ids := []string{a.entityID, b.entityID}
 				sort.Strings(ids)
 				fusedID := fmt.Sprintf("fused-%s-%s", ids[0], ids[1])
@@ -204,8 +226,58 @@ func FusedPosition(a, b *trackInfo) (lat, lon float64) {
 	return (a.lat + b.lat) / 2.0, (a.lon + b.lon) / 2.0
 }
 
+// distance returns how far apart two tracks are, in the units of
+// DistThreshold.
+func (f *Fusioner) distance(a, b *trackInfo) float64 {
+	if f.cfg.FlatEarth {
+		return Distance(a.lat, a.lon, b.lat, b.lon)
+	}
+	return Haversine(a.lat, a.lon, b.lat, b.lon)
+}
+
+// consistent reports whether two nearby tracks agree closely enough on
+// altitude, speed, and heading to be one object.
+func (f *Fusioner) consistent(a, b *trackInfo) bool {
+	if f.cfg.AltThreshold > 0 && a.hasAlt && b.hasAlt && math.Abs(a.alt-b.alt) > f.cfg.AltThreshold {
+		return false
+	}
+	if !a.hasVel || !b.hasVel {
+		return true
+	}
+	if f.cfg.SpeedThreshold > 0 && math.Abs(a.speed-b.speed) > f.cfg.SpeedThreshold {
+		return false
+	}
+	if f.cfg.HeadingThreshold > 0 && a.speed >= minHeadingSpeed && b.speed >= minHeadingSpeed &&
+		HeadingDiff(a.heading, b.heading) > f.cfg.HeadingThreshold {
+		return false
+	}
+	return true
+}
+
+// HeadingDiff returns the angle in degrees, from 0 to 180, between two
+// headings in degrees.
+func HeadingDiff(h1, h2 float64) float64 {
+	d := math.Mod(math.Abs(h1-h2), 360)
+	if d > 180 {
+		d = 360 - d
+	}
+	return d
+}
+
+// Haversine returns the great-circle distance in meters between two points,
+// on a spherical Earth. It errs by under 0.5% from the ellipsoid's geodesic,
+// anywhere.
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dphi := phi2 - phi1
+	dlambda := (lon2 - lon1) * math.Pi / 180
+	h := math.Sin(dphi/2)*math.Sin(dphi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dlambda/2)*math.Sin(dlambda/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(min(h, 1)))
+}
+
 // Distance returns the Euclidean distance in degrees between two points
-// (flat-earth approximation, fine for the learning lab).
+// (flat-earth approximation), as fusion measured before Haversine; used
+// when Config.FlatEarth is set.
 func Distance(lat1, lon1, lat2, lon2 float64) float64 {
 	dlat := lat2 - lat1
 	dlon := lon2 - lon1
@@ -232,18 +304,30 @@ func extractTrackInfo(entity *entityv1.Entity) (*trackInfo, error) {
 		return nil, fmt.Errorf("unmarshal source on %s: %w", entity.Id, err)
 	}
 
-	return &trackInfo{
+	ti := &trackInfo{
 		entityID: entity.Id,
 		lat:      pos.Lat,
 		lon:      pos.Lon,
+		alt:      pos.Alt,
+		hasAlt:   pos.Alt != 0,
 		sensorID: src.SensorId,
-	}, nil
+	}
+	// Velocity is optional: a track without one is gated on position and
+	// altitude alone.
+	if velAny, ok := entity.Components["velocity"]; ok {
+		vel := &entityv1.VelocityComponent{}
+		if err := velAny.UnmarshalTo(vel); err != nil {
+			return nil, fmt.Errorf("unmarshal velocity on %s: %w", entity.Id, err)
+		}
+		ti.hasVel, ti.speed, ti.heading = true, vel.Speed, vel.Heading
+	}
+	return ti, nil
 }
 
 // Run connects to the store, watches all TRACK entities, and manages fused
 // entities until ctx is cancelled.
 func (f *Fusioner) Run(ctx context.Context) error {
-	conn, err := grpc.NewClient(f.cfg.StoreAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := client.Dial(f.cfg.StoreAddr)
 	if err != nil {
 		return fmt.Errorf("connect to store: %w", err)
 	}
@@ -258,7 +342,8 @@ func (f *Fusioner) Run(ctx context.Context) error {
 		return fmt.Errorf("watch entities: %w", err)
 	}
 
-	slog.Info("fusion service watching tracks", "store_addr", f.cfg.StoreAddr, "dist_threshold", f.cfg.DistThreshold)
+	slog.Info("fusion service watching tracks", "store_addr", f.cfg.StoreAddr, "dist_threshold", f.cfg.DistThreshold, "flat_earth", f.cfg.FlatEarth,
+		"alt_threshold", f.cfg.AltThreshold, "speed_threshold", f.cfg.SpeedThreshold, "heading_threshold", f.cfg.HeadingThreshold)
 
 	// Track which fused entities currently exist in the store.
 	activeFused := make(map[string]bool)
@@ -273,15 +358,20 @@ func (f *Fusioner) Run(ctx context.Context) error {
 		}
 
 		switch event.Type {
-		case storev1.EventType_EVENT_TYPE_DELETED:
+		case storev1.EventType_EVENT_TYPE_DELETED, storev1.EventType_EVENT_TYPE_EXPIRED:
 			f.RemoveTrack(event.Entity.Id)
 		default:
-			f.UpdateTrack(event.Entity)
+			// Fused entities carry no source, so their own updates come
+			// back here; recomputing on them would rewrite them forever.
+			if !f.UpdateTrack(event.Entity) {
+				continue
+			}
 		}
 
 		// Recompute correlations.
 		fused := f.BuildFusedEntities()
 		newFused := make(map[string]bool)
+		correlations.Set(float64(len(fused)))
 
 		for _, ent := range fused {
 			newFused[ent.Id] = true
@@ -289,15 +379,20 @@ func (f *Fusioner) Run(ctx context.Context) error {
 				// Update existing fused entity.
 				if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: ent}); err != nil {
 					slog.Error("update fused entity", "id", ent.Id, "error", err)
+					fusedWrites.Inc("update", "error")
 				} else {
 					slog.Info("updated fused entity", "id", ent.Id)
+					fusedWrites.Inc("update", "ok")
 				}
 			} else {
 				// Create new fused entity.
 				if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: ent}); err != nil {
 					slog.Error("create fused entity", "id", ent.Id, "error", err)
+					fusedWrites.Inc("create", "error")
 				} else {
 					slog.Info("created fused entity", "id", ent.Id)
+					fusedWrites.Inc("create", "ok")
+					linkSources(ctx, client, ent)
 				}
 			}
 		}
@@ -305,10 +400,13 @@ func (f *Fusioner) Run(ctx context.Context) error {
 		// Delete fused entities that are no longer correlated.
 		for id := range activeFused {
 			if !newFused[id] {
-				if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: id}); err != nil {
+				// NotFound: the store already cascaded a source's delete.
+				if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: id}); err != nil && status.Code(err) != codes.NotFound {
 					slog.Error("delete fused entity", "id", id, "error", err)
+					fusedWrites.Inc("delete", "error")
 				} else {
 					slog.Info("deleted fused entity", "id", id)
+					fusedWrites.Inc("delete", "ok")
 				}
 			}
 		}
@@ -316,3 +414,17 @@ func (f *Fusioner) Run(ctx context.Context) error {
 		activeFused = newFused
 	}
 }
+
+// linkSources links a newly created fused entity to its source tracks.
+func linkSources(ctx context.Context, client storev1.EntityStoreServiceClient, ent *entityv1.Entity) {
+	fc := &entityv1.FusionComponent{}
+	if err := ent.Components["fusion"].UnmarshalTo(fc); err != nil {
+		return
+	}
+	for _, src := range fc.SourceIds {
+		link := &storev1.Link{FromId: ent.Id, ToId: src, Relation: RelationFusedFrom, Cascade: true}
+		if _, err := client.AddLink(ctx, &storev1.AddLinkRequest{Link: link}); err != nil {
+			slog.Error("link fused entity", "id", ent.Id, "source_id", src, "error", err)
+		}
+	}
+}
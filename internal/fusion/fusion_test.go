@@ -22,10 +22,19 @@ func makeTrackEntity(id string, lat, lon float64, sensorID, sensorType string) *
 	}
 }
 
+// makeMovingTrack builds a test entity at alt meters, moving at speed
+// knots on heading.
+func makeMovingTrack(id string, lat, lon, alt, speed, heading float64, sensorID string) *entityv1.Entity {
+	e := makeTrackEntity(id, lat, lon, sensorID, "radar")
+	e.Components["position"], _ = anypb.New(&entityv1.PositionComponent{Lat: lat, Lon: lon, Alt: alt})
+	e.Components["velocity"], _ = anypb.New(&entityv1.VelocityComponent{Speed: speed, Heading: heading})
+	return e
+}
+
 func TestCorrelate_WithinThreshold(t *testing.T) {
-	f := New(Config{DistThreshold: 0.01})
+	f := New(Config{DistThreshold: 1000})
 
-	// Two tracks from different sensors, within 0.005 degrees apart.
+	// Two tracks from different sensors, about 515m apart.
 	f.UpdateTrack(makeTrackEntity("track-0", 38.9000, -77.0000, "eo-1", "eo"))
 	f.UpdateTrack(makeTrackEntity("radar-track-0", 38.9040, -77.0030, "radar-1", "radar"))
 
@@ -42,9 +51,9 @@ func TestCorrelate_WithinThreshold(t *testing.T) {
 }
 
 func TestCorrelate_BeyondThreshold(t *testing.T) {
-	f := New(Config{DistThreshold: 0.01})
+	f := New(Config{DistThreshold: 1000})
 
-	// Two tracks from different sensors, far apart (> 0.01 degrees).
+	// Two tracks from different sensors, far apart (about 20km).
 	f.UpdateTrack(makeTrackEntity("track-0", 38.9000, -77.0000, "eo-1", "eo"))
 	f.UpdateTrack(makeTrackEntity("radar-track-0", 39.0000, -76.8000, "radar-1", "radar"))
 
@@ -55,7 +64,7 @@ func TestCorrelate_BeyondThreshold(t *testing.T) {
 }
 
 func TestCorrelate_SameSensorIgnored(t *testing.T) {
-	f := New(Config{DistThreshold: 0.01})
+	f := New(Config{DistThreshold: 1000})
 
 	// Two tracks from the SAME sensor, very close together.
 	f.UpdateTrack(makeTrackEntity("track-0", 38.9000, -77.0000, "eo-1", "eo"))
@@ -85,7 +94,7 @@ func TestFusedPosition_WeightedAverage(t *testing.T) {
 }
 
 func TestDecorrelate(t *testing.T) {
-	f := New(Config{DistThreshold: 0.01})
+	f := New(Config{DistThreshold: 1000})
 
 	// Start correlated.
 	f.UpdateTrack(makeTrackEntity("track-0", 38.9000, -77.0000, "eo-1", "eo"))
@@ -106,7 +115,7 @@ func TestDecorrelate(t *testing.T) {
 }
 
 func TestFusionComponent(t *testing.T) {
-	f := New(Config{DistThreshold: 0.01})
+	f := New(Config{DistThreshold: 1000})
 
 	f.UpdateTrack(makeTrackEntity("track-0", 38.9000, -77.0000, "eo-1", "eo"))
 	f.UpdateTrack(makeTrackEntity("radar-track-0", 38.9040, -77.0030, "radar-1", "radar"))
@@ -148,7 +157,7 @@ func TestFusionComponent(t *testing.T) {
 }
 
 func TestRemoveTrack(t *testing.T) {
-	f := New(Config{DistThreshold: 0.01})
+	f := New(Config{DistThreshold: 1000})
 
 	f.UpdateTrack(makeTrackEntity("track-0", 38.9000, -77.0000, "eo-1", "eo"))
 	f.UpdateTrack(makeTrackEntity("radar-track-0", 38.9040, -77.0030, "radar-1", "radar"))
@@ -182,12 +191,140 @@ func TestDistance(t *testing.T) {
 	}
 }
 
+func TestHaversine(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		want                   float64 // meters
+	}{
+		{"same point", 38.9, -77.0, 38.9, -77.0, 0},
+		{"degree of latitude", 0, 0, 1, 0, 111195},
+		{"degree of longitude at the equator", 0, 0, 0, 1, 111195},
+		{"degree of longitude at 60N", 60, 0, 60, 1, 55597},
+		{"across the antimeridian", 0, 179.5, 0, -179.5, 111195},
+		{"antipodes", 0, 0, 0, 180, math.Pi * earthRadius},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if d := Haversine(tt.lat1, tt.lon1, tt.lat2, tt.lon2); math.Abs(d-tt.want) > 1 {
+				t.Fatalf("got %.1fm, want %.1fm", d, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorrelate_HighLatitude(t *testing.T) {
+	// 0.02 degrees of longitude apart at 70N is about 760m: within 1km,
+	// though twice the old 0.01 degree threshold.
+	f := New(Config{DistThreshold: 1000})
+	f.UpdateTrack(makeTrackEntity("track-0", 70.0, 20.00, "eo-1", "eo"))
+	f.UpdateTrack(makeTrackEntity("radar-track-0", 70.0, 20.02, "radar-1", "radar"))
+	if corrs := f.Correlations(); len(corrs) != 1 {
+		t.Fatalf("expected 1 correlation, got %d", len(corrs))
+	}
+
+	flat := New(Config{DistThreshold: 0.01, FlatEarth: true})
+	flat.UpdateTrack(makeTrackEntity("track-0", 70.0, 20.00, "eo-1", "eo"))
+	flat.UpdateTrack(makeTrackEntity("radar-track-0", 70.0, 20.02, "radar-1", "radar"))
+	if corrs := flat.Correlations(); len(corrs) != 0 {
+		t.Fatalf("expected 0 correlations measuring in degrees, got %d", len(corrs))
+	}
+}
+
+func TestCorrelate_FlatEarth(t *testing.T) {
+	f := New(Config{DistThreshold: 0.01, FlatEarth: true})
+	f.UpdateTrack(makeTrackEntity("track-0", 38.9000, -77.0000, "eo-1", "eo"))
+	f.UpdateTrack(makeTrackEntity("radar-track-0", 38.9040, -77.0030, "radar-1", "radar"))
+
+	fused := f.BuildFusedEntities()
+	if len(fused) != 1 {
+		t.Fatalf("expected 1 fused entity, got %d", len(fused))
+	}
+	fc := &entityv1.FusionComponent{}
+	if err := fused[0].Components["fusion"].UnmarshalTo(fc); err != nil {
+		t.Fatalf("unmarshal fusion component: %v", err)
+	}
+	// 0.005 degrees apart of a 0.01 threshold.
+	if math.Abs(float64(fc.Confidence)-0.5) > 1e-6 {
+		t.Fatalf("expected confidence 0.5, got %f", fc.Confidence)
+	}
+}
+
+func TestCorrelate_Gating(t *testing.T) {
+	// A fast jet at 3000m, and candidates 500m from it.
+	jet := makeMovingTrack("jet", 38.9000, -77.0000, 3000, 450, 90, "radar-1")
+	tests := []struct {
+		name  string
+		other *entityv1.Entity
+		fused bool
+	}{
+		{"same object", makeMovingTrack("other", 38.9045, -77.0000, 3100, 440, 95, "eo-1"), true},
+		{"slow helicopter", makeMovingTrack("other", 38.9045, -77.0000, 3000, 80, 90, "eo-1"), false},
+		{"below", makeMovingTrack("other", 38.9045, -77.0000, 500, 450, 90, "eo-1"), false},
+		{"crossing", makeMovingTrack("other", 38.9045, -77.0000, 3000, 450, 180, "eo-1"), false},
+		{"heading across north", makeMovingTrack("other", 38.9045, -77.0000, 3000, 450, 350, "eo-1"), false},
+		{"no velocity", makeTrackEntity("other", 38.9045, -77.0000, "eo-1", "eo"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := New(DefaultConfig())
+			f.UpdateTrack(jet)
+			f.UpdateTrack(tt.other)
+			if got := len(f.Correlations()) == 1; got != tt.fused {
+				t.Fatalf("fused = %v, want %v", got, tt.fused)
+			}
+		})
+	}
+}
+
+func TestCorrelate_GatingOff(t *testing.T) {
+	f := New(Config{DistThreshold: 1000})
+	f.UpdateTrack(makeMovingTrack("jet", 38.9000, -77.0000, 3000, 450, 90, "radar-1"))
+	f.UpdateTrack(makeMovingTrack("helo", 38.9045, -77.0000, 300, 80, 270, "eo-1"))
+	if corrs := f.Correlations(); len(corrs) != 1 {
+		t.Fatalf("expected thresholds of 0 to fuse on distance alone, got %d correlations", len(corrs))
+	}
+}
+
+func TestCorrelate_HoveringHeadingIgnored(t *testing.T) {
+	f := New(DefaultConfig())
+	f.UpdateTrack(makeMovingTrack("helo-eo", 38.9000, -77.0000, 150, 2, 10, "eo-1"))
+	f.UpdateTrack(makeMovingTrack("helo-radar", 38.9010, -77.0000, 150, 3, 200, "radar-1"))
+	if corrs := f.Correlations(); len(corrs) != 1 {
+		t.Fatalf("expected a hovering track's heading ignored, got %d correlations", len(corrs))
+	}
+}
+
+func TestCorrelate_NoAltitudeIgnored(t *testing.T) {
+	// A 2-D surveillance radar reports no altitude; the 3-D track of the
+	// same jet is gated on speed and heading alone.
+	f := New(DefaultConfig())
+	f.UpdateTrack(makeMovingTrack("jet-3d", 38.9000, -77.0000, 9000, 450, 90, "radar-3d"))
+	f.UpdateTrack(makeMovingTrack("jet-2d", 38.9045, -77.0000, 0, 440, 95, "radar-2d"))
+	if corrs := f.Correlations(); len(corrs) != 1 {
+		t.Fatalf("expected a track without altitude fused on the rest, got %d correlations", len(corrs))
+	}
+}
+
+func TestHeadingDiff(t *testing.T) {
+	for _, tt := range []struct{ h1, h2, want float64 }{
+		{10, 50, 40}, {350, 10, 20}, {10, 350, 20}, {0, 180, 180}, {90, 450, 0},
+	} {
+		if got := HeadingDiff(tt.h1, tt.h2); math.Abs(got-tt.want) > 1e-9 {
+			t.Fatalf("HeadingDiff(%v, %v) = %v, want %v", tt.h1, tt.h2, got, tt.want)
+		}
+	}
+}
+
 func TestDefaultConfig(t *testing.T) {
 	cfg := DefaultConfig()
 	if cfg.StoreAddr != "localhost:50051" {
 		t.Fatalf("expected localhost:50051, got %s", cfg.StoreAddr)
 	}
-	if cfg.DistThreshold != 0.01 {
-		t.Fatalf("expected 0.01, got %f", cfg.DistThreshold)
+	if cfg.DistThreshold != 1000 || cfg.FlatEarth {
+		t.Fatalf("expected 1000m, got %f (flat earth %v)", cfg.DistThreshold, cfg.FlatEarth)
+	}
+	if cfg.AltThreshold != 300 || cfg.SpeedThreshold != 100 || cfg.HeadingThreshold != 45 {
+		t.Fatalf("expected gates of 300m, 100kt, 45deg, got %v", cfg)
 	}
 }
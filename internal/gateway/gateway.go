@@ -0,0 +1,348 @@
+// Package gateway serves the EntityStoreService as REST with JSON bodies,
+// for dashboards and scripts that have curl but no protobuf toolchain.
+// Every request is forwarded to the store over gRPC, so it passes the
+// store's authentication and audit like any other call.
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+)
+
+// Resolver finds the message types of components, to read and write them
+// as JSON. A *registry.Registry is one.
+type Resolver interface {
+	protoregistry.MessageTypeResolver
+	protoregistry.ExtensionTypeResolver
+}
+
+type gateway struct {
+	client   storev1.EntityStoreServiceClient
+	resolver Resolver
+}
+
+// Handler serves client's methods as JSON over HTTP:
+//
+//	GET    /v1/entities                        ListEntities
+//	POST   /v1/entities                        CreateEntity
+//	GET    /v1/entities/{id}                   GetEntity
+//	PUT    /v1/entities/{id}                   UpdateEntity
+//	PATCH  /v1/entities/{id}                   PatchComponent
+//	DELETE /v1/entities/{id}                   DeleteEntity
+//	GET    /v1/entities/{id}/components/{key}  GetComponent
+//	DELETE /v1/entities/{id}/components/{key}  RemoveComponent
+//	POST   /v1/entities/{id}/counters/{key}    IncrementCounter
+//	GET    /v1/entities/{id}/history           GetEntityHistory
+//	GET    /v1/entities/{id}/links             ListLinks
+//	POST   /v1/entities/{id}/approve           ApproveAction
+//	POST   /v1/entities/{id}/deny              DenyAction
+//	POST   /v1/links                           AddLink
+//	DELETE /v1/links                           RemoveLink
+//	GET    /v1/bbox                            QueryEntitiesByBBox
+//	POST   /v1/batch                           BatchWriteEntities
+//	POST   /v1/transact                        Transact
+//	GET    /v1/archive                         ListArchivedEntities
+//	GET    /v1/audit                           GetAuditLog
+//	GET    /v1/watch                           WatchEntities, as NDJSON
+//	GET    /v1/events                          WatchEntities, as Server-Sent Events
+//
+// A request body is the method's request message in protojson; query
+// parameters set its scalar fields by name (type_filter=track,
+// label_selector=side=blue), and path parameters its IDs. Responses are
+// protojson, and errors a google.rpc.Status with the matching HTTP
+// status. An Authorization header is passed on to the store.
+func Handler(client storev1.EntityStoreServiceClient, resolver Resolver) http.Handler {
+	g := &gateway{client: client, resolver: resolver}
+	c := client
+	mux := http.NewServeMux()
+	mux.Handle("GET /v1/entities", unary(g, c.ListEntities, nil))
+	mux.Handle("POST /v1/entities", unary(g, c.CreateEntity, nil))
+	mux.Handle("GET /v1/entities/{id}", unary(g, c.GetEntity, func(r *http.Request, req *storev1.GetEntityRequest) error {
+		req.Id = r.PathValue("id")
+		return nil
+	}))
+	mux.Handle("PUT /v1/entities/{id}", unary(g, c.UpdateEntity, func(r *http.Request, req *storev1.UpdateEntityRequest) error {
+		if req.Entity == nil {
+			return fmt.Errorf("body must hold the entity")
+		}
+		if req.Entity.Id != "" && req.Entity.Id != r.PathValue("id") {
+			return fmt.Errorf("entity ID %q does not match the path", req.Entity.Id)
+		}
+		req.Entity.Id = r.PathValue("id")
+		return nil
+	}))
+	mux.Handle("PATCH /v1/entities/{id}", unary(g, c.PatchComponent, func(r *http.Request, req *storev1.PatchComponentRequest) error {
+		req.Id = r.PathValue("id")
+		return nil
+	}))
+	mux.Handle("DELETE /v1/entities/{id}", unary(g, c.DeleteEntity, func(r *http.Request, req *storev1.DeleteEntityRequest) error {
+		req.Id = r.PathValue("id")
+		return nil
+	}))
+	mux.Handle("GET /v1/entities/{id}/components/{key}", unary(g, c.GetComponent, func(r *http.Request, req *storev1.GetComponentRequest) error {
+		req.Id, req.Key = r.PathValue("id"), r.PathValue("key")
+		return nil
+	}))
+	mux.Handle("DELETE /v1/entities/{id}/components/{key}", unary(g, c.RemoveComponent, func(r *http.Request, req *storev1.RemoveComponentRequest) error {
+		req.Id, req.Keys = r.PathValue("id"), []string{r.PathValue("key")}
+		return nil
+	}))
+	mux.Handle("POST /v1/entities/{id}/counters/{key}", unary(g, c.IncrementCounter, func(r *http.Request, req *storev1.IncrementCounterRequest) error {
+		req.Id, req.Key = r.PathValue("id"), r.PathValue("key")
+		return nil
+	}))
+	mux.Handle("GET /v1/entities/{id}/history", unary(g, c.GetEntityHistory, func(r *http.Request, req *storev1.GetEntityHistoryRequest) error {
+		req.Id = r.PathValue("id")
+		return nil
+	}))
+	mux.Handle("GET /v1/entities/{id}/links", unary(g, c.ListLinks, func(r *http.Request, req *storev1.ListLinksRequest) error {
+		req.Id = r.PathValue("id")
+		return nil
+	}))
+	mux.Handle("POST /v1/entities/{id}/approve", unary(g, c.ApproveAction, func(r *http.Request, req *storev1.ApproveActionRequest) error {
+		req.EntityId = r.PathValue("id")
+		return nil
+	}))
+	mux.Handle("POST /v1/entities/{id}/deny", unary(g, c.DenyAction, func(r *http.Request, req *storev1.DenyActionRequest) error {
+		req.EntityId = r.PathValue("id")
+		return nil
+	}))
+	mux.Handle("POST /v1/links", unary(g, c.AddLink, nil))
+	mux.Handle("DELETE /v1/links", unary(g, c.RemoveLink, nil))
+	mux.Handle("GET /v1/bbox", unary(g, c.QueryEntitiesByBBox, nil))
+	mux.Handle("POST /v1/batch", unary(g, c.BatchWriteEntities, nil))
+	mux.Handle("POST /v1/transact", unary(g, c.Transact, nil))
+	mux.Handle("GET /v1/archive", unary(g, c.ListArchivedEntities, nil))
+	mux.Handle("GET /v1/audit", unary(g, c.GetAuditLog, nil))
+	mux.HandleFunc("GET /v1/watch", g.watch)
+	mux.HandleFunc("GET /v1/events", g.events)
+	return mux
+}
+
+// unary serves call: it reads the request from the body and query, lets
+// bind fill in path parameters, and writes the response.
+func unary[R any, Req interface {
+	*R
+	proto.Message
+}, Resp proto.Message](g *gateway, call func(context.Context, Req, ...grpc.CallOption) (Resp, error), bind func(*http.Request, Req) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := Req(new(R))
+		if err := g.read(r, req); err != nil {
+			g.writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+		if bind != nil {
+			if err := bind(r, req); err != nil {
+				g.writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+				return
+			}
+		}
+		resp, err := call(outgoing(r), req)
+		if err != nil {
+			g.writeError(w, err)
+			return
+		}
+		g.write(w, http.StatusOK, resp)
+	})
+}
+
+// watch streams WatchEntities events as NDJSON until the client goes away.
+func (g *gateway) watch(w http.ResponseWriter, r *http.Request) {
+	req := &storev1.WatchEntitiesRequest{}
+	if err := g.read(r, req); err != nil {
+		g.writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	stream, err := g.client.WatchEntities(outgoing(r), req)
+	if err != nil {
+		g.writeError(w, err)
+		return
+	}
+	flusher, _ := w.(http.Flusher)
+	opts := protojson.MarshalOptions{Resolver: g.resolver}
+	for n := 0; ; n++ {
+		ev, err := stream.Recv()
+		if err != nil {
+			if n == 0 && r.Context().Err() == nil {
+				g.writeError(w, err) // nothing sent yet, so the status can still say why
+			}
+			return
+		}
+		line, err := opts.Marshal(ev)
+		if err != nil {
+			return
+		}
+		if n == 0 {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", line); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// outgoing returns r's context carrying its Authorization header, if any,
+// to the store.
+func outgoing(r *http.Request) context.Context {
+	ctx := r.Context()
+	if v := r.Header.Get("Authorization"); v != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", v)
+	}
+	return ctx
+}
+
+// read fills m from r's body, if any, then from its query parameters.
+func (g *gateway) read(r *http.Request, m proto.Message) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(body))) > 0 {
+		if err := (protojson.UnmarshalOptions{Resolver: g.resolver}).Unmarshal(body, m); err != nil {
+			return fmt.Errorf("body: %v", err)
+		}
+	}
+	return setQuery(m.ProtoReflect(), r.URL.Query())
+}
+
+// setQuery sets m's scalar fields, by proto or JSON name, from query
+// parameters; a dotted name sets a field of a message field, as in
+// bbox.min_lat. Enum values are given by name, in full (ENTITY_TYPE_TRACK)
+// or by their last part (track), or by number.
+func setQuery(m protoreflect.Message, query map[string][]string) error {
+	for name, values := range query {
+		msg, fd := m, protoreflect.FieldDescriptor(nil)
+		for part := range strings.SplitSeq(name, ".") {
+			if fd != nil {
+				if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+					return fmt.Errorf("unknown query parameter %q", name)
+				}
+				msg = msg.Mutable(fd).Message()
+			}
+			fields := msg.Descriptor().Fields()
+			if fd = fields.ByName(protoreflect.Name(part)); fd == nil {
+				fd = fields.ByJSONName(part)
+			}
+			if fd == nil {
+				return fmt.Errorf("unknown query parameter %q", name)
+			}
+		}
+		if fd.IsMap() || fd.Kind() == protoreflect.MessageKind || fd.Kind() == protoreflect.GroupKind {
+			return fmt.Errorf("query parameter %q is not a scalar field", name)
+		}
+		for _, s := range values {
+			v, err := scalar(fd, s)
+			if err != nil {
+				return fmt.Errorf("query parameter %q: %v", name, err)
+			}
+			if fd.IsList() {
+				msg.Mutable(fd).List().Append(v)
+			} else {
+				msg.Set(fd, v)
+			}
+		}
+	}
+	return nil
+}
+
+// scalar parses s as a value of fd's kind.
+func scalar(fd protoreflect.FieldDescriptor, s string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(s), nil
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(s)), nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		return protoreflect.ValueOfBool(b), err
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		if ev := values.ByName(protoreflect.Name(s)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		for i := range values.Len() {
+			if ev := values.Get(i); strings.HasSuffix(string(ev.Name()), "_"+strings.ToUpper(s)) {
+				return protoreflect.ValueOfEnum(ev.Number()), nil
+			}
+		}
+		n, err := strconv.ParseInt(s, 10, 32)
+		if err != nil {
+			return protoreflect.Value{}, fmt.Errorf("no %s value %q", fd.Enum().Name(), s)
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n)), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(s, 10, 32)
+		return protoreflect.ValueOfInt32(int32(n)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(s, 10, 64)
+		return protoreflect.ValueOfInt64(n), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(s, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(n)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(s, 10, 64)
+		return protoreflect.ValueOfUint64(n), err
+	case protoreflect.FloatKind:
+		f, err := strconv.ParseFloat(s, 32)
+		return protoreflect.ValueOfFloat32(float32(f)), err
+	case protoreflect.DoubleKind:
+		f, err := strconv.ParseFloat(s, 64)
+		return protoreflect.ValueOfFloat64(f), err
+	}
+	return protoreflect.Value{}, fmt.Errorf("unsupported kind %v", fd.Kind())
+}
+
+func (g *gateway) write(w http.ResponseWriter, code int, m proto.Message) {
+	out, err := protojson.MarshalOptions{Resolver: g.resolver}.Marshal(m)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(out) //nolint:errcheck
+}
+
+// writeError writes err's gRPC status as a google.rpc.Status.
+func (g *gateway) writeError(w http.ResponseWriter, err error) {
+	st := status.Convert(err)
+	g.write(w, httpStatus[st.Code()], st.Proto())
+}
+
+// httpStatus maps gRPC codes to HTTP statuses, as grpc-gateway does.
+var httpStatus = map[codes.Code]int{
+	codes.OK:                 http.StatusOK,
+	codes.Canceled:           499,
+	codes.Unknown:            http.StatusInternalServerError,
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.DeadlineExceeded:   http.StatusGatewayTimeout,
+	codes.NotFound:           http.StatusNotFound,
+	codes.AlreadyExists:      http.StatusConflict,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.FailedPrecondition: http.StatusBadRequest,
+	codes.Aborted:            http.StatusConflict,
+	codes.OutOfRange:         http.StatusBadRequest,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Internal:           http.StatusInternalServerError,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+	codes.DataLoss:           http.StatusInternalServerError,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+}
@@ -0,0 +1,181 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/registry"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// serveGateway starts a store behind gRPC with opts and a gateway in front
+// of it, and returns the gateway's URL.
+func serveGateway(t *testing.T, opts ...server.Option) string {
+	t.Helper()
+	srv := server.New(store.New(), opts...)
+	gs := grpc.NewServer(grpc.UnaryInterceptor(srv.UnaryInterceptor()), grpc.StreamInterceptor(srv.StreamInterceptor()))
+	storev1.RegisterEntityStoreServiceServer(gs, srv)
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go gs.Serve(lis) //nolint:errcheck
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	hs := httptest.NewServer(Handler(storev1.NewEntityStoreServiceClient(conn), registry.New()))
+	t.Cleanup(hs.Close)
+	return hs.URL
+}
+
+func do(t *testing.T, method, url, body string, header ...string) (int, map[string]any) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	var m map[string]any
+	if err := json.Unmarshal(out, &m); err != nil {
+		t.Fatalf("%s %s: response %q is not JSON: %v", method, url, out, err)
+	}
+	return resp.StatusCode, m
+}
+
+func TestGateway(t *testing.T) {
+	url := serveGateway(t)
+
+	code, e := do(t, "POST", url+"/v1/entities", `{"entity": {"id": "t1", "type": "ENTITY_TYPE_TRACK", "labels": {"side": "blue"},
+		"components": {"position": {"@type": "type.googleapis.com/entity.v1.PositionComponent", "lat": 38.9, "lon": -77}}}}`)
+	if code != http.StatusOK || e["id"] != "t1" {
+		t.Fatalf("create: %d %v", code, e)
+	}
+	_, _ = do(t, "POST", url+"/v1/entities", `{"entity": {"id": "a1", "type": "ENTITY_TYPE_ASSET"}}`)
+
+	code, e = do(t, "GET", url+"/v1/entities/t1", "")
+	pos, _ := e["components"].(map[string]any)["position"].(map[string]any)
+	if code != http.StatusOK || pos["lat"] != 38.9 {
+		t.Fatalf("get: %d %v", code, e)
+	}
+
+	// Query parameters set fields by name, enums by their short name.
+	_, list := do(t, "GET", url+"/v1/entities?type_filter=track&labelSelector=side%3Dblue", "")
+	if n := len(list["entities"].([]any)); n != 1 {
+		t.Fatalf("expected 1 blue track, got %d", n)
+	}
+
+	code, _ = do(t, "PATCH", url+"/v1/entities/t1", `{"components": {"velocity": {"@type": "type.googleapis.com/entity.v1.VelocityComponent", "speed": 250}}}`)
+	if code != http.StatusOK {
+		t.Fatalf("patch: %d", code)
+	}
+	code, c := do(t, "GET", url+"/v1/entities/t1/components/velocity", "")
+	if code != http.StatusOK || c["component"].(map[string]any)["speed"] != 250.0 {
+		t.Fatalf("get component: %d %v", code, c)
+	}
+	if code, _ = do(t, "DELETE", url+"/v1/entities/t1/components/velocity", ""); code != http.StatusOK {
+		t.Fatalf("remove component: %d", code)
+	}
+	if code, _ = do(t, "GET", url+"/v1/entities/t1/components/velocity", ""); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a removed component, got %d", code)
+	}
+
+	do(t, "POST", url+"/v1/entities/t1/counters/detections_count", `{"delta": 5}`)
+	code, c = do(t, "POST", url+"/v1/entities/t1/counters/detections_count", `{"delta": -2}`)
+	if code != http.StatusOK || c["value"] != "3" {
+		t.Fatalf("increment counter: %d %v", code, c)
+	}
+
+	if code, _ = do(t, "PUT", url+"/v1/entities/t1", `{"entity": {"id": "t2"}}`); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an ID not matching the path, got %d", code)
+	}
+	if code, _ = do(t, "GET", url+"/v1/entities?colour=red", ""); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown query parameter, got %d", code)
+	}
+
+	if code, _ = do(t, "DELETE", url+"/v1/entities/t1", ""); code != http.StatusOK {
+		t.Fatalf("delete: %d", code)
+	}
+	code, st := do(t, "GET", url+"/v1/entities/t1", "")
+	if code != http.StatusNotFound || st["code"] != 5.0 || st["message"] == "" {
+		t.Fatalf("expected a 404 google.rpc.Status, got %d %v", code, st)
+	}
+}
+
+func TestGatewayAuth(t *testing.T) {
+	url := serveGateway(t, server.WithAuth(server.StaticTokens{"op": server.RoleOperator}))
+
+	if code, _ := do(t, "GET", url+"/v1/entities", ""); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", code)
+	}
+	if code, _ := do(t, "GET", url+"/v1/entities", "", "Authorization", "Bearer op"); code != http.StatusOK {
+		t.Fatalf("expected 200 with a token, got %d", code)
+	}
+}
+
+func TestGatewayWatch(t *testing.T) {
+	url := serveGateway(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", url+"/v1/watch?type_filter=track", nil)
+	watch := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Errorf("watch: %v", err)
+			close(watch)
+			return
+		}
+		watch <- resp
+	}()
+
+	// The watch's response starts with its first event, so keep writing
+	// until one arrives.
+	var resp *http.Response
+	for i := 0; resp == nil; i++ {
+		_, _ = do(t, "POST", url+"/v1/entities", `{"entity": {"id": "t`+string(rune('a'+i))+`", "type": "ENTITY_TYPE_TRACK"}}`)
+		select {
+		case resp = <-watch:
+			if resp == nil {
+				return
+			}
+		case <-time.After(50 * time.Millisecond):
+		}
+		if i > 20 {
+			t.Fatal("no watch response")
+		}
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Fatalf("expected NDJSON, got %q", ct)
+	}
+	line, err := bufio.NewReader(resp.Body).ReadBytes('\n')
+	if err != nil {
+		t.Fatalf("read event: %v", err)
+	}
+	var ev map[string]any
+	if err := json.Unmarshal(line, &ev); err != nil || ev["type"] != "EVENT_TYPE_CREATED" {
+		t.Fatalf("expected a CREATED event, got %s (%v)", line, err)
+	}
+}
@@ -0,0 +1,118 @@
+package gateway
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// heartbeat is how often an idle event stream sends a comment, so proxies
+// and browsers do not time it out.
+const heartbeat = 15 * time.Second
+
+// events streams WatchEntities as Server-Sent Events for a browser's
+// EventSource. Each event is named for its type (created, updated,
+// deleted, expired), carries the EntityEvent as protojson data, and has
+// its sequence as its id, so a reconnecting EventSource resumes where it
+// left off through Last-Event-ID. A failed watch ends with an "error"
+// event holding a google.rpc.Status; OUT_OF_RANGE means the events missed
+// are gone and the page should reload before watching again.
+//
+// EventSource cannot set headers, so the bearer token may be given as
+// ?access_token= instead of an Authorization header.
+func (g *gateway) events(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if token := query.Get("access_token"); token != "" && r.Header.Get("Authorization") == "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	query.Del("access_token")
+	r.URL.RawQuery = query.Encode()
+
+	req := &storev1.WatchEntitiesRequest{}
+	if err := g.read(r, req); err != nil {
+		g.writeError(w, status.Error(codes.InvalidArgument, err.Error()))
+		return
+	}
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		seq, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			g.writeError(w, status.Errorf(codes.InvalidArgument, "Last-Event-ID %q is not a sequence", id))
+			return
+		}
+		// A reconnect resumes; the initial state was sent the first time.
+		req.SinceSequence, req.InitialState = seq, false
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		g.writeError(w, status.Error(codes.Unimplemented, "streaming is not supported by this connection"))
+		return
+	}
+
+	stream, err := g.client.WatchEntities(outgoing(r), req)
+	if err != nil {
+		g.writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	type recv struct {
+		ev  *storev1.EntityEvent
+		err error
+	}
+	received := make(chan recv)
+	go func() {
+		for {
+			ev, err := stream.Recv()
+			select {
+			case received <- recv{ev, err}:
+			case <-r.Context().Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	opts := protojson.MarshalOptions{Resolver: g.resolver}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case rv := <-received:
+			if rv.err != nil {
+				if r.Context().Err() == nil {
+					data, _ := opts.Marshal(status.Convert(rv.err).Proto())
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", data) //nolint:errcheck
+					flusher.Flush()
+				}
+				return
+			}
+			data, err := opts.Marshal(rv.ev)
+			if err != nil {
+				return
+			}
+			name := strings.ToLower(strings.TrimPrefix(rv.ev.Type.String(), "EVENT_TYPE_"))
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", rv.ev.Sequence, name, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
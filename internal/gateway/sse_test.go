@@ -0,0 +1,97 @@
+package gateway
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// sseEvent is one parsed Server-Sent Event.
+type sseEvent struct {
+	id, name string
+	data     map[string]any
+}
+
+// readEvent reads the next event from br, skipping comments.
+func readEvent(t *testing.T, br *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read event: %v", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "" && ev.name != "":
+			return ev
+		case strings.HasPrefix(line, "id: "):
+			ev.id = line[len("id: "):]
+		case strings.HasPrefix(line, "event: "):
+			ev.name = line[len("event: "):]
+		case strings.HasPrefix(line, "data: "):
+			if err := json.Unmarshal([]byte(line[len("data: "):]), &ev.data); err != nil {
+				t.Fatalf("event data %q: %v", line, err)
+			}
+		}
+	}
+}
+
+func openEvents(t *testing.T, ctx context.Context, url, lastID string) *bufio.Reader {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if ct := resp.Header.Get("Content-Type"); resp.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("expected an event stream, got %d %q", resp.StatusCode, ct)
+	}
+	return bufio.NewReader(resp.Body)
+}
+
+func TestGatewayEvents(t *testing.T) {
+	url := serveGateway(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	track := func(id, lat string) string {
+		return `{"entity": {"id": "` + id + `", "type": "ENTITY_TYPE_TRACK", "components": {"position":
+			{"@type": "type.googleapis.com/entity.v1.PositionComponent", "lat": ` + lat + `, "lon": 0}}}}`
+	}
+	_, _ = do(t, "POST", url+"/v1/entities", track("inside", "10"))
+	_, _ = do(t, "POST", url+"/v1/entities", track("outside", "50"))
+
+	// The initial state, filtered by a box given as dotted query parameters.
+	events := url + "/v1/events?type_filter=track&initial_state=true&bbox.min_lat=0&bbox.max_lat=20&bbox.min_lon=-1&bbox.max_lon=1"
+	br := openEvents(t, ctx, events, "")
+	ev := readEvent(t, br)
+	if ev.name != "created" || ev.data["entity"].(map[string]any)["id"] != "inside" {
+		t.Fatalf("expected the initial CREATED for inside, got %+v", ev)
+	}
+	pos := ev.data["entity"].(map[string]any)["components"].(map[string]any)["position"].(map[string]any)
+	if pos["lat"] != 10.0 {
+		t.Fatalf("expected the position as JSON, got %v", pos)
+	}
+
+	_, _ = do(t, "DELETE", url+"/v1/entities/inside", "")
+	ev = readEvent(t, br)
+	if ev.name != "deleted" || ev.id == "" {
+		t.Fatalf("expected a DELETED event with an id, got %+v", ev)
+	}
+
+	// A reconnect with Last-Event-ID resumes after it instead of starting
+	// over with the initial state.
+	_, _ = do(t, "POST", url+"/v1/entities", track("late", "5"))
+	ev = readEvent(t, openEvents(t, ctx, events, ev.id))
+	if ev.name != "created" || ev.data["entity"].(map[string]any)["id"] != "late" {
+		t.Fatalf("expected the resumed stream to start with late, got %+v", ev)
+	}
+}
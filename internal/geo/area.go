@@ -0,0 +1,188 @@
+// Package geo publishes GEO entities — restricted zones, engagement areas,
+// and sensor coverage — from a YAML file, and keeps the store in step with it.
+package geo
+
+import (
+	"fmt"
+	"math"
+	"os"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"go.yaml.in/yaml/v3"
+)
+
+const metersPerDegreeLat = 111_320.0
+
+// File is a set of areas loaded from YAML:
+//
+//	areas:
+//	  - id: dc-frz
+//	    name: DC Flight Restricted Zone
+//	    kind: restricted
+//	    center: {lat: 38.8977, lon: -77.0365}
+//	    radius_m: 28000
+//	    max_alt: 5500
+//	  - id: ea-north
+//	    kind: engagement
+//	    polygon:
+//	      - {lat: 39.05, lon: -77.20}
+//	      - {lat: 39.05, lon: -76.90}
+//	      - {lat: 38.98, lon: -76.90}
+//	      - {lat: 38.98, lon: -77.20}
+type File struct {
+	Areas []Area `yaml:"areas"`
+}
+
+// Area is one GEO entity: a circle of RadiusM about Center, or a Polygon.
+type Area struct {
+	ID      string  `yaml:"id"`
+	Name    string  `yaml:"name"` // default the ID
+	Kind    string  `yaml:"kind"` // restricted, engagement, or coverage
+	Center  *Point  `yaml:"center"`
+	RadiusM float64 `yaml:"radius_m"`
+	Polygon []Point `yaml:"polygon"`
+	MinAlt  float64 `yaml:"min_alt"` // 0 = surface
+	MaxAlt  float64 `yaml:"max_alt"` // 0 = unlimited
+}
+
+// Point is a vertex or circle centre.
+type Point struct {
+	Lat float64 `yaml:"lat"`
+	Lon float64 `yaml:"lon"`
+}
+
+var kinds = map[string]entityv1.GeoKind{
+	"restricted": entityv1.GeoKind_GEO_KIND_RESTRICTED,
+	"engagement": entityv1.GeoKind_GEO_KIND_ENGAGEMENT,
+	"coverage":   entityv1.GeoKind_GEO_KIND_COVERAGE,
+}
+
+// LoadFile reads and validates an area file.
+func LoadFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read geo file: %w", err)
+	}
+	return ParseFile(data)
+}
+
+// ParseFile parses and validates an area file.
+func ParseFile(data []byte) (*File, error) {
+	var f File
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse geo file: %w", err)
+	}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// Validate checks every area is well formed and IDs are unique.
+func (f *File) Validate() error {
+	seen := make(map[string]bool, len(f.Areas))
+	for i, a := range f.Areas {
+		if a.ID == "" {
+			return fmt.Errorf("area %d: id is required", i)
+		}
+		if seen[a.ID] {
+			return fmt.Errorf("area %s: duplicate id", a.ID)
+		}
+		seen[a.ID] = true
+		if err := a.Validate(); err != nil {
+			return fmt.Errorf("area %s: %w", a.ID, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks the area has exactly one shape and a known kind.
+func (a Area) Validate() error {
+	if _, ok := kinds[a.Kind]; !ok {
+		return fmt.Errorf("unknown kind %q (want restricted, engagement, or coverage)", a.Kind)
+	}
+	switch {
+	case a.Center != nil && len(a.Polygon) > 0:
+		return fmt.Errorf("center and polygon are exclusive")
+	case a.Center != nil:
+		if a.RadiusM <= 0 {
+			return fmt.Errorf("circle requires a positive radius_m")
+		}
+	case len(a.Polygon) < 3:
+		return fmt.Errorf("want a center and radius_m, or a polygon of at least 3 points")
+	}
+	if a.MinAlt < 0 || (a.MaxAlt != 0 && a.MaxAlt <= a.MinAlt) {
+		return fmt.Errorf("want 0 <= min_alt < max_alt")
+	}
+	return nil
+}
+
+// Component returns the area as a GeoComponent.
+func (a Area) Component() *entityv1.GeoComponent {
+	name := a.Name
+	if name == "" {
+		name = a.ID
+	}
+	c := &entityv1.GeoComponent{
+		Name:    name,
+		Kind:    kinds[a.Kind],
+		RadiusM: a.RadiusM,
+		MinAlt:  a.MinAlt,
+		MaxAlt:  a.MaxAlt,
+	}
+	pts := a.Polygon
+	if a.Center != nil {
+		pts = []Point{*a.Center}
+	}
+	for _, p := range pts {
+		c.Points = append(c.Points, &entityv1.GeoPoint{Lat: p.Lat, Lon: p.Lon})
+	}
+	return c
+}
+
+// Contains reports whether the point lies inside c, including its altitude
+// band. A circle uses flat-earth distance; a polygon, ray casting in lat/lon.
+func Contains(c *entityv1.GeoComponent, lat, lon, alt float64) bool {
+	if alt < c.MinAlt || (c.MaxAlt != 0 && alt > c.MaxAlt) {
+		return false
+	}
+	pts := c.Points
+	if c.RadiusM > 0 && len(pts) == 1 {
+		north := (lat - pts[0].Lat) * metersPerDegreeLat
+		east := (lon - pts[0].Lon) * metersPerDegreeLat * math.Cos(pts[0].Lat*math.Pi/180)
+		return math.Hypot(north, east) <= c.RadiusM
+	}
+	in := false
+	for i, j := 0, len(pts)-1; i < len(pts); j, i = i, i+1 {
+		a, b := pts[i], pts[j]
+		if (a.Lat > lat) != (b.Lat > lat) && lon < (b.Lon-a.Lon)*(lat-a.Lat)/(b.Lat-a.Lat)+a.Lon {
+			in = !in
+		}
+	}
+	return in
+}
+
+// Outline returns c's boundary as a closed ring (first point repeated
+// last). A circle is approximated by a polygon of segments sides.
+func Outline(c *entityv1.GeoComponent, segments int) []*entityv1.GeoPoint {
+	pts := c.Points
+	if c.RadiusM > 0 && len(pts) == 1 {
+		centre := pts[0]
+		ring := make([]*entityv1.GeoPoint, 0, segments+1)
+		for i := 0; i < segments; i++ {
+			bearing := 2 * math.Pi * float64(i) / float64(segments)
+			north := c.RadiusM * math.Cos(bearing) / metersPerDegreeLat
+			east := c.RadiusM * math.Sin(bearing) / (metersPerDegreeLat * math.Cos(centre.Lat*math.Pi/180))
+			ring = append(ring, &entityv1.GeoPoint{Lat: centre.Lat + north, Lon: centre.Lon + east})
+		}
+		return append(ring, ring[0])
+	}
+	if len(pts) == 0 {
+		return nil
+	}
+	ring := append([]*entityv1.GeoPoint(nil), pts...)
+	if first, last := pts[0], pts[len(pts)-1]; first.Lat != last.Lat || first.Lon != last.Lon {
+		ring = append(ring, first)
+	}
+	return ring
+}
@@ -0,0 +1,83 @@
+package geo
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+)
+
+func TestLoadFile_DeployExample(t *testing.T) {
+	f, err := LoadFile(filepath.Join("..", "..", "deploy", "geo", "dc.yaml"))
+	if err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+	if len(f.Areas) != 3 {
+		t.Fatalf("expected 3 areas, got %d", len(f.Areas))
+	}
+	c := f.Areas[0].Component()
+	if c.Kind != entityv1.GeoKind_GEO_KIND_RESTRICTED || c.Name != "DC Flight Restricted Zone" || len(c.Points) != 1 {
+		t.Fatalf("unexpected component %v", c)
+	}
+}
+
+func TestParseFile_Invalid(t *testing.T) {
+	for name, doc := range map[string]string{
+		"no id":        "areas: [{kind: restricted, center: {lat: 1, lon: 1}, radius_m: 10}]",
+		"duplicate":    "areas: [{id: a, kind: restricted, center: {lat: 1, lon: 1}, radius_m: 10}, {id: a, kind: restricted, center: {lat: 1, lon: 1}, radius_m: 10}]",
+		"kind":         "areas: [{id: a, kind: nofly, center: {lat: 1, lon: 1}, radius_m: 10}]",
+		"no radius":    "areas: [{id: a, kind: restricted, center: {lat: 1, lon: 1}}]",
+		"both shapes":  "areas: [{id: a, kind: restricted, center: {lat: 1, lon: 1}, radius_m: 10, polygon: [{lat: 0, lon: 0}, {lat: 1, lon: 0}, {lat: 1, lon: 1}]}]",
+		"two vertices": "areas: [{id: a, kind: engagement, polygon: [{lat: 0, lon: 0}, {lat: 1, lon: 0}]}]",
+		"alt band":     "areas: [{id: a, kind: restricted, center: {lat: 1, lon: 1}, radius_m: 10, min_alt: 500, max_alt: 100}]",
+	} {
+		if _, err := ParseFile([]byte(doc)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestContains(t *testing.T) {
+	circle := Area{Kind: "restricted", Center: &Point{Lat: 38.9, Lon: -77.0}, RadiusM: 1000, MaxAlt: 3000}.Component()
+	square := Area{Kind: "engagement", Polygon: []Point{{39, -77}, {39, -76}, {38, -76}, {38, -77}}}.Component()
+
+	for _, tc := range []struct {
+		name          string
+		c             *entityv1.GeoComponent
+		lat, lon, alt float64
+		want          bool
+	}{
+		{"circle centre", circle, 38.9, -77.0, 0, true},
+		{"circle edge", circle, 38.9 + 900/metersPerDegreeLat, -77.0, 0, true},
+		{"circle outside", circle, 38.9 + 1100/metersPerDegreeLat, -77.0, 0, false},
+		{"above ceiling", circle, 38.9, -77.0, 3500, false},
+		{"square inside", square, 38.5, -76.5, 10000, true},
+		{"square outside", square, 39.5, -76.5, 0, false},
+		{"square west", square, 38.5, -77.5, 0, false},
+	} {
+		if got := Contains(tc.c, tc.lat, tc.lon, tc.alt); got != tc.want {
+			t.Fatalf("%s: Contains = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestOutline(t *testing.T) {
+	circle := Area{Kind: "restricted", Center: &Point{Lat: 38.9, Lon: -77.0}, RadiusM: 1000}.Component()
+	ring := Outline(circle, 32)
+	if len(ring) != 33 || ring[0] != ring[32] {
+		t.Fatalf("expected a closed 32-gon, got %d points", len(ring))
+	}
+	for _, p := range ring {
+		north := (p.Lat - 38.9) * metersPerDegreeLat
+		east := (p.Lon + 77.0) * metersPerDegreeLat * math.Cos(38.9*math.Pi/180)
+		if d := math.Hypot(north, east); math.Abs(d-1000) > 1 {
+			t.Fatalf("vertex %v is %.1fm from the centre, want 1000m", p, d)
+		}
+	}
+
+	square := Area{Kind: "engagement", Polygon: []Point{{39, -77}, {39, -76}, {38, -76}, {38, -77}}}.Component()
+	if ring := Outline(square, 32); len(ring) != 5 || ring[4].Lat != 39 || ring[4].Lon != -77 {
+		t.Fatalf("expected the square closed, got %v", ring)
+	}
+}
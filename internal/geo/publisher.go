@@ -0,0 +1,180 @@
+package geo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Config controls the GEO publisher.
+type Config struct {
+	StoreAddr string
+	File      string        // YAML area file
+	Interval  time.Duration // how often to reload the file and reconcile
+}
+
+// DefaultConfig returns a config reconciling every 10 seconds.
+func DefaultConfig() Config {
+	return Config{
+		StoreAddr: "localhost:50051",
+		Interval:  10 * time.Second,
+	}
+}
+
+// Validate checks the config is runnable.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.File == "":
+		return fmt.Errorf("geo file is required")
+	case cfg.Interval <= 0:
+		return fmt.Errorf("interval must be positive")
+	}
+	return nil
+}
+
+// Publisher keeps one GEO entity per area in the file. Each pass it recreates
+// areas that were deleted, restores ones edited in the store, and deletes
+// areas removed from the file.
+type Publisher struct {
+	cfg       Config
+	areas     []Area
+	modTime   time.Time
+	published map[string]bool
+}
+
+// New creates a publisher with the given config.
+func New(cfg Config) *Publisher {
+	return &Publisher{cfg: cfg, published: make(map[string]bool)}
+}
+
+// Run loads the area file and reconciles the store against it every
+// Interval until ctx is cancelled. A file that fails to load at startup is an
+// error; later, the last good version stays in force.
+func (p *Publisher) Run(ctx context.Context) error {
+	if err := p.reload(); err != nil {
+		return err
+	}
+
+	conn, err := client.Dial(p.cfg.StoreAddr)
+	if err != nil {
+		return fmt.Errorf("connect to store: %w", err)
+	}
+	defer conn.Close()
+
+	client := storev1.NewEntityStoreServiceClient(conn)
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	slog.Info("geo-publisher started", "file", p.cfg.File, "areas", len(p.areas), "interval", p.cfg.Interval, "store_addr", p.cfg.StoreAddr)
+
+	for {
+		if err := p.reconcile(ctx, client); err != nil {
+			slog.Error("reconcile failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := p.reload(); err != nil {
+				slog.Error("reload failed, keeping previous areas", "file", p.cfg.File, "error", err)
+			}
+		}
+	}
+}
+
+// reload re-reads the area file if it changed since the last load.
+func (p *Publisher) reload() error {
+	info, err := os.Stat(p.cfg.File)
+	if err != nil {
+		return fmt.Errorf("stat geo file: %w", err)
+	}
+	if info.ModTime().Equal(p.modTime) {
+		return nil
+	}
+	f, err := LoadFile(p.cfg.File)
+	if err != nil {
+		return err
+	}
+	if !p.modTime.IsZero() {
+		slog.Info("geo file reloaded", "file", p.cfg.File, "areas", len(f.Areas))
+	}
+	p.areas, p.modTime = f.Areas, info.ModTime()
+	return nil
+}
+
+// reconcile makes the store match p.areas.
+func (p *Publisher) reconcile(ctx context.Context, client storev1.EntityStoreServiceClient) error {
+	var errs []error
+	want := make(map[string]bool, len(p.areas))
+	for _, a := range p.areas {
+		want[a.ID] = true
+		if err := p.publish(ctx, client, a); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for id := range p.published {
+		if want[id] {
+			continue
+		}
+		_, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: id})
+		if err != nil && status.Code(err) != codes.NotFound {
+			errs = append(errs, fmt.Errorf("delete %s: %w", id, err))
+			continue
+		}
+		delete(p.published, id)
+		slog.Info("removed area", "area_id", id)
+	}
+	return errors.Join(errs...)
+}
+
+// publish creates a's entity if it is missing and updates it if the stored
+// area differs.
+func (p *Publisher) publish(ctx context.Context, client storev1.EntityStoreServiceClient, a Area) error {
+	geo := a.Component()
+	packed, err := anypb.New(geo)
+	if err != nil {
+		return fmt.Errorf("pack geo: %w", err)
+	}
+	entity := &entityv1.Entity{
+		Id:         a.ID,
+		Type:       entityv1.EntityType_ENTITY_TYPE_GEO,
+		Components: map[string]*anypb.Any{"geo": packed},
+	}
+
+	existing, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: a.ID})
+	if status.Code(err) == codes.NotFound {
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity}); err != nil {
+			return fmt.Errorf("create %s: %w", a.ID, err)
+		}
+		p.published[a.ID] = true
+		slog.Info("created area", "area_id", a.ID, "kind", a.Kind)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get %s: %w", a.ID, err)
+	}
+	p.published[a.ID] = true
+
+	stored := &entityv1.GeoComponent{}
+	if c, ok := existing.Components["geo"]; ok && c.UnmarshalTo(stored) == nil && proto.Equal(stored, geo) {
+		return nil
+	}
+	// Carry the stored HLC so the store's merge accepts the correction.
+	entity.HlcPhysical, entity.HlcLogical, entity.HlcNode = existing.HlcPhysical, existing.HlcLogical, existing.HlcNode
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity}); err != nil {
+		return fmt.Errorf("update %s: %w", a.ID, err)
+	}
+	slog.Info("updated area", "area_id", a.ID, "kind", a.Kind)
+	return nil
+}
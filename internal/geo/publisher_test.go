@@ -0,0 +1,124 @@
+package geo
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func startTestServer(t *testing.T) (storev1.EntityStoreServiceClient, *store.Store, func()) {
+	t.Helper()
+
+	s := store.New()
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	cleanup := func() {
+		conn.Close()
+		srv.Stop()
+	}
+	return storev1.NewEntityStoreServiceClient(conn), s, cleanup
+}
+
+const twoAreas = `
+areas:
+  - {id: zone-a, kind: restricted, center: {lat: 38.9, lon: -77.0}, radius_m: 5000}
+  - {id: zone-b, kind: engagement, polygon: [{lat: 39, lon: -77}, {lat: 39, lon: -76}, {lat: 38, lon: -76}]}
+`
+
+func writeFile(t *testing.T, path, doc string, mod time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if err := os.Chtimes(path, mod, mod); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+func TestPublisher_Reconcile(t *testing.T) {
+	client, s, cleanup := startTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "areas.yaml")
+	start := time.Now().Add(-time.Hour)
+	writeFile(t, path, twoAreas, start)
+
+	p := New(Config{File: path, Interval: time.Second})
+	if err := p.reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if err := p.reconcile(ctx, client); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if n := len(s.List(entityv1.EntityType_ENTITY_TYPE_GEO)); n != 2 {
+		t.Fatalf("expected 2 geo entities, got %d", n)
+	}
+
+	// Deleted and edited areas are put back.
+	if err := s.Delete("zone-a"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	e, _ := s.Get("zone-b")
+	bogus, _ := anypb.New(&entityv1.GeoComponent{Name: "moved"})
+	e.Components["geo"] = bogus
+	e.HlcPhysical++
+	if _, err := s.Update(e); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if err := p.reconcile(ctx, client); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if _, err := s.Get("zone-a"); err != nil {
+		t.Fatalf("expected zone-a recreated: %v", err)
+	}
+	e, _ = s.Get("zone-b")
+	geo := &entityv1.GeoComponent{}
+	if err := e.Components["geo"].UnmarshalTo(geo); err != nil {
+		t.Fatalf("unmarshal geo: %v", err)
+	}
+	if geo.Name != "zone-b" || len(geo.Points) != 3 {
+		t.Fatalf("expected zone-b restored, got %v", geo)
+	}
+
+	// Removing an area from the file deletes it; a bad edit is ignored.
+	writeFile(t, path, "areas: [{id: zone-a, kind: bogus}]", start.Add(time.Minute))
+	if err := p.reload(); err == nil {
+		t.Fatal("expected reload error for invalid file")
+	}
+	writeFile(t, path, "areas: [{id: zone-a, kind: restricted, center: {lat: 38.9, lon: -77.0}, radius_m: 5000}]", start.Add(2*time.Minute))
+	if err := p.reload(); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if err := p.reconcile(ctx, client); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if _, err := s.Get("zone-b"); err == nil {
+		t.Fatal("expected zone-b deleted after removal from the file")
+	}
+	if _, err := s.Get("zone-a"); err != nil {
+		t.Fatalf("expected zone-a kept: %v", err)
+	}
+}
@@ -0,0 +1,184 @@
+// Package health serves a service's liveness and readiness over HTTP, for
+// compose and Kubernetes probes:
+//
+//	GET /healthz  200 unless a watchdog has been stuck past its limit
+//	GET /readyz   200 once every readiness condition is met and healthy
+//
+// Failing responses are 503 with one reason per line. A nil *Probe, and a
+// nil *Watchdog, accept every call and report nothing, so services take
+// one optionally.
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultStall is how long one pass through a loop may take before its
+// watchdog reports the service wedged, unless told otherwise.
+const DefaultStall = time.Minute
+
+// ErrStarting is the readiness error of a condition not yet met.
+var ErrStarting = errors.New("starting")
+
+// Probe holds a service's readiness conditions and watchdogs.
+type Probe struct {
+	mu        sync.Mutex
+	ready     map[string]error // by condition; nil once met
+	watchdogs map[string]*Watchdog
+}
+
+// New returns a probe with no conditions: healthy and ready.
+func New() *Probe {
+	return &Probe{ready: make(map[string]error), watchdogs: make(map[string]*Watchdog)}
+}
+
+// SetReady records condition name as met (err nil) or not, and why.
+func (p *Probe) SetReady(name string, err error) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ready[name] = err
+}
+
+// Watchdog returns the watchdog called name, creating it with limit if
+// new. A pass between Begin and End longer than limit fails liveness.
+func (p *Probe) Watchdog(name string, limit time.Duration) *Watchdog {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	w, ok := p.watchdogs[name]
+	if !ok {
+		w = &Watchdog{limit: limit}
+		p.watchdogs[name] = w
+	}
+	return w
+}
+
+// Remove drops condition and watchdog name, for a loop stopped for good,
+// such as a relay's watch for a peer it no longer has.
+func (p *Probe) Remove(name string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.ready, name)
+	delete(p.watchdogs, name)
+}
+
+// Live returns why the service is wedged, or nil.
+func (p *Probe) Live() error {
+	if p == nil {
+		return nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(p.watchdogs)) {
+		if d := p.watchdogs[name].stuck(); d > 0 {
+			errs = append(errs, fmt.Errorf("%s: stuck for %s", name, d.Round(time.Second)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Ready returns why the service is not ready, or nil.
+func (p *Probe) Ready() error {
+	if p == nil {
+		return nil
+	}
+	errs := []error{p.Live()}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, name := range slices.Sorted(maps.Keys(p.ready)) {
+		if err := p.ready[name]; err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Handler serves /healthz and /readyz.
+func (p *Probe) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, _ *http.Request) { respond(w, p.Live()) })
+	mux.HandleFunc("GET /readyz", func(w http.ResponseWriter, _ *http.Request) { respond(w, p.Ready()) })
+	return mux
+}
+
+func respond(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, err)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// Serve serves p's Handler on addr until ctx is cancelled. It returns once
+// addr is listening, or with the error if it cannot be; an empty addr
+// serves nothing.
+func Serve(ctx context.Context, addr string, p *Probe) error {
+	if addr == "" {
+		return nil
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen health: %w", err)
+	}
+	srv := &http.Server{Handler: p.Handler()}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go srv.Serve(lis) //nolint:errcheck
+	slog.Info("health probes listening", "addr", lis.Addr().String())
+	return nil
+}
+
+// Watchdog times passes through a loop, such as handling one event.
+type Watchdog struct {
+	limit time.Duration
+	since atomic.Int64 // UnixNano the current pass began, or 0 between passes
+}
+
+// Begin marks the start of a pass.
+func (w *Watchdog) Begin() {
+	if w != nil {
+		w.since.Store(time.Now().UnixNano())
+	}
+}
+
+// End marks the end of a pass.
+func (w *Watchdog) End() {
+	if w != nil {
+		w.since.Store(0)
+	}
+}
+
+// stuck returns how long the current pass has run, if past the limit.
+func (w *Watchdog) stuck() time.Duration {
+	since := w.since.Load()
+	if since == 0 {
+		return 0
+	}
+	if d := time.Since(time.Unix(0, since)); d > w.limit {
+		return d
+	}
+	return 0
+}
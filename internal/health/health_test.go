@@ -0,0 +1,66 @@
+package health
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func get(t *testing.T, h http.Handler, path string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestProbe(t *testing.T) {
+	p := New()
+	h := p.Handler()
+	if code, _ := get(t, h, "/readyz"); code != http.StatusOK {
+		t.Fatalf("expected a probe without conditions ready, got %d", code)
+	}
+
+	p.SetReady("watch", ErrStarting)
+	if code, body := get(t, h, "/readyz"); code != http.StatusServiceUnavailable || !strings.Contains(body, "watch: starting") {
+		t.Fatalf("expected not ready while starting, got %d %q", code, body)
+	}
+	if code, _ := get(t, h, "/healthz"); code != http.StatusOK {
+		t.Fatalf("expected a starting service healthy, got %d", code)
+	}
+	p.SetReady("watch", nil)
+	if code, _ := get(t, h, "/readyz"); code != http.StatusOK {
+		t.Fatalf("expected ready, got %d", code)
+	}
+
+	// A pass stuck past its limit fails both probes; an idle loop does not.
+	w := p.Watchdog("loop", 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	if code, _ := get(t, h, "/healthz"); code != http.StatusOK {
+		t.Fatalf("expected an idle loop healthy, got %d", code)
+	}
+	w.Begin()
+	time.Sleep(20 * time.Millisecond)
+	if code, body := get(t, h, "/healthz"); code != http.StatusServiceUnavailable || !strings.Contains(body, "loop: stuck") {
+		t.Fatalf("expected a stuck loop unhealthy, got %d %q", code, body)
+	}
+	if code, _ := get(t, h, "/readyz"); code != http.StatusServiceUnavailable {
+		t.Fatalf("expected a stuck loop not ready, got %d", code)
+	}
+	w.End()
+	if code, _ := get(t, h, "/healthz"); code != http.StatusOK {
+		t.Fatalf("expected healthy once the pass ended, got %d", code)
+	}
+}
+
+func TestNilProbe(t *testing.T) {
+	var p *Probe
+	p.SetReady("watch", ErrStarting)
+	w := p.Watchdog("loop", time.Nanosecond)
+	w.Begin()
+	w.End()
+	if p.Live() != nil || p.Ready() != nil {
+		t.Fatal("expected a nil probe healthy and ready")
+	}
+}
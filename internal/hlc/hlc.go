@@ -1,6 +1,9 @@
 package hlc
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -14,6 +17,39 @@ type Timestamp struct {
 	Node     string // Node ID for tie-breaking
 }
 
+// String formats t as physical.logical@node, the physical time in Unix
+// nanoseconds padded to 19 digits and the logical counter to 4, e.g.
+// "1760000000000000000.0002@store-1". Padded, the strings sort in the
+// order Compare gives, so one line of a log or table can be compared with
+// another at a glance, as long as the logical counters are below 10000
+// (they reset each time the wall clock moves on) and the physical times
+// before the year 2286.
+func (t Timestamp) String() string {
+	return fmt.Sprintf("%019d.%04d@%s", t.Physical, t.Logical, t.Node)
+}
+
+// ParseTimestamp parses a timestamp formatted by String. Unpadded fields
+// are accepted too.
+func ParseTimestamp(s string) (Timestamp, error) {
+	phys, node, ok := strings.Cut(s, "@")
+	if !ok {
+		return Timestamp{}, fmt.Errorf("hlc: parse %q: want physical.logical@node", s)
+	}
+	phys, logical, ok := strings.Cut(phys, ".")
+	if !ok {
+		return Timestamp{}, fmt.Errorf("hlc: parse %q: want physical.logical@node", s)
+	}
+	p, err := strconv.ParseUint(phys, 10, 64)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("hlc: parse %q: %w", s, err)
+	}
+	l, err := strconv.ParseUint(logical, 10, 32)
+	if err != nil {
+		return Timestamp{}, fmt.Errorf("hlc: parse %q: %w", s, err)
+	}
+	return Timestamp{Physical: p, Logical: uint32(l), Node: node}, nil
+}
+
 // Before returns true if t is ordered before other.
 func (t Timestamp) Before(other Timestamp) bool {
 	return Compare(t, other) == -1
@@ -42,12 +78,23 @@ func Compare(a, b Timestamp) int {
 	return strings.Compare(a.Node, b.Node)
 }
 
+// ErrDrift is returned by Next and Observe when a timestamp would drift
+// further from the wall clock than the clock's bound allows.
+var ErrDrift = errors.New("hlc: drift past bound")
+
 // Clock is a hybrid logical clock bound to a specific node.
 type Clock struct {
 	mu           sync.Mutex
 	node         string
+	offset       time.Duration // added to the wall clock, to simulate skew
 	lastPhysical uint64
 	lastLogical  uint32
+
+	maxAhead   time.Duration // how far Physical may lead the wall clock; 0 is unbounded
+	maxLogical uint32        // how high Logical may count; 0 is unbounded
+
+	state   string // file the clock is persisted to, if any
+	ceiling uint64 // Physical time saved to state, past every one issued
 }
 
 // NewClock creates a new HLC for the given node ID.
@@ -55,69 +102,143 @@ func NewClock(nodeID string) *Clock {
 	return &Clock{node: nodeID}
 }
 
+// SetOffset skews the clock's wall time by d, as if the node's system clock
+// were d ahead (or behind, if negative). Timestamps stay monotonic: after a
+// backwards skew the logical counter advances until wall time catches up.
+func (c *Clock) SetOffset(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.offset = d
+}
+
+// SetMaxDrift bounds the timestamps Next and Observe issue: Physical may
+// lead the wall clock by at most ahead, and Logical count to at most
+// logical. Zero leaves either unbounded. A clock runs ahead of the wall
+// when it adopts a remote timestamp from a fast node, or after its own wall
+// clock steps back; past the bound it refuses to issue until the wall
+// catches up, rather than stamping writes ever further from real time.
+func (c *Clock) SetMaxDrift(ahead time.Duration, logical uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxAhead, c.maxLogical = ahead, logical
+}
+
+// Wall returns the wall time the clock reads, skewed by SetOffset: the
+// time this node's system clock would show.
+func (c *Clock) Wall() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Now().Add(c.offset)
+}
+
+// wall returns the skewed wall time. Must hold mu.
+func (c *Clock) wall() uint64 {
+	return uint64(time.Now().Add(c.offset).UnixNano())
+}
+
 // Now generates a new timestamp that is guaranteed to be greater than
-// any previously generated timestamp from this clock.
+// any previously generated timestamp from this clock. It ignores the
+// drift bound, and issues even if a persisted clock fails to save.
 func (c *Clock) Now() Timestamp {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	ts, _ := c.advance(nil, false)
+	return ts
+}
 
-	wall := uint64(time.Now().UnixNano())
-
-	if wall > c.lastPhysical {
-		c.lastPhysical = wall
-		c.lastLogical = 0
-	} else {
-		c.lastLogical++
-	}
+// Next is Now, but refuses with ErrDrift to issue a timestamp past the
+// drift bound, and with the save's error if a persisted clock cannot save.
+func (c *Clock) Next() (Timestamp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.advance(nil, true)
+}
 
-	return Timestamp{
-		Physical: c.lastPhysical,
-		Logical:  c.lastLogical,
-		Node:     c.node,
-	}
+// Last returns the latest timestamp the clock has issued, without issuing
+// another.
+func (c *Clock) Last() Timestamp {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Timestamp{Physical: c.lastPhysical, Logical: c.lastLogical, Node: c.node}
 }
 
 // Update merges a remote timestamp with the local clock state, producing
 // a new timestamp that is greater than both the local state and the remote timestamp.
+// Like Now, it ignores the drift bound.
 func (c *Clock) Update(remote Timestamp) Timestamp {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	ts, _ := c.advance(&remote, false)
+	return ts
+}
 
-	wall := uint64(time.Now().UnixNano())
+// Observe is Update, but refuses as Next does. A refused remote timestamp
+// is not adopted, so a peer whose clock runs fast cannot drag this one
+// past the bound.
+func (c *Clock) Observe(remote Timestamp) (Timestamp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.advance(&remote, true)
+}
 
-	// Determine the maximum physical time among wall, local last, and remote.
-	maxPhys := wall
-	if c.lastPhysical > maxPhys {
-		maxPhys = c.lastPhysical
-	}
-	if remote.Physical > maxPhys {
-		maxPhys = remote.Physical
+// tick returns the wall time and the timestamp to issue next, after remote
+// if it is set, without issuing it. Must hold mu.
+func (c *Clock) tick(remote *Timestamp) (uint64, Timestamp) {
+	wall := c.wall()
+	ts := Timestamp{Physical: c.lastPhysical, Logical: c.lastLogical, Node: c.node}
+	if remote == nil {
+		if wall > c.lastPhysical {
+			ts.Physical, ts.Logical = wall, 0
+		} else {
+			ts.Logical++
+		}
+		return wall, ts
 	}
 
+	// Determine the maximum physical time among wall, local last, and remote.
+	maxPhys := max(wall, c.lastPhysical, remote.Physical)
+
 	switch {
 	case maxPhys == c.lastPhysical && maxPhys == remote.Physical:
 		// All three tied — advance logical past the max of local and remote.
-		if c.lastLogical > remote.Logical {
-			c.lastLogical++
-		} else {
-			c.lastLogical = remote.Logical + 1
-		}
+		ts.Logical = max(c.lastLogical, remote.Logical) + 1
 	case maxPhys == c.lastPhysical:
 		// Local physical wins — just increment local logical.
-		c.lastLogical++
+		ts.Logical++
 	case maxPhys == remote.Physical:
 		// Remote physical wins — adopt remote logical + 1.
-		c.lastLogical = remote.Logical + 1
+		ts.Logical = remote.Logical + 1
 	default:
 		// Wall clock wins — reset logical.
-		c.lastLogical = 0
+		ts.Logical = 0
 	}
+	ts.Physical = maxPhys
+	return wall, ts
+}
 
-	c.lastPhysical = maxPhys
-
-	return Timestamp{
-		Physical: c.lastPhysical,
-		Logical:  c.lastLogical,
-		Node:     c.node,
+// advance issues the clock's next timestamp, after remote if it is set. If
+// checked, it refuses one past the drift bound, and one a persisted clock
+// cannot save the ceiling past, leaving the clock as it was. Must hold mu.
+func (c *Clock) advance(remote *Timestamp, checked bool) (Timestamp, error) {
+	wall, ts := c.tick(remote)
+	if checked {
+		if ahead := time.Duration(ts.Physical - wall); c.maxAhead > 0 && ts.Physical > wall && ahead > c.maxAhead {
+			return Timestamp{}, fmt.Errorf("%w: %v ahead of the wall clock, max %v", ErrDrift, ahead, c.maxAhead)
+		}
+		if c.maxLogical > 0 && ts.Logical > c.maxLogical {
+			return Timestamp{}, fmt.Errorf("%w: logical %d, max %d", ErrDrift, ts.Logical, c.maxLogical)
+		}
+	}
+	if c.state != "" && ts.Physical >= c.ceiling {
+		ceiling := ts.Physical + uint64(reserve)
+		if err := saveState(c.state, ceiling); err != nil {
+			if checked {
+				return Timestamp{}, err
+			}
+		} else {
+			c.ceiling = ceiling
+		}
 	}
+	c.lastPhysical, c.lastLogical = ts.Physical, ts.Logical
+	return ts, nil
 }
@@ -1,8 +1,12 @@
 package hlc
 
 import (
+	"errors"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestNow_Monotonic(t *testing.T) {
@@ -32,6 +36,17 @@ func TestNow_AdvancesLogical(t *testing.T) {
 	}
 }
 
+func TestLast(t *testing.T) {
+	c := NewClock("node-1")
+	issued := c.Now()
+	if last := c.Last(); last != issued {
+		t.Fatalf("expected Last %+v, got %+v", issued, last)
+	}
+	if last := c.Last(); last != issued {
+		t.Fatalf("expected Last not to advance the clock, got %+v after %+v", last, issued)
+	}
+}
+
 func TestUpdate_AdvancesPastRemote(t *testing.T) {
 	c := NewClock("node-1")
 	remote := Timestamp{Physical: uint64(1e18), Logical: 5, Node: "node-2"} // far future
@@ -173,3 +188,145 @@ func TestAfter(t *testing.T) {
 		t.Error("expected !a.After(b)")
 	}
 }
+
+func TestSetOffset(t *testing.T) {
+	c := NewClock("node-1")
+	c.SetOffset(time.Hour)
+	ahead := c.Now()
+	if d := time.Duration(ahead.Physical - uint64(time.Now().UnixNano())); d < 59*time.Minute {
+		t.Fatalf("expected clock an hour ahead, got %v", d)
+	}
+
+	// Skewing back must not move timestamps backwards.
+	c.SetOffset(0)
+	if next := c.Now(); !next.After(ahead) || next.Physical != ahead.Physical {
+		t.Fatalf("expected logical advance past %+v, got %+v", ahead, next)
+	}
+}
+
+func TestMaxDrift_RefusesRemoteAhead(t *testing.T) {
+	c := NewClock("node-1")
+	c.SetMaxDrift(time.Minute, 0)
+	before := c.Now()
+
+	ahead := Timestamp{Physical: before.Physical + uint64(time.Hour), Node: "node-2"}
+	if _, err := c.Observe(ahead); !errors.Is(err, ErrDrift) {
+		t.Fatalf("expected ErrDrift for a remote an hour ahead, got %v", err)
+	}
+	// The refused remote is not adopted.
+	if next, err := c.Next(); err != nil || next.Physical >= ahead.Physical {
+		t.Fatalf("expected the clock to stay near the wall, got %+v, %v", next, err)
+	}
+	near := Timestamp{Physical: before.Physical + uint64(time.Second), Node: "node-2"}
+	if got, err := c.Observe(near); err != nil || !got.After(near) {
+		t.Fatalf("expected a remote within the bound adopted, got %+v, %v", got, err)
+	}
+	// Update ignores the bound.
+	if got := c.Update(ahead); !got.After(ahead) {
+		t.Fatalf("expected Update past %+v, got %+v", ahead, got)
+	}
+}
+
+func TestMaxDrift_RefusesAfterWallSetBack(t *testing.T) {
+	c := NewClock("node-1")
+	c.SetMaxDrift(time.Minute, 0)
+	c.Now()
+	// The wall clock steps back an hour; the clock is now an hour ahead of it.
+	c.SetOffset(-time.Hour)
+	if _, err := c.Next(); !errors.Is(err, ErrDrift) {
+		t.Fatalf("expected ErrDrift an hour ahead of the wall, got %v", err)
+	}
+	c.SetMaxDrift(2*time.Hour, 0)
+	if _, err := c.Next(); err != nil {
+		t.Fatalf("expected issue within a wider bound, got %v", err)
+	}
+}
+
+func TestMaxDrift_Logical(t *testing.T) {
+	c := NewClock("node-1")
+	c.SetMaxDrift(0, 3)
+	c.SetOffset(time.Hour)
+	c.Now()
+	// Held an hour ahead, every timestamp counts up the logical counter.
+	c.SetOffset(0)
+	for i := 1; i <= 3; i++ {
+		if ts, err := c.Next(); err != nil || ts.Logical != uint32(i) {
+			t.Fatalf("next %d: got %+v, %v", i, ts, err)
+		}
+	}
+	if _, err := c.Next(); !errors.Is(err, ErrDrift) {
+		t.Fatalf("expected ErrDrift past logical 3, got %v", err)
+	}
+}
+
+func TestPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hlc.json")
+	c := NewClock("node-1")
+	if err := c.Persist(path); err != nil {
+		t.Fatalf("persist a new clock: %v", err)
+	}
+	var last Timestamp
+	for range 100 {
+		last = c.Now()
+	}
+
+	// Restarted with its wall clock set back an hour, the clock still
+	// issues after every timestamp it issued before.
+	restarted := NewClock("node-1")
+	restarted.SetOffset(-time.Hour)
+	if err := restarted.Persist(path); err != nil {
+		t.Fatalf("persist the restarted clock: %v", err)
+	}
+	if next := restarted.Now(); !next.After(last) {
+		t.Fatalf("expected %+v after %+v", next, last)
+	}
+	// Without the file it would have gone back.
+	unsaved := NewClock("node-1")
+	unsaved.SetOffset(-time.Hour)
+	if !unsaved.Now().Before(last) {
+		t.Fatal("expected an unpersisted clock set back to issue earlier timestamps")
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewClock("node-1").Persist(path); err == nil {
+		t.Fatal("expected a corrupt state file refused")
+	}
+}
+
+func TestString(t *testing.T) {
+	ts := Timestamp{Physical: 1760000000000000000, Logical: 2, Node: "store-1"}
+	if got, want := ts.String(), "1760000000000000000.0002@store-1"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	// The strings sort as the timestamps do.
+	stamps := []Timestamp{
+		{Physical: 999999999, Logical: 3, Node: "a"},
+		{Physical: 1760000000000000000, Logical: 2, Node: "b"},
+		{Physical: 1760000000000000000, Logical: 10, Node: "a"},
+		{Physical: 1760000000000000000, Logical: 10, Node: "b"},
+		{Physical: 1760000000000000001, Node: "a"},
+	}
+	for i := 1; i < len(stamps); i++ {
+		if a, b := stamps[i-1].String(), stamps[i].String(); a >= b {
+			t.Errorf("expected %q to sort before %q", a, b)
+		}
+	}
+}
+
+func TestParseTimestamp(t *testing.T) {
+	ts := Timestamp{Physical: 1760000000000000000, Logical: 2, Node: "store-1"}
+	got, err := ParseTimestamp(ts.String())
+	if err != nil || got != ts {
+		t.Fatalf("round trip: got %+v, %v; want %+v", got, err, ts)
+	}
+	if got, err := ParseTimestamp("42.7@n"); err != nil || got != (Timestamp{Physical: 42, Logical: 7, Node: "n"}) {
+		t.Fatalf("unpadded: got %+v, %v", got, err)
+	}
+	for _, bad := range []string{"", "1.2", "1@n", "x.2@n", "1.x@n", "1.4294967296@n"} {
+		if _, err := ParseTimestamp(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package hlc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// reserve is how far past a timestamp a persisted clock saves its ceiling,
+// so it saves about once a reserve rather than once a timestamp.
+const reserve = time.Second
+
+// state is the file a persisted clock saves.
+type state struct {
+	// Ceiling is a physical time, in Unix nanoseconds, past every
+	// timestamp the clock has issued.
+	Ceiling uint64 `json:"ceiling"`
+}
+
+// Persist makes c's timestamps keep increasing across restarts, through
+// the file at path. It resumes c past the ceiling saved there, if any.
+// After that, before issuing a timestamp at or past the ceiling, c saves
+// one a second past it, so the file always holds a time later than every
+// timestamp c has issued. A node restarted with its wall clock rolled back,
+// or set back while it was down, then cannot stamp writes before ones it
+// made earlier; its Logical counts up from the ceiling until the wall
+// catches up, so a drift bound, if set, should allow for the rollback.
+func (c *Clock) Persist(path string) error {
+	ceiling, err := loadState(path)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ceiling > c.lastPhysical {
+		c.lastPhysical, c.lastLogical = ceiling, 0
+	}
+	c.state, c.ceiling = path, ceiling
+	return nil
+}
+
+// loadState returns the ceiling saved at path, or zero if there is no file.
+func loadState(path string) (uint64, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("load clock state: %w", err)
+	}
+	var st state
+	if err := json.Unmarshal(b, &st); err != nil {
+		return 0, fmt.Errorf("load clock state %s: %w", path, err)
+	}
+	return st.Ceiling, nil
+}
+
+// saveState writes ceiling to path. The file is synced beside the old and
+// renamed over it, so a crash mid-save leaves one or the other intact.
+func saveState(path string, ceiling uint64) error {
+	b, err := json.Marshal(state{Ceiling: ceiling})
+	if err != nil {
+		return fmt.Errorf("save clock state: %w", err)
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err == nil {
+		_, err = f.Write(b)
+		if err == nil {
+			err = f.Sync()
+		}
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("save clock state: %w", err)
+	}
+	return syncDir(filepath.Dir(path))
+}
+
+// syncDir syncs the directory dir, so a rename in it survives power loss.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("save clock state: %w", err)
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil {
+		return fmt.Errorf("save clock state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,421 @@
+// Package lab runs the whole pipeline — entity-store, classifier, fusion,
+// task-manager, mesh relay, simulators, and the GeoJSON/KML export — in one
+// process, for laptops and CI.
+package lab
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	meshv1 "github.com/boshu2/lattice-lab/gen/mesh/v1"
+	registryv1 "github.com/boshu2/lattice-lab/gen/registry/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	taskv1 "github.com/boshu2/lattice-lab/gen/task/v1"
+	"github.com/boshu2/lattice-lab/internal/classifier"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/crdt"
+	"github.com/boshu2/lattice-lab/internal/effector"
+	"github.com/boshu2/lattice-lab/internal/export"
+	"github.com/boshu2/lattice-lab/internal/fusion"
+	"github.com/boshu2/lattice-lab/internal/mesh"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"github.com/boshu2/lattice-lab/internal/registry"
+	"github.com/boshu2/lattice-lab/internal/sensor"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"github.com/boshu2/lattice-lab/internal/task"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// Component names, as accepted in Config.Components.
+const (
+	Classifier  = "classifier"
+	TaskManager = "task-manager"
+	Fusion      = "fusion"
+	SensorSim   = "sensor-sim"
+	RadarSim    = "radar-sim"
+	EffectorSim = "effector-sim"
+	Relay       = "relay"
+)
+
+// AllComponents lists every component in start order. The relay only runs
+// when it has peers or a way to discover them.
+var AllComponents = []string{Classifier, TaskManager, Fusion, SensorSim, RadarSim, EffectorSim, Relay}
+
+// ParseComponents parses a comma-separated component list. Empty means all.
+func ParseComponents(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return AllComponents, nil
+	}
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		name := strings.TrimSpace(f)
+		if !slices.Contains(AllComponents, name) {
+			return nil, fmt.Errorf("unknown component %q (want one of %s)", name, strings.Join(AllComponents, ", "))
+		}
+		out = append(out, name)
+	}
+	return out, nil
+}
+
+// Config holds the shared listen addresses, the components to run, and
+// each component's own config. StoreAddr fields in the component configs
+// are ignored; every component talks to the in-process store.
+type Config struct {
+	Listen                string           // entity-store gRPC address
+	TaskListen            string           // task-manager gRPC address; empty disables the service
+	RelayListen           string           // relay admin gRPC address; empty disables the service
+	HTTPListen            string           // GeoJSON/KML export address; empty disables it
+	Advertise             string           // mDNS service to advertise the store under, such as _lattice._tcp; empty disables
+	Components            []string         // which components to run alongside the store
+	History               int              // versions kept per entity for GetEntityHistory; 0 disables
+	TombstoneTTL          time.Duration    // how long deletes are remembered; 0 keeps them
+	ComponentTombstoneTTL time.Duration    // how long component removals are remembered; 0 keeps them
+	ArchiveRetention      time.Duration    // how long removed entities stay listable; 0 disables
+	ReaperInterval        time.Duration    // how often entities past their ttl are removed
+	Indexes               []store.IndexKey // component fields ListEntities filters use an index for
+	ClockState            string           // file the store's clock is saved to and resumed from; empty disables
+	MaxDrift              time.Duration    // how far the clock may run ahead of the wall before writes fail; 0 is unbounded
+	MaxLogical            uint32           // how high the clock's logical counter may go before writes fail; 0 is unbounded
+
+	// Entities the store holds per type at most; types not listed are
+	// unlimited.
+	Quotas map[entityv1.EntityType]int
+
+	// Strategies resolve the components the store and relay merge; nil
+	// uses crdt.Default.
+	Strategies *crdt.Registry
+
+	Classifier classifier.Config
+	Task       task.Config
+	Fusion     fusion.Config
+	Sensor     sensor.Config
+	Radar      sensor.Config
+	Effector   effector.Config
+	Relay      mesh.Config
+}
+
+var defaultIndexes, _ = store.ParseIndexes(store.DefaultIndexes)
+
+// DefaultConfig returns the same defaults as the standalone binaries.
+func DefaultConfig() Config {
+	radar := sensor.DefaultConfig()
+	radar.Interval = 2 * time.Second
+	radar.NumTracks = 3
+	radar.TrackPrefix = "radar-track-"
+	radar.Sensor = sensor.Profile("radar", "radar-1")
+
+	return Config{
+		Listen:                ":50051",
+		TaskListen:            ":50052",
+		RelayListen:           ":50053",
+		HTTPListen:            ":8080",
+		Components:            AllComponents,
+		History:               16,
+		TombstoneTTL:          store.DefaultTombstoneTTL,
+		ComponentTombstoneTTL: store.DefaultTombstoneTTL,
+		ArchiveRetention:      store.DefaultArchiveRetention,
+		ReaperInterval:        time.Second,
+		MaxDrift:              store.DefaultMaxDrift,
+		Indexes:               defaultIndexes,
+		Classifier:            classifier.DefaultConfig(),
+		Task:                  task.DefaultConfig(),
+		Fusion:                fusion.DefaultConfig(),
+		Sensor:                sensor.DefaultConfig(),
+		Radar:                 radar,
+		Effector:              effector.DefaultConfig(),
+		Relay:                 mesh.DefaultConfig(),
+	}
+}
+
+// Validate checks the config of every enabled component.
+func (cfg Config) Validate() error {
+	if cfg.Listen == "" {
+		return fmt.Errorf("listen address is required")
+	}
+	if cfg.History < 0 {
+		return fmt.Errorf("history depth must not be negative")
+	}
+	if cfg.TombstoneTTL < 0 {
+		return fmt.Errorf("tombstone ttl must not be negative")
+	}
+	if cfg.ComponentTombstoneTTL < 0 {
+		return fmt.Errorf("component tombstone ttl must not be negative")
+	}
+	if cfg.ArchiveRetention < 0 {
+		return fmt.Errorf("archive retention must not be negative")
+	}
+	if cfg.ReaperInterval <= 0 {
+		return fmt.Errorf("reaper interval must be positive")
+	}
+	if cfg.MaxDrift < 0 {
+		return fmt.Errorf("hlc max drift must not be negative")
+	}
+	checks := map[string]func() error{
+		SensorSim:   cfg.Sensor.Validate,
+		RadarSim:    cfg.Radar.Validate,
+		EffectorSim: cfg.Effector.Validate,
+	}
+	for _, name := range cfg.Components {
+		if check, ok := checks[name]; ok {
+			if err := check(); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Lab is one running pipeline.
+type Lab struct {
+	cfg Config
+}
+
+// New creates a lab with the given config.
+func New(cfg Config) *Lab {
+	return &Lab{cfg: cfg}
+}
+
+// Run serves the store and runs every enabled component until ctx is
+// cancelled or a component fails, then stops the rest and returns the
+// first failure.
+func (l *Lab) Run(ctx context.Context) error {
+	lis, err := net.Listen("tcp", l.cfg.Listen)
+	if err != nil {
+		return fmt.Errorf("listen store: %w", err)
+	}
+	return l.run(ctx, lis)
+}
+
+type component struct {
+	name string
+	run  func(context.Context) error
+}
+
+func (l *Lab) run(ctx context.Context, lis net.Listener) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s := store.New(store.WithHistory(l.cfg.History), store.WithTombstoneTTL(l.cfg.TombstoneTTL), store.WithComponentTombstoneTTL(l.cfg.ComponentTombstoneTTL), store.WithArchiveRetention(l.cfg.ArchiveRetention), store.WithIndex(l.cfg.Indexes...), store.WithQuotas(l.cfg.Quotas), store.WithStrategies(l.cfg.Strategies), store.WithMaxDrift(l.cfg.MaxDrift, l.cfg.MaxLogical))
+	if l.cfg.ClockState != "" {
+		if err := s.PersistClock(l.cfg.ClockState); err != nil {
+			lis.Close()
+			return err
+		}
+	}
+	go s.StartReaper(ctx, l.cfg.ReaperInterval)
+
+	// Components are built before the store serves, so an embedded
+	// task-manager can decide its approvals.
+	addr := "localhost:" + strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+	components, tasks, err := l.components(addr)
+	if err != nil {
+		lis.Close()
+		return err
+	}
+
+	reg := registry.New()
+	srvOpts := []server.Option{server.WithRegistry(reg)}
+	if tasks != nil {
+		srvOpts = append(srvOpts, server.WithApprovals(tasks))
+	}
+	srv := server.New(s, srvOpts...)
+	storeSrv := grpc.NewServer(metrics.ServerOption(), client.ServerOption(), grpc.UnaryInterceptor(srv.UnaryInterceptor()), grpc.StreamInterceptor(srv.StreamInterceptor()))
+	storev1.RegisterEntityStoreServiceServer(storeSrv, srv)
+	registryv1.RegisterSchemaRegistryServiceServer(storeSrv, registry.NewService(reg))
+	reflection.Register(storeSrv)
+	go storeSrv.Serve(lis) //nolint:errcheck
+	defer storeSrv.GracefulStop()
+	slog.Info("lab entity-store listening", "addr", lis.Addr().String())
+
+	if l.cfg.HTTPListen != "" {
+		lis, err := net.Listen("tcp", l.cfg.HTTPListen)
+		if err != nil {
+			return fmt.Errorf("listen export: %w", err)
+		}
+		components = append(components, component{"export", serveExport(s, lis)})
+	}
+	if l.cfg.Advertise != "" {
+		components = append(components, component{"mdns", advertise(l.cfg.Advertise, l.cfg.Relay.NodeID, lis)})
+	}
+	var names []string
+	for _, c := range components {
+		names = append(names, c.name)
+	}
+	slog.Info("lab started", "components", names)
+
+	var (
+		wg    sync.WaitGroup
+		once  sync.Once
+		first error
+	)
+	for _, c := range components {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.run(ctx)
+			if ctx.Err() != nil {
+				return // shutting down; errors from cancellation are expected
+			}
+			if err == nil {
+				err = errors.New("exited")
+			}
+			once.Do(func() {
+				first = fmt.Errorf("%s: %w", c.name, err)
+				slog.Error("lab component failed, stopping", "component", c.name, "error", err)
+				cancel()
+			})
+		}()
+	}
+	<-ctx.Done()
+	wg.Wait()
+	return first
+}
+
+// components builds the enabled components against the store at addr,
+// and returns the task-manager among them, if enabled, to decide the
+// store's approvals.
+func (l *Lab) components(addr string) ([]component, *task.Manager, error) {
+	var (
+		out   []component
+		tasks *task.Manager
+	)
+	for _, name := range AllComponents {
+		if !slices.Contains(l.cfg.Components, name) {
+			continue
+		}
+		switch name {
+		case Classifier:
+			cfg := l.cfg.Classifier
+			cfg.StoreAddr = addr
+			out = append(out, component{name, classifier.New(cfg).Run})
+		case TaskManager:
+			cfg := l.cfg.Task
+			cfg.StoreAddr = addr
+			mgr := task.New(cfg)
+			tasks = mgr
+			out = append(out, component{name, mgr.Run})
+			if l.cfg.TaskListen != "" {
+				lis, err := net.Listen("tcp", l.cfg.TaskListen)
+				if err != nil {
+					return nil, nil, fmt.Errorf("listen task-manager: %w", err)
+				}
+				out = append(out, component{"task-service", serveTasks(mgr, lis)})
+			}
+		case Fusion:
+			cfg := l.cfg.Fusion
+			cfg.StoreAddr = addr
+			out = append(out, component{name, fusion.New(cfg).Run})
+		case SensorSim:
+			cfg := l.cfg.Sensor
+			cfg.StoreAddr = addr
+			out = append(out, component{name, sensor.New(cfg).Run})
+		case RadarSim:
+			cfg := l.cfg.Radar
+			cfg.StoreAddr = addr
+			out = append(out, component{name, sensor.New(cfg).Run})
+		case EffectorSim:
+			cfg := l.cfg.Effector
+			cfg.StoreAddr = addr
+			out = append(out, component{name, effector.New(cfg).Run})
+		case Relay:
+			if len(l.cfg.Relay.Peers) == 0 && l.cfg.Relay.Discovery == nil {
+				continue
+			}
+			cfg := l.cfg.Relay
+			cfg.LocalAddr = addr
+			cfg.Strategies = l.cfg.Strategies
+			relay := mesh.New(cfg)
+			out = append(out, component{name, relay.Run})
+			if l.cfg.RelayListen != "" {
+				lis, err := net.Listen("tcp", l.cfg.RelayListen)
+				if err != nil {
+					return nil, nil, fmt.Errorf("listen relay admin: %w", err)
+				}
+				out = append(out, component{"relay-admin", serveRelay(relay, lis)})
+			}
+		}
+	}
+	return out, tasks, nil
+}
+
+// serveTasks runs the task-manager gRPC service on lis until ctx is
+// cancelled.
+func serveTasks(mgr *task.Manager, lis net.Listener) func(context.Context) error {
+	return func(ctx context.Context) error {
+		srv := grpc.NewServer(metrics.ServerOption(), client.ServerOption(),
+			grpc.ChainUnaryInterceptor(server.UnaryServerHLC(client.ProcessClock)), grpc.ChainStreamInterceptor(server.StreamServerHLC(client.ProcessClock)))
+		taskv1.RegisterTaskManagerServiceServer(srv, task.NewService(mgr))
+		reflection.Register(srv)
+		go func() {
+			<-ctx.Done()
+			srv.GracefulStop()
+		}()
+		slog.Info("lab task-manager service listening", "addr", lis.Addr().String())
+		return srv.Serve(lis)
+	}
+}
+
+// serveRelay runs the relay's admin gRPC service on lis until ctx is
+// cancelled.
+func serveRelay(relay *mesh.Relay, lis net.Listener) func(context.Context) error {
+	return func(ctx context.Context) error {
+		srv := grpc.NewServer(metrics.ServerOption(), client.ServerOption())
+		meshv1.RegisterRelayAdminServiceServer(srv, mesh.NewService(relay))
+		reflection.Register(srv)
+		go func() {
+			<-ctx.Done()
+			srv.GracefulStop()
+		}()
+		slog.Info("lab relay admin service listening", "addr", lis.Addr().String())
+		return srv.Serve(lis)
+	}
+}
+
+// advertise answers mDNS queries for service with the store on lis, named
+// instance or, if empty, the host's name, until ctx is cancelled.
+func advertise(service, instance string, lis net.Listener) func(context.Context) error {
+	return func(ctx context.Context) error {
+		if instance == "" {
+			host, err := os.Hostname()
+			if err != nil {
+				return err
+			}
+			instance = host
+		}
+		return mesh.Advertise(ctx, service, instance, lis.Addr().(*net.TCPAddr).Port)
+	}
+}
+
+// serveExport serves the GeoJSON and KML picture of s, and its metrics and
+// those of the components, on lis until ctx is cancelled.
+func serveExport(s *store.Store, lis net.Listener) func(context.Context) error {
+	return func(ctx context.Context) error {
+		mux := http.NewServeMux()
+		mux.Handle("/", export.Handler(s.List))
+		mux.Handle("GET /metrics", metrics.Default.Handler(func(w io.Writer) { s.Stats().WriteMetrics(w) }))
+		srv := &http.Server{Handler: mux}
+		go func() {
+			<-ctx.Done()
+			srv.Close()
+		}()
+		slog.Info("lab export listening", "addr", lis.Addr().String())
+		if err := srv.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		return nil
+	}
+}
@@ -0,0 +1,93 @@
+package lab
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestLab_RunsPipelineAndShutsDown(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.TaskListen = ""
+	cfg.HTTPListen = ""
+	cfg.Components = []string{Classifier, SensorSim}
+	cfg.Sensor.Interval = 50 * time.Millisecond
+	cfg.Sensor.TTL = 0
+	cfg.Sensor.NumTracks = 2
+	cfg.Sensor.Seed = 1
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- New(cfg).run(ctx, lis) }()
+	time.Sleep(500 * time.Millisecond)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	resp, err := storev1.NewEntityStoreServiceClient(conn).ListEntities(context.Background(), &storev1.ListEntitiesRequest{TypeFilter: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(resp.Entities) != 2 {
+		t.Fatalf("expected 2 simulated tracks, got %d", len(resp.Entities))
+	}
+	for _, e := range resp.Entities {
+		if _, ok := e.Components["classification"]; !ok {
+			t.Fatalf("expected %s classified in-process", e.Id)
+		}
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected clean shutdown, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("lab did not shut down")
+	}
+}
+
+func TestLab_StartupFailure(t *testing.T) {
+	busy, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer busy.Close()
+
+	cfg := DefaultConfig()
+	cfg.TaskListen = busy.Addr().String()
+	cfg.Components = []string{TaskManager}
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	if err := New(cfg).run(context.Background(), lis); err == nil {
+		t.Fatal("expected error when the task-manager port is taken")
+	}
+}
+
+func TestParseComponents(t *testing.T) {
+	got, err := ParseComponents("classifier, fusion")
+	if err != nil || len(got) != 2 || got[1] != Fusion {
+		t.Fatalf("got %v, %v", got, err)
+	}
+	if all, _ := ParseComponents(""); len(all) != len(AllComponents) {
+		t.Fatalf("expected all components, got %v", all)
+	}
+	if _, err := ParseComponents("radar"); err == nil {
+		t.Fatal("expected error for unknown component")
+	}
+}
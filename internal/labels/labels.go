@@ -0,0 +1,132 @@
+// Package labels parses entity label sets ("exercise=bravo,side=blue") and
+// the selectors that filter entities by them ("exercise=bravo,side!=red").
+package labels
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Validate checks a label set can be written as a selector: keys are
+// non-empty and neither keys nor values hold the separators , = !.
+func Validate(set map[string]string) error {
+	for k, v := range set {
+		if k == "" || strings.ContainsAny(k, ",=! ") {
+			return fmt.Errorf("label key %q must be non-empty without , = ! or spaces", k)
+		}
+		if strings.ContainsAny(v, ",=!") {
+			return fmt.Errorf("label %q value %q must not contain , = or !", k, v)
+		}
+	}
+	return nil
+}
+
+// ParseSet parses "key=value,key=value" into a label set.
+func ParseSet(s string) (map[string]string, error) {
+	set := make(map[string]string)
+	for _, term := range strings.Split(s, ",") {
+		if term = strings.TrimSpace(term); term == "" {
+			continue
+		}
+		k, v, ok := strings.Cut(term, "=")
+		if !ok {
+			return nil, fmt.Errorf("label %q: want key=value", term)
+		}
+		set[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	if err := Validate(set); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// Format writes a label set as ParseSet reads it, keys sorted.
+func Format(set map[string]string) string {
+	terms := make([]string, 0, len(set))
+	for _, k := range slices.Sorted(maps.Keys(set)) {
+		terms = append(terms, k+"="+set[k])
+	}
+	return strings.Join(terms, ",")
+}
+
+type op int
+
+const (
+	opEq     op = iota // key=value or key==value
+	opNe               // key!=value; also matches entities without key
+	opExists           // key
+	opAbsent           // !key
+)
+
+type requirement struct {
+	key, value string
+	op         op
+}
+
+// Selector matches label sets against every one of its requirements. The
+// zero Selector matches everything.
+type Selector struct {
+	reqs []requirement
+}
+
+// Parse parses a comma-separated selector. Each term is key=value,
+// key==value, key!=value, key (the label is set), or !key (it is not).
+func Parse(s string) (Selector, error) {
+	var sel Selector
+	for _, term := range strings.Split(s, ",") {
+		if term = strings.TrimSpace(term); term == "" {
+			continue
+		}
+		var r requirement
+		switch {
+		case strings.Contains(term, "!="):
+			r.key, r.value, _ = strings.Cut(term, "!=")
+			r.op = opNe
+		case strings.Contains(term, "=="):
+			r.key, r.value, _ = strings.Cut(term, "==")
+		case strings.Contains(term, "="):
+			r.key, r.value, _ = strings.Cut(term, "=")
+		case strings.HasPrefix(term, "!"):
+			r.key, r.op = term[1:], opAbsent
+		default:
+			r.key, r.op = term, opExists
+		}
+		r.key, r.value = strings.TrimSpace(r.key), strings.TrimSpace(r.value)
+		if r.key == "" || strings.ContainsAny(r.key, "=! ") || strings.ContainsAny(r.value, "=!") {
+			return Selector{}, fmt.Errorf("selector term %q: want key=value, key!=value, key, or !key", term)
+		}
+		sel.reqs = append(sel.reqs, r)
+	}
+	return sel, nil
+}
+
+// Empty reports whether the selector has no requirements.
+func (sel Selector) Empty() bool { return len(sel.reqs) == 0 }
+
+// Matches reports whether set meets every requirement.
+func (sel Selector) Matches(set map[string]string) bool {
+	for _, r := range sel.reqs {
+		v, ok := set[r.key]
+		switch r.op {
+		case opEq:
+			if !ok || v != r.value {
+				return false
+			}
+		case opNe:
+			if ok && v == r.value {
+				return false
+			}
+		case opExists:
+			if !ok {
+				return false
+			}
+		case opAbsent:
+			if ok {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package labels
+
+import "testing"
+
+func TestSelector(t *testing.T) {
+	set := map[string]string{"exercise": "bravo", "side": "blue"}
+	for _, tc := range []struct {
+		selector string
+		want     bool
+	}{
+		{"", true},
+		{"exercise=bravo", true},
+		{"exercise==bravo, side=blue", true},
+		{"exercise=alpha", false},
+		{"side!=red", true},
+		{"side!=blue", false},
+		{"team!=red", true},
+		{"side", true},
+		{"team", false},
+		{"!team", true},
+		{"!side", false},
+	} {
+		sel, err := Parse(tc.selector)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tc.selector, err)
+		}
+		if got := sel.Matches(set); got != tc.want {
+			t.Errorf("%q matches %v = %v, want %v", tc.selector, set, got, tc.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, s := range []string{"=bravo", "a=b=c", "!", "side!=!red", "two words"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q): expected an error", s)
+		}
+	}
+}
+
+func TestParseSet(t *testing.T) {
+	set, err := ParseSet("exercise=bravo, side=blue,")
+	if err != nil {
+		t.Fatalf("ParseSet: %v", err)
+	}
+	if got := Format(set); got != "exercise=bravo,side=blue" {
+		t.Fatalf("Format = %q", got)
+	}
+	for _, s := range []string{"exercise", "=bravo", "side=blue=red", "a!=b"} {
+		if _, err := ParseSet(s); err == nil {
+			t.Errorf("ParseSet(%q): expected an error", s)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+// Package loadgen drives an entity store with many synthetic tracks at a
+// controlled aggregate update rate, and reports client-side latency and
+// errors, for store, relay, and fusion performance testing.
+package loadgen
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/sensor"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	metersPerDegreeLat = 111_320.0
+	knotsToMps         = 0.514444
+	pace               = 10 * time.Millisecond // dispatcher granularity
+)
+
+// RampStep sets the target update rate at At after the start; the rate
+// moves linearly between steps.
+type RampStep struct {
+	At   time.Duration
+	Rate float64 // updates/s
+}
+
+// ParseRamp parses "0s:100,30s:1000,2m:5000". Steps must be in time order.
+func ParseRamp(s string) ([]RampStep, error) {
+	var steps []RampStep
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		at, rate, ok := strings.Cut(part, ":")
+		if !ok {
+			return nil, fmt.Errorf("ramp step %q: want duration:rate", part)
+		}
+		d, err := time.ParseDuration(at)
+		if err != nil {
+			return nil, fmt.Errorf("ramp step %q: %w", part, err)
+		}
+		r, err := strconv.ParseFloat(rate, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ramp step %q: %w", part, err)
+		}
+		if len(steps) > 0 && d <= steps[len(steps)-1].At {
+			return nil, fmt.Errorf("ramp step %q: out of time order", part)
+		}
+		steps = append(steps, RampStep{At: d, Rate: r})
+	}
+	return steps, nil
+}
+
+// Config controls the load generator.
+type Config struct {
+	StoreAddr   string
+	Tracks      int
+	Rate        float64       // aggregate updates/s when Ramp is empty
+	Ramp        []RampStep    // rate profile; overrides Rate
+	Duration    time.Duration // stop after this long; 0 runs until cancelled
+	Workers     int           // concurrent RPCs
+	ReportEvery time.Duration
+	IDPrefix    string
+	BBox        sensor.BBox
+	TTL         time.Duration // store expiry on each write; 0 disables
+	Seed        uint64        // 0 picks one at random
+	AuthToken   string        // bearer token, for a store with AUTH_TOKENS set
+}
+
+// DefaultConfig returns a config for a thousand tracks over the DC metro
+// area at 500 updates/s.
+func DefaultConfig() Config {
+	return Config{
+		StoreAddr:   "localhost:50051",
+		Tracks:      1000,
+		Rate:        500,
+		Workers:     32,
+		ReportEvery: 5 * time.Second,
+		IDPrefix:    "load-",
+		BBox: sensor.BBox{
+			MinLat: 38.8, MaxLat: 39.0,
+			MinLon: -77.2, MaxLon: -76.9,
+		},
+		TTL: 30 * time.Second,
+	}
+}
+
+// Validate checks the config is runnable.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.Tracks <= 0:
+		return fmt.Errorf("tracks must be positive")
+	case cfg.Rate < 0:
+		return fmt.Errorf("rate must not be negative")
+	case cfg.Workers <= 0:
+		return fmt.Errorf("workers must be positive")
+	case cfg.ReportEvery <= 0:
+		return fmt.Errorf("report interval must be positive")
+	case cfg.Duration < 0 || cfg.TTL < 0:
+		return fmt.Errorf("duration and ttl must not be negative")
+	}
+	for _, st := range cfg.Ramp {
+		if st.Rate < 0 {
+			return fmt.Errorf("ramp rate must not be negative")
+		}
+	}
+	return cfg.BBox.Validate()
+}
+
+// rateAt returns the target rate at elapsed.
+func (cfg Config) rateAt(elapsed time.Duration) float64 {
+	if len(cfg.Ramp) == 0 {
+		return cfg.Rate
+	}
+	if elapsed <= cfg.Ramp[0].At {
+		return cfg.Ramp[0].Rate
+	}
+	for i := 1; i < len(cfg.Ramp); i++ {
+		a, b := cfg.Ramp[i-1], cfg.Ramp[i]
+		if elapsed < b.At {
+			f := float64(elapsed-a.At) / float64(b.At-a.At)
+			return a.Rate + f*(b.Rate-a.Rate)
+		}
+	}
+	return cfg.Ramp[len(cfg.Ramp)-1].Rate
+}
+
+// loadTrack is one synthetic track. Its position is a closed-form function
+// of time, so workers can update any track without shared mutable state.
+type loadTrack struct {
+	id       string
+	lat, lon float64
+	alt      float64
+	speed    float64 // m/s
+	heading  float64 // degrees
+}
+
+// Generator publishes synthetic tracks at the configured rate.
+type Generator struct {
+	cfg    Config
+	tracks []loadTrack
+	start  time.Time
+	stats  *stats
+}
+
+// New creates a generator with the given config.
+func New(cfg Config) *Generator {
+	if cfg.Seed == 0 {
+		cfg.Seed = rand.Uint64()
+	}
+	rng := rand.New(rand.NewPCG(cfg.Seed, 0))
+	tracks := make([]loadTrack, cfg.Tracks)
+	for i := range tracks {
+		tracks[i] = loadTrack{
+			id:      fmt.Sprintf("%s%d", cfg.IDPrefix, i),
+			lat:     cfg.BBox.MinLat + rng.Float64()*(cfg.BBox.MaxLat-cfg.BBox.MinLat),
+			lon:     cfg.BBox.MinLon + rng.Float64()*(cfg.BBox.MaxLon-cfg.BBox.MinLon),
+			alt:     rng.Float64()*5000 + 1000,
+			speed:   (rng.Float64()*400 + 100) * knotsToMps,
+			heading: rng.Float64() * 360,
+		}
+	}
+	return &Generator{cfg: cfg, tracks: tracks, stats: newStats()}
+}
+
+// Run creates every track, then updates them round-robin at the target rate
+// until ctx is cancelled or Duration passes. It logs a report every
+// ReportEvery and a summary at the end, which it also returns.
+func (g *Generator) Run(ctx context.Context) (Report, error) {
+	conn, err := client.Dial(g.cfg.StoreAddr, client.WithToken(g.cfg.AuthToken))
+	if err != nil {
+		return Report{}, fmt.Errorf("connect to store: %w", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	// Duration bounds dispatch only, so in-flight writes finish cleanly.
+	dispatchCtx := ctx
+	if g.cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		dispatchCtx, cancel = context.WithTimeout(ctx, g.cfg.Duration)
+		defer cancel()
+	}
+
+	slog.Info("loadgen started", "tracks", len(g.tracks), "rate", g.cfg.Rate, "ramp_steps", len(g.cfg.Ramp), "workers", g.cfg.Workers, "seed", g.cfg.Seed, "store_addr", g.cfg.StoreAddr)
+
+	g.start = time.Now()
+	jobs := make(chan int, g.cfg.Workers*2)
+	var wg sync.WaitGroup
+	for range g.cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				g.publish(ctx, client, i)
+			}
+		}()
+	}
+
+	g.dispatch(dispatchCtx, jobs)
+	close(jobs)
+	wg.Wait()
+
+	elapsed := time.Since(g.start)
+	total := g.stats.total(elapsed)
+	total.TargetRate = g.cfg.rateAt(elapsed)
+	total.log("loadgen finished")
+	return total, nil
+}
+
+// dispatch queues track indexes at the target rate: creates first, then
+// updates round-robin. Work that finds every worker busy is counted as
+// dropped rather than queued, so the achieved rate shows saturation.
+func (g *Generator) dispatch(ctx context.Context, jobs chan<- int) {
+	ticker := time.NewTicker(pace)
+	defer ticker.Stop()
+	report := time.NewTicker(g.cfg.ReportEvery)
+	defer report.Stop()
+
+	next, due, last := 0, 0.0, time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-report.C:
+			r := g.stats.window()
+			r.TargetRate = g.cfg.rateAt(time.Since(g.start))
+			r.log("load report")
+		case now := <-ticker.C:
+			due += g.cfg.rateAt(now.Sub(g.start)) * now.Sub(last).Seconds()
+			last = now
+			for ; due >= 1; due-- {
+				select {
+				case jobs <- next:
+					next++
+				default:
+					g.stats.drop()
+				}
+			}
+		}
+	}
+}
+
+// publish creates track n's entity on its first pass and updates it after. A
+// track left over from an earlier run is updated in place.
+func (g *Generator) publish(ctx context.Context, client storev1.EntityStoreServiceClient, n int) {
+	t := &g.tracks[n%len(g.tracks)]
+	now := time.Now()
+	entity, err := g.buildEntity(t, now.Sub(g.start))
+	if err != nil {
+		g.stats.record(0, err)
+		return
+	}
+
+	var ttl *durationpb.Duration
+	if g.cfg.TTL > 0 {
+		ttl = durationpb.New(g.cfg.TTL)
+	}
+	if n < len(g.tracks) {
+		_, err = client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity, Ttl: ttl})
+	}
+	if n >= len(g.tracks) || status.Code(err) == codes.AlreadyExists {
+		// Stamp the wall clock as the HLC so the merge accepts the update
+		// without a Get round trip; this assumes the store's clock is close.
+		entity.HlcPhysical = uint64(now.UnixNano())
+		_, err = client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity, Ttl: ttl})
+	}
+	if ctx.Err() != nil {
+		return // shutting down; not a store error
+	}
+	g.stats.record(time.Since(now), err)
+}
+
+// position dead-reckons t to elapsed, wrapping at the bbox edges.
+func (g *Generator) position(t *loadTrack, elapsed time.Duration) (float64, float64) {
+	bb := g.cfg.BBox
+	hdg := t.heading * math.Pi / 180
+	ds := t.speed * elapsed.Seconds()
+	lat := t.lat + ds*math.Cos(hdg)/metersPerDegreeLat
+	lon := t.lon + ds*math.Sin(hdg)/(metersPerDegreeLat*math.Cos(t.lat*math.Pi/180))
+	return wrap(lat, bb.MinLat, bb.MaxLat), wrap(lon, bb.MinLon, bb.MaxLon)
+}
+
+func wrap(v, lo, hi float64) float64 {
+	span := hi - lo
+	return lo + math.Mod(math.Mod(v-lo, span)+span, span)
+}
+
+func (g *Generator) buildEntity(t *loadTrack, elapsed time.Duration) (*entityv1.Entity, error) {
+	lat, lon := g.position(t, elapsed)
+	pos, err := anypb.New(&entityv1.PositionComponent{Lat: lat, Lon: lon, Alt: t.alt})
+	if err != nil {
+		return nil, fmt.Errorf("pack position: %w", err)
+	}
+	vel, err := anypb.New(&entityv1.VelocityComponent{Speed: t.speed / knotsToMps, Heading: t.heading})
+	if err != nil {
+		return nil, fmt.Errorf("pack velocity: %w", err)
+	}
+	src, err := anypb.New(&entityv1.SourceComponent{SensorId: "loadgen", SensorType: "synthetic"})
+	if err != nil {
+		return nil, fmt.Errorf("pack source: %w", err)
+	}
+	return &entityv1.Entity{
+		Id:   t.id,
+		Type: entityv1.EntityType_ENTITY_TYPE_TRACK,
+		Components: map[string]*anypb.Any{
+			"position": pos,
+			"velocity": vel,
+			"source":   src,
+		},
+	}, nil
+}
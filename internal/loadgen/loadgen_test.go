@@ -0,0 +1,144 @@
+package loadgen
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+)
+
+func startTestServer(t *testing.T, opts ...server.Option) (string, *store.Store, func()) {
+	t.Helper()
+
+	s := store.New()
+	api := server.New(s, opts...)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(api.UnaryInterceptor()), grpc.StreamInterceptor(api.StreamInterceptor()))
+	storev1.RegisterEntityStoreServiceServer(srv, api)
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+
+	go srv.Serve(lis) //nolint:errcheck
+
+	cleanup := func() {
+		srv.Stop()
+	}
+	return lis.Addr().String(), s, cleanup
+}
+
+func TestParseRamp(t *testing.T) {
+	steps, err := ParseRamp("0s:100, 30s:1000,1m:0")
+	if err != nil {
+		t.Fatalf("ParseRamp: %v", err)
+	}
+	if len(steps) != 3 || steps[1] != (RampStep{At: 30 * time.Second, Rate: 1000}) {
+		t.Fatalf("unexpected steps %+v", steps)
+	}
+	for _, bad := range []string{"100", "soon:5", "1s:x", "10s:1,5s:2"} {
+		if _, err := ParseRamp(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestConfig_RateAt(t *testing.T) {
+	cfg := Config{Rate: 50}
+	if r := cfg.rateAt(time.Hour); r != 50 {
+		t.Fatalf("expected flat rate 50, got %v", r)
+	}
+
+	cfg.Ramp = []RampStep{{At: 10 * time.Second, Rate: 100}, {At: 20 * time.Second, Rate: 300}}
+	for _, tc := range []struct {
+		at   time.Duration
+		want float64
+	}{
+		{0, 100},
+		{15 * time.Second, 200},
+		{time.Minute, 300},
+	} {
+		if r := cfg.rateAt(tc.at); r != tc.want {
+			t.Fatalf("rateAt(%s) = %v, want %v", tc.at, r, tc.want)
+		}
+	}
+}
+
+func TestGenerator_Run(t *testing.T) {
+	addr, s, cleanup := startTestServer(t)
+	defer cleanup()
+
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	cfg.Tracks = 20
+	cfg.Rate = 400
+	cfg.Workers = 4
+	cfg.Duration = 600 * time.Millisecond
+	cfg.ReportEvery = 200 * time.Millisecond
+
+	gen := New(cfg)
+	first, _ := gen.position(&gen.tracks[0], 0)
+	r, err := gen.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// 400/s for 0.6s is ~240 writes; allow for scheduling slack.
+	if r.OK < 150 || r.ErrorCount() != 0 {
+		t.Fatalf("expected ~240 clean writes, got %+v", r)
+	}
+	if r.P50 <= 0 || r.Max < r.P99 || r.P99 < r.P50 {
+		t.Fatalf("inconsistent latency percentiles %+v", r)
+	}
+	if n := len(s.List(entityv1.EntityType_ENTITY_TYPE_TRACK)); n != 20 {
+		t.Fatalf("expected 20 tracks in the store, got %d", n)
+	}
+
+	// Updates land: the stored position has moved off the start.
+	e, err := s.Get("load-0")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	pos := &entityv1.PositionComponent{}
+	if err := e.Components["position"].UnmarshalTo(pos); err != nil {
+		t.Fatalf("unmarshal position: %v", err)
+	}
+	if pos.Lat == first {
+		t.Fatal("expected updates to move load-0")
+	}
+}
+
+func TestGenerator_RunWithAuth(t *testing.T) {
+	addr, s, cleanup := startTestServer(t, server.WithAuth(server.StaticTokens{"load": server.RoleSensor}))
+	defer cleanup()
+
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	cfg.Tracks = 5
+	cfg.Rate = 100
+	cfg.Workers = 2
+	cfg.Duration = 200 * time.Millisecond
+	cfg.ReportEvery = time.Second
+
+	if r, _ := New(cfg).Run(context.Background()); r.Errors["Unauthenticated"] == 0 {
+		t.Fatalf("expected writes without a token refused, got %+v", r)
+	}
+
+	cfg.AuthToken = "load"
+	r, err := New(cfg).Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if r.OK == 0 || r.ErrorCount() != 0 {
+		t.Fatalf("expected clean writes with a token, got %+v", r)
+	}
+	if n := len(s.List(entityv1.EntityType_ENTITY_TYPE_TRACK)); n != 5 {
+		t.Fatalf("expected 5 tracks in the store, got %d", n)
+	}
+}
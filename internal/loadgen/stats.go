@@ -0,0 +1,130 @@
+package loadgen
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/status"
+)
+
+// Report summarises client-side results over a window or a whole run.
+type Report struct {
+	Elapsed      time.Duration
+	TargetRate   float64 // updates/s asked for at the end of the window
+	OK           int
+	Errors       map[string]int // by gRPC status code
+	Dropped      int            // due work skipped because every worker was busy
+	P50, P95     time.Duration
+	P99, Max     time.Duration
+	AchievedRate float64 // successful writes/s
+}
+
+// ErrorCount returns the total number of failed writes.
+func (r Report) ErrorCount() int {
+	n := 0
+	for _, c := range r.Errors {
+		n += c
+	}
+	return n
+}
+
+func (r Report) log(msg string) {
+	slog.Info(msg,
+		"elapsed", r.Elapsed.Round(time.Millisecond),
+		"target_rate", r.TargetRate,
+		"achieved_rate", r.AchievedRate,
+		"ok", r.OK,
+		"errors", r.ErrorCount(),
+		"error_codes", r.Errors,
+		"dropped", r.Dropped,
+		"p50", r.P50,
+		"p95", r.P95,
+		"p99", r.P99,
+		"max", r.Max,
+	)
+}
+
+// stats accumulates latencies and outcomes, per report window and for the
+// whole run.
+type stats struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	cur, all    counts
+}
+
+type counts struct {
+	latencies []time.Duration
+	ok        int
+	errors    map[string]int
+	dropped   int
+}
+
+func newStats() *stats {
+	return &stats{
+		windowStart: time.Now(),
+		cur:         counts{errors: make(map[string]int)},
+		all:         counts{errors: make(map[string]int)},
+	}
+}
+
+func (s *stats) record(latency time.Duration, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range []*counts{&s.cur, &s.all} {
+		if err != nil {
+			c.errors[status.Code(err).String()]++
+			continue
+		}
+		c.ok++
+		c.latencies = append(c.latencies, latency)
+	}
+}
+
+func (s *stats) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cur.dropped++
+	s.all.dropped++
+}
+
+// window returns the report for the window since the last call and starts a
+// new one.
+func (s *stats) window() Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := s.cur.report(time.Since(s.windowStart))
+	s.cur = counts{errors: make(map[string]int)}
+	s.windowStart = time.Now()
+	return r
+}
+
+// total returns the report for the whole run.
+func (s *stats) total(elapsed time.Duration) Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.all.report(elapsed)
+}
+
+func (c counts) report(elapsed time.Duration) Report {
+	r := Report{Elapsed: elapsed, OK: c.ok, Errors: make(map[string]int, len(c.errors)), Dropped: c.dropped}
+	for code, n := range c.errors {
+		r.Errors[code] = n
+	}
+	if elapsed > 0 {
+		r.AchievedRate = float64(c.ok) / elapsed.Seconds()
+	}
+	if len(c.latencies) > 0 {
+		lat := slices.Clone(c.latencies)
+		slices.Sort(lat)
+		r.P50, r.P95, r.P99 = percentile(lat, 0.50), percentile(lat, 0.95), percentile(lat, 0.99)
+		r.Max = lat[len(lat)-1]
+	}
+	return r
+}
+
+// percentile returns the q-th percentile of sorted latencies.
+func percentile(sorted []time.Duration, q float64) time.Duration {
+	return sorted[int(q*float64(len(sorted)-1))]
+}
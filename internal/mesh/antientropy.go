@@ -0,0 +1,384 @@
+package mesh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/crdt"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// antiEntropy reconciles the local store with each current peer every
+// cfg.AntiEntropy until ctx is cancelled, so stores that missed events, to
+// a partition or a dropped forward, converge without waiting for the
+// entities to be written again.
+func (r *Relay) antiEntropy(ctx context.Context, local storev1.EntityStoreServiceClient) {
+	ticker := time.NewTicker(r.cfg.AntiEntropy)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for addr, peer := range r.reconcilePeers() {
+			if err := r.reconcile(ctx, addr, local, peer); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.Warn("mesh-relay anti-entropy failed", "peer", addr, "error", err)
+				r.mu.Lock()
+				r.stats.Errors++
+				r.mu.Unlock()
+				errorsTotal.Inc()
+			}
+		}
+	}
+}
+
+// reconcilePeers returns the peers for an anti-entropy pass: all of them,
+// or under gossip GossipFanout picked at random.
+func (r *Relay) reconcilePeers() map[string]storev1.EntityStoreServiceClient {
+	peers := r.peerClients()
+	if r.cfg.GossipFanout <= 0 || len(peers) <= r.cfg.GossipFanout {
+		return peers
+	}
+	addrs := slices.Collect(maps.Keys(peers))
+	rand.Shuffle(len(addrs), func(i, j int) { addrs[i], addrs[j] = addrs[j], addrs[i] })
+	for _, addr := range addrs[r.cfg.GossipFanout:] {
+		delete(peers, addr)
+	}
+	return peers
+}
+
+// reconcile finds the entities on which the local store and peer differ
+// and, for each whose HLC differs between them, writes the CRDT merge of
+// the two copies to each side that does not already hold it. An entity
+// only one side has is created on the other, unless that side's tombstone
+// refuses it.
+//
+// Peers are repaired only as the replication policy allows: with what it
+// replicates of the entities it admits.
+//
+// Repairs carry this node as their origin: the local relay does not
+// forward them, and each pair of stores is settled by its own relays'
+// passes rather than by the repair echoing round the mesh.
+//
+// A pass that completes records what it found as the peer at addr's
+// Convergence.
+func (r *Relay) reconcile(ctx context.Context, addr string, local, peer storev1.EntityStoreServiceClient) error {
+	mine, theirs, err := diverged(ctx, local, peer)
+	if err != nil {
+		return err
+	}
+
+	ctx = server.ContextWithOrigin(ctx, r.cfg.NodeID)
+	var conv Convergence
+	for id, e := range mine {
+		p, ok := theirs[id]
+		if !ok {
+			if !r.cfg.Policy.admits(e) {
+				continue
+			}
+			conv.Diverged++
+			if err := r.repair(ctx, peer, nil, r.cfg.Policy.strip(e), nil); err != nil {
+				return fmt.Errorf("repair %q on peer: %w", id, err)
+			}
+			continue
+		}
+		if sameHLC(e, p) {
+			continue
+		}
+		// Copies differing only in their HLCs, or in what the policy
+		// strips, have converged.
+		if !sameContent(r.cfg.Policy.strip(e), r.cfg.Policy.strip(p)) {
+			conv.Diverged++
+			for _, strategy := range r.cfg.Strategies.Conflicts(e, p) {
+				conv.resolved(strategy)
+			}
+		}
+		merged, report := r.cfg.Strategies.MergeEntityReport(e, p)
+		logMerge(merged, report)
+		if err := r.repair(ctx, local, e, merged, mergeDecisions(report, crdt.SideA, nil)); err != nil {
+			return fmt.Errorf("repair %q locally: %w", id, err)
+		}
+		if !r.cfg.Policy.admits(merged) {
+			continue
+		}
+		// Compared without the stripped components too, so a peer's own
+		// are not taken for a difference on every pass.
+		if err := r.repair(ctx, peer, r.cfg.Policy.strip(p), r.cfg.Policy.strip(merged), mergeDecisions(report, crdt.SideB, r.cfg.Policy)); err != nil {
+			return fmt.Errorf("repair %q on peer: %w", id, err)
+		}
+	}
+	for id, p := range theirs {
+		if _, ok := mine[id]; ok {
+			continue
+		}
+		conv.Diverged++
+		if err := r.repair(ctx, local, nil, p, nil); err != nil {
+			return fmt.Errorf("repair %q locally: %w", id, err)
+		}
+	}
+	r.converged(addr, conv)
+	return nil
+}
+
+// Convergence is what the last anti-entropy pass between the local store
+// and one peer found: how many entities they disagreed on, and how the
+// components both held with different values were resolved. A converged
+// pair finds none.
+type Convergence struct {
+	Diverged        int       // entities only one store had, or whose copies differed
+	ResolvedLWW     int       // component conflicts resolved last-writer-wins
+	ResolvedMaxWins int       // component conflicts resolved max-wins
+	ResolvedOther   int       // component conflicts resolved by any other strategy
+	At              time.Time // when the pass finished; zero before the first
+}
+
+func (c *Convergence) resolved(strategy crdt.Strategy) {
+	switch strategy {
+	case crdt.LWW:
+		c.ResolvedLWW++
+	case crdt.MaxWins:
+		c.ResolvedMaxWins++
+	default:
+		c.ResolvedOther++
+	}
+	resolvedTotal.Inc(string(strategy))
+}
+
+// converged records conv as the peer at addr's latest pass and adds it to
+// the relay's totals.
+func (r *Relay) converged(addr string, conv Convergence) {
+	conv.At = time.Now()
+	r.mu.Lock()
+	r.convergence[addr] = conv
+	r.stats.Diverged += conv.Diverged
+	r.stats.ResolvedLWW += conv.ResolvedLWW
+	r.stats.ResolvedMaxWins += conv.ResolvedMaxWins
+	r.stats.ResolvedOther += conv.ResolvedOther
+	r.mu.Unlock()
+	divergedTotal.Add(float64(conv.Diverged))
+	if conv.Diverged > 0 {
+		slog.Info("mesh-relay anti-entropy pass", "peer", addr, "diverged", conv.Diverged, "lww", conv.ResolvedLWW, "max_wins", conv.ResolvedMaxWins, "other", conv.ResolvedOther)
+	}
+}
+
+// diverged returns each side's copies of the entities the local store and
+// peer disagree on, found by walking their digest trees, or every entity
+// if either store predates DigestEntities.
+func diverged(ctx context.Context, local, peer storev1.EntityStoreServiceClient) (mine, theirs map[string]*entityv1.Entity, err error) {
+	ids, err := divergedIDs(ctx, local, peer)
+	if status.Code(err) == codes.Unimplemented {
+		if mine, err = snapshot(ctx, local); err != nil {
+			return nil, nil, fmt.Errorf("list local store: %w", err)
+		}
+		if theirs, err = snapshot(ctx, peer); err != nil {
+			return nil, nil, fmt.Errorf("list peer: %w", err)
+		}
+		return mine, theirs, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if mine, err = fetch(ctx, local, ids); err != nil {
+		return nil, nil, fmt.Errorf("read local store: %w", err)
+	}
+	if theirs, err = fetch(ctx, peer, ids); err != nil {
+		return nil, nil, fmt.Errorf("read peer: %w", err)
+	}
+	return mine, theirs, nil
+}
+
+// divergedIDs descends the two stores' digest trees from the root, one
+// level per call to each, into only the nodes whose hashes differ, and
+// returns the IDs of the entities that differ in the leaves reached. Stores
+// that agree cost one call each.
+func divergedIDs(ctx context.Context, local, peer storev1.EntityStoreServiceClient) ([]string, error) {
+	nodes := []uint32{0}
+	for level := uint32(0); ; level++ {
+		req := &storev1.DigestEntitiesRequest{Level: level, Nodes: nodes}
+		mine, err := local.DigestEntities(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("digest local store: %w", err)
+		}
+		theirs, err := peer.DigestEntities(ctx, req)
+		if err != nil {
+			return nil, fmt.Errorf("digest peer: %w", err)
+		}
+		if len(mine.Nodes) != len(nodes) || len(theirs.Nodes) != len(nodes) {
+			return nil, fmt.Errorf("digest level %d: asked for %d nodes, got %d and %d", level, len(nodes), len(mine.Nodes), len(theirs.Nodes))
+		}
+
+		if level == store.DigestDepth {
+			var ids []string
+			for i, leaf := range mine.Nodes {
+				ids = append(ids, differing(leaf.Entities, theirs.Nodes[i].Entities)...)
+			}
+			return ids, nil
+		}
+		var next []uint32
+		for i, node := range mine.Nodes {
+			if !bytes.Equal(node.Hash, theirs.Nodes[i].Hash) {
+				for c := range uint32(store.DigestFanout) {
+					next = append(next, node.Index*store.DigestFanout+c)
+				}
+			}
+		}
+		if len(next) == 0 {
+			return nil, nil
+		}
+		nodes = next
+	}
+}
+
+// differing returns the IDs in either leaf whose hashes do not match the
+// other's.
+func differing(a, b []*storev1.EntityDigest) []string {
+	hashes := make(map[string][]byte, len(b))
+	for _, d := range b {
+		hashes[d.Id] = d.Hash
+	}
+	var ids []string
+	for _, d := range a {
+		h, ok := hashes[d.Id]
+		if !ok || !bytes.Equal(h, d.Hash) {
+			ids = append(ids, d.Id)
+		}
+		delete(hashes, d.Id)
+	}
+	for id := range hashes {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// fetch reads the entities called ids from a store, leaving out those it
+// does not have.
+func fetch(ctx context.Context, c storev1.EntityStoreServiceClient, ids []string) (map[string]*entityv1.Entity, error) {
+	entities := make(map[string]*entityv1.Entity, len(ids))
+	for _, id := range ids {
+		e, err := c.GetEntity(ctx, &storev1.GetEntityRequest{Id: id})
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		entities[id] = e
+	}
+	return entities, nil
+}
+
+// repair brings one store's copy of an entity, have, nil if it has none,
+// up to want. A copy already matching want is left alone, so stores that
+// have converged are not written to on every pass.
+func (r *Relay) repair(ctx context.Context, c storev1.EntityStoreServiceClient, have, want *entityv1.Entity, merge []*storev1.MergeDecision) error {
+	if have == nil {
+		_, err := c.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: want})
+		switch status.Code(err) {
+		case codes.OK:
+		case codes.FailedPrecondition, codes.AlreadyExists:
+			return nil // deleted there, or written since the listing
+		default:
+			return err
+		}
+	} else {
+		if sameContent(have, want) {
+			return nil
+		}
+		update := proto.Clone(want).(*entityv1.Entity)
+		update.Type = have.Type
+		if _, err := c.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: update, Merge: merge}); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	r.stats.Repaired++
+	r.mu.Unlock()
+	repairedTotal.Inc()
+	return nil
+}
+
+// mergeDecisions converts report for the audit log of the store that held
+// side's copy, leaving out the keys policy never replicates to it.
+func mergeDecisions(report *crdt.MergeReport, stored crdt.Side, policy *Policy) []*storev1.MergeDecision {
+	var out []*storev1.MergeDecision
+	for _, d := range report.Decisions {
+		if policy != nil && slices.Contains(policy.Strip, d.Key) {
+			continue
+		}
+		kept := string(d.Kept)
+		switch d.Kept {
+		case stored:
+			kept = "stored"
+		case crdt.SideA, crdt.SideB:
+			kept = "incoming"
+		}
+		out = append(out, &storev1.MergeDecision{Key: d.Key, Kept: kept, Strategy: string(d.Strategy), Removed: d.Removed})
+	}
+	return out
+}
+
+// logMerge logs how the merge that made merged resolved the keys its
+// copies differed on, if any, with the merged HLC.
+func logMerge(merged *entityv1.Entity, report *crdt.MergeReport) {
+	if len(report.Decisions) > 0 {
+		ts := hlc.Timestamp{Physical: merged.HlcPhysical, Logical: merged.HlcLogical, Node: merged.HlcNode}
+		slog.Debug("mesh-relay merge", "entity", merged.Id, "hlc", ts.String(), "decisions", report.String())
+	}
+}
+
+// snapshot lists every entity in a store by ID.
+func snapshot(ctx context.Context, c storev1.EntityStoreServiceClient) (map[string]*entityv1.Entity, error) {
+	stream, err := c.SnapshotEntities(ctx, &storev1.SnapshotEntitiesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	entities := make(map[string]*entityv1.Entity)
+	for {
+		e, err := stream.Recv()
+		if err == io.EOF {
+			return entities, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		entities[e.Id] = e
+	}
+}
+
+func sameHLC(a, b *entityv1.Entity) bool {
+	return a.HlcPhysical == b.HlcPhysical && a.HlcLogical == b.HlcLogical && a.HlcNode == b.HlcNode
+}
+
+// sameContent reports whether a and b hold the same components and labels.
+// Stores restamp the HLCs of what they are sent, so converged copies
+// differ in those alone.
+func sameContent(a, b *entityv1.Entity) bool {
+	if len(a.Components) != len(b.Components) || !maps.Equal(a.Labels, b.Labels) {
+		return false
+	}
+	for key, comp := range a.Components {
+		if !proto.Equal(comp, b.Components[key]) {
+			return false
+		}
+	}
+	return true
+}
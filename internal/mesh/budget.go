@@ -7,6 +7,7 @@ import (
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/watch"
 )
 
 // Priority constants for event ordering. Higher value = higher priority.
@@ -46,7 +47,31 @@ func (tb *TokenBucket) Allow(bytes int, priority int) bool {
 
 	tb.mu.Lock()
 	defer tb.mu.Unlock()
+	tb.refillLocked()
 
+	cost := float64(bytes)
+	if cost > tb.tokens {
+		return false
+	}
+	tb.tokens -= cost
+	return true
+}
+
+// Rate returns the bucket's fill rate in bytes per second.
+func (tb *TokenBucket) Rate() float64 {
+	return tb.rate
+}
+
+// Available returns the bytes that can be consumed now.
+func (tb *TokenBucket) Available() float64 {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.refillLocked()
+	return tb.tokens
+}
+
+// refillLocked adds the tokens accrued since the last refill. Must hold mu.
+func (tb *TokenBucket) refillLocked() {
 	now := time.Now()
 	elapsed := now.Sub(tb.lastTime).Seconds()
 	tb.tokens += elapsed * tb.rate
@@ -54,19 +79,12 @@ func (tb *TokenBucket) Allow(bytes int, priority int) bool {
 		tb.tokens = tb.maxTokens
 	}
 	tb.lastTime = now
-
-	cost := float64(bytes)
-	if cost > tb.tokens {
-		return false
-	}
-	tb.tokens -= cost
-	return true
 }
 
 // EventPriority returns the priority of an entity event based on its type
 // and threat component. DELETE events get the highest priority.
 func EventPriority(event *storev1.EntityEvent) int {
-	if event.Type == storev1.EventType_EVENT_TYPE_DELETED {
+	if watch.Removed(event) {
 		return PriorityDelete
 	}
 
@@ -114,22 +132,32 @@ func NewCoalescer() *Coalescer {
 }
 
 // Add queues an event. If the same entityID already exists and the event
-// is not a DELETE, the older event is replaced with the latest.
-// DELETE events are always preserved (never coalesced).
-func (c *Coalescer) Add(event *storev1.EntityEvent) {
+// is not a DELETE, the older event is replaced with the latest, and Add
+// reports true. An event older, by sequence, than the one queued is
+// dropped instead. DELETE events are always preserved (never coalesced),
+// and replace a queued event they follow.
+func (c *Coalescer) Add(event *storev1.EntityEvent) (replaced bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	if event.Type == storev1.EventType_EVENT_TYPE_DELETED {
+	id := event.Entity.Id
+	old, exists := c.events[id]
+	if watch.Removed(event) {
+		if exists && old.Sequence <= event.Sequence {
+			delete(c.events, id)
+			replaced = true
+		}
 		c.deletes = append(c.deletes, event)
-		return
+		return replaced
 	}
 
-	id := event.Entity.Id
-	if _, exists := c.events[id]; !exists {
+	if !exists {
 		c.order = append(c.order, id)
+	} else if old.Sequence > event.Sequence {
+		return true
 	}
 	c.events[id] = event
+	return exists
 }
 
 // Drain returns all queued events sorted by priority (highest first) and clears the queue.
@@ -139,18 +167,20 @@ func (c *Coalescer) Drain() []*storev1.EntityEvent {
 
 	result := make([]*storev1.EntityEvent, 0, len(c.events)+len(c.deletes))
 
-	// Collect non-delete events in insertion order.
+	// Collect non-delete events in insertion order. An ID a delete
+	// replaced and a later event requeued is in order twice.
 	for _, id := range c.order {
 		if ev, ok := c.events[id]; ok {
 			result = append(result, ev)
+			delete(c.events, id)
 		}
 	}
 
 	// Append delete events.
 	result = append(result, c.deletes...)
 
-	// Sort by priority, highest first.
-	sort.Slice(result, func(i, j int) bool {
+	// Sort by priority, highest first, in order within each.
+	sort.SliceStable(result, func(i, j int) bool {
 		return EventPriority(result[i]) > EventPriority(result[j])
 	})
 
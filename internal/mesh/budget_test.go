@@ -256,6 +256,36 @@ func TestCoalescer_DrainSortsByPriority(t *testing.T) {
 	}
 }
 
+func TestCoalescer_DeleteReplacesQueuedUpdate(t *testing.T) {
+	c := NewCoalescer()
+	track := &entityv1.Entity{Id: "track-0", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}
+
+	c.Add(&storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_UPDATED, Entity: track, Sequence: 1})
+	if !c.Add(&storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_DELETED, Entity: track, Sequence: 2}) {
+		t.Fatal("expected the delete to replace the queued update")
+	}
+	c.Add(&storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_CREATED, Entity: track, Sequence: 3})
+
+	events := c.Drain()
+	if len(events) != 2 || events[0].Sequence != 2 || events[1].Sequence != 3 {
+		t.Fatalf("expected the delete then the create, got %v", events)
+	}
+}
+
+func TestCoalescer_KeepsNewerEvent(t *testing.T) {
+	c := NewCoalescer()
+	track := &entityv1.Entity{Id: "track-0", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}
+
+	c.Add(&storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_UPDATED, Entity: track, Sequence: 5})
+	// An older event put back after a failed send does not replace it.
+	if !c.Add(&storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_UPDATED, Entity: track, Sequence: 4}) {
+		t.Fatal("expected the older event coalesced away")
+	}
+	if events := c.Drain(); len(events) != 1 || events[0].Sequence != 5 {
+		t.Fatalf("expected the newer event kept, got %v", events)
+	}
+}
+
 // makeEventWithThreat creates an update event with the given threat level.
 func makeEventWithThreat(level entityv1.ThreatLevel) *storev1.EntityEvent {
 	threatAny, _ := anypb.New(&entityv1.ThreatComponent{Level: level})
@@ -0,0 +1,196 @@
+package mesh
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"os"
+	"sync"
+	"time"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+)
+
+// progress tracks how far through the local store's events one peer has
+// got: the sequence its watch would resume after, were the relay to stop
+// now, with nothing skipped. Events handed on but not yet taken, whether
+// batched, queued in the outbox, or in flight, hold it back. Safe for
+// concurrent use.
+type progress struct {
+	mu      sync.Mutex
+	last    uint64            // sequence of the last event handed on
+	pending map[string]uint64 // by entity ID, the sequence of its latest event not yet taken
+	lost    uint64            // if set, the resume point before events evicted since the last resync
+	evicted int               // events evicted ever, so a resync knows if it covers them all
+}
+
+func newProgress(since uint64) *progress {
+	return &progress{last: since, pending: make(map[string]uint64)}
+}
+
+// handed records event passed on to the peer's batch, outbox, or forward.
+func (p *progress) handed(event *storev1.EntityEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pending[event.Entity.GetId()] = event.Sequence
+	p.last = max(p.last, event.Sequence)
+}
+
+// taken records events the peer has taken, or the relay has chosen not to
+// send it. An event a later one for its entity has replaced is left to
+// that one.
+func (p *progress) taken(events ...*storev1.EntityEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, event := range events {
+		id := event.Entity.GetId()
+		if p.pending[id] == event.Sequence {
+			delete(p.pending, id)
+		}
+	}
+}
+
+// evict records event dropped from a full outbox: it will not be taken,
+// so the checkpoint stays before it until a resync sends the peer a
+// snapshot in its place.
+func (p *progress) evict(event *storev1.EntityEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	id := event.Entity.GetId()
+	if p.pending[id] == event.Sequence {
+		delete(p.pending, id)
+	}
+	if p.lost == 0 || event.Sequence-1 < p.lost {
+		p.lost = event.Sequence - 1
+	}
+	p.evicted++
+}
+
+// evictions returns how many events have been evicted, to pass to resynced
+// once a resync begun now succeeds.
+func (p *progress) evictions() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.evicted
+}
+
+// resynced records the peer sent a snapshot, begun when evictions returned
+// n, which stands in for any events evicted before it.
+func (p *progress) resynced(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.evicted == n {
+		p.lost = 0
+	}
+}
+
+// checkpoint returns the sequence of the last event the peer has taken
+// with every event before it: one before the oldest still pending, or else
+// the last handed on.
+func (p *progress) checkpoint() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	seq := p.last
+	for _, s := range p.pending {
+		seq = min(seq, s-1)
+	}
+	if p.lost != 0 {
+		seq = min(seq, p.lost)
+	}
+	return seq
+}
+
+// loadCheckpoints reads the sequences saved by saveCheckpoints, by peer
+// address. A missing file holds none.
+func loadCheckpoints(path string) (map[string]uint64, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]uint64{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoints: %w", err)
+	}
+	var seqs map[string]uint64
+	if err := json.Unmarshal(b, &seqs); err != nil {
+		return nil, fmt.Errorf("load checkpoints %s: %w", path, err)
+	}
+	if seqs == nil {
+		seqs = map[string]uint64{}
+	}
+	return seqs, nil
+}
+
+// saveCheckpoints writes seqs to path as JSON. The file is written beside
+// the old and renamed over it, so a crash mid-save leaves one or the other
+// intact.
+func saveCheckpoints(path string, seqs map[string]uint64) error {
+	b, err := json.Marshal(seqs)
+	if err != nil {
+		return fmt.Errorf("save checkpoints: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("save checkpoints: %w", err)
+	}
+	return nil
+}
+
+// checkpoints returns each peer's checkpoint, by address, leaving out
+// peers with none.
+func (r *Relay) checkpoints() map[string]uint64 {
+	r.peersMu.Lock()
+	defer r.peersMu.Unlock()
+	seqs := make(map[string]uint64, len(r.peers))
+	for addr, p := range r.peers {
+		if seq := p.checkpoint(r.saved[addr]); seq != 0 {
+			seqs[addr] = seq
+		}
+	}
+	return seqs
+}
+
+// checkpoint returns the sequence p's watch resumes after: its progress,
+// or since if it has not yet run.
+func (p *peer) checkpoint(since uint64) uint64 {
+	if p.progress == nil {
+		return since
+	}
+	return p.progress.checkpoint()
+}
+
+// saveProgress writes each peer's checkpoint to Config.Checkpoint every
+// checkpointInterval, if any has moved on, until ctx is cancelled.
+func (r *Relay) saveProgress(ctx context.Context) {
+	ticker := time.NewTicker(checkpointInterval)
+	defer ticker.Stop()
+	var saved map[string]uint64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		seqs := r.checkpoints()
+		if maps.Equal(seqs, saved) {
+			continue
+		}
+		if err := saveCheckpoints(r.cfg.Checkpoint, seqs); err != nil {
+			slog.Error("mesh-relay checkpoint failed", "error", err)
+			continue
+		}
+		saved = seqs
+	}
+}
+
+// checkpointInterval is how often a relay saves its peers' checkpoints.
+// A relay killed between saves re-sends what its peers took since the
+// last, which they merge as no change.
+const checkpointInterval = time.Second
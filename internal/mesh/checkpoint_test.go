@@ -0,0 +1,172 @@
+package mesh
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+)
+
+func TestProgress_Checkpoint(t *testing.T) {
+	event := func(id string, seq uint64) *storev1.EntityEvent {
+		return &storev1.EntityEvent{Sequence: seq, Entity: &entityv1.Entity{Id: id}}
+	}
+	p := newProgress(10)
+	if got := p.checkpoint(); got != 10 {
+		t.Fatalf("fresh: got %d, want 10", got)
+	}
+
+	a11, b12, a13 := event("a", 11), event("b", 12), event("a", 13)
+	p.handed(a11)
+	p.handed(b12)
+	if got := p.checkpoint(); got != 10 {
+		t.Fatalf("nothing taken: got %d, want 10", got)
+	}
+	// a's later event replaces the one batched, so b holds it back.
+	p.handed(a13)
+	if got := p.checkpoint(); got != 11 {
+		t.Fatalf("a replaced: got %d, want 11", got)
+	}
+	p.taken(a11) // stale: a13 is still pending
+	p.taken(b12)
+	if got := p.checkpoint(); got != 12 {
+		t.Fatalf("b taken: got %d, want 12", got)
+	}
+	p.taken(a13)
+	if got := p.checkpoint(); got != 13 {
+		t.Fatalf("all taken: got %d, want 13", got)
+	}
+
+	// An evicted event holds it back until a resync begun after it.
+	c14, d15 := event("c", 14), event("d", 15)
+	p.handed(c14)
+	p.handed(d15)
+	n := p.evictions()
+	p.evict(c14)
+	p.taken(d15)
+	p.resynced(n)
+	if got := p.checkpoint(); got != 13 {
+		t.Fatalf("resync begun before the eviction: got %d, want 13", got)
+	}
+	p.resynced(p.evictions())
+	if got := p.checkpoint(); got != 15 {
+		t.Fatalf("resynced: got %d, want 15", got)
+	}
+}
+
+func TestLoadCheckpoints(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "relay.json")
+	seqs, err := loadCheckpoints(path)
+	if err != nil || len(seqs) != 0 {
+		t.Fatalf("missing file: got %v, %v; want none", seqs, err)
+	}
+	want := map[string]uint64{"ship-1:50051": 42, "ship-2:50051": 7}
+	if err := saveCheckpoints(path, want); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	seqs, err = loadCheckpoints(path)
+	if err != nil || len(seqs) != 2 || seqs["ship-1:50051"] != 42 || seqs["ship-2:50051"] != 7 {
+		t.Fatalf("got %v, %v; want %v", seqs, err, want)
+	}
+}
+
+// serveStore serves s on a free port until the test ends, and returns its
+// address.
+func serveStore(t *testing.T, s *store.Store) string {
+	t.Helper()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+	go srv.Serve(lis) //nolint:errcheck
+	t.Cleanup(srv.Stop)
+	return lis.Addr().String()
+}
+
+func TestRelay_CheckpointResumesAfterRestart(t *testing.T) {
+	localStore, peerStore := store.New(), store.New()
+	localAddr, peerAddr := serveStore(t, localStore), serveStore(t, peerStore)
+	create := func(id string) {
+		t.Helper()
+		if _, err := localStore.Create(&entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK}); err != nil {
+			t.Fatalf("create %s: %v", id, err)
+		}
+	}
+	waitPeer := func(ctx context.Context, id string) {
+		t.Helper()
+		for ctx.Err() == nil {
+			if _, err := peerStore.Get(id); err == nil {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("%s never reached the peer", id)
+	}
+	path := filepath.Join(t.TempDir(), "relay.json")
+	run := func(ctx context.Context) (*Relay, chan error) {
+		relay := New(Config{LocalAddr: localAddr, Peers: []string{peerAddr}, Checkpoint: path})
+		done := make(chan error, 1)
+		go func() { done <- relay.Run(ctx) }()
+		for ctx.Err() == nil && localStore.WatcherCount() == 0 {
+			time.Sleep(20 * time.Millisecond)
+		}
+		return relay, done
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	first, stop := context.WithCancel(ctx)
+	_, done := run(first)
+	create("a")
+	waitPeer(ctx, "a")
+	stop()
+	if err := <-done; err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if seqs, err := loadCheckpoints(path); err != nil || seqs[peerAddr] == 0 {
+		t.Fatalf("expected a checkpoint for the peer, got %v, %v", seqs, err)
+	}
+
+	// Written while the relay is down, so only a resumed watch carries it;
+	// a is not sent again.
+	create("b")
+	relay, _ := run(ctx)
+	waitPeer(ctx, "b")
+	if got := relay.GetStats().Forwarded; got != 1 {
+		t.Fatalf("expected 1 forwarded after the restart, got %d", got)
+	}
+}
+
+func TestRelay_CheckpointResyncsStaleStore(t *testing.T) {
+	localStore, peerStore := store.New(), store.New()
+	localAddr, peerAddr := serveStore(t, localStore), serveStore(t, peerStore)
+	if _, err := localStore.Create(&entityv1.Entity{Id: "old", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// The checkpoint is from a local store since restarted, whose
+	// sequences it cannot resume from, so the peer is resynced instead.
+	path := filepath.Join(t.TempDir(), "relay.json")
+	if err := saveCheckpoints(path, map[string]uint64{peerAddr: 1}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	go New(Config{LocalAddr: localAddr, Peers: []string{peerAddr}, Checkpoint: path}).Run(ctx) //nolint:errcheck
+	for ctx.Err() == nil {
+		if _, err := peerStore.Get("old"); err == nil {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("the peer was never resynced")
+}
@@ -0,0 +1,194 @@
+package mesh
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Discoverer finds the peer stores a relay should replicate with, so
+// nodes can join the mesh without each being given the others' addresses.
+type Discoverer interface {
+	// Discover returns the addresses of the stores it finds now. An error
+	// leaves the relay's peers as they are.
+	Discover(ctx context.Context) ([]string, error)
+}
+
+// ParseDiscovery parses a discovery spec: dns:<SRV name>, mdns:<service>
+// (such as _lattice._tcp), or file:<path>.
+func ParseDiscovery(spec string) (Discoverer, error) {
+	kind, arg, ok := strings.Cut(spec, ":")
+	if !ok || arg == "" {
+		return nil, fmt.Errorf("discovery %q: want dns:<name>, mdns:<service>, or file:<path>", spec)
+	}
+	switch kind {
+	case "dns":
+		return DNSSRV(arg), nil
+	case "mdns":
+		return MDNS(arg), nil
+	case "file":
+		return PeersFile(arg), nil
+	}
+	return nil, fmt.Errorf("discovery %q: unknown kind %q (want dns, mdns, or file)", spec, kind)
+}
+
+// DNSSRV discovers the stores named by the SRV records of name, such as
+// _lattice._tcp.mesh.example.org or a Kubernetes headless service's
+// _grpc._tcp.entity-store.lattice.svc.cluster.local.
+func DNSSRV(name string) Discoverer {
+	return dnsSRV{name: name, resolver: net.DefaultResolver}
+}
+
+type dnsSRV struct {
+	name     string
+	resolver *net.Resolver
+}
+
+func (d dnsSRV) Discover(ctx context.Context) ([]string, error) {
+	_, srvs, err := d.resolver.LookupSRV(ctx, "", "", d.name)
+	if err != nil {
+		return nil, fmt.Errorf("look up %s: %w", d.name, err)
+	}
+	addrs := make([]string, 0, len(srvs))
+	for _, srv := range srvs {
+		addrs = append(addrs, net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))))
+	}
+	return addrs, nil
+}
+
+// PeersFile discovers the stores listed in a file, one address per line;
+// blank lines and those starting with # are skipped. The file is read on
+// every pass, so edits take effect without a restart.
+func PeersFile(path string) Discoverer {
+	return peersFile(path)
+}
+
+type peersFile string
+
+func (f peersFile) Discover(context.Context) ([]string, error) {
+	file, err := os.Open(string(f))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var addrs []string
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(line); err != nil {
+			return nil, fmt.Errorf("%s: %w", string(f), err)
+		}
+		addrs = append(addrs, line)
+	}
+	return addrs, sc.Err()
+}
+
+// discover keeps the relay's peers in line with cfg.Discovery until ctx is
+// cancelled, every cfg.DiscoveryInterval: stores it finds are added, and
+// those it added and no longer finds are removed. Peers configured or
+// added through AddPeer are left alone, as is the local store if found.
+func (r *Relay) discover(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.DiscoveryInterval)
+	defer ticker.Stop()
+	found := make(map[string]bool) // peers discovery added
+	for {
+		if err := r.rediscover(ctx, found); err != nil && ctx.Err() == nil {
+			slog.Warn("mesh-relay discovery failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) rediscover(ctx context.Context, found map[string]bool) error {
+	addrs, err := r.cfg.Discovery.Discover(ctx)
+	if err != nil {
+		return err
+	}
+	self, err := localIPs()
+	if err != nil {
+		return err
+	}
+	var want []string
+	for _, addr := range addrs {
+		if !slices.Contains(want, addr) && !r.isSelf(ctx, addr, self) {
+			want = append(want, addr)
+		}
+	}
+
+	for _, addr := range want {
+		if found[addr] {
+			continue
+		}
+		err := r.AddPeer(addr, r.cfg.InitialSync)
+		switch {
+		case errors.Is(err, ErrPeerExists):
+		case err != nil:
+			slog.Warn("mesh-relay discovered peer not added", "peer", addr, "error", err)
+		default:
+			found[addr] = true
+		}
+	}
+	for addr := range found {
+		if slices.Contains(want, addr) {
+			continue
+		}
+		delete(found, addr)
+		if err := r.RemovePeer(addr); err != nil && !errors.Is(err, ErrNoPeer) {
+			return err
+		}
+	}
+	return nil
+}
+
+// isSelf reports whether addr is the local store's: on its port, at an
+// address of this host.
+func (r *Relay) isSelf(ctx context.Context, addr string, self []net.IP) bool {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	_, localPort, err := net.SplitHostPort(r.cfg.LocalAddr)
+	if err != nil || port != localPort {
+		return false
+	}
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return false
+	}
+	for _, ip := range ips {
+		if ip.IsLoopback() || slices.ContainsFunc(self, ip.Equal) {
+			return true
+		}
+	}
+	return false
+}
+
+// localIPs returns the addresses of this host's interfaces.
+func localIPs() ([]net.IP, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return nil, fmt.Errorf("list interface addresses: %w", err)
+	}
+	var ips []net.IP
+	for _, a := range addrs {
+		if n, ok := a.(*net.IPNet); ok {
+			ips = append(ips, n.IP)
+		}
+	}
+	return ips, nil
+}
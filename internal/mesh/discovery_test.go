@@ -0,0 +1,193 @@
+package mesh
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestParseDiscovery(t *testing.T) {
+	for spec, want := range map[string]Discoverer{
+		"dns:_lattice._tcp.example.org": DNSSRV("_lattice._tcp.example.org"),
+		"mdns:_lattice._tcp":            MDNS("_lattice._tcp"),
+		"file:/etc/lattice/peers":       PeersFile("/etc/lattice/peers"),
+	} {
+		got, err := ParseDiscovery(spec)
+		if err != nil || got != want {
+			t.Errorf("%s: got %v, %v; want %v", spec, got, err, want)
+		}
+	}
+	for _, spec := range []string{"", "dns:", "consul:lattice", "/etc/lattice/peers"} {
+		if _, err := ParseDiscovery(spec); err == nil {
+			t.Errorf("%q: expected error", spec)
+		}
+	}
+}
+
+func TestPeersFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "peers")
+	os.WriteFile(path, []byte("# edge nodes\nnode-a:50051\n\n  node-b:50051  \n"), 0o644) //nolint:errcheck
+	got, err := PeersFile(path).Discover(context.Background())
+	if err != nil || !slices.Equal(got, []string{"node-a:50051", "node-b:50051"}) {
+		t.Fatalf("got %v, %v", got, err)
+	}
+
+	os.WriteFile(path, []byte("node-a\n"), 0o644) //nolint:errcheck
+	if _, err := PeersFile(path).Discover(context.Background()); err == nil {
+		t.Fatal("expected error for an address without a port")
+	}
+	if _, err := PeersFile(filepath.Join(t.TempDir(), "missing")).Discover(context.Background()); err == nil {
+		t.Fatal("expected error for a missing file")
+	}
+}
+
+// serveUDP answers each packet sent to a loopback UDP port with respond's
+// reply, if any, until the test ends, and returns the port's address.
+func serveUDP(t *testing.T, respond func([]byte) ([]byte, bool)) string {
+	t.Helper()
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 9000)
+		for {
+			n, from, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if resp, ok := respond(buf[:n]); ok {
+				conn.WriteToUDP(resp, from) //nolint:errcheck
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestDNSSRV(t *testing.T) {
+	addr := serveUDP(t, func(query []byte) ([]byte, bool) {
+		var q dnsmessage.Message
+		if q.Unpack(query) != nil || len(q.Questions) == 0 {
+			return nil, false
+		}
+		resp := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: q.ID, Response: true, Authoritative: true},
+			Questions: q.Questions[:1],
+		}
+		if name := q.Questions[0].Name; q.Questions[0].Type == dnsmessage.TypeSRV {
+			for i, target := range []string{"node-a.mesh.test.", "node-b.mesh.test."} {
+				resp.Answers = append(resp.Answers, dnsmessage.Resource{
+					Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.SRVResource{Target: dnsmessage.MustNewName(target), Port: uint16(50051 + i)},
+				})
+			}
+		}
+		packet, err := resp.Pack()
+		return packet, err == nil
+	})
+
+	d := dnsSRV{name: "_lattice._tcp.mesh.test", resolver: &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "udp", addr)
+		},
+	}}
+	got, err := d.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"node-a.mesh.test:50051", "node-b.mesh.test:50052"}) {
+		t.Fatalf("got %v", got)
+	}
+}
+
+func TestMDNS_FindsAdvertisedStore(t *testing.T) {
+	a, err := newAdvertiser("_lattice._tcp", "node-a", "edge-1.example.org", 50051, []net.IP{net.IPv4(192, 0, 2, 7), net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("newAdvertiser: %v", err)
+	}
+	other, _ := newAdvertiser("_other._tcp", "node-b", "edge-2", 50051, []net.IP{net.IPv4(192, 0, 2, 8)})
+	group := serveUDP(t, func(query []byte) ([]byte, bool) { return a.answer(query, true) })
+
+	got, err := mdns{service: "_lattice._tcp", group: group, wait: 200 * time.Millisecond}.Discover(context.Background())
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	if !slices.Equal(got, []string{"192.0.2.7:50051"}) {
+		t.Fatalf("got %v", got)
+	}
+	if _, ok := other.answer(mustQuery(t, "_lattice._tcp.local."), true); ok {
+		t.Fatal("expected no answer for another service")
+	}
+}
+
+func mustQuery(t *testing.T, name string) []byte {
+	t.Helper()
+	q := dnsmessage.Message{Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName(name), Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}}}
+	packet, err := q.Pack()
+	if err != nil {
+		t.Fatalf("pack: %v", err)
+	}
+	return packet
+}
+
+func TestRelay_DiscoversPeers(t *testing.T) {
+	localAddr, localCleanup := startTestServer(t)
+	defer localCleanup()
+	peerA, cleanupA := startTestServer(t)
+	defer cleanupA()
+	peerB, cleanupB := startTestServer(t)
+	defer cleanupB()
+
+	path := filepath.Join(t.TempDir(), "peers")
+	write := func(addrs ...string) {
+		t.Helper()
+		var b []byte
+		for _, a := range addrs {
+			b = append(b, a+"\n"...)
+		}
+		if err := os.WriteFile(path, b, 0o644); err != nil {
+			t.Fatalf("write peers: %v", err)
+		}
+	}
+	write(peerA, localAddr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	relay := New(Config{LocalAddr: localAddr, Discovery: PeersFile(path), DiscoveryInterval: 50 * time.Millisecond})
+	go relay.Run(ctx) //nolint:errcheck
+
+	waitPeers := func(want ...string) {
+		t.Helper()
+		slices.Sort(want)
+		var got []string
+		for ctx.Err() == nil {
+			got = got[:0]
+			for _, p := range relay.ListPeers() {
+				got = append(got, p.Addr)
+			}
+			if slices.Equal(got, want) {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("peers: got %v, want %v", got, want)
+	}
+	// The local store is left out.
+	waitPeers(peerA)
+	write(peerB)
+	waitPeers(peerB)
+
+	// A broken file leaves the peers as they are.
+	write("node-c")
+	time.Sleep(200 * time.Millisecond)
+	waitPeers(peerB)
+}
@@ -0,0 +1,244 @@
+package mesh
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// HealthComponent is the component key of a node's MeshHealthComponent.
+const HealthComponent = "mesh_health"
+
+// defaultHeartbeatMisses is how many heartbeats in a row a peer misses
+// before it counts as partitioned when Config.HeartbeatMisses is 0.
+const defaultHeartbeatMisses = 3
+
+// HealthID is the ID of the SYSTEM entity holding the mesh health of the
+// node called nodeID.
+func HealthID(nodeID string) string {
+	if nodeID == "" {
+		return "mesh-health"
+	}
+	return "mesh-health/" + nodeID
+}
+
+// heartbeat pings each peer every cfg.Heartbeat until ctx is cancelled,
+// and counts one that misses HeartbeatMisses in a row as partitioned until
+// it answers again. It writes the node's view of the mesh to the local
+// store after the first round and whenever it changes, not on every
+// round, so the entity's events mark partitions and heals.
+//
+// Each round also measures how far each peer's wall clock is from the
+// local store's, from the wall time each sends with its answer, and
+// reports a reachable peer further off than MaxSkew as skewed.
+func (r *Relay) heartbeat(ctx context.Context, local storev1.EntityStoreServiceClient) {
+	limit := r.cfg.HeartbeatMisses
+	if limit <= 0 {
+		limit = defaultHeartbeatMisses
+	}
+	ticker := time.NewTicker(r.cfg.Heartbeat)
+	defer ticker.Stop()
+	misses := make(map[string]int)
+	var last *entityv1.MeshHealthComponent
+	for {
+		peers := r.peerClients()
+		home, homeOK := r.ping(ctx, map[string]storev1.EntityStoreServiceClient{"": local})[""].offset()
+		r.mu.Lock()
+		for addr, p := range r.ping(ctx, peers) {
+			if p.missed {
+				misses[addr]++
+			} else {
+				misses[addr] = 0
+			}
+			if offset, ok := p.offset(); ok && homeOK {
+				r.offsets[addr] = offset - home
+			}
+		}
+		// Drop peers removed since, which RemovePeer may have missed.
+		maps.DeleteFunc(r.offsets, func(addr string, _ time.Duration) bool { return peers[addr] == nil })
+		r.mu.Unlock()
+		maps.DeleteFunc(misses, func(addr string, _ int) bool { return peers[addr] == nil })
+		if ctx.Err() != nil {
+			return
+		}
+
+		view := &entityv1.MeshHealthComponent{NodeId: r.cfg.NodeID}
+		for _, addr := range slices.Sorted(maps.Keys(misses)) {
+			if misses[addr] >= limit {
+				view.Partitioned = append(view.Partitioned, addr)
+			} else {
+				view.Reachable = append(view.Reachable, addr)
+			}
+		}
+		view.Degraded = len(view.Partitioned) > 0
+		view.Skewed = r.skewedPeers(view.Reachable)
+		if last == nil || !slices.Equal(view.Partitioned, last.Partitioned) || !slices.Equal(view.Reachable, last.Reachable) ||
+			!slices.Equal(view.Skewed, last.Skewed) {
+			view.Since = timestamppb.Now()
+			if err := r.writeHealth(ctx, local, view); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				slog.Warn("mesh-relay health not written", "error", err)
+			} else {
+				if view.Degraded {
+					slog.Warn("mesh-relay degraded", "partitioned", view.Partitioned)
+				} else if last != nil && last.Degraded {
+					slog.Info("mesh-relay healed", "peers", view.Reachable)
+				}
+				r.logSkew(view.Skewed, last)
+				partitionedPeers.Set(float64(len(view.Partitioned)))
+				skewedPeers.Set(float64(len(view.Skewed)))
+				last = view
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pong is a peer's answer to a heartbeat.
+type pong struct {
+	missed bool
+	// sent and received are when the heartbeat went out and its answer
+	// came back; wall is the peer's wall time in the answer, zero if it
+	// sent none.
+	sent, received, wall time.Time
+}
+
+// offset returns how far the peer's wall clock is ahead of the relay's,
+// taking the peer to have read it halfway through the round trip, and
+// whether the answer carried a wall time to measure.
+func (p pong) offset() (time.Duration, bool) {
+	if p.missed || p.wall.IsZero() {
+		return 0, false
+	}
+	mid := p.sent.Add(p.received.Sub(p.sent) / 2)
+	return p.wall.Sub(mid), true
+}
+
+// ping sends each peer a heartbeat, all at once, and reports those that
+// did not answer within the interval. Any answer counts, errors included:
+// the peer is there.
+func (r *Relay) ping(ctx context.Context, peers map[string]storev1.EntityStoreServiceClient) map[string]pong {
+	var (
+		mu    sync.Mutex
+		wg    sync.WaitGroup
+		pongs = make(map[string]pong, len(peers))
+	)
+	for addr, peer := range peers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, r.cfg.Heartbeat)
+			defer cancel()
+			var header metadata.MD
+			p := pong{sent: time.Now()}
+			_, err := peer.GetEntity(ctx, &storev1.GetEntityRequest{Id: HealthID(r.cfg.NodeID)}, grpc.Header(&header))
+			p.received = time.Now()
+			code := status.Code(err)
+			p.missed = code == codes.Unavailable || code == codes.DeadlineExceeded
+			if v := header.Get(server.WallHeader); len(v) > 0 {
+				if ns, err := strconv.ParseInt(v[0], 10, 64); err == nil {
+					p.wall = time.Unix(0, ns)
+				}
+			}
+			mu.Lock()
+			pongs[addr] = p
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return pongs
+}
+
+// skewed reports whether a peer whose clock is offset from the local
+// store's by d is beyond MaxSkew.
+func (r *Relay) skewed(d time.Duration) bool {
+	return r.cfg.MaxSkew > 0 && (d > r.cfg.MaxSkew || d < -r.cfg.MaxSkew)
+}
+
+// skewedPeers returns those of addrs, in order, whose last measured clock
+// offset is beyond MaxSkew, and sets each one's offset gauge.
+func (r *Relay) skewedPeers(addrs []string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var out []string
+	for _, addr := range addrs {
+		offset, ok := r.offsets[addr]
+		if !ok {
+			continue
+		}
+		clockOffset.Set(offset.Seconds(), addr)
+		if r.skewed(offset) {
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
+// logSkew warns of each peer in skewed that was not in the last view, and
+// notes each that was and has recovered.
+func (r *Relay) logSkew(skewed []string, last *entityv1.MeshHealthComponent) {
+	var before []string
+	if last != nil {
+		before = last.Skewed
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, addr := range skewed {
+		if !slices.Contains(before, addr) {
+			slog.Warn("mesh-relay peer clock skewed", "peer", addr, "offset", r.offsets[addr], "max", r.cfg.MaxSkew)
+		}
+	}
+	for _, addr := range before {
+		if !slices.Contains(skewed, addr) {
+			slog.Info("mesh-relay peer clock back in step", "peer", addr)
+		}
+	}
+}
+
+// writeHealth writes view to the local store as the node's mesh health.
+// Like any entity it replicates, so an operator on any node can see each
+// node's view that reaches it.
+func (r *Relay) writeHealth(ctx context.Context, local storev1.EntityStoreServiceClient, view *entityv1.MeshHealthComponent) error {
+	comp, err := anypb.New(view)
+	if err != nil {
+		return err
+	}
+	e := &entityv1.Entity{
+		Id:         HealthID(r.cfg.NodeID),
+		Type:       entityv1.EntityType_ENTITY_TYPE_SYSTEM,
+		Components: map[string]*anypb.Any{HealthComponent: comp},
+	}
+	existing, err := local.GetEntity(ctx, &storev1.GetEntityRequest{Id: e.Id})
+	if status.Code(err) == codes.NotFound {
+		_, err = local.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: e})
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	// Carry the stored HLC so the store's merge accepts the new view.
+	e.HlcPhysical, e.HlcLogical, e.HlcNode = existing.HlcPhysical, existing.HlcLogical, existing.HlcNode
+	_, err = local.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: e})
+	return err
+}
@@ -0,0 +1,125 @@
+package mesh
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+)
+
+func TestRelay_HeartbeatMarksPartition(t *testing.T) {
+	localStore := store.New()
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(localStore))
+	go srv.Serve(lis) //nolint:errcheck
+	defer srv.Stop()
+	peerAddr, stopPeer := startTestServer(t)
+	defer stopPeer()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	relay := New(Config{
+		LocalAddr: lis.Addr().String(), Peers: []string{peerAddr}, NodeID: "node-a",
+		Heartbeat: 50 * time.Millisecond, HeartbeatMisses: 2,
+	})
+	go relay.Run(ctx) //nolint:errcheck
+
+	waitHealth := func(degraded bool, partitioned []string) *entityv1.MeshHealthComponent {
+		t.Helper()
+		for ctx.Err() == nil {
+			if e, err := localStore.Get(HealthID("node-a")); err == nil {
+				view := &entityv1.MeshHealthComponent{}
+				if e.Components[HealthComponent].UnmarshalTo(view) == nil && view.Degraded == degraded && slices.Equal(view.Partitioned, partitioned) {
+					if e.Type != entityv1.EntityType_ENTITY_TYPE_SYSTEM {
+						t.Fatalf("expected a SYSTEM entity, got %v", e.Type)
+					}
+					return view
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("mesh health never showed degraded=%v partitioned=%v", degraded, partitioned)
+		return nil
+	}
+
+	view := waitHealth(false, nil)
+	if !slices.Equal(view.Reachable, []string{peerAddr}) || view.NodeId != "node-a" {
+		t.Fatalf("expected node-a reaching %s, got %v", peerAddr, view)
+	}
+
+	stopPeer()
+	view = waitHealth(true, []string{peerAddr})
+	if len(view.Reachable) != 0 || view.Since == nil {
+		t.Fatalf("expected no peer reachable since the partition, got %v", view)
+	}
+}
+
+func TestRelay_HeartbeatReportsSkew(t *testing.T) {
+	serve := func(s *store.Store) string {
+		t.Helper()
+		lis, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		api := server.New(s)
+		srv := grpc.NewServer(grpc.UnaryInterceptor(api.UnaryInterceptor()), grpc.StreamInterceptor(api.StreamInterceptor()))
+		storev1.RegisterEntityStoreServiceServer(srv, api)
+		go srv.Serve(lis) //nolint:errcheck
+		t.Cleanup(srv.Stop)
+		return lis.Addr().String()
+	}
+	localStore := store.New()
+	localAddr := serve(localStore)
+	inStep := serve(store.New())
+	fast := hlc.NewClock("node-c")
+	fast.SetOffset(time.Hour)
+	skewedAddr := serve(store.New(store.WithClock(fast)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	relay := New(Config{
+		LocalAddr: localAddr, Peers: []string{inStep, skewedAddr}, NodeID: "node-a",
+		Heartbeat: 50 * time.Millisecond, MaxSkew: time.Minute,
+	})
+	go relay.Run(ctx) //nolint:errcheck
+
+	for {
+		if e, err := localStore.Get(HealthID("node-a")); err == nil {
+			view := &entityv1.MeshHealthComponent{}
+			if e.Components[HealthComponent].UnmarshalTo(view) == nil && len(view.Skewed) > 0 {
+				if !slices.Equal(view.Skewed, []string{skewedAddr}) {
+					t.Fatalf("expected only %s skewed, got %v", skewedAddr, view.Skewed)
+				}
+				break
+			}
+		}
+		if ctx.Err() != nil {
+			t.Fatal("mesh health never showed a skewed peer")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	for _, p := range relay.ListPeers() {
+		if !p.Measured {
+			t.Fatalf("expected %s's clock measured", p.Addr)
+		}
+		if want := p.Addr == skewedAddr; p.ClockSkewed != want {
+			t.Fatalf("%s: expected skewed=%v, got offset %v", p.Addr, want, p.ClockOffset)
+		}
+		if p.Addr == skewedAddr && (p.ClockOffset < 59*time.Minute || p.ClockOffset > 61*time.Minute) {
+			t.Fatalf("expected %s about an hour ahead, got %v", p.Addr, p.ClockOffset)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package mesh
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Impairment emulates a degraded link to one peer, for tests and demos of
+// disconnected, intermittent, and low-bandwidth (DIL) networks between
+// the all-or-nothing of a partition and a healthy LAN. It acts on the
+// relay's own connection to the peer, so only the relay sees it: the
+// peer's other clients, and its relay's link back, are unaffected.
+type Impairment struct {
+	Latency      time.Duration // added to every write to the peer
+	Jitter       time.Duration // up to this much more, at random, per write
+	Loss         float64       // chance, 0 to 1, that a call or stream is lost and fails UNAVAILABLE
+	BandwidthBPS float64       // bytes per second the link carries; 0 = unlimited
+}
+
+// ParseImpairments parses per-peer impairments:
+// "addr=key:value,...;...", keys latency, jitter, loss, and bandwidth,
+// such as "ship-1:50051=latency:600ms,jitter:200ms,loss:0.05,bandwidth:2400".
+func ParseImpairments(s string) (map[string]Impairment, error) {
+	imps := make(map[string]Impairment)
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		addr, spec, ok := strings.Cut(part, "=")
+		if !ok || spec == "" {
+			return nil, fmt.Errorf("impairment %q: want addr=key:value,...", part)
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("impairment %q: %w", part, err)
+		}
+		var imp Impairment
+		for _, field := range strings.Split(spec, ",") {
+			key, value, ok := strings.Cut(strings.TrimSpace(field), ":")
+			if !ok {
+				return nil, fmt.Errorf("impairment %q: field %q: want key:value", part, field)
+			}
+			var err error
+			switch key {
+			case "latency", "jitter":
+				var d time.Duration
+				if d, err = time.ParseDuration(value); err != nil || d < 0 {
+					return nil, fmt.Errorf("impairment %q: %s must be a non-negative duration", part, key)
+				}
+				if key == "latency" {
+					imp.Latency = d
+				} else {
+					imp.Jitter = d
+				}
+			case "loss":
+				if imp.Loss, err = strconv.ParseFloat(value, 64); err != nil || imp.Loss < 0 || imp.Loss > 1 {
+					return nil, fmt.Errorf("impairment %q: loss must be a probability from 0 to 1", part)
+				}
+			case "bandwidth":
+				if imp.BandwidthBPS, err = strconv.ParseFloat(value, 64); err != nil || imp.BandwidthBPS < 0 {
+					return nil, fmt.Errorf("impairment %q: bandwidth must be a non-negative number of bytes per second", part)
+				}
+			default:
+				return nil, fmt.Errorf("impairment %q: unknown key %q (want latency, jitter, loss, or bandwidth)", part, key)
+			}
+		}
+		if _, dup := imps[addr]; dup {
+			return nil, fmt.Errorf("impairment %q: peer %s given twice", part, addr)
+		}
+		imps[addr] = imp
+	}
+	return imps, nil
+}
+
+// dialOptions returns the dial options that impair a connection: a dialer
+// whose connections delay and pace their writes, and interceptors that
+// lose calls and streams.
+func (imp Impairment) dialOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+		conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return nil, err
+		}
+		return &impairedConn{Conn: conn, imp: imp}, nil
+	})}
+	if imp.Loss > 0 {
+		opts = append(opts,
+			grpc.WithChainUnaryInterceptor(func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				if imp.lost() {
+					return status.Error(codes.Unavailable, "emulated loss")
+				}
+				return invoker(ctx, method, req, reply, cc, opts...)
+			}),
+			grpc.WithChainStreamInterceptor(func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				if imp.lost() {
+					return nil, status.Error(codes.Unavailable, "emulated loss")
+				}
+				return streamer(ctx, desc, cc, method, opts...)
+			}))
+	}
+	return opts
+}
+
+func (imp Impairment) lost() bool {
+	return imp.Loss > 0 && rand.Float64() < imp.Loss
+}
+
+// impairedConn delays each write by the impairment's latency and jitter,
+// and paces writes to its bandwidth, one at a time, as a narrow link
+// queues what it is sent.
+type impairedConn struct {
+	net.Conn
+	imp Impairment
+
+	mu   sync.Mutex
+	free time.Time // when the link has sent what it was given
+}
+
+func (c *impairedConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	d := c.imp.Latency
+	if c.imp.Jitter > 0 {
+		d += rand.N(c.imp.Jitter)
+	}
+	if c.imp.BandwidthBPS > 0 {
+		now := time.Now()
+		if c.free.Before(now) {
+			c.free = now
+		}
+		c.free = c.free.Add(time.Duration(float64(len(b)) / c.imp.BandwidthBPS * float64(time.Second)))
+		d += time.Until(c.free)
+	}
+	if d > 0 {
+		time.Sleep(d)
+	}
+	return c.Conn.Write(b)
+}
@@ -0,0 +1,74 @@
+package mesh
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseImpairments(t *testing.T) {
+	imps, err := ParseImpairments("ship-1:50051=latency:600ms,jitter:200ms,loss:0.05,bandwidth:2400; node-b:50051=loss:1")
+	if err != nil {
+		t.Fatalf("ParseImpairments: %v", err)
+	}
+	want := Impairment{Latency: 600 * time.Millisecond, Jitter: 200 * time.Millisecond, Loss: 0.05, BandwidthBPS: 2400}
+	if imps["ship-1:50051"] != want {
+		t.Fatalf("expected %+v, got %+v", want, imps["ship-1:50051"])
+	}
+	if imps["node-b:50051"] != (Impairment{Loss: 1}) {
+		t.Fatalf("expected total loss to node-b, got %+v", imps["node-b:50051"])
+	}
+
+	for _, bad := range []string{
+		"ship-1:50051",
+		"ship-1=latency:1s",
+		"ship-1:50051=latency",
+		"ship-1:50051=latency:-1s",
+		"ship-1:50051=loss:1.5",
+		"ship-1:50051=bandwidth:fast",
+		"ship-1:50051=drop:1",
+		"ship-1:50051=loss:0.1;ship-1:50051=loss:0.2",
+	} {
+		if _, err := ParseImpairments(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestImpairment_DelaysAndLoses(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	call := func(imp Impairment) (time.Duration, error) {
+		conn, err := client.Dial(addr, client.WithoutRetries(), client.WithDialOptions(imp.dialOptions()...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		start := time.Now()
+		_, err = storev1.NewEntityStoreServiceClient(conn).ListEntities(ctx, &storev1.ListEntitiesRequest{})
+		return time.Since(start), err
+	}
+
+	if d, err := call(Impairment{Latency: 100 * time.Millisecond}); err != nil || d < 100*time.Millisecond {
+		t.Fatalf("expected a call delayed by the latency, got %v, %v", d, err)
+	}
+	// The HTTP/2 preface and settings alone take a 1000-byte-per-second
+	// link most of a tenth of a second.
+	if d, err := call(Impairment{BandwidthBPS: 1000}); err != nil || d < 50*time.Millisecond {
+		t.Fatalf("expected a call slowed by the bandwidth, got %v, %v", d, err)
+	}
+	if _, err := call(Impairment{Loss: 1}); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected a lost call to fail UNAVAILABLE, got %v", err)
+	}
+	if _, err := call(Impairment{}); err != nil {
+		t.Fatalf("expected an unimpaired call to succeed: %v", err)
+	}
+}
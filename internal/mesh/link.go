@@ -0,0 +1,94 @@
+package mesh
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Link describes the path to one peer, for meshes whose links differ, say
+// a LAN to the next rack and satcom to a ship: its own bandwidth budget,
+// so a slow peer neither throttles a fast one nor is flooded by the budget
+// a fast one needs, and how its latency class batches and times out sends.
+type Link struct {
+	Class         string        // lan, wan, or satcom
+	BandwidthBPS  float64       // bytes per second budget; 0 = unlimited
+	BurstBytes    float64       // burst capacity; 0 = use BandwidthBPS as burst
+	FlushInterval time.Duration // as Config.FlushInterval, for this peer alone
+	Timeout       time.Duration // deadline of each call to the peer; 0 = client.DefaultTimeout
+}
+
+// linkClasses are the defaults for each latency class. A LAN sends each
+// event as it comes; slower classes batch more, to spend their bandwidth on
+// each entity's latest state, and wait longer before calling a peer
+// unreachable.
+var linkClasses = map[string]Link{
+	"lan":    {Class: "lan"},
+	"wan":    {Class: "wan", FlushInterval: 250 * time.Millisecond},
+	"satcom": {Class: "satcom", BandwidthBPS: 4000, FlushInterval: 2 * time.Second, Timeout: 2 * time.Minute},
+}
+
+// ParseLinks parses per-peer links:
+// "addr=class[:bandwidth_bps[:burst_bytes]];...", such as
+// "node-b:50051=lan;ship-1:50051=satcom:2400". Bandwidth and burst
+// override the class's.
+func ParseLinks(s string) (map[string]Link, error) {
+	links := make(map[string]Link)
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		addr, spec, ok := strings.Cut(part, "=")
+		if !ok {
+			return nil, fmt.Errorf("link %q: want addr=class[:bandwidth_bps[:burst_bytes]]", part)
+		}
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return nil, fmt.Errorf("link %q: %w", part, err)
+		}
+		f := strings.Split(spec, ":")
+		if len(f) > 3 {
+			return nil, fmt.Errorf("link %q: want addr=class[:bandwidth_bps[:burst_bytes]]", part)
+		}
+		link, ok := linkClasses[f[0]]
+		if !ok {
+			return nil, fmt.Errorf("link %q: unknown class %q (want lan, wan, or satcom)", part, f[0])
+		}
+		var err error
+		if len(f) > 1 {
+			if link.BandwidthBPS, err = strconv.ParseFloat(f[1], 64); err != nil || link.BandwidthBPS < 0 {
+				return nil, fmt.Errorf("link %q: bandwidth must be a non-negative number of bytes per second", part)
+			}
+		}
+		if len(f) > 2 {
+			if link.BurstBytes, err = strconv.ParseFloat(f[2], 64); err != nil || link.BurstBytes < 0 {
+				return nil, fmt.Errorf("link %q: burst must be a non-negative number of bytes", part)
+			}
+		}
+		if _, dup := links[addr]; dup {
+			return nil, fmt.Errorf("link %q: peer %s given twice", part, addr)
+		}
+		links[addr] = link
+	}
+	return links, nil
+}
+
+// bucket returns the token bucket for a link's budget, or nil if it has
+// none.
+func (l Link) bucket() *TokenBucket {
+	return newBucket(l.BandwidthBPS, l.BurstBytes)
+}
+
+// newBucket returns a token bucket filling at bps, nil if bps is not
+// positive; burst 0 is bps.
+func newBucket(bps, burst float64) *TokenBucket {
+	if bps <= 0 {
+		return nil
+	}
+	if burst == 0 {
+		burst = bps
+	}
+	return NewTokenBucket(bps, burst)
+}
@@ -0,0 +1,40 @@
+package mesh
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseLinks(t *testing.T) {
+	links, err := ParseLinks("node-b:50051=lan; ship-1:50051=satcom:2400 ;relay.example:50051=wan:0:8000")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	want := map[string]Link{
+		"node-b:50051":        {Class: "lan"},
+		"ship-1:50051":        {Class: "satcom", BandwidthBPS: 2400, FlushInterval: 2 * time.Second, Timeout: 2 * time.Minute},
+		"relay.example:50051": {Class: "wan", BurstBytes: 8000, FlushInterval: 250 * time.Millisecond},
+	}
+	if len(links) != len(want) {
+		t.Fatalf("got %d links, want %d: %+v", len(links), len(want), links)
+	}
+	for addr, w := range want {
+		if links[addr] != w {
+			t.Errorf("%s: got %+v, want %+v", addr, links[addr], w)
+		}
+	}
+
+	for _, bad := range []string{
+		"node-b:50051",
+		"node-b=lan",
+		"node-b:50051=dialup",
+		"node-b:50051=satcom:fast",
+		"node-b:50051=satcom:-1",
+		"node-b:50051=wan:1:2:3",
+		"node-b:50051=lan;node-b:50051=wan",
+	} {
+		if _, err := ParseLinks(bad); err == nil {
+			t.Errorf("ParseLinks(%q): expected an error", bad)
+		}
+	}
+}
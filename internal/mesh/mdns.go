@@ -0,0 +1,251 @@
+package mesh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// mdnsGroup is the IPv4 multicast address mDNS queries go to (RFC 6762).
+const mdnsGroup = "224.0.0.251:5353"
+
+// mdnsWait is how long an mDNS query collects answers.
+const mdnsWait = time.Second
+
+// MDNS discovers the stores on the local network that Advertise service,
+// such as _lattice._tcp, over multicast DNS.
+func MDNS(service string) Discoverer {
+	return mdns{service: service, group: mdnsGroup, wait: mdnsWait}
+}
+
+type mdns struct {
+	service string
+	group   string // where queries go; a test responder's address in tests
+	wait    time.Duration
+}
+
+// mdnsName is the fully qualified name of service in the .local domain.
+func mdnsName(service string) (dnsmessage.Name, error) {
+	return dnsmessage.NewName(strings.TrimSuffix(service, ".") + ".local.")
+}
+
+// Discover asks for service's instances and follows each answer's SRV and
+// address records to a store address. It sends from an ephemeral port, so
+// responders answer it directly rather than to the group (RFC 6762, 6.7).
+func (m mdns) Discover(ctx context.Context) ([]string, error) {
+	name, err := mdnsName(m.service)
+	if err != nil {
+		return nil, fmt.Errorf("mdns service %q: %w", m.service, err)
+	}
+	group, err := net.ResolveUDPAddr("udp4", m.group)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("mdns listen: %w", err)
+	}
+	defer conn.Close()
+
+	q := dnsmessage.Message{Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}}}
+	packet, err := q.Pack()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.WriteToUDP(packet, group); err != nil {
+		return nil, fmt.Errorf("mdns query: %w", err)
+	}
+
+	deadline := time.Now().Add(m.wait)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetReadDeadline(deadline) //nolint:errcheck
+
+	var (
+		instances []dnsmessage.Name
+		srvs      = make(map[dnsmessage.Name]dnsmessage.SRVResource)
+		ips       = make(map[dnsmessage.Name]net.IP)
+		buf       = make([]byte, 9000)
+	)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		var nerr net.Error
+		if errors.As(err, &nerr) && nerr.Timeout() {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("mdns read: %w", err)
+		}
+		var msg dnsmessage.Message
+		if msg.Unpack(buf[:n]) != nil || !msg.Response {
+			continue
+		}
+		for _, rr := range append(msg.Answers, msg.Additionals...) {
+			switch b := rr.Body.(type) {
+			case *dnsmessage.PTRResource:
+				if rr.Header.Name == name {
+					instances = append(instances, b.PTR)
+				}
+			case *dnsmessage.SRVResource:
+				srvs[rr.Header.Name] = *b
+			case *dnsmessage.AResource:
+				ips[rr.Header.Name] = net.IP(b.A[:])
+			}
+		}
+	}
+
+	var addrs []string
+	for _, inst := range instances {
+		srv, ok := srvs[inst]
+		if !ok {
+			continue
+		}
+		host := strings.TrimSuffix(srv.Target.String(), ".")
+		if ip, ok := ips[srv.Target]; ok {
+			host = ip.String()
+		}
+		addrs = append(addrs, net.JoinHostPort(host, strconv.Itoa(int(srv.Port))))
+	}
+	return addrs, nil
+}
+
+// Advertise answers mDNS queries for service, such as _lattice._tcp, with
+// a store on port of this host named instance, until ctx is cancelled, so
+// relays on the network with mdns discovery find it.
+func Advertise(ctx context.Context, service, instance string, port int) error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	ips, err := localIPs()
+	if err != nil {
+		return err
+	}
+	a, err := newAdvertiser(service, instance, hostname, port, ips)
+	if err != nil {
+		return err
+	}
+	group, err := net.ResolveUDPAddr("udp4", mdnsGroup)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenMulticastUDP("udp4", nil, group)
+	if err != nil {
+		return fmt.Errorf("mdns listen: %w", err)
+	}
+	slog.Info("mdns advertising", "service", service, "instance", instance, "port", port)
+	return a.serve(ctx, conn, group)
+}
+
+// advertiser answers queries for one service instance.
+type advertiser struct {
+	service, instance, host dnsmessage.Name
+	port                    uint16
+	ips                     [][4]byte
+}
+
+func newAdvertiser(service, instance, hostname string, port int, ips []net.IP) (*advertiser, error) {
+	a := &advertiser{port: uint16(port)}
+	var err error
+	if a.service, err = mdnsName(service); err != nil {
+		return nil, fmt.Errorf("mdns service %q: %w", service, err)
+	}
+	if a.instance, err = dnsmessage.NewName(instance + "." + a.service.String()); err != nil {
+		return nil, fmt.Errorf("mdns instance %q: %w", instance, err)
+	}
+	if a.host, err = dnsmessage.NewName(strings.Split(hostname, ".")[0] + ".local."); err != nil {
+		return nil, fmt.Errorf("mdns host %q: %w", hostname, err)
+	}
+	for _, ip := range ips {
+		if ip4 := ip.To4(); ip4 != nil && !ip4.IsLoopback() {
+			a.ips = append(a.ips, [4]byte(ip4))
+		}
+	}
+	return a, nil
+}
+
+// serve answers queries read from conn until ctx is cancelled, and closes
+// conn. Queries from port 5353 are answered to group, others to the
+// querier.
+func (a *advertiser) serve(ctx context.Context, conn *net.UDPConn, group *net.UDPAddr) error {
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("mdns read: %w", err)
+		}
+		resp, ok := a.answer(buf[:n], from.Port != 5353)
+		if !ok {
+			continue
+		}
+		to := from
+		if from.Port == 5353 {
+			to = group
+		}
+		if _, err := conn.WriteToUDP(resp, to); err != nil {
+			slog.Warn("mdns answer failed", "to", to.String(), "error", err)
+		}
+	}
+}
+
+// answer returns the response to query, if it asks for the service. A
+// direct (legacy unicast) response echoes the query's ID and question.
+func (a *advertiser) answer(query []byte, direct bool) ([]byte, bool) {
+	var q dnsmessage.Message
+	if q.Unpack(query) != nil || q.Response {
+		return nil, false
+	}
+	var asked []dnsmessage.Question
+	for _, question := range q.Questions {
+		if question.Name == a.service && (question.Type == dnsmessage.TypePTR || question.Type == dnsmessage.TypeALL) {
+			asked = append(asked, question)
+		}
+	}
+	if len(asked) == 0 {
+		return nil, false
+	}
+
+	const ttl = 120
+	resp := dnsmessage.Message{
+		Header: dnsmessage.Header{Response: true, Authoritative: true},
+		Answers: []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: a.service, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: ttl},
+			Body:   &dnsmessage.PTRResource{PTR: a.instance},
+		}},
+		Additionals: []dnsmessage.Resource{{
+			Header: dnsmessage.ResourceHeader{Name: a.instance, Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET, TTL: ttl},
+			Body:   &dnsmessage.SRVResource{Target: a.host, Port: a.port},
+		}},
+	}
+	for _, ip := range a.ips {
+		resp.Additionals = append(resp.Additionals, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: a.host, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: ttl},
+			Body:   &dnsmessage.AResource{A: ip},
+		})
+	}
+	if direct {
+		resp.Header.ID = q.Header.ID
+		resp.Questions = asked
+	}
+	packet, err := resp.Pack()
+	if err != nil {
+		return nil, false
+	}
+	return packet, true
+}
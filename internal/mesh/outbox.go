@@ -0,0 +1,149 @@
+package mesh
+
+import (
+	"sync"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+)
+
+// outbox queues the events a peer could not take, until it can. Events are
+// coalesced by entity, the latest replacing any queued before it, since a
+// forward carries the whole entity. It holds at most limit entities: when
+// full, the oldest event of the lowest priority queued goes, or the new one
+// if its priority is lower still. Safe for concurrent use.
+type outbox struct {
+	mu      sync.Mutex
+	limit   int
+	byID    map[string]*outboxEntry
+	queues  [PriorityDelete + 1][]*outboxEntry // by priority, oldest first; may hold removed entries
+	dead    int                                // removed entries still in queues
+	evicted bool                               // events lost since the last drain
+	wake    chan struct{}                      // signalled when an event is queued
+}
+
+type outboxEntry struct {
+	event   *storev1.EntityEvent
+	removed bool
+}
+
+func newOutbox(limit int) *outbox {
+	return &outbox{limit: limit, byID: make(map[string]*outboxEntry), wake: make(chan struct{}, 1)}
+}
+
+// len returns the number of entities queued.
+func (o *outbox) len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.byID)
+}
+
+// push queues event, and returns the event evicted to make room for it, if
+// any; that may be event itself.
+func (o *outbox) push(event *storev1.EntityEvent) (evicted *storev1.EntityEvent) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	priority := EventPriority(event)
+	id := event.Entity.GetId()
+	if old, ok := o.byID[id]; ok {
+		o.removeLocked(old)
+	} else if len(o.byID) >= o.limit {
+		victim := o.lowestLocked()
+		if victim == nil || EventPriority(victim.event) > priority {
+			o.evicted = true
+			return event
+		}
+		o.removeLocked(victim)
+		o.evicted = true
+		evicted = victim.event
+	}
+	e := &outboxEntry{event: event}
+	o.byID[id] = e
+	o.queues[priority] = append(o.queues[priority], e)
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+	return evicted
+}
+
+// head returns the oldest event queued, leaving it queued, or nil.
+func (o *outbox) head() *outboxEntry {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var oldest *outboxEntry
+	for p := range o.queues {
+		o.trimLocked(p)
+		if q := o.queues[p]; len(q) > 0 && (oldest == nil || q[0].event.Sequence < oldest.event.Sequence) {
+			oldest = q[0]
+		}
+	}
+	return oldest
+}
+
+// done removes e once sent, unless a later event for its entity has
+// replaced it meanwhile.
+func (o *outbox) done(e *outboxEntry) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if !e.removed {
+		o.removeLocked(e)
+	}
+}
+
+// drained reports whether events were evicted since it last did, once the
+// outbox is empty.
+func (o *outbox) drained() (lost bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	lost, o.evicted = o.evicted, false
+	return lost
+}
+
+// lowestLocked returns the oldest entry of the lowest priority queued.
+// Must hold mu.
+func (o *outbox) lowestLocked() *outboxEntry {
+	for p := range o.queues {
+		o.trimLocked(p)
+		if len(o.queues[p]) > 0 {
+			return o.queues[p][0]
+		}
+	}
+	return nil
+}
+
+// removeLocked drops e, leaving it in its queue until trimmed or
+// compacted. Must hold mu.
+func (o *outbox) removeLocked(e *outboxEntry) {
+	e.removed = true
+	delete(o.byID, e.event.Entity.GetId())
+	o.dead++
+	if o.dead > len(o.byID)+64 {
+		o.compactLocked()
+	}
+}
+
+// trimLocked drops removed entries from the front of queue p. Must hold mu.
+func (o *outbox) trimLocked(p int) {
+	q := o.queues[p]
+	for len(q) > 0 && q[0].removed {
+		q = q[1:]
+		o.dead--
+	}
+	o.queues[p] = q
+}
+
+// compactLocked drops every removed entry, so an entity updated over and
+// over does not fill the queues. Must hold mu.
+func (o *outbox) compactLocked() {
+	for p, q := range o.queues {
+		live := q[:0]
+		for _, e := range q {
+			if !e.removed {
+				live = append(live, e)
+			}
+		}
+		clear(q[len(live):])
+		o.queues[p] = live
+	}
+	o.dead = 0
+}
@@ -0,0 +1,99 @@
+package mesh
+
+import (
+	"fmt"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// outboxEvent is an update of id at seq, with a threat of level, if any.
+func outboxEvent(t *testing.T, seq uint64, id string, level entityv1.ThreatLevel) *storev1.EntityEvent {
+	t.Helper()
+	e := &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK}
+	if level != entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED {
+		threat, err := anypb.New(&entityv1.ThreatComponent{Level: level})
+		if err != nil {
+			t.Fatal(err)
+		}
+		e.Components = map[string]*anypb.Any{"threat": threat}
+	}
+	return &storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_UPDATED, Entity: e, Sequence: seq}
+}
+
+// drainOutbox returns the sequences of the events queued, oldest first,
+// emptying ob.
+func drainOutbox(ob *outbox) []uint64 {
+	var seqs []uint64
+	for e := ob.head(); e != nil; e = ob.head() {
+		seqs = append(seqs, e.event.Sequence)
+		ob.done(e)
+	}
+	return seqs
+}
+
+func TestOutbox_CoalescesByEntity(t *testing.T) {
+	ob := newOutbox(10)
+	none := entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED
+	ob.push(outboxEvent(t, 1, "a", none))
+	ob.push(outboxEvent(t, 2, "b", entityv1.ThreatLevel_THREAT_LEVEL_HIGH))
+	ob.push(outboxEvent(t, 3, "a", none))
+	ob.push(&storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_DELETED, Entity: &entityv1.Entity{Id: "b"}, Sequence: 4})
+
+	if n := ob.len(); n != 2 {
+		t.Fatalf("expected 2 entities queued, got %d", n)
+	}
+	if got := fmt.Sprint(drainOutbox(ob)); got != "[3 4]" {
+		t.Fatalf("expected the latest event of each entity, oldest first, got %s", got)
+	}
+	if ob.drained() {
+		t.Fatal("expected nothing evicted")
+	}
+}
+
+func TestOutbox_EvictsLowestPriority(t *testing.T) {
+	ob := newOutbox(3)
+	ob.push(outboxEvent(t, 1, "high", entityv1.ThreatLevel_THREAT_LEVEL_HIGH))
+	ob.push(outboxEvent(t, 2, "low-1", entityv1.ThreatLevel_THREAT_LEVEL_LOW))
+	ob.push(outboxEvent(t, 3, "low-2", entityv1.ThreatLevel_THREAT_LEVEL_LOW))
+
+	// Full: a medium event evicts the oldest low one.
+	if evicted := ob.push(outboxEvent(t, 4, "medium", entityv1.ThreatLevel_THREAT_LEVEL_MEDIUM)); evicted.GetEntity().GetId() != "low-1" {
+		t.Fatalf("expected low-1 evicted, got %v", evicted)
+	}
+	// An event of no priority is itself the lowest, so it goes.
+	none := outboxEvent(t, 5, "none", entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED)
+	if evicted := ob.push(none); evicted != none {
+		t.Fatalf("expected the new event evicted, got %v", evicted)
+	}
+	// An entity already queued is replaced, not evicted for.
+	if evicted := ob.push(outboxEvent(t, 6, "high", entityv1.ThreatLevel_THREAT_LEVEL_HIGH)); evicted != nil {
+		t.Fatalf("expected no eviction for a queued entity, got %v", evicted)
+	}
+
+	if got := fmt.Sprint(drainOutbox(ob)); got != "[3 4 6]" {
+		t.Fatalf("got %s", got)
+	}
+	if !ob.drained() {
+		t.Fatal("expected the evictions reported once drained")
+	}
+	if ob.drained() {
+		t.Fatal("expected the evictions reported once")
+	}
+}
+
+func TestOutbox_CompactsReplacedEvents(t *testing.T) {
+	ob := newOutbox(10)
+	ob.push(outboxEvent(t, 1, "stuck", entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED))
+	for seq := uint64(2); seq < 1000; seq++ {
+		ob.push(outboxEvent(t, seq, "busy", entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED))
+	}
+	if n := len(ob.queues[PriorityNone]); n > 100 {
+		t.Fatalf("expected replaced events compacted away, %d left", n)
+	}
+	if got := fmt.Sprint(drainOutbox(ob)); got != "[1 999]" {
+		t.Fatalf("got %s", got)
+	}
+}
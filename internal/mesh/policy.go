@@ -0,0 +1,110 @@
+package mesh
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// A Policy decides what a relay replicates, so data of little value far
+// away does not spend the budget of tactical links. It covers forwards,
+// syncs, and anti-entropy's repairs of peers, not what the relay takes in.
+// Deletes always replicate, so no peer keeps what the mesh has deleted. A
+// nil Policy replicates everything.
+type Policy struct {
+	// Types, if set, are the entity types replicated, each with the lowest
+	// threat level its entities must have; THREAT_LEVEL_UNSPECIFIED admits
+	// them all. Entities of other types are not replicated.
+	Types map[entityv1.EntityType]entityv1.ThreatLevel
+
+	// Strip are the component keys never replicated; entities are sent
+	// without them.
+	Strip []string
+}
+
+// ParsePolicy parses a replication policy: rules separated by ";", each
+// an entity type replicated, "type" or "type>=threat", or a component key
+// never replicated, "-key". "track>=medium;asset;-task_catalog"
+// replicates tracks of medium threat or higher and every asset, without
+// their task catalogs, and nothing else but deletes. With no type rules
+// every type is replicated.
+func ParsePolicy(s string) (*Policy, error) {
+	p := &Policy{}
+	for _, rule := range strings.Split(s, ";") {
+		rule = strings.TrimSpace(rule)
+		if rule == "" {
+			continue
+		}
+		if key, ok := strings.CutPrefix(rule, "-"); ok {
+			if key == "" {
+				return nil, fmt.Errorf("policy rule %q: want -<component>", rule)
+			}
+			p.Strip = append(p.Strip, key)
+			continue
+		}
+		name, level, hasLevel := strings.Cut(rule, ">=")
+		typ, ok := entityv1.EntityType_value["ENTITY_TYPE_"+strings.ToUpper(strings.TrimSpace(name))]
+		if !ok || typ == 0 {
+			return nil, fmt.Errorf("policy rule %q: unknown entity type %q (want track, asset, geo, or system)", rule, name)
+		}
+		floor := entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED
+		if hasLevel {
+			v, ok := entityv1.ThreatLevel_value["THREAT_LEVEL_"+strings.ToUpper(strings.TrimSpace(level))]
+			if !ok || v == 0 {
+				return nil, fmt.Errorf("policy rule %q: unknown threat level %q (want none, low, medium, or high)", rule, level)
+			}
+			floor = entityv1.ThreatLevel(v)
+		}
+		if p.Types == nil {
+			p.Types = make(map[entityv1.EntityType]entityv1.ThreatLevel)
+		}
+		if _, dup := p.Types[entityv1.EntityType(typ)]; dup {
+			return nil, fmt.Errorf("policy rule %q: type %s given twice", rule, name)
+		}
+		p.Types[entityv1.EntityType(typ)] = floor
+	}
+	return p, nil
+}
+
+// admits reports whether e is replicated.
+func (p *Policy) admits(e *entityv1.Entity) bool {
+	if p == nil || p.Types == nil {
+		return true
+	}
+	floor, ok := p.Types[e.GetType()]
+	if !ok {
+		return false
+	}
+	return floor == entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED || threatLevel(e) >= floor
+}
+
+// strip returns e as replicated, without the components never replicated
+// or their tombstones; e itself if it has none of them.
+func (p *Policy) strip(e *entityv1.Entity) *entityv1.Entity {
+	if p == nil || e == nil || !slices.ContainsFunc(p.Strip, func(key string) bool {
+		return e.Components[key] != nil || e.RemovedComponents[key] != nil
+	}) {
+		return e
+	}
+	out := proto.Clone(e).(*entityv1.Entity)
+	for _, key := range p.Strip {
+		delete(out.Components, key)
+		delete(out.ComponentHlc, key)
+		delete(out.ComponentWrites, key)
+		delete(out.RemovedComponents, key)
+	}
+	return out
+}
+
+// threatLevel returns e's threat level, or THREAT_LEVEL_UNSPECIFIED if it
+// has no threat component.
+func threatLevel(e *entityv1.Entity) entityv1.ThreatLevel {
+	threat := &entityv1.ThreatComponent{}
+	if c, ok := e.GetComponents()["threat"]; !ok || c.UnmarshalTo(threat) != nil {
+		return entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED
+	}
+	return threat.Level
+}
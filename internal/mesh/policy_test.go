@@ -0,0 +1,94 @@
+package mesh
+
+import (
+	"slices"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func policyEntity(t *testing.T, typ entityv1.EntityType, level entityv1.ThreatLevel, keys ...string) *entityv1.Entity {
+	t.Helper()
+	e := &entityv1.Entity{Id: "p1", Type: typ, Components: map[string]*anypb.Any{}}
+	if level != entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED {
+		threat, err := anypb.New(&entityv1.ThreatComponent{Level: level})
+		if err != nil {
+			t.Fatalf("marshal threat: %v", err)
+		}
+		e.Components["threat"] = threat
+	}
+	for _, key := range keys {
+		e.Components[key], _ = anypb.New(&entityv1.TaskCatalogComponent{})
+	}
+	return e
+}
+
+func TestParsePolicy(t *testing.T) {
+	p, err := ParsePolicy("track>=medium; asset ;-task_catalog")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(p.Types) != 2 || p.Types[entityv1.EntityType_ENTITY_TYPE_TRACK] != entityv1.ThreatLevel_THREAT_LEVEL_MEDIUM ||
+		p.Types[entityv1.EntityType_ENTITY_TYPE_ASSET] != entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED {
+		t.Errorf("unexpected types %v", p.Types)
+	}
+	if !slices.Equal(p.Strip, []string{"task_catalog"}) {
+		t.Errorf("unexpected strip %v", p.Strip)
+	}
+
+	for _, bad := range []string{"vessel", "track>=extreme", "track>=", "-", "track;track>=high", "unspecified"} {
+		if _, err := ParsePolicy(bad); err == nil {
+			t.Errorf("ParsePolicy(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestPolicy_Admits(t *testing.T) {
+	p, err := ParsePolicy("track>=medium;asset")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	track, asset, geo := entityv1.EntityType_ENTITY_TYPE_TRACK, entityv1.EntityType_ENTITY_TYPE_ASSET, entityv1.EntityType_ENTITY_TYPE_GEO
+	for _, tc := range []struct {
+		typ   entityv1.EntityType
+		level entityv1.ThreatLevel
+		want  bool
+	}{
+		{track, entityv1.ThreatLevel_THREAT_LEVEL_HIGH, true},
+		{track, entityv1.ThreatLevel_THREAT_LEVEL_MEDIUM, true},
+		{track, entityv1.ThreatLevel_THREAT_LEVEL_LOW, false},
+		{track, entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED, false},
+		{asset, entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED, true},
+		{geo, entityv1.ThreatLevel_THREAT_LEVEL_HIGH, false},
+	} {
+		if got := p.admits(policyEntity(t, tc.typ, tc.level)); got != tc.want {
+			t.Errorf("%v at %v: admits = %v, want %v", tc.typ, tc.level, got, tc.want)
+		}
+	}
+
+	var none *Policy
+	if !none.admits(policyEntity(t, geo, 0)) {
+		t.Error("a nil policy should admit everything")
+	}
+	strip, _ := ParsePolicy("-task_catalog")
+	if !strip.admits(policyEntity(t, geo, 0)) {
+		t.Error("a policy without type rules should admit every type")
+	}
+}
+
+func TestPolicy_Strip(t *testing.T) {
+	p, _ := ParsePolicy("-task_catalog")
+	e := policyEntity(t, entityv1.EntityType_ENTITY_TYPE_ASSET, entityv1.ThreatLevel_THREAT_LEVEL_LOW, "task_catalog")
+	got := p.strip(e)
+	if got.Components["task_catalog"] != nil || got.Components["threat"] == nil {
+		t.Errorf("expected the task catalog stripped and the threat kept, got %v", got.Components)
+	}
+	if e.Components["task_catalog"] == nil {
+		t.Error("strip modified its argument")
+	}
+	plain := policyEntity(t, entityv1.EntityType_ENTITY_TYPE_TRACK, entityv1.ThreatLevel_THREAT_LEVEL_LOW)
+	if p.strip(plain) != plain {
+		t.Error("expected an entity without stripped components returned as is")
+	}
+}
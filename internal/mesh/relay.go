@@ -2,16 +2,31 @@ package mesh
 
 import (
 	"context"
+	"encoding/binary"
+	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"log/slog"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
 	"github.com/boshu2/lattice-lab/internal/crdt"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/watch"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
 	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 )
@@ -21,24 +36,174 @@ type Config struct {
 	LocalAddr    string   // address of the local entity-store
 	Peers        []string // addresses of peer entity-stores
 	NodeID       string   // for echo suppression — skip events originating from this node
-	BandwidthBPS float64  // bytes per second budget; 0 = unlimited (default)
+	BandwidthBPS float64  // bytes per second budget, shared by peers without a Link; 0 = unlimited (default)
 	BurstBytes   float64  // burst capacity; 0 = use BandwidthBPS as burst
+	InitialSync  bool     // restore a snapshot of the local store to each peer on start
+	Compression  string   // compressor for peer RPCs, one of client.Compressors; "" = none
+
+	// AntiEntropy is how often to reconcile the local store with each
+	// peer, listing both and merging what differs; 0 disables it.
+	AntiEntropy time.Duration
+
+	// Discovery, if set, finds further peers every DiscoveryInterval; see
+	// ParseDiscovery.
+	Discovery         Discoverer
+	DiscoveryInterval time.Duration
+
+	// GossipFanout, if positive, sends each event to that many peers,
+	// picked at random per event, instead of to every peer; the peers'
+	// relays pass it on alike, until it is GossipHops relays from the
+	// node it was written on (0 = 6). Anti-entropy then reconciles with
+	// that many peers per pass.
+	GossipFanout int
+	GossipHops   int
+
+	// OutboxSize, if positive, is how many entities' events are queued for
+	// a peer that cannot take them, coalesced by entity, to send once it
+	// can; 0 leaves them in the local store, and the peer's watch waits.
+	OutboxSize int
+
+	// FlushInterval, if positive, batches each peer's events, keeping the
+	// latest per entity, and sends the batch every FlushInterval, highest
+	// priority first; 0 sends each event as it comes.
+	FlushInterval time.Duration
+
+	// Links, by peer address, give peers whose links differ from the rest
+	// their own budget, flush interval, and send timeout; see ParseLinks.
+	// Peers not listed share BandwidthBPS and use FlushInterval.
+	Links map[string]Link
+
+	// Heartbeat, if positive, is how often each peer is pinged. One that
+	// misses HeartbeatMisses in a row (0 = 3) counts as partitioned, and
+	// the relay writes the node's view of the mesh to the local store as
+	// SYSTEM entity HealthID(NodeID), so operators and services see they
+	// work on a partitioned picture.
+	Heartbeat       time.Duration
+	HeartbeatMisses int
+
+	// MaxSkew, if positive, is how far a peer's wall clock may be from the
+	// local store's before the relay reports it skewed: in the node's mesh
+	// health, in ListPeers, and with a warning. Heartbeats measure the
+	// offsets, so without Heartbeat there are none. Skew leaves HLCs
+	// ordered, but a node whose clock runs fast wins last-writer-wins
+	// merges it should lose.
+	MaxSkew time.Duration
+
+	// Policy, if set, limits what is replicated to peers; see ParsePolicy.
+	Policy *Policy
+
+	// Strategies, if set, resolve the components the relay merges in
+	// place of crdt.Default's; they must match the stores'.
+	Strategies *crdt.Registry
+
+	// Impairments, by peer address, emulate degraded links to those
+	// peers, for tests and demos; see ParseImpairments.
+	Impairments map[string]Impairment
+
+	// Checkpoint, if set, is a file the relay saves each peer's progress
+	// in: the sequence of the last local event the peer took with every
+	// one before it. A restarted relay resumes each peer's watch from
+	// there, neither re-sending the local store's history nor skipping
+	// what was written while it was down. If the local store no longer
+	// holds those events, having restarted itself, the peer is resynced.
+	Checkpoint string
+
+	Health *health.Probe // optional; ready while watching the local store, wedged if a forward hangs, per peer
 }
 
 // DefaultConfig returns mesh relay defaults.
 func DefaultConfig() Config {
 	return Config{
-		LocalAddr: "localhost:50051",
+		LocalAddr:         "localhost:50051",
+		AntiEntropy:       time.Minute,
+		DiscoveryInterval: 30 * time.Second,
+		OutboxSize:        10000,
+		Heartbeat:         5 * time.Second,
+		MaxSkew:           time.Second,
 	}
 }
 
+var (
+	forwardedTotal = metrics.NewCounter("lattice_relay_forwarded_total",
+		"Events forwarded to a peer; one event to three peers counts three.")
+	droppedTotal = metrics.NewCounter("lattice_relay_dropped_total",
+		"Events dropped by the bandwidth budget, by priority, 0 (none) to 4 (delete).", "priority")
+	mergedTotal = metrics.NewCounter("lattice_relay_merged_total",
+		"Forwarded entities CRDT-merged with the peer's copy.")
+	errorsTotal = metrics.NewCounter("lattice_relay_errors_total",
+		"Failed forwards and peer syncs.")
+	repairedTotal = metrics.NewCounter("lattice_relay_repaired_total",
+		"Entities anti-entropy created or merged in the local store or a peer.")
+	divergedTotal = metrics.NewCounter("lattice_relay_diverged_total",
+		"Entities anti-entropy found the local store and a peer disagreeing on, per pass.")
+	resolvedTotal = metrics.NewCounter("lattice_relay_resolved_total",
+		"Component conflicts anti-entropy resolved, by merge strategy, such as lww or max-wins.", "strategy")
+	coalescedTotal = metrics.NewCounter("lattice_relay_coalesced_total",
+		"Events replaced in a peer's batch by a later event for the same entity, so never sent.")
+	evictedTotal = metrics.NewCounter("lattice_relay_evicted_total",
+		"Events evicted from a full peer outbox, by priority, 0 (none) to 4 (delete).", "priority")
+	filteredTotal = metrics.NewCounter("lattice_relay_filtered_total",
+		"Events the replication policy kept from a peer; one event kept from three peers counts three.")
+	partitionedPeers = metrics.NewGauge("lattice_relay_partitioned_peers",
+		"Peers that missed HeartbeatMisses heartbeats in a row.")
+	skewedPeers = metrics.NewGauge("lattice_relay_skewed_peers",
+		"Peers whose wall clocks are further than MaxSkew from the local store's.")
+	clockOffset = metrics.NewGauge("lattice_relay_peer_clock_offset_seconds",
+		"How far each peer's wall clock is ahead of the local store's, negative if behind.", "peer")
+)
+
+// peerBackoff is how a relay redials a peer it has lost: gRPC's jittered
+// exponential backoff, capped at seconds rather than its default two
+// minutes, so a healed partition is noticed soon after.
+var peerBackoff = grpc.ConnectParams{
+	Backoff:           backoff.Config{BaseDelay: 100 * time.Millisecond, Multiplier: 1.6, Jitter: 0.2, MaxDelay: 5 * time.Second},
+	MinConnectTimeout: 5 * time.Second,
+}
+
 // Relay replicates entities between peer entity-stores.
-// It watches the local store and forwards events to all peers.
+// It watches the local store and forwards events to all peers, or under
+// gossip to a few.
 type Relay struct {
-	cfg    Config
-	mu     sync.RWMutex
-	stats  Stats
-	bucket *TokenBucket // nil when BandwidthBPS == 0 (unlimited)
+	cfg     Config
+	mu      sync.RWMutex
+	stats   Stats
+	bucket  *TokenBucket        // nil when BandwidthBPS == 0 (unlimited)
+	traffic map[string]*Traffic // by peer address; guarded by mu
+
+	convergence map[string]Convergence   // by peer address, from anti-entropy; guarded by mu
+	offsets     map[string]time.Duration // peers' clock offsets, by address, from heartbeats; guarded by mu
+
+	buckets map[string]*TokenBucket // by peer address, for Links with a budget
+
+	peersMu sync.Mutex
+	peers   map[string]*peer // by address
+	running *running         // nil unless Run is running
+
+	addrs atomic.Pointer[[]string] // the peers' addresses, sorted, for gossip
+	seed  uint64                   // gossip's per-relay randomness
+
+	saved map[string]uint64 // checkpoints loaded from Config.Checkpoint, by peer address; guarded by peersMu
+}
+
+// running is what a running relay starts peers with.
+type running struct {
+	ctx   context.Context
+	local storev1.EntityStoreServiceClient
+}
+
+// peer is one peer store and, while the relay runs, the connection and
+// watch feeding it.
+type peer struct {
+	addr   string
+	conn   *grpc.ClientConn
+	client storev1.EntityStoreServiceClient
+	stop   context.CancelFunc
+	done   chan struct{}
+	outbox *outbox // nil without Config.OutboxSize
+
+	// progress is how far its watch has got; kept once stopped, so a
+	// restarted watch resumes from there.
+	progress *progress
 }
 
 // Stats tracks relay activity.
@@ -47,17 +212,78 @@ type Stats struct {
 	Errors    int
 	Merged    int // entities that required CRDT merge
 	Dropped   int // events dropped by bandwidth budget
+	Repaired  int // entities anti-entropy wrote to either side
+	Evicted   int // events evicted from full peer outboxes
+	Filtered  int // events the replication policy kept from peers
+
+	// Anti-entropy's findings, summed over its passes with every peer:
+	// entities that had diverged, and component conflicts by how they
+	// were resolved. Convergence has the last pass with each peer, by
+	// address.
+	Diverged        int
+	ResolvedLWW     int
+	ResolvedMaxWins int
+	ResolvedOther   int
+	Convergence     map[string]Convergence
 }
 
+// Traffic counts what a relay sent one peer.
+type Traffic struct {
+	Forwarded int
+	Dropped   int   // events dropped by the peer's bandwidth budget
+	SentBytes int64 // forwarded entities' size on the wire
+}
+
+// PeerInfo describes one of a relay's peers.
+type PeerInfo struct {
+	Addr   string
+	State  string // the connection's state; empty before Run
+	Queued int    // entities in its outbox
+	Link   string // its Link's class; empty if it has none
+	Traffic
+	Convergence
+
+	// Budget is its bandwidth budget in bytes per second, 0 if unlimited;
+	// Available, the bytes it can take now. SharedBudget is set if the
+	// budget is BandwidthBPS, shared by every peer without a Link.
+	Budget       float64
+	Available    float64
+	SharedBudget bool
+
+	// ClockOffset is how far its wall clock is ahead of the local store's,
+	// negative if behind, as of the last heartbeat it answered; Measured is
+	// unset before the first. ClockSkewed is set if the offset is beyond
+	// MaxSkew.
+	ClockOffset time.Duration
+	Measured    bool
+	ClockSkewed bool
+}
+
+var (
+	// ErrPeerExists is returned by AddPeer for an address already a peer.
+	ErrPeerExists = errors.New("already a peer")
+	// ErrNoPeer is returned by RemovePeer for an address not a peer.
+	ErrNoPeer = errors.New("not a peer")
+)
+
 // New creates a relay with the given config.
 func New(cfg Config) *Relay {
-	r := &Relay{cfg: cfg}
-	if cfg.BandwidthBPS > 0 {
-		burst := cfg.BurstBytes
-		if burst == 0 {
-			burst = cfg.BandwidthBPS
+	if cfg.Strategies == nil {
+		cfg.Strategies = crdt.Default
+	}
+	r := &Relay{cfg: cfg, peers: make(map[string]*peer), traffic: make(map[string]*Traffic), seed: rand.Uint64()}
+	r.convergence = make(map[string]Convergence)
+	r.offsets = make(map[string]time.Duration)
+	for _, addr := range cfg.Peers {
+		r.peers[addr] = &peer{addr: addr}
+	}
+	r.setAddrsLocked()
+	r.bucket = newBucket(cfg.BandwidthBPS, cfg.BurstBytes)
+	r.buckets = make(map[string]*TokenBucket)
+	for addr, link := range cfg.Links {
+		if b := link.bucket(); b != nil {
+			r.buckets[addr] = b
 		}
-		r.bucket = NewTokenBucket(cfg.BandwidthBPS, burst)
 	}
 	return r
 }
@@ -66,106 +292,697 @@ func New(cfg Config) *Relay {
 func (r *Relay) GetStats() Stats {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	return r.stats
+	st := r.stats
+	st.Convergence = maps.Clone(r.convergence)
+	return st
 }
 
-// Run watches the local store and replicates events to peers until ctx is cancelled.
+// Run watches the local store and replicates events to peers until ctx is
+// cancelled. Neither a lost peer nor a dropped watch ends it: each peer's
+// watch resumes, once the peer or the local store is back, from the last
+// event the peer took. Peers can be added and removed while it runs, and
+// are kept in line with cfg.Discovery if set.
 func (r *Relay) Run(ctx context.Context) error {
-	if len(r.cfg.Peers) == 0 {
+	r.peersMu.Lock()
+	if len(r.peers) == 0 && r.cfg.Discovery == nil {
+		r.peersMu.Unlock()
 		return fmt.Errorf("no peers configured")
 	}
+	if r.running != nil {
+		r.peersMu.Unlock()
+		return fmt.Errorf("relay already running")
+	}
+	if r.cfg.Checkpoint != "" {
+		saved, err := loadCheckpoints(r.cfg.Checkpoint)
+		if err != nil {
+			r.peersMu.Unlock()
+			return err
+		}
+		r.saved = saved
+	}
 
 	// Connect to local store.
-	localConn, err := grpc.NewClient(r.cfg.LocalAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	localConn, err := client.Dial(r.cfg.LocalAddr)
 	if err != nil {
+		r.peersMu.Unlock()
 		return fmt.Errorf("connect to local store: %w", err)
 	}
 	defer localConn.Close()
 
-	localClient := storev1.NewEntityStoreServiceClient(localConn)
+	local := storev1.NewEntityStoreServiceClient(localConn)
+	r.running = &running{ctx: ctx, local: local}
+	for _, p := range r.peers {
+		if err := r.startLocked(p, r.cfg.InitialSync); err != nil {
+			r.stopAllLocked()
+			r.peersMu.Unlock()
+			return err
+		}
+	}
+	slog.Info("mesh-relay started", "local", r.cfg.LocalAddr, "peers", slices.Sorted(maps.Keys(r.peers)))
+	r.peersMu.Unlock()
 
-	// Connect to all peers.
-	peerClients := make([]storev1.EntityStoreServiceClient, 0, len(r.cfg.Peers))
-	var peerConns []*grpc.ClientConn
-	for _, addr := range r.cfg.Peers {
-		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-		if err != nil {
-			for _, c := range peerConns {
-				c.Close()
+	var wg sync.WaitGroup
+	if r.cfg.Discovery != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.discover(ctx)
+		}()
+	}
+	if r.cfg.Heartbeat > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.heartbeat(ctx, local)
+		}()
+	}
+	if r.cfg.Checkpoint != "" {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.saveProgress(ctx)
+		}()
+	}
+	if r.cfg.AntiEntropy > 0 {
+		r.antiEntropy(ctx, local)
+	} else {
+		<-ctx.Done()
+	}
+	wg.Wait()
+	r.peersMu.Lock()
+	r.stopAllLocked()
+	r.peersMu.Unlock()
+	if r.cfg.Checkpoint != "" {
+		// The peers are stopped, so this is where they resume.
+		if err := saveCheckpoints(r.cfg.Checkpoint, r.checkpoints()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startLocked connects to p and starts its watch. Each peer is fed by its
+// own watch of the local store, so a peer that is down holds up only its
+// own forwards. With an outbox, what it cannot take is queued there and
+// drained once it can; without, a forward it cannot take ends its watch,
+// which backs off and resumes from that event, by when the peer's
+// connection may be back. A peer's watch resyncs it from a snapshot if the
+// local store has since lost the events it missed, and on start if
+// initialSync is set; syncs run once the watch is open, so writes during
+// them are still relayed. Must hold peersMu, with the relay running.
+func (r *Relay) startLocked(p *peer, initialSync bool) error {
+	opts := []client.Option{client.WithoutRetries(), client.WithCompression(r.cfg.Compression),
+		client.WithDialOptions(grpc.WithConnectParams(peerBackoff))}
+	if link := r.cfg.Links[p.addr]; link.Timeout > 0 {
+		opts = append(opts, client.WithTimeout(link.Timeout))
+	}
+	if imp, ok := r.cfg.Impairments[p.addr]; ok {
+		opts = append(opts, client.WithDialOptions(imp.dialOptions()...))
+	}
+	conn, err := client.Dial(p.addr, opts...)
+	if err != nil {
+		return fmt.Errorf("connect to peer %s: %w", p.addr, err)
+	}
+	ctx, stop := context.WithCancel(r.running.ctx)
+	p.conn, p.client, p.stop, p.done = conn, storev1.NewEntityStoreServiceClient(conn), stop, make(chan struct{})
+
+	since := p.checkpoint(r.saved[p.addr])
+	p.progress = newProgress(since)
+	local, peerClient, done, prog := r.running.local, p.client, p.done, p.progress
+	resync := func(ctx context.Context) error {
+		n := prog.evictions()
+		if err := r.syncPeer(ctx, local, peerClient); err != nil {
+			slog.Error("mesh-relay sync failed", "peer", p.addr, "error", err)
+			r.mu.Lock()
+			r.stats.Errors++
+			r.mu.Unlock()
+			errorsTotal.Inc()
+			return nil
+		}
+		prog.resynced(n)
+		return nil
+	}
+	// A peer resuming from a checkpoint needs no snapshot: its watch
+	// resyncs it if the local store cannot resume.
+	cfg := watch.Config{Resync: resync, ResyncOnStart: initialSync && since == 0, Since: since, Health: r.cfg.Health, Name: "relay " + p.addr}
+	send := func(events []*storev1.EntityEvent) error {
+		if err := r.forwardBatch(ctx, p.addr, peerClient, events); err != nil {
+			return err
+		}
+		prog.taken(events...)
+		return nil
+	}
+	var wg sync.WaitGroup
+	if r.cfg.OutboxSize > 0 {
+		p.outbox = newOutbox(r.cfg.OutboxSize)
+		ob := p.outbox
+		send = func(events []*storev1.EntityEvent) error {
+			// Events wait behind those already queued, so each entity's
+			// reach the peer in order.
+			if ob.len() > 0 || r.forwardBatch(ctx, p.addr, peerClient, events) != nil {
+				for _, event := range events {
+					r.enqueue(p.addr, ob, prog, event)
+				}
+				return nil
 			}
-			return fmt.Errorf("connect to peer %s: %w", addr, err)
+			prog.taken(events...)
+			return nil
 		}
-		peerConns = append(peerConns, conn)
-		peerClients = append(peerClients, storev1.NewEntityStoreServiceClient(conn))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.drain(ctx, p.addr, peerClient, ob, prog, resync)
+		}()
 	}
-	defer func() {
-		for _, c := range peerConns {
-			c.Close()
+	handle := func(event *storev1.EntityEvent) error {
+		prog.handed(event)
+		return send([]*storev1.EntityEvent{event})
+	}
+	if interval := r.flushInterval(p.addr); interval > 0 {
+		batch := NewCoalescer()
+		handle = func(event *storev1.EntityEvent) error {
+			prog.handed(event)
+			if batch.Add(event) {
+				coalescedTotal.Inc()
+			}
+			return nil
 		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.flush(ctx, interval, batch, send)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		watch.RunE(ctx, local, cfg, handle)
+	}()
+	go func() {
+		wg.Wait()
+		close(done)
 	}()
+	return nil
+}
 
-	// Watch local store for all entity events.
-	stream, err := localClient.WatchEntities(ctx, &storev1.WatchEntitiesRequest{})
-	if err != nil {
-		return fmt.Errorf("watch local store: %w", err)
+// flushInterval is how often the peer at addr is sent its batched events:
+// its Link's, or FlushInterval.
+func (r *Relay) flushInterval(addr string) time.Duration {
+	if link, ok := r.cfg.Links[addr]; ok {
+		return link.FlushInterval
+	}
+	return r.cfg.FlushInterval
+}
+
+// flush sends a peer's batched events every interval until ctx is
+// cancelled, highest priority first and up to maxReplicateBatch to a call,
+// so a constrained link carries each entity's latest state once per
+// interval rather than every change, and the bandwidth budget runs out on
+// the least important. Events send cannot deliver go back in the batch for
+// the next flush.
+func (r *Relay) flush(ctx context.Context, interval time.Duration, batch *Coalescer, send func([]*storev1.EntityEvent) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for events := batch.Drain(); len(events) > 0; {
+			n := min(len(events), maxReplicateBatch)
+			if send(events[:n]) != nil {
+				for _, e := range events {
+					batch.Add(e)
+				}
+				break
+			}
+			events = events[n:]
+		}
 	}
+}
 
-	slog.Info("mesh-relay started", "local", r.cfg.LocalAddr, "peers", r.cfg.Peers)
+// maxReplicateBatch is the most events sent a peer in one ReplicateBatch
+// call, keeping a call well under gRPC's default 4 MiB message limit.
+const maxReplicateBatch = 256
+
+// enqueue queues event in a peer's outbox, counting any event evicted for
+// it.
+func (r *Relay) enqueue(addr string, ob *outbox, prog *progress, event *storev1.EntityEvent) {
+	evicted := ob.push(event)
+	if evicted == nil {
+		return
+	}
+	prog.evict(evicted)
+	priority := EventPriority(evicted)
+	r.mu.Lock()
+	r.stats.Evicted++
+	r.mu.Unlock()
+	evictedTotal.Inc(strconv.Itoa(priority))
+	slog.Debug("mesh-relay outbox eviction", "peer", addr, "entity", evicted.Entity.GetId(), "priority", priority)
+}
 
+// drain sends a peer the events queued in its outbox, oldest first, until
+// ctx is cancelled. While the peer is unreachable it retries with jittered
+// backoff, as a watch does. Once the outbox empties, if events were evicted
+// from it, resync sends the peer a snapshot in their place.
+func (r *Relay) drain(ctx context.Context, addr string, peer storev1.EntityStoreServiceClient, ob *outbox, prog *progress, resync func(context.Context) error) {
+	backoff := drainBackoff
 	for {
-		event, err := stream.Recv()
-		if err != nil {
-			if ctx.Err() != nil {
-				return nil
+		select {
+		case <-ctx.Done():
+			return
+		case <-ob.wake:
+		}
+		for e := ob.head(); e != nil; e = ob.head() {
+			if err := r.forward(ctx, addr, peer, e.event); err != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(backoff/2 + rand.N(backoff/2)):
+				}
+				backoff = min(2*backoff, maxDrainBackoff)
+				continue
 			}
-			return fmt.Errorf("recv: %w", err)
+			backoff = drainBackoff
+			ob.done(e)
+			prog.taken(e.event)
 		}
+		if ob.drained() {
+			slog.Warn("mesh-relay outbox overflowed, resyncing peer", "peer", addr)
+			resync(ctx) //nolint:errcheck
+		}
+	}
+}
 
-		// Echo suppression: skip events that originated from this node.
-		if r.cfg.NodeID != "" && event.OriginNode == r.cfg.NodeID {
+// The backoff between a peer's outbox drain attempts while it is
+// unreachable, which is jittered.
+const (
+	drainBackoff    = 100 * time.Millisecond
+	maxDrainBackoff = 5 * time.Second
+)
+
+// stopLocked stops p's watch and closes its connection, dropping its
+// outbox. Must hold peersMu.
+func (r *Relay) stopLocked(p *peer) {
+	if p.stop == nil {
+		return
+	}
+	p.stop()
+	<-p.done
+	p.conn.Close()
+	r.cfg.Health.Remove("relay " + p.addr)
+	p.conn, p.client, p.stop, p.done, p.outbox = nil, nil, nil, nil, nil
+}
+
+// stopAllLocked stops every peer, once Run is ending. Must hold peersMu.
+func (r *Relay) stopAllLocked() {
+	for _, p := range r.peers {
+		r.stopLocked(p)
+	}
+	r.running = nil
+}
+
+// AddPeer starts relaying to the store at addr, at once if the relay is
+// running, else when it runs. If sync is set, the peer is first sent a
+// snapshot of the local store.
+func (r *Relay) AddPeer(addr string, sync bool) error {
+	r.peersMu.Lock()
+	defer r.peersMu.Unlock()
+	if _, ok := r.peers[addr]; ok {
+		return fmt.Errorf("peer %s: %w", addr, ErrPeerExists)
+	}
+	p := &peer{addr: addr}
+	if r.running != nil {
+		if err := r.startLocked(p, sync); err != nil {
+			return err
+		}
+	}
+	r.peers[addr] = p
+	r.setAddrsLocked()
+	slog.Info("mesh-relay peer added", "peer", addr)
+	return nil
+}
+
+// RemovePeer stops relaying to the store at addr. Forwards to it in
+// flight are abandoned.
+func (r *Relay) RemovePeer(addr string) error {
+	r.peersMu.Lock()
+	defer r.peersMu.Unlock()
+	p, ok := r.peers[addr]
+	if !ok {
+		return fmt.Errorf("peer %s: %w", addr, ErrNoPeer)
+	}
+	r.stopLocked(p)
+	delete(r.peers, addr)
+	delete(r.saved, addr)
+	r.setAddrsLocked()
+	r.mu.Lock()
+	delete(r.traffic, addr)
+	delete(r.convergence, addr)
+	delete(r.offsets, addr)
+	r.mu.Unlock()
+	slog.Info("mesh-relay peer removed", "peer", addr)
+	return nil
+}
+
+// ListPeers returns the relay's peers, by address.
+func (r *Relay) ListPeers() []PeerInfo {
+	r.peersMu.Lock()
+	defer r.peersMu.Unlock()
+	out := make([]PeerInfo, 0, len(r.peers))
+	for _, addr := range slices.Sorted(maps.Keys(r.peers)) {
+		info := PeerInfo{Addr: addr, Link: r.cfg.Links[addr].Class}
+		p := r.peers[addr]
+		if p.conn != nil {
+			info.State = p.conn.GetState().String()
+		}
+		if p.outbox != nil {
+			info.Queued = p.outbox.len()
+		}
+		if bucket, shared := r.budget(addr); bucket != nil {
+			info.Budget, info.Available, info.SharedBudget = bucket.Rate(), bucket.Available(), shared
+		}
+		r.mu.RLock()
+		if t, ok := r.traffic[addr]; ok {
+			info.Traffic = *t
+		}
+		info.Convergence = r.convergence[addr]
+		info.ClockOffset, info.Measured = r.offsets[addr]
+		info.ClockSkewed = info.Measured && r.skewed(info.ClockOffset)
+		r.mu.RUnlock()
+		out = append(out, info)
+	}
+	return out
+}
+
+// setAddrsLocked publishes the peers' addresses for gossips, which cannot
+// take peersMu: RemovePeer holds it while a peer's watch finishes a
+// forward. Must hold peersMu.
+func (r *Relay) setAddrsLocked() {
+	addrs := slices.Sorted(maps.Keys(r.peers))
+	r.addrs.Store(&addrs)
+}
+
+// peerClients returns a client for each running peer, by address.
+func (r *Relay) peerClients() map[string]storev1.EntityStoreServiceClient {
+	r.peersMu.Lock()
+	defer r.peersMu.Unlock()
+	out := make(map[string]storev1.EntityStoreServiceClient, len(r.peers))
+	for addr, p := range r.peers {
+		if p.client != nil {
+			out[addr] = p.client
+		}
+	}
+	return out
+}
+
+// syncPeer streams a snapshot of the local store into peer, bringing a new
+// peer up to date without waiting for each entity to change. The peer keeps
+// any entity whose copy is at least as new as the local one.
+func (r *Relay) syncPeer(ctx context.Context, local, peer storev1.EntityStoreServiceClient) error {
+	snap, err := local.SnapshotEntities(ctx, &storev1.SnapshotEntitiesRequest{})
+	if err != nil {
+		return fmt.Errorf("snapshot local store: %w", err)
+	}
+	restore, err := peer.RestoreEntities(server.ContextWithOrigin(ctx, r.cfg.NodeID))
+	if err != nil {
+		return fmt.Errorf("restore to peer: %w", err)
+	}
+	for {
+		entity, err := snap.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			restore.CloseSend() //nolint:errcheck
+			return fmt.Errorf("snapshot recv: %w", err)
+		}
+		if !r.cfg.Policy.admits(entity) {
 			continue
 		}
+		if err := restore.Send(&storev1.RestoreEntitiesRequest{Entity: r.cfg.Policy.strip(entity)}); err != nil {
+			break // the peer ended the stream; CloseAndRecv has its status
+		}
+	}
+	resp, err := restore.CloseAndRecv()
+	if err != nil {
+		return fmt.Errorf("restore to peer: %w", err)
+	}
+	slog.Info("mesh-relay synced peer", "created", resp.Created, "replaced", resp.Replaced, "skipped", resp.Skipped)
+	return nil
+}
 
-		r.forwardToPeers(ctx, peerClients, event)
+// wireSize is the size of e sent to a peer, compressed if the relay
+// compresses.
+func (r *Relay) wireSize(e *entityv1.Entity) int {
+	if r.cfg.Compression == "" {
+		return proto.Size(e)
+	}
+	b, err := proto.Marshal(e)
+	if err != nil {
+		return proto.Size(e)
 	}
+	return client.CompressedSize(r.cfg.Compression, b)
 }
 
-func (r *Relay) forwardToPeers(ctx context.Context, peers []storev1.EntityStoreServiceClient, event *storev1.EntityEvent) {
-	// Echo suppression: skip events that originated from this node.
-	if r.cfg.NodeID != "" && event.OriginNode == r.cfg.NodeID {
-		return
+// forward sends event to one peer; see forwardBatch.
+func (r *Relay) forward(ctx context.Context, addr string, peer storev1.EntityStoreServiceClient, event *storev1.EntityEvent) error {
+	return r.forwardBatch(ctx, addr, peer, []*storev1.EntityEvent{event})
+}
+
+// forwardBatch sends events to one peer in one ReplicateBatch call, less
+// those that are echoes or over the bandwidth budget. It fails only if the
+// peer could not be reached, for the peer's watch or batch to send the
+// events again; any other failure is logged and counted, and the event
+// skipped.
+func (r *Relay) forwardBatch(ctx context.Context, addr string, peer storev1.EntityStoreServiceClient, events []*storev1.EntityEvent) error {
+	var (
+		out   []*storev1.EntityEvent
+		sizes []int
+	)
+	for _, event := range events {
+		if e, size, ok := r.prepare(addr, event); ok {
+			out = append(out, e)
+			sizes = append(sizes, size)
+		}
+	}
+	if len(out) == 0 {
+		return nil
 	}
 
-	// Budget check: if a token bucket is configured, check the budget.
-	if r.bucket != nil {
-		size := 0
-		if event.Entity != nil {
-			size = proto.Size(event.Entity)
+	errs, err := r.replicate(ctx, peer, out)
+	if err != nil {
+		r.mu.Lock()
+		r.stats.Errors += len(out)
+		r.mu.Unlock()
+		errorsTotal.Add(float64(len(out)))
+		if code := status.Code(err); code == codes.Unavailable || code == codes.DeadlineExceeded {
+			return fmt.Errorf("peer unreachable: %w", err)
 		}
-		priority := EventPriority(event)
-		if !r.bucket.Allow(size, priority) {
-			r.mu.Lock()
-			r.stats.Dropped++
-			r.mu.Unlock()
-			slog.Debug("mesh-relay budget drop", "entity", event.Entity.GetId(), "priority", priority, "size", size)
-			return
+		slog.Error("mesh-relay forward failed", "peer", addr, "events", len(out), "error", err)
+		return nil
+	}
+	r.mu.Lock()
+	t := r.trafficLocked(addr)
+	for i, err := range errs {
+		if err != nil {
+			r.stats.Errors++
+			continue
+		}
+		r.stats.Forwarded++
+		t.Forwarded++
+		t.SentBytes += int64(sizes[i])
+	}
+	r.mu.Unlock()
+	for i, err := range errs {
+		if err != nil {
+			errorsTotal.Inc()
+			slog.Error("mesh-relay forward failed", "peer", addr, "entity", out[i].Entity.GetId(), "error", err)
+		} else {
+			forwardedTotal.Inc()
 		}
 	}
+	return nil
+}
 
-	for i, peer := range peers {
-		if err := r.forwardEvent(ctx, peer, event); err != nil {
-			slog.Error("mesh-relay forward failed", "peer_index", i, "error", err)
+// prepare readies event for the peer at addr: it reports false for an
+// echo, an event gossip does not send there, one the replication policy
+// keeps from peers, or one over the bandwidth budget, and otherwise returns
+// the event as sent, with the size it takes on the wire.
+//
+// Events sent carry their origin, or this node for a local write, so the
+// relay back on the origin node sees the peers' copies of them as its own
+// and does not send them round again; one more hop; and their path with
+// this node added, so no relay on it sends them round again either.
+func (r *Relay) prepare(addr string, event *storev1.EntityEvent) (*storev1.EntityEvent, int, bool) {
+	// Echo suppression: skip events that originated from this node, or
+	// that its relay has passed on already, which have come back round a
+	// loop of three or more nodes.
+	if r.cfg.NodeID != "" && (event.OriginNode == r.cfg.NodeID || slices.Contains(event.Path, r.cfg.NodeID)) {
+		return nil, 0, false
+	}
+	if r.cfg.GossipFanout > 0 && !r.gossips(addr, event) {
+		return nil, 0, false
+	}
+	entity := event.Entity
+	if !watch.Removed(event) {
+		if !r.cfg.Policy.admits(entity) {
 			r.mu.Lock()
-			r.stats.Errors++
+			r.stats.Filtered++
 			r.mu.Unlock()
-		} else {
+			filteredTotal.Inc()
+			return nil, 0, false
+		}
+		entity = r.cfg.Policy.strip(entity)
+	}
+
+	// Budget check: if a token bucket is configured, check the budget,
+	// in the bytes the entity takes on the wire. Each peer's copy counts,
+	// against its link's budget if it has one.
+	size := 0
+	if entity != nil {
+		size = r.wireSize(entity)
+	}
+	if bucket, _ := r.budget(addr); bucket != nil {
+		priority := EventPriority(event)
+		if !bucket.Allow(size, priority) {
 			r.mu.Lock()
-			r.stats.Forwarded++
+			r.stats.Dropped++
+			r.trafficLocked(addr).Dropped++
 			r.mu.Unlock()
+			droppedTotal.Inc(strconv.Itoa(priority))
+			slog.Debug("mesh-relay budget drop", "entity", entity.GetId(), "priority", priority, "size", size)
+			return nil, 0, false
+		}
+	}
+
+	out := &storev1.EntityEvent{Type: event.Type, Entity: entity, OriginNode: event.OriginNode, Hops: event.Hops + 1}
+	if out.OriginNode == "" {
+		out.OriginNode = r.cfg.NodeID
+	}
+	if r.cfg.NodeID != "" {
+		out.Path = append(slices.Clone(event.Path), r.cfg.NodeID)
+	}
+	return out, size, true
+}
+
+// replicate applies events to peer with one ReplicateBatch call, returning
+// each event's error, nil once applied or if the peer had no use for it.
+// A peer whose store predates ReplicateBatch is sent each event with the
+// calls of the public API instead. It fails only if the call, or one of
+// those, does.
+func (r *Relay) replicate(ctx context.Context, peer storev1.EntityStoreServiceClient, events []*storev1.EntityEvent) ([]error, error) {
+	errs := make([]error, len(events))
+	// Under gossip, a write the peer already has is not written again, so
+	// it spreads no further from there.
+	resp, err := peer.ReplicateBatch(ctx, &storev1.ReplicateBatchRequest{Events: events, SkipUnchanged: r.cfg.GossipFanout > 0})
+	if status.Code(err) == codes.Unimplemented {
+		for i, event := range events {
+			ctx := server.ContextWithHops(server.ContextWithOrigin(ctx, event.OriginNode), event.Hops)
+			if len(event.Path) > 0 {
+				ctx = server.ContextWithPath(ctx, event.Path)
+			}
+			errs[i] = r.forwardEvent(ctx, peer, event)
+			if code := status.Code(errs[i]); code == codes.Unavailable || code == codes.DeadlineExceeded {
+				return nil, errs[i]
+			}
+		}
+		return errs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Results) != len(events) {
+		return nil, fmt.Errorf("replicate %d events: got %d results", len(events), len(resp.Results))
+	}
+	for i, res := range resp.Results {
+		switch code := codes.Code(res.Code); code {
+		case codes.OK, codes.FailedPrecondition, codes.NotFound:
+			// FailedPrecondition: the peer deleted the entity since;
+			// NotFound: a delete of one it never had.
+		default:
+			errs[i] = status.Error(code, res.Message)
 		}
 	}
+	if resp.Merged > 0 {
+		r.mu.Lock()
+		r.stats.Merged += int(resp.Merged)
+		r.mu.Unlock()
+		mergedTotal.Add(float64(resp.Merged))
+	}
+	return errs, nil
+}
+
+// budget returns the token bucket the peer at addr spends, nil if
+// unlimited, and whether it is shared with the other peers without a Link.
+func (r *Relay) budget(addr string) (bucket *TokenBucket, shared bool) {
+	if b, ok := r.buckets[addr]; ok {
+		return b, false
+	}
+	if _, ok := r.cfg.Links[addr]; ok {
+		return nil, false
+	}
+	return r.bucket, r.bucket != nil
+}
+
+// trafficLocked returns the counts of what was sent to the peer at addr.
+// Must hold mu.
+func (r *Relay) trafficLocked(addr string) *Traffic {
+	t, ok := r.traffic[addr]
+	if !ok {
+		t = &Traffic{}
+		r.traffic[addr] = t
+	}
+	return t
 }
 
+// defaultGossipHops is how far a gossiped event travels when
+// Config.GossipHops is 0: enough for a mesh of thousands at a fanout of 3.
+const defaultGossipHops = 6
+
+// gossips reports whether event goes to the peer at addr: whether it is
+// still short of its last hop, and addr is among the GossipFanout peers
+// picked for it. The pick is the peers ranked highest by a hash of the
+// relay's seed, the event, and their address, so each peer's watch makes
+// the same one without coordinating, and each event a different one.
+func (r *Relay) gossips(addr string, event *storev1.EntityEvent) bool {
+	hops := r.cfg.GossipHops
+	if hops == 0 {
+		hops = defaultGossipHops
+	}
+	if event.Hops >= uint32(hops) {
+		return false
+	}
+	addrs := *r.addrs.Load()
+	if len(addrs) <= r.cfg.GossipFanout {
+		return true
+	}
+	mine := r.rank(addr, event.Sequence)
+	above := 0
+	for _, a := range addrs {
+		if rank := r.rank(a, event.Sequence); rank > mine || rank == mine && a > addr {
+			above++
+		}
+	}
+	return above < r.cfg.GossipFanout
+}
+
+// rank is the peer at addr's rank for event seq. FNV alone mixes the last
+// bytes hashed into few of the high bits, and addresses often differ only
+// there, so the hash is finished with splitmix64's mixer.
+func (r *Relay) rank(addr string, seq uint64) uint64 {
+	h := fnv.New64a()
+	h.Write(binary.LittleEndian.AppendUint64(binary.LittleEndian.AppendUint64(nil, r.seed), seq))
+	h.Write([]byte(addr))
+	x := h.Sum64()
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	return x ^ (x >> 31)
+}
+
+// forwardEvent applies one event to a peer with the calls of its public
+// API, for stores that predate ReplicateBatch.
 func (r *Relay) forwardEvent(ctx context.Context, peer storev1.EntityStoreServiceClient, event *storev1.EntityEvent) error {
 	entity := event.Entity
 
@@ -173,22 +990,27 @@ func (r *Relay) forwardEvent(ctx context.Context, peer storev1.EntityStoreServic
 	case storev1.EventType_EVENT_TYPE_CREATED:
 		// Try create first.
 		_, err := peer.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity})
-		if err != nil {
-			if status.Code(err) == codes.AlreadyExists {
-				// Entity exists on peer — merge.
-				return r.mergeAndUpdate(ctx, peer, entity)
-			}
-			return err
+		switch status.Code(err) {
+		case codes.OK, codes.FailedPrecondition:
+			return nil // FailedPrecondition: the peer deleted it since
+		case codes.AlreadyExists:
+			// Entity exists on peer — merge.
+			return r.mergeAndUpdate(ctx, peer, entity)
 		}
-		return nil
+		return err
 
 	case storev1.EventType_EVENT_TYPE_UPDATED:
 		// Always merge for updates.
 		return r.mergeAndUpdate(ctx, peer, entity)
 
-	case storev1.EventType_EVENT_TYPE_DELETED:
-		// Delete, ignore NotFound.
-		_, err := peer.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: entity.Id})
+	case storev1.EventType_EVENT_TYPE_DELETED, storev1.EventType_EVENT_TYPE_EXPIRED:
+		// Replicate the delete with its HLC, so the peer keeps a tombstone
+		// and a newer write on the peer survives it. Ignore NotFound.
+		req := &storev1.DeleteEntityRequest{Id: entity.Id}
+		if entity.HlcPhysical != 0 {
+			req.Hlc = &entityv1.HLCTimestamp{Physical: entity.HlcPhysical, Logical: entity.HlcLogical, Node: entity.HlcNode}
+		}
+		_, err := peer.DeleteEntity(ctx, req)
 		if err != nil && status.Code(err) != codes.NotFound {
 			return err
 		}
@@ -206,21 +1028,30 @@ func (r *Relay) mergeAndUpdate(ctx context.Context, peer storev1.EntityStoreServ
 	existing, err := peer.GetEntity(ctx, &storev1.GetEntityRequest{Id: incoming.Id})
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
-			// Peer doesn't have it — create.
+			// Peer doesn't have it — create, unless it deleted this version.
 			_, createErr := peer.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: incoming})
+			if status.Code(createErr) == codes.FailedPrecondition {
+				return nil
+			}
 			return createErr
 		}
 		return err
 	}
 
 	// MERGE using CRDT strategies (LWW per-component, max-wins for threat).
-	merged := crdt.MergeEntity(existing, incoming)
+	merged, report := r.cfg.Strategies.MergeEntityReport(existing, incoming)
 	merged.Id = incoming.Id
 	merged.Type = incoming.Type
-	merged.CreatedAt = existing.CreatedAt
+
+	// A gossiped write the peer already has is not written again, so it
+	// spreads no further from there.
+	if r.cfg.GossipFanout > 0 && sameContent(existing, merged) {
+		return nil
+	}
 
 	// PUT merged result.
-	_, err = peer.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: merged})
+	logMerge(merged, report)
+	_, err = peer.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: merged, Merge: mergeDecisions(report, crdt.SideA, nil)})
 	if err != nil {
 		return err
 	}
@@ -228,6 +1059,7 @@ func (r *Relay) mergeAndUpdate(ctx context.Context, peer storev1.EntityStoreServ
 	r.mu.Lock()
 	r.stats.Merged++
 	r.mu.Unlock()
+	mergedTotal.Inc()
 
 	return nil
 }
@@ -2,16 +2,26 @@ package mesh
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/audit"
+	"github.com/boshu2/lattice-lab/internal/client"
 	"github.com/boshu2/lattice-lab/internal/server"
 	"github.com/boshu2/lattice-lab/internal/store"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 )
 
@@ -105,6 +115,43 @@ func TestRelayForwardCreate(t *testing.T) {
 	}
 }
 
+func TestRelay_InitialSync(t *testing.T) {
+	localAddr, localCleanup := startTestServer(t)
+	defer localCleanup()
+	peerAddr, peerCleanup := startTestServer(t)
+	defer peerCleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	localConn, _ := grpc.NewClient(localAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer localConn.Close()
+	localClient := storev1.NewEntityStoreServiceClient(localConn)
+	peerConn, _ := grpc.NewClient(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer peerConn.Close()
+	peerClient := storev1.NewEntityStoreServiceClient(peerConn)
+
+	// Written before the relay starts, so only the sync can carry it.
+	before, err := localClient.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "pre-existing", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	})
+	if err != nil {
+		t.Fatalf("create on local: %v", err)
+	}
+
+	relay := New(Config{LocalAddr: localAddr, Peers: []string{peerAddr}, InitialSync: true})
+	go relay.Run(ctx) //nolint:errcheck
+	time.Sleep(300 * time.Millisecond)
+
+	got, err := peerClient.GetEntity(ctx, &storev1.GetEntityRequest{Id: "pre-existing"})
+	if err != nil {
+		t.Fatalf("expected pre-existing entity synced to peer: %v", err)
+	}
+	if got.HlcPhysical != before.HlcPhysical || got.HlcNode != before.HlcNode {
+		t.Fatalf("expected local HLC kept, got %v", got)
+	}
+}
+
 func TestRelayForwardDelete(t *testing.T) {
 	localAddr, localCleanup := startTestServer(t)
 	defer localCleanup()
@@ -159,6 +206,55 @@ func TestRelayForwardDelete(t *testing.T) {
 	}
 }
 
+func TestRelay_TombstoneBlocksStaleUpdate(t *testing.T) {
+	peerAddr, peerCleanup := startTestServer(t)
+	defer peerCleanup()
+
+	peerConn, err := grpc.NewClient(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial peer: %v", err)
+	}
+	defer peerConn.Close()
+	peerClient := storev1.NewEntityStoreServiceClient(peerConn)
+	ctx := context.Background()
+	relay := New(Config{Peers: []string{peerAddr}})
+
+	created, err := peerClient.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "tomb-1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	})
+	if err != nil {
+		t.Fatalf("create on peer: %v", err)
+	}
+	stale := proto.Clone(created).(*entityv1.Entity)
+	if _, err := peerClient.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: "tomb-1"}); err != nil {
+		t.Fatalf("delete on peer: %v", err)
+	}
+
+	// An update written before the delete, relayed after it, as from a
+	// node that was partitioned.
+	relay.forward(ctx, "", peerClient, &storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_UPDATED, Entity: stale})
+	relay.forward(ctx, "", peerClient, &storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_CREATED, Entity: stale})
+	if _, err := peerClient.GetEntity(ctx, &storev1.GetEntityRequest{Id: "tomb-1"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected the deleted entity to stay deleted, got %v", err)
+	}
+	if stats := relay.GetStats(); stats.Errors != 0 {
+		t.Fatalf("expected refused stale copies not to count as errors, got %d", stats.Errors)
+	}
+
+	// A delete relayed to a peer that never had the entity leaves a
+	// tombstone there too.
+	ts := stale.HlcPhysical + uint64(time.Second)
+	relay.forward(ctx, "", peerClient, &storev1.EntityEvent{
+		Type:   storev1.EventType_EVENT_TYPE_DELETED,
+		Entity: &entityv1.Entity{Id: "tomb-2", HlcPhysical: ts, HlcNode: "node-B"},
+	})
+	older := &entityv1.Entity{Id: "tomb-2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, HlcPhysical: ts - 1, HlcNode: "node-B"}
+	relay.forward(ctx, "", peerClient, &storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_UPDATED, Entity: older})
+	if _, err := peerClient.GetEntity(ctx, &storev1.GetEntityRequest{Id: "tomb-2"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected the relayed tombstone to refuse an older copy, got %v", err)
+	}
+}
+
 func TestRelayForwardUpdate(t *testing.T) {
 	localAddr, localCleanup := startTestServer(t)
 	defer localCleanup()
@@ -294,6 +390,15 @@ func TestDefaultConfig(t *testing.T) {
 	if cfg.LocalAddr != "localhost:50051" {
 		t.Fatalf("expected localhost:50051, got %s", cfg.LocalAddr)
 	}
+	if cfg.AntiEntropy != time.Minute {
+		t.Fatalf("expected anti-entropy every minute, got %v", cfg.AntiEntropy)
+	}
+	if cfg.DiscoveryInterval != 30*time.Second {
+		t.Fatalf("expected discovery every 30s, got %v", cfg.DiscoveryInterval)
+	}
+	if cfg.Heartbeat != 5*time.Second {
+		t.Fatalf("expected a heartbeat every 5s, got %v", cfg.Heartbeat)
+	}
 }
 
 func TestRelay_EchoSuppression(t *testing.T) {
@@ -310,7 +415,7 @@ func TestRelay_EchoSuppression(t *testing.T) {
 		NodeID:    "node-A",
 	})
 
-	// Directly test forwardToPeers with an event that has matching origin_node.
+	// Directly test forward with an event that has matching origin_node.
 	// The relay should suppress it (not forward to peer).
 	peerConn, err := grpc.NewClient(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
@@ -328,7 +433,7 @@ func TestRelay_EchoSuppression(t *testing.T) {
 		OriginNode: "node-A", // Same as relay's NodeID — should be suppressed
 	}
 
-	relay.forwardToPeers(context.Background(), []storev1.EntityStoreServiceClient{peerClient}, event)
+	relay.forward(context.Background(), "", peerClient, event)
 
 	// Entity should NOT exist on peer because it was suppressed.
 	_, err = peerClient.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "echo-test-1"})
@@ -372,7 +477,7 @@ func TestRelay_ForwardsNonLocalEvents(t *testing.T) {
 		OriginNode: "node-B", // Different from relay's NodeID — should forward
 	}
 
-	relay.forwardToPeers(context.Background(), []storev1.EntityStoreServiceClient{peerClient}, event)
+	relay.forward(context.Background(), "", peerClient, event)
 
 	// Entity should exist on peer because it was forwarded.
 	got, err := peerClient.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "nonlocal-test-1"})
@@ -459,7 +564,7 @@ func TestRelay_MergeOnConflict(t *testing.T) {
 		OriginNode: "node-B",
 	}
 
-	relay.forwardToPeers(ctx, []storev1.EntityStoreServiceClient{peerClient}, event)
+	relay.forward(ctx, "", peerClient, event)
 
 	// Verify merged result on peer.
 	got, err := peerClient.GetEntity(ctx, &storev1.GetEntityRequest{Id: "merge-test-1"})
@@ -538,7 +643,7 @@ func TestRelay_MergeStats(t *testing.T) {
 		OriginNode: "node-B",
 	}
 
-	relay.forwardToPeers(ctx, []storev1.EntityStoreServiceClient{peerClient}, event)
+	relay.forward(ctx, "", peerClient, event)
 
 	stats := relay.GetStats()
 	if stats.Forwarded != 1 {
@@ -548,3 +653,959 @@ func TestRelay_MergeStats(t *testing.T) {
 		t.Fatalf("expected 1 merged, got %d", stats.Merged)
 	}
 }
+
+func TestRelay_Compression(t *testing.T) {
+	for _, name := range client.Compressors {
+		t.Run(name, func(t *testing.T) {
+			localAddr, localCleanup := startTestServer(t)
+			defer localCleanup()
+			peerAddr, peerCleanup := startTestServer(t)
+			defer peerCleanup()
+
+			relay := New(Config{LocalAddr: localAddr, Peers: []string{peerAddr}, Compression: name})
+			ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+			defer cancel()
+			go relay.Run(ctx) //nolint:errcheck
+			time.Sleep(100 * time.Millisecond)
+
+			localConn, err := grpc.NewClient(localAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				t.Fatalf("dial local: %v", err)
+			}
+			defer localConn.Close()
+			pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 38.9, Lon: -77})
+			if _, err := storev1.NewEntityStoreServiceClient(localConn).CreateEntity(ctx, &storev1.CreateEntityRequest{
+				Entity: &entityv1.Entity{Id: "z1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos}},
+			}); err != nil {
+				t.Fatalf("create on local: %v", err)
+			}
+			time.Sleep(500 * time.Millisecond)
+
+			peerConn, err := grpc.NewClient(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+			if err != nil {
+				t.Fatalf("dial peer: %v", err)
+			}
+			defer peerConn.Close()
+			got, err := storev1.NewEntityStoreServiceClient(peerConn).GetEntity(ctx, &storev1.GetEntityRequest{Id: "z1"})
+			if err != nil {
+				t.Fatalf("get on peer: %v", err)
+			}
+			if got.Components["position"] == nil {
+				t.Fatal("expected the position to arrive")
+			}
+		})
+	}
+}
+
+func TestRelay_BudgetCountsCompressedBytes(t *testing.T) {
+	e := &entityv1.Entity{Id: "z1", Labels: map[string]string{}}
+	for i := range 50 {
+		e.Labels[strconv.Itoa(i)] = "exercise-bravo-side-blue"
+	}
+	plain := New(Config{}).wireSize(e)
+	compressed := New(Config{Compression: "gzip"}).wireSize(e)
+	if plain != proto.Size(e) || compressed >= plain/2 {
+		t.Fatalf("expected gzip well under %d bytes, got %d", plain, compressed)
+	}
+}
+
+func TestRelay_StampsOrigin(t *testing.T) {
+	// The peer runs the interceptors that take a write's origin.
+	peerStore := store.New()
+	api := server.New(peerStore)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(api.UnaryInterceptor()), grpc.StreamInterceptor(api.StreamInterceptor()))
+	storev1.RegisterEntityStoreServiceServer(srv, api)
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+	defer srv.Stop()
+
+	peerConn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial peer: %v", err)
+	}
+	defer peerConn.Close()
+	peerClient := storev1.NewEntityStoreServiceClient(peerConn)
+
+	w := peerStore.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	defer peerStore.Unwatch(w)
+
+	relay := New(Config{NodeID: "node-A"})
+	for _, tc := range []struct{ id, origin, want string }{
+		{"local-1", "", "node-A"},         // a local write: from this node
+		{"relayed-1", "node-C", "node-C"}, // relayed in: keeps its origin
+	} {
+		relay.forward(context.Background(), "", peerClient, &storev1.EntityEvent{
+			Type:       storev1.EventType_EVENT_TYPE_CREATED,
+			Entity:     &entityv1.Entity{Id: tc.id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+			OriginNode: tc.origin,
+		})
+		ev := <-w.Events
+		if ev.Entity.Id != tc.id || ev.OriginNode != tc.want {
+			t.Fatalf("expected %s from %q, got %s from %q", tc.id, tc.want, ev.Entity.Id, ev.OriginNode)
+		}
+		// The peer's copy, relayed back, is recognized as an echo.
+		if tc.want == "node-A" {
+			relay.forward(context.Background(), "", peerClient, ev)
+			if got := relay.GetStats().Forwarded; got != 1 {
+				t.Fatalf("expected the echo to be suppressed, forwarded %d", got)
+			}
+		}
+	}
+}
+
+func TestRelay_AntiEntropy(t *testing.T) {
+	localAddr, localCleanup := startTestServer(t)
+	defer localCleanup()
+	peerAddr, peerCleanup := startTestServer(t)
+	defer peerCleanup()
+
+	ctx := context.Background()
+	localConn, _ := grpc.NewClient(localAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer localConn.Close()
+	localClient := storev1.NewEntityStoreServiceClient(localConn)
+	peerConn, _ := grpc.NewClient(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer peerConn.Close()
+	peerClient := storev1.NewEntityStoreServiceClient(peerConn)
+
+	// Written to each side while no relay ran: one entity each, and one on
+	// both, HIGH locally and LOW with a position on the peer.
+	threatHigh, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	threatLow, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_LOW})
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 10, Lon: 20})
+	for _, w := range []struct {
+		c storev1.EntityStoreServiceClient
+		e *entityv1.Entity
+	}{
+		{localClient, &entityv1.Entity{Id: "only-local", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}},
+		{peerClient, &entityv1.Entity{Id: "only-peer", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}},
+		{localClient, &entityv1.Entity{Id: "both", Type: entityv1.EntityType_ENTITY_TYPE_TRACK,
+			Components: map[string]*anypb.Any{"threat": threatHigh}}},
+		{peerClient, &entityv1.Entity{Id: "both", Type: entityv1.EntityType_ENTITY_TYPE_TRACK,
+			Components: map[string]*anypb.Any{"threat": threatLow, "position": pos}}},
+	} {
+		if _, err := w.c.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: w.e}); err != nil {
+			t.Fatalf("create %s: %v", w.e.Id, err)
+		}
+	}
+
+	relay := New(Config{LocalAddr: localAddr, Peers: []string{peerAddr}, NodeID: "node-A"})
+	if err := relay.reconcile(ctx, peerAddr, localClient, peerClient); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	for name, c := range map[string]storev1.EntityStoreServiceClient{"local": localClient, "peer": peerClient} {
+		for _, id := range []string{"only-local", "only-peer"} {
+			if _, err := c.GetEntity(ctx, &storev1.GetEntityRequest{Id: id}); err != nil {
+				t.Fatalf("%s: expected %s: %v", name, id, err)
+			}
+		}
+		got, err := c.GetEntity(ctx, &storev1.GetEntityRequest{Id: "both"})
+		if err != nil {
+			t.Fatalf("%s: get both: %v", name, err)
+		}
+		var threat entityv1.ThreatComponent
+		if err := got.Components["threat"].UnmarshalTo(&threat); err != nil {
+			t.Fatalf("%s: unmarshal threat: %v", name, err)
+		}
+		if threat.Level != entityv1.ThreatLevel_THREAT_LEVEL_HIGH || got.Components["position"] == nil {
+			t.Fatalf("%s: expected HIGH threat and the peer's position, got %v", name, got.Components)
+		}
+	}
+	if got := relay.GetStats().Repaired; got != 4 {
+		t.Fatalf("expected 4 repairs, got %d", got)
+	}
+	// Three entities diverged; both's threat conflicted and went to the
+	// higher level, while the position only the peer had is no conflict.
+	conv := relay.GetStats().Convergence[peerAddr]
+	if conv.Diverged != 3 || conv.ResolvedMaxWins != 1 || conv.ResolvedLWW != 0 || conv.At.IsZero() {
+		t.Fatalf("expected 3 diverged and 1 max-wins conflict, got %+v", conv)
+	}
+
+	// Converged stores differ only in HLCs, so another pass writes nothing
+	// and finds nothing diverged.
+	if err := relay.reconcile(ctx, peerAddr, localClient, peerClient); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	st := relay.GetStats()
+	if st.Repaired != 4 {
+		t.Fatalf("expected no repairs once converged, got %d", st.Repaired-4)
+	}
+	if conv := st.Convergence[peerAddr]; conv.Diverged != 0 || conv.ResolvedMaxWins != 0 {
+		t.Fatalf("expected nothing diverged once converged, got %+v", conv)
+	}
+	if st.Diverged != 3 || st.ResolvedMaxWins != 1 {
+		t.Fatalf("expected totals of 3 diverged and 1 max-wins, got %d and %d", st.Diverged, st.ResolvedMaxWins)
+	}
+	if peers := relay.ListPeers(); peers[0].Convergence != st.Convergence[peerAddr] {
+		t.Fatalf("expected ListPeers to report the last pass, got %+v", peers[0].Convergence)
+	}
+}
+
+func TestRelay_AntiEntropyAuditsMerges(t *testing.T) {
+	serve := func(log *audit.Log) storev1.EntityStoreServiceClient {
+		t.Helper()
+		srv := server.New(store.New(), server.WithAudit(log))
+		g := grpc.NewServer(grpc.UnaryInterceptor(srv.UnaryInterceptor()))
+		storev1.RegisterEntityStoreServiceServer(g, srv)
+		lis, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		go g.Serve(lis) //nolint:errcheck
+		t.Cleanup(g.Stop)
+		conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return storev1.NewEntityStoreServiceClient(conn)
+	}
+	localLog, peerLog := audit.New(audit.DefaultSize), audit.New(audit.DefaultSize)
+	localClient, peerClient := serve(localLog), serve(peerLog)
+
+	ctx := context.Background()
+	threatHigh, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	threatLow, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_LOW})
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 10, Lon: 20})
+	if _, err := localClient.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "both", Type: entityv1.EntityType_ENTITY_TYPE_TRACK,
+		Components: map[string]*anypb.Any{"threat": threatHigh}}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := peerClient.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "both", Type: entityv1.EntityType_ENTITY_TYPE_TRACK,
+		Components: map[string]*anypb.Any{"threat": threatLow, "position": pos}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	relay := New(Config{LocalAddr: "local", Peers: []string{"peer"}, NodeID: "node-A"})
+	if err := relay.reconcile(ctx, "peer", localClient, peerClient); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	// Each store's log says which of the merged values were its own.
+	for name, tc := range map[string]struct {
+		log  *audit.Log
+		want []*storev1.MergeDecision
+	}{
+		"local": {localLog, []*storev1.MergeDecision{
+			{Key: "position", Kept: "incoming"},
+			{Key: "threat", Kept: "stored", Strategy: "max-wins"},
+		}},
+		"peer": {peerLog, []*storev1.MergeDecision{
+			{Key: "position", Kept: "stored"},
+			{Key: "threat", Kept: "incoming", Strategy: "max-wins"},
+		}},
+	} {
+		entries := tc.log.Entries("both", 0)
+		update := entries[len(entries)-1]
+		if update.Method != storev1.EntityStoreService_UpdateEntity_FullMethodName ||
+			!slices.EqualFunc(update.Merge, tc.want, func(a, b *storev1.MergeDecision) bool { return proto.Equal(a, b) }) {
+			t.Fatalf("%s: expected the merge %v audited, got %v", name, tc.want, update)
+		}
+	}
+}
+
+// legacyStore is a store server from before DigestEntities and
+// ReplicateBatch.
+type legacyStore struct{ *server.Server }
+
+func (legacyStore) DigestEntities(context.Context, *storev1.DigestEntitiesRequest) (*storev1.DigestEntitiesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "unknown method DigestEntities")
+}
+
+func (legacyStore) ReplicateBatch(context.Context, *storev1.ReplicateBatchRequest) (*storev1.ReplicateBatchResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "unknown method ReplicateBatch")
+}
+
+func TestRelay_AntiEntropyWithoutDigests(t *testing.T) {
+	localAddr, localCleanup := startTestServer(t)
+	defer localCleanup()
+
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, legacyStore{server.New(store.New())})
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+	defer srv.Stop()
+
+	ctx := context.Background()
+	localConn, _ := grpc.NewClient(localAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer localConn.Close()
+	localClient := storev1.NewEntityStoreServiceClient(localConn)
+	peerConn, _ := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer peerConn.Close()
+	peerClient := storev1.NewEntityStoreServiceClient(peerConn)
+
+	if _, err := peerClient.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "only-peer", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	}); err != nil {
+		t.Fatalf("create on peer: %v", err)
+	}
+
+	// The peer cannot digest, so the relay lists both stores instead.
+	relay := New(Config{LocalAddr: localAddr, Peers: []string{lis.Addr().String()}, NodeID: "node-A"})
+	if err := relay.reconcile(ctx, lis.Addr().String(), localClient, peerClient); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if _, err := localClient.GetEntity(ctx, &storev1.GetEntityRequest{Id: "only-peer"}); err != nil {
+		t.Fatalf("expected only-peer created locally: %v", err)
+	}
+}
+
+func TestRelay_ForwardBatch(t *testing.T) {
+	for _, legacy := range []bool{false, true} {
+		t.Run(fmt.Sprintf("legacy=%v", legacy), func(t *testing.T) {
+			peerStore := store.New()
+			base := server.New(peerStore)
+			var svc storev1.EntityStoreServiceServer = base
+			if legacy {
+				svc = legacyStore{base}
+			}
+			srv := grpc.NewServer(grpc.UnaryInterceptor(base.UnaryInterceptor()))
+			storev1.RegisterEntityStoreServiceServer(srv, svc)
+			lis, err := net.Listen("tcp", "localhost:0")
+			if err != nil {
+				t.Fatalf("listen: %v", err)
+			}
+			go srv.Serve(lis) //nolint:errcheck
+			defer srv.Stop()
+			conn, _ := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+			defer conn.Close()
+			peer := storev1.NewEntityStoreServiceClient(conn)
+
+			ctx := context.Background()
+			if _, err := peerStore.Create(&entityv1.Entity{Id: "both", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := peerStore.Create(&entityv1.Entity{Id: "doomed", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}); err != nil {
+				t.Fatal(err)
+			}
+			w := peerStore.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+			defer peerStore.Unwatch(w)
+
+			pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1})
+			now := uint64(time.Now().UnixNano())
+			track := func(id string) *entityv1.Entity {
+				return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos},
+					HlcPhysical: now, HlcNode: "node-B"}
+			}
+			relay := New(Config{NodeID: "node-A"})
+			if err := relay.forwardBatch(ctx, "", peer, []*storev1.EntityEvent{
+				{Type: storev1.EventType_EVENT_TYPE_UPDATED, Entity: track("both"), OriginNode: "node-B", Hops: 1, Path: []string{"node-B"}},
+				{Type: storev1.EventType_EVENT_TYPE_CREATED, Entity: track("new")},
+				{Type: storev1.EventType_EVENT_TYPE_DELETED, Entity: &entityv1.Entity{Id: "doomed", HlcPhysical: now, HlcNode: "node-B"}},
+				{Type: storev1.EventType_EVENT_TYPE_CREATED, Entity: track("echo"), OriginNode: "node-A"},
+			}); err != nil {
+				t.Fatalf("forwardBatch: %v", err)
+			}
+
+			stats := relay.GetStats()
+			if stats.Forwarded != 3 || stats.Merged != 1 || stats.Errors != 0 {
+				t.Fatalf("expected 3 forwarded, 1 merged, no errors, got %+v", stats)
+			}
+			if e, err := peerStore.Get("both"); err != nil || e.Components["position"] == nil {
+				t.Fatalf("expected both merged with the position, got %v (%v)", e, err)
+			}
+			if _, err := peerStore.Get("doomed"); err == nil {
+				t.Fatal("expected doomed deleted")
+			}
+			if _, err := peerStore.Get("echo"); err == nil {
+				t.Fatal("expected the echo not forwarded")
+			}
+			// Each write carries its own event's origin, hops, and path.
+			want := map[string][]string{"both": {"node-B", "node-A"}, "new": {"node-A"}, "doomed": {"node-A"}}
+			for range want {
+				ev := <-w.Events
+				path := want[ev.Entity.Id]
+				if ev.OriginNode != path[0] || !slices.Equal(ev.Path, path) {
+					t.Fatalf("expected %s's event from %s with path %v, got %q, %v", ev.Entity.Id, path[0], path, ev.OriginNode, ev.Path)
+				}
+			}
+		})
+	}
+}
+
+func TestRelay_ResumesAfterPeerOutage(t *testing.T) {
+	localAddr, localCleanup := startTestServer(t)
+	defer localCleanup()
+
+	// The peer's store outlives its server, which goes down and comes back
+	// on the same address.
+	peerStore := store.New()
+	servePeer := func(addr string) (string, func()) {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		srv := grpc.NewServer()
+		storev1.RegisterEntityStoreServiceServer(srv, server.New(peerStore))
+		go srv.Serve(lis) //nolint:errcheck
+		return lis.Addr().String(), srv.Stop
+	}
+	peerAddr, stopPeer := servePeer("localhost:0")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	// No anti-entropy: only the resumed watch can carry the missed writes.
+	relay := New(Config{LocalAddr: localAddr, Peers: []string{peerAddr}})
+	done := make(chan error, 1)
+	go func() { done <- relay.Run(ctx) }()
+
+	localConn, _ := grpc.NewClient(localAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer localConn.Close()
+	localClient := storev1.NewEntityStoreServiceClient(localConn)
+	create := func(id string) {
+		t.Helper()
+		if _, err := localClient.CreateEntity(ctx, &storev1.CreateEntityRequest{
+			Entity: &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+		}); err != nil {
+			t.Fatalf("create %s: %v", id, err)
+		}
+	}
+	waitPeer := func(id string) {
+		t.Helper()
+		for ctx.Err() == nil {
+			if _, err := peerStore.Get(id); err == nil {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("%s never reached the peer", id)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	create("before")
+	waitPeer("before")
+
+	stopPeer()
+	create("during-1")
+	create("during-2")
+	time.Sleep(300 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("relay exited with the peer down: %v", err)
+	default:
+	}
+
+	_, stopPeer = servePeer(peerAddr)
+	defer stopPeer()
+	waitPeer("during-1")
+	waitPeer("during-2")
+}
+
+func TestRelay_AddRemovePeer(t *testing.T) {
+	localAddr, localCleanup := startTestServer(t)
+	defer localCleanup()
+	peerAddr, peerCleanup := startTestServer(t)
+	defer peerCleanup()
+
+	// The added peer's store is read directly.
+	addedStore := store.New()
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(addedStore))
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+	defer srv.Stop()
+	addedAddr := lis.Addr().String()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	relay := New(Config{LocalAddr: localAddr, Peers: []string{peerAddr}})
+	go relay.Run(ctx) //nolint:errcheck
+
+	localConn, _ := grpc.NewClient(localAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer localConn.Close()
+	localClient := storev1.NewEntityStoreServiceClient(localConn)
+	peerConn, _ := grpc.NewClient(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer peerConn.Close()
+	peerClient := storev1.NewEntityStoreServiceClient(peerConn)
+	create := func(id string) {
+		t.Helper()
+		if _, err := localClient.CreateEntity(ctx, &storev1.CreateEntityRequest{
+			Entity: &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+		}); err != nil {
+			t.Fatalf("create %s: %v", id, err)
+		}
+	}
+	waitFor := func(what string, has func() bool) {
+		t.Helper()
+		for ctx.Err() == nil {
+			if has() {
+				return
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("%s never happened", what)
+	}
+	onAdded := func(id string) func() bool {
+		return func() bool { _, err := addedStore.Get(id); return err == nil }
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	create("before")
+	if err := relay.AddPeer(addedAddr, true); err != nil {
+		t.Fatalf("AddPeer: %v", err)
+	}
+	if err := relay.AddPeer(addedAddr, false); !errors.Is(err, ErrPeerExists) {
+		t.Fatalf("adding a peer twice: got %v, want ErrPeerExists", err)
+	}
+	waitFor("sync of before", onAdded("before"))
+	create("added")
+	waitFor("forward of added", onAdded("added"))
+
+	peers := relay.ListPeers()
+	if len(peers) != 2 || peers[0].Addr > peers[1].Addr {
+		t.Fatalf("expected both peers by address, got %+v", peers)
+	}
+
+	if err := relay.RemovePeer(addedAddr); err != nil {
+		t.Fatalf("RemovePeer: %v", err)
+	}
+	if err := relay.RemovePeer(addedAddr); !errors.Is(err, ErrNoPeer) {
+		t.Fatalf("removing a peer twice: got %v, want ErrNoPeer", err)
+	}
+	create("removed")
+	waitFor("forward of removed to the remaining peer", func() bool {
+		_, err := peerClient.GetEntity(ctx, &storev1.GetEntityRequest{Id: "removed"})
+		return err == nil
+	})
+	time.Sleep(100 * time.Millisecond)
+	if _, err := addedStore.Get("removed"); err == nil {
+		t.Fatal("expected no forwards to a removed peer")
+	}
+	if peers := relay.ListPeers(); len(peers) != 1 || peers[0].Addr != peerAddr {
+		t.Fatalf("expected only %s left, got %+v", peerAddr, peers)
+	}
+}
+
+func TestRelay_GossipPicksFanoutPeers(t *testing.T) {
+	peers := []string{"a:1", "b:1", "c:1", "d:1", "e:1"}
+	relay := New(Config{Peers: peers, GossipFanout: 2, GossipHops: 3})
+
+	picks := make(map[string]bool)
+	for seq := uint64(1); seq <= 50; seq++ {
+		event := &storev1.EntityEvent{Sequence: seq, Hops: 2}
+		var picked []string
+		for _, addr := range peers {
+			if relay.gossips(addr, event) {
+				picked = append(picked, addr)
+			}
+		}
+		if len(picked) != 2 {
+			t.Fatalf("event %d: expected 2 peers, got %v", seq, picked)
+		}
+		picks[strings.Join(picked, ",")] = true
+
+		event.Hops = 3
+		for _, addr := range peers {
+			if relay.gossips(addr, event) {
+				t.Fatalf("event %d: expected no peers at the last hop, got %s", seq, addr)
+			}
+		}
+	}
+	if len(picks) < 5 {
+		t.Fatalf("expected events spread over different peers, got %v", picks)
+	}
+}
+
+func TestRelay_GossipConverges(t *testing.T) {
+	const nodes, entities = 5, 20
+	addrs := make([]string, nodes)
+	for i := range addrs {
+		addr, cleanup := startTestServer(t)
+		defer cleanup()
+		addrs[i] = addr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	relays := make([]*Relay, nodes)
+	for i, addr := range addrs {
+		relays[i] = New(Config{
+			LocalAddr:    addr,
+			Peers:        slices.Delete(slices.Clone(addrs), i, i+1),
+			NodeID:       fmt.Sprintf("node-%d", i),
+			GossipFanout: 2,
+			AntiEntropy:  200 * time.Millisecond,
+		})
+		go relays[i].Run(ctx) //nolint:errcheck
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	clients := make([]storev1.EntityStoreServiceClient, nodes)
+	for i, addr := range addrs {
+		conn, _ := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		defer conn.Close()
+		clients[i] = storev1.NewEntityStoreServiceClient(conn)
+	}
+	for i := range entities {
+		if _, err := clients[0].CreateEntity(ctx, &storev1.CreateEntityRequest{
+			Entity: &entityv1.Entity{Id: fmt.Sprintf("g%d", i), Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+		}); err != nil {
+			t.Fatalf("create: %v", err)
+		}
+	}
+
+	for i, c := range clients {
+		for ctx.Err() == nil {
+			resp, err := c.ListEntities(ctx, &storev1.ListEntitiesRequest{})
+			if err == nil && len(resp.Entities) == entities {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		if ctx.Err() != nil {
+			t.Fatalf("node-%d never received every entity", i)
+		}
+	}
+	// The writing node sent each of its events to two peers, not four.
+	if got := relays[0].GetStats().Forwarded; got > 2*entities {
+		t.Fatalf("expected at most %d forwards from node-0, got %d", 2*entities, got)
+	}
+}
+
+func TestRelay_OutboxDrainsAfterPeerOutage(t *testing.T) {
+	localAddr, localCleanup := startTestServer(t)
+	defer localCleanup()
+
+	peerStore := store.New()
+	servePeer := func(addr string) (string, func()) {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		srv := grpc.NewServer()
+		storev1.RegisterEntityStoreServiceServer(srv, server.New(peerStore))
+		go srv.Serve(lis) //nolint:errcheck
+		return lis.Addr().String(), srv.Stop
+	}
+	peerAddr, stopPeer := servePeer("localhost:0")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	relay := New(Config{LocalAddr: localAddr, Peers: []string{peerAddr}, OutboxSize: 100})
+	go relay.Run(ctx) //nolint:errcheck
+
+	localConn, _ := grpc.NewClient(localAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer localConn.Close()
+	localClient := storev1.NewEntityStoreServiceClient(localConn)
+	write := func(id string, lat float64) {
+		t.Helper()
+		pos, _ := anypb.New(&entityv1.PositionComponent{Lat: lat})
+		comps := map[string]*anypb.Any{"position": pos}
+		_, err := localClient.PatchComponent(ctx, &storev1.PatchComponentRequest{Id: id, Components: comps})
+		if status.Code(err) == codes.NotFound {
+			_, err = localClient.CreateEntity(ctx, &storev1.CreateEntityRequest{
+				Entity: &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: comps},
+			})
+		}
+		if err != nil {
+			t.Fatalf("write %s: %v", id, err)
+		}
+	}
+	waitPeer := func(id string, lat float64) {
+		t.Helper()
+		for ctx.Err() == nil {
+			if e, err := peerStore.Get(id); err == nil {
+				pos := &entityv1.PositionComponent{}
+				if e.Components["position"].UnmarshalTo(pos) == nil && pos.Lat == lat {
+					return
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		t.Fatalf("%s at %v never reached the peer", id, lat)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	write("before", 1)
+	waitPeer("before", 1)
+
+	stopPeer()
+	for i := range 5 {
+		write("during", float64(i))
+	}
+	write("also", 1)
+	for ctx.Err() == nil && relay.ListPeers()[0].Queued != 2 {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if q := relay.ListPeers()[0].Queued; q != 2 {
+		t.Fatalf("expected 2 entities queued while the peer is down, got %d", q)
+	}
+
+	_, stopPeer = servePeer(peerAddr)
+	defer stopPeer()
+	waitPeer("during", 4)
+	waitPeer("also", 1)
+}
+
+func TestRelay_FlushCoalescesBatch(t *testing.T) {
+	localAddr, localCleanup := startTestServer(t)
+	defer localCleanup()
+	peerAddr, peerCleanup := startTestServer(t)
+	defer peerCleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	relay := New(Config{LocalAddr: localAddr, Peers: []string{peerAddr}, FlushInterval: 500 * time.Millisecond})
+	go relay.Run(ctx) //nolint:errcheck
+	time.Sleep(100 * time.Millisecond)
+
+	localConn, _ := grpc.NewClient(localAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer localConn.Close()
+	localClient := storev1.NewEntityStoreServiceClient(localConn)
+	peerConn, _ := grpc.NewClient(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer peerConn.Close()
+	peerClient := storev1.NewEntityStoreServiceClient(peerConn)
+
+	// A track moving ten times, and another created once.
+	if _, err := localClient.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "moving", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for i := range 10 {
+		pos, _ := anypb.New(&entityv1.PositionComponent{Lat: float64(i)})
+		if _, err := localClient.PatchComponent(ctx, &storev1.PatchComponentRequest{
+			Id: "moving", Components: map[string]*anypb.Any{"position": pos},
+		}); err != nil {
+			t.Fatalf("patch: %v", err)
+		}
+	}
+	if _, err := localClient.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "still", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	for ctx.Err() == nil {
+		e, err := peerClient.GetEntity(ctx, &storev1.GetEntityRequest{Id: "moving"})
+		pos := &entityv1.PositionComponent{}
+		if err == nil && e.Components["position"].UnmarshalTo(pos) == nil && pos.Lat == 9 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, err := peerClient.GetEntity(ctx, &storev1.GetEntityRequest{Id: "still"}); err != nil {
+		t.Fatalf("expected still on the peer: %v", err)
+	}
+	// Twelve events, sent as the latest of each entity per flush.
+	if got := relay.GetStats().Forwarded; got > 4 {
+		t.Fatalf("expected the moving track's updates coalesced, got %d forwards", got)
+	}
+}
+
+func TestRelay_LinksBudgetPerPeer(t *testing.T) {
+	fastAddr, cleanup := startTestServer(t)
+	defer cleanup()
+	relay := New(Config{
+		BandwidthBPS: 1, BurstBytes: 1,
+		Links: map[string]Link{
+			fastAddr: linkClasses["lan"],
+			"slow:1": {Class: "satcom", BandwidthBPS: 1, BurstBytes: 1, FlushInterval: 2 * time.Second},
+		},
+	})
+	conn, err := grpc.NewClient(fastAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	fast := storev1.NewEntityStoreServiceClient(conn)
+
+	ctx := context.Background()
+	for i := range 3 {
+		e := &entityv1.Entity{Id: "l" + strconv.Itoa(i), Type: entityv1.EntityType_ENTITY_TYPE_TRACK}
+		ev := &storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_CREATED, Entity: e}
+		// Neither peer behind the tight budgets is reached, so needs no
+		// client.
+		for _, addr := range []string{"slow:1", "other:1"} {
+			if err := relay.forward(ctx, addr, nil, ev); err != nil {
+				t.Fatalf("forward to %s: %v", addr, err)
+			}
+		}
+		if err := relay.forward(ctx, fastAddr, fast, ev); err != nil {
+			t.Fatalf("forward to lan peer: %v", err)
+		}
+	}
+
+	if stats := relay.GetStats(); stats.Forwarded != 3 || stats.Dropped != 6 {
+		t.Fatalf("expected the lan peer's 3 forwarded and the rest dropped, got %+v", stats)
+	}
+	if got := relay.flushInterval("slow:1"); got != 2*time.Second {
+		t.Errorf("slow link flush interval = %v, want 2s", got)
+	}
+	if got := relay.flushInterval(fastAddr); got != 0 {
+		t.Errorf("lan link flush interval = %v, want 0", got)
+	}
+}
+
+func TestRelay_PathStopsLoops(t *testing.T) {
+	// A triangle: each relay forwards to both other nodes. A write on a
+	// reaches b and c, and each passes the other's copy on; the paths stop
+	// the copies circling between them.
+	nodes := []string{"a", "b", "c"}
+	stores := make(map[string]*store.Store)
+	addrs := make(map[string]string)
+	for _, node := range nodes {
+		stores[node] = store.New()
+		lis, err := net.Listen("tcp", "localhost:0")
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		svc := server.New(stores[node])
+		srv := grpc.NewServer(grpc.UnaryInterceptor(svc.UnaryInterceptor()), grpc.StreamInterceptor(svc.StreamInterceptor()))
+		storev1.RegisterEntityStoreServiceServer(srv, svc)
+		go srv.Serve(lis) //nolint:errcheck
+		defer srv.Stop()
+		addrs[node] = lis.Addr().String()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for _, node := range nodes {
+		var peers []string
+		for _, other := range nodes {
+			if other != node {
+				peers = append(peers, addrs[other])
+			}
+		}
+		go New(Config{LocalAddr: addrs[node], Peers: peers, NodeID: node}).Run(ctx) //nolint:errcheck
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 38.9})
+	if _, err := stores["a"].Create(&entityv1.Entity{Id: "loop", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos}}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for ctx.Err() == nil {
+		if _, err := stores["c"].Get("loop"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	events := func() (n uint64) {
+		for _, s := range stores {
+			for _, count := range s.Stats().Events {
+				n += count
+			}
+		}
+		return n
+	}
+	settled := events()
+	for range 10 {
+		time.Sleep(100 * time.Millisecond)
+		if n := events(); n == settled {
+			return
+		} else {
+			settled = n
+		}
+	}
+	t.Fatalf("writes still circling the triangle after a second: %d events", settled)
+}
+
+func TestRelay_PolicyFiltersForwards(t *testing.T) {
+	peerAddr, cleanup := startTestServer(t)
+	defer cleanup()
+	policy, err := ParsePolicy("track>=medium;-task_catalog")
+	if err != nil {
+		t.Fatalf("parse policy: %v", err)
+	}
+	relay := New(Config{Policy: policy})
+	conn, err := grpc.NewClient(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	peer := storev1.NewEntityStoreServiceClient(conn)
+
+	ctx := context.Background()
+	entity := func(id string, level entityv1.ThreatLevel) *entityv1.Entity {
+		threat, _ := anypb.New(&entityv1.ThreatComponent{Level: level})
+		catalog, _ := anypb.New(&entityv1.TaskCatalogComponent{AvailableTasks: []string{"intercept"}})
+		return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"threat": threat, "task_catalog": catalog}}
+	}
+	created := storev1.EventType_EVENT_TYPE_CREATED
+	relay.forward(ctx, peerAddr, peer, &storev1.EntityEvent{Type: created, Entity: entity("low", entityv1.ThreatLevel_THREAT_LEVEL_LOW)})       //nolint:errcheck
+	relay.forward(ctx, peerAddr, peer, &storev1.EntityEvent{Type: created, Entity: entity("medium", entityv1.ThreatLevel_THREAT_LEVEL_MEDIUM)}) //nolint:errcheck
+
+	if _, err := peer.GetEntity(ctx, &storev1.GetEntityRequest{Id: "low"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected the low-threat track kept from the peer, got %v", err)
+	}
+	got, err := peer.GetEntity(ctx, &storev1.GetEntityRequest{Id: "medium"})
+	if err != nil {
+		t.Fatalf("get medium: %v", err)
+	}
+	if got.Components["threat"] == nil || got.Components["task_catalog"] != nil {
+		t.Errorf("expected the medium track without its task catalog, got %v", got.Components)
+	}
+
+	// Deletes replicate whatever the entity was.
+	deleted := entity("medium", entityv1.ThreatLevel_THREAT_LEVEL_LOW)
+	relay.forward(ctx, peerAddr, peer, &storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_DELETED, Entity: deleted}) //nolint:errcheck
+	if _, err := peer.GetEntity(ctx, &storev1.GetEntityRequest{Id: "medium"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected the delete replicated, got %v", err)
+	}
+	if stats := relay.GetStats(); stats.Filtered != 1 || stats.Forwarded != 2 {
+		t.Errorf("expected 1 filtered and 2 forwarded, got %+v", stats)
+	}
+}
+
+func TestRelay_PolicyLimitsAntiEntropy(t *testing.T) {
+	localAddr, localCleanup := startTestServer(t)
+	defer localCleanup()
+	peerAddr, peerCleanup := startTestServer(t)
+	defer peerCleanup()
+
+	ctx := context.Background()
+	localConn, _ := grpc.NewClient(localAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer localConn.Close()
+	localClient := storev1.NewEntityStoreServiceClient(localConn)
+	peerConn, _ := grpc.NewClient(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer peerConn.Close()
+	peerClient := storev1.NewEntityStoreServiceClient(peerConn)
+
+	catalog, _ := anypb.New(&entityv1.TaskCatalogComponent{AvailableTasks: []string{"intercept"}})
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 10, Lon: 20})
+	for _, e := range []*entityv1.Entity{
+		{Id: "asset", Type: entityv1.EntityType_ENTITY_TYPE_ASSET, Components: map[string]*anypb.Any{"task_catalog": catalog, "position": pos}},
+		{Id: "track", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	} {
+		if _, err := localClient.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: e}); err != nil {
+			t.Fatalf("create %s: %v", e.Id, err)
+		}
+	}
+
+	policy, _ := ParsePolicy("asset;-task_catalog")
+	relay := New(Config{LocalAddr: localAddr, Peers: []string{peerAddr}, NodeID: "node-A", Policy: policy})
+	if err := relay.reconcile(ctx, peerAddr, localClient, peerClient); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if _, err := peerClient.GetEntity(ctx, &storev1.GetEntityRequest{Id: "track"}); status.Code(err) != codes.NotFound {
+		t.Errorf("expected the track kept from the peer, got %v", err)
+	}
+	got, err := peerClient.GetEntity(ctx, &storev1.GetEntityRequest{Id: "asset"})
+	if err != nil {
+		t.Fatalf("get asset: %v", err)
+	}
+	if got.Components["position"] == nil || got.Components["task_catalog"] != nil {
+		t.Fatalf("expected the asset without its task catalog, got %v", got.Components)
+	}
+
+	// The copies still differ in what the policy strips, which is no
+	// reason to write either again.
+	repaired := relay.GetStats().Repaired
+	if err := relay.reconcile(ctx, peerAddr, localClient, peerClient); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if got := relay.GetStats().Repaired; got != repaired {
+		t.Fatalf("expected no repairs on the second pass, got %d", got-repaired)
+	}
+}
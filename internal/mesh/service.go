@@ -0,0 +1,106 @@
+package mesh
+
+import (
+	"context"
+	"errors"
+
+	meshv1 "github.com/boshu2/lattice-lab/gen/mesh/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Service implements the RelayAdminService gRPC interface.
+type Service struct {
+	meshv1.UnimplementedRelayAdminServiceServer
+	relay *Relay
+}
+
+// NewService creates a gRPC service managing the given relay's peers and
+// reporting its status.
+func NewService(r *Relay) *Service {
+	return &Service{relay: r}
+}
+
+func (s *Service) AddPeer(_ context.Context, req *meshv1.AddPeerRequest) (*emptypb.Empty, error) {
+	if req.Addr == "" {
+		return nil, status.Error(codes.InvalidArgument, "addr is required")
+	}
+	err := s.relay.AddPeer(req.Addr, req.Sync)
+	switch {
+	case errors.Is(err, ErrPeerExists):
+		return nil, status.Errorf(codes.AlreadyExists, "%v", err)
+	case err != nil:
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Service) RemovePeer(_ context.Context, req *meshv1.RemovePeerRequest) (*emptypb.Empty, error) {
+	if req.Addr == "" {
+		return nil, status.Error(codes.InvalidArgument, "addr is required")
+	}
+	if err := s.relay.RemovePeer(req.Addr); err != nil {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Service) ListPeers(_ context.Context, _ *meshv1.ListPeersRequest) (*meshv1.ListPeersResponse, error) {
+	return &meshv1.ListPeersResponse{Peers: s.peers()}, nil
+}
+
+func (s *Service) GetStatus(_ context.Context, _ *meshv1.GetStatusRequest) (*meshv1.GetStatusResponse, error) {
+	st := s.relay.GetStats()
+	return &meshv1.GetStatusResponse{
+		NodeId: s.relay.cfg.NodeID,
+		Stats: &meshv1.RelayStats{
+			Forwarded: int64(st.Forwarded),
+			Errors:    int64(st.Errors),
+			Merged:    int64(st.Merged),
+			Dropped:   int64(st.Dropped),
+			Repaired:  int64(st.Repaired),
+			Evicted:   int64(st.Evicted),
+			Filtered:  int64(st.Filtered),
+
+			Diverged:        int64(st.Diverged),
+			ResolvedLww:     int64(st.ResolvedLWW),
+			ResolvedMaxWins: int64(st.ResolvedMaxWins),
+			ResolvedOther:   int64(st.ResolvedOther),
+		},
+		Peers: s.peers(),
+	}, nil
+}
+
+func (s *Service) peers() []*meshv1.Peer {
+	var out []*meshv1.Peer
+	for _, p := range s.relay.ListPeers() {
+		peer := &meshv1.Peer{
+			Addr:            p.Addr,
+			State:           p.State,
+			Queued:          int32(p.Queued),
+			Link:            p.Link,
+			Forwarded:       int64(p.Forwarded),
+			Dropped:         int64(p.Dropped),
+			SentBytes:       p.SentBytes,
+			BudgetBps:       p.Budget,
+			BudgetAvailable: p.Available,
+			SharedBudget:    p.SharedBudget,
+			Diverged:        int32(p.Diverged),
+			ResolvedLww:     int32(p.ResolvedLWW),
+			ResolvedMaxWins: int32(p.ResolvedMaxWins),
+			ResolvedOther:   int32(p.ResolvedOther),
+			ClockSkewed:     p.ClockSkewed,
+		}
+		if !p.At.IsZero() {
+			peer.ReconciledAt = timestamppb.New(p.At)
+		}
+		if p.Measured {
+			peer.ClockOffset = durationpb.New(p.ClockOffset)
+		}
+		out = append(out, peer)
+	}
+	return out
+}
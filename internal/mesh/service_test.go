@@ -0,0 +1,97 @@
+package mesh
+
+import (
+	"context"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	meshv1 "github.com/boshu2/lattice-lab/gen/mesh/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestService_Peers(t *testing.T) {
+	ctx := context.Background()
+	svc := NewService(New(Config{Peers: []string{"b:50051"}}))
+
+	if _, err := svc.AddPeer(ctx, &meshv1.AddPeerRequest{Addr: "a:50051"}); err != nil {
+		t.Fatalf("AddPeer: %v", err)
+	}
+	resp, err := svc.ListPeers(ctx, &meshv1.ListPeersRequest{})
+	if err != nil {
+		t.Fatalf("ListPeers: %v", err)
+	}
+	if len(resp.Peers) != 2 || resp.Peers[0].Addr != "a:50051" || resp.Peers[1].Addr != "b:50051" {
+		t.Fatalf("expected a and b by address, got %v", resp.Peers)
+	}
+	if resp.Peers[0].State != "" {
+		t.Fatalf("expected no state before the relay runs, got %q", resp.Peers[0].State)
+	}
+	if _, err := svc.RemovePeer(ctx, &meshv1.RemovePeerRequest{Addr: "b:50051"}); err != nil {
+		t.Fatalf("RemovePeer: %v", err)
+	}
+
+	if _, err := svc.AddPeer(ctx, &meshv1.AddPeerRequest{Addr: "a:50051"}); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("adding a peer twice: expected AlreadyExists, got %v", err)
+	}
+	if _, err := svc.AddPeer(ctx, &meshv1.AddPeerRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("adding no address: expected InvalidArgument, got %v", err)
+	}
+	if _, err := svc.RemovePeer(ctx, &meshv1.RemovePeerRequest{Addr: "b:50051"}); status.Code(err) != codes.NotFound {
+		t.Errorf("removing an unknown peer: expected NotFound, got %v", err)
+	}
+	if _, err := svc.RemovePeer(ctx, &meshv1.RemovePeerRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("removing no address: expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestService_Status(t *testing.T) {
+	peerAddr, cleanup := startTestServer(t)
+	defer cleanup()
+	relay := New(Config{
+		NodeID:       "node-a",
+		Peers:        []string{peerAddr, "ship:1"},
+		BandwidthBPS: 1000,
+		Links:        map[string]Link{"ship:1": {Class: "satcom", BandwidthBPS: 1, BurstBytes: 1}},
+	})
+	conn, err := grpc.NewClient(peerAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	ev := &storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_CREATED, Entity: &entityv1.Entity{Id: "s1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}}
+	if err := relay.forward(ctx, peerAddr, storev1.NewEntityStoreServiceClient(conn), ev); err != nil {
+		t.Fatalf("forward: %v", err)
+	}
+	if err := relay.forward(ctx, "ship:1", nil, ev); err != nil {
+		t.Fatalf("forward: %v", err)
+	}
+
+	resp, err := NewService(relay).GetStatus(ctx, &meshv1.GetStatusRequest{})
+	if err != nil {
+		t.Fatalf("GetStatus: %v", err)
+	}
+	if resp.NodeId != "node-a" || resp.Stats.Forwarded != 1 || resp.Stats.Dropped != 1 {
+		t.Fatalf("expected node-a with 1 forwarded and 1 dropped, got %v", resp)
+	}
+	if len(resp.Peers) != 2 {
+		t.Fatalf("expected 2 peers, got %v", resp.Peers)
+	}
+	lan, ship := resp.Peers[0], resp.Peers[1]
+	if ship.Addr != "ship:1" {
+		lan, ship = ship, lan
+	}
+	size := int64(proto.Size(ev.Entity))
+	if lan.Forwarded != 1 || lan.SentBytes != size || lan.BudgetBps != 1000 || !lan.SharedBudget || lan.Link != "" {
+		t.Errorf("expected 1 forwarded, %d bytes, on the shared budget, got %v", size, lan)
+	}
+	if ship.Dropped != 1 || ship.Forwarded != 0 || ship.BudgetBps != 1 || ship.SharedBudget || ship.Link != "satcom" {
+		t.Errorf("expected 1 dropped on the satcom link's own budget, got %v", ship)
+	}
+}
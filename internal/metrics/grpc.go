@@ -0,0 +1,77 @@
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	client = newRPCHandler(Default, "client", "made", "opened, such as watches of the store", "open now")
+	server = newRPCHandler(Default, "server", "served", "served", "being served now")
+)
+
+// DialOption instruments a client connection: unary call latency, and
+// streams opened and open.
+func DialOption() grpc.DialOption { return grpc.WithStatsHandler(client) }
+
+// ServerOption instruments a server like DialOption does a client. Calls
+// refused by interceptors count too.
+func ServerOption() grpc.ServerOption { return grpc.StatsHandler(server) }
+
+// rpcHandler is a stats.Handler feeding one side's metrics.
+type rpcHandler struct {
+	latency Histogram
+	streams Counter
+	active  Gauge
+}
+
+func newRPCHandler(r *Registry, side, done, opened, open string) rpcHandler {
+	prefix := "lattice_grpc_" + side + "_"
+	return rpcHandler{
+		latency: r.NewHistogram(prefix+"handling_seconds",
+			"Latency of unary gRPC calls "+done+", by method and status code.", nil, "method", "code"),
+		streams: r.NewCounter(prefix+"streams_total", "gRPC streams "+opened+", by method.", "method"),
+		active:  r.NewGauge(prefix+"streams_active", "gRPC streams "+open+", by method.", "method"),
+	}
+}
+
+// rpcTag follows one RPC from TagRPC through its Begin and End.
+type rpcTag struct {
+	method string
+	stream atomic.Bool // set by Begin, read by End
+}
+
+type tagKey struct{}
+
+func (h rpcHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, tagKey{}, &rpcTag{method: info.FullMethodName})
+}
+
+func (h rpcHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	tag, ok := ctx.Value(tagKey{}).(*rpcTag)
+	if !ok {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.Begin:
+		if s.IsClientStream || s.IsServerStream {
+			tag.stream.Store(true)
+			h.streams.Inc(tag.method)
+			h.active.Add(1, tag.method)
+		}
+	case *stats.End:
+		if tag.stream.Load() {
+			h.active.Add(-1, tag.method)
+			return
+		}
+		h.latency.Observe(s.EndTime.Sub(s.BeginTime).Seconds(), tag.method, status.Code(s.Error).String())
+	}
+}
+
+func (rpcHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context { return ctx }
+
+func (rpcHandler) HandleConn(context.Context, stats.ConnStats) {}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestGRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := NewRegistry()
+	srv := grpc.NewServer(grpc.StatsHandler(newRPCHandler(reg, "server", "", "", "")))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	go srv.Serve(lis) //nolint:errcheck
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(newRPCHandler(reg, "client", "", "", "")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: "missing"}); err == nil {
+		t.Fatal("expected NotFound for an unknown service")
+	}
+	stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatal(err)
+	}
+
+	const check, watch = `method="/grpc.health.v1.Health/Check"`, `method="/grpc.health.v1.Health/Watch"`
+	got := scrape(reg)
+	for _, want := range []string{
+		`lattice_grpc_client_handling_seconds_count{` + check + `,code="OK"} 1`,
+		`lattice_grpc_client_handling_seconds_count{` + check + `,code="NotFound"} 1`,
+		`lattice_grpc_server_handling_seconds_count{` + check + `,code="OK"} 1`,
+		`lattice_grpc_client_streams_total{` + watch + `} 1`,
+		`lattice_grpc_client_streams_active{` + watch + `} 1`,
+		`lattice_grpc_server_streams_active{` + watch + `} 1`,
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("missing %s in:\n%s", want, got)
+		}
+	}
+
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for !strings.Contains(scrape(reg), `lattice_grpc_server_streams_active{`+watch+`} 0`) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the watch closed on both sides, got:\n%s", scrape(reg))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := scrape(reg); !strings.Contains(got, `lattice_grpc_client_streams_active{`+watch+`} 0`) {
+		t.Fatalf("expected the client stream closed, got:\n%s", got)
+	}
+}
@@ -0,0 +1,296 @@
+// Package metrics is a small Prometheus registry shared by the services.
+// Packages declare their metrics once, as package variables registered in
+// Default, and every cmd binary serves Default on /metrics:
+//
+//	var classified = metrics.NewCounter("lattice_classifier_classified_total",
+//		"Entities classified, by result.", "classification")
+//
+//	classified.Inc("hostile")
+//
+// Label values are passed to each call, in the order the labels were
+// declared. Several instances of a component in one process, as in
+// lattice-lab, add to the same series.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets are the histogram upper bounds, in seconds, used for
+// latencies.
+var DefaultBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default is the registry the package-level constructors register in.
+var Default = NewRegistry()
+
+// Registry holds metric families by name.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// family is one named metric and its series, one per label value set.
+type family struct {
+	name, help, typ string
+	labels          []string
+	buckets         []float64 // histograms only
+
+	mu     sync.Mutex
+	series map[string]*series // by joined label values
+	fn     func() float64     // func metrics only; no series
+}
+
+type series struct {
+	values []string
+	value  atomic.Uint64 // float64 bits; the sum, for histograms
+	count  atomic.Uint64 // histograms only
+	counts []atomic.Uint64
+}
+
+// register returns the family called name, adding it if new. Declaring a
+// name twice with a different type or labels is a programming error.
+func (r *Registry) register(name, help, typ string, labels []string, buckets []float64) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		if f.typ != typ || !slices.Equal(f.labels, labels) {
+			panic(fmt.Sprintf("metrics: %s registered as %s%v and %s%v", name, f.typ, f.labels, typ, labels))
+		}
+		return f
+	}
+	f := &family{name: name, help: help, typ: typ, labels: labels, buckets: buckets, series: make(map[string]*series)}
+	r.families[name] = f
+	return f
+}
+
+// get returns the series for label values, adding it if new.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes labels %v, got %d values", f.name, f.labels, len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: slices.Clone(values)}
+		if f.buckets != nil {
+			s.counts = make([]atomic.Uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+func (s *series) add(v float64) {
+	for {
+		old := s.value.Load()
+		if s.value.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func (s *series) load() float64 { return math.Float64frombits(s.value.Load()) }
+
+// Counter is a monotonically increasing count.
+type Counter struct{ f *family }
+
+// NewCounter registers a counter in Default.
+func NewCounter(name, help string, labels ...string) Counter {
+	return Default.NewCounter(name, help, labels...)
+}
+
+// NewCounter registers a counter in r.
+func (r *Registry) NewCounter(name, help string, labels ...string) Counter {
+	return Counter{r.register(name, help, "counter", labels, nil)}
+}
+
+// Inc adds one to the series for labelValues.
+func (c Counter) Inc(labelValues ...string) { c.f.get(labelValues).add(1) }
+
+// Add adds v, which must not be negative, to the series for labelValues.
+func (c Counter) Add(v float64, labelValues ...string) { c.f.get(labelValues).add(v) }
+
+// Gauge is a value that goes up and down.
+type Gauge struct{ f *family }
+
+// NewGauge registers a gauge in Default.
+func NewGauge(name, help string, labels ...string) Gauge {
+	return Default.NewGauge(name, help, labels...)
+}
+
+// NewGauge registers a gauge in r.
+func (r *Registry) NewGauge(name, help string, labels ...string) Gauge {
+	return Gauge{r.register(name, help, "gauge", labels, nil)}
+}
+
+// Set sets the series for labelValues to v.
+func (g Gauge) Set(v float64, labelValues ...string) {
+	g.f.get(labelValues).value.Store(math.Float64bits(v))
+}
+
+// Add adds v, negative to subtract, to the series for labelValues.
+func (g Gauge) Add(v float64, labelValues ...string) { g.f.get(labelValues).add(v) }
+
+// Histogram counts observations into buckets.
+type Histogram struct{ f *family }
+
+// NewHistogram registers a histogram in Default with the given upper
+// bounds, ascending; nil means DefaultBuckets.
+func NewHistogram(name, help string, buckets []float64, labels ...string) Histogram {
+	return Default.NewHistogram(name, help, buckets, labels...)
+}
+
+// NewHistogram registers a histogram in r.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) Histogram {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return Histogram{r.register(name, help, "histogram", labels, buckets)}
+}
+
+// Observe records v in the series for labelValues.
+func (h Histogram) Observe(v float64, labelValues ...string) {
+	s := h.f.get(labelValues)
+	if i, _ := slices.BinarySearch(h.f.buckets, v); i < len(s.counts) {
+		s.counts[i].Add(1)
+	}
+	s.count.Add(1)
+	s.add(v)
+}
+
+// GaugeFunc registers a gauge in r read from fn at each scrape, such as
+// the size of a queue another package already tracks. Registering the
+// same name again replaces fn, so the last instance created reports.
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.setFunc(name, help, "gauge", fn)
+}
+
+// CounterFunc is GaugeFunc for a count that only increases.
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.setFunc(name, help, "counter", fn)
+}
+
+func (r *Registry) setFunc(name, help, typ string, fn func() float64) {
+	f := r.register(name, help, typ, nil, nil)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fn = fn
+}
+
+// Write writes every family in the Prometheus text format, sorted by
+// name. Series appear once they have been written to.
+func (r *Registry) Write(w io.Writer) {
+	r.mu.Lock()
+	families := slices.Collect(maps.Values(r.families))
+	r.mu.Unlock()
+	slices.SortFunc(families, func(a, b *family) int { return strings.Compare(a.name, b.name) })
+	for _, f := range families {
+		f.write(w)
+	}
+}
+
+func (f *family) write(w io.Writer) {
+	f.mu.Lock()
+	fn := f.fn
+	all := slices.Collect(maps.Values(f.series))
+	f.mu.Unlock()
+	if fn == nil && len(all) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.typ)
+	if fn != nil {
+		fmt.Fprintf(w, "%s %s\n", f.name, formatValue(fn()))
+		return
+	}
+	slices.SortFunc(all, func(a, b *series) int { return slices.Compare(a.values, b.values) })
+	for _, s := range all {
+		labels := f.labelPairs(s.values)
+		if f.typ != "histogram" {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, braces(labels), formatValue(s.load()))
+			continue
+		}
+		var cumulative uint64
+		for i, le := range f.buckets {
+			cumulative += s.counts[i].Load()
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, braces(append(labels, "le="+strconv.Quote(formatValue(le)))), cumulative)
+		}
+		count := s.count.Load()
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, braces(append(labels, `le="+Inf"`)), count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, braces(labels), formatValue(s.load()))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, braces(labels), count)
+	}
+}
+
+func (f *family) labelPairs(values []string) []string {
+	pairs := make([]string, len(values), len(values)+1)
+	for i, v := range values {
+		pairs[i] = f.labels[i] + "=" + strconv.Quote(v)
+	}
+	return pairs
+}
+
+func braces(pairs []string) string {
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// Handler serves r in the Prometheus text format, after the metrics each
+// of also writes, such as the store's own.
+func (r *Registry) Handler(also ...func(io.Writer)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		for _, write := range also {
+			write(w)
+		}
+		r.Write(w)
+	})
+}
+
+// Serve serves Default on addr's /metrics until ctx is cancelled. It
+// returns once addr is listening, or with the error if it cannot be; an
+// empty addr serves nothing.
+func Serve(ctx context.Context, addr string) error {
+	if addr == "" {
+		return nil
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen metrics: %w", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", Default.Handler())
+	srv := &http.Server{Handler: mux}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	go srv.Serve(lis) //nolint:errcheck
+	slog.Info("metrics listening", "addr", lis.Addr().String())
+	return nil
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func scrape(r *Registry) string {
+	var b strings.Builder
+	r.Write(&b)
+	return b.String()
+}
+
+func TestRegistry_Write(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounter("test_events_total", "Events.", "kind")
+	g := r.NewGauge("test_queue", "Queue depth.")
+	h := r.NewHistogram("test_seconds", "Latency.", []float64{0.1, 1})
+	r.NewCounter("test_unused_total", "Never written.")
+	r.GaugeFunc("test_pending", "Pending.", func() float64 { return 7 })
+
+	c.Inc("b")
+	c.Add(2, "a")
+	g.Set(5)
+	g.Add(-2)
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	want := `# HELP test_events_total Events.
+# TYPE test_events_total counter
+test_events_total{kind="a"} 2
+test_events_total{kind="b"} 1
+# HELP test_pending Pending.
+# TYPE test_pending gauge
+test_pending 7
+# HELP test_queue Queue depth.
+# TYPE test_queue gauge
+test_queue 3
+# HELP test_seconds Latency.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 1
+test_seconds_bucket{le="1"} 2
+test_seconds_bucket{le="+Inf"} 3
+test_seconds_sum 3.55
+test_seconds_count 3
+`
+	if got := scrape(r); got != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestRegistry_SameNameSameFamily(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_total", "Help.").Inc()
+	r.NewCounter("test_total", "Help.").Inc()
+	if got := scrape(r); !strings.Contains(got, "test_total 2\n") {
+		t.Fatalf("expected both counters to add to one series, got:\n%s", got)
+	}
+
+	r.GaugeFunc("test_func", "Help.", func() float64 { return 1 })
+	r.GaugeFunc("test_func", "Help.", func() float64 { return 2 })
+	if got := scrape(r); !strings.Contains(got, "test_func 2\n") {
+		t.Fatalf("expected the last func to report, got:\n%s", got)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic registering a name as another type")
+		}
+	}()
+	r.NewGauge("test_total", "Help.")
+}
+
+func TestCounter_WrongLabels(t *testing.T) {
+	c := NewRegistry().NewCounter("test_total", "Help.", "kind")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic with the wrong number of label values")
+		}
+	}()
+	c.Inc()
+}
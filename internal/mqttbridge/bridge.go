@@ -0,0 +1,367 @@
+// Package mqttbridge adapts the entity store to MQTT for constrained edge
+// devices: compact per-entity updates out, IoT position reports in.
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/mesh"
+	"github.com/boshu2/lattice-lab/internal/watch"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// Config controls the MQTT bridge.
+type Config struct {
+	StoreAddr    string
+	BrokerURL    string // tcp://host:1883
+	ClientID     string
+	TopicPrefix  string        // entities publish under <prefix>/entities/<id>, reports arrive on <prefix>/reports/<device>
+	IDPrefix     string        // prefix for entities created from device reports
+	ReportTTL    time.Duration // store expiry for device entities; 0 disables
+	BandwidthBPS float64       // outbound byte budget; 0 = unlimited
+	BurstBytes   float64       // burst capacity; 0 = BandwidthBPS
+	Flush        time.Duration // how often updates held back by the budget are retried
+}
+
+// DefaultConfig returns a config for a local broker with no byte budget.
+func DefaultConfig() Config {
+	return Config{
+		StoreAddr:   "localhost:50051",
+		BrokerURL:   "tcp://localhost:1883",
+		ClientID:    "lattice-mqtt-bridge",
+		TopicPrefix: "lattice",
+		IDPrefix:    "iot-",
+		ReportTTL:   time.Minute,
+		Flush:       time.Second,
+	}
+}
+
+// Validate checks the config is runnable.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.BrokerURL == "":
+		return fmt.Errorf("broker url is required")
+	case cfg.TopicPrefix == "" || strings.ContainsAny(cfg.TopicPrefix, "+#"):
+		return fmt.Errorf("topic prefix must be set and free of wildcards")
+	case cfg.IDPrefix == "":
+		return fmt.Errorf("id prefix is required")
+	case cfg.ReportTTL < 0 || cfg.BandwidthBPS < 0 || cfg.BurstBytes < 0:
+		return fmt.Errorf("ttl and bandwidth must not be negative")
+	case cfg.Flush <= 0:
+		return fmt.Errorf("flush interval must be positive")
+	}
+	return nil
+}
+
+// Update is the compact form of an entity published to its topic. Short
+// keys and omitted zero fields keep a typical track under 80 bytes of JSON.
+type Update struct {
+	Type    string  `json:"t"`
+	Lat     float64 `json:"la"`
+	Lon     float64 `json:"lo"`
+	Alt     float64 `json:"al,omitempty"`
+	Speed   float64 `json:"sp,omitempty"` // knots
+	Heading float64 `json:"hd,omitempty"` // degrees
+	Threat  int     `json:"th,omitempty"` // ThreatLevel: 1 none … 4 high
+	Time    int64   `json:"ts"`           // unix seconds
+}
+
+// Report is a device position report.
+type Report struct {
+	Lat     float64 `json:"lat"`
+	Lon     float64 `json:"lon"`
+	Alt     float64 `json:"alt"`
+	Speed   float64 `json:"speed_kts"`
+	Heading float64 `json:"heading"`
+	Domain  string  `json:"domain"` // air (default), surface, or land
+}
+
+// Client is the slice of an MQTT client the bridge uses.
+type Client interface {
+	Publish(topic string, retained bool, payload []byte) error
+	Subscribe(topic string, handle func(topic string, payload []byte)) error
+	Close()
+}
+
+// Bridge publishes entities to MQTT and applies device reports.
+type Bridge struct {
+	cfg     Config
+	bucket  *mesh.TokenBucket // nil when BandwidthBPS == 0
+	pending *mesh.Coalescer   // updates held back by the budget
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// Stats counts bridge activity.
+type Stats struct {
+	Published int
+	Deferred  int // updates held back by the budget, later coalesced
+	Reports   int
+	Errors    int
+}
+
+// New creates a bridge with the given config.
+func New(cfg Config) *Bridge {
+	b := &Bridge{cfg: cfg, pending: mesh.NewCoalescer()}
+	if cfg.BandwidthBPS > 0 {
+		burst := cfg.BurstBytes
+		if burst == 0 {
+			burst = cfg.BandwidthBPS
+		}
+		b.bucket = mesh.NewTokenBucket(cfg.BandwidthBPS, burst)
+	}
+	return b
+}
+
+// GetStats returns current bridge statistics.
+func (b *Bridge) GetStats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stats
+}
+
+// Run connects to the broker and the store and bridges until ctx is
+// cancelled.
+func (b *Bridge) Run(ctx context.Context) error {
+	mc, err := Dial(b.cfg)
+	if err != nil {
+		return err
+	}
+	defer mc.Close()
+	return b.run(ctx, mc)
+}
+
+func (b *Bridge) run(ctx context.Context, mc Client) error {
+	conn, err := client.Dial(b.cfg.StoreAddr)
+	if err != nil {
+		return fmt.Errorf("connect to store: %w", err)
+	}
+	defer conn.Close()
+
+	client := storev1.NewEntityStoreServiceClient(conn)
+	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{})
+	if err != nil {
+		return fmt.Errorf("watch entities: %w", err)
+	}
+
+	reports := b.cfg.TopicPrefix + "/reports/+"
+	if err := mc.Subscribe(reports, func(topic string, payload []byte) {
+		if err := b.applyReport(ctx, client, topic, payload); err != nil {
+			b.count(func(s *Stats) { s.Errors++ })
+			slog.Warn("device report rejected", "topic", topic, "error", err)
+		}
+	}); err != nil {
+		return fmt.Errorf("subscribe %s: %w", reports, err)
+	}
+
+	events := make(chan *storev1.EntityEvent)
+	errCh := make(chan error, 1)
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				errCh <- err
+				return
+			}
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	deleted := make(map[string]bool)
+	ticker := time.NewTicker(b.cfg.Flush)
+	defer ticker.Stop()
+
+	slog.Info("mqtt-bridge started", "broker", b.cfg.BrokerURL, "topic_prefix", b.cfg.TopicPrefix, "bandwidth_bps", b.cfg.BandwidthBPS, "store_addr", b.cfg.StoreAddr)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errCh:
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("recv: %w", err)
+		case event := <-events:
+			// A deferred update must not re-publish an entity deleted after it.
+			if watch.Removed(event) {
+				deleted[event.Entity.GetId()] = true
+			} else {
+				delete(deleted, event.Entity.GetId())
+			}
+			b.publish(mc, event)
+		case <-ticker.C:
+			held := false
+			for _, event := range b.pending.Drain() {
+				if !watch.Removed(event) && deleted[event.Entity.GetId()] {
+					continue
+				}
+				held = !b.publish(mc, event) || held
+			}
+			if !held {
+				clear(deleted)
+			}
+		}
+	}
+}
+
+// Topic returns the topic an entity's updates are published to.
+func (b *Bridge) Topic(id string) string {
+	return b.cfg.TopicPrefix + "/entities/" + id
+}
+
+// publish sends event as a retained compact update, or a retained empty
+// payload for a delete, which clears the topic. Events over the byte budget
+// wait in the coalescer, replaced by any newer update for the same entity;
+// publish reports false for those.
+func (b *Bridge) publish(mc Client, event *storev1.EntityEvent) bool {
+	var payload []byte
+	if !watch.Removed(event) {
+		u, ok := Compact(event.Entity, time.Now())
+		if !ok {
+			return true
+		}
+		var err error
+		if payload, err = json.Marshal(u); err != nil {
+			b.count(func(s *Stats) { s.Errors++ })
+			return true
+		}
+	}
+
+	if b.bucket != nil && !b.bucket.Allow(len(payload), mesh.EventPriority(event)) {
+		b.pending.Add(event)
+		b.count(func(s *Stats) { s.Deferred++ })
+		return false
+	}
+	if err := mc.Publish(b.Topic(event.Entity.GetId()), true, payload); err != nil {
+		b.count(func(s *Stats) { s.Errors++ })
+		slog.Warn("mqtt publish failed", "entity_id", event.Entity.GetId(), "error", err)
+		return true
+	}
+	b.count(func(s *Stats) { s.Published++ })
+	return true
+}
+
+func (b *Bridge) count(fn func(*Stats)) {
+	b.mu.Lock()
+	fn(&b.stats)
+	b.mu.Unlock()
+}
+
+// Compact returns the compact update for an entity with a position.
+func Compact(entity *entityv1.Entity, now time.Time) (Update, bool) {
+	pos := &entityv1.PositionComponent{}
+	if c, ok := entity.Components["position"]; !ok || c.UnmarshalTo(pos) != nil {
+		return Update{}, false
+	}
+	u := Update{
+		Type: strings.ToLower(strings.TrimPrefix(entity.Type.String(), "ENTITY_TYPE_")),
+		Lat:  pos.Lat,
+		Lon:  pos.Lon,
+		Alt:  pos.Alt,
+		Time: now.Unix(),
+	}
+	vel := &entityv1.VelocityComponent{}
+	if c, ok := entity.Components["velocity"]; ok && c.UnmarshalTo(vel) == nil {
+		u.Speed, u.Heading = vel.Speed, vel.Heading
+	}
+	threat := &entityv1.ThreatComponent{}
+	if c, ok := entity.Components["threat"]; ok && c.UnmarshalTo(threat) == nil {
+		u.Threat = int(threat.Level)
+	}
+	return u, true
+}
+
+// applyReport upserts the entity for a device report on
+// <prefix>/reports/<device>.
+func (b *Bridge) applyReport(ctx context.Context, client storev1.EntityStoreServiceClient, topic string, payload []byte) error {
+	device := topic[strings.LastIndex(topic, "/")+1:]
+	if device == "" {
+		return fmt.Errorf("report topic has no device id")
+	}
+	var r Report
+	if err := json.Unmarshal(payload, &r); err != nil {
+		return fmt.Errorf("decode report: %w", err)
+	}
+	if r.Lat < -90 || r.Lat > 90 || r.Lon < -180 || r.Lon > 180 {
+		return fmt.Errorf("position %.4f,%.4f out of range", r.Lat, r.Lon)
+	}
+	entity, err := reportEntity(b.cfg.IDPrefix+device, device, r)
+	if err != nil {
+		return err
+	}
+	var ttl *durationpb.Duration
+	if b.cfg.ReportTTL > 0 {
+		ttl = durationpb.New(b.cfg.ReportTTL)
+	}
+
+	existing, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: entity.Id})
+	if status.Code(err) == codes.NotFound {
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity, Ttl: ttl}); err != nil {
+			return fmt.Errorf("create %s: %w", entity.Id, err)
+		}
+		slog.Info("device acquired", "track_id", entity.Id, "lat", r.Lat, "lon", r.Lon)
+		b.count(func(s *Stats) { s.Reports++ })
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("get %s: %w", entity.Id, err)
+	}
+	entity.HlcPhysical, entity.HlcLogical, entity.HlcNode = existing.HlcPhysical, existing.HlcLogical, existing.HlcNode
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity, Ttl: ttl}); err != nil {
+		return fmt.Errorf("update %s: %w", entity.Id, err)
+	}
+	b.count(func(s *Stats) { s.Reports++ })
+	return nil
+}
+
+func reportEntity(id, device string, r Report) (*entityv1.Entity, error) {
+	domain := entityv1.Domain_DOMAIN_AIR
+	switch r.Domain {
+	case "", "air":
+	case "surface":
+		domain = entityv1.Domain_DOMAIN_SURFACE
+	case "land":
+		domain = entityv1.Domain_DOMAIN_LAND
+	default:
+		return nil, fmt.Errorf("unknown domain %q", r.Domain)
+	}
+	pos, err := anypb.New(&entityv1.PositionComponent{Lat: r.Lat, Lon: r.Lon, Alt: r.Alt})
+	if err != nil {
+		return nil, fmt.Errorf("pack position: %w", err)
+	}
+	vel, err := anypb.New(&entityv1.VelocityComponent{Speed: r.Speed, Heading: r.Heading})
+	if err != nil {
+		return nil, fmt.Errorf("pack velocity: %w", err)
+	}
+	src, err := anypb.New(&entityv1.SourceComponent{SensorId: device, SensorType: "iot", Domain: domain})
+	if err != nil {
+		return nil, fmt.Errorf("pack source: %w", err)
+	}
+	return &entityv1.Entity{
+		Id:   id,
+		Type: entityv1.EntityType_ENTITY_TYPE_TRACK,
+		Components: map[string]*anypb.Any{
+			"position": pos,
+			"velocity": vel,
+			"source":   src,
+		},
+	}, nil
+}
@@ -0,0 +1,220 @@
+package mqttbridge
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func startTestServer(t *testing.T) (string, *store.Store, func()) {
+	t.Helper()
+
+	s := store.New()
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+
+	return lis.Addr().String(), s, func() { srv.Stop() }
+}
+
+// memClient keeps the retained payload per topic, like a broker, and lets
+// tests deliver messages to the bridge's subscription.
+type memClient struct {
+	mu       sync.Mutex
+	retained map[string][]byte
+	handle   func(string, []byte)
+}
+
+func (m *memClient) Publish(topic string, retained bool, payload []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(payload) == 0 {
+		delete(m.retained, topic)
+		return nil
+	}
+	m.retained[topic] = payload
+	return nil
+}
+
+func (m *memClient) Subscribe(_ string, handle func(string, []byte)) error {
+	m.mu.Lock()
+	m.handle = handle
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *memClient) Close() {}
+
+func (m *memClient) deliver(topic string, payload []byte) {
+	m.mu.Lock()
+	h := m.handle
+	m.mu.Unlock()
+	h(topic, payload)
+}
+
+func (m *memClient) get(topic string) (Update, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.retained[topic]
+	if !ok {
+		return Update{}, false
+	}
+	var u Update
+	json.Unmarshal(data, &u) //nolint:errcheck
+	return u, true
+}
+
+func trackEntity(t *testing.T, id string, lat float64) *entityv1.Entity {
+	t.Helper()
+	pos, err := anypb.New(&entityv1.PositionComponent{Lat: lat, Lon: -77.0})
+	if err != nil {
+		t.Fatalf("pack position: %v", err)
+	}
+	return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos}}
+}
+
+func startBridge(t *testing.T, cfg Config) (*Bridge, *memClient, *store.Store, func()) {
+	t.Helper()
+	addr, s, cleanup := startTestServer(t)
+	cfg.StoreAddr = addr
+	b := New(cfg)
+	mc := &memClient{retained: make(map[string][]byte)}
+	ctx, cancel := context.WithCancel(context.Background())
+	go b.run(ctx, mc) //nolint:errcheck
+	time.Sleep(100 * time.Millisecond)
+	return b, mc, s, func() { cancel(); cleanup() }
+}
+
+func TestBridge_PublishesAndIngests(t *testing.T) {
+	b, mc, s, stop := startBridge(t, DefaultConfig())
+	defer stop()
+
+	if _, err := s.Create(trackEntity(t, "track-0", 38.9)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	u, ok := mc.get("lattice/entities/track-0")
+	if !ok || u.Type != "track" || u.Lat != 38.9 {
+		t.Fatalf("expected retained track-0 update, got %+v (%v)", u, ok)
+	}
+
+	if err := s.Delete("track-0"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := mc.get("lattice/entities/track-0"); ok {
+		t.Fatal("expected delete to clear the retained update")
+	}
+
+	// Two reports from one device create, then update, one track.
+	mc.deliver("lattice/reports/buoy7", []byte(`{"lat":36.8,"lon":-76.1,"speed_kts":3,"domain":"surface"}`))
+	mc.deliver("lattice/reports/buoy7", []byte(`{"lat":36.81,"lon":-76.1,"speed_kts":3,"domain":"surface"}`))
+	mc.deliver("lattice/reports/bad", []byte(`{"lat":120}`))
+
+	e, err := s.Get("iot-buoy7")
+	if err != nil {
+		t.Fatalf("expected iot-buoy7: %v", err)
+	}
+	pos := &entityv1.PositionComponent{}
+	src := &entityv1.SourceComponent{}
+	if err := e.Components["position"].UnmarshalTo(pos); err != nil || pos.Lat != 36.81 {
+		t.Fatalf("expected updated position, got %v (%v)", pos, err)
+	}
+	if err := e.Components["source"].UnmarshalTo(src); err != nil || src.SensorType != "iot" || src.Domain != entityv1.Domain_DOMAIN_SURFACE {
+		t.Fatalf("unexpected source %v (%v)", src, err)
+	}
+	if st := b.GetStats(); st.Reports != 2 || st.Errors != 1 {
+		t.Fatalf("expected 2 reports and 1 error, got %+v", st)
+	}
+}
+
+func TestBridge_BudgetCoalesces(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.BandwidthBPS = 1
+	cfg.BurstBytes = 60 // room for one update
+	cfg.Flush = 50 * time.Millisecond
+	b, mc, s, stop := startBridge(t, cfg)
+	defer stop()
+
+	e, err := s.Create(trackEntity(t, "track-0", 38.0))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	for _, lat := range []float64{38.1, 38.2, 38.3} {
+		e.Components = trackEntity(t, "track-0", lat).Components
+		if e, err = s.Update(e); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+	if _, err := s.Create(trackEntity(t, "track-1", 39.0)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := s.Delete("track-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	st := b.GetStats()
+	if st.Deferred == 0 || st.Published > 3 {
+		t.Fatalf("expected updates held back by the budget, got %+v", st)
+	}
+	if u, ok := mc.get("lattice/entities/track-0"); !ok || u.Lat != 38.0 {
+		t.Fatalf("expected only the first update within budget, got %+v", u)
+	}
+	if _, ok := mc.get("lattice/entities/track-1"); ok {
+		t.Fatal("expected deferred create of a deleted entity to be dropped")
+	}
+}
+
+func TestCompact(t *testing.T) {
+	e := trackEntity(t, "t", 38.9)
+	vel, _ := anypb.New(&entityv1.VelocityComponent{Speed: 420, Heading: 90})
+	threat, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	e.Components["velocity"], e.Components["threat"] = vel, threat
+
+	u, ok := Compact(e, time.Unix(1700000000, 0))
+	if !ok {
+		t.Fatal("expected compact update")
+	}
+	data, _ := json.Marshal(u)
+	want := `{"t":"track","la":38.9,"lo":-77,"sp":420,"hd":90,"th":4,"ts":1700000000}`
+	if string(data) != want {
+		t.Fatalf("got %s, want %s", data, want)
+	}
+	if _, ok := Compact(&entityv1.Entity{Id: "x"}, time.Now()); ok {
+		t.Fatal("expected no update without a position")
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("default config: %v", err)
+	}
+	for name, mut := range map[string]func(*Config){
+		"wildcard": func(c *Config) { c.TopicPrefix = "lattice/#" },
+		"no url":   func(c *Config) { c.BrokerURL = "" },
+		"flush":    func(c *Config) { c.Flush = 0 },
+		"negative": func(c *Config) { c.BandwidthBPS = -1 },
+	} {
+		cfg := DefaultConfig()
+		mut(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
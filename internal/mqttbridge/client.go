@@ -0,0 +1,64 @@
+package mqttbridge
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// pahoClient adapts a paho client to Client. Subscriptions are replayed on
+// every reconnect, since the bridge connects with a clean session.
+type pahoClient struct {
+	client mqtt.Client
+
+	mu   sync.Mutex
+	subs map[string]mqtt.MessageHandler
+}
+
+// Dial connects to the broker at cfg.BrokerURL.
+func Dial(cfg Config) (Client, error) {
+	c := &pahoClient{subs: make(map[string]mqtt.MessageHandler)}
+	opts := mqtt.NewClientOptions().
+		AddBroker(cfg.BrokerURL).
+		SetClientID(cfg.ClientID).
+		SetAutoReconnect(true).
+		SetOnConnectHandler(c.resubscribe)
+	c.client = mqtt.NewClient(opts)
+	if err := wait(c.client.Connect()); err != nil {
+		return nil, fmt.Errorf("connect mqtt %s: %w", cfg.BrokerURL, err)
+	}
+	return c, nil
+}
+
+// Publish sends at QoS 0: updates are retained and superseded by the next
+// one, so redelivery is not worth the extra round trip on a thin link.
+func (c *pahoClient) Publish(topic string, retained bool, payload []byte) error {
+	return wait(c.client.Publish(topic, 0, retained, payload))
+}
+
+func (c *pahoClient) Subscribe(topic string, handle func(string, []byte)) error {
+	h := func(_ mqtt.Client, m mqtt.Message) { handle(m.Topic(), m.Payload()) }
+	c.mu.Lock()
+	c.subs[topic] = h
+	c.mu.Unlock()
+	return wait(c.client.Subscribe(topic, 1, h))
+}
+
+func (c *pahoClient) resubscribe(client mqtt.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for topic, h := range c.subs {
+		client.Subscribe(topic, 1, h)
+	}
+}
+
+func (c *pahoClient) Close() { c.client.Disconnect(250) }
+
+func wait(tok mqtt.Token) error {
+	if !tok.WaitTimeout(10 * time.Second) {
+		return fmt.Errorf("mqtt: timed out")
+	}
+	return tok.Error()
+}
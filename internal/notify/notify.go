@@ -0,0 +1,157 @@
+// Package notify watches entity events and mesh peers and sends webhook,
+// Slack, or email notifications for the events an operator should not miss.
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/watch"
+)
+
+// Kind is a notification condition.
+type Kind string
+
+const (
+	KindThreatHigh      Kind = "threat_high"      // an entity's threat rose to HIGH
+	KindApprovalPending Kind = "approval_pending" // an intercept is waiting for operator approval
+	KindPeerPartition   Kind = "peer_partition"   // a mesh peer stopped (or resumed) answering
+	KindFusedCreated    Kind = "fused_created"    // fusion created a fused track
+
+	// KindDivergence is sent by divergence-monitor, not detected here.
+	KindDivergence Kind = "divergence" // store nodes stayed out of sync (or converged again)
+)
+
+// AllKinds lists every condition the notifier detects, in the order they
+// are documented.
+var AllKinds = []Kind{KindThreatHigh, KindApprovalPending, KindPeerPartition, KindFusedCreated}
+
+// ParseKinds parses a comma-separated list of kinds. Empty means all.
+func ParseKinds(s string) ([]Kind, error) {
+	if strings.TrimSpace(s) == "" {
+		return AllKinds, nil
+	}
+	var kinds []Kind
+	for _, f := range strings.Split(s, ",") {
+		k := Kind(strings.TrimSpace(f))
+		switch k {
+		case KindThreatHigh, KindApprovalPending, KindPeerPartition, KindFusedCreated:
+			kinds = append(kinds, k)
+		default:
+			return nil, fmt.Errorf("unknown notification kind %q", f)
+		}
+	}
+	return kinds, nil
+}
+
+// Notification is one message to deliver. Subject is the entity ID, or the
+// peer address for partitions. Kind, Subject, and Title form the dedup key,
+// so a peer's recovery is not deduplicated against its partition.
+type Notification struct {
+	Kind    Kind      `json:"kind"`
+	Subject string    `json:"subject"`
+	Title   string    `json:"title"`
+	Detail  string    `json:"detail,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+func (n Notification) key() string { return string(n.Kind) + "/" + n.Subject + "/" + n.Title }
+
+// Detector turns entity events into notifications. It remembers each
+// entity's last threat level and approval state so that only transitions
+// notify, not every update of an entity that stays HIGH or PENDING. It is
+// not safe for concurrent use.
+type Detector struct {
+	threat   map[string]entityv1.ThreatLevel
+	approval map[string]entityv1.ApprovalState
+}
+
+// NewDetector returns a detector with no history.
+func NewDetector() *Detector {
+	return &Detector{
+		threat:   make(map[string]entityv1.ThreatLevel),
+		approval: make(map[string]entityv1.ApprovalState),
+	}
+}
+
+// Observe returns the notifications event triggers.
+func (d *Detector) Observe(event *storev1.EntityEvent, now time.Time) []Notification {
+	entity := event.Entity
+	if entity == nil {
+		return nil
+	}
+	id := entity.Id
+	if watch.Removed(event) {
+		delete(d.threat, id)
+		delete(d.approval, id)
+		return nil
+	}
+
+	var out []Notification
+
+	threat := &entityv1.ThreatComponent{}
+	if c, ok := entity.Components["threat"]; ok && c.UnmarshalTo(threat) == nil {
+		prev := d.threat[id]
+		d.threat[id] = threat.Level
+		if threat.Level == entityv1.ThreatLevel_THREAT_LEVEL_HIGH && prev != entityv1.ThreatLevel_THREAT_LEVEL_HIGH {
+			out = append(out, Notification{
+				Kind:    KindThreatHigh,
+				Subject: id,
+				Title:   fmt.Sprintf("%s escalated to HIGH threat", id),
+				Detail:  describe(entity, "was "+levelName(prev)),
+				Time:    now,
+			})
+		}
+	}
+
+	approval := &entityv1.ApprovalComponent{}
+	if c, ok := entity.Components["approval"]; ok && c.UnmarshalTo(approval) == nil {
+		prev := d.approval[id]
+		d.approval[id] = approval.State
+		if approval.State == entityv1.ApprovalState_APPROVAL_STATE_PENDING && prev != entityv1.ApprovalState_APPROVAL_STATE_PENDING {
+			detail := "awaiting operator approval"
+			if approval.TimeoutSeconds > 0 {
+				detail = fmt.Sprintf("awaiting operator approval, times out in %ds", approval.TimeoutSeconds)
+			}
+			out = append(out, Notification{
+				Kind:    KindApprovalPending,
+				Subject: id,
+				Title:   fmt.Sprintf("Intercept of %s needs approval", id),
+				Detail:  describe(entity, detail),
+				Time:    now,
+			})
+		}
+	}
+
+	fused := &entityv1.FusionComponent{}
+	if c, ok := entity.Components["fusion"]; ok && event.Type == storev1.EventType_EVENT_TYPE_CREATED && c.UnmarshalTo(fused) == nil {
+		out = append(out, Notification{
+			Kind:    KindFusedCreated,
+			Subject: id,
+			Title:   fmt.Sprintf("Fused track %s created", id),
+			Detail: fmt.Sprintf("from %s at %.4f,%.4f (confidence %.0f%%)",
+				strings.Join(fused.SourceIds, ", "), fused.FusedLat, fused.FusedLon, fused.Confidence*100),
+			Time: now,
+		})
+	}
+	return out
+}
+
+// describe prefixes note with the entity's position, when it has one.
+func describe(entity *entityv1.Entity, note string) string {
+	pos := &entityv1.PositionComponent{}
+	if c, ok := entity.Components["position"]; ok && c.UnmarshalTo(pos) == nil {
+		return fmt.Sprintf("at %.4f,%.4f; %s", pos.Lat, pos.Lon, note)
+	}
+	return note
+}
+
+func levelName(l entityv1.ThreatLevel) string {
+	if l == entityv1.ThreatLevel_THREAT_LEVEL_UNSPECIFIED {
+		return "unassessed"
+	}
+	return strings.TrimPrefix(l.String(), "THREAT_LEVEL_")
+}
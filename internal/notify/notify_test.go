@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func withComponent(t *testing.T, id, key string, m proto.Message) *entityv1.Entity {
+	t.Helper()
+	a, err := anypb.New(m)
+	if err != nil {
+		t.Fatalf("pack %s: %v", key, err)
+	}
+	return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{key: a}}
+}
+
+func event(typ storev1.EventType, e *entityv1.Entity) *storev1.EntityEvent {
+	return &storev1.EntityEvent{Type: typ, Entity: e}
+}
+
+func threatAt(t *testing.T, id string, level entityv1.ThreatLevel) *entityv1.Entity {
+	return withComponent(t, id, "threat", &entityv1.ThreatComponent{Level: level})
+}
+
+func TestDetector_ThreatTransitions(t *testing.T) {
+	d := NewDetector()
+	now := time.Now()
+	upd := storev1.EventType_EVENT_TYPE_UPDATED
+
+	steps := []struct {
+		level entityv1.ThreatLevel
+		want  int
+	}{
+		{entityv1.ThreatLevel_THREAT_LEVEL_LOW, 0},
+		{entityv1.ThreatLevel_THREAT_LEVEL_HIGH, 1},
+		{entityv1.ThreatLevel_THREAT_LEVEL_HIGH, 0}, // still HIGH
+		{entityv1.ThreatLevel_THREAT_LEVEL_MEDIUM, 0},
+		{entityv1.ThreatLevel_THREAT_LEVEL_HIGH, 1}, // escalated again
+	}
+	for i, s := range steps {
+		got := d.Observe(event(upd, threatAt(t, "t1", s.level)), now)
+		if len(got) != s.want {
+			t.Fatalf("step %d: got %d notifications, want %d", i, len(got), s.want)
+		}
+		if s.want == 1 && (got[0].Kind != KindThreatHigh || got[0].Subject != "t1") {
+			t.Fatalf("step %d: unexpected %+v", i, got[0])
+		}
+	}
+
+	// A delete forgets history, so a recreated HIGH entity notifies.
+	d.Observe(event(storev1.EventType_EVENT_TYPE_DELETED, &entityv1.Entity{Id: "t1"}), now)
+	if got := d.Observe(event(upd, threatAt(t, "t1", entityv1.ThreatLevel_THREAT_LEVEL_HIGH)), now); len(got) != 1 {
+		t.Fatalf("expected notification after recreate, got %v", got)
+	}
+}
+
+func TestDetector_ApprovalAndFusion(t *testing.T) {
+	d := NewDetector()
+	now := time.Now()
+	pending := withComponent(t, "t2", "approval", &entityv1.ApprovalComponent{State: entityv1.ApprovalState_APPROVAL_STATE_PENDING, TimeoutSeconds: 30})
+
+	got := d.Observe(event(storev1.EventType_EVENT_TYPE_UPDATED, pending), now)
+	if len(got) != 1 || got[0].Kind != KindApprovalPending || got[0].Detail != "awaiting operator approval, times out in 30s" {
+		t.Fatalf("unexpected %+v", got)
+	}
+	if got := d.Observe(event(storev1.EventType_EVENT_TYPE_UPDATED, pending), now); len(got) != 0 {
+		t.Fatalf("expected no repeat while pending, got %+v", got)
+	}
+
+	fused := withComponent(t, "fused-1", "fusion", &entityv1.FusionComponent{SourceIds: []string{"a", "b"}, FusedLat: 38.9, FusedLon: -77, Confidence: 0.8})
+	got = d.Observe(event(storev1.EventType_EVENT_TYPE_CREATED, fused), now)
+	if len(got) != 1 || got[0].Kind != KindFusedCreated || got[0].Detail != "from a, b at 38.9000,-77.0000 (confidence 80%)" {
+		t.Fatalf("unexpected %+v", got)
+	}
+	if got := d.Observe(event(storev1.EventType_EVENT_TYPE_UPDATED, fused), now); len(got) != 0 {
+		t.Fatalf("expected fused updates to be quiet, got %+v", got)
+	}
+}
+
+func TestParseKinds(t *testing.T) {
+	kinds, err := ParseKinds("threat_high, peer_partition")
+	if err != nil || len(kinds) != 2 || kinds[1] != KindPeerPartition {
+		t.Fatalf("got %v, %v", kinds, err)
+	}
+	if kinds, _ := ParseKinds(""); len(kinds) != len(AllKinds) {
+		t.Fatalf("expected all kinds for empty, got %v", kinds)
+	}
+	if _, err := ParseKinds("threat_low"); err == nil {
+		t.Fatal("expected error for unknown kind")
+	}
+}
@@ -0,0 +1,261 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/mesh"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Config controls the notification service.
+type Config struct {
+	StoreAddr string
+	Kinds     []Kind
+	Sinks     []Sink
+
+	Peers         []string      // mesh peer stores to probe for partitions
+	ProbeInterval time.Duration // how often peers are probed
+	PeerFailures  int           // consecutive failed probes before a peer counts as partitioned
+
+	Dedup      time.Duration // repeat notifications for the same kind and subject are dropped within this window
+	RatePerMin float64       // sustained notifications per minute across all kinds
+	Burst      int           // notifications that may be sent back to back
+}
+
+// DefaultConfig returns a config notifying on every kind, with no sinks.
+func DefaultConfig() Config {
+	return Config{
+		StoreAddr:     "localhost:50051",
+		Kinds:         AllKinds,
+		ProbeInterval: 10 * time.Second,
+		PeerFailures:  3,
+		Dedup:         5 * time.Minute,
+		RatePerMin:    30,
+		Burst:         10,
+	}
+}
+
+// Validate checks the config is runnable.
+func (cfg Config) Validate() error {
+	switch {
+	case len(cfg.Sinks) == 0:
+		return fmt.Errorf("at least one sink (webhook, slack, or email) is required")
+	case len(cfg.Kinds) == 0:
+		return fmt.Errorf("at least one notification kind is required")
+	case len(cfg.Peers) > 0 && (cfg.ProbeInterval <= 0 || cfg.PeerFailures < 1):
+		return fmt.Errorf("peer probing needs a positive interval and failure count")
+	case cfg.Dedup < 0:
+		return fmt.Errorf("dedup window must not be negative")
+	case cfg.RatePerMin <= 0 || cfg.Burst < 1:
+		return fmt.Errorf("rate and burst must be positive")
+	}
+	return nil
+}
+
+// Stats counts notification outcomes.
+type Stats struct {
+	Sent    int // notifications delivered to at least one sink
+	Deduped int // dropped as repeats within the dedup window
+	Limited int // dropped by the rate limit
+	Errors  int // failed sink deliveries
+}
+
+// Service detects notification conditions and delivers them.
+type Service struct {
+	cfg      Config
+	kinds    map[Kind]bool
+	detector *Detector
+	limiter  *mesh.TokenBucket // one token per notification
+	queue    chan Notification
+
+	mu    sync.Mutex
+	last  map[string]time.Time // dedup key → last accepted
+	stats Stats
+}
+
+// New creates a service with the given config.
+func New(cfg Config) *Service {
+	kinds := make(map[Kind]bool, len(cfg.Kinds))
+	for _, k := range cfg.Kinds {
+		kinds[k] = true
+	}
+	return &Service{
+		cfg:      cfg,
+		kinds:    kinds,
+		detector: NewDetector(),
+		limiter:  mesh.NewTokenBucket(cfg.RatePerMin/60, float64(cfg.Burst)),
+		queue:    make(chan Notification, 64),
+		last:     make(map[string]time.Time),
+	}
+}
+
+// GetStats returns current notification statistics.
+func (s *Service) GetStats() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stats
+}
+
+// Run watches the store, probes peers, and delivers notifications until ctx
+// is cancelled.
+func (s *Service) Run(ctx context.Context) error {
+	conn, err := client.Dial(s.cfg.StoreAddr)
+	if err != nil {
+		return fmt.Errorf("connect to store: %w", err)
+	}
+	defer conn.Close()
+
+	client := storev1.NewEntityStoreServiceClient(conn)
+	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{})
+	if err != nil {
+		return fmt.Errorf("watch entities: %w", err)
+	}
+
+	go s.deliver(ctx)
+	if s.kinds[KindPeerPartition] {
+		for _, addr := range s.cfg.Peers {
+			go s.probe(ctx, addr)
+		}
+	}
+
+	slog.Info("notifier started", "store_addr", s.cfg.StoreAddr, "kinds", s.cfg.Kinds, "sinks", len(s.cfg.Sinks), "peers", s.cfg.Peers)
+
+	for {
+		event, err := stream.Recv()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("recv: %w", err)
+		}
+		for _, n := range s.detector.Observe(event, time.Now()) {
+			s.notify(n)
+		}
+	}
+}
+
+// notify queues n for delivery unless its kind is disabled, it repeats a
+// recent notification, or the rate limit is exhausted.
+func (s *Service) notify(n Notification) {
+	if !s.kinds[n.Kind] {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if last, ok := s.last[n.key()]; ok && n.Time.Sub(last) < s.cfg.Dedup {
+		s.stats.Deduped++
+		return
+	}
+	if !s.limiter.Allow(1, mesh.PriorityNone) {
+		s.stats.Limited++
+		slog.Warn("notification rate limited", "kind", n.Kind, "subject", n.Subject)
+		return
+	}
+	select {
+	case s.queue <- n:
+		if len(s.last) >= 4096 {
+			for k, t := range s.last {
+				if n.Time.Sub(t) >= s.cfg.Dedup {
+					delete(s.last, k)
+				}
+			}
+		}
+		s.last[n.key()] = n.Time
+	default:
+		s.stats.Limited++
+		slog.Warn("notification queue full", "kind", n.Kind, "subject", n.Subject)
+	}
+}
+
+// deliver sends queued notifications to every sink, so a slow sink does
+// not hold up the watch.
+func (s *Service) deliver(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-s.queue:
+			sent := false
+			for _, sink := range s.cfg.Sinks {
+				sendCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+				err := sink.Send(sendCtx, n)
+				cancel()
+				if err != nil {
+					s.count(func(st *Stats) { st.Errors++ })
+					slog.Error("notification failed", "sink", sink.Name(), "kind", n.Kind, "subject", n.Subject, "error", err)
+					continue
+				}
+				sent = true
+			}
+			if sent {
+				s.count(func(st *Stats) { st.Sent++ })
+				slog.Info("notification sent", "kind", n.Kind, "subject", n.Subject)
+			}
+		}
+	}
+}
+
+func (s *Service) count(fn func(*Stats)) {
+	s.mu.Lock()
+	fn(&s.stats)
+	s.mu.Unlock()
+}
+
+// probe polls a peer store and notifies when it has failed PeerFailures
+// probes in a row, and again when it answers after that.
+func (s *Service) probe(ctx context.Context, addr string) {
+	conn, err := client.Dial(addr, client.WithoutRetries())
+	if err != nil {
+		slog.Error("peer probe disabled", "peer", addr, "error", err)
+		return
+	}
+	defer conn.Close()
+	peer := storev1.NewEntityStoreServiceClient(conn)
+
+	ticker := time.NewTicker(s.cfg.ProbeInterval)
+	defer ticker.Stop()
+
+	failures, partitioned := 0, false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			err := ping(ctx, peer, s.cfg.ProbeInterval)
+			switch {
+			case err == nil && partitioned:
+				partitioned = false
+				s.notify(Notification{Kind: KindPeerPartition, Subject: addr, Title: fmt.Sprintf("Mesh peer %s reachable again", addr), Time: now})
+			case err == nil:
+			case !partitioned:
+				failures++
+				if failures >= s.cfg.PeerFailures {
+					partitioned = true
+					s.notify(Notification{Kind: KindPeerPartition, Subject: addr, Title: fmt.Sprintf("Mesh peer %s partitioned", addr), Detail: fmt.Sprintf("%d probes failed: %v", failures, err), Time: now})
+				}
+			}
+			if err == nil {
+				failures = 0
+			}
+		}
+	}
+}
+
+// ping asks the peer for an entity that does not exist: NotFound means the
+// store answered.
+func ping(ctx context.Context, peer storev1.EntityStoreServiceClient, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	_, err := peer.GetEntity(ctx, &storev1.GetEntityRequest{Id: "notifier-probe"})
+	if err == nil || status.Code(err) == codes.NotFound {
+		return nil
+	}
+	return err
+}
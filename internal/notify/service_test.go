@@ -0,0 +1,159 @@
+package notify
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+)
+
+func startTestServer(t *testing.T) (string, *store.Store, func()) {
+	t.Helper()
+
+	s := store.New()
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+
+	return lis.Addr().String(), s, func() { srv.Stop() }
+}
+
+// memSink records what it is sent.
+type memSink struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (m *memSink) Name() string { return "mem" }
+
+func (m *memSink) Send(_ context.Context, n Notification) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, n)
+	return nil
+}
+
+func (m *memSink) kinds() []Kind {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Kind
+	for _, n := range m.sent {
+		out = append(out, n.Kind)
+	}
+	return out
+}
+
+func TestService_NotifiesOnStoreEvents(t *testing.T) {
+	addr, s, cleanup := startTestServer(t)
+	defer cleanup()
+
+	sink := &memSink{}
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	cfg.Sinks = []Sink{sink}
+	cfg.Kinds = []Kind{KindThreatHigh}
+	svc := New(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.Run(ctx) //nolint:errcheck
+	time.Sleep(100 * time.Millisecond)
+
+	e, err := s.Create(threatAt(t, "t1", entityv1.ThreatLevel_THREAT_LEVEL_HIGH))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	// Dropping and re-escalating within the dedup window is not repeated.
+	for _, level := range []entityv1.ThreatLevel{entityv1.ThreatLevel_THREAT_LEVEL_LOW, entityv1.ThreatLevel_THREAT_LEVEL_HIGH} {
+		e.Components = threatAt(t, "t1", level).Components
+		if e, err = s.Update(e); err != nil {
+			t.Fatalf("Update: %v", err)
+		}
+	}
+	// Approvals are not enabled.
+	if _, err := s.Create(withComponent(t, "t2", "approval", &entityv1.ApprovalComponent{State: entityv1.ApprovalState_APPROVAL_STATE_PENDING})); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+
+	if got := sink.kinds(); len(got) != 1 || got[0] != KindThreatHigh {
+		t.Fatalf("expected one threat_high notification, got %v", got)
+	}
+	if st := svc.GetStats(); st.Sent != 1 || st.Deduped != 1 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
+
+func TestService_RateLimit(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Burst = 2
+	cfg.RatePerMin = 1
+	svc := New(cfg)
+
+	now := time.Now()
+	for _, id := range []string{"a", "b", "c", "d"} {
+		svc.notify(Notification{Kind: KindThreatHigh, Subject: id, Title: id, Time: now})
+	}
+	if st := svc.GetStats(); st.Limited != 2 || len(svc.queue) != 2 {
+		t.Fatalf("expected 2 queued and 2 limited, got %+v with %d queued", st, len(svc.queue))
+	}
+}
+
+func TestService_PeerPartition(t *testing.T) {
+	addr, _, cleanup := startTestServer(t)
+	defer cleanup()
+	peerAddr, _, stopPeer := startTestServer(t)
+
+	sink := &memSink{}
+	cfg := DefaultConfig()
+	cfg.StoreAddr = addr
+	cfg.Sinks = []Sink{sink}
+	cfg.Peers = []string{peerAddr}
+	cfg.ProbeInterval = 30 * time.Millisecond
+	cfg.PeerFailures = 2
+	svc := New(cfg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go svc.Run(ctx) //nolint:errcheck
+	time.Sleep(100 * time.Millisecond)
+	if got := sink.kinds(); len(got) != 0 {
+		t.Fatalf("expected a healthy peer to be quiet, got %v", got)
+	}
+
+	stopPeer()
+	time.Sleep(300 * time.Millisecond)
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+	if len(sink.sent) != 1 || sink.sent[0].Kind != KindPeerPartition || sink.sent[0].Subject != peerAddr {
+		t.Fatalf("expected one partition notification, got %+v", sink.sent)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	cfg := DefaultConfig()
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error without sinks")
+	}
+	cfg.Sinks = []Sink{&memSink{}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("valid config: %v", err)
+	}
+	cfg.Peers, cfg.PeerFailures = []string{"peer:50051"}, 0
+	if err := cfg.Validate(); err == nil {
+		t.Fatal("expected error for zero peer failures")
+	}
+}
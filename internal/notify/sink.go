@@ -0,0 +1,104 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strings"
+)
+
+// Sink delivers notifications to one destination.
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, n Notification) error
+}
+
+// Webhook posts each notification as JSON.
+type Webhook struct {
+	URL string
+}
+
+func (w Webhook) Name() string { return "webhook" }
+
+func (w Webhook) Send(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("encode notification: %w", err)
+	}
+	return post(ctx, w.URL, body)
+}
+
+// Slack posts to a Slack incoming webhook.
+type Slack struct {
+	URL string
+}
+
+func (s Slack) Name() string { return "slack" }
+
+func (s Slack) Send(ctx context.Context, n Notification) error {
+	text := "*" + n.Title + "*"
+	if n.Detail != "" {
+		text += "\n" + n.Detail
+	}
+	body, err := json.Marshal(map[string]string{"text": text})
+	if err != nil {
+		return fmt.Errorf("encode slack message: %w", err)
+	}
+	return post(ctx, s.URL, body)
+}
+
+func post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("post %s: %s", url, resp.Status)
+	}
+	return nil
+}
+
+// Email sends plain-text mail through an SMTP relay, authenticating with
+// PLAIN when Username is set.
+type Email struct {
+	Addr     string // host:port
+	From     string
+	To       []string
+	Username string
+	Password string
+}
+
+func (e Email) Name() string { return "email" }
+
+func (e Email) Send(_ context.Context, n Notification) error {
+	var auth smtp.Auth
+	if e.Username != "" {
+		host, _, _ := net.SplitHostPort(e.Addr)
+		auth = smtp.PlainAuth("", e.Username, e.Password, host)
+	}
+	if err := smtp.SendMail(e.Addr, auth, e.From, e.To, e.message(n)); err != nil {
+		return fmt.Errorf("send mail via %s: %w", e.Addr, err)
+	}
+	return nil
+}
+
+func (e Email) message(n Notification) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", e.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(e.To, ", "))
+	fmt.Fprintf(&b, "Subject: [lattice] %s\r\n", n.Title)
+	fmt.Fprintf(&b, "Date: %s\r\n", n.Time.Format("Mon, 02 Jan 2006 15:04:05 -0700"))
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&b, "%s\r\n\r\nkind: %s\r\nsubject: %s\r\n", n.Detail, n.Kind, n.Subject)
+	return []byte(b.String())
+}
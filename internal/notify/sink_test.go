@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSinks_HTTP(t *testing.T) {
+	var bodies []string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+	}))
+	defer hook.Close()
+
+	n := Notification{Kind: KindThreatHigh, Subject: "t1", Title: "t1 escalated to HIGH threat", Detail: "was LOW", Time: time.Unix(0, 0).UTC()}
+	if err := (Webhook{URL: hook.URL}).Send(context.Background(), n); err != nil {
+		t.Fatalf("webhook: %v", err)
+	}
+	if err := (Slack{URL: hook.URL}).Send(context.Background(), n); err != nil {
+		t.Fatalf("slack: %v", err)
+	}
+
+	var got Notification
+	if err := json.Unmarshal([]byte(bodies[0]), &got); err != nil || got != n {
+		t.Fatalf("webhook body %s (%v)", bodies[0], err)
+	}
+	if want := `{"text":"*t1 escalated to HIGH threat*\nwas LOW"}`; bodies[1] != want {
+		t.Fatalf("slack body %s, want %s", bodies[1], want)
+	}
+
+	rejecting := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer rejecting.Close()
+	if err := (Webhook{URL: rejecting.URL}).Send(context.Background(), n); err == nil {
+		t.Fatal("expected error for a rejected post")
+	}
+}
+
+func TestEmail_Message(t *testing.T) {
+	e := Email{From: "lattice@example.com", To: []string{"ops@example.com", "watch@example.com"}}
+	msg := string(e.message(Notification{Kind: KindApprovalPending, Subject: "t2", Title: "Intercept of t2 needs approval", Time: time.Now()}))
+	for _, want := range []string{"To: ops@example.com, watch@example.com\r\n", "Subject: [lattice] Intercept of t2 needs approval\r\n", "kind: approval_pending"} {
+		if !strings.Contains(msg, want) {
+			t.Fatalf("message missing %q:\n%s", want, msg)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+// Package registry holds component schemas: a message descriptor per type
+// URL plus field rules. The entity-store checks components against it on
+// write, and clients use it to decode components they were not compiled
+// with.
+package registry
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	registryv1 "github.com/boshu2/lattice-lab/gen/registry/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// entry is a registered schema resolved against its descriptors.
+type entry struct {
+	schema *registryv1.Schema
+	typ    protoreflect.MessageType
+	rules  []rule
+}
+
+type rule struct {
+	field    protoreflect.FieldDescriptor
+	required bool
+	min, max *float64
+	pattern  *regexp.Regexp
+}
+
+// Registry is a thread-safe set of schemas keyed by type URL. It is also a
+// protojson/prototext resolver: registered types resolve to dynamic
+// messages, everything else falls back to the types linked into the binary.
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]*entry
+	byName  map[protoreflect.FullName]*entry
+}
+
+// New creates an empty registry.
+func New() *Registry {
+	return &Registry{
+		entries: make(map[string]*entry),
+		byName:  make(map[protoreflect.FullName]*entry),
+	}
+}
+
+// Register adds or replaces the schema for s.TypeUrl. It fails if the
+// message cannot be resolved or a rule does not fit its field.
+func (r *Registry) Register(s *registryv1.Schema) error {
+	e, err := compile(s)
+	if err != nil {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, ok := r.entries[s.TypeUrl]; ok {
+		delete(r.byName, old.typ.Descriptor().FullName())
+	}
+	r.entries[s.TypeUrl] = e
+	r.byName[e.typ.Descriptor().FullName()] = e
+	return nil
+}
+
+// Get returns the schema registered for typeURL.
+func (r *Registry) Get(typeURL string) (*registryv1.Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.entries[typeURL]
+	if !ok {
+		return nil, fmt.Errorf("schema %q not found", typeURL)
+	}
+	return proto.Clone(e.schema).(*registryv1.Schema), nil
+}
+
+// List returns every schema, sorted by type URL.
+func (r *Registry) List() []*registryv1.Schema {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]*registryv1.Schema, 0, len(r.entries))
+	for _, e := range r.entries {
+		out = append(out, proto.Clone(e.schema).(*registryv1.Schema))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].TypeUrl < out[j].TypeUrl })
+	return out
+}
+
+// Delete removes the schema for typeURL.
+func (r *Registry) Delete(typeURL string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	e, ok := r.entries[typeURL]
+	if !ok {
+		return fmt.Errorf("schema %q not found", typeURL)
+	}
+	delete(r.entries, typeURL)
+	delete(r.byName, e.typ.Descriptor().FullName())
+	return nil
+}
+
+// Validate checks a component against its schema. Components whose type
+// URL has no schema are accepted unchecked.
+func (r *Registry) Validate(c *anypb.Any) error {
+	r.mu.RLock()
+	e, ok := r.entries[c.GetTypeUrl()]
+	r.mu.RUnlock()
+	if !ok {
+		return nil
+	}
+
+	m := e.typ.New()
+	if err := proto.Unmarshal(c.Value, m.Interface()); err != nil {
+		return fmt.Errorf("does not decode as %s: %v", e.typ.Descriptor().FullName(), err)
+	}
+	if len(m.GetUnknown()) > 0 {
+		return fmt.Errorf("has fields not in %s", e.typ.Descriptor().FullName())
+	}
+	for _, rl := range e.rules {
+		if err := rl.check(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rl rule) check(m protoreflect.Message) error {
+	fd := rl.field
+	if !m.Has(fd) {
+		if rl.required {
+			return fmt.Errorf("field %s is required", fd.Name())
+		}
+		if fd.HasPresence() {
+			return nil
+		}
+	}
+	// Fields without presence are checked at their zero value.
+	v := m.Get(fd)
+	if rl.min != nil || rl.max != nil {
+		n := number(fd, v)
+		if rl.min != nil && n < *rl.min {
+			return fmt.Errorf("field %s is %v, below minimum %v", fd.Name(), n, *rl.min)
+		}
+		if rl.max != nil && n > *rl.max {
+			return fmt.Errorf("field %s is %v, above maximum %v", fd.Name(), n, *rl.max)
+		}
+	}
+	if rl.pattern != nil && !rl.pattern.MatchString(v.String()) {
+		return fmt.Errorf("field %s %q does not match %s", fd.Name(), v.String(), rl.pattern)
+	}
+	return nil
+}
+
+func number(fd protoreflect.FieldDescriptor, v protoreflect.Value) float64 {
+	switch fd.Kind() {
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float()
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return float64(v.Uint())
+	case protoreflect.EnumKind:
+		return float64(v.Enum())
+	default:
+		return float64(v.Int())
+	}
+}
+
+// MessageName returns the message name a type URL refers to.
+func MessageName(typeURL string) protoreflect.FullName {
+	return protoreflect.FullName(typeURL[strings.LastIndex(typeURL, "/")+1:])
+}
+
+// compile resolves a schema's message and rules.
+func compile(s *registryv1.Schema) (*entry, error) {
+	if s.GetTypeUrl() == "" {
+		return nil, fmt.Errorf("type_url is required")
+	}
+	name := MessageName(s.TypeUrl)
+	if !name.IsValid() {
+		return nil, fmt.Errorf("type_url %q does not end in a message name", s.TypeUrl)
+	}
+
+	files, err := descriptorFiles(s.Descriptors)
+	if err != nil {
+		return nil, err
+	}
+	d, err := files.FindDescriptorByName(name)
+	if err != nil {
+		d, err = protoregistry.GlobalFiles.FindDescriptorByName(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("message %s not found in descriptors", name)
+	}
+	md, ok := d.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a message", name)
+	}
+
+	e := &entry{schema: proto.Clone(s).(*registryv1.Schema), typ: dynamicpb.NewMessageType(md)}
+	for _, fr := range s.Rules {
+		fd := md.Fields().ByName(protoreflect.Name(fr.Field))
+		if fd == nil {
+			return nil, fmt.Errorf("rule: %s has no field %q", name, fr.Field)
+		}
+		rl := rule{field: fd, required: fr.Required, min: fr.Min, max: fr.Max}
+		if (fr.Min != nil || fr.Max != nil) && !numeric(fd) {
+			return nil, fmt.Errorf("rule: min/max on non-numeric field %s", fr.Field)
+		}
+		if fr.Pattern != "" {
+			if fd.Kind() != protoreflect.StringKind || fd.IsList() || fd.IsMap() {
+				return nil, fmt.Errorf("rule: pattern on non-string field %s", fr.Field)
+			}
+			if rl.pattern, err = regexp.Compile(fr.Pattern); err != nil {
+				return nil, fmt.Errorf("rule: field %s: %w", fr.Field, err)
+			}
+		}
+		e.rules = append(e.rules, rl)
+	}
+	return e, nil
+}
+
+func numeric(fd protoreflect.FieldDescriptor) bool {
+	if fd.IsList() || fd.IsMap() {
+		return false
+	}
+	switch fd.Kind() {
+	case protoreflect.BoolKind, protoreflect.StringKind, protoreflect.BytesKind,
+		protoreflect.MessageKind, protoreflect.GroupKind:
+		return false
+	}
+	return true
+}
+
+// descriptorFiles builds the files in set. Imports missing from the set,
+// such as the well-known types, resolve against the files linked into the
+// binary.
+func descriptorFiles(set *descriptorpb.FileDescriptorSet) (*protoregistry.Files, error) {
+	files := new(protoregistry.Files)
+	for _, fdp := range set.GetFile() {
+		if _, err := files.FindFileByPath(fdp.GetName()); err == nil {
+			continue
+		}
+		if _, err := protoregistry.GlobalFiles.FindFileByPath(fdp.GetName()); err == nil {
+			continue
+		}
+		fd, err := protodesc.NewFile(fdp, resolvers{files, protoregistry.GlobalFiles})
+		if err != nil {
+			return nil, fmt.Errorf("descriptor %s: %w", fdp.GetName(), err)
+		}
+		if err := files.RegisterFile(fd); err != nil {
+			return nil, fmt.Errorf("descriptor %s: %w", fdp.GetName(), err)
+		}
+	}
+	return files, nil
+}
+
+// resolvers looks up descriptors in each registry in turn.
+type resolvers []*protoregistry.Files
+
+func (rs resolvers) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	for _, r := range rs {
+		if fd, err := r.FindFileByPath(path); err == nil {
+			return fd, nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+func (rs resolvers) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	for _, r := range rs {
+		if d, err := r.FindDescriptorByName(name); err == nil {
+			return d, nil
+		}
+	}
+	return nil, protoregistry.NotFound
+}
+
+// FindMessageByName implements protoregistry.MessageTypeResolver.
+func (r *Registry) FindMessageByName(name protoreflect.FullName) (protoreflect.MessageType, error) {
+	r.mu.RLock()
+	e, ok := r.byName[name]
+	r.mu.RUnlock()
+	if ok {
+		return e.typ, nil
+	}
+	return protoregistry.GlobalTypes.FindMessageByName(name)
+}
+
+// FindMessageByURL implements protoregistry.MessageTypeResolver.
+func (r *Registry) FindMessageByURL(url string) (protoreflect.MessageType, error) {
+	r.mu.RLock()
+	e, ok := r.entries[url]
+	r.mu.RUnlock()
+	if ok {
+		return e.typ, nil
+	}
+	return r.FindMessageByName(MessageName(url))
+}
+
+// FindExtensionByName implements protoregistry.ExtensionTypeResolver.
+func (r *Registry) FindExtensionByName(field protoreflect.FullName) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByName(field)
+}
+
+// FindExtensionByNumber implements protoregistry.ExtensionTypeResolver.
+func (r *Registry) FindExtensionByNumber(message protoreflect.FullName, field protoreflect.FieldNumber) (protoreflect.ExtensionType, error) {
+	return protoregistry.GlobalTypes.FindExtensionByNumber(message, field)
+}
+
+// Decode unmarshals a component with its registered schema or, failing
+// that, the type linked into the binary.
+func (r *Registry) Decode(c *anypb.Any) (proto.Message, error) {
+	return anypb.UnmarshalNew(c, proto.UnmarshalOptions{Resolver: r})
+}
+
+// ParseRules builds field rules from flag-style specs: required field
+// names, ranges as "field=min:max" (either bound may be empty), and
+// patterns as "field=regexp".
+func ParseRules(required, ranges, patterns []string) ([]*registryv1.FieldRule, error) {
+	var out []*registryv1.FieldRule
+	byField := map[string]*registryv1.FieldRule{}
+	ruleFor := func(field string) *registryv1.FieldRule {
+		if r, ok := byField[field]; ok {
+			return r
+		}
+		r := &registryv1.FieldRule{Field: field}
+		byField[field] = r
+		out = append(out, r)
+		return r
+	}
+
+	for _, f := range required {
+		ruleFor(strings.TrimSpace(f)).Required = true
+	}
+	for _, spec := range ranges {
+		field, bounds, ok := strings.Cut(spec, "=")
+		lo, hi, ok2 := strings.Cut(bounds, ":")
+		if !ok || !ok2 {
+			return nil, fmt.Errorf("range %q: want field=min:max", spec)
+		}
+		r := ruleFor(strings.TrimSpace(field))
+		for _, b := range []struct {
+			s   string
+			dst **float64
+		}{{lo, &r.Min}, {hi, &r.Max}} {
+			if b.s == "" {
+				continue
+			}
+			v, err := strconv.ParseFloat(b.s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("range %q: %w", spec, err)
+			}
+			*b.dst = &v
+		}
+	}
+	for _, spec := range patterns {
+		field, pattern, ok := strings.Cut(spec, "=")
+		if !ok {
+			return nil, fmt.Errorf("pattern %q: want field=regexp", spec)
+		}
+		ruleFor(strings.TrimSpace(field)).Pattern = pattern
+	}
+	return out, nil
+}
@@ -0,0 +1,209 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	registryv1 "github.com/boshu2/lattice-lab/gen/registry/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const widgetURL = "type.googleapis.com/acme.v1.Widget"
+
+// widgetSet is a third-party descriptor set the store was not compiled
+// with. It imports a well-known type that is left out of the set.
+func widgetSet() *descriptorpb.FileDescriptorSet {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{Name: proto.String(name), JsonName: proto.String(name), Number: proto.Int32(num), Type: typ.Enum(), Label: label.Enum()}
+	}
+	opt := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	seen := field("seen", 4, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, opt)
+	seen.TypeName = proto.String(".google.protobuf.Timestamp")
+	return &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:       proto.String("acme/v1/widget.proto"),
+		Package:    proto.String("acme.v1"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto"},
+		MessageType: []*descriptorpb.DescriptorProto{{
+			Name: proto.String("Widget"),
+			Field: []*descriptorpb.FieldDescriptorProto{
+				field("serial", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt),
+				field("charge", 2, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, opt),
+				field("tags", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_LABEL_REPEATED),
+				seen,
+			},
+		}},
+	}}}
+}
+
+// widget encodes a Widget component with the given serial and charge.
+func widget(t *testing.T, serial string, charge float64) *anypb.Any {
+	t.Helper()
+	fd, err := protodesc.NewFile(widgetSet().File[0], protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatalf("NewFile: %v", err)
+	}
+	m := dynamicpb.NewMessage(fd.Messages().ByName("Widget"))
+	m.Set(m.Descriptor().Fields().ByName("serial"), protoreflect.ValueOfString(serial))
+	m.Set(m.Descriptor().Fields().ByName("charge"), protoreflect.ValueOfFloat64(charge))
+	b, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	return &anypb.Any{TypeUrl: widgetURL, Value: b}
+}
+
+func TestRegistry_ThirdPartyRules(t *testing.T) {
+	r := New()
+	rules, err := ParseRules([]string{"serial"}, []string{"charge=0:100"}, []string{"serial=^W-[0-9]+$"})
+	if err != nil {
+		t.Fatalf("ParseRules: %v", err)
+	}
+	if err := r.Register(&registryv1.Schema{TypeUrl: widgetURL, Descriptors: widgetSet(), Rules: rules}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	if err := r.Validate(widget(t, "W-42", 55)); err != nil {
+		t.Fatalf("expected valid widget, got %v", err)
+	}
+	for _, tc := range []struct {
+		c    *anypb.Any
+		want string
+	}{
+		{widget(t, "", 55), "required"},
+		{widget(t, "W-1", 101), "above maximum"},
+		{widget(t, "W-1", -1), "below minimum"},
+		{widget(t, "X-1", 1), "does not match"},
+	} {
+		if err := r.Validate(tc.c); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("expected %q error, got %v", tc.want, err)
+		}
+	}
+
+	// A component claiming the type URL but encoding another message.
+	threat, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	threat.TypeUrl = widgetURL
+	if err := r.Validate(threat); err == nil {
+		t.Fatal("expected error for a component that does not match its schema")
+	}
+
+	// Unregistered types pass unchecked.
+	other, _ := anypb.New(&entityv1.PositionComponent{Lat: 1000})
+	if err := r.Validate(other); err != nil {
+		t.Fatalf("expected unregistered type accepted, got %v", err)
+	}
+}
+
+func TestRegistry_BuiltInType(t *testing.T) {
+	r := New()
+	lat := -90.0
+	err := r.Register(&registryv1.Schema{
+		TypeUrl: "type.googleapis.com/entity.v1.PositionComponent",
+		Rules:   []*registryv1.FieldRule{{Field: "lat", Min: &lat, Max: proto.Float64(90)}},
+	})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	ok, _ := anypb.New(&entityv1.PositionComponent{Lat: 45})
+	bad, _ := anypb.New(&entityv1.PositionComponent{Lat: 91})
+	if err := r.Validate(ok); err != nil {
+		t.Fatalf("expected valid position, got %v", err)
+	}
+	if err := r.Validate(bad); err == nil {
+		t.Fatal("expected lat 91 rejected")
+	}
+}
+
+func TestRegistry_RegisterErrors(t *testing.T) {
+	r := New()
+	for _, s := range []*registryv1.Schema{
+		{},
+		{TypeUrl: "type.googleapis.com/acme.v1.Missing", Descriptors: widgetSet()},
+		{TypeUrl: widgetURL, Descriptors: widgetSet(), Rules: []*registryv1.FieldRule{{Field: "nope", Required: true}}},
+		{TypeUrl: widgetURL, Descriptors: widgetSet(), Rules: []*registryv1.FieldRule{{Field: "serial", Min: proto.Float64(1)}}},
+		{TypeUrl: widgetURL, Descriptors: widgetSet(), Rules: []*registryv1.FieldRule{{Field: "charge", Pattern: "x"}}},
+		{TypeUrl: widgetURL, Descriptors: widgetSet(), Rules: []*registryv1.FieldRule{{Field: "serial", Pattern: "("}}},
+	} {
+		if err := r.Register(s); err == nil {
+			t.Errorf("expected error registering %v", s)
+		}
+	}
+	if len(r.List()) != 0 {
+		t.Fatalf("expected nothing registered, got %v", r.List())
+	}
+}
+
+func TestRegistry_GetListDelete(t *testing.T) {
+	r := New()
+	if err := r.Register(&registryv1.Schema{TypeUrl: widgetURL, Descriptors: widgetSet()}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if s, err := r.Get(widgetURL); err != nil || len(s.Descriptors.File) != 1 {
+		t.Fatalf("Get: %v, %v", s, err)
+	}
+	if n := len(r.List()); n != 1 {
+		t.Fatalf("expected 1 schema, got %d", n)
+	}
+	if err := r.Delete(widgetURL); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := r.Get(widgetURL); err == nil {
+		t.Fatal("expected schema gone")
+	}
+	if err := r.Delete(widgetURL); err == nil {
+		t.Fatal("expected error deleting twice")
+	}
+}
+
+func TestRegistry_DecodeJSON(t *testing.T) {
+	r := New()
+	if err := r.Register(&registryv1.Schema{TypeUrl: widgetURL, Descriptors: widgetSet()}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	m, err := r.Decode(widget(t, "W-7", 12.5))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	b, err := protojson.MarshalOptions{Resolver: r}.Marshal(m)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if got := string(b); !strings.Contains(got, `"serial":"W-7"`) || !strings.Contains(got, `"charge":12.5`) {
+		t.Fatalf("unexpected JSON %s", got)
+	}
+
+	// Compiled-in types still decode.
+	ts, _ := anypb.New(timestamppb.Now())
+	if _, err := r.Decode(ts); err != nil {
+		t.Fatalf("expected compiled-in type decoded, got %v", err)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]string{"a"}, []string{"a=1:", "b=:2.5"}, []string{"c=^x"})
+	if err != nil {
+		t.Fatalf("ParseRules: %v", err)
+	}
+	if len(rules) != 3 || !rules[0].Required || *rules[0].Min != 1 || rules[0].Max != nil ||
+		rules[1].Min != nil || *rules[1].Max != 2.5 || rules[2].Pattern != "^x" {
+		t.Fatalf("unexpected rules %v", rules)
+	}
+	for _, bad := range [][]string{{"a"}, {"a=1"}, {"a=x:1"}} {
+		if _, err := ParseRules(nil, bad, nil); err == nil {
+			t.Errorf("expected error for range %v", bad)
+		}
+	}
+	if _, err := ParseRules(nil, nil, []string{"c"}); err == nil {
+		t.Error("expected error for pattern without field")
+	}
+}
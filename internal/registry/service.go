@@ -0,0 +1,50 @@
+package registry
+
+import (
+	"context"
+
+	registryv1 "github.com/boshu2/lattice-lab/gen/registry/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// Service implements the SchemaRegistryService gRPC interface.
+type Service struct {
+	registryv1.UnimplementedSchemaRegistryServiceServer
+	reg *Registry
+}
+
+// NewService creates a gRPC service backed by the given registry.
+func NewService(r *Registry) *Service {
+	return &Service{reg: r}
+}
+
+func (s *Service) RegisterSchema(_ context.Context, req *registryv1.RegisterSchemaRequest) (*registryv1.Schema, error) {
+	if req.Schema == nil {
+		return nil, status.Error(codes.InvalidArgument, "schema is required")
+	}
+	if err := s.reg.Register(req.Schema); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return s.reg.Get(req.Schema.TypeUrl)
+}
+
+func (s *Service) GetSchema(_ context.Context, req *registryv1.GetSchemaRequest) (*registryv1.Schema, error) {
+	sc, err := s.reg.Get(req.TypeUrl)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	return sc, nil
+}
+
+func (s *Service) ListSchemas(_ context.Context, _ *registryv1.ListSchemasRequest) (*registryv1.ListSchemasResponse, error) {
+	return &registryv1.ListSchemasResponse{Schemas: s.reg.List()}, nil
+}
+
+func (s *Service) DeleteSchema(_ context.Context, req *registryv1.DeleteSchemaRequest) (*emptypb.Empty, error) {
+	if err := s.reg.Delete(req.TypeUrl); err != nil {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,40 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	registryv1 "github.com/boshu2/lattice-lab/gen/registry/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestService(t *testing.T) {
+	svc := NewService(New())
+	ctx := context.Background()
+
+	if _, err := svc.RegisterSchema(ctx, &registryv1.RegisterSchemaRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without schema, got %v", err)
+	}
+	bad := &registryv1.Schema{TypeUrl: widgetURL}
+	if _, err := svc.RegisterSchema(ctx, &registryv1.RegisterSchemaRequest{Schema: bad}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without descriptors, got %v", err)
+	}
+
+	s, err := svc.RegisterSchema(ctx, &registryv1.RegisterSchemaRequest{Schema: &registryv1.Schema{TypeUrl: widgetURL, Descriptors: widgetSet()}})
+	if err != nil || s.TypeUrl != widgetURL {
+		t.Fatalf("RegisterSchema: %v, %v", s, err)
+	}
+	if _, err := svc.GetSchema(ctx, &registryv1.GetSchemaRequest{TypeUrl: widgetURL}); err != nil {
+		t.Fatalf("GetSchema: %v", err)
+	}
+	if resp, _ := svc.ListSchemas(ctx, &registryv1.ListSchemasRequest{}); len(resp.Schemas) != 1 {
+		t.Fatalf("expected 1 schema, got %v", resp.Schemas)
+	}
+	if _, err := svc.DeleteSchema(ctx, &registryv1.DeleteSchemaRequest{TypeUrl: widgetURL}); err != nil {
+		t.Fatalf("DeleteSchema: %v", err)
+	}
+	if _, err := svc.GetSchema(ctx, &registryv1.GetSchemaRequest{TypeUrl: widgetURL}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound after delete, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// AttackProfile is a scripted hostile behavior.
+type AttackProfile string
+
+const (
+	AttackDash   AttackProfile = "dash"   // fly the route, then dash at the target
+	AttackLoiter AttackProfile = "loiter" // orbit the route's end, then commit at Commit
+	AttackPopup  AttackProfile = "popup"  // appear at RangeM from the target, already committed
+)
+
+// Attack scripts a hostile track against a defended asset. A committed
+// track flies straight at the target at SpeedKnots and is removed when it
+// arrives.
+type Attack struct {
+	Profile       AttackProfile `yaml:"profile"`
+	Target        *Waypoint     `yaml:"target"`          // default the scenario's defended asset
+	SpeedKnots    float64       `yaml:"speed_kts"`       // commit speed; default the track's
+	Commit        time.Duration `yaml:"commit"`          // loiter: time after spawn to commit
+	LoiterRadiusM float64       `yaml:"loiter_radius_m"` // loiter: orbit radius; default 3000
+	RangeM        float64       `yaml:"range_m"`         // popup: distance from the target
+	Bearing       float64       `yaml:"bearing"`         // popup: direction from the target, degrees
+	AltM          float64       `yaml:"alt_m"`           // popup: altitude; default 150
+}
+
+// Validate checks the attack has what its profile needs. hasRoute reports
+// whether the track has waypoints; hasTarget whether the scenario declares a
+// defended asset.
+func (a Attack) Validate(hasRoute, hasTarget bool) error {
+	if a.Target == nil && !hasTarget {
+		return fmt.Errorf("attack needs a target or a scenario defended asset")
+	}
+	if a.SpeedKnots < 0 {
+		return fmt.Errorf("attack speed_kts must not be negative")
+	}
+	switch a.Profile {
+	case AttackDash:
+	case AttackLoiter:
+		if a.Commit <= 0 {
+			return fmt.Errorf("loiter attack requires a positive commit time")
+		}
+		if a.LoiterRadiusM < 0 {
+			return fmt.Errorf("loiter_radius_m must not be negative")
+		}
+	case AttackPopup:
+		if a.RangeM <= 0 {
+			return fmt.Errorf("popup attack requires a positive range_m")
+		}
+		if hasRoute {
+			return fmt.Errorf("popup attack starts from its target; remove waypoints")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown attack profile %q (want dash, loiter, or popup)", a.Profile)
+	}
+	if !hasRoute {
+		return fmt.Errorf("%s attack requires waypoints", a.Profile)
+	}
+	return nil
+}
+
+// armTrack sets up t's attack against target: a popup is placed at its
+// range and bearing, a loiter gets its orbit.
+func armTrack(t *track, a Attack, target Waypoint) {
+	t.attack, t.target = &a, target
+	if a.SpeedKnots == 0 {
+		t.attack.SpeedKnots = t.speed / knotsToMps
+	}
+	switch a.Profile {
+	case AttackPopup:
+		brg := a.Bearing * math.Pi / 180
+		t.lat = target.Lat + a.RangeM*math.Cos(brg)/metersPerDegreeLat
+		t.lon = target.Lon + a.RangeM*math.Sin(brg)/(metersPerDegreeLat*math.Cos(target.Lat*math.Pi/180))
+		t.alt = a.AltM
+		if t.alt == 0 {
+			t.alt = 150
+		}
+		t.committed = true
+		commit(t)
+	case AttackLoiter:
+		radius := a.LoiterRadiusM
+		if radius == 0 {
+			radius = 3000
+		}
+		t.maneuver = Maneuver{Behavior: BehaviorOrbit, OrbitRadiusM: radius}
+	}
+}
+
+// shouldCommit reports whether t begins its attack run at elapsed.
+func (t *track) shouldCommit(elapsed time.Duration) bool {
+	switch {
+	case t.attack == nil || t.committed:
+		return false
+	case t.attack.Profile == AttackLoiter:
+		return elapsed >= t.spawn+t.attack.Commit
+	default:
+		return !onRoute(t)
+	}
+}
+
+// commit points t at its target at attack speed.
+func commit(t *track) {
+	t.speed = t.attack.SpeedKnots * knotsToMps
+	t.heading = bearing(t.lat, t.lon, t.target.Lat, t.target.Lon)
+}
+
+// attackRun flies a committed track dt closer to its target and reports
+// whether it arrived.
+func attackRun(t *track, dt time.Duration) bool {
+	if t.speed*dt.Seconds() >= distance(t.lat, t.lon, t.target.Lat, t.target.Lon) {
+		t.lat, t.lon = t.target.Lat, t.target.Lon
+		return true
+	}
+	commit(t)
+	advanceTrack(t, dt)
+	return false
+}
@@ -0,0 +1,134 @@
+package sensor
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+const attackScenario = `
+defended: {lat: 38.89, lon: -77.03}
+tracks:
+  - id: dash-1
+    speed_kts: 300
+    attack: {profile: dash, speed_kts: 600}
+    waypoints:
+      - {lat: 38.95, lon: -77.10, alt: 500}
+  - id: loiter-1
+    speed_kts: 250
+    attack: {profile: loiter, commit: 60s, loiter_radius_m: 2000}
+    waypoints:
+      - {lat: 38.80, lon: -77.03, alt: 4000}
+  - id: popup-1
+    speed_kts: 500
+    attack: {profile: popup, range_m: 6000, bearing: 90}
+`
+
+// stepScenario moves every track one interval, as Run does without a store.
+func stepScenario(sim *Simulator) {
+	for _, tr := range sim.tracks {
+		if !tr.gone && sim.elapsed >= tr.spawn {
+			sim.move(tr)
+			tr.gone = tr.arrived
+		}
+	}
+	sim.elapsed += sim.cfg.Interval
+}
+
+func TestAttack_Profiles(t *testing.T) {
+	sc, err := ParseScenario([]byte(attackScenario))
+	if err != nil {
+		t.Fatalf("ParseScenario: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.Scenario = sc
+	sim := New(cfg)
+	dash, loiter, popup := sim.tracks[0], sim.tracks[1], sim.tracks[2]
+
+	if d := distance(38.89, -77.03, popup.lat, popup.lon); math.Abs(d-6000) > 1 || popup.alt != 150 {
+		t.Fatalf("expected popup 6km out at 150m, got %.0fm at %.0fm", d, popup.alt)
+	}
+	if math.Abs(popup.heading-270) > 0.1 || !popup.committed {
+		t.Fatalf("expected popup committed heading west, got %.1f", popup.heading)
+	}
+
+	for sim.elapsed < 59*time.Second {
+		stepScenario(sim)
+	}
+	if !dash.committed || dash.speed != 600*knotsToMps {
+		t.Fatalf("expected dash committed at 600kts, speed %.0f m/s", dash.speed)
+	}
+	if loiter.committed {
+		t.Fatal("loiter committed before its commit time")
+	}
+	if d := distance(38.80, -77.03, loiter.lat, loiter.lon); d > 4100 {
+		t.Fatalf("expected loiter to orbit its station, %.0fm away", d)
+	}
+	if !dash.gone || !popup.gone {
+		t.Fatal("expected dash and popup to have reached the target")
+	}
+
+	for sim.elapsed < 62*time.Second {
+		stepScenario(sim)
+	}
+	want := bearing(loiter.lat, loiter.lon, 38.89, -77.03)
+	if !loiter.committed || math.Abs(loiter.heading-want) > 0.5 {
+		t.Fatalf("expected loiter committed toward target (%.1f), heading %.1f", want, loiter.heading)
+	}
+}
+
+func TestAttack_Validate(t *testing.T) {
+	for name, doc := range map[string]string{
+		"no target":      "tracks: [{id: a, attack: {profile: dash}, waypoints: [{lat: 1, lon: 1}]}]",
+		"unknown":        "defended: {lat: 1, lon: 1}\ntracks: [{id: a, attack: {profile: ram}, waypoints: [{lat: 1, lon: 1}]}]",
+		"loiter no time": "defended: {lat: 1, lon: 1}\ntracks: [{id: a, attack: {profile: loiter}, waypoints: [{lat: 1, lon: 1}]}]",
+		"popup no range": "defended: {lat: 1, lon: 1}\ntracks: [{id: a, attack: {profile: popup}}]",
+		"dash no route":  "defended: {lat: 1, lon: 1}\ntracks: [{id: a, attack: {profile: dash}}]",
+	} {
+		if _, err := ParseScenario([]byte(doc)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestAttack_ArrivalDeletesTrack(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	sc, err := ParseScenario([]byte(`
+defended: {lat: 38.89, lon: -77.03}
+tracks:
+  - id: popup-1
+    attack: {profile: popup, range_m: 100, speed_kts: 600}
+`))
+	if err != nil {
+		t.Fatalf("ParseScenario: %v", err)
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	sim := New(Config{StoreAddr: addr, Interval: 50 * time.Millisecond, Scenario: sc})
+	sim.Step(context.Background(), client)
+	if _, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "popup-1"}); err != nil {
+		t.Fatalf("expected popup reported on spawn: %v", err)
+	}
+	// 100m at 600kts is under 7 ticks of 50ms.
+	for range 10 {
+		sim.Step(context.Background(), client)
+	}
+	if _, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "popup-1"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected popup deleted on reaching the target, got %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// batch collects a step's writes so they reach the store in one
+// BatchWriteEntities call instead of a unary call per track.
+type batch struct {
+	ops  []*storev1.WriteOp
+	done []func(*storev1.WriteResult) error // per op, run with its result
+
+	reports []*storev1.PublishEntitiesRequest // with Config.Publish, sent instead of creates and patches
+}
+
+func (b *batch) add(op *storev1.WriteOp, done func(*storev1.WriteResult) error) {
+	b.ops = append(b.ops, op)
+	b.done = append(b.done, done)
+}
+
+// flush sends the collected ops and runs each one's callback. Callbacks
+// may add follow-up ops, which are sent in another call.
+func (b *batch) flush(ctx context.Context, client storev1.EntityStoreServiceClient) error {
+	var errs []error
+	for len(b.ops) > 0 {
+		ops, done := b.ops, b.done
+		b.ops, b.done = nil, nil
+		resp, err := client.BatchWriteEntities(ctx, &storev1.BatchWriteEntitiesRequest{Ops: ops})
+		if err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+		for i, r := range resp.Results {
+			if err := done[i](r); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// resultErr returns a failed op's status as an error, or nil.
+func resultErr(r *storev1.WriteResult) error {
+	if codes.Code(r.Code) == codes.OK {
+		return nil
+	}
+	return status.Error(codes.Code(r.Code), r.Message)
+}
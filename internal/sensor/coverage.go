@@ -0,0 +1,178 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Coverage is the region a sensor can see: a circle of RangeM around its
+// site, optionally narrowed to a sector of Beamwidth degrees centred on
+// Azimuth.
+type Coverage struct {
+	Lat       float64 `yaml:"lat"`
+	Lon       float64 `yaml:"lon"`
+	RangeM    float64 `yaml:"range_m"`
+	Azimuth   float64 `yaml:"azimuth"`   // sector centre, degrees from north
+	Beamwidth float64 `yaml:"beamwidth"` // sector width, degrees; 0 or >=360 is a full circle
+}
+
+// Contains reports whether a point lies inside the coverage region.
+func (c Coverage) Contains(lat, lon float64) bool {
+	if distance(c.Lat, c.Lon, lat, lon) > c.RangeM {
+		return false
+	}
+	if c.Beamwidth <= 0 || c.Beamwidth >= 360 {
+		return true
+	}
+	off := math.Abs(math.Mod(bearing(c.Lat, c.Lon, lat, lon)-c.Azimuth+540, 360) - 180)
+	return off <= c.Beamwidth/2
+}
+
+// ParseCoverage parses "lat,lon,range_m" or
+// "lat,lon,range_m,azimuth,beamwidth".
+func ParseCoverage(s string) (Coverage, error) {
+	fields := strings.Split(s, ",")
+	if len(fields) != 3 && len(fields) != 5 {
+		return Coverage{}, fmt.Errorf("coverage %q: want lat,lon,range_m[,azimuth,beamwidth]", s)
+	}
+	vals := make([]float64, len(fields))
+	for i, f := range fields {
+		v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+		if err != nil {
+			return Coverage{}, fmt.Errorf("coverage %q: %w", s, err)
+		}
+		vals[i] = v
+	}
+	c := Coverage{Lat: vals[0], Lon: vals[1], RangeM: vals[2]}
+	if len(vals) == 5 {
+		c.Azimuth, c.Beamwidth = vals[3], vals[4]
+	}
+	return c, c.Validate()
+}
+
+// Validate checks the coverage has a usable range.
+func (c Coverage) Validate() error {
+	if c.RangeM <= 0 {
+		return fmt.Errorf("coverage range_m must be positive")
+	}
+	return nil
+}
+
+// SensorSpec describes one simulated sensor: its identity, what it can see,
+// how reliably it reports what it sees, and what its reports contain.
+type SensorSpec struct {
+	ID            string      `yaml:"id"`
+	Type          string      `yaml:"type"`
+	Coverage      *Coverage   `yaml:"coverage"`       // nil sees everywhere
+	DetectionProb float64     `yaml:"detection_prob"` // chance per update of reporting a covered track; 0 means 1
+	NoiseM        float64     `yaml:"noise_m"`        // 1-sigma position error per axis, meters
+	Radar         *RadarNoise `yaml:"radar"`          // range/bearing error; replaces NoiseM
+	Components    []string    `yaml:"components"`     // components reported; empty reports all
+}
+
+// Validate checks the sensor spec.
+func (s SensorSpec) Validate() error {
+	if s.DetectionProb < 0 || s.DetectionProb > 1 {
+		return fmt.Errorf("sensor %s: detection_prob must be between 0 and 1", s.ID)
+	}
+	if s.NoiseM < 0 {
+		return fmt.Errorf("sensor %s: noise_m must not be negative", s.ID)
+	}
+	if s.Radar != nil {
+		if s.NoiseM > 0 {
+			return fmt.Errorf("sensor %s: noise_m and radar noise are exclusive", s.ID)
+		}
+		if err := s.Radar.Validate(); err != nil {
+			return fmt.Errorf("sensor %s: %w", s.ID, err)
+		}
+	}
+	for _, c := range s.Components {
+		if !slices.Contains(reportComponents, c) {
+			return fmt.Errorf("sensor %s: unknown component %q, want one of %v", s.ID, c, reportComponents)
+		}
+	}
+	if s.Coverage != nil {
+		if err := s.Coverage.Validate(); err != nil {
+			return fmt.Errorf("sensor %s: %w", s.ID, err)
+		}
+	}
+	return nil
+}
+
+// Detects rolls one detection of a target at lat/lon: the target must be in
+// coverage and survive the detection-probability draw, taken from rng.
+func (s SensorSpec) Detects(rng *rand.Rand, lat, lon float64) bool {
+	if s.Coverage != nil && !s.Coverage.Contains(lat, lon) {
+		return false
+	}
+	return s.DetectionProb <= 0 || s.DetectionProb >= 1 || rng.Float64() < s.DetectionProb
+}
+
+// measure returns the reported position of a target at lat/lon, offset by
+// the radar's range/bearing error or else Gaussian noise of NoiseM meters per
+// axis, drawn from rng.
+func (s SensorSpec) measure(rng *rand.Rand, lat, lon float64) (float64, float64) {
+	if s.Radar != nil {
+		return s.Radar.Measure(rng, lat, lon)
+	}
+	if s.NoiseM <= 0 {
+		return lat, lon
+	}
+	north := rng.NormFloat64() * s.NoiseM
+	east := rng.NormFloat64() * s.NoiseM
+	return lat + north/metersPerDegreeLat, lon + east/(metersPerDegreeLat*math.Cos(lat*math.Pi/180))
+}
+
+// ParseSensors parses a sensor list of the form
+// "id:type[:detection_prob[:noise_m[:lat,lon,range_m[,azimuth,beamwidth]]]];...".
+// Empty optional fields keep their defaults.
+func ParseSensors(s string) ([]SensorSpec, error) {
+	var specs []SensorSpec
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		fields := strings.SplitN(part, ":", 5)
+		if len(fields) < 2 || fields[0] == "" {
+			return nil, fmt.Errorf("sensor %q: want id:type[:detection_prob[:noise_m[:coverage]]]", part)
+		}
+		spec := SensorSpec{ID: fields[0], Type: fields[1]}
+		if seen[spec.ID] {
+			return nil, fmt.Errorf("sensor %s: duplicate id", spec.ID)
+		}
+		seen[spec.ID] = true
+
+		if len(fields) > 2 && fields[2] != "" {
+			v, err := strconv.ParseFloat(fields[2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("sensor %s: detection_prob: %w", spec.ID, err)
+			}
+			spec.DetectionProb = v
+		}
+		if len(fields) > 3 && fields[3] != "" {
+			v, err := strconv.ParseFloat(fields[3], 64)
+			if err != nil {
+				return nil, fmt.Errorf("sensor %s: noise_m: %w", spec.ID, err)
+			}
+			spec.NoiseM = v
+		}
+		if len(fields) > 4 && fields[4] != "" {
+			c, err := ParseCoverage(fields[4])
+			if err != nil {
+				return nil, fmt.Errorf("sensor %s: %w", spec.ID, err)
+			}
+			spec.Coverage = &c
+		}
+		if err := spec.Validate(); err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
@@ -0,0 +1,109 @@
+package sensor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestCoverage_Circle(t *testing.T) {
+	c := Coverage{Lat: 38.9, Lon: -77.0, RangeM: 5000}
+	if !c.Contains(38.93, -77.0) { // ~3.3km north
+		t.Fatal("expected point inside range")
+	}
+	if c.Contains(38.95, -77.0) { // ~5.6km north
+		t.Fatal("expected point outside range")
+	}
+}
+
+func TestCoverage_Sector(t *testing.T) {
+	c := Coverage{Lat: 38.9, Lon: -77.0, RangeM: 10000, Azimuth: 0, Beamwidth: 90}
+	if !c.Contains(38.95, -76.99) { // north-northeast
+		t.Fatal("expected point inside sector")
+	}
+	if c.Contains(38.9, -76.95) { // due east, 90 deg off boresight
+		t.Fatal("expected point outside sector")
+	}
+	if c.Contains(38.85, -77.0) { // behind the sensor
+		t.Fatal("expected point behind sensor outside sector")
+	}
+}
+
+func TestParseCoverage(t *testing.T) {
+	c, err := ParseCoverage("38.9,-77.0,20000,45,120")
+	if err != nil {
+		t.Fatalf("ParseCoverage: %v", err)
+	}
+	if c.RangeM != 20000 || c.Azimuth != 45 || c.Beamwidth != 120 {
+		t.Fatalf("unexpected coverage: %+v", c)
+	}
+	for _, bad := range []string{"38.9,-77.0", "38.9,-77.0,x", "38.9,-77.0,0"} {
+		if _, err := ParseCoverage(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestSensorSpec_DetectionProb(t *testing.T) {
+	spec := SensorSpec{DetectionProb: 0.3}
+	rng := testRand()
+	hits := 0
+	for i := 0; i < 10000; i++ {
+		if spec.Detects(rng, 38.9, -77.0) {
+			hits++
+		}
+	}
+	if hits < 2700 || hits > 3300 {
+		t.Fatalf("expected ~30%% detections, got %d/10000", hits)
+	}
+	if !(SensorSpec{}).Detects(rng, 0, 0) {
+		t.Fatal("expected zero spec to always detect")
+	}
+}
+
+func TestSimulator_ReportsOnlyCoveredTracks(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	sc, err := ParseScenario([]byte(`
+sensors:
+  - id: eo-near
+    type: eo
+    coverage: {lat: 38.9, lon: -77.0, range_m: 5000}
+tracks:
+  - id: inside
+    sensor: eo-near
+    waypoints: [{lat: 38.91, lon: -77.0}]
+  - id: outside
+    sensor: eo-near
+    waypoints: [{lat: 39.2, lon: -77.0}]
+`))
+	if err != nil {
+		t.Fatalf("ParseScenario: %v", err)
+	}
+
+	sim := New(Config{StoreAddr: addr, Interval: 50 * time.Millisecond, Scenario: sc})
+	ctx, cancel := context.WithTimeout(context.Background(), 250*time.Millisecond)
+	defer cancel()
+	_ = sim.Run(ctx)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	resp, err := storev1.NewEntityStoreServiceClient(conn).ListEntities(context.Background(), &storev1.ListEntitiesRequest{
+		TypeFilter: entityv1.EntityType_ENTITY_TYPE_TRACK,
+	})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(resp.Entities) != 1 || resp.Entities[0].Id != "inside" {
+		t.Fatalf("expected only the covered track reported, got %v", resp.Entities)
+	}
+}
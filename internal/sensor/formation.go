@@ -0,0 +1,41 @@
+package sensor
+
+import (
+	"math"
+	"time"
+)
+
+// FormationSlot places a scenario track in formation on a lead track. The
+// wingman holds its offset in the lead's frame, so it turns, climbs, and
+// accelerates with the lead. At Break it leaves formation and flies its own
+// maneuver from wherever it is; wingmen sharing a Break split together.
+type FormationSlot struct {
+	Lead   string        `yaml:"lead"`    // ID of a track declared earlier
+	RightM float64       `yaml:"right_m"` // offset to the lead's right, meters; negative is left
+	BackM  float64       `yaml:"back_m"`  // offset behind the lead, meters; negative is ahead
+	UpM    float64       `yaml:"up_m"`    // offset above the lead, meters
+	Break  time.Duration `yaml:"break"`   // time to leave formation; 0 = never
+}
+
+// inFormation reports whether t is flying on its lead at elapsed. A wingman
+// whose lead has despawned breaks off.
+func (t *track) inFormation(elapsed time.Duration) bool {
+	if t.lead == nil || t.lead.gone {
+		return false
+	}
+	return t.slot.Break == 0 || elapsed < t.slot.Break
+}
+
+// holdFormation moves t to its slot off the lead, matching the lead's speed
+// and heading.
+func holdFormation(t *track) {
+	l := t.lead
+	hdg := l.heading * math.Pi / 180
+	north := -t.slot.BackM*math.Cos(hdg) - t.slot.RightM*math.Sin(hdg)
+	east := -t.slot.BackM*math.Sin(hdg) + t.slot.RightM*math.Cos(hdg)
+
+	t.lat = l.lat + north/metersPerDegreeLat
+	t.lon = l.lon + east/(metersPerDegreeLat*math.Cos(l.lat*math.Pi/180))
+	t.alt = l.alt + t.slot.UpM
+	t.speed, t.heading = l.speed, l.heading
+}
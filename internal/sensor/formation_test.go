@@ -0,0 +1,73 @@
+package sensor
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHoldFormation_OffsetsInLeadFrame(t *testing.T) {
+	lead := &track{lat: 38.9, lon: -77.0, alt: 3000, speed: 200, heading: 90}
+	wing := &track{lead: lead, slot: FormationSlot{RightM: 500, BackM: 300, UpM: -100}}
+
+	holdFormation(wing)
+
+	// Heading east: right is south, back is west.
+	north := (wing.lat - lead.lat) * metersPerDegreeLat
+	east := (wing.lon - lead.lon) * metersPerDegreeLat * math.Cos(lead.lat*math.Pi/180)
+	if math.Abs(north+500) > 0.01 || math.Abs(east+300) > 0.01 {
+		t.Fatalf("expected 500m south, 300m west of lead, got north %.2f east %.2f", north, east)
+	}
+	if wing.alt != 2900 || wing.speed != 200 || wing.heading != 90 {
+		t.Fatalf("expected wingman to match lead, got %+v", wing)
+	}
+}
+
+func TestFormation_FollowsLeadThenBreaks(t *testing.T) {
+	sc, err := ParseScenario([]byte(`
+tracks:
+  - id: lead
+    speed_kts: 300
+    waypoints:
+      - {lat: 38.90, lon: -77.00, alt: 3000}
+      - {lat: 38.90, lon: -76.98}
+      - {lat: 38.93, lon: -76.98}
+      - {lat: 38.93, lon: -77.00}
+  - id: wing
+    spawn: 2s
+    formation: {lead: lead, right_m: 400, back_m: 300, break: 20s}
+    maneuver: {behavior: straight}
+`))
+	if err != nil {
+		t.Fatalf("ParseScenario: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.Scenario = sc
+	sim := New(cfg)
+	lead, wing := sim.tracks[0], sim.tracks[1]
+	if wing.spawn != 2*time.Second {
+		t.Fatalf("expected wingman spawn 2s, got %s", wing.spawn)
+	}
+
+	// Through the lead's 90° turn the wingman stays on its slot.
+	want := math.Hypot(400, 300)
+	for ; sim.elapsed < 20*time.Second; sim.elapsed += cfg.Interval {
+		for _, tr := range sim.tracks {
+			sim.move(tr)
+		}
+		if d := distance(lead.lat, lead.lon, wing.lat, wing.lon); math.Abs(d-want) > 1 {
+			t.Fatalf("at %s: wingman %.1fm from lead, want %.1fm", sim.elapsed, d, want)
+		}
+	}
+
+	// After the break it flies straight on while the lead turns west.
+	for range 30 {
+		for _, tr := range sim.tracks {
+			sim.move(tr)
+		}
+		sim.elapsed += cfg.Interval
+	}
+	if d := distance(lead.lat, lead.lon, wing.lat, wing.lon); math.Abs(d-want) < 100 {
+		t.Fatalf("expected wingman to leave formation after break, still %.1fm from lead", d)
+	}
+}
@@ -0,0 +1,158 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Behavior is a track's large-scale motion pattern.
+type Behavior string
+
+const (
+	BehaviorStraight Behavior = "straight" // fly on, leaving the bbox (default)
+	BehaviorBounce   Behavior = "bounce"   // reflect off the bbox edges
+	BehaviorOrbit    Behavior = "orbit"    // circle clockwise at OrbitRadiusM
+)
+
+// ParseBehavior validates a behavior name. Empty means straight.
+func ParseBehavior(s string) (Behavior, error) {
+	switch b := Behavior(s); b {
+	case "":
+		return BehaviorStraight, nil
+	case BehaviorStraight, BehaviorBounce, BehaviorOrbit:
+		return b, nil
+	default:
+		return "", fmt.Errorf("unknown behavior %q (want straight, bounce, or orbit)", s)
+	}
+}
+
+// AltitudeStep sets the altitude a track heads for from At (time since the
+// track began free flight) onward.
+type AltitudeStep struct {
+	At  time.Duration `yaml:"at"`
+	Alt float64       `yaml:"alt"` // meters
+}
+
+// Maneuver describes how a free-flying track turns, climbs, and stays in
+// its area. The zero value flies straight and level.
+type Maneuver struct {
+	Behavior     Behavior       `yaml:"behavior"`
+	TurnRate     float64        `yaml:"turn_rate"`      // max deg/s; 0 turns instantly
+	JinkProb     float64        `yaml:"jink_prob"`      // chance per second of a random heading change
+	JinkMax      float64        `yaml:"jink_max"`       // largest random heading change, degrees
+	OrbitRadiusM float64        `yaml:"orbit_radius_m"` // orbit behavior only
+	ClimbRate    float64        `yaml:"climb_rate"`     // m/s toward the profile altitude; 0 is instant
+	Altitude     []AltitudeStep `yaml:"altitude_profile"`
+}
+
+// Validate checks the maneuver is usable.
+func (m Maneuver) Validate() error {
+	if _, err := ParseBehavior(string(m.Behavior)); err != nil {
+		return err
+	}
+	switch {
+	case m.Behavior == BehaviorOrbit && m.OrbitRadiusM <= 0:
+		return fmt.Errorf("orbit requires a positive orbit_radius_m")
+	case m.TurnRate < 0 || m.ClimbRate < 0:
+		return fmt.Errorf("turn_rate and climb_rate must not be negative")
+	case m.JinkProb < 0 || m.JinkProb > 1:
+		return fmt.Errorf("jink_prob must be between 0 and 1")
+	}
+	return nil
+}
+
+// Kinematics is the motion state a Maneuver steps.
+type Kinematics struct {
+	Lat, Lon, Alt float64
+	Speed         float64 // m/s
+	Heading       float64 // degrees, 0=north, clockwise
+}
+
+// ManeuverState carries a track's progress through its maneuver between
+// steps. The zero value starts on the current heading.
+type ManeuverState struct {
+	desired float64 // heading being turned toward
+	started bool
+	age     time.Duration
+}
+
+// Step advances k by dt: turn toward the desired heading within TurnRate,
+// climb toward the profile altitude, move, then apply the behavior's bbox
+// rule. A zero bbox disables bouncing. Jinks draw from rng.
+func (m Maneuver) Step(rng *rand.Rand, k *Kinematics, s *ManeuverState, dt time.Duration, bbox BBox) {
+	sec := dt.Seconds()
+	if !s.started {
+		s.desired, s.started = k.Heading, true
+	}
+	s.age += dt
+
+	if m.Behavior == BehaviorOrbit && m.OrbitRadiusM > 0 {
+		s.desired = normalizeHeading(s.desired + k.Speed/m.OrbitRadiusM*sec*180/math.Pi)
+	}
+	if m.JinkProb > 0 && rng.Float64() < m.JinkProb*sec {
+		s.desired = normalizeHeading(k.Heading + (rng.Float64()*2-1)*m.JinkMax)
+	}
+	k.Heading = turnToward(k.Heading, s.desired, m.TurnRate*sec)
+
+	if alt, ok := m.altitudeAt(s.age); ok {
+		k.Alt = approach(k.Alt, alt, m.ClimbRate*sec)
+	}
+
+	hdgRad := k.Heading * math.Pi / 180
+	ds := k.Speed * sec
+	k.Lat += (ds * math.Cos(hdgRad)) / metersPerDegreeLat
+	k.Lon += (ds * math.Sin(hdgRad)) / (metersPerDegreeLat * math.Cos(k.Lat*math.Pi/180))
+
+	if m.Behavior == BehaviorBounce && bbox != (BBox{}) {
+		bounce(k, s, bbox)
+	}
+}
+
+// altitudeAt returns the profile altitude in force at age.
+func (m Maneuver) altitudeAt(age time.Duration) (float64, bool) {
+	alt, ok := 0.0, false
+	for _, st := range m.Altitude {
+		if st.At <= age {
+			alt, ok = st.Alt, true
+		}
+	}
+	return alt, ok
+}
+
+// bounce reflects a track that crossed a bbox edge back inside.
+func bounce(k *Kinematics, s *ManeuverState, bbox BBox) {
+	if k.Lat < bbox.MinLat || k.Lat > bbox.MaxLat {
+		k.Lat = math.Max(bbox.MinLat, math.Min(bbox.MaxLat, k.Lat))
+		k.Heading = normalizeHeading(180 - k.Heading)
+		s.desired = k.Heading
+	}
+	if k.Lon < bbox.MinLon || k.Lon > bbox.MaxLon {
+		k.Lon = math.Max(bbox.MinLon, math.Min(bbox.MaxLon, k.Lon))
+		k.Heading = normalizeHeading(360 - k.Heading)
+		s.desired = k.Heading
+	}
+}
+
+// turnToward turns from heading toward desired by at most maxDeg the short
+// way round. maxDeg of 0 turns all the way.
+func turnToward(heading, desired, maxDeg float64) float64 {
+	diff := math.Mod(desired-heading+540, 360) - 180 // (-180, 180]
+	if maxDeg > 0 && math.Abs(diff) > maxDeg {
+		diff = math.Copysign(maxDeg, diff)
+	}
+	return normalizeHeading(heading + diff)
+}
+
+// approach moves v toward target by at most step. step of 0 jumps.
+func approach(v, target, step float64) float64 {
+	if step <= 0 || math.Abs(target-v) <= step {
+		return target
+	}
+	return v + math.Copysign(step, target-v)
+}
+
+func normalizeHeading(h float64) float64 {
+	return math.Mod(math.Mod(h, 360)+360, 360)
+}
@@ -0,0 +1,96 @@
+package sensor
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestManeuver_ZeroValueFliesStraight(t *testing.T) {
+	k := Kinematics{Lat: 38.9, Lon: -77.0, Alt: 2000, Speed: 100, Heading: 90}
+	tr := &track{lat: 38.9, lon: -77.0, alt: 2000, speed: 100, heading: 90}
+
+	var s ManeuverState
+	Maneuver{}.Step(testRand(), &k, &s, time.Second, BBox{})
+	advanceTrack(tr, time.Second)
+
+	if k.Lat != tr.lat || k.Lon != tr.lon || k.Heading != 90 || k.Alt != 2000 {
+		t.Fatalf("expected straight-and-level like advanceTrack, got %+v", k)
+	}
+}
+
+func TestManeuver_TurnRateLimitsTurn(t *testing.T) {
+	k := Kinematics{Lat: 38.9, Lon: -77.0, Speed: 100, Heading: 350}
+	s := ManeuverState{desired: 20, started: true}
+
+	Maneuver{TurnRate: 10}.Step(testRand(), &k, &s, time.Second, BBox{})
+	if math.Abs(k.Heading) > 1e-9 {
+		t.Fatalf("expected 10 deg turn across north to 0, got %.2f", k.Heading)
+	}
+	Maneuver{TurnRate: 10}.Step(testRand(), &k, &s, 2*time.Second, BBox{})
+	if math.Abs(k.Heading-20) > 1e-9 {
+		t.Fatalf("expected to settle on 20, got %.2f", k.Heading)
+	}
+}
+
+func TestManeuver_BounceStaysInBBox(t *testing.T) {
+	bb := BBox{MinLat: 38.8, MaxLat: 39.0, MinLon: -77.2, MaxLon: -76.9}
+	k := Kinematics{Lat: 38.99, Lon: -77.0, Speed: 250, Heading: 30}
+	var s ManeuverState
+	m := Maneuver{Behavior: BehaviorBounce}
+
+	for i := 0; i < 600; i++ {
+		m.Step(testRand(), &k, &s, time.Second, bb)
+		if k.Lat < bb.MinLat || k.Lat > bb.MaxLat || k.Lon < bb.MinLon || k.Lon > bb.MaxLon {
+			t.Fatalf("step %d: left bbox at (%f, %f)", i, k.Lat, k.Lon)
+		}
+	}
+}
+
+func TestManeuver_OrbitReturnsToStart(t *testing.T) {
+	k := Kinematics{Lat: 38.9, Lon: -77.0, Speed: 100, Heading: 0}
+	var s ManeuverState
+	m := Maneuver{Behavior: BehaviorOrbit, OrbitRadiusM: 1000}
+
+	// One lap is 2*pi*1000/100 ≈ 62.8s.
+	maxDist := 0.0
+	for i := 0; i < 628; i++ {
+		m.Step(testRand(), &k, &s, 100*time.Millisecond, BBox{})
+		maxDist = math.Max(maxDist, distance(38.9, -77.0, k.Lat, k.Lon))
+	}
+	if d := distance(38.9, -77.0, k.Lat, k.Lon); d > 50 {
+		t.Fatalf("expected to close the orbit, %.0fm from start", d)
+	}
+	if maxDist < 1900 || maxDist > 2100 {
+		t.Fatalf("expected ~2000m orbit diameter, got %.0f", maxDist)
+	}
+}
+
+func TestManeuver_AltitudeProfile(t *testing.T) {
+	k := Kinematics{Alt: 1000}
+	var s ManeuverState
+	m := Maneuver{ClimbRate: 50, Altitude: []AltitudeStep{{At: 0, Alt: 1000}, {At: 2 * time.Second, Alt: 2000}}}
+
+	m.Step(testRand(), &k, &s, time.Second, BBox{})
+	if k.Alt != 1000 {
+		t.Fatalf("expected to hold 1000m before the climb, got %.0f", k.Alt)
+	}
+	for i := 0; i < 3; i++ {
+		m.Step(testRand(), &k, &s, time.Second, BBox{})
+	}
+	if k.Alt != 1150 {
+		t.Fatalf("expected climb at 50 m/s to 1150m, got %.0f", k.Alt)
+	}
+}
+
+func TestManeuver_Validate(t *testing.T) {
+	if err := (Maneuver{Behavior: BehaviorOrbit}).Validate(); err == nil {
+		t.Fatal("expected orbit without radius to fail")
+	}
+	if err := (Maneuver{Behavior: "loiter"}).Validate(); err == nil {
+		t.Fatal("expected unknown behavior to fail")
+	}
+	if err := DefaultManeuver().Validate(); err != nil {
+		t.Fatalf("default maneuver invalid: %v", err)
+	}
+}
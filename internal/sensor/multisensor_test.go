@@ -0,0 +1,110 @@
+package sensor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestParseSensors(t *testing.T) {
+	specs, err := ParseSensors("radar-1:radar:0.9:50:38.9,-77.0,40000; eo-1:eo::5")
+	if err != nil {
+		t.Fatalf("ParseSensors: %v", err)
+	}
+	if len(specs) != 2 {
+		t.Fatalf("expected 2 sensors, got %d", len(specs))
+	}
+	r := specs[0]
+	if r.ID != "radar-1" || r.Type != "radar" || r.DetectionProb != 0.9 || r.NoiseM != 50 || r.Coverage == nil || r.Coverage.RangeM != 40000 {
+		t.Fatalf("unexpected radar spec: %+v", r)
+	}
+	if e := specs[1]; e.DetectionProb != 0 || e.NoiseM != 5 || e.Coverage != nil {
+		t.Fatalf("unexpected eo spec: %+v", e)
+	}
+
+	for _, bad := range []string{"radar-1", "a:x;a:y", "a:x:2", "a:x:1:-1"} {
+		if _, err := ParseSensors(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestSensorSpec_NoiseSpread(t *testing.T) {
+	spec := SensorSpec{NoiseM: 100}
+	var sumSq float64
+	const n = 5000
+	rng := testRand()
+	for i := 0; i < n; i++ {
+		lat, _ := spec.measure(rng, 38.9, -77.0)
+		d := (lat - 38.9) * metersPerDegreeLat
+		sumSq += d * d
+	}
+	if rms := sumSq / n; rms < 80*80 || rms > 120*120 {
+		t.Fatalf("expected ~100m sigma, got variance %.0f", rms)
+	}
+}
+
+func TestSimulator_MultipleSensorsReportSameTruth(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	sim := New(Config{
+		StoreAddr: addr,
+		Interval:  50 * time.Millisecond,
+		NumTracks: 2,
+		BBox:      BBox{MinLat: 38.8, MaxLat: 39.0, MinLon: -77.2, MaxLon: -76.9},
+		Sensors: []SensorSpec{
+			{ID: "radar-1", Type: "radar", NoiseM: 50},
+			{ID: "eo-1", Type: "eo", NoiseM: 5},
+		},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	_ = sim.Run(ctx)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	resp, err := client.ListEntities(context.Background(), &storev1.ListEntitiesRequest{
+		TypeFilter: entityv1.EntityType_ENTITY_TYPE_TRACK,
+	})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(resp.Entities) != 4 {
+		t.Fatalf("expected 2 tracks x 2 sensors, got %d", len(resp.Entities))
+	}
+
+	e, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "radar-1-track-0"})
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	src := &entityv1.SourceComponent{}
+	if err := e.Components["source"].UnmarshalTo(src); err != nil {
+		t.Fatalf("unmarshal source: %v", err)
+	}
+	if src.SensorId != "radar-1" || src.SensorType != "radar" {
+		t.Fatalf("unexpected source: %v", src)
+	}
+}
+
+func TestScenario_SensorsMustBeDeclared(t *testing.T) {
+	_, err := ParseScenario([]byte(`
+tracks:
+  - id: a
+    sensors: [radar-9]
+    waypoints: [{lat: 1, lon: 1}]
+`))
+	if err == nil {
+		t.Fatal("expected error for undeclared sensor")
+	}
+}
@@ -0,0 +1,42 @@
+package sensor
+
+import "slices"
+
+// reportComponents are the components a sensor report can carry.
+var reportComponents = []string{"position", "velocity", "source"}
+
+// Profile returns the built-in spec for a sensor type, with the given ID:
+//
+//   - radar: 50m range and 0.3° bearing error about its site
+//   - eo: exact position
+//
+// Every profile reports position, velocity, and source, so its tracks are
+// classifiable. Unknown types get an exact, all-component spec.
+func Profile(typ, id string) SensorSpec {
+	spec := SensorSpec{ID: id, Type: typ}
+	if typ == "radar" {
+		spec.Radar = &RadarNoise{RangeM: 50, BearingDeg: 0.3}
+	}
+	return spec
+}
+
+// reports reports whether spec's reports carry component key.
+func (s SensorSpec) reports(key string) bool {
+	return len(s.Components) == 0 || slices.Contains(s.Components, key)
+}
+
+// placeRadar returns spec with an unset (0,0) radar site moved to its
+// coverage centre, or else the centre of bbox.
+func placeRadar(spec SensorSpec, bbox BBox) SensorSpec {
+	if spec.Radar == nil || spec.Radar.SiteLat != 0 || spec.Radar.SiteLon != 0 {
+		return spec
+	}
+	radar := *spec.Radar
+	if spec.Coverage != nil {
+		radar.SiteLat, radar.SiteLon = spec.Coverage.Lat, spec.Coverage.Lon
+	} else {
+		radar.SiteLat, radar.SiteLon = (bbox.MinLat+bbox.MaxLat)/2, (bbox.MinLon+bbox.MaxLon)/2
+	}
+	spec.Radar = &radar
+	return spec
+}
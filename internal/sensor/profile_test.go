@@ -0,0 +1,58 @@
+package sensor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	r := Profile("radar", "radar-1")
+	if r.ID != "radar-1" || r.Type != "radar" || r.Radar == nil || r.Radar.RangeM <= 0 || r.Radar.BearingDeg <= 0 {
+		t.Fatalf("unexpected radar profile: %+v", r)
+	}
+	if eo := Profile("eo", "eo-1"); eo.Radar != nil || eo.NoiseM != 0 {
+		t.Fatalf("expected exact eo profile, got %+v", eo)
+	}
+	for _, key := range reportComponents {
+		if !r.reports(key) {
+			t.Fatalf("expected radar profile to report %s", key)
+		}
+	}
+}
+
+func TestBuildReport_Components(t *testing.T) {
+	tr := &track{id: "track-0", lat: 38.9, lon: -77.0, speed: 100, heading: 90}
+	spec := SensorSpec{ID: "esm-1", Type: "esm", Components: []string{"position", "source"}}
+
+	e, err := buildReport(testRand(), tr, spec, tr.id)
+	if err != nil {
+		t.Fatalf("buildReport: %v", err)
+	}
+	if _, ok := e.Components["velocity"]; ok || len(e.Components) != 2 {
+		t.Fatalf("expected only position and source, got %v", e.Components)
+	}
+
+	spec.Components = []string{"heading"}
+	if err := spec.Validate(); err == nil {
+		t.Fatal("expected unknown component to fail validation")
+	}
+}
+
+func TestNew_PlacesRadarSite(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Sensor = Profile("radar", "radar-1")
+
+	sim := New(cfg)
+	site := sim.tracks[0].sensors[0].Radar
+	if math.Abs(site.SiteLat-38.9) > 1e-9 || math.Abs(site.SiteLon+77.05) > 1e-9 {
+		t.Fatalf("expected bbox-centre site, got %v,%v", site.SiteLat, site.SiteLon)
+	}
+	if cfg.Sensor.Radar.SiteLat != 0 {
+		t.Fatal("New must not modify the caller's spec")
+	}
+
+	cfg.Sensor.Coverage = &Coverage{Lat: 38.95, Lon: -77.1, RangeM: 40000}
+	if site := New(cfg).tracks[0].sensors[0].Radar; site.SiteLat != 38.95 || site.SiteLon != -77.1 {
+		t.Fatalf("expected coverage-centre site, got %v,%v", site.SiteLat, site.SiteLon)
+	}
+}
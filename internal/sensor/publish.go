@@ -0,0 +1,61 @@
+package sensor
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// publisher keeps one PublishEntities stream open across steps, for
+// Config.Publish. Reports are applied by the store as they arrive; their
+// outcomes come back only when the stream closes, so a failed send closes
+// it to learn why, and the next step opens another.
+type publisher struct {
+	stream grpc.ClientStreamingClient[storev1.PublishEntitiesRequest, storev1.PublishEntitiesResponse]
+}
+
+// send streams reqs, opening the stream if none is open. It is opened
+// outside ctx's cancellation so Run can still close it for the summary.
+func (p *publisher) send(ctx context.Context, client storev1.EntityStoreServiceClient, reqs []*storev1.PublishEntitiesRequest) error {
+	if len(reqs) == 0 {
+		return nil
+	}
+	if p.stream == nil {
+		stream, err := client.PublishEntities(context.WithoutCancel(ctx))
+		if err != nil {
+			return fmt.Errorf("publish: %w", err)
+		}
+		p.stream = stream
+	}
+	for _, req := range reqs {
+		if err := p.stream.Send(req); err != nil {
+			// Send only says the stream is gone; CloseAndRecv says why.
+			if err := p.close(); err != nil {
+				return err
+			}
+			return fmt.Errorf("publish: %w", err)
+		}
+	}
+	return nil
+}
+
+// close ends the stream, if open, and logs what the store made of it.
+func (p *publisher) close() error {
+	if p.stream == nil {
+		return nil
+	}
+	resp, err := p.stream.CloseAndRecv()
+	p.stream = nil
+	if err != nil {
+		return fmt.Errorf("publish: %w", err)
+	}
+	for _, f := range resp.Failures {
+		slog.Error("publish refused", "track_id", f.EntityId, "index", f.Index, "code", codes.Code(f.Code), "error", f.Message)
+	}
+	slog.Info("publish stream closed", "accepted", resp.Accepted, "rejected", resp.Rejected)
+	return nil
+}
@@ -0,0 +1,66 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+)
+
+// RadarNoise is a radar's measurement error: Gaussian errors in range and
+// bearing about the radar site, rather than independent north/east noise.
+// Cross-range error grows with range, so distant targets report along an arc
+// the way real radar plots do.
+type RadarNoise struct {
+	SiteLat    float64 `yaml:"site_lat"`
+	SiteLon    float64 `yaml:"site_lon"`
+	RangeM     float64 `yaml:"range_m"`     // 1-sigma range error, meters
+	BearingDeg float64 `yaml:"bearing_deg"` // 1-sigma bearing error, degrees
+}
+
+// Validate checks the site is on the globe and the errors are not negative.
+func (n RadarNoise) Validate() error {
+	switch {
+	case n.SiteLat < -90 || n.SiteLat > 90 || n.SiteLon < -180 || n.SiteLon > 180:
+		return fmt.Errorf("radar site %v,%v is off the globe", n.SiteLat, n.SiteLon)
+	case n.RangeM < 0:
+		return fmt.Errorf("radar range noise must not be negative")
+	case n.BearingDeg < 0:
+		return fmt.Errorf("radar bearing noise must not be negative")
+	}
+	return nil
+}
+
+// Measure returns the reported position of a target at lat/lon: its range
+// and bearing from the site, each perturbed by a draw from rng, converted
+// back to lat/lon.
+func (n RadarNoise) Measure(rng *rand.Rand, lat, lon float64) (float64, float64) {
+	if n.RangeM <= 0 && n.BearingDeg <= 0 {
+		return lat, lon
+	}
+	r := distance(n.SiteLat, n.SiteLon, lat, lon) + rng.NormFloat64()*n.RangeM
+	b := bearing(n.SiteLat, n.SiteLon, lat, lon) + rng.NormFloat64()*n.BearingDeg
+	if r < 0 {
+		r, b = -r, b+180
+	}
+	bRad := b * math.Pi / 180
+	north, east := r*math.Cos(bRad), r*math.Sin(bRad)
+	return n.SiteLat + north/metersPerDegreeLat,
+		n.SiteLon + east/(metersPerDegreeLat*math.Cos(n.SiteLat*math.Pi/180))
+}
+
+// ParseSite parses a radar site of the form "lat,lon".
+func ParseSite(s string) (lat, lon float64, err error) {
+	latS, lonS, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, 0, fmt.Errorf("site %q: want lat,lon", s)
+	}
+	if lat, err = strconv.ParseFloat(strings.TrimSpace(latS), 64); err != nil {
+		return 0, 0, fmt.Errorf("site %q: %w", s, err)
+	}
+	if lon, err = strconv.ParseFloat(strings.TrimSpace(lonS), 64); err != nil {
+		return 0, 0, fmt.Errorf("site %q: %w", s, err)
+	}
+	return lat, lon, nil
+}
@@ -0,0 +1,52 @@
+package sensor
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRadarNoise_ZeroIsExact(t *testing.T) {
+	n := RadarNoise{SiteLat: 38.9, SiteLon: -77.0}
+	if lat, lon := n.Measure(testRand(), 38.95, -76.9); lat != 38.95 || lon != -76.9 {
+		t.Fatalf("expected exact position, got %v,%v", lat, lon)
+	}
+}
+
+func TestRadarNoise_ErrorGeometry(t *testing.T) {
+	// Target 20km due north of the site: range error is north-south,
+	// bearing error is east-west.
+	n := RadarNoise{SiteLat: 38.9, SiteLon: -77.0, RangeM: 30, BearingDeg: 0.5}
+	tgtLat, tgtLon := 38.9+20000/metersPerDegreeLat, -77.0
+
+	rng := testRand()
+	var sumN, sumE float64
+	const samples = 5000
+	for i := 0; i < samples; i++ {
+		lat, lon := n.Measure(rng, tgtLat, tgtLon)
+		dn := (lat - tgtLat) * metersPerDegreeLat
+		de := (lon - tgtLon) * metersPerDegreeLat * math.Cos(38.9*math.Pi/180)
+		sumN += dn * dn
+		sumE += de * de
+	}
+	sigmaN, sigmaE := math.Sqrt(sumN/samples), math.Sqrt(sumE/samples)
+
+	// Cross-range sigma is range * bearing sigma: 20km * 0.5deg ≈ 175m.
+	if sigmaN < 25 || sigmaN > 35 {
+		t.Fatalf("expected ~30m range error, got %.1fm", sigmaN)
+	}
+	if sigmaE < 155 || sigmaE > 195 {
+		t.Fatalf("expected ~175m cross-range error, got %.1fm", sigmaE)
+	}
+}
+
+func TestParseSite(t *testing.T) {
+	lat, lon, err := ParseSite("38.9, -77.05")
+	if err != nil || lat != 38.9 || lon != -77.05 {
+		t.Fatalf("ParseSite = %v,%v,%v", lat, lon, err)
+	}
+	for _, bad := range []string{"38.9", "x,1", "1,y"} {
+		if _, _, err := ParseSite(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package sensor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/watch"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// RecordedEvent is one entity event captured by `lattice-cli record`.
+type RecordedEvent struct {
+	Time  time.Time
+	Event *storev1.EntityEvent
+}
+
+// recordLine is the NDJSON form of a RecordedEvent:
+//
+//	{"time":"2026-10-16T12:00:00.5Z","event":{"type":"EVENT_TYPE_UPDATED","entity":{...}}}
+type recordLine struct {
+	Time  time.Time       `json:"time"`
+	Event json.RawMessage `json:"event"`
+}
+
+// MarshalRecordedEvent encodes ev as one NDJSON line, without the newline.
+func MarshalRecordedEvent(ev RecordedEvent) ([]byte, error) {
+	event, err := protojson.Marshal(ev.Event)
+	if err != nil {
+		return nil, fmt.Errorf("marshal event: %w", err)
+	}
+	return json.Marshal(recordLine{Time: ev.Time, Event: event})
+}
+
+// LoadRecording reads an NDJSON recording file.
+func LoadRecording(path string) ([]RecordedEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open recording: %w", err)
+	}
+	defer f.Close()
+	return ReadRecording(f)
+}
+
+// ReadRecording decodes NDJSON recorded events, skipping blank lines. Events
+// must be in time order.
+func ReadRecording(r io.Reader) ([]RecordedEvent, error) {
+	var events []RecordedEvent
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		var rl recordLine
+		if err := json.Unmarshal([]byte(line), &rl); err != nil {
+			return nil, fmt.Errorf("recording line %d: %w", n, err)
+		}
+		ev := &storev1.EntityEvent{}
+		if err := protojson.Unmarshal(rl.Event, ev); err != nil {
+			return nil, fmt.Errorf("recording line %d: event: %w", n, err)
+		}
+		if ev.Entity == nil || ev.Entity.Id == "" {
+			return nil, fmt.Errorf("recording line %d: event has no entity", n)
+		}
+		if len(events) > 0 && rl.Time.Before(events[len(events)-1].Time) {
+			return nil, fmt.Errorf("recording line %d: out of time order", n)
+		}
+		events = append(events, RecordedEvent{Time: rl.Time, Event: ev})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read recording: %w", err)
+	}
+	return events, nil
+}
+
+// ReplayTiming is how a replay spaces recorded events.
+type ReplayTiming string
+
+const (
+	TimingOriginal ReplayTiming = "original" // the recorded gaps
+	TimingScaled   ReplayTiming = "scaled"   // the recorded gaps divided by Speed
+	TimingStepped  ReplayTiming = "stepped"  // Step apart, ignoring recorded times
+)
+
+// ParseTiming parses a replay timing name.
+func ParseTiming(s string) (ReplayTiming, error) {
+	switch t := ReplayTiming(s); t {
+	case TimingOriginal, TimingScaled, TimingStepped:
+		return t, nil
+	}
+	return "", fmt.Errorf("unknown replay timing %q (want original, scaled, or stepped)", s)
+}
+
+// Replay re-publishes a recording in place of simulated tracks.
+type Replay struct {
+	Events     []RecordedEvent
+	Timing     ReplayTiming      // empty means scaled
+	Speed      float64           // time scale; 2 plays twice as fast, 0 means 1
+	Step       time.Duration     // stepped: gap between events; 0 plays them back to back
+	IDPrefix   string            // prepended to every replayed entity ID
+	IDMap      map[string]string // renames recorded IDs before IDPrefix is applied
+	Components []string          // components to publish; empty publishes all
+	Labels     map[string]string // replace each entity's recorded labels; empty keeps them
+}
+
+// Validate checks the replay settings.
+func (r *Replay) Validate() error {
+	switch {
+	case r.Speed < 0:
+		return fmt.Errorf("replay speed must not be negative")
+	case r.Step < 0:
+		return fmt.Errorf("replay step must not be negative")
+	}
+	if r.Timing != "" {
+		if _, err := ParseTiming(string(r.Timing)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseIDMap parses "old=new,old2=new2".
+func ParseIDMap(s string) (map[string]string, error) {
+	m := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(pair, "=")
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("id map %q: want old=new", pair)
+		}
+		m[from] = to
+	}
+	return m, nil
+}
+
+// mapID returns the ID a recorded entity is replayed under.
+func (r *Replay) mapID(id string) string {
+	if to, ok := r.IDMap[id]; ok {
+		id = to
+	}
+	return r.IDPrefix + id
+}
+
+// offset returns when event i plays, relative to the start of the replay.
+func (r *Replay) offset(i int) time.Duration {
+	if r.Timing == TimingStepped {
+		return time.Duration(i) * r.Step
+	}
+	d := r.Events[i].Time.Sub(r.Events[0].Time)
+	if r.Timing != TimingOriginal && r.Speed > 0 {
+		d = time.Duration(float64(d) / r.Speed)
+	}
+	return d
+}
+
+// runReplay publishes the recording in place of simulating.
+func (s *Simulator) runReplay(ctx context.Context, client storev1.EntityStoreServiceClient) error {
+	slog.Info("replaying recording", "events", len(s.cfg.Replay.Events), "store_addr", s.cfg.StoreAddr)
+	return s.cfg.Replay.Run(ctx, client)
+}
+
+// Run publishes each recorded event at its offset, in order and one at a
+// time, and returns when the recording ends or ctx is cancelled. Stepped
+// timing makes the writes a deterministic sequence, for tests and load.
+func (r *Replay) Run(ctx context.Context, client storev1.EntityStoreServiceClient) error {
+	timing := r.Timing
+	if timing == "" {
+		timing = TimingScaled
+	}
+	slog.Info("replay started", "events", len(r.Events), "timing", timing, "speed", r.Speed, "step", r.Step)
+
+	start := time.Now()
+	failed := 0
+	for i, ev := range r.Events {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(start.Add(r.offset(i)))):
+		}
+		if err := r.publish(ctx, client, ev.Event); err != nil {
+			failed++
+			slog.Error("replay event failed", "entity_id", ev.Event.Entity.Id, "error", err)
+		}
+	}
+	slog.Info("replay finished", "events", len(r.Events), "failed", failed, "elapsed", time.Since(start))
+	return nil
+}
+
+// publish applies one recorded event. Creates and updates are both upserts,
+// so a recording that starts mid-stream still replays.
+func (r *Replay) publish(ctx context.Context, client storev1.EntityStoreServiceClient, ev *storev1.EntityEvent) error {
+	id := r.mapID(ev.Entity.Id)
+	if watch.Removed(ev) {
+		if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: id}); err != nil {
+			return fmt.Errorf("delete %s: %w", id, err)
+		}
+		return nil
+	}
+
+	entity := proto.Clone(ev.Entity).(*entityv1.Entity)
+	entity.Id = id
+	entity.CreatedAt, entity.UpdatedAt = nil, nil
+	if len(r.Labels) > 0 {
+		entity.Labels = r.Labels
+	}
+	if len(r.Components) > 0 {
+		for key := range entity.Components {
+			if !slices.Contains(r.Components, key) {
+				delete(entity.Components, key)
+			}
+		}
+	}
+
+	existing, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: id})
+	if err != nil {
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity}); err != nil {
+			return fmt.Errorf("create %s: %w", id, err)
+		}
+		return nil
+	}
+
+	// The recorded HLC belongs to the original run; carry the stored one so
+	// the store's merge accepts the replayed components.
+	entity.HlcPhysical, entity.HlcLogical, entity.HlcNode = existing.HlcPhysical, existing.HlcLogical, existing.HlcNode
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity}); err != nil {
+		return fmt.Errorf("update %s: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package sensor
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func recordedTrack(t *testing.T, typ storev1.EventType, at time.Time, id string, lat float64) RecordedEvent {
+	t.Helper()
+	pos, err := anypb.New(&entityv1.PositionComponent{Lat: lat, Lon: -77.0})
+	if err != nil {
+		t.Fatalf("pack position: %v", err)
+	}
+	cl, err := anypb.New(&entityv1.ClassificationComponent{Label: "military"})
+	if err != nil {
+		t.Fatalf("pack classification: %v", err)
+	}
+	return RecordedEvent{Time: at, Event: &storev1.EntityEvent{
+		Type: typ,
+		Entity: &entityv1.Entity{
+			Id:          id,
+			Type:        entityv1.EntityType_ENTITY_TYPE_TRACK,
+			Components:  map[string]*anypb.Any{"position": pos, "classification": cl},
+			HlcPhysical: 1, // from the recording run; must not win or lose merges
+		},
+	}}
+}
+
+func TestRecording_RoundTrip(t *testing.T) {
+	t0 := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	var buf bytes.Buffer
+	for _, ev := range []RecordedEvent{
+		recordedTrack(t, storev1.EventType_EVENT_TYPE_CREATED, t0, "track-0", 38.9),
+		recordedTrack(t, storev1.EventType_EVENT_TYPE_UPDATED, t0.Add(time.Second), "track-0", 38.91),
+	} {
+		line, err := MarshalRecordedEvent(ev)
+		if err != nil {
+			t.Fatalf("MarshalRecordedEvent: %v", err)
+		}
+		buf.Write(line)
+		buf.WriteString("\n\n")
+	}
+
+	events, err := ReadRecording(&buf)
+	if err != nil {
+		t.Fatalf("ReadRecording: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if !events[1].Time.Equal(t0.Add(time.Second)) || events[1].Event.Type != storev1.EventType_EVENT_TYPE_UPDATED {
+		t.Fatalf("unexpected second event: %v %v", events[1].Time, events[1].Event.Type)
+	}
+	pos := &entityv1.PositionComponent{}
+	if err := events[1].Event.Entity.Components["position"].UnmarshalTo(pos); err != nil || pos.Lat != 38.91 {
+		t.Fatalf("position did not round-trip: %v %v", pos, err)
+	}
+
+	for _, bad := range []string{
+		"not json",
+		`{"time":"2026-10-16T12:00:00Z","event":{"type":"EVENT_TYPE_CREATED"}}`,
+		`{"time":"2026-10-16T12:00:01Z","event":{"entity":{"id":"a"}}}` + "\n" + `{"time":"2026-10-16T12:00:00Z","event":{"entity":{"id":"a"}}}`,
+	} {
+		if _, err := ReadRecording(strings.NewReader(bad)); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestParseIDMap(t *testing.T) {
+	m, err := ParseIDMap("track-0=bogey-1, track-1=bogey-2")
+	if err != nil {
+		t.Fatalf("ParseIDMap: %v", err)
+	}
+	if m["track-0"] != "bogey-1" || m["track-1"] != "bogey-2" {
+		t.Fatalf("unexpected map %v", m)
+	}
+	if _, err := ParseIDMap("track-0"); err == nil {
+		t.Fatal("expected error for missing =")
+	}
+}
+
+func TestSimulator_Replay(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	// Four seconds of recording replayed at 20x.
+	t0 := time.Now().Add(-time.Hour)
+	replay := &Replay{
+		Events: []RecordedEvent{
+			recordedTrack(t, storev1.EventType_EVENT_TYPE_CREATED, t0, "track-0", 38.9),
+			recordedTrack(t, storev1.EventType_EVENT_TYPE_UPDATED, t0.Add(time.Second), "track-1", 38.8),
+			recordedTrack(t, storev1.EventType_EVENT_TYPE_UPDATED, t0.Add(2*time.Second), "track-0", 38.95),
+			recordedTrack(t, storev1.EventType_EVENT_TYPE_DELETED, t0.Add(4*time.Second), "track-1", 0),
+		},
+		Speed:      20,
+		IDPrefix:   "replay-",
+		IDMap:      map[string]string{"track-0": "bogey"},
+		Components: []string{"position"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := New(Config{StoreAddr: addr, Interval: time.Second, Replay: replay}).Run(ctx); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Fatalf("expected ~200ms at 20x, took %v", elapsed)
+	}
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	e, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "replay-bogey"})
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	pos := &entityv1.PositionComponent{}
+	if err := e.Components["position"].UnmarshalTo(pos); err != nil {
+		t.Fatalf("unmarshal position: %v", err)
+	}
+	if pos.Lat != 38.95 {
+		t.Fatalf("expected replayed update to land (lat 38.95), got %v", pos.Lat)
+	}
+	if _, ok := e.Components["classification"]; ok {
+		t.Fatal("expected classification filtered out")
+	}
+	if _, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "replay-track-1"}); err == nil {
+		t.Fatal("expected replay-track-1 deleted")
+	}
+}
+
+func TestReplay_Timing(t *testing.T) {
+	t0 := time.Now()
+	r := &Replay{
+		Events: []RecordedEvent{
+			recordedTrack(t, storev1.EventType_EVENT_TYPE_CREATED, t0, "track-0", 38.9),
+			recordedTrack(t, storev1.EventType_EVENT_TYPE_UPDATED, t0.Add(4*time.Second), "track-0", 38.8),
+			recordedTrack(t, storev1.EventType_EVENT_TYPE_UPDATED, t0.Add(5*time.Second), "track-0", 38.7),
+		},
+		Speed: 2,
+		Step:  100 * time.Millisecond,
+	}
+	for _, tc := range []struct {
+		timing ReplayTiming
+		want   time.Duration
+	}{
+		{"", 2500 * time.Millisecond},
+		{TimingScaled, 2500 * time.Millisecond},
+		{TimingOriginal, 5 * time.Second},
+		{TimingStepped, 200 * time.Millisecond},
+	} {
+		r.Timing = tc.timing
+		if got := r.offset(2); got != tc.want {
+			t.Fatalf("%q: offset = %v, want %v", tc.timing, got, tc.want)
+		}
+	}
+
+	if _, err := ParseTiming("warp"); err == nil {
+		t.Fatal("expected error for unknown timing")
+	}
+	if err := (&Replay{Timing: "warp"}).Validate(); err == nil {
+		t.Fatal("expected Validate to reject unknown timing")
+	}
+	if err := (&Replay{Step: -time.Second}).Validate(); err == nil {
+		t.Fatal("expected Validate to reject negative step")
+	}
+}
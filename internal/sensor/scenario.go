@@ -0,0 +1,242 @@
+package sensor
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// Scenario is a reproducible set of tracks loaded from YAML:
+//
+//	bbox: {min_lat: 38.8, max_lat: 39.0, min_lon: -77.2, max_lon: -76.9}
+//	maneuver: {behavior: bounce, turn_rate: 3}
+//	sensors:
+//	  - id: radar-1
+//	    type: radar
+//	    detection_prob: 0.9
+//	    coverage: {lat: 38.9, lon: -77.05, range_m: 40000}
+//	tracks:
+//	  - id: bogey-1
+//	    sensors: [radar-1, eo-1]
+//	    speed_kts: 450
+//	    spawn: 10s
+//	    despawn: 5m
+//	    loop: false
+//	    waypoints:
+//	      - {lat: 38.95, lon: -77.15, alt: 3000}
+//	      - {lat: 38.85, lon: -76.95}
+//	  - id: bogey-2
+//	    formation: {lead: bogey-1, right_m: 400, back_m: 300, break: 2m}
+//	  - id: leaker-1
+//	    spawn: 1m
+//	    attack: {profile: popup, range_m: 8000, bearing: 300, speed_kts: 550}
+type Scenario struct {
+	BBox     *BBox           `yaml:"bbox"`     // bounce area; default the simulator's
+	Maneuver Maneuver        `yaml:"maneuver"` // default for tracks without their own
+	Defended *Waypoint       `yaml:"defended"` // default target for attack tracks
+	Sensors  []SensorSpec    `yaml:"sensors"`  // coverage and Pd for sensors tracks name
+	Tracks   []ScenarioTrack `yaml:"tracks"`
+}
+
+// ScenarioTrack scripts one track. It appears at its first waypoint at Spawn
+// (time since the simulator started), flies the waypoints in order at
+// SpeedKnots, and is deleted at Despawn if set. After the last waypoint it
+// either loops back to the first or flies on under its Maneuver. A track with
+// a Formation has no waypoints: it flies on its lead until the break. A track
+// with an Attack flies a hostile profile against the defended asset.
+type ScenarioTrack struct {
+	ID         string         `yaml:"id"`
+	Sensor     string         `yaml:"sensor"`      // reporting sensor ID; default the simulator's
+	SensorType string         `yaml:"sensor_type"` // for sensors not declared under sensors
+	Sensors    []string       `yaml:"sensors"`     // several declared sensors, each reporting its own entity
+	SpeedKnots float64        `yaml:"speed_kts"`
+	Spawn      time.Duration  `yaml:"spawn"`
+	Despawn    time.Duration  `yaml:"despawn"` // 0 = never
+	Loop       bool           `yaml:"loop"`
+	Waypoints  []Waypoint     `yaml:"waypoints"`
+	Maneuver   *Maneuver      `yaml:"maneuver"` // overrides the scenario default
+	Formation  *FormationSlot `yaml:"formation"`
+	Attack     *Attack        `yaml:"attack"`
+}
+
+// Waypoint is a point on a scripted route. Alt of 0 keeps the current
+// altitude.
+type Waypoint struct {
+	Lat float64 `yaml:"lat"`
+	Lon float64 `yaml:"lon"`
+	Alt float64 `yaml:"alt"`
+}
+
+// LoadScenario reads and validates a scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read scenario: %w", err)
+	}
+	return ParseScenario(data)
+}
+
+// ParseScenario decodes and validates scenario YAML.
+func ParseScenario(data []byte) (*Scenario, error) {
+	var sc Scenario
+	if err := yaml.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("parse scenario: %w", err)
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// Validate checks that every track is well formed and IDs are unique.
+func (sc *Scenario) Validate() error {
+	if err := sc.Maneuver.Validate(); err != nil {
+		return fmt.Errorf("maneuver: %w", err)
+	}
+	if sc.BBox != nil {
+		if err := sc.BBox.Validate(); err != nil {
+			return err
+		}
+	}
+	sensors := make(map[string]bool, len(sc.Sensors))
+	for i, spec := range sc.Sensors {
+		switch {
+		case spec.ID == "":
+			return fmt.Errorf("sensor %d: id is required", i)
+		case sensors[spec.ID]:
+			return fmt.Errorf("sensor %s: duplicate id", spec.ID)
+		}
+		if err := spec.Validate(); err != nil {
+			return err
+		}
+		sensors[spec.ID] = true
+	}
+
+	seen := make(map[string]bool, len(sc.Tracks))
+	for i, st := range sc.Tracks {
+		if st.Maneuver != nil {
+			if err := st.Maneuver.Validate(); err != nil {
+				return fmt.Errorf("track %s: maneuver: %w", st.ID, err)
+			}
+		}
+		switch {
+		case st.ID == "":
+			return fmt.Errorf("track %d: id is required", i)
+		case seen[st.ID]:
+			return fmt.Errorf("track %s: duplicate id", st.ID)
+		case st.Formation != nil && len(st.Waypoints) > 0:
+			return fmt.Errorf("track %s: set waypoints or formation, not both", st.ID)
+		case st.Formation != nil && st.Attack != nil:
+			return fmt.Errorf("track %s: set formation or attack, not both", st.ID)
+		case st.Formation == nil && st.Attack == nil && len(st.Waypoints) == 0:
+			return fmt.Errorf("track %s: at least one waypoint is required", st.ID)
+		case st.SpeedKnots < 0:
+			return fmt.Errorf("track %s: speed_kts must not be negative", st.ID)
+		case st.Spawn < 0:
+			return fmt.Errorf("track %s: spawn must not be negative", st.ID)
+		case st.Despawn != 0 && st.Despawn <= st.Spawn:
+			return fmt.Errorf("track %s: despawn must be after spawn", st.ID)
+		case st.Sensor != "" && len(st.Sensors) > 0:
+			return fmt.Errorf("track %s: set sensor or sensors, not both", st.ID)
+		}
+		for _, id := range st.Sensors {
+			if !sensors[id] {
+				return fmt.Errorf("track %s: sensor %s is not declared", st.ID, id)
+			}
+		}
+		if st.Attack != nil {
+			if err := st.Attack.Validate(len(st.Waypoints) > 0, sc.Defended != nil); err != nil {
+				return fmt.Errorf("track %s: %w", st.ID, err)
+			}
+		}
+		if f := st.Formation; f != nil {
+			switch {
+			case !seen[f.Lead]:
+				return fmt.Errorf("track %s: formation lead %q must be a track declared earlier", st.ID, f.Lead)
+			case f.Break < 0:
+				return fmt.Errorf("track %s: formation break must not be negative", st.ID)
+			}
+		}
+		seen[st.ID] = true
+	}
+	return nil
+}
+
+// newScenarioTrack builds a track positioned at its first waypoint. def is
+// the maneuver used when the track sets none. Formation tracks are placed
+// once their lead is known.
+func newScenarioTrack(st ScenarioTrack, def Maneuver) *track {
+	t := &track{
+		id:       st.ID,
+		speed:    st.SpeedKnots * knotsToMps,
+		loop:     st.Loop,
+		spawn:    st.Spawn,
+		despawn:  st.Despawn,
+		maneuver: def,
+	}
+	if st.Maneuver != nil {
+		t.maneuver = *st.Maneuver
+	}
+	if len(st.Waypoints) == 0 {
+		return t
+	}
+	t.lat, t.lon, t.alt = st.Waypoints[0].Lat, st.Waypoints[0].Lon, st.Waypoints[0].Alt
+	t.route, t.next = st.Waypoints, 1
+	if len(st.Waypoints) > 1 {
+		t.heading = bearing(t.lat, t.lon, st.Waypoints[1].Lat, st.Waypoints[1].Lon)
+	}
+	return t
+}
+
+// onRoute reports whether a track still has waypoints to fly.
+func onRoute(t *track) bool {
+	return t.route != nil && (t.next < len(t.route) || (t.loop && len(t.route) > 1))
+}
+
+// followRoute steers toward the next waypoint and moves dt along the route.
+// A track with no waypoints left dead-reckons on its last heading.
+func followRoute(t *track, dt time.Duration) {
+	if t.next >= len(t.route) {
+		if !t.loop || len(t.route) < 2 {
+			advanceTrack(t, dt)
+			return
+		}
+		t.next = 0
+	}
+
+	wp := t.route[t.next]
+	step := t.speed * dt.Seconds()
+	if step >= distance(t.lat, t.lon, wp.Lat, wp.Lon) {
+		t.lat, t.lon = wp.Lat, wp.Lon
+		if wp.Alt != 0 {
+			t.alt = wp.Alt
+		}
+		t.next++
+		if t.next < len(t.route) || t.loop {
+			n := t.route[t.next%len(t.route)]
+			t.heading = bearing(t.lat, t.lon, n.Lat, n.Lon)
+		}
+		return
+	}
+
+	t.heading = bearing(t.lat, t.lon, wp.Lat, wp.Lon)
+	advanceTrack(t, dt)
+}
+
+// bearing returns the flat-earth heading from one point to another in
+// degrees, 0=north, clockwise.
+func bearing(lat, lon, toLat, toLon float64) float64 {
+	north := (toLat - lat) * metersPerDegreeLat
+	east := (toLon - lon) * metersPerDegreeLat * math.Cos(lat*math.Pi/180)
+	return math.Mod(math.Atan2(east, north)*180/math.Pi+360, 360)
+}
+
+// distance returns the flat-earth distance between two points in meters.
+func distance(lat, lon, toLat, toLon float64) float64 {
+	north := (toLat - lat) * metersPerDegreeLat
+	east := (toLon - lon) * metersPerDegreeLat * math.Cos(lat*math.Pi/180)
+	return math.Hypot(north, east)
+}
@@ -0,0 +1,157 @@
+package sensor
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestLoadScenario(t *testing.T) {
+	sc, err := LoadScenario("testdata/scenario.yaml")
+	if err != nil {
+		t.Fatalf("LoadScenario: %v", err)
+	}
+	if len(sc.Tracks) != 2 {
+		t.Fatalf("expected 2 tracks, got %d", len(sc.Tracks))
+	}
+	late := sc.Tracks[1]
+	if late.Spawn != 200*time.Millisecond || late.Despawn != 400*time.Millisecond {
+		t.Fatalf("unexpected spawn/despawn: %s %s", late.Spawn, late.Despawn)
+	}
+	if sc.Tracks[0].Sensor != "radar-1" || len(sc.Tracks[0].Waypoints) != 2 {
+		t.Fatalf("unexpected first track: %+v", sc.Tracks[0])
+	}
+}
+
+func TestLoadScenario_DeployExamples(t *testing.T) {
+	paths, err := filepath.Glob("../../deploy/scenarios/*.yaml")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no example scenarios found: %v", err)
+	}
+	for _, path := range paths {
+		if _, err := LoadScenario(path); err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+	}
+}
+
+func TestParseScenario_Invalid(t *testing.T) {
+	for name, doc := range map[string]string{
+		"missing id":         "tracks: [{waypoints: [{lat: 1, lon: 1}]}]",
+		"no waypoints":       "tracks: [{id: a}]",
+		"duplicate id":       "tracks: [{id: a, waypoints: [{lat: 1, lon: 1}]}, {id: a, waypoints: [{lat: 1, lon: 1}]}]",
+		"despawn too early":  "tracks: [{id: a, spawn: 10s, despawn: 5s, waypoints: [{lat: 1, lon: 1}]}]",
+		"bad duration":       "tracks: [{id: a, spawn: soon, waypoints: [{lat: 1, lon: 1}]}]",
+		"unknown lead":       "tracks: [{id: a, formation: {lead: b}}]",
+		"lead declared late": "tracks: [{id: a, formation: {lead: b}}, {id: b, waypoints: [{lat: 1, lon: 1}]}]",
+		"route and slot":     "tracks: [{id: b, waypoints: [{lat: 1, lon: 1}]}, {id: a, formation: {lead: b}, waypoints: [{lat: 1, lon: 1}]}]",
+	} {
+		if _, err := ParseScenario([]byte(doc)); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestFollowRoute_ReachesWaypoints(t *testing.T) {
+	tr := newScenarioTrack(ScenarioTrack{
+		ID:         "a",
+		SpeedKnots: 400,
+		Waypoints:  []Waypoint{{Lat: 38.9, Lon: -77.0, Alt: 1000}, {Lat: 38.9, Lon: -76.99, Alt: 2000}, {Lat: 38.91, Lon: -76.99}},
+	}, Maneuver{})
+	if math.Abs(tr.heading-90) > 0.01 {
+		t.Fatalf("expected initial heading east, got %.2f", tr.heading)
+	}
+
+	for i := 0; i < 100 && tr.next < len(tr.route); i++ {
+		followRoute(tr, time.Second)
+	}
+	if tr.next != len(tr.route) {
+		t.Fatalf("expected route finished, at waypoint %d", tr.next)
+	}
+	if tr.lat != 38.91 || tr.lon != -76.99 {
+		t.Fatalf("expected to stop on final waypoint, got (%f, %f)", tr.lat, tr.lon)
+	}
+	if tr.alt != 2000 {
+		t.Fatalf("expected altitude from second waypoint, got %.0f", tr.alt)
+	}
+	if math.Abs(tr.heading) > 0.01 {
+		t.Fatalf("expected final heading north, got %.2f", tr.heading)
+	}
+}
+
+func TestFollowRoute_Loops(t *testing.T) {
+	tr := newScenarioTrack(ScenarioTrack{
+		ID:         "a",
+		SpeedKnots: 400,
+		Loop:       true,
+		Waypoints:  []Waypoint{{Lat: 38.9, Lon: -77.0}, {Lat: 38.905, Lon: -77.0}},
+	}, Maneuver{})
+	for i := 0; i < 10; i++ {
+		followRoute(tr, time.Second)
+	}
+	if tr.lat < 38.9-1e-9 || tr.lat > 38.905+1e-9 {
+		t.Fatalf("expected looping track to stay on its route, lat=%f", tr.lat)
+	}
+}
+
+func TestScenarioIntegration(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	sc, err := LoadScenario("testdata/scenario.yaml")
+	if err != nil {
+		t.Fatalf("LoadScenario: %v", err)
+	}
+	sim := New(Config{StoreAddr: addr, Interval: 50 * time.Millisecond, Scenario: sc})
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	ids := func() map[string]bool {
+		resp, err := client.ListEntities(context.Background(), &storev1.ListEntitiesRequest{
+			TypeFilter: entityv1.EntityType_ENTITY_TYPE_TRACK,
+		})
+		if err != nil {
+			t.Fatalf("ListEntities: %v", err)
+		}
+		out := make(map[string]bool)
+		for _, e := range resp.Entities {
+			out[e.Id] = true
+		}
+		return out
+	}
+
+	// Step rather than Run: ticks at 0-150ms, then the 200ms spawn, then
+	// 250-400ms, when late-1 despawns.
+	step := func(n int) {
+		for range n {
+			sim.Step(context.Background(), client)
+		}
+	}
+	step(4)
+	if got := ids(); !got["bogey-1"] || got["late-1"] {
+		t.Fatalf("expected only bogey-1 before spawn, got %v", got)
+	}
+	step(1)
+	if got := ids(); !got["late-1"] {
+		t.Fatalf("expected late-1 after spawn, got %v", got)
+	}
+	step(4)
+	if got := ids(); got["late-1"] {
+		t.Fatalf("expected late-1 despawned, got %v", got)
+	}
+	if sim.Elapsed() != 450*time.Millisecond {
+		t.Fatalf("expected 450ms simulated, got %v", sim.Elapsed())
+	}
+}
@@ -2,6 +2,7 @@ package sensor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"math"
@@ -10,9 +11,12 @@ import (
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/labels"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 const (
@@ -22,8 +26,25 @@ const (
 
 // BBox defines a geographic bounding box.
 type BBox struct {
-	MinLat, MaxLat float64
-	MinLon, MaxLon float64
+	MinLat float64 `yaml:"min_lat"`
+	MaxLat float64 `yaml:"max_lat"`
+	MinLon float64 `yaml:"min_lon"`
+	MaxLon float64 `yaml:"max_lon"`
+}
+
+// Validate checks the box is well formed and on the globe.
+func (b BBox) Validate() error {
+	switch {
+	case b.MinLat < -90 || b.MaxLat > 90:
+		return fmt.Errorf("bbox latitude must be within [-90, 90]")
+	case b.MinLon < -180 || b.MaxLon > 180:
+		return fmt.Errorf("bbox longitude must be within [-180, 180]")
+	case b.MinLat >= b.MaxLat:
+		return fmt.Errorf("bbox min_lat %v must be below max_lat %v", b.MinLat, b.MaxLat)
+	case b.MinLon >= b.MaxLon:
+		return fmt.Errorf("bbox min_lon %v must be below max_lon %v", b.MinLon, b.MaxLon)
+	}
+	return nil
 }
 
 // Config controls the sensor simulator.
@@ -32,6 +53,29 @@ type Config struct {
 	Interval  time.Duration
 	NumTracks int
 	BBox      BBox
+	Maneuver  Maneuver      // how random tracks move
+	Sensor    SensorSpec    // the reporting sensor for tracks that name none
+	Sensors   []SensorSpec  // when set, every track is reported by each of these instead
+	Scenario  *Scenario     // scripted tracks; when set, NumTracks is ignored
+	Replay    *Replay       // recorded events to re-publish instead of simulating
+	Seed      uint64        // seeds track placement, maneuvers, and sensor draws; 0 picks one at random
+	TTL       time.Duration // store expiry for reported tracks, refreshed each report; 0 disables
+
+	TrackPrefix string // random tracks are <prefix><n>; empty means "track-"
+
+	// Labels are set on every track the simulator creates, e.g.
+	// exercise=bravo, so scenarios sharing a store can be told apart.
+	Labels map[string]string
+
+	// AuthToken is sent as a bearer token, for a store with AUTH_TOKENS
+	// set. A sensor-role token cannot delete: despawned tracks are left to
+	// expire, and replays need an operator token.
+	AuthToken string
+
+	// Publish sends reports over one long-lived PublishEntities stream
+	// instead of a BatchWriteEntities call per step. Deletes are still
+	// batched.
+	Publish bool
 }
 
 // DefaultConfig returns a config with DC metro area defaults.
@@ -40,10 +84,58 @@ func DefaultConfig() Config {
 		StoreAddr: "localhost:50051",
 		Interval:  time.Second,
 		NumTracks: 5,
+		TTL:       10 * time.Second,
 		BBox: BBox{
 			MinLat: 38.8, MaxLat: 39.0,
 			MinLon: -77.2, MaxLon: -76.9,
 		},
+		Maneuver: DefaultManeuver(),
+		Sensor:   SensorSpec{ID: "eo-1", Type: "eo"},
+	}
+}
+
+// Validate checks the config is runnable.
+func (cfg Config) Validate() error {
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("interval must be positive")
+	}
+	if cfg.NumTracks < 0 {
+		return fmt.Errorf("num_tracks must not be negative")
+	}
+	if cfg.TTL < 0 || (cfg.TTL > 0 && cfg.TTL <= cfg.Interval) {
+		return fmt.Errorf("ttl %v must be 0 or longer than interval %v", cfg.TTL, cfg.Interval)
+	}
+	if err := cfg.BBox.Validate(); err != nil {
+		return err
+	}
+	if err := labels.Validate(cfg.Labels); err != nil {
+		return err
+	}
+	if err := cfg.Maneuver.Validate(); err != nil {
+		return fmt.Errorf("maneuver: %w", err)
+	}
+	if err := cfg.Sensor.Validate(); err != nil {
+		return err
+	}
+	for _, spec := range cfg.Sensors {
+		if err := spec.Validate(); err != nil {
+			return err
+		}
+	}
+	if cfg.Replay != nil {
+		return cfg.Replay.Validate()
+	}
+	return nil
+}
+
+// DefaultManeuver keeps random tracks in the bbox with gentle, occasional
+// heading changes.
+func DefaultManeuver() Maneuver {
+	return Maneuver{
+		Behavior: BehaviorBounce,
+		TurnRate: 3,
+		JinkProb: 0.05,
+		JinkMax:  60,
 	}
 }
 
@@ -54,100 +146,352 @@ type track struct {
 	alt     float64
 	speed   float64 // m/s
 	heading float64 // degrees, 0=north, clockwise
-	created bool
+	started bool    // spawned and moving
+
+	sensors  []SensorSpec    // who reports this track; empty reports as eo-1 everywhere
+	multi    bool            // each sensor reports its own entity, <sensor>-<track>
+	reported map[string]bool // entity IDs created in the store
+
+	// Scenario tracks only.
+	route   []Waypoint
+	next    int // index of the waypoint being flown to
+	loop    bool
+	spawn   time.Duration
+	despawn time.Duration
+	gone    bool // despawned and deleted from the store
+
+	maneuver Maneuver // free flight, and scenario tracks once their route ends
+	motion   ManeuverState
+
+	// Formation wingmen only.
+	lead *track
+	slot FormationSlot
+
+	// Attack tracks only.
+	attack    *Attack
+	target    Waypoint
+	committed bool // flying at the target
+	arrived   bool // reached the target; deleted on this tick
 }
 
 // Simulator generates Track entities and streams them to an entity store.
 type Simulator struct {
-	cfg    Config
-	tracks []*track
+	cfg     Config
+	rng     *rand.Rand
+	tracks  []*track
+	elapsed time.Duration // simulated time since the first tick
+	pub     publisher     // Config.Publish only
 }
 
-// New creates a simulator with the given config.
+// New creates a simulator with the given config. Two simulators built from
+// the same config and non-zero Seed produce the same tracks.
 func New(cfg Config) *Simulator {
+	if cfg.Seed == 0 {
+		cfg.Seed = rand.Uint64()
+	}
+	rng := rand.New(rand.NewPCG(cfg.Seed, 0))
+	if cfg.Scenario != nil && cfg.Scenario.BBox != nil {
+		cfg.BBox = *cfg.Scenario.BBox
+	}
+	cfg.Sensor = placeRadar(cfg.Sensor, cfg.BBox)
+	if len(cfg.Sensors) > 0 {
+		specs := make([]SensorSpec, len(cfg.Sensors))
+		for i, spec := range cfg.Sensors {
+			specs[i] = placeRadar(spec, cfg.BBox)
+		}
+		cfg.Sensors = specs
+	}
+
+	if cfg.Scenario != nil {
+		tracks := make([]*track, len(cfg.Scenario.Tracks))
+		sensors := make(map[string]SensorSpec, len(cfg.Scenario.Sensors))
+		for _, spec := range cfg.Scenario.Sensors {
+			sensors[spec.ID] = placeRadar(spec, cfg.BBox)
+		}
+		byID := make(map[string]*track, len(cfg.Scenario.Tracks))
+		for i, st := range cfg.Scenario.Tracks {
+			t := newScenarioTrack(st, cfg.Scenario.Maneuver)
+			if st.Formation != nil {
+				t.lead, t.slot = byID[st.Formation.Lead], *st.Formation
+				t.spawn = max(t.spawn, t.lead.spawn)
+				holdFormation(t)
+			}
+			if a := st.Attack; a != nil {
+				target := a.Target
+				if target == nil {
+					target = cfg.Scenario.Defended
+				}
+				armTrack(t, *a, *target)
+			}
+			byID[st.ID] = t
+			switch spec, ok := sensors[st.Sensor]; {
+			case len(st.Sensors) > 0:
+				for _, id := range st.Sensors {
+					t.sensors = append(t.sensors, sensors[id])
+				}
+				t.multi = true
+			case ok:
+				t.sensors = []SensorSpec{spec}
+			case st.Sensor != "":
+				t.sensors = []SensorSpec{{ID: st.Sensor, Type: st.SensorType}}
+			default:
+				cfg.assignSensors(t)
+			}
+			tracks[i] = t
+		}
+		return &Simulator{cfg: cfg, rng: rng, tracks: tracks}
+	}
+
 	tracks := make([]*track, cfg.NumTracks)
 	for i := range tracks {
-		tracks[i] = newTrack(i, cfg.BBox)
+		tracks[i] = newTrack(rng, cfg.TrackPrefix, i, cfg.BBox)
+		tracks[i].maneuver = cfg.Maneuver
+		cfg.assignSensors(tracks[i])
 	}
-	return &Simulator{cfg: cfg, tracks: tracks}
+	return &Simulator{cfg: cfg, rng: rng, tracks: tracks}
 }
 
-func newTrack(n int, bbox BBox) *track {
+// assignSensors gives a track the configured reporting sensors.
+func (cfg Config) assignSensors(t *track) {
+	if len(cfg.Sensors) > 0 {
+		t.sensors, t.multi = cfg.Sensors, true
+		return
+	}
+	t.sensors = []SensorSpec{cfg.Sensor}
+}
+
+func newTrack(rng *rand.Rand, prefix string, n int, bbox BBox) *track {
+	if prefix == "" {
+		prefix = "track-"
+	}
 	return &track{
-		id:      fmt.Sprintf("track-%d", n),
-		lat:     bbox.MinLat + rand.Float64()*(bbox.MaxLat-bbox.MinLat),
-		lon:     bbox.MinLon + rand.Float64()*(bbox.MaxLon-bbox.MinLon),
-		alt:     rand.Float64()*5000 + 1000, // 1000-6000m
-		speed:   (rand.Float64()*400 + 100) * knotsToMps,
-		heading: rand.Float64() * 360,
+		id:      fmt.Sprintf("%s%d", prefix, n),
+		lat:     bbox.MinLat + rng.Float64()*(bbox.MaxLat-bbox.MinLat),
+		lon:     bbox.MinLon + rng.Float64()*(bbox.MaxLon-bbox.MinLon),
+		alt:     rng.Float64()*5000 + 1000, // 1000-6000m
+		speed:   (rng.Float64()*400 + 100) * knotsToMps,
+		heading: rng.Float64() * 360,
 	}
 }
 
 // Run connects to the entity store and streams track updates until ctx is cancelled.
 func (s *Simulator) Run(ctx context.Context) error {
-	conn, err := grpc.NewClient(s.cfg.StoreAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := client.Dial(s.cfg.StoreAddr, client.WithToken(s.cfg.AuthToken))
 	if err != nil {
 		return fmt.Errorf("connect to store: %w", err)
 	}
 	defer conn.Close()
 
 	client := storev1.NewEntityStoreServiceClient(conn)
+	if s.cfg.Replay != nil {
+		return s.runReplay(ctx, client)
+	}
+
 	ticker := time.NewTicker(s.cfg.Interval)
 	defer ticker.Stop()
 
-	slog.Info("sensor-sim started", "num_tracks", s.cfg.NumTracks, "interval", s.cfg.Interval, "store_addr", s.cfg.StoreAddr)
+	slog.Info("sensor-sim started", "num_tracks", len(s.tracks), "scenario", s.cfg.Scenario != nil, "seed", s.cfg.Seed, "interval", s.cfg.Interval, "store_addr", s.cfg.StoreAddr)
 
 	for {
 		select {
 		case <-ctx.Done():
+			if err := s.pub.close(); err != nil {
+				slog.Error("write failed", "error", err)
+			}
 			return nil
 		case <-ticker.C:
-			for _, t := range s.tracks {
-				if err := s.tick(ctx, client, t); err != nil {
-					slog.Error("tick failed", "track_id", t.id, "error", err)
-				}
-			}
+			s.Step(ctx, client)
 		}
 	}
 }
 
-func (s *Simulator) tick(ctx context.Context, client storev1.EntityStoreServiceClient, t *track) error {
-	if !t.created {
-		return s.createTrack(ctx, client, t)
+// Step advances simulated time by one interval and reports every track
+// its sensors detect, in one batched write. Run calls it on each tick;
+// tests that need deterministic time call it directly instead of running
+// the simulator.
+func (s *Simulator) Step(ctx context.Context, client storev1.EntityStoreServiceClient) {
+	var b batch
+	for _, t := range s.tracks {
+		if err := s.tick(&b, t); err != nil {
+			slog.Error("tick failed", "track_id", t.id, "error", err)
+		}
+	}
+	if err := b.flush(ctx, client); err != nil {
+		slog.Error("write failed", "error", err)
+	}
+	if err := s.pub.send(ctx, client, b.reports); err != nil {
+		slog.Error("write failed", "error", err)
 	}
-	advanceTrack(t, s.cfg.Interval)
-	return s.updateTrack(ctx, client, t)
+	s.elapsed += s.cfg.Interval
 }
 
-func (s *Simulator) createTrack(ctx context.Context, client storev1.EntityStoreServiceClient, t *track) error {
-	entity, err := buildEntity(t)
-	if err != nil {
-		return err
+// Elapsed returns the simulated time since the first step.
+func (s *Simulator) Elapsed() time.Duration {
+	return s.elapsed
+}
+
+func (s *Simulator) tick(b *batch, t *track) error {
+	switch {
+	case t.gone || s.elapsed < t.spawn:
+		return nil
+	case t.despawn > 0 && s.elapsed >= t.despawn:
+		s.deleteTrack(b, t)
+		return nil
 	}
-	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity}); err != nil {
-		return fmt.Errorf("create %s: %w", t.id, err)
+
+	// Truth moves every tick; each sensor only reports what it detects.
+	s.move(t)
+	if t.arrived {
+		slog.Info("track reached target", "track_id", t.id, "lat", t.target.Lat, "lon", t.target.Lon)
+		s.deleteTrack(b, t)
+		return nil
 	}
-	t.created = true
-	slog.Info("created track", "track_id", t.id, "lat", t.lat, "lon", t.lon, "speed_kts", t.speed/knotsToMps, "heading_deg", t.heading)
-	return nil
+
+	var errs []error
+	for _, spec := range t.reporters() {
+		if !spec.Detects(s.rng, t.lat, t.lon) {
+			continue
+		}
+		if err := s.report(b, t, spec); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
 }
 
-func (s *Simulator) updateTrack(ctx context.Context, client storev1.EntityStoreServiceClient, t *track) error {
-	entity, err := buildEntity(t)
+// move advances t's truth by one interval. Leads tick before their
+// wingmen, so a wingman's slot is current.
+func (s *Simulator) move(t *track) {
+	switch {
+	case t.inFormation(s.elapsed):
+		t.started = true
+		holdFormation(t)
+	case !t.started:
+		t.started = true
+	case t.committed || t.shouldCommit(s.elapsed):
+		if !t.committed {
+			t.committed = true
+			slog.Info("track committed", "track_id", t.id, "profile", t.attack.Profile, "speed_kts", t.attack.SpeedKnots)
+		}
+		t.arrived = attackRun(t, s.cfg.Interval)
+	case onRoute(t):
+		followRoute(t, s.cfg.Interval)
+	default:
+		maneuverTrack(s.rng, t, s.cfg.Interval, s.cfg.BBox)
+	}
+}
+
+func (s *Simulator) deleteTrack(b *batch, t *track) {
+	t.gone = true
+	for id := range t.reported {
+		b.add(&storev1.WriteOp{Op: &storev1.WriteOp_Delete{Delete: &storev1.DeleteEntityRequest{Id: id}}}, func(r *storev1.WriteResult) error {
+			if err := resultErr(r); err != nil {
+				return fmt.Errorf("delete %s: %w", id, err)
+			}
+			slog.Info("despawned track", "track_id", id)
+			return nil
+		})
+	}
+}
+
+// report adds the write creating or updating the entity spec reports for t.
+func (s *Simulator) report(b *batch, t *track, spec SensorSpec) error {
+	id := t.reportID(spec)
+	entity, err := buildReport(s.rng, t, spec, id)
 	if err != nil {
 		return err
 	}
-	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity}); err != nil {
-		return fmt.Errorf("update %s: %w", t.id, err)
+	if s.cfg.Publish {
+		// The store creates or patches it; either way it now holds it.
+		entity.Labels = s.cfg.Labels
+		b.reports = append(b.reports, &storev1.PublishEntitiesRequest{Entity: entity, Ttl: s.ttl()})
+		t.markReported(id)
+		return nil
+	}
+	if !t.reported[id] {
+		s.create(b, t, spec, entity)
+		return nil
 	}
-	slog.Info("updated track", "track_id", t.id, "lat", t.lat, "lon", t.lon, "speed_kts", t.speed/knotsToMps, "heading_deg", t.heading)
+
+	// A patch stamps the components fresh, so the store accepts the new
+	// position without the stored HLC having to be read first.
+	patch := &storev1.PatchComponentRequest{Id: id, Components: entity.Components, Ttl: s.ttl()}
+	b.add(&storev1.WriteOp{Op: &storev1.WriteOp_Patch{Patch: patch}}, func(r *storev1.WriteResult) error {
+		err := resultErr(r)
+		if status.Code(err) == codes.NotFound {
+			// Expired while unreported, e.g. out of coverage past the TTL.
+			delete(t.reported, id)
+			s.create(b, t, spec, entity)
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("update %s: %w", id, err)
+		}
+		slog.Info("updated track", "track_id", id, "sensor_id", spec.ID, "lat", t.lat, "lon", t.lon, "speed_kts", t.speed/knotsToMps, "heading_deg", t.heading)
+		return nil
+	})
 	return nil
 }
 
-func buildEntity(t *track) (*entityv1.Entity, error) {
+// create adds the write creating entity, which spec reports for t.
+func (s *Simulator) create(b *batch, t *track, spec SensorSpec, entity *entityv1.Entity) {
+	entity.Labels = s.cfg.Labels
+	create := &storev1.CreateEntityRequest{Entity: entity, Ttl: s.ttl()}
+	b.add(&storev1.WriteOp{Op: &storev1.WriteOp_Create{Create: create}}, func(r *storev1.WriteResult) error {
+		if err := resultErr(r); err != nil {
+			return fmt.Errorf("create %s: %w", entity.Id, err)
+		}
+		t.markReported(entity.Id)
+		slog.Info("created track", "track_id", entity.Id, "sensor_id", spec.ID, "lat", t.lat, "lon", t.lon, "speed_kts", t.speed/knotsToMps, "heading_deg", t.heading)
+		return nil
+	})
+}
+
+// ttl returns the expiry to attach to each write, or nil for none.
+func (s *Simulator) ttl() *durationpb.Duration {
+	if s.cfg.TTL <= 0 {
+		return nil
+	}
+	return durationpb.New(s.cfg.TTL)
+}
+
+// reporters returns the sensors reporting t; a track without any reports
+// through the default eo-1 sensor.
+func (t *track) reporters() []SensorSpec {
+	if len(t.sensors) == 0 {
+		return []SensorSpec{{}}
+	}
+	return t.sensors
+}
+
+// reportID is the entity ID spec reports t under.
+func (t *track) reportID(spec SensorSpec) string {
+	if t.multi {
+		return spec.ID + "-" + t.id
+	}
+	return t.id
+}
+
+// markReported records that the store holds the entity id.
+func (t *track) markReported(id string) {
+	if t.reported == nil {
+		t.reported = make(map[string]bool)
+	}
+	t.reported[id] = true
+}
+
+// buildEntity builds the entity t's first sensor reports.
+func buildEntity(rng *rand.Rand, t *track) (*entityv1.Entity, error) {
+	return buildReport(rng, t, t.reporters()[0], t.id)
+}
+
+// buildReport builds the entity spec reports for t under id, with the
+// sensor's measurement noise applied to the position.
+func buildReport(rng *rand.Rand, t *track, spec SensorSpec, id string) (*entityv1.Entity, error) {
+	lat, lon := spec.measure(rng, t.lat, t.lon)
 	pos, err := anypb.New(&entityv1.PositionComponent{
-		Lat: t.lat,
-		Lon: t.lon,
+		Lat: lat,
+		Lon: lon,
 		Alt: t.alt,
 	})
 	if err != nil {
@@ -162,25 +506,41 @@ func buildEntity(t *track) (*entityv1.Entity, error) {
 		return nil, fmt.Errorf("pack velocity: %w", err)
 	}
 
+	sensorID, sensorType := "eo-1", "eo"
+	if spec.ID != "" {
+		sensorID = spec.ID
+	}
+	if spec.Type != "" {
+		sensorType = spec.Type
+	}
 	src, err := anypb.New(&entityv1.SourceComponent{
-		SensorId:   "eo-1",
-		SensorType: "eo",
+		SensorId:   sensorID,
+		SensorType: sensorType,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("pack source: %w", err)
 	}
 
+	components := make(map[string]*anypb.Any, 3)
+	for key, c := range map[string]*anypb.Any{"position": pos, "velocity": vel, "source": src} {
+		if spec.reports(key) {
+			components[key] = c
+		}
+	}
 	return &entityv1.Entity{
-		Id:   t.id,
-		Type: entityv1.EntityType_ENTITY_TYPE_TRACK,
-		Components: map[string]*anypb.Any{
-			"position": pos,
-			"velocity": vel,
-			"source":   src,
-		},
+		Id:         id,
+		Type:       entityv1.EntityType_ENTITY_TYPE_TRACK,
+		Components: components,
 	}, nil
 }
 
+// maneuverTrack steps a free-flying track through its maneuver.
+func maneuverTrack(rng *rand.Rand, t *track, dt time.Duration, bbox BBox) {
+	k := Kinematics{Lat: t.lat, Lon: t.lon, Alt: t.alt, Speed: t.speed, Heading: t.heading}
+	t.maneuver.Step(rng, &k, &t.motion, dt, bbox)
+	t.lat, t.lon, t.alt, t.heading = k.Lat, k.Lon, k.Alt, k.Heading
+}
+
 // advanceTrack updates position using dead-reckoning (flat-earth approximation).
 func advanceTrack(t *track, dt time.Duration) {
 	hdgRad := t.heading * math.Pi / 180
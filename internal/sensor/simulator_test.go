@@ -3,6 +3,7 @@ package sensor
 import (
 	"context"
 	"math"
+	"math/rand/v2"
 	"net"
 	"testing"
 	"time"
@@ -12,12 +13,19 @@ import (
 	"github.com/boshu2/lattice-lab/internal/server"
 	"github.com/boshu2/lattice-lab/internal/store"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
+// testRand returns a fixed-seed random source.
+func testRand() *rand.Rand {
+	return rand.New(rand.NewPCG(1, 0))
+}
+
 func TestNewTrack(t *testing.T) {
 	bbox := BBox{MinLat: 38.8, MaxLat: 39.0, MinLon: -77.2, MaxLon: -76.9}
-	tr := newTrack(0, bbox)
+	tr := newTrack(testRand(), "", 0, bbox)
 
 	if tr.id != "track-0" {
 		t.Fatalf("expected track-0, got %s", tr.id)
@@ -98,7 +106,7 @@ func TestBuildEntity(t *testing.T) {
 		heading: 45,
 	}
 
-	entity, err := buildEntity(tr)
+	entity, err := buildEntity(testRand(), tr)
 	if err != nil {
 		t.Fatalf("buildEntity: %v", err)
 	}
@@ -123,20 +131,70 @@ func startTestServer(t *testing.T) (string, func()) {
 	s := store.New()
 	srv := grpc.NewServer()
 	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.StartReaper(ctx, 20*time.Millisecond)
 
 	lis, err := net.Listen("tcp", "localhost:0")
 	if err != nil {
+		cancel()
 		t.Fatalf("listen: %v", err)
 	}
 
 	go srv.Serve(lis) //nolint:errcheck
 
 	cleanup := func() {
+		cancel()
 		srv.Stop()
 	}
 	return lis.Addr().String(), cleanup
 }
 
+func TestNew_SeedReproducesRun(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Seed = 42
+	cfg.Maneuver.JinkProb = 0.5
+	cfg.Sensor = SensorSpec{ID: "radar-1", Type: "radar", DetectionProb: 0.7, NoiseM: 50}
+
+	// run steps a simulator as tick does, without a store, and returns the
+	// reported positions.
+	run := func(sim *Simulator) []float64 {
+		var out []float64
+		for range 20 {
+			for _, tr := range sim.tracks {
+				maneuverTrack(sim.rng, tr, sim.cfg.Interval, sim.cfg.BBox)
+				if !tr.sensors[0].Detects(sim.rng, tr.lat, tr.lon) {
+					out = append(out, math.NaN())
+					continue
+				}
+				lat, lon := tr.sensors[0].measure(sim.rng, tr.lat, tr.lon)
+				out = append(out, lat, lon, tr.heading)
+			}
+		}
+		return out
+	}
+
+	a, b := run(New(cfg)), run(New(cfg))
+	if len(a) != len(b) {
+		t.Fatalf("runs differ in length: %d vs %d", len(a), len(b))
+	}
+	for i := range a {
+		if a[i] != b[i] && !(math.IsNaN(a[i]) && math.IsNaN(b[i])) {
+			t.Fatalf("runs diverge at sample %d: %v vs %v", i, a[i], b[i])
+		}
+	}
+
+	cfg.Seed = 43
+	if c := run(New(cfg)); len(c) == len(a) && c[0] == a[0] {
+		t.Fatal("expected a different seed to give a different run")
+	}
+}
+
+func TestNew_ZeroSeedPicksOne(t *testing.T) {
+	if sim := New(DefaultConfig()); sim.cfg.Seed == 0 {
+		t.Fatal("expected a random seed to be chosen and recorded")
+	}
+}
+
 func TestSimulatorIntegration(t *testing.T) {
 	addr, cleanup := startTestServer(t)
 	defer cleanup()
@@ -146,6 +204,7 @@ func TestSimulatorIntegration(t *testing.T) {
 		Interval:  100 * time.Millisecond,
 		NumTracks: 2,
 		BBox:      BBox{MinLat: 38.8, MaxLat: 39.0, MinLon: -77.2, MaxLon: -76.9},
+		Labels:    map[string]string{"exercise": "bravo"},
 	}
 
 	sim := New(cfg)
@@ -165,13 +224,14 @@ func TestSimulatorIntegration(t *testing.T) {
 
 	client := storev1.NewEntityStoreServiceClient(conn)
 	resp, err := client.ListEntities(context.Background(), &storev1.ListEntitiesRequest{
-		TypeFilter: entityv1.EntityType_ENTITY_TYPE_TRACK,
+		TypeFilter:    entityv1.EntityType_ENTITY_TYPE_TRACK,
+		LabelSelector: "exercise=bravo",
 	})
 	if err != nil {
 		t.Fatalf("ListEntities: %v", err)
 	}
 	if len(resp.Entities) != 2 {
-		t.Fatalf("expected 2 tracks, got %d", len(resp.Entities))
+		t.Fatalf("expected 2 labelled tracks, got %d", len(resp.Entities))
 	}
 
 	// Verify entities have components.
@@ -184,3 +244,152 @@ func TestSimulatorIntegration(t *testing.T) {
 		}
 	}
 }
+
+func TestSimulator_Publish(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+	watchCtx, stop := context.WithTimeout(context.Background(), 5*time.Second)
+	defer stop()
+	w, err := client.WatchEntities(watchCtx, &storev1.WatchEntitiesRequest{TypeFilter: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	if err != nil {
+		t.Fatalf("WatchEntities: %v", err)
+	}
+	if _, err := w.Header(); err != nil {
+		t.Fatalf("WatchEntities: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 350*time.Millisecond)
+	defer cancel()
+	_ = New(Config{
+		StoreAddr: addr,
+		Interval:  100 * time.Millisecond,
+		NumTracks: 2,
+		BBox:      BBox{MinLat: 38.8, MaxLat: 39.0, MinLon: -77.2, MaxLon: -76.9},
+		Labels:    map[string]string{"exercise": "bravo"},
+		Publish:   true,
+	}).Run(ctx)
+
+	// Run closes the stream before returning, so every report is applied:
+	// two creates, then updates.
+	created, updated := 0, 0
+	for created+updated < 4 {
+		event, err := w.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		switch event.Type {
+		case storev1.EventType_EVENT_TYPE_CREATED:
+			created++
+			if event.Entity.Labels["exercise"] != "bravo" {
+				t.Fatalf("expected the labels on a published track, got %v", event.Entity.Labels)
+			}
+		case storev1.EventType_EVENT_TYPE_UPDATED:
+			updated++
+		}
+	}
+	if created != 2 {
+		t.Fatalf("expected 2 tracks created, then updated; got %d created, %d updated", created, updated)
+	}
+}
+
+func TestSimulator_UpdatesMoveStoredPosition(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go New(Config{
+		StoreAddr: addr,
+		Interval:  50 * time.Millisecond,
+		NumTracks: 1,
+		BBox:      BBox{MinLat: 38.8, MaxLat: 39.0, MinLon: -77.2, MaxLon: -76.9},
+	}).Run(ctx) //nolint:errcheck
+
+	position := func() *entityv1.PositionComponent {
+		t.Helper()
+		e, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "track-0"})
+		if err != nil {
+			t.Fatalf("GetEntity: %v", err)
+		}
+		pos := &entityv1.PositionComponent{}
+		if err := e.Components["position"].UnmarshalTo(pos); err != nil {
+			t.Fatalf("unmarshal position: %v", err)
+		}
+		return pos
+	}
+
+	time.Sleep(120 * time.Millisecond)
+	first := position()
+	time.Sleep(200 * time.Millisecond)
+	if last := position(); last.Lat == first.Lat && last.Lon == first.Lon {
+		t.Fatalf("expected stored position to advance, stuck at %v,%v", first.Lat, first.Lon)
+	}
+}
+
+func TestSimulator_TracksExpireAfterStop(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	_ = New(Config{
+		StoreAddr: addr,
+		Interval:  50 * time.Millisecond,
+		NumTracks: 1,
+		TTL:       200 * time.Millisecond,
+		BBox:      BBox{MinLat: 38.8, MaxLat: 39.0, MinLon: -77.2, MaxLon: -76.9},
+	}).Run(ctx)
+
+	// Reports kept refreshing the TTL, so the track outlived it while running.
+	if _, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "track-0"}); err != nil {
+		t.Fatalf("expected track to be live right after stop: %v", err)
+	}
+	time.Sleep(400 * time.Millisecond)
+	if _, err := client.GetEntity(context.Background(), &storev1.GetEntityRequest{Id: "track-0"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected track to expire after the simulator stopped, got %v", err)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Fatalf("default config invalid: %v", err)
+	}
+
+	for name, mutate := range map[string]func(*Config){
+		"zero interval":   func(c *Config) { c.Interval = 0 },
+		"negative tracks": func(c *Config) { c.NumTracks = -1 },
+		"inverted lat":    func(c *Config) { c.BBox.MinLat, c.BBox.MaxLat = 39, 38 },
+		"lon off globe":   func(c *Config) { c.BBox.MaxLon = 200 },
+		"bad maneuver":    func(c *Config) { c.Maneuver.Behavior = BehaviorOrbit },
+		"bad sensor":      func(c *Config) { c.Sensor.DetectionProb = 2 },
+		"ttl below tick":  func(c *Config) { c.TTL = c.Interval },
+		"bad label":       func(c *Config) { c.Labels = map[string]string{"side": "a,b"} },
+	} {
+		cfg := DefaultConfig()
+		mutate(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ApprovalBackend decides pending intercepts for ApproveAction and
+// DenyAction. The task-manager is one: task.Manager in the same process,
+// task.Remote over gRPC. Errors carrying a gRPC status are returned as
+// they are; others become FAILED_PRECONDITION.
+type ApprovalBackend interface {
+	ApproveAction(ctx context.Context, entityID, operator string) error
+	DenyAction(ctx context.Context, entityID, operator string) error
+}
+
+// WithApprovals serves ApproveAction and DenyAction through b. Without it
+// they are UNIMPLEMENTED.
+func WithApprovals(b ApprovalBackend) Option {
+	return func(s *Server) { s.approvals = b }
+}
+
+func (s *Server) ApproveAction(ctx context.Context, req *storev1.ApproveActionRequest) (*entityv1.Entity, error) {
+	return s.decide(req.EntityId, func() error {
+		return s.approvals.ApproveAction(ctx, req.EntityId, req.Operator)
+	})
+}
+
+func (s *Server) DenyAction(ctx context.Context, req *storev1.DenyActionRequest) (*entityv1.Entity, error) {
+	return s.decide(req.EntityId, func() error {
+		return s.approvals.DenyAction(ctx, req.EntityId, req.Operator)
+	})
+}
+
+// decide makes an approval decision on id with call and returns the
+// entity as the store now holds it.
+func (s *Server) decide(id string, call func() error) (*entityv1.Entity, error) {
+	if s.approvals == nil {
+		return nil, status.Error(codes.Unimplemented, "no approval backend is registered with this store")
+	}
+	if id == "" {
+		return nil, status.Error(codes.InvalidArgument, "entity id is required")
+	}
+	if _, err := s.store.Get(id); err != nil {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	if err := call(); err != nil {
+		if _, ok := status.FromError(err); ok {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+	e, err := s.store.Get(id)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	return e, nil
+}
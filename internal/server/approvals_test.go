@@ -0,0 +1,72 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fakeApprovals decides for the entities in pending, recording who did.
+type fakeApprovals struct {
+	pending   map[string]bool
+	decisions []string
+}
+
+func (f *fakeApprovals) decide(id, operator, verb string) error {
+	if !f.pending[id] {
+		return errors.New("no pending approval")
+	}
+	delete(f.pending, id)
+	f.decisions = append(f.decisions, verb+" "+id+" by "+operator)
+	return nil
+}
+
+func (f *fakeApprovals) ApproveAction(_ context.Context, id, operator string) error {
+	return f.decide(id, operator, "approved")
+}
+
+func (f *fakeApprovals) DenyAction(_ context.Context, id, operator string) error {
+	return f.decide(id, operator, "denied")
+}
+
+func TestGRPCApprovals(t *testing.T) {
+	ctx := context.Background()
+	s := store.New()
+	_, _ = s.Create(&entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	_, _ = s.Create(&entityv1.Entity{Id: "t2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+
+	client, cleanup := serveStore(t, s)
+	_, err := client.ApproveAction(ctx, &storev1.ApproveActionRequest{EntityId: "t1"})
+	cleanup()
+	if status.Code(err) != codes.Unimplemented {
+		t.Fatalf("expected Unimplemented without a backend, got %v", err)
+	}
+
+	backend := &fakeApprovals{pending: map[string]bool{"t1": true, "t2": true}}
+	client, cleanup = serveStore(t, s, WithApprovals(backend))
+	defer cleanup()
+
+	e, err := client.ApproveAction(ctx, &storev1.ApproveActionRequest{EntityId: "t1", Operator: "alice"})
+	if err != nil || e.Id != "t1" {
+		t.Fatalf("ApproveAction: %v, %v", e, err)
+	}
+	if _, err := client.DenyAction(ctx, &storev1.DenyActionRequest{EntityId: "t2", Operator: "bob"}); err != nil {
+		t.Fatalf("DenyAction: %v", err)
+	}
+	if len(backend.decisions) != 2 || backend.decisions[0] != "approved t1 by alice" || backend.decisions[1] != "denied t2 by bob" {
+		t.Fatalf("unexpected decisions %v", backend.decisions)
+	}
+
+	if _, err := client.ApproveAction(ctx, &storev1.ApproveActionRequest{EntityId: "t1"}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition for an entity not pending, got %v", err)
+	}
+	if _, err := client.ApproveAction(ctx, &storev1.ApproveActionRequest{EntityId: "nope"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for an unknown entity, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"maps"
+	"slices"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/audit"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// WithAudit records every call to a mutating method in l, through the
+// server's interceptors, and serves it with GetAuditLog.
+func WithAudit(l *audit.Log) Option {
+	return func(s *Server) { s.auditLog = l }
+}
+
+// mutatingMethods are the methods the audit log records.
+var mutatingMethods = map[string]bool{
+	storev1.EntityStoreService_CreateEntity_FullMethodName:       true,
+	storev1.EntityStoreService_UpdateEntity_FullMethodName:       true,
+	storev1.EntityStoreService_DeleteEntity_FullMethodName:       true,
+	storev1.EntityStoreService_PatchComponent_FullMethodName:     true,
+	storev1.EntityStoreService_RemoveComponent_FullMethodName:    true,
+	storev1.EntityStoreService_IncrementCounter_FullMethodName:   true,
+	storev1.EntityStoreService_BatchWriteEntities_FullMethodName: true,
+	storev1.EntityStoreService_PublishEntities_FullMethodName:    true,
+	storev1.EntityStoreService_Transact_FullMethodName:           true,
+	storev1.EntityStoreService_RestoreEntities_FullMethodName:    true,
+	storev1.EntityStoreService_ReplicateBatch_FullMethodName:     true,
+	storev1.EntityStoreService_AddLink_FullMethodName:            true,
+	storev1.EntityStoreService_RemoveLink_FullMethodName:         true,
+	storev1.EntityStoreService_ApproveAction_FullMethodName:      true,
+	storev1.EntityStoreService_DenyAction_FullMethodName:         true,
+}
+
+func (s *Server) GetAuditLog(_ context.Context, req *storev1.GetAuditLogRequest) (*storev1.GetAuditLogResponse, error) {
+	if s.auditLog == nil {
+		return nil, status.Error(codes.FailedPrecondition, "this store keeps no audit log")
+	}
+	return &storev1.GetAuditLogResponse{Entries: s.auditLog.Entries(req.EntityId, int(req.Limit))}, nil
+}
+
+// audit records a finished call to method with request req, nil for
+// streams, if the method mutates and the server keeps an audit log.
+func (s *Server) audit(ctx context.Context, method string, req any, err error) {
+	if s.auditLog == nil || !mutatingMethods[method] {
+		return
+	}
+	ts := s.store.Now()
+	st := status.Convert(err)
+	e := &storev1.AuditEntry{
+		Time:    timestamppb.Now(),
+		Hlc:     &entityv1.HLCTimestamp{Physical: ts.Physical, Logical: ts.Logical, Node: ts.Node},
+		Method:  method,
+		Targets: auditTargets(req),
+		Code:    int32(st.Code()),
+		Message: st.Message(),
+	}
+	if u, ok := req.(*storev1.UpdateEntityRequest); ok {
+		e.Merge = u.GetMerge()
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		e.Peer = p.Addr.String()
+	}
+	if token := bearerToken(ctx); token != "" {
+		// The token itself is a secret; a short hash of it tells callers
+		// apart without letting the log be used to impersonate them.
+		sum := sha256.Sum256([]byte(token))
+		e.TokenId = hex.EncodeToString(sum[:8])
+		if s.auth != nil {
+			if role, ok := s.auth.Authenticate(token); ok {
+				e.Role = string(role)
+			}
+		}
+	}
+	s.auditLog.Record(e)
+}
+
+// auditTargets lists the entities a request writes, with the component
+// keys it carries for each.
+func auditTargets(req any) []*storev1.AuditTarget {
+	target := func(id string, comps map[string]*anypb.Any) *storev1.AuditTarget {
+		return &storev1.AuditTarget{EntityId: id, ComponentKeys: slices.Sorted(maps.Keys(comps))}
+	}
+	ops := func(ops []*storev1.WriteOp) []*storev1.AuditTarget {
+		var targets []*storev1.AuditTarget
+		for _, op := range ops {
+			targets = append(targets, auditTargets(op.GetCreate())...)
+			targets = append(targets, auditTargets(op.GetUpdate())...)
+			targets = append(targets, auditTargets(op.GetPatch())...)
+			targets = append(targets, auditTargets(op.GetDelete())...)
+			targets = append(targets, auditTargets(op.GetIncrement())...)
+		}
+		return targets
+	}
+
+	switch req := req.(type) {
+	case *storev1.CreateEntityRequest:
+		if req != nil {
+			return []*storev1.AuditTarget{target(req.Entity.GetId(), req.Entity.GetComponents())}
+		}
+	case *storev1.UpdateEntityRequest:
+		if req != nil {
+			return []*storev1.AuditTarget{target(req.Entity.GetId(), req.Entity.GetComponents())}
+		}
+	case *storev1.PatchComponentRequest:
+		if req != nil {
+			return []*storev1.AuditTarget{target(req.Id, req.Components)}
+		}
+	case *storev1.IncrementCounterRequest:
+		if req != nil {
+			return []*storev1.AuditTarget{{EntityId: req.Id, ComponentKeys: []string{req.Key}}}
+		}
+	case *storev1.RemoveComponentRequest:
+		if req != nil {
+			return []*storev1.AuditTarget{{EntityId: req.Id, ComponentKeys: slices.Sorted(slices.Values(req.Keys))}}
+		}
+	case *storev1.DeleteEntityRequest:
+		if req != nil {
+			return []*storev1.AuditTarget{{EntityId: req.Id}}
+		}
+	case *storev1.BatchWriteEntitiesRequest:
+		return ops(req.GetOps())
+	case *storev1.TransactRequest:
+		return ops(req.GetOps())
+	case *storev1.ReplicateBatchRequest:
+		var targets []*storev1.AuditTarget
+		for _, event := range req.GetEvents() {
+			targets = append(targets, target(event.GetEntity().GetId(), event.GetEntity().GetComponents()))
+		}
+		return targets
+	case *storev1.AddLinkRequest:
+		return []*storev1.AuditTarget{{EntityId: req.GetLink().GetFromId()}, {EntityId: req.GetLink().GetToId()}}
+	case *storev1.RemoveLinkRequest:
+		return []*storev1.AuditTarget{{EntityId: req.GetFromId()}, {EntityId: req.GetToId()}}
+	case *storev1.ApproveActionRequest:
+		return []*storev1.AuditTarget{{EntityId: req.GetEntityId()}}
+	case *storev1.DenyActionRequest:
+		return []*storev1.AuditTarget{{EntityId: req.GetEntityId()}}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/audit"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestGRPCAuditLog(t *testing.T) {
+	client, cleanup := serveStore(t, store.New(),
+		WithAuth(StaticTokens{"op": RoleOperator, "radar": RoleSensor}), WithAudit(audit.New(audit.DefaultSize)))
+	defer cleanup()
+
+	ctx := context.Background()
+	op, sensor := grpc.PerRPCCredentials(Token("op")), grpc.PerRPCCredentials(Token("radar"))
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1, Lon: 2})
+
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+		Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos},
+	}}, sensor); err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "t1"}, op); err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: "t1"}, sensor); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected a sensor refused a delete, got %v", err)
+	}
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET}}, op); err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+
+	resp, err := client.GetAuditLog(ctx, &storev1.GetAuditLogRequest{EntityId: "t1"}, op)
+	if err != nil {
+		t.Fatalf("GetAuditLog: %v", err)
+	}
+	// Reads are not audited; refused writes are.
+	if len(resp.Entries) != 2 {
+		t.Fatalf("expected 2 entries for t1, got %d", len(resp.Entries))
+	}
+	create, del := resp.Entries[0], resp.Entries[1]
+	if create.Method != storev1.EntityStoreService_CreateEntity_FullMethodName || create.Role != string(RoleSensor) || create.Code != int32(codes.OK) {
+		t.Fatalf("unexpected create entry %v", create)
+	}
+	if create.TokenId == "" || create.TokenId == "radar" || create.Peer == "" || create.Hlc.GetPhysical() == 0 {
+		t.Fatalf("expected a hashed token, peer, and HLC, got %v", create)
+	}
+	if len(create.Targets) != 1 || len(create.Targets[0].ComponentKeys) != 1 || create.Targets[0].ComponentKeys[0] != "position" {
+		t.Fatalf("expected t1's position as the target, got %v", create.Targets)
+	}
+	if del.Code != int32(codes.PermissionDenied) || del.Role != string(RoleSensor) {
+		t.Fatalf("expected the refused delete, got %v", del)
+	}
+	if d, c := del.Hlc, create.Hlc; hlc.Compare(hlc.Timestamp{Physical: d.Physical, Logical: d.Logical, Node: d.Node},
+		hlc.Timestamp{Physical: c.Physical, Logical: c.Logical, Node: c.Node}) <= 0 {
+		t.Fatalf("expected entries ordered by HLC")
+	}
+
+	resp, err = client.GetAuditLog(ctx, &storev1.GetAuditLogRequest{Limit: 1}, op)
+	if err != nil {
+		t.Fatalf("GetAuditLog: %v", err)
+	}
+	if len(resp.Entries) != 1 || resp.Entries[0].Targets[0].EntityId != "a1" || resp.Entries[0].Role != string(RoleOperator) {
+		t.Fatalf("expected the operator's create of a1 last, got %v", resp.Entries)
+	}
+}
+
+func TestGRPCAuditLogMerge(t *testing.T) {
+	client, cleanup := serveStore(t, store.New(), WithAudit(audit.New(audit.DefaultSize)))
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "t1"}}); err != nil {
+		t.Fatal(err)
+	}
+	merge := []*storev1.MergeDecision{{Key: "threat", Kept: "incoming", Strategy: "max-wins"}}
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: &entityv1.Entity{Id: "t1"}, Merge: merge}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.GetAuditLog(ctx, &storev1.GetAuditLogRequest{EntityId: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Entries) != 2 || len(resp.Entries[0].Merge) != 0 || len(resp.Entries[1].Merge) != 1 || resp.Entries[1].Merge[0].Key != "threat" {
+		t.Fatalf("expected the update's merge recorded, got %v", resp.Entries)
+	}
+}
+
+func TestGRPCAuditLogDisabled(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	if _, err := client.GetAuditLog(context.Background(), &storev1.GetAuditLogRequest{}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition without an audit log, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Role is what an authenticated caller may do.
+type Role string
+
+const (
+	// RoleOperator may call every method.
+	RoleOperator Role = "operator"
+	// RoleSensor may only create, update, and patch tracks.
+	RoleSensor Role = "sensor"
+)
+
+// Authenticator maps a bearer token to the role it grants.
+type Authenticator interface {
+	Authenticate(token string) (Role, bool)
+}
+
+// StaticTokens authenticates against a fixed table of tokens.
+type StaticTokens map[string]Role
+
+// Authenticate implements Authenticator.
+func (t StaticTokens) Authenticate(token string) (Role, bool) {
+	role, ok := t[token]
+	return role, ok
+}
+
+// ParseTokens parses comma-separated token=role pairs.
+func ParseTokens(s string) (StaticTokens, error) {
+	tokens := make(StaticTokens)
+	for _, term := range strings.Split(s, ",") {
+		if term = strings.TrimSpace(term); term == "" {
+			continue
+		}
+		token, role, ok := strings.Cut(term, "=")
+		if !ok || token == "" {
+			return nil, fmt.Errorf("auth token %q: want token=role", term)
+		}
+		switch r := Role(role); r {
+		case RoleOperator, RoleSensor:
+			tokens[token] = r
+		default:
+			return nil, fmt.Errorf("auth token role %q: want %s or %s", role, RoleOperator, RoleSensor)
+		}
+	}
+	return tokens, nil
+}
+
+// WithAuth requires every call through the server's interceptors to carry
+// a bearer token a grants a role to, and limits each role's methods.
+func WithAuth(a Authenticator) Option {
+	return func(s *Server) { s.auth = a }
+}
+
+// sensorMethods are the methods a sensor may call; operators may call any.
+var sensorMethods = map[string]bool{
+	storev1.EntityStoreService_CreateEntity_FullMethodName:       true,
+	storev1.EntityStoreService_UpdateEntity_FullMethodName:       true,
+	storev1.EntityStoreService_PatchComponent_FullMethodName:     true,
+	storev1.EntityStoreService_IncrementCounter_FullMethodName:   true,
+	storev1.EntityStoreService_BatchWriteEntities_FullMethodName: true,
+	storev1.EntityStoreService_PublishEntities_FullMethodName:    true,
+}
+
+type roleKey struct{}
+
+// UnaryInterceptor authenticates and authorizes unary calls, takes a
+// trusted caller's origin from OriginHeader for the writes it makes, keeps
+// the store's clock in step with a trusted caller's through HLCHeader (see
+// UnaryServerHLC), and records mutating ones in the audit log. Without
+// WithAuth it lets every call through; without WithAudit it records
+// nothing.
+func (s *Server) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		authed, err := s.authorize(ctx, info.FullMethod)
+		var resp any
+		if err == nil {
+			resp, err = UnaryServerHLC(s.clock(authed))(s.withOrigin(authed), req, info, handler)
+		}
+		s.audit(ctx, info.FullMethod, req, err)
+		return resp, err
+	}
+}
+
+// StreamInterceptor is UnaryInterceptor for streaming calls.
+func (s *Server) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		authed, err := s.authorize(ss.Context(), info.FullMethod)
+		if err == nil {
+			err = StreamServerHLC(s.clock(authed))(srv, authedStream{ss, s.withOrigin(authed)}, info, handler)
+		}
+		s.audit(ss.Context(), info.FullMethod, nil, err)
+		return err
+	}
+}
+
+// authedStream is a stream whose context carries the caller's role and
+// origin, for handlers that check each write, like PublishEntities.
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s authedStream) Context() context.Context { return s.ctx }
+
+// bearerToken returns the token a call carries, if any.
+func bearerToken(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get("authorization"); len(v) > 0 {
+		token, _ := strings.CutPrefix(v[0], "Bearer ")
+		return token
+	}
+	return ""
+}
+
+// authorize checks the caller's token may call method and returns ctx
+// carrying its role.
+func (s *Server) authorize(ctx context.Context, method string) (context.Context, error) {
+	// Probes from compose and Kubernetes carry no token.
+	if s.auth == nil || strings.HasPrefix(method, "/grpc.health.v1.Health/") {
+		return ctx, nil
+	}
+	token := bearerToken(ctx)
+	role, ok := s.auth.Authenticate(token)
+	if token == "" || !ok {
+		return nil, status.Error(codes.Unauthenticated, "a valid bearer token is required")
+	}
+	if role != RoleOperator && !(role == RoleSensor && sensorMethods[method]) {
+		return nil, status.Errorf(codes.PermissionDenied, "role %s may not call %s", role, method)
+	}
+	return context.WithValue(ctx, roleKey{}, role), nil
+}
+
+// trusted reports whether the caller of ctx, as authorized, may speak for
+// the mesh: every caller without WithAuth, else only operators.
+func (s *Server) trusted(ctx context.Context) bool {
+	role, _ := ctx.Value(roleKey{}).(Role)
+	return s.auth == nil || role == RoleOperator
+}
+
+// authorizeWrite limits sensors to writing tracks: creates, updates, and
+// upserts must carry a track, and all but creates must target one.
+func (s *Server) authorizeWrite(ctx context.Context, w store.Write) error {
+	if role, _ := ctx.Value(roleKey{}).(Role); role != RoleSensor {
+		return nil
+	}
+	track := entityv1.EntityType_ENTITY_TYPE_TRACK
+	switch {
+	case w.Op == store.OpDelete:
+		return status.Error(codes.PermissionDenied, "sensors may not delete entities")
+	case w.Op != store.OpPatch && w.Op != store.OpIncrement && w.Entity.Type != track:
+		return status.Errorf(codes.PermissionDenied, "sensors may only write tracks, not %s", w.Entity.Type)
+	case w.Op != store.OpCreate:
+		if e, err := s.store.Get(w.Entity.Id); err == nil && e.Type != track {
+			return status.Errorf(codes.PermissionDenied, "sensors may only write tracks, %q is %s", e.Id, e.Type)
+		}
+	}
+	return nil
+}
+
+// RequireOperator serves h only to requests whose Authorization header
+// carries a bearer token a grants RoleOperator, for HTTP endpoints that
+// read the store directly rather than through the gRPC server, such as
+// the export and /metrics. With a nil a it returns h.
+func RequireOperator(a Authenticator, h http.Handler) http.Handler {
+	if a == nil {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		role, valid := a.Authenticate(token)
+		switch {
+		case !ok || token == "" || !valid:
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "a valid bearer token is required", http.StatusUnauthorized)
+		case role != RoleOperator:
+			http.Error(w, fmt.Sprintf("role %s may not read %s", role, r.URL.Path), http.StatusForbidden)
+		default:
+			h.ServeHTTP(w, r)
+		}
+	})
+}
+
+// Token is per-RPC credentials sending a bearer token, for clients of a
+// server WithAuth. It is sent over plaintext connections too.
+type Token string
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (t Token) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(t)}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (Token) RequireTransportSecurity() bool { return false }
@@ -0,0 +1,171 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestParseTokens(t *testing.T) {
+	tokens, err := ParseTokens("op1=operator, radar=sensor,")
+	if err != nil {
+		t.Fatalf("ParseTokens: %v", err)
+	}
+	if len(tokens) != 2 || tokens["op1"] != RoleOperator || tokens["radar"] != RoleSensor {
+		t.Fatalf("unexpected tokens %v", tokens)
+	}
+	for _, bad := range []string{"op1", "=operator", "op1=admin"} {
+		if _, err := ParseTokens(bad); err == nil {
+			t.Errorf("ParseTokens(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestAuthorizeHealthChecks(t *testing.T) {
+	s := New(store.New(), WithAuth(StaticTokens{"op": RoleOperator}))
+	if _, err := s.authorize(context.Background(), "/grpc.health.v1.Health/Check"); err != nil {
+		t.Fatalf("expected health checks allowed without a token, got %v", err)
+	}
+	if _, err := s.authorize(context.Background(), storev1.EntityStoreService_GetEntity_FullMethodName); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected other methods to need a token, got %v", err)
+	}
+}
+
+func TestGRPCAuth(t *testing.T) {
+	client, cleanup := serveStore(t, store.New(), WithAuth(StaticTokens{"op": RoleOperator, "radar": RoleSensor}))
+	defer cleanup()
+
+	ctx := context.Background()
+	op, sensor := grpc.PerRPCCredentials(Token("op")), grpc.PerRPCCredentials(Token("radar"))
+	track := &entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}
+	asset := &entityv1.Entity{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET}
+
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "t1"}); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated without a token, got %v", err)
+	}
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "t1"}, grpc.PerRPCCredentials(Token("guess"))); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("expected Unauthenticated for an unknown token, got %v", err)
+	}
+
+	// Sensors write tracks and nothing else.
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: track}, sensor); err != nil {
+		t.Fatalf("sensor CreateEntity track: %v", err)
+	}
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: asset}, sensor); status.Code(err) != codes.PermissionDenied {
+		t.Fatalf("expected a sensor refused an asset, got %v", err)
+	}
+	for name, call := range map[string]func() error{
+		"GetEntity": func() error {
+			_, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "t1"}, sensor)
+			return err
+		},
+		"DeleteEntity": func() error {
+			_, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: "t1"}, sensor)
+			return err
+		},
+		"WatchEntities": func() error {
+			stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{}, sensor)
+			if err == nil {
+				_, err = stream.Recv()
+			}
+			return err
+		},
+	} {
+		if err := call(); status.Code(err) != codes.PermissionDenied {
+			t.Errorf("%s: expected a sensor refused, got %v", name, err)
+		}
+	}
+
+	// Operators may do anything, including write what sensors may not.
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: asset}, op); err != nil {
+		t.Fatalf("operator CreateEntity asset: %v", err)
+	}
+
+	// In a batch, each op a sensor may not make fails on its own.
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1, Lon: 2})
+	resp, err := client.BatchWriteEntities(ctx, &storev1.BatchWriteEntitiesRequest{Ops: []*storev1.WriteOp{
+		{Op: &storev1.WriteOp_Patch{Patch: &storev1.PatchComponentRequest{Id: "t1", Components: map[string]*anypb.Any{"position": pos}}}},
+		{Op: &storev1.WriteOp_Patch{Patch: &storev1.PatchComponentRequest{Id: "a1", Components: map[string]*anypb.Any{"position": pos}}}},
+		{Op: &storev1.WriteOp_Delete{Delete: &storev1.DeleteEntityRequest{Id: "t1"}}},
+	}}, sensor)
+	if err != nil {
+		t.Fatalf("BatchWriteEntities: %v", err)
+	}
+	want := []codes.Code{codes.OK, codes.PermissionDenied, codes.PermissionDenied}
+	for i, code := range want {
+		if got := codes.Code(resp.Results[i].Code); got != code {
+			t.Errorf("op %d: expected %v, got %v (%s)", i, code, got, resp.Results[i].Message)
+		}
+	}
+
+	if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: "t1"}, op); err != nil {
+		t.Fatalf("operator DeleteEntity: %v", err)
+	}
+}
+
+func TestGRPCAuthOrigin(t *testing.T) {
+	s := store.New()
+	client, cleanup := serveStore(t, s, WithAuth(StaticTokens{"op": RoleOperator, "radar": RoleSensor}))
+	defer cleanup()
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	defer s.Unwatch(w)
+
+	// A sensor naming a mesh node as its origin, or in its path, must not
+	// pass for a write relays have already passed on.
+	ctx := ContextWithPath(ContextWithHops(ContextWithOrigin(context.Background(), "node-b"), 2), []string{"node-b", "node-c"})
+	for _, call := range []struct {
+		id, token, origin string
+		hops              uint32
+		path              []string
+	}{{"s1", "radar", "", 0, nil}, {"s2", "op", "node-b", 2, []string{"node-b", "node-c"}}} {
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+			Entity: &entityv1.Entity{Id: call.id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+		}, grpc.PerRPCCredentials(Token(call.token))); err != nil {
+			t.Fatalf("CreateEntity %s: %v", call.id, err)
+		}
+		ev := <-w.Events
+		if ev.OriginNode != call.origin || ev.Hops != call.hops || !slices.Equal(ev.Path, call.path) {
+			t.Errorf("%s as %s: expected origin %q after %d hops via %v, got %q after %d via %v", call.id, call.token, call.origin, call.hops, call.path, ev.OriginNode, ev.Hops, ev.Path)
+		}
+	}
+}
+
+func TestRequireOperator(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h := RequireOperator(StaticTokens{"op": RoleOperator, "radar": RoleSensor}, ok)
+	for _, tt := range []struct {
+		header string
+		want   int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer nope", http.StatusUnauthorized},
+		{"op", http.StatusUnauthorized},
+		{"Bearer radar", http.StatusForbidden},
+		{"Bearer op", http.StatusOK},
+	} {
+		r := httptest.NewRequest("GET", "/metrics", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.want {
+			t.Errorf("Authorization %q: got %d, want %d", tt.header, w.Code, tt.want)
+		}
+	}
+	w := httptest.NewRecorder()
+	RequireOperator(nil, ok).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected every request served without an authenticator, got %d", w.Code)
+	}
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"context"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Every write RPC checks its request into a store.Write and applies it
+// through store.Batch, alone or with the rest of a BatchWriteEntities
+// call, so both paths validate and fail the same way.
+
+// BatchWriteEntities applies every op under one store lock. An op that
+// fails validation is skipped; the others are still applied.
+func (s *Server) BatchWriteEntities(ctx context.Context, req *storev1.BatchWriteEntitiesRequest) (*storev1.BatchWriteEntitiesResponse, error) {
+	results := make([]*storev1.WriteResult, len(req.Ops))
+	writes := make([]store.Write, 0, len(req.Ops))
+	index := make([]int, 0, len(req.Ops)) // op index of each write
+	for i, op := range req.Ops {
+		w, err := s.opWrite(op)
+		if err == nil {
+			err = s.authorizeWrite(ctx, w)
+		}
+		if err != nil {
+			results[i] = writeResult(nil, err)
+			continue
+		}
+		w.Origin, w.Hops, w.Path = origin(ctx), hops(ctx), path(ctx)
+		writes = append(writes, w)
+		index = append(index, i)
+	}
+	for j, r := range s.store.Batch(writes) {
+		var err error
+		if r.Err != nil {
+			err = storeError(failCode(writes[j].Op), r.Err)
+		}
+		results[index[j]] = writeResult(r.Entity, err)
+	}
+	return &storev1.BatchWriteEntitiesResponse{Results: results}, nil
+}
+
+func (s *Server) opWrite(op *storev1.WriteOp) (store.Write, error) {
+	switch op := op.GetOp().(type) {
+	case *storev1.WriteOp_Create:
+		return s.createWrite(op.Create)
+	case *storev1.WriteOp_Update:
+		return s.updateWrite(op.Update)
+	case *storev1.WriteOp_Patch:
+		return s.patchWrite(op.Patch)
+	case *storev1.WriteOp_Delete:
+		return deleteWrite(op.Delete)
+	case *storev1.WriteOp_Increment:
+		return incrementWrite(op.Increment)
+	}
+	return store.Write{}, status.Error(codes.InvalidArgument, "op is required")
+}
+
+func writeResult(e *entityv1.Entity, err error) *storev1.WriteResult {
+	if err != nil {
+		st := status.Convert(err)
+		return &storev1.WriteResult{Code: int32(st.Code()), Message: st.Message()}
+	}
+	return &storev1.WriteResult{Entity: e}
+}
+
+// apply applies a single write the caller may make, stamped with its origin,
+// hops, and path.
+func (s *Server) apply(ctx context.Context, w store.Write) (*entityv1.Entity, error) {
+	if err := s.authorizeWrite(ctx, w); err != nil {
+		return nil, err
+	}
+	w.Origin, w.Hops, w.Path = origin(ctx), hops(ctx), path(ctx)
+	r := s.store.Batch([]store.Write{w})[0]
+	if r.Err != nil {
+		return nil, storeError(failCode(w.Op), r.Err)
+	}
+	return r.Entity, nil
+}
+
+// failCode is the status a store error maps to for an op: the entity
+// already exists for a create, and is missing otherwise.
+func failCode(op store.WriteOp) codes.Code {
+	if op == store.OpCreate {
+		return codes.AlreadyExists
+	}
+	return codes.NotFound
+}
+
+func (s *Server) createWrite(req *storev1.CreateEntityRequest) (store.Write, error) {
+	if req.GetEntity() == nil {
+		return store.Write{}, status.Error(codes.InvalidArgument, "entity is required")
+	}
+	if req.Entity.Id == "" {
+		return store.Write{}, status.Error(codes.InvalidArgument, "entity id is required")
+	}
+	ttl, err := requestTTL(req.Ttl)
+	if err != nil {
+		return store.Write{}, err
+	}
+	if err := s.validate(req.Entity); err != nil {
+		return store.Write{}, err
+	}
+	return store.Write{Op: store.OpCreate, Entity: req.Entity, TTL: ttl}, nil
+}
+
+func (s *Server) updateWrite(req *storev1.UpdateEntityRequest) (store.Write, error) {
+	if req.GetEntity() == nil {
+		return store.Write{}, status.Error(codes.InvalidArgument, "entity is required")
+	}
+	ttl, err := requestTTL(req.Ttl)
+	if err != nil {
+		return store.Write{}, err
+	}
+	if err := s.validate(req.Entity); err != nil {
+		return store.Write{}, err
+	}
+	w := store.Write{Op: store.OpUpdate, Entity: req.Entity, TTL: ttl}
+	if ts := req.ExpectedHlc; ts != nil {
+		w.Expected = &hlc.Timestamp{Physical: ts.Physical, Logical: ts.Logical, Node: ts.Node}
+	}
+	return w, nil
+}
+
+func (s *Server) patchWrite(req *storev1.PatchComponentRequest) (store.Write, error) {
+	if req.GetId() == "" {
+		return store.Write{}, status.Error(codes.InvalidArgument, "entity id is required")
+	}
+	if len(req.Components) == 0 {
+		return store.Write{}, status.Error(codes.InvalidArgument, "components are required")
+	}
+	for key, c := range req.Components {
+		if c == nil {
+			return store.Write{}, status.Errorf(codes.InvalidArgument, "component %q is empty", key)
+		}
+	}
+	ttl, err := requestTTL(req.Ttl)
+	if err != nil {
+		return store.Write{}, err
+	}
+	if err := s.validate(&entityv1.Entity{Components: req.Components}); err != nil {
+		return store.Write{}, err
+	}
+	return store.Write{Op: store.OpPatch, Entity: &entityv1.Entity{Id: req.Id, Components: req.Components}, TTL: ttl}, nil
+}
+
+func removeWrite(req *storev1.RemoveComponentRequest) (store.Write, error) {
+	if req.GetId() == "" {
+		return store.Write{}, status.Error(codes.InvalidArgument, "entity id is required")
+	}
+	if len(req.Keys) == 0 {
+		return store.Write{}, status.Error(codes.InvalidArgument, "component keys are required")
+	}
+	for _, key := range req.Keys {
+		if key == "" {
+			return store.Write{}, status.Error(codes.InvalidArgument, "component key is empty")
+		}
+	}
+	return store.Write{Op: store.OpRemove, Entity: &entityv1.Entity{Id: req.Id}, Keys: req.Keys}, nil
+}
+
+func incrementWrite(req *storev1.IncrementCounterRequest) (store.Write, error) {
+	if req.GetId() == "" {
+		return store.Write{}, status.Error(codes.InvalidArgument, "entity id is required")
+	}
+	if req.Key == "" {
+		return store.Write{}, status.Error(codes.InvalidArgument, "counter key is required")
+	}
+	return store.Write{Op: store.OpIncrement, Entity: &entityv1.Entity{Id: req.Id}, Keys: []string{req.Key}, Delta: req.Delta}, nil
+}
+
+func deleteWrite(req *storev1.DeleteEntityRequest) (store.Write, error) {
+	w := store.Write{Op: store.OpDelete, Entity: &entityv1.Entity{Id: req.GetId()}}
+	if ts := req.GetHlc(); ts != nil {
+		if req.Id == "" {
+			return store.Write{}, status.Error(codes.InvalidArgument, "entity id is required")
+		}
+		w.At = &hlc.Timestamp{Physical: ts.Physical, Logical: ts.Logical, Node: ts.Node}
+	}
+	return w, nil
+}
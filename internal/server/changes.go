@@ -0,0 +1,43 @@
+package server
+
+import (
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func (s *Server) StreamChanges(req *storev1.StreamChangesRequest, stream grpc.ServerStreamingServer[storev1.ChangeRecord]) error {
+	f, err := s.store.Changes(req.SinceSequence)
+	if err != nil {
+		return status.Errorf(codes.OutOfRange, "%v", err)
+	}
+	defer s.store.StopChanges(f)
+
+	// As for WatchEntities, headers tell the client the feed is registered.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for _, r := range f.Backlog {
+		if err := stream.Send(r); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case r, ok := <-f.Records:
+			if !ok {
+				if f.Lagged() {
+					return status.Error(codes.ResourceExhausted, "change feed fell behind; resume from the last sequence received")
+				}
+				return nil
+			}
+			if err := stream.Send(r); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
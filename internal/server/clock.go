@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// HLCHeader is the metadata key carrying the sender's HLC, formatted by
+// hlc.Timestamp.String. Clients send it on every request and servers on
+// every response, in the header of a unary call and the trailer of a
+// stream, and each side advances its clock past the other's. A write made
+// after reading from one store is then stamped, on any store, after what
+// was read, even if the writer never relays through the mesh.
+const HLCHeader = "lattice-hlc"
+
+// WallHeader is the metadata key carrying, in the header of a unary
+// call's response, the server's wall time in Unix nanoseconds, which may
+// differ from its HLC's physical time. Relays compare it with their own
+// store's to measure how far apart the nodes' clocks are.
+const WallHeader = "lattice-wall"
+
+// Clock is the clock the HLC interceptors keep in step with the other
+// side: an *hlc.Clock, or a store.Store for the store's own.
+type Clock interface {
+	Now() hlc.Timestamp
+	Observe(remote hlc.Timestamp) (hlc.Timestamp, error)
+	Wall() time.Time
+}
+
+// UnaryClientHLC sends c's HLC with every unary call and advances c past
+// the HLC of the server's response.
+func UnaryClientHLC(c Clock) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		var header metadata.MD
+		ctx = metadata.AppendToOutgoingContext(ctx, HLCHeader, c.Now().String())
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Header(&header))...)
+		observeHLC(c, header)
+		return err
+	}
+}
+
+// StreamClientHLC sends c's HLC when a stream opens and advances c past
+// the HLC the server sends when it ends.
+func StreamClientHLC(c Clock) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx = metadata.AppendToOutgoingContext(ctx, HLCHeader, c.Now().String())
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			return nil, err
+		}
+		return &hlcClientStream{ClientStream: cs, clock: c, unary: !desc.ServerStreams}, nil
+	}
+}
+
+// hlcClientStream observes the server's HLC in the stream's trailer once
+// the stream ends: at its only response, if the server sends one, else
+// when a receive fails.
+type hlcClientStream struct {
+	grpc.ClientStream
+	clock Clock
+	unary bool
+}
+
+func (s *hlcClientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil || s.unary {
+		observeHLC(s.clock, s.Trailer())
+	}
+	return err
+}
+
+// UnaryServerHLC advances c past the caller's HLC, if it sent one, before
+// handling a unary call, and sends c's HLC and wall time in the response
+// header. A call whose HLC is malformed fails with InvalidArgument, and
+// one whose HLC c refuses, for drifting too far ahead of c's wall clock,
+// with Unavailable, rather than dragging c along.
+func UnaryServerHLC(c Clock) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if err := observeCaller(c, md); err != nil {
+			return nil, err
+		}
+		resp, err := handler(ctx, req)
+		grpc.SetHeader(ctx, metadata.Pairs(HLCHeader, c.Now().String(), WallHeader, strconv.FormatInt(c.Wall().UnixNano(), 10))) //nolint:errcheck
+		return resp, err
+	}
+}
+
+// StreamServerHLC is UnaryServerHLC for streams, sending c's HLC in the
+// trailer, as the stream ends.
+func StreamServerHLC(c Clock) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		md, _ := metadata.FromIncomingContext(ss.Context())
+		if err := observeCaller(c, md); err != nil {
+			return err
+		}
+		err := handler(srv, ss)
+		ss.SetTrailer(metadata.Pairs(HLCHeader, c.Now().String()))
+		return err
+	}
+}
+
+// observeCaller advances c past the HLC a caller sent in md, if any, and
+// returns the status to fail the call with if it is malformed or c
+// refuses it.
+func observeCaller(c Clock, md metadata.MD) error {
+	v := md.Get(HLCHeader)
+	if len(v) == 0 {
+		return nil
+	}
+	ts, err := hlc.ParseTimestamp(v[0])
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%s: %v", HLCHeader, err)
+	}
+	if _, err := c.Observe(ts); err != nil {
+		return status.Errorf(codes.Unavailable, "%s: %v", HLCHeader, err)
+	}
+	return nil
+}
+
+// clock returns the clock the HLC interceptors keep in step with the
+// caller of ctx: the store's, which only a trusted caller's HLC advances,
+// so a sensor with a fast or hostile clock cannot push every later write
+// ahead.
+func (s *Server) clock(ctx context.Context) Clock {
+	if s.trusted(ctx) {
+		return s.store
+	}
+	return unobserved{s.store}
+}
+
+// unobserved is the store's clock as an untrusted caller sees it: it
+// neither adopts the caller's HLC nor ticks for the call, answering both
+// with the store's latest timestamp. The caller still advances past every
+// write it read, since each was stamped at or before that.
+type unobserved struct{ store *store.Store }
+
+func (c unobserved) Now() hlc.Timestamp                           { return c.store.Last() }
+func (c unobserved) Observe(hlc.Timestamp) (hlc.Timestamp, error) { return c.store.Last(), nil }
+func (c unobserved) Wall() time.Time                              { return c.store.Wall() }
+
+// observeHLC advances c past the HLC in md, if any: a server's, in its
+// response to a client. A malformed HLC, or one c refuses for drifting too
+// far ahead, is logged and ignored: the call has been made, and c keeps
+// its own time.
+func observeHLC(c Clock, md metadata.MD) {
+	v := md.Get(HLCHeader)
+	if len(v) == 0 {
+		return
+	}
+	ts, err := hlc.ParseTimestamp(v[0])
+	if err == nil {
+		_, err = c.Observe(ts)
+	}
+	if err != nil {
+		slog.Debug("ignoring peer hlc", "hlc", v[0], "error", err)
+	}
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestHLCPropagation(t *testing.T) {
+	s := store.New(store.WithNodeID("store"))
+	srv := New(s)
+	g := grpc.NewServer(grpc.UnaryInterceptor(srv.UnaryInterceptor()), grpc.StreamInterceptor(srv.StreamInterceptor()))
+	storev1.RegisterEntityStoreServiceServer(g, srv)
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go g.Serve(lis) //nolint:errcheck
+	defer g.Stop()
+	dial := func(c *hlc.Clock) storev1.EntityStoreServiceClient {
+		t.Helper()
+		conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithChainUnaryInterceptor(UnaryClientHLC(c)), grpc.WithChainStreamInterceptor(StreamClientHLC(c)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return storev1.NewEntityStoreServiceClient(conn)
+	}
+	ctx := context.Background()
+
+	// A sim whose clock runs an hour ahead writes a track: the store
+	// stamps it after the sim's HLC, not by its own wall clock.
+	sim := hlc.NewClock("sim")
+	sim.SetOffset(time.Hour)
+	sent := sim.Now()
+	created, err := dial(sim).CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	stamped := hlc.Timestamp{Physical: created.HlcPhysical, Logical: created.HlcLogical, Node: created.HlcNode}
+	if !stamped.After(sent) {
+		t.Fatalf("expected the write stamped after the sim's %v, got %v", sent, stamped)
+	}
+
+	// A classifier reading it advances past the write, so what it writes
+	// next, to this store or any other, orders after it.
+	classifier := hlc.NewClock("classifier")
+	if _, err := dial(classifier).GetEntity(ctx, &storev1.GetEntityRequest{Id: "t1"}); err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if now := classifier.Now(); !now.After(stamped) {
+		t.Fatalf("expected the classifier's clock past %v, got %v", stamped, now)
+	}
+
+	// Streams carry the server's HLC in their trailer.
+	task := hlc.NewClock("task-manager")
+	stream, err := dial(task).PublishEntities(ctx)
+	if err != nil {
+		t.Fatalf("PublishEntities: %v", err)
+	}
+	if _, err := stream.CloseAndRecv(); err != nil {
+		t.Fatalf("CloseAndRecv: %v", err)
+	}
+	if now := task.Now(); !now.After(stamped) {
+		t.Fatalf("expected the task manager's clock past %v, got %v", stamped, now)
+	}
+}
+
+func TestServerHLCRefusesDrift(t *testing.T) {
+	s := store.New(store.WithNodeID("store"), store.WithMaxDrift(time.Minute, 0))
+	client, cleanup := serveStore(t, s, WithAuth(StaticTokens{"op": RoleOperator, "radar": RoleSensor}))
+	defer cleanup()
+	op, sensor := grpc.PerRPCCredentials(Token("op")), grpc.PerRPCCredentials(Token("radar"))
+
+	// An operator's HLC an hour ahead fails the call rather than dragging
+	// the store's clock along, and a malformed one fails it too.
+	ahead := hlc.Timestamp{Physical: uint64(time.Now().Add(time.Hour).UnixNano()), Node: "fast"}
+	fast := metadata.AppendToOutgoingContext(context.Background(), HLCHeader, ahead.String())
+	if _, err := client.GetEntity(fast, &storev1.GetEntityRequest{Id: "t1"}, op); status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable for an HLC past the bound, got %v", err)
+	}
+	bad := metadata.AppendToOutgoingContext(context.Background(), HLCHeader, "soon")
+	if _, err := client.GetEntity(bad, &storev1.GetEntityRequest{Id: "t1"}, op); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for a malformed HLC, got %v", err)
+	}
+
+	// A sensor's HLC is not observed at all: its write is stamped by the
+	// store's own clock.
+	created, err := client.CreateEntity(fast, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	}, sensor)
+	if err != nil {
+		t.Fatalf("sensor CreateEntity: %v", err)
+	}
+	if stamped := (hlc.Timestamp{Physical: created.HlcPhysical, Logical: created.HlcLogical, Node: created.HlcNode}); !ahead.After(stamped) {
+		t.Fatalf("expected the sensor's write stamped by the store's clock, got %v", stamped)
+	}
+}
+
+func TestServerHLCSensorLeavesClock(t *testing.T) {
+	s := store.New(store.WithNodeID("store"))
+	client, cleanup := serveStore(t, s, WithAuth(StaticTokens{"op": RoleOperator, "radar": RoleSensor}))
+	defer cleanup()
+	sensor := grpc.PerRPCCredentials(Token("radar"))
+
+	// A sensor's write, with an HLC or without, moves the store's clock to
+	// the write's stamp and no further: neither its HLC nor the response
+	// ticks the clock, and the response carries the stamp.
+	sent := hlc.Timestamp{Physical: uint64(time.Now().UnixNano()), Node: "radar"}
+	for i, ctx := range []context.Context{
+		metadata.AppendToOutgoingContext(context.Background(), HLCHeader, sent.String()),
+		context.Background(),
+	} {
+		var header metadata.MD
+		created, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+			Entity: &entityv1.Entity{Id: fmt.Sprintf("t%d", i), Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+		}, sensor, grpc.Header(&header))
+		if err != nil {
+			t.Fatalf("sensor CreateEntity: %v", err)
+		}
+		stamped := hlc.Timestamp{Physical: created.HlcPhysical, Logical: created.HlcLogical, Node: created.HlcNode}
+		if last := s.Last(); last != stamped {
+			t.Fatalf("expected the store's clock at the write's %v, got %v", stamped, last)
+		}
+		if v := header.Get(HLCHeader); len(v) != 1 || v[0] != stamped.String() {
+			t.Fatalf("expected the response HLC %v, got %v", stamped, v)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain is the ErrorInfo domain of the store's errors.
+const errorDomain = "lattice.store"
+
+// withDetails attaches to st the google.rpc details of a store error, so a
+// client can see what a write collided with without the server's logs:
+// the entity's HLC on a conflict or duplicate create, the components
+// written since the caller read it, the quota hit, or the component that
+// failed validation. Errors with nothing to add return st as it is.
+func withDetails(st *status.Status, err error) *status.Status {
+	var details []protoadapt.MessageV1
+	var (
+		conflict *store.ConflictError
+		exists   *store.ExistsError
+		quota    *store.QuotaError
+		invalid  *store.InvalidError
+	)
+	switch {
+	case errors.As(err, &conflict):
+		details = append(details, &errdetails.ErrorInfo{
+			Reason: "CONFLICT",
+			Domain: errorDomain,
+			Metadata: map[string]string{
+				"entity_id":    conflict.ID,
+				"expected_hlc": conflict.Expected.String(),
+				"current_hlc":  conflict.Current.String(),
+			},
+		})
+		pf := &errdetails.PreconditionFailure{}
+		for _, key := range conflict.Stale {
+			pf.Violations = append(pf.Violations, &errdetails.PreconditionFailure_Violation{
+				Type:        "STALE_COMPONENT",
+				Subject:     key,
+				Description: "component written since " + conflict.Expected.String(),
+			})
+		}
+		if len(pf.Violations) == 0 {
+			// Only the entity's labels or type changed.
+			pf.Violations = append(pf.Violations, &errdetails.PreconditionFailure_Violation{
+				Type:        "STALE_ENTITY",
+				Subject:     conflict.ID,
+				Description: "entity written since " + conflict.Expected.String(),
+			})
+		}
+		details = append(details, pf)
+	case errors.As(err, &exists):
+		details = append(details,
+			&errdetails.ErrorInfo{
+				Reason:   "ALREADY_EXISTS",
+				Domain:   errorDomain,
+				Metadata: map[string]string{"entity_id": exists.ID, "current_hlc": exists.HLC.String()},
+			},
+			&errdetails.ResourceInfo{ResourceType: "entity", ResourceName: exists.ID},
+		)
+	case errors.As(err, &quota):
+		details = append(details,
+			&errdetails.ErrorInfo{
+				Reason:   "QUOTA_EXCEEDED",
+				Domain:   errorDomain,
+				Metadata: map[string]string{"entity_type": quota.Type.String(), "limit": strconv.Itoa(quota.Limit)},
+			},
+			&errdetails.QuotaFailure{Violations: []*errdetails.QuotaFailure_Violation{{
+				Subject:     quota.Type.String(),
+				Description: quota.Type.String() + " is limited to " + strconv.Itoa(quota.Limit) + " entities",
+			}}},
+		)
+	case errors.As(err, &invalid):
+		details = append(details,
+			&errdetails.ErrorInfo{Reason: "INVALID_COMPONENT", Domain: errorDomain, Metadata: map[string]string{"component": invalid.Key}},
+			&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{{
+				Field:       "components[" + strconv.Quote(invalid.Key) + "]",
+				Description: invalid.Err.Error(),
+			}}},
+		)
+	case errors.Is(err, store.ErrDeleted):
+		details = append(details, &errdetails.ErrorInfo{Reason: "DELETED", Domain: errorDomain})
+	}
+	if len(details) == 0 {
+		return st
+	}
+	if detailed, err := st.WithDetails(details...); err == nil {
+		return detailed
+	}
+	return st
+}
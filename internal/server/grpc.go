@@ -2,40 +2,62 @@ package server
 
 import (
 	"context"
+	"errors"
+	"io"
+	"slices"
+	"time"
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/audit"
+	"github.com/boshu2/lattice-lab/internal/crdt"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/labels"
+	"github.com/boshu2/lattice-lab/internal/registry"
 	"github.com/boshu2/lattice-lab/internal/store"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 )
 
 // Server implements the EntityStoreService gRPC interface.
 type Server struct {
 	storev1.UnimplementedEntityStoreServiceServer
-	store *store.Store
+	store    *store.Store
+	registry *registry.Registry
+	auth     Authenticator // nil unless WithAuth
+	auditLog *audit.Log    // nil unless WithAudit
+
+	approvals ApprovalBackend // nil unless WithApprovals
 }
 
-// New creates a gRPC server backed by the given store.
-func New(s *store.Store) *Server {
-	return &Server{store: s}
+// Option configures a Server.
+type Option func(*Server)
+
+// WithRegistry rejects writes whose components fail their registered
+// schema. Components with unregistered type URLs are always accepted.
+func WithRegistry(r *registry.Registry) Option {
+	return func(s *Server) { s.registry = r }
 }
 
-func (s *Server) CreateEntity(_ context.Context, req *storev1.CreateEntityRequest) (*entityv1.Entity, error) {
-	if req.Entity == nil {
-		return nil, status.Error(codes.InvalidArgument, "entity is required")
-	}
-	if req.Entity.Id == "" {
-		return nil, status.Error(codes.InvalidArgument, "entity id is required")
+// New creates a gRPC server backed by the given store.
+func New(s *store.Store, opts ...Option) *Server {
+	srv := &Server{store: s}
+	for _, opt := range opts {
+		opt(srv)
 	}
+	return srv
+}
 
-	e, err := s.store.Create(req.Entity)
+func (s *Server) CreateEntity(ctx context.Context, req *storev1.CreateEntityRequest) (*entityv1.Entity, error) {
+	w, err := s.createWrite(req)
 	if err != nil {
-		return nil, status.Errorf(codes.AlreadyExists, "%v", err)
+		return nil, err
 	}
-	return e, nil
+	return s.apply(ctx, w)
 }
 
 func (s *Server) GetEntity(_ context.Context, req *storev1.GetEntityRequest) (*entityv1.Entity, error) {
@@ -47,47 +69,229 @@ func (s *Server) GetEntity(_ context.Context, req *storev1.GetEntityRequest) (*e
 }
 
 func (s *Server) ListEntities(_ context.Context, req *storev1.ListEntitiesRequest) (*storev1.ListEntitiesResponse, error) {
-	entities := s.store.List(req.TypeFilter)
+	sel, err := labels.Parse(req.LabelSelector)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	var entities []*entityv1.Entity
+	if len(req.Filters) == 0 {
+		entities = s.store.List(req.TypeFilter)
+	} else if entities, err = s.store.Filter(req.TypeFilter, req.Filters); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if !sel.Empty() {
+		entities = slices.DeleteFunc(entities, func(e *entityv1.Entity) bool { return !sel.Matches(e.Labels) })
+	}
 	return &storev1.ListEntitiesResponse{Entities: entities}, nil
 }
 
-func (s *Server) UpdateEntity(_ context.Context, req *storev1.UpdateEntityRequest) (*entityv1.Entity, error) {
-	if req.Entity == nil {
-		return nil, status.Error(codes.InvalidArgument, "entity is required")
+func (s *Server) QueryEntitiesByBBox(_ context.Context, req *storev1.QueryEntitiesByBBoxRequest) (*storev1.QueryEntitiesByBBoxResponse, error) {
+	b := store.BBox{MinLat: req.MinLat, MaxLat: req.MaxLat, MinLon: req.MinLon, MaxLon: req.MaxLon}
+	if err := b.Validate(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
+	return &storev1.QueryEntitiesByBBoxResponse{Entities: s.store.QueryBBox(b, req.TypeFilter)}, nil
+}
+
+func (s *Server) GetEntityHistory(_ context.Context, req *storev1.GetEntityHistoryRequest) (*storev1.GetEntityHistoryResponse, error) {
+	versions := s.store.History(req.Id)
+	if len(versions) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no history for entity %q", req.Id)
+	}
+	return &storev1.GetEntityHistoryResponse{Id: req.Id, Versions: versions}, nil
+}
 
-	e, err := s.store.Update(req.Entity)
+func (s *Server) ListArchivedEntities(_ context.Context, req *storev1.ListArchivedEntitiesRequest) (*storev1.ListArchivedEntitiesResponse, error) {
+	sel, err := labels.Parse(req.LabelSelector)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "%v", err)
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
 	}
-	return e, nil
+	archived := s.store.Archived(req.TypeFilter)
+	if !sel.Empty() {
+		archived = slices.DeleteFunc(archived, func(a *storev1.ArchivedEntity) bool { return !sel.Matches(a.Entity.Labels) })
+	}
+	return &storev1.ListArchivedEntitiesResponse{Entities: archived}, nil
 }
 
-func (s *Server) DeleteEntity(_ context.Context, req *storev1.DeleteEntityRequest) (*emptypb.Empty, error) {
-	if err := s.store.Delete(req.Id); err != nil {
+func (s *Server) DigestEntities(_ context.Context, req *storev1.DigestEntitiesRequest) (*storev1.DigestEntitiesResponse, error) {
+	nodes := make([]int, len(req.Nodes))
+	for i, n := range req.Nodes {
+		nodes[i] = int(n)
+	}
+	digest, err := s.store.Digest(int(req.Level), nodes)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	return &storev1.DigestEntitiesResponse{Nodes: digest}, nil
+}
+
+func (s *Server) UpdateEntity(ctx context.Context, req *storev1.UpdateEntityRequest) (*entityv1.Entity, error) {
+	w, err := s.updateWrite(req)
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(ctx, w)
+}
+
+func (s *Server) GetComponent(_ context.Context, req *storev1.GetComponentRequest) (*storev1.GetComponentResponse, error) {
+	c, ts, err := s.store.GetComponent(req.Id, req.Key)
+	if err != nil {
 		return nil, status.Errorf(codes.NotFound, "%v", err)
 	}
-	return &emptypb.Empty{}, nil
+	return &storev1.GetComponentResponse{
+		Component: c,
+		Hlc:       &entityv1.HLCTimestamp{Physical: ts.Physical, Logical: ts.Logical, Node: ts.Node},
+	}, nil
+}
+
+func (s *Server) PatchComponent(ctx context.Context, req *storev1.PatchComponentRequest) (*storev1.PatchComponentResponse, error) {
+	w, err := s.patchWrite(req)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.apply(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	return &storev1.PatchComponentResponse{
+		Hlc: &entityv1.HLCTimestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode},
+	}, nil
+}
+
+func (s *Server) RemoveComponent(ctx context.Context, req *storev1.RemoveComponentRequest) (*storev1.RemoveComponentResponse, error) {
+	w, err := removeWrite(req)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.apply(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	return &storev1.RemoveComponentResponse{
+		Hlc: &entityv1.HLCTimestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode},
+	}, nil
+}
+
+func (s *Server) IncrementCounter(ctx context.Context, req *storev1.IncrementCounterRequest) (*storev1.IncrementCounterResponse, error) {
+	w, err := incrementWrite(req)
+	if err != nil {
+		return nil, err
+	}
+	e, err := s.apply(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	counter := &entityv1.CounterComponent{}
+	e.Components[req.Key].UnmarshalTo(counter) //nolint:errcheck // the store wrote it
+	return &storev1.IncrementCounterResponse{
+		Value: crdt.CounterValue(counter),
+		Hlc:   &entityv1.HLCTimestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode},
+	}, nil
+}
+
+// storeError maps a store write error to code, to Internal if the
+// write-ahead log failed, to FailedPrecondition if the write was a stale
+// copy of a deleted entity or lost a conditional update, to
+// ResourceExhausted if a create hit its type's quota, to InvalidArgument
+// if a component failed the store's validation, or to Unavailable if the
+// store's clock drifted past its bound. The status carries the error's
+// details; see withDetails.
+func storeError(code codes.Code, err error) error {
+	switch {
+	case errors.Is(err, store.ErrLog):
+		code = codes.Internal
+	case errors.Is(err, hlc.ErrDrift):
+		code = codes.Unavailable
+	case errors.Is(err, store.ErrDeleted), errors.Is(err, store.ErrConflict):
+		code = codes.FailedPrecondition
+	case errors.Is(err, store.ErrQuota):
+		code = codes.ResourceExhausted
+	case errors.Is(err, store.ErrInvalid):
+		code = codes.InvalidArgument
+	}
+	return withDetails(status.New(code, err.Error()), err).Err()
+}
+
+// requestTTL validates an optional request TTL; unset returns zero.
+func requestTTL(d *durationpb.Duration) (time.Duration, error) {
+	if d == nil {
+		return 0, nil
+	}
+	if err := d.CheckValid(); err != nil || d.AsDuration() <= 0 {
+		return 0, status.Error(codes.InvalidArgument, "ttl must be a positive duration")
+	}
+	return d.AsDuration(), nil
 }
 
-func (s *Server) ApproveAction(_ context.Context, req *storev1.ApproveActionRequest) (*entityv1.Entity, error) {
-	return nil, status.Error(codes.Unimplemented, "approval gate not wired to this server instance")
+// validate checks the entity's labels, and every component against the
+// schema registry, if any.
+func (s *Server) validate(e *entityv1.Entity) error {
+	if err := labels.Validate(e.Labels); err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	if s.registry == nil {
+		return nil
+	}
+	for key, c := range e.Components {
+		if err := s.registry.Validate(c); err != nil {
+			return status.Errorf(codes.InvalidArgument, "component %q: %v", key, err)
+		}
+	}
+	return nil
 }
 
-func (s *Server) DenyAction(_ context.Context, req *storev1.DenyActionRequest) (*entityv1.Entity, error) {
-	return nil, status.Error(codes.Unimplemented, "approval gate not wired to this server instance")
+func (s *Server) DeleteEntity(ctx context.Context, req *storev1.DeleteEntityRequest) (*emptypb.Empty, error) {
+	w, err := deleteWrite(req)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.apply(ctx, w); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
 }
 
 func (s *Server) WatchEntities(req *storev1.WatchEntitiesRequest, stream grpc.ServerStreamingServer[storev1.EntityEvent]) error {
-	w := s.store.Watch(req.TypeFilter)
+	filter, err := newWatchFilter(req)
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+	var w *store.Watcher
+	switch {
+	case req.InitialState && req.SinceSequence != 0:
+		return status.Error(codes.InvalidArgument, "initial_state cannot be combined with since_sequence")
+	case req.InitialState:
+		w = s.store.WatchSnapshot(req.TypeFilter)
+	default:
+		if w, err = s.store.WatchFrom(req.TypeFilter, req.SinceSequence); err != nil {
+			return status.Errorf(codes.OutOfRange, "%v", err)
+		}
+	}
 	defer s.store.Unwatch(w)
 
+	// Headers tell the client the watch is registered, so it can load
+	// current state knowing no later event will be missed.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	for _, event := range w.Backlog {
+		if !filter.pass(event) {
+			continue
+		}
+		if err := stream.Send(event); err != nil {
+			return err
+		}
+	}
+
 	for {
 		select {
 		case event, ok := <-w.Events:
 			if !ok {
 				return nil
 			}
+			if !filter.pass(event) {
+				continue
+			}
 			if err := stream.Send(event); err != nil {
 				return err
 			}
@@ -96,3 +300,43 @@ func (s *Server) WatchEntities(req *storev1.WatchEntitiesRequest, stream grpc.Se
 		}
 	}
 }
+
+func (s *Server) SnapshotEntities(req *storev1.SnapshotEntitiesRequest, stream grpc.ServerStreamingServer[entityv1.Entity]) error {
+	for _, e := range s.store.List(req.TypeFilter) {
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) RestoreEntities(stream grpc.ClientStreamingServer[storev1.RestoreEntitiesRequest, storev1.RestoreEntitiesResponse]) error {
+	resp := &storev1.RestoreEntitiesResponse{}
+	for {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			return err
+		}
+		if req.Entity.GetId() == "" {
+			return status.Error(codes.InvalidArgument, "entity id is required")
+		}
+		if err := s.validate(req.Entity); err != nil {
+			return err
+		}
+		outcome, err := s.store.RestoreFrom(req.Entity, origin(stream.Context()))
+		if err != nil {
+			return storeError(codes.Internal, err)
+		}
+		switch outcome {
+		case store.RestoreCreated:
+			resp.Created++
+		case store.RestoreReplaced:
+			resp.Replaced++
+		case store.RestoreSkipped:
+			resp.Skipped++
+		}
+	}
+}
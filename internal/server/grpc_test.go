@@ -2,29 +2,48 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	registryv1 "github.com/boshu2/lattice-lab/gen/registry/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/registry"
 	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 // startTestServer spins up a gRPC server on a random port and returns the client + cleanup.
-func startTestServer(t *testing.T) (storev1.EntityStoreServiceClient, func()) {
+func startTestServer(t *testing.T, opts ...Option) (storev1.EntityStoreServiceClient, func()) {
 	t.Helper()
+	return serveStore(t, store.New(), opts...)
+}
 
-	s := store.New()
-	srv := grpc.NewServer()
-	storev1.RegisterEntityStoreServiceServer(srv, New(s))
+// serveStore is startTestServer for a store the test configures.
+func serveStore(t *testing.T, s *store.Store, opts ...Option) (storev1.EntityStoreServiceClient, func()) {
+	t.Helper()
+
+	server := New(s, opts...)
+	srv := grpc.NewServer(grpc.UnaryInterceptor(server.UnaryInterceptor()), grpc.StreamInterceptor(server.StreamInterceptor()))
+	storev1.RegisterEntityStoreServiceServer(srv, server)
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.StartReaper(ctx, 20*time.Millisecond)
 
 	lis, err := net.Listen("tcp", "localhost:0")
 	if err != nil {
+		cancel()
 		t.Fatalf("listen: %v", err)
 	}
 
@@ -32,12 +51,14 @@ func startTestServer(t *testing.T) (storev1.EntityStoreServiceClient, func()) {
 
 	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
+		cancel()
 		srv.Stop()
 		t.Fatalf("dial: %v", err)
 	}
 
 	client := storev1.NewEntityStoreServiceClient(conn)
 	cleanup := func() {
+		cancel()
 		conn.Close()
 		srv.Stop()
 	}
@@ -113,6 +134,231 @@ func TestGRPCListEntities(t *testing.T) {
 	}
 }
 
+func TestGRPCListEntitiesFilters(t *testing.T) {
+	client, cleanup := serveStore(t, store.New(store.WithIndex(store.IndexKey{Component: "threat", Field: "level"})))
+	defer cleanup()
+
+	ctx := context.Background()
+	for id, level := range map[string]entityv1.ThreatLevel{
+		"t1": entityv1.ThreatLevel_THREAT_LEVEL_HIGH,
+		"t2": entityv1.ThreatLevel_THREAT_LEVEL_LOW,
+	} {
+		threat, _ := anypb.New(&entityv1.ThreatComponent{Level: level})
+		_, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+			Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"threat": threat},
+		}})
+		if err != nil {
+			t.Fatalf("CreateEntity: %v", err)
+		}
+	}
+
+	resp, err := client.ListEntities(ctx, &storev1.ListEntitiesRequest{Filters: []*storev1.ComponentFilter{
+		{Component: "threat", Field: "level", Op: storev1.FilterOp_FILTER_OP_GE, Value: "HIGH"},
+	}})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(resp.Entities) != 1 || resp.Entities[0].Id != "t1" {
+		t.Fatalf("expected only t1, got %v", resp.Entities)
+	}
+
+	_, err = client.ListEntities(ctx, &storev1.ListEntitiesRequest{Filters: []*storev1.ComponentFilter{
+		{Component: "threat", Field: "level", Op: storev1.FilterOp_FILTER_OP_GE, Value: "SEVERE"},
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for a bad value, got %v", err)
+	}
+}
+
+func TestListEntitiesFilterUsesIndex(t *testing.T) {
+	s := store.New(store.WithIndex(store.IndexKey{Component: "threat", Field: "level"}))
+	srv := New(s)
+	high, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	if _, err := s.Create(&entityv1.Entity{Id: "t0", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"threat": high}}); err != nil {
+		t.Fatal(err)
+	}
+	// Entities without a threat are not in the index; a filtered list
+	// must not clone, or even visit, them.
+	const others = 2000
+	for i := range others {
+		if _, err := s.Create(&entityv1.Entity{Id: fmt.Sprint("a", i), Type: entityv1.EntityType_ENTITY_TYPE_ASSET}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	req := &storev1.ListEntitiesRequest{Filters: []*storev1.ComponentFilter{
+		{Component: "threat", Field: "level", Op: storev1.FilterOp_FILTER_OP_EQ, Value: "HIGH"},
+	}}
+	allocs := testing.AllocsPerRun(5, func() {
+		resp, err := srv.ListEntities(context.Background(), req)
+		if err != nil || len(resp.Entities) != 1 {
+			t.Fatalf("expected only t0, got %v, %v", resp, err)
+		}
+	})
+	if allocs >= others {
+		t.Fatalf("expected the filtered list served from the index, got %v allocations for %d unindexed entities", allocs, others)
+	}
+}
+
+func TestGRPCLabelSelector(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	create := func(id, exercise string) {
+		t.Helper()
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+			Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Labels: map[string]string{"exercise": exercise},
+		}}); err != nil {
+			t.Fatalf("CreateEntity: %v", err)
+		}
+	}
+
+	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{LabelSelector: "exercise=bravo"})
+	if err != nil {
+		t.Fatalf("WatchEntities: %v", err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Header: %v", err)
+	}
+	create("a1", "alpha")
+	create("b1", "bravo")
+
+	event, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if event.Entity.Id != "b1" {
+		t.Fatalf("expected the watch to skip alpha's entity, got %s", event.Entity.Id)
+	}
+
+	resp, err := client.ListEntities(ctx, &storev1.ListEntitiesRequest{LabelSelector: "exercise!=bravo"})
+	if err != nil {
+		t.Fatalf("ListEntities: %v", err)
+	}
+	if len(resp.Entities) != 1 || resp.Entities[0].Id != "a1" {
+		t.Fatalf("expected only a1, got %v", resp.Entities)
+	}
+
+	if _, err := client.ListEntities(ctx, &storev1.ListEntitiesRequest{LabelSelector: "exercise=a=b"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for a bad selector, got %v", err)
+	}
+	_, err = client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+		Id: "bad", Labels: map[string]string{"side": "blue,red"},
+	}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for a bad label, got %v", err)
+	}
+}
+
+func TestGRPCWatchFilters(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	components := func(lat float64, threat bool) map[string]*anypb.Any {
+		pos, _ := anypb.New(&entityv1.PositionComponent{Lat: lat, Lon: -77})
+		comps := map[string]*anypb.Any{"position": pos}
+		if threat {
+			comps["threat"], _ = anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+		}
+		return comps
+	}
+	create := func(id string, lat float64, threat bool) {
+		t.Helper()
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+			Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: components(lat, threat),
+		}}); err != nil {
+			t.Fatalf("CreateEntity: %v", err)
+		}
+	}
+	move := func(id string, lat float64) {
+		t.Helper()
+		if _, err := client.PatchComponent(ctx, &storev1.PatchComponentRequest{Id: id, Components: components(lat, false)}); err != nil {
+			t.Fatalf("PatchComponent: %v", err)
+		}
+	}
+	recv := func(stream grpc.ServerStreamingClient[storev1.EntityEvent], want string) {
+		t.Helper()
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if event.Entity.Id != want {
+			t.Fatalf("expected an event for %s, got %s %s", want, event.Type, event.Entity.Id)
+		}
+	}
+
+	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{
+		Components: []string{"threat"},
+		Bbox:       &storev1.BoundingBox{MinLat: 38, MaxLat: 39, MinLon: -78, MaxLon: -76},
+	})
+	if err != nil {
+		t.Fatalf("WatchEntities: %v", err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Header: %v", err)
+	}
+	create("outside", 40, true)
+	create("no-threat", 38.5, false)
+	create("inside", 38.5, true)
+	recv(stream, "inside")
+
+	// Leaving the box is sent once; moving about outside it is not.
+	move("inside", 40)
+	recv(stream, "inside")
+	move("inside", 41)
+	create("later", 38.6, true)
+	recv(stream, "later")
+
+	bad, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{Bbox: &storev1.BoundingBox{MinLat: 39, MaxLat: 38}})
+	if err == nil {
+		_, err = bad.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for an inverted box, got %v", err)
+	}
+}
+
+func TestGRPCStreamChanges(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	stream, err := client.StreamChanges(ctx, &storev1.StreamChangesRequest{})
+	if err != nil {
+		t.Fatalf("StreamChanges: %v", err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Header: %v", err)
+	}
+	threat, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_LOW})
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+		Id: "c1", Components: map[string]*anypb.Any{"threat": threat},
+	}}); err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+
+	r, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	if r.EntityId != "c1" || len(r.Components) != 1 || r.Components[0].OldValue != nil || !proto.Equal(r.Components[0].NewValue, threat) {
+		t.Fatalf("unexpected change record %v", r)
+	}
+
+	gap, err := client.StreamChanges(ctx, &storev1.StreamChangesRequest{SinceSequence: r.Sequence + 5})
+	if err == nil {
+		_, err = gap.Recv()
+	}
+	if status.Code(err) != codes.OutOfRange {
+		t.Fatalf("expected OutOfRange resuming past the last record, got %v", err)
+	}
+}
+
 func TestGRPCUpdateAndDelete(t *testing.T) {
 	client, cleanup := startTestServer(t)
 	defer cleanup()
@@ -143,6 +389,130 @@ func TestGRPCUpdateAndDelete(t *testing.T) {
 	}
 }
 
+func TestGRPCUpdateExpectedHLC(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	read, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "c1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	expected := &entityv1.HLCTimestamp{Physical: read.HlcPhysical, Logical: read.HlcLogical, Node: read.HlcNode}
+
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: read, ExpectedHlc: expected}); err != nil {
+		t.Fatalf("UpdateEntity at the read version: %v", err)
+	}
+	_, err = client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: read, ExpectedHlc: expected})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition once the entity advanced, got %v", err)
+	}
+}
+
+func TestGRPCErrorDetails(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	read, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "c1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	expected := &entityv1.HLCTimestamp{Physical: read.HlcPhysical, Logical: read.HlcLogical, Node: read.HlcNode}
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1, Lon: 2})
+	patched, err := client.PatchComponent(ctx, &storev1.PatchComponentRequest{Id: "c1", Components: map[string]*anypb.Any{"position": pos}})
+	if err != nil {
+		t.Fatalf("PatchComponent: %v", err)
+	}
+	current := hlc.Timestamp{Physical: patched.Hlc.Physical, Logical: patched.Hlc.Logical, Node: patched.Hlc.Node}.String()
+
+	detail := func(err error) (*errdetails.ErrorInfo, []proto.Message) {
+		t.Helper()
+		var info *errdetails.ErrorInfo
+		var rest []proto.Message
+		for _, d := range status.Convert(err).Details() {
+			switch d := d.(type) {
+			case *errdetails.ErrorInfo:
+				info = d
+			case proto.Message:
+				rest = append(rest, d)
+			}
+		}
+		if info == nil {
+			t.Fatalf("expected an ErrorInfo on %v", err)
+		}
+		return info, rest
+	}
+
+	_, err = client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: read, ExpectedHlc: expected})
+	info, rest := detail(err)
+	if info.Reason != "CONFLICT" || info.Metadata["current_hlc"] != current || info.Metadata["entity_id"] != "c1" {
+		t.Fatalf("unexpected conflict info %v", info)
+	}
+	if len(rest) != 1 {
+		t.Fatalf("expected a PreconditionFailure, got %v", rest)
+	}
+	if pf, ok := rest[0].(*errdetails.PreconditionFailure); !ok || len(pf.Violations) != 1 || pf.Violations[0].Subject != "position" {
+		t.Fatalf("expected position to be stale, got %v", rest[0])
+	}
+
+	_, err = client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "c1"}})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("expected AlreadyExists, got %v", err)
+	}
+	if info, _ := detail(err); info.Reason != "ALREADY_EXISTS" || info.Metadata["current_hlc"] != current {
+		t.Fatalf("unexpected exists info %v", info)
+	}
+
+	bad, _ := anypb.New(&entityv1.PositionComponent{Lat: 100})
+	_, err = client.PatchComponent(ctx, &storev1.PatchComponentRequest{Id: "c1", Components: map[string]*anypb.Any{"position": bad}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	if _, rest := detail(err); len(rest) != 1 || rest[0].(*errdetails.BadRequest).FieldViolations[0].Field != `components["position"]` {
+		t.Fatalf("expected a field violation on position, got %v", rest)
+	}
+}
+
+func TestGRPCDeleteTombstone(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	created, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "d1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: "d1"}); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	_, err = client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: created})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition for a stale create, got %v", err)
+	}
+
+	// A replicated delete succeeds even where the entity never existed.
+	_, err = client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{
+		Id:  "d2",
+		Hlc: &entityv1.HLCTimestamp{Physical: created.HlcPhysical, Node: "peer"},
+	})
+	if err != nil {
+		t.Fatalf("DeleteEntity with hlc: %v", err)
+	}
+	_, err = client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "d2", HlcPhysical: created.HlcPhysical - 1, HlcNode: "peer"},
+	})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition against a replicated tombstone, got %v", err)
+	}
+}
+
 func TestGRPCWatchEntities(t *testing.T) {
 	client, cleanup := startTestServer(t)
 	defer cleanup()
@@ -175,6 +545,154 @@ func TestGRPCWatchEntities(t *testing.T) {
 	}
 }
 
+func TestGRPCOriginNode(t *testing.T) {
+	s := store.New()
+	client, cleanup := serveStore(t, s)
+	defer cleanup()
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	defer s.Unwatch(w)
+
+	ctx := context.Background()
+	if _, err := client.CreateEntity(ContextWithPath(ContextWithHops(ContextWithOrigin(ctx, "node-b"), 2), []string{"node-b", "node-d"}), &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "o1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	}); err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	if _, err := client.BatchWriteEntities(ctx, &storev1.BatchWriteEntitiesRequest{Ops: []*storev1.WriteOp{
+		{Op: &storev1.WriteOp_Delete{Delete: &storev1.DeleteEntityRequest{Id: "o1"}}},
+	}}); err != nil {
+		t.Fatalf("BatchWriteEntities: %v", err)
+	}
+	restore, err := client.RestoreEntities(ContextWithOrigin(ctx, "node-c"))
+	if err != nil {
+		t.Fatalf("RestoreEntities: %v", err)
+	}
+	if err := restore.Send(&storev1.RestoreEntitiesRequest{Entity: &entityv1.Entity{Id: "o2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if _, err := restore.CloseAndRecv(); err != nil {
+		t.Fatalf("CloseAndRecv: %v", err)
+	}
+
+	for _, want := range []struct {
+		origin string
+		hops   uint32
+		path   []string
+	}{{"node-b", 2, []string{"node-b", "node-d"}}, {"", 0, nil}, {"node-c", 0, nil}} {
+		ev := <-w.Events
+		if ev.OriginNode != want.origin || ev.Hops != want.hops || !slices.Equal(ev.Path, want.path) {
+			t.Fatalf("expected %v of %s from %q after %d hops via %v, got %q after %d via %v", ev.Type, ev.Entity.Id, want.origin, want.hops, want.path, ev.OriginNode, ev.Hops, ev.Path)
+		}
+	}
+}
+
+func TestGRPCWatchEntitiesInitialState(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, id := range []string{"i1", "i2"} {
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+			Entity: &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+		}); err != nil {
+			t.Fatalf("CreateEntity: %v", err)
+		}
+	}
+
+	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{InitialState: true})
+	if err != nil {
+		t.Fatalf("WatchEntities: %v", err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Header: %v", err)
+	}
+	if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: "i1"}); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	for _, want := range []struct {
+		typ storev1.EventType
+		id  string
+	}{
+		{storev1.EventType_EVENT_TYPE_CREATED, "i1"},
+		{storev1.EventType_EVENT_TYPE_CREATED, "i2"},
+		{storev1.EventType_EVENT_TYPE_DELETED, "i1"},
+	} {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if event.Type != want.typ || event.Entity.Id != want.id {
+			t.Fatalf("expected %v %s, got %v %s", want.typ, want.id, event.Type, event.Entity.Id)
+		}
+	}
+
+	bad, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{InitialState: true, SinceSequence: 1})
+	if err == nil {
+		_, err = bad.Recv()
+	}
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument with since_sequence, got %v", err)
+	}
+}
+
+func TestGRPCWatchEntitiesResume(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	create := func(id string) {
+		t.Helper()
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+			Entity: &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+		}); err != nil {
+			t.Fatalf("CreateEntity: %v", err)
+		}
+	}
+
+	watchCtx, stop := context.WithCancel(ctx)
+	stream, err := client.WatchEntities(watchCtx, &storev1.WatchEntitiesRequest{})
+	if err != nil {
+		t.Fatalf("WatchEntities: %v", err)
+	}
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Header: %v", err)
+	}
+	create("s1")
+	first, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv: %v", err)
+	}
+	stop()
+
+	create("s2")
+	create("s3")
+
+	stream, err = client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{SinceSequence: first.Sequence})
+	if err != nil {
+		t.Fatalf("WatchEntities: %v", err)
+	}
+	for i, want := range []string{"s2", "s3"} {
+		event, err := stream.Recv()
+		if err != nil {
+			t.Fatalf("Recv: %v", err)
+		}
+		if event.Entity.Id != want || event.Sequence != first.Sequence+uint64(i)+1 {
+			t.Fatalf("expected %s at %d, got %s at %d", want, first.Sequence+uint64(i)+1, event.Entity.Id, event.Sequence)
+		}
+	}
+
+	stream, err = client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{SinceSequence: first.Sequence + 100})
+	if err != nil {
+		t.Fatalf("WatchEntities: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.OutOfRange {
+		t.Fatalf("expected OutOfRange for an unknown sequence, got %v", err)
+	}
+}
+
 func TestGRPCValidation(t *testing.T) {
 	client, cleanup := startTestServer(t)
 	defer cleanup()
@@ -195,3 +713,667 @@ func TestGRPCValidation(t *testing.T) {
 		t.Fatalf("expected InvalidArgument for empty id, got %v", err)
 	}
 }
+
+func TestGRPCSchemaValidation(t *testing.T) {
+	reg := registry.New()
+	err := reg.Register(&registryv1.Schema{
+		TypeUrl: "type.googleapis.com/entity.v1.PositionComponent",
+		Rules:   []*registryv1.FieldRule{{Field: "lat", Min: proto.Float64(-90), Max: proto.Float64(90)}},
+	})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	client, cleanup := startTestServer(t, WithRegistry(reg))
+	defer cleanup()
+	ctx := context.Background()
+
+	entity := func(lat float64) *entityv1.Entity {
+		pos, _ := anypb.New(&entityv1.PositionComponent{Lat: lat})
+		return &entityv1.Entity{Id: "t-1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos}}
+	}
+
+	_, err = client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity(91)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for lat 91, got %v", err)
+	}
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: entity(45)}); err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	_, err = client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity(-91)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for lat -91, got %v", err)
+	}
+	e, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "t-1"})
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	var pos entityv1.PositionComponent
+	if err := e.Components["position"].UnmarshalTo(&pos); err != nil || pos.Lat != 45 {
+		t.Fatalf("expected rejected update not applied, got lat %v (%v)", pos.Lat, err)
+	}
+}
+
+func TestGRPCTTL(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	ttl := durationpb.New(150 * time.Millisecond)
+	track := &entityv1.Entity{Id: "ttl-1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}
+
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: track, Ttl: ttl}); err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+
+	// Each write with a ttl restarts the countdown.
+	for range 4 {
+		time.Sleep(75 * time.Millisecond)
+		if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: track, Ttl: ttl}); err != nil {
+			t.Fatalf("UpdateEntity while refreshed: %v", err)
+		}
+	}
+
+	// Without further writes it expires.
+	time.Sleep(300 * time.Millisecond)
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "ttl-1"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound after ttl, got %v", err)
+	}
+
+	_, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: track, Ttl: durationpb.New(-time.Second)})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for negative ttl, got %v", err)
+	}
+}
+
+func TestGRPCSnapshotRestore(t *testing.T) {
+	src, cleanupSrc := startTestServer(t)
+	defer cleanupSrc()
+	dst, cleanupDst := startTestServer(t)
+	defer cleanupDst()
+	ctx := context.Background()
+
+	for _, e := range []*entityv1.Entity{
+		{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+		{Id: "t2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+		{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET},
+	} {
+		if _, err := src.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: e}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// dst already has a newer a1 of its own.
+	if _, err := src.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: &entityv1.Entity{Id: "t2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dst.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET}}); err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := src.SnapshotEntities(ctx, &storev1.SnapshotEntitiesRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore, err := dst.RestoreEntities(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var t2 *entityv1.Entity
+	for {
+		e, err := snap.Recv()
+		if err != nil {
+			break
+		}
+		if e.Id == "t2" {
+			t2 = e
+		}
+		if err := restore.Send(&storev1.RestoreEntitiesRequest{Entity: e}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resp, err := restore.CloseAndRecv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Created != 2 || resp.Replaced != 0 || resp.Skipped != 1 {
+		t.Fatalf("expected 2 created and 1 skipped, got %v", resp)
+	}
+	got, err := dst.GetEntity(ctx, &storev1.GetEntityRequest{Id: "t2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(got, t2) {
+		t.Fatalf("expected t2 restored as snapshotted, got %v want %v", got, t2)
+	}
+
+	// An entity without an ID fails the whole stream.
+	restore, err = dst.RestoreEntities(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restore.Send(&storev1.RestoreEntitiesRequest{Entity: &entityv1.Entity{}}) //nolint:errcheck
+	if _, err := restore.CloseAndRecv(); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestGRPCGetAndPatchComponent(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1})
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+		Id: "c1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	threat, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	patched, err := client.PatchComponent(ctx, &storev1.PatchComponentRequest{
+		Id: "c1", Components: map[string]*anypb.Any{"threat": threat},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := client.GetComponent(ctx, &storev1.GetComponentRequest{Id: "c1", Key: "threat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(got.Component, threat) || !proto.Equal(got.Hlc, patched.Hlc) {
+		t.Fatalf("expected patched threat at %v, got %v", patched.Hlc, got)
+	}
+	e, _ := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "c1"})
+	if _, ok := e.Components["position"]; !ok {
+		t.Fatal("expected position untouched by the patch")
+	}
+
+	if _, err := client.PatchComponent(ctx, &storev1.PatchComponentRequest{Id: "nope", Components: map[string]*anypb.Any{"threat": threat}}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound patching a missing entity, got %v", err)
+	}
+	if _, err := client.PatchComponent(ctx, &storev1.PatchComponentRequest{Id: "c1"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without components, got %v", err)
+	}
+	if _, err := client.GetComponent(ctx, &storev1.GetComponentRequest{Id: "c1", Key: "velocity"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for a missing component, got %v", err)
+	}
+}
+
+func TestGRPCRemoveComponent(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1})
+	threat, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+		Id: "c1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos, "threat": threat},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := client.RemoveComponent(ctx, &storev1.RemoveComponentRequest{Id: "c1", Keys: []string{"threat"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, _ := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "c1"})
+	if _, ok := e.Components["threat"]; ok {
+		t.Fatal("expected threat removed")
+	}
+	if _, ok := e.Components["position"]; !ok {
+		t.Fatal("expected position untouched by the removal")
+	}
+	if !proto.Equal(e.RemovedComponents["threat"], removed.Hlc) {
+		t.Fatalf("expected threat's tombstone at %v, got %v", removed.Hlc, e.RemovedComponents)
+	}
+
+	if _, err := client.RemoveComponent(ctx, &storev1.RemoveComponentRequest{Id: "nope", Keys: []string{"threat"}}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound removing from a missing entity, got %v", err)
+	}
+	if _, err := client.RemoveComponent(ctx, &storev1.RemoveComponentRequest{Id: "c1"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without keys, got %v", err)
+	}
+}
+
+func TestGRPCIncrementCounter(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+		Id: "n1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK,
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	var last *storev1.IncrementCounterResponse
+	for _, delta := range []int64{2, 5, -3} {
+		resp, err := client.IncrementCounter(ctx, &storev1.IncrementCounterRequest{Id: "n1", Key: "detections_count", Delta: delta})
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = resp
+	}
+	if last.Value != 4 {
+		t.Fatalf("expected 2+5-3 = 4, got %d", last.Value)
+	}
+	e, _ := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "n1"})
+	if !proto.Equal(e.ComponentHlc["detections_count"], last.Hlc) {
+		t.Fatalf("expected the counter stamped %v, got %v", last.Hlc, e.ComponentHlc["detections_count"])
+	}
+
+	if _, err := client.IncrementCounter(ctx, &storev1.IncrementCounterRequest{Id: "nope", Key: "detections_count", Delta: 1}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound incrementing a missing entity, got %v", err)
+	}
+	if _, err := client.IncrementCounter(ctx, &storev1.IncrementCounterRequest{Id: "n1", Delta: 1}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without a key, got %v", err)
+	}
+}
+
+func TestGRPCQueryEntitiesByBBox(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for id, lat := range map[string]float64{"near": 38.9, "far": 45} {
+		pos, _ := anypb.New(&entityv1.PositionComponent{Lat: lat, Lon: -77})
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+			Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos},
+		}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	resp, err := client.QueryEntitiesByBBox(ctx, &storev1.QueryEntitiesByBBoxRequest{MinLat: 38, MaxLat: 40, MinLon: -78, MaxLon: -76})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Entities) != 1 || resp.Entities[0].Id != "near" {
+		t.Fatalf("expected only near, got %v", resp.Entities)
+	}
+
+	_, err = client.QueryEntitiesByBBox(ctx, &storev1.QueryEntitiesByBBoxRequest{MinLat: 40, MaxLat: 38, MinLon: -78, MaxLon: -76})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for an inverted box, got %v", err)
+	}
+}
+
+func TestGRPCGetEntityHistory(t *testing.T) {
+	client, cleanup := serveStore(t, store.New(store.WithHistory(4)))
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "h1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: "h1"}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.GetEntityHistory(ctx, &storev1.GetEntityHistoryRequest{Id: "h1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Versions) != 2 || resp.Versions[0].Type != storev1.EventType_EVENT_TYPE_CREATED || resp.Versions[1].Type != storev1.EventType_EVENT_TYPE_DELETED {
+		t.Fatalf("expected created then deleted, got %v", resp.Versions)
+	}
+	if _, err := client.GetEntityHistory(ctx, &storev1.GetEntityHistoryRequest{Id: "never"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestGRPCBatchWriteEntities(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1, Lon: 2})
+	track := func(id string) *entityv1.Entity {
+		return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK}
+	}
+	resp, err := client.BatchWriteEntities(ctx, &storev1.BatchWriteEntitiesRequest{Ops: []*storev1.WriteOp{
+		{Op: &storev1.WriteOp_Create{Create: &storev1.CreateEntityRequest{Entity: track("w1")}}},
+		{Op: &storev1.WriteOp_Create{Create: &storev1.CreateEntityRequest{Entity: track("w1")}}},
+		{Op: &storev1.WriteOp_Patch{Patch: &storev1.PatchComponentRequest{Id: "w1", Components: map[string]*anypb.Any{"position": pos}}}},
+		{Op: &storev1.WriteOp_Update{Update: &storev1.UpdateEntityRequest{Entity: track("missing")}}},
+		{Op: &storev1.WriteOp_Create{Create: &storev1.CreateEntityRequest{Entity: &entityv1.Entity{}}}},
+		{},
+		{Op: &storev1.WriteOp_Delete{Delete: &storev1.DeleteEntityRequest{Id: "w1"}}},
+	}})
+	if err != nil {
+		t.Fatalf("BatchWriteEntities: %v", err)
+	}
+	want := []codes.Code{codes.OK, codes.AlreadyExists, codes.OK, codes.NotFound, codes.InvalidArgument, codes.InvalidArgument, codes.OK}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(resp.Results))
+	}
+	for i, code := range want {
+		if got := codes.Code(resp.Results[i].Code); got != code {
+			t.Errorf("op %d: expected %v, got %v (%s)", i, code, got, resp.Results[i].Message)
+		}
+	}
+	if patched := resp.Results[2].Entity; patched.GetComponents()["position"] == nil {
+		t.Fatalf("expected the patched entity in the result, got %v", patched)
+	}
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "w1"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected w1 deleted by the last op, got %v", err)
+	}
+}
+
+func TestGRPCReplicateBatch(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "r1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}}); err != nil {
+		t.Fatal(err)
+	}
+	watch, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{})
+	if err != nil {
+		t.Fatalf("WatchEntities: %v", err)
+	}
+	if _, err := watch.Header(); err != nil {
+		t.Fatalf("Header: %v", err)
+	}
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1, Lon: 2})
+	now := uint64(time.Now().UnixNano())
+	replica := func(id string) *entityv1.Entity {
+		return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos},
+			HlcPhysical: now, HlcNode: "node-b"}
+	}
+	updated, created, deleted := storev1.EventType_EVENT_TYPE_UPDATED, storev1.EventType_EVENT_TYPE_CREATED, storev1.EventType_EVENT_TYPE_DELETED
+	resp, err := client.ReplicateBatch(ctx, &storev1.ReplicateBatchRequest{Events: []*storev1.EntityEvent{
+		{Type: updated, Entity: replica("r1"), OriginNode: "node-b", Hops: 1, Path: []string{"node-b"}},
+		{Type: created, Entity: replica("r2"), OriginNode: "node-b", Hops: 1, Path: []string{"node-b"}},
+		{Type: created, Entity: &entityv1.Entity{}},
+		{Type: deleted, Entity: &entityv1.Entity{Id: "missing"}},
+		{Type: storev1.EventType_EVENT_TYPE_UNSPECIFIED, Entity: replica("r3")},
+	}})
+	if err != nil {
+		t.Fatalf("ReplicateBatch: %v", err)
+	}
+	want := []codes.Code{codes.OK, codes.OK, codes.InvalidArgument, codes.NotFound, codes.InvalidArgument}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(resp.Results))
+	}
+	for i, code := range want {
+		if got := codes.Code(resp.Results[i].Code); got != code {
+			t.Errorf("event %d: expected %v, got %v (%s)", i, code, got, resp.Results[i].Message)
+		}
+	}
+	if resp.Merged != 1 {
+		t.Fatalf("expected r1 merged, got %d merged", resp.Merged)
+	}
+	if resp.Results[0].Entity.GetComponents()["position"] == nil {
+		t.Fatalf("expected the merged entity in the result, got %v", resp.Results[0].Entity)
+	}
+
+	// The writes carry each event's origin, hops, and path, not the call's.
+	for _, id := range []string{"r1", "r2"} {
+		ev, err := watch.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ev.Entity.Id != id || ev.OriginNode != "node-b" || ev.Hops != 1 || !slices.Equal(ev.Path, []string{"node-b"}) {
+			t.Fatalf("expected %s's event from node-b, 1 hop, path [node-b], got %s from %q, %d hops, path %v", id, ev.Entity.Id, ev.OriginNode, ev.Hops, ev.Path)
+		}
+	}
+}
+
+func TestGRPCListArchivedEntities(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for _, e := range []*entityv1.Entity{
+		{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Labels: map[string]string{"exercise": "bravo"}},
+		{Id: "t2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK},
+	} {
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: e}); err != nil {
+			t.Fatalf("CreateEntity: %v", err)
+		}
+		if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: e.Id}); err != nil {
+			t.Fatalf("DeleteEntity: %v", err)
+		}
+	}
+
+	resp, err := client.ListArchivedEntities(ctx, &storev1.ListArchivedEntitiesRequest{LabelSelector: "exercise=bravo"})
+	if err != nil {
+		t.Fatalf("ListArchivedEntities: %v", err)
+	}
+	if len(resp.Entities) != 1 || resp.Entities[0].Entity.Id != "t1" || resp.Entities[0].ArchivedAt == nil {
+		t.Fatalf("expected t1 archived, got %v", resp.Entities)
+	}
+	if _, err := client.ListArchivedEntities(ctx, &storev1.ListArchivedEntitiesRequest{LabelSelector: "=x"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument for a bad selector, got %v", err)
+	}
+}
+
+func TestGRPCQuota(t *testing.T) {
+	client, cleanup := serveStore(t, store.New(store.WithQuotas(map[entityv1.EntityType]int{entityv1.EntityType_ENTITY_TYPE_TRACK: 1})))
+	defer cleanup()
+
+	ctx := context.Background()
+	track := func(id string) *entityv1.Entity {
+		return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK}
+	}
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: track("t1")}); err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: track("t2")}); status.Code(err) != codes.ResourceExhausted {
+		t.Fatalf("expected ResourceExhausted, got %v", err)
+	}
+	resp, err := client.BatchWriteEntities(ctx, &storev1.BatchWriteEntitiesRequest{Ops: []*storev1.WriteOp{
+		{Op: &storev1.WriteOp_Create{Create: &storev1.CreateEntityRequest{Entity: track("t2")}}},
+	}})
+	if err != nil {
+		t.Fatalf("BatchWriteEntities: %v", err)
+	}
+	if got := codes.Code(resp.Results[0].Code); got != codes.ResourceExhausted {
+		t.Fatalf("expected the batch create refused with ResourceExhausted, got %v", got)
+	}
+}
+
+func TestGRPCClockDrift(t *testing.T) {
+	client, cleanup := serveStore(t, store.New(store.WithMaxDrift(time.Minute, 0)))
+	defer cleanup()
+
+	// A copy stamped by a peer whose clock runs an hour fast.
+	ahead := &entityv1.HLCTimestamp{Physical: uint64(time.Now().Add(time.Hour).UnixNano()), Node: "fast"}
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1, Lon: 2})
+	_, err := client.CreateEntity(context.Background(), &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+		Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK,
+		Components:   map[string]*anypb.Any{"position": pos},
+		ComponentHlc: map[string]*entityv1.HLCTimestamp{"position": ahead},
+	}})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("expected Unavailable, got %v", err)
+	}
+}
+
+func TestGRPCComponentValidation(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 95, Lon: 10})
+	_, err := client.CreateEntity(context.Background(), &storev1.CreateEntityRequest{Entity: &entityv1.Entity{
+		Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos},
+	}})
+	if status.Code(err) != codes.InvalidArgument || !strings.Contains(err.Error(), "lat 95") {
+		t.Fatalf("expected InvalidArgument naming the latitude, got %v", err)
+	}
+}
+
+func TestGRPCDigestEntities(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}}); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.DigestEntities(ctx, &storev1.DigestEntitiesRequest{Level: 0, Nodes: []uint32{0}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Nodes) != 1 || len(resp.Nodes[0].Hash) == 0 {
+		t.Fatalf("expected the root's hash, got %v", resp.Nodes)
+	}
+
+	_, err = client.DigestEntities(ctx, &storev1.DigestEntitiesRequest{Level: store.DigestDepth + 1, Nodes: []uint32{0}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument below the leaves, got %v", err)
+	}
+}
+
+func TestGRPCTransact(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	created, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	seen := &entityv1.HLCTimestamp{Physical: created.HlcPhysical, Logical: created.HlcLogical, Node: created.HlcNode}
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1, Lon: 2})
+	patch := &storev1.WriteOp{Op: &storev1.WriteOp_Patch{Patch: &storev1.PatchComponentRequest{Id: "t1", Components: map[string]*anypb.Any{"position": pos}}}}
+	create := &storev1.WriteOp{Op: &storev1.WriteOp_Create{Create: &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET}}}}
+
+	resp, err := client.Transact(ctx, &storev1.TransactRequest{
+		Reads: []*storev1.TransactRead{{Id: "t1", ExpectedHlc: seen}},
+		Ops:   []*storev1.WriteOp{patch, create},
+	})
+	if err != nil {
+		t.Fatalf("Transact: %v", err)
+	}
+	if len(resp.Reads) != 1 || len(resp.Results) != 2 || resp.Results[0].Entity.GetComponents()["position"] == nil {
+		t.Fatalf("unexpected response %v", resp)
+	}
+
+	tests := []struct {
+		name string
+		req  *storev1.TransactRequest
+		want codes.Code
+	}{
+		{"stale read", &storev1.TransactRequest{Reads: []*storev1.TransactRead{{Id: "t1", ExpectedHlc: seen}}}, codes.FailedPrecondition},
+		{"missing read", &storev1.TransactRequest{Reads: []*storev1.TransactRead{{Id: "missing"}}}, codes.NotFound},
+		{"existing create", &storev1.TransactRequest{Ops: []*storev1.WriteOp{patch, create}}, codes.AlreadyExists},
+		{"invalid op", &storev1.TransactRequest{Ops: []*storev1.WriteOp{patch, {}}}, codes.InvalidArgument},
+		{"replicated delete", &storev1.TransactRequest{Ops: []*storev1.WriteOp{
+			{Op: &storev1.WriteOp_Delete{Delete: &storev1.DeleteEntityRequest{Id: "a1", Hlc: seen}}},
+		}}, codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		if _, err := client.Transact(ctx, tt.req); status.Code(err) != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	// None of the failed transactions patched t1 again.
+	got, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "t1"})
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	if got.HlcPhysical != resp.Results[0].Entity.HlcPhysical || got.HlcLogical != resp.Results[0].Entity.HlcLogical {
+		t.Fatal("expected t1 unchanged by the aborted transactions")
+	}
+}
+
+func TestGRPCLinks(t *testing.T) {
+	client, cleanup := startTestServer(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for _, id := range []string{"fused", "t1"} {
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK}}); err != nil {
+			t.Fatalf("CreateEntity: %v", err)
+		}
+	}
+	link := &storev1.Link{FromId: "fused", ToId: "t1", Relation: "fused_from", Cascade: true}
+	if _, err := client.AddLink(ctx, &storev1.AddLinkRequest{Link: link}); err != nil {
+		t.Fatalf("AddLink: %v", err)
+	}
+	_, err := client.AddLink(ctx, &storev1.AddLinkRequest{Link: &storev1.Link{FromId: "fused", ToId: "t1"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument without a relation, got %v", err)
+	}
+	_, err = client.AddLink(ctx, &storev1.AddLinkRequest{Link: &storev1.Link{FromId: "fused", ToId: "nope", Relation: "fused_from"}})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound for a missing entity, got %v", err)
+	}
+
+	resp, err := client.ListLinks(ctx, &storev1.ListLinksRequest{Id: "t1", Direction: storev1.LinkDirection_LINK_DIRECTION_INCOMING})
+	if err != nil {
+		t.Fatalf("ListLinks: %v", err)
+	}
+	if len(resp.Links) != 1 || !proto.Equal(resp.Links[0], link) {
+		t.Fatalf("expected %v, got %v", link, resp.Links)
+	}
+
+	// Deleting the source cascades to the fused entity.
+	if _, err := client.DeleteEntity(ctx, &storev1.DeleteEntityRequest{Id: "t1"}); err != nil {
+		t.Fatalf("DeleteEntity: %v", err)
+	}
+	if _, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "fused"}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected the fused entity cascaded, got %v", err)
+	}
+	_, err = client.RemoveLink(ctx, &storev1.RemoveLinkRequest{FromId: "fused", ToId: "t1", Relation: "fused_from"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound removing a dropped link, got %v", err)
+	}
+}
+
+func TestGRPCPublishEntities(t *testing.T) {
+	client, cleanup := serveStore(t, store.New(), WithAuth(StaticTokens{"op": RoleOperator, "radar": RoleSensor}))
+	defer cleanup()
+
+	ctx := context.Background()
+	op, sensor := grpc.PerRPCCredentials(Token("op")), grpc.PerRPCCredentials(Token("radar"))
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: &entityv1.Entity{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET}}, op); err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+
+	report := func(id string, lat float64) *storev1.PublishEntitiesRequest {
+		pos, _ := anypb.New(&entityv1.PositionComponent{Lat: lat})
+		return &storev1.PublishEntitiesRequest{Entity: &entityv1.Entity{
+			Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos},
+		}}
+	}
+	stream, err := client.PublishEntities(ctx, sensor)
+	if err != nil {
+		t.Fatalf("PublishEntities: %v", err)
+	}
+	for _, req := range []*storev1.PublishEntitiesRequest{
+		report("t1", 1), // created
+		report("t1", 2), // patched
+		{Entity: &entityv1.Entity{Type: entityv1.EntityType_ENTITY_TYPE_TRACK}},
+		report("a1", 3), // an asset: not the sensor's to write
+		report("t2", 4),
+	} {
+		if err := stream.Send(req); err != nil {
+			t.Fatalf("Send: %v", err)
+		}
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		t.Fatalf("CloseAndRecv: %v", err)
+	}
+	if resp.Accepted != 3 || resp.Rejected != 2 || len(resp.Failures) != 2 {
+		t.Fatalf("expected 3 accepted and 2 failures, got %v", resp)
+	}
+	if f := resp.Failures[0]; f.Index != 2 || codes.Code(f.Code) != codes.InvalidArgument {
+		t.Fatalf("expected report 2 refused as invalid, got %v", f)
+	}
+	if f := resp.Failures[1]; f.Index != 3 || f.EntityId != "a1" || codes.Code(f.Code) != codes.PermissionDenied {
+		t.Fatalf("expected report 3 refused to the sensor, got %v", f)
+	}
+
+	e, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "t1"}, op)
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	pos := &entityv1.PositionComponent{}
+	if err := e.Components["position"].UnmarshalTo(pos); err != nil || pos.Lat != 2 {
+		t.Fatalf("expected t1 at the second report, got %v (%v)", pos, err)
+	}
+}
@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func (s *Server) AddLink(_ context.Context, req *storev1.AddLinkRequest) (*storev1.Link, error) {
+	l := req.GetLink()
+	switch {
+	case l.GetFromId() == "" || l.ToId == "":
+		return nil, status.Error(codes.InvalidArgument, "link from_id and to_id are required")
+	case l.Relation == "":
+		return nil, status.Error(codes.InvalidArgument, "link relation is required")
+	case l.FromId == l.ToId:
+		return nil, status.Error(codes.InvalidArgument, "an entity cannot link to itself")
+	}
+	if err := s.store.AddLink(store.Link{From: l.FromId, To: l.ToId, Relation: l.Relation, Cascade: l.Cascade}); err != nil {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	return l, nil
+}
+
+func (s *Server) RemoveLink(_ context.Context, req *storev1.RemoveLinkRequest) (*emptypb.Empty, error) {
+	if err := s.store.RemoveLink(req.FromId, req.ToId, req.Relation); err != nil {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *Server) ListLinks(_ context.Context, req *storev1.ListLinksRequest) (*storev1.ListLinksResponse, error) {
+	if req.Id == "" {
+		return nil, status.Error(codes.InvalidArgument, "entity id is required")
+	}
+	links := s.store.Links(req.Id, req.Relation, req.Direction)
+	resp := &storev1.ListLinksResponse{Links: make([]*storev1.Link, len(links))}
+	for i, l := range links {
+		resp.Links[i] = &storev1.Link{FromId: l.From, ToId: l.To, Relation: l.Relation, Cascade: l.Cascade}
+	}
+	return resp, nil
+}
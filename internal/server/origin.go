@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// OriginHeader is the metadata key naming the mesh node a write comes
+// from. The store stamps it on the events the write emits as origin_node,
+// so the relay that sent it can recognize its echo. Writes without it
+// emit events with no origin.
+const OriginHeader = "lattice-origin-node"
+
+// HopsHeader is the metadata key counting the relays a write has passed
+// through, which the store stamps on its events as hops, so a gossiping
+// relay can tell how far it has spread.
+const HopsHeader = "lattice-hops"
+
+// PathHeader is the metadata key listing, one value each, the nodes whose
+// relays passed a write on, which the store stamps on its events as path,
+// so a relay can tell a write it has already passed on.
+const PathHeader = "lattice-path"
+
+type (
+	originKey struct{}
+	hopsKey   struct{}
+	pathKey   struct{}
+)
+
+// ContextWithOrigin returns ctx with node as the origin of the calls made
+// with it, for a client forwarding writes from another node.
+func ContextWithOrigin(ctx context.Context, node string) context.Context {
+	if node == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, OriginHeader, node)
+}
+
+// ContextWithHops returns ctx with n as the hops of the writes made with
+// it.
+func ContextWithHops(ctx context.Context, n uint32) context.Context {
+	if n == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, HopsHeader, strconv.FormatUint(uint64(n), 10))
+}
+
+// ContextWithPath returns ctx with nodes as the path of the writes made
+// with it.
+func ContextWithPath(ctx context.Context, nodes []string) context.Context {
+	for _, node := range nodes {
+		ctx = metadata.AppendToOutgoingContext(ctx, PathHeader, node)
+	}
+	return ctx
+}
+
+// withOrigin returns ctx, as authorized, carrying the origin, hops, and
+// path the caller named, for origin, hops, and path to find. Relays name
+// all three, so only a trusted caller's are taken: a sensor naming a mesh
+// node as its origin, or listing it in the path, would have relays take
+// its writes for ones they had already passed on and never replicate
+// them, and one claiming the hop limit would keep gossip from passing
+// them on.
+func (s *Server) withOrigin(ctx context.Context) context.Context {
+	if !s.trusted(ctx) {
+		return ctx
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get(OriginHeader); len(v) > 0 {
+		ctx = context.WithValue(ctx, originKey{}, v[0])
+	}
+	if v := md.Get(HopsHeader); len(v) > 0 {
+		if n, err := strconv.ParseUint(v[0], 10, 32); err == nil {
+			ctx = context.WithValue(ctx, hopsKey{}, uint32(n))
+		}
+	}
+	if v := md.Get(PathHeader); len(v) > 0 {
+		ctx = context.WithValue(ctx, pathKey{}, v)
+	}
+	return ctx
+}
+
+// origin returns the origin stamped on ctx by the interceptors, if any.
+func origin(ctx context.Context) string {
+	node, _ := ctx.Value(originKey{}).(string)
+	return node
+}
+
+// hops returns the hops stamped on ctx by the interceptors, or 0.
+func hops(ctx context.Context) uint32 {
+	n, _ := ctx.Value(hopsKey{}).(uint32)
+	return n
+}
+
+// path returns the path stamped on ctx by the interceptors, if any.
+func path(ctx context.Context) []string {
+	nodes, _ := ctx.Value(pathKey{}).([]string)
+	return nodes
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"errors"
+	"io"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxPublishFailures bounds the failures a PublishEntities response lists,
+// since one stream may carry a sensor's reports for hours.
+const maxPublishFailures = 1000
+
+// PublishEntities applies each report as an upsert as it arrives. A report
+// that fails, validation and authorization included, is counted and the
+// stream goes on.
+func (s *Server) PublishEntities(stream grpc.ClientStreamingServer[storev1.PublishEntitiesRequest, storev1.PublishEntitiesResponse]) error {
+	resp := &storev1.PublishEntitiesResponse{}
+	for i := uint64(0); ; i++ {
+		req, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(resp)
+		}
+		if err != nil {
+			return err
+		}
+
+		w, err := s.publishWrite(req)
+		if err == nil {
+			_, err = s.apply(stream.Context(), w)
+		}
+		if err != nil {
+			resp.Rejected++
+			if len(resp.Failures) < maxPublishFailures {
+				st := status.Convert(err)
+				resp.Failures = append(resp.Failures, &storev1.PublishFailure{
+					Index: i, EntityId: req.Entity.GetId(), Code: int32(st.Code()), Message: st.Message(),
+				})
+			}
+			continue
+		}
+		resp.Accepted++
+	}
+}
+
+func (s *Server) publishWrite(req *storev1.PublishEntitiesRequest) (store.Write, error) {
+	if req.GetEntity() == nil {
+		return store.Write{}, status.Error(codes.InvalidArgument, "entity is required")
+	}
+	if req.Entity.Id == "" {
+		return store.Write{}, status.Error(codes.InvalidArgument, "entity id is required")
+	}
+	ttl, err := requestTTL(req.Ttl)
+	if err != nil {
+		return store.Write{}, err
+	}
+	if err := s.validate(req.Entity); err != nil {
+		return store.Write{}, err
+	}
+	return store.Write{Op: store.OpUpsert, Entity: req.Entity, TTL: ttl}, nil
+}
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReplicateBatch applies a relay's events under one store lock, merging
+// each entity with store.OpMerge rather than the Get and Update a relay
+// would otherwise make per event. An event that fails validation is
+// skipped; the others are still applied.
+func (s *Server) ReplicateBatch(ctx context.Context, req *storev1.ReplicateBatchRequest) (*storev1.ReplicateBatchResponse, error) {
+	resp := &storev1.ReplicateBatchResponse{Results: make([]*storev1.WriteResult, len(req.Events))}
+	writes := make([]store.Write, 0, len(req.Events))
+	index := make([]int, 0, len(req.Events)) // event index of each write
+	for i, event := range req.Events {
+		w, err := s.eventWrite(event)
+		if err == nil {
+			err = s.authorizeWrite(ctx, w)
+		}
+		if err != nil {
+			resp.Results[i] = writeResult(nil, err)
+			continue
+		}
+		w.Origin, w.Hops, w.Path = event.OriginNode, event.Hops, event.Path
+		w.SkipUnchanged = req.SkipUnchanged
+		writes = append(writes, w)
+		index = append(index, i)
+	}
+	for j, r := range s.store.Batch(writes) {
+		var err error
+		if r.Err != nil {
+			err = storeError(codes.NotFound, r.Err)
+		}
+		if r.Merged {
+			resp.Merged++
+		}
+		resp.Results[index[j]] = writeResult(r.Entity, err)
+	}
+	return resp, nil
+}
+
+// eventWrite checks a replicated event into a store.Write: a merge for a
+// created or updated entity, a delete at the event's HLC for a deleted or
+// expired one.
+func (s *Server) eventWrite(event *storev1.EntityEvent) (store.Write, error) {
+	e := event.GetEntity()
+	if e.GetId() == "" {
+		return store.Write{}, status.Error(codes.InvalidArgument, "entity id is required")
+	}
+	switch event.Type {
+	case storev1.EventType_EVENT_TYPE_CREATED, storev1.EventType_EVENT_TYPE_UPDATED:
+		if err := s.validate(e); err != nil {
+			return store.Write{}, err
+		}
+		return store.Write{Op: store.OpMerge, Entity: e}, nil
+	case storev1.EventType_EVENT_TYPE_DELETED, storev1.EventType_EVENT_TYPE_EXPIRED:
+		w := store.Write{Op: store.OpDelete, Entity: &entityv1.Entity{Id: e.Id}}
+		if e.HlcPhysical != 0 {
+			w.At = &hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
+		}
+		return w, nil
+	}
+	return store.Write{}, status.Errorf(codes.InvalidArgument, "event type %s is not replicated", event.Type)
+}
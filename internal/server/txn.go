@@ -0,0 +1,57 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Transact checks every read and op and applies the ops only if none
+// fails. Unlike BatchWriteEntities, an invalid op aborts the whole call.
+func (s *Server) Transact(ctx context.Context, req *storev1.TransactRequest) (*storev1.TransactResponse, error) {
+	reads := make([]store.Read, len(req.Reads))
+	for i, r := range req.Reads {
+		if r.Id == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "read %d: entity id is required", i)
+		}
+		reads[i] = store.Read{ID: r.Id}
+		if ts := r.ExpectedHlc; ts != nil {
+			reads[i].Expected = &hlc.Timestamp{Physical: ts.Physical, Logical: ts.Logical, Node: ts.Node}
+		}
+	}
+	writes := make([]store.Write, len(req.Ops))
+	for i, op := range req.Ops {
+		w, err := s.opWrite(op)
+		if err != nil {
+			st := status.Convert(err)
+			return nil, status.Errorf(st.Code(), "op %d: %s", i, st.Message())
+		}
+		if w.At != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "op %d: replicated deletes cannot be part of a transaction", i)
+		}
+		w.Origin, w.Hops, w.Path = origin(ctx), hops(ctx), path(ctx)
+		writes[i] = w
+	}
+
+	read, results, err := s.store.Transact(reads, writes)
+	var txnErr *store.TxnError
+	switch {
+	case errors.As(err, &txnErr) && txnErr.Read >= 0:
+		return nil, storeError(codes.NotFound, txnErr)
+	case errors.As(err, &txnErr):
+		return nil, storeError(failCode(writes[txnErr.Write].Op), txnErr)
+	case err != nil:
+		return nil, status.Errorf(codes.Internal, "%v", err)
+	}
+
+	resp := &storev1.TransactResponse{Reads: read, Results: make([]*storev1.WriteResult, len(results))}
+	for i, r := range results {
+		resp.Results[i] = writeResult(r.Entity, nil)
+	}
+	return resp, nil
+}
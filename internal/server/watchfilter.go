@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/labels"
+	"github.com/boshu2/lattice-lab/internal/store"
+)
+
+// watchFilter decides which events one WatchEntities stream is sent,
+// beyond the store's type filter. Not safe for concurrent use.
+type watchFilter struct {
+	selector   labels.Selector
+	components []string
+	box        *store.BBox
+	inside     map[string]bool // entities last sent from inside box
+}
+
+func newWatchFilter(req *storev1.WatchEntitiesRequest) (*watchFilter, error) {
+	sel, err := labels.Parse(req.LabelSelector)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range req.Components {
+		if key == "" {
+			return nil, fmt.Errorf("component keys must not be empty")
+		}
+	}
+	f := &watchFilter{selector: sel, components: req.Components}
+	if b := req.Bbox; b != nil {
+		f.box = &store.BBox{MinLat: b.MinLat, MaxLat: b.MaxLat, MinLon: b.MinLon, MaxLon: b.MaxLon}
+		if err := f.box.Validate(); err != nil {
+			return nil, err
+		}
+		f.inside = make(map[string]bool)
+	}
+	return f, nil
+}
+
+// pass reports whether event should be sent. An entity sent from inside
+// the box is sent once more when an update moves it out, so the watcher
+// learns it left, and then not again until it returns.
+func (f *watchFilter) pass(event *storev1.EntityEvent) bool {
+	e := event.Entity
+	if !f.selector.Matches(e.GetLabels()) {
+		return false
+	}
+	for _, key := range f.components {
+		if _, ok := e.GetComponents()[key]; !ok {
+			return false
+		}
+	}
+	if f.box == nil {
+		return true
+	}
+	id := e.GetId()
+	in := f.box.ContainsEntity(e)
+	switch {
+	case event.Type == storev1.EventType_EVENT_TYPE_DELETED || event.Type == storev1.EventType_EVENT_TYPE_EXPIRED:
+		was := f.inside[id]
+		delete(f.inside, id)
+		return in || was
+	case in:
+		f.inside[id] = true
+		return true
+	case f.inside[id]:
+		delete(f.inside, id)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package store
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// DefaultArchiveRetention is how long a store keeps removed entities
+// unless WithArchiveRetention says otherwise.
+const DefaultArchiveRetention = 15 * time.Minute
+
+// archived is an entity as it stood before it was removed.
+type archived struct {
+	entity *entityv1.Entity
+	reason storev1.EventType // DELETED or EXPIRED
+	at     time.Time
+}
+
+// WithArchiveRetention sets how long deleted and expired entities are kept
+// for Archived before the reaper collects them; zero disables the archive.
+func WithArchiveRetention(d time.Duration) Option {
+	return func(s *Store) { s.archiveRetention = d }
+}
+
+// Archived returns the removed entities of the given type still within the
+// retention, or all of them for UNSPECIFIED, most recently removed first.
+func (s *Store) Archived(typeFilter entityv1.EntityType) []*storev1.ArchivedEntity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []*storev1.ArchivedEntity
+	for _, a := range s.archive {
+		if typeFilter != entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED && a.entity.Type != typeFilter {
+			continue
+		}
+		out = append(out, &storev1.ArchivedEntity{
+			Entity:     proto.Clone(a.entity).(*entityv1.Entity),
+			Reason:     a.reason,
+			ArchivedAt: timestamppb.New(a.at),
+		})
+	}
+	slices.SortFunc(out, func(a, b *storev1.ArchivedEntity) int {
+		return cmp.Or(b.ArchivedAt.AsTime().Compare(a.ArchivedAt.AsTime()), cmp.Compare(a.Entity.Id, b.Entity.Id))
+	})
+	return out
+}
+
+// archiveLocked keeps e, removed with reason typ. Must hold mu.
+func (s *Store) archiveLocked(e *entityv1.Entity, typ storev1.EventType) {
+	if s.archiveRetention <= 0 {
+		return
+	}
+	s.archive[e.Id] = archived{entity: proto.Clone(e).(*entityv1.Entity), reason: typ, at: time.Now()}
+}
+
+// collectArchive drops archived entities older than the retention.
+func (s *Store) collectArchive(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, a := range s.archive {
+		if now.Sub(a.at) > s.archiveRetention {
+			delete(s.archive, id)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestArchive(t *testing.T) {
+	s := New(WithArchiveRetention(time.Minute))
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1, Lon: 2})
+	_, _ = s.Create(&entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos}})
+	_, _ = s.Create(&entityv1.Entity{Id: "t2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	_, _ = s.Create(&entityv1.Entity{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET})
+
+	if err := s.Delete("t1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	s.SetTTL("t2", time.Millisecond)
+	if err := s.expire("t2", time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	_ = s.Delete("a1")
+
+	got := s.Archived(entityv1.EntityType_ENTITY_TYPE_TRACK)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 archived tracks, got %d", len(got))
+	}
+	if got[0].Entity.Id != "t2" || got[0].Reason != storev1.EventType_EVENT_TYPE_EXPIRED {
+		t.Fatalf("expected the expired t2 first, got %s %v", got[0].Entity.Id, got[0].Reason)
+	}
+	if got[1].Reason != storev1.EventType_EVENT_TYPE_DELETED || got[1].Entity.Components["position"] == nil {
+		t.Fatalf("expected t1 archived with its components, got %v", got[1])
+	}
+
+	// An entity created again is live, not archived.
+	_, _ = s.Create(&entityv1.Entity{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET})
+	if got := s.Archived(entityv1.EntityType_ENTITY_TYPE_ASSET); len(got) != 0 {
+		t.Fatalf("expected a1 dropped from the archive, got %v", got)
+	}
+
+	s.collectArchive(time.Now())
+	if got := s.Archived(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED); len(got) != 2 {
+		t.Fatalf("expected entities kept within the retention, got %d", len(got))
+	}
+	s.collectArchive(time.Now().Add(2 * time.Minute))
+	if got := s.Archived(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED); len(got) != 0 {
+		t.Fatalf("expected the archive collected, got %d", len(got))
+	}
+}
+
+func TestArchive_Disabled(t *testing.T) {
+	s := New(WithArchiveRetention(0))
+	_, _ = s.Create(&entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	_ = s.Delete("t1")
+	if got := s.Archived(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED); len(got) != 0 {
+		t.Fatalf("expected nothing archived, got %v", got)
+	}
+}
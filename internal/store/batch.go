@@ -0,0 +1,110 @@
+package store
+
+import (
+	"fmt"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+)
+
+// WriteOp is the kind of a Write.
+type WriteOp int
+
+const (
+	OpCreate    WriteOp = iota // Create Entity
+	OpUpdate                   // Update Entity, or UpdateIf with Expected
+	OpPatch                    // Patch Entity.Id with Entity.Components
+	OpDelete                   // Delete Entity.Id, or DeleteAt with At
+	OpUpsert                   // Create Entity if absent, else Patch its components
+	OpMerge                    // CRDT-merge Entity, replicated from another node, into the stored copy, or create it if absent
+	OpRemove                   // RemoveComponents Keys from Entity.Id
+	OpIncrement                // Increment Entity.Id's counter Keys[0] by Delta
+)
+
+// Write is one operation of a Batch.
+type Write struct {
+	Op       WriteOp
+	Entity   *entityv1.Entity
+	Expected *hlc.Timestamp // updates: apply only at this version
+	At       *hlc.Timestamp // deletes: a replicated delete's HLC
+	TTL      time.Duration  // all but deletes: as SetTTL, if positive
+	Origin   string         // the node the write came from, stamped on its events
+	Hops     uint32         // relays the write passed through, stamped on its events
+	Path     []string       // nodes whose relays passed the write on, stamped on its events
+	Keys     []string       // removes: the component keys to remove; increments: the counter's
+	Delta    int64          // increments: the amount to add
+
+	// SkipUnchanged, for merges, leaves a stored copy the merge would not
+	// change unwritten.
+	SkipUnchanged bool
+}
+
+// WriteResult is the outcome of one Write: the entity as stored, nil for
+// deletes, or the error the single-write method would have returned.
+type WriteResult struct {
+	Entity *entityv1.Entity
+	Err    error
+	Merged bool // merges: Entity was merged with a stored copy and written
+}
+
+// Batch applies writes in order under one lock acquisition, so no other
+// write interleaves with them. Each write succeeds or fails on its own;
+// the results are in the same order.
+func (s *Store) Batch(writes []Write) []WriteResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	results := make([]WriteResult, len(writes))
+	for i, w := range writes {
+		results[i] = s.writeLocked(w)
+	}
+	return results
+}
+
+// writeLocked applies one write. Must hold mu.
+func (s *Store) writeLocked(w Write) WriteResult {
+	s.origin, s.hops, s.path = w.Origin, w.Hops, w.Path
+	defer func() { s.origin, s.hops, s.path = "", 0, nil }()
+	var (
+		e      *entityv1.Entity
+		err    error
+		merged bool
+	)
+	switch w.Op {
+	case OpCreate:
+		e, err = s.createLocked(w.Entity)
+	case OpUpdate:
+		e, err = s.updateLocked(w.Entity, w.Expected)
+	case OpPatch:
+		e, err = s.patchLocked(w.Entity.Id, w.Entity.Components)
+	case OpUpsert:
+		if _, ok := s.entities[w.Entity.Id]; ok {
+			e, err = s.patchLocked(w.Entity.Id, w.Entity.Components)
+		} else {
+			e, err = s.createLocked(w.Entity)
+		}
+	case OpMerge:
+		e, merged, err = s.mergeLocked(w.Entity, w.SkipUnchanged)
+	case OpRemove:
+		e, err = s.removeComponentsLocked(w.Entity.Id, w.Keys)
+	case OpIncrement:
+		if len(w.Keys) != 1 {
+			err = fmt.Errorf("increment %q: want one counter key, got %d", w.Entity.Id, len(w.Keys))
+			break
+		}
+		e, err = s.incrementLocked(w.Entity.Id, w.Keys[0], w.Delta)
+	case OpDelete:
+		if w.At != nil {
+			err = s.deleteAtLocked(w.Entity.Id, *w.At)
+		} else {
+			err = s.deleteLocked(w.Entity.Id)
+		}
+	default:
+		err = fmt.Errorf("unknown write op %d", w.Op)
+	}
+	if err == nil && e != nil && w.TTL > 0 {
+		s.ttls[e.Id] = time.Now().Add(w.TTL)
+	}
+	return WriteResult{Entity: e, Err: err, Merged: merged}
+}
@@ -0,0 +1,168 @@
+package store
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestBatch(t *testing.T) {
+	s := New()
+	_, _ = s.Create(&entityv1.Entity{Id: "old", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1, Lon: 2})
+
+	results := s.Batch([]Write{
+		{Op: OpCreate, Entity: &entityv1.Entity{Id: "b1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}, TTL: time.Minute},
+		{Op: OpPatch, Entity: &entityv1.Entity{Id: "b1", Components: map[string]*anypb.Any{"position": pos}}},
+		{Op: OpCreate, Entity: &entityv1.Entity{Id: "old", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}},
+		{Op: OpDelete, Entity: &entityv1.Entity{Id: "old"}},
+		{Op: OpUpdate, Entity: &entityv1.Entity{Id: "missing", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}},
+	})
+	if len(results) != 5 {
+		t.Fatalf("expected 5 results, got %d", len(results))
+	}
+	for _, i := range []int{0, 1, 3} {
+		if results[i].Err != nil {
+			t.Fatalf("write %d: %v", i, results[i].Err)
+		}
+	}
+	if results[1].Entity.Components["position"] == nil {
+		t.Fatal("expected the patch to see the entity created earlier in the batch")
+	}
+	if results[2].Err == nil || results[4].Err == nil {
+		t.Fatalf("expected the duplicate create and missing update to fail, got %v, %v", results[2].Err, results[4].Err)
+	}
+	if errors.Is(results[2].Err, ErrDeleted) {
+		t.Fatal("expected the duplicate create to fail on the live entity, not a tombstone")
+	}
+	if results[3].Entity != nil {
+		t.Fatalf("expected no entity for a delete, got %v", results[3].Entity)
+	}
+
+	if _, err := s.Get("old"); err == nil {
+		t.Fatal("expected old deleted")
+	}
+	s.mu.RLock()
+	_, ok := s.ttls["b1"]
+	s.mu.RUnlock()
+	if !ok {
+		t.Fatal("expected the create's TTL set")
+	}
+}
+
+func TestBatch_Upsert(t *testing.T) {
+	s := New()
+	pos := func(lat float64) map[string]*anypb.Any {
+		c, _ := anypb.New(&entityv1.PositionComponent{Lat: lat})
+		return map[string]*anypb.Any{"position": c}
+	}
+	report := func(lat float64) Write {
+		return Write{Op: OpUpsert, Entity: &entityv1.Entity{Id: "u1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: pos(lat)}}
+	}
+
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	defer s.Unwatch(w)
+	results := s.Batch([]Write{report(1), report(2)})
+	for i, r := range results {
+		if r.Err != nil {
+			t.Fatalf("upsert %d: %v", i, r.Err)
+		}
+	}
+	for _, want := range []storev1.EventType{storev1.EventType_EVENT_TYPE_CREATED, storev1.EventType_EVENT_TYPE_UPDATED} {
+		if ev := <-w.Events; ev.Type != want {
+			t.Fatalf("expected %v, got %v", want, ev.Type)
+		}
+	}
+	got := &entityv1.PositionComponent{}
+	if err := results[1].Entity.Components["position"].UnmarshalTo(got); err != nil || got.Lat != 2 {
+		t.Fatalf("expected the second upsert to patch the position, got %v (%v)", got, err)
+	}
+
+	if err := s.Delete("u1"); err != nil {
+		t.Fatal(err)
+	}
+	if r := s.Batch([]Write{report(3)})[0]; r.Err != nil {
+		t.Fatalf("expected an upsert after a delete to recreate the entity: %v", r.Err)
+	}
+}
+
+func TestBatch_Origin(t *testing.T) {
+	s := New()
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	defer s.Unwatch(w)
+
+	s.Batch([]Write{
+		{Op: OpCreate, Entity: &entityv1.Entity{Id: "o1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}, Origin: "node-b"},
+		{Op: OpDelete, Entity: &entityv1.Entity{Id: "o1"}, Origin: "node-b"},
+	})
+	if _, err := s.Create(&entityv1.Entity{Id: "o2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RestoreFrom(&entityv1.Entity{Id: "o3", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}, "node-c"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"node-b", "node-b", "", "node-c"} {
+		if ev := <-w.Events; ev.OriginNode != want {
+			t.Fatalf("expected %s's event from %q, got %q", ev.Entity.Id, want, ev.OriginNode)
+		}
+	}
+}
+
+func TestBatch_Merge(t *testing.T) {
+	s := New()
+	pos := func(lat float64) map[string]*anypb.Any {
+		c, _ := anypb.New(&entityv1.PositionComponent{Lat: lat})
+		return map[string]*anypb.Any{"position": c}
+	}
+	if _, err := s.Create(&entityv1.Entity{Id: "m1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: pos(1)}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create(&entityv1.Entity{Id: "gone", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}); err != nil {
+		t.Fatal(err)
+	}
+	stale := s.Now()
+	if err := s.Delete("gone"); err != nil {
+		t.Fatal(err)
+	}
+	later := s.Now()
+	later.Physical += uint64(time.Second)
+	replica := func(id string, lat float64, ts hlc.Timestamp) *entityv1.Entity {
+		return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: pos(lat),
+			HlcPhysical: ts.Physical, HlcLogical: ts.Logical, HlcNode: "node-b"}
+	}
+
+	results := s.Batch([]Write{
+		{Op: OpMerge, Entity: replica("m1", 2, later)},
+		{Op: OpMerge, Entity: replica("m2", 3, later)},
+		{Op: OpMerge, Entity: replica("gone", 4, stale)},
+		{Op: OpMerge, Entity: replica("m1", 2, later), SkipUnchanged: true},
+	})
+	if results[0].Err != nil || !results[0].Merged {
+		t.Fatalf("expected m1 merged, got merged=%v (%v)", results[0].Merged, results[0].Err)
+	}
+	got := &entityv1.PositionComponent{}
+	if err := results[0].Entity.Components["position"].UnmarshalTo(got); err != nil || got.Lat != 2 {
+		t.Fatalf("expected the later position to win the merge, got %v (%v)", got, err)
+	}
+	if results[1].Err != nil || results[1].Merged || results[1].Entity == nil {
+		t.Fatalf("expected m2 created, got merged=%v (%v)", results[1].Merged, results[1].Err)
+	}
+	if !errors.Is(results[2].Err, ErrDeleted) {
+		t.Fatalf("expected a copy from before the delete refused, got %v", results[2].Err)
+	}
+	if results[3].Err != nil || results[3].Merged {
+		t.Fatalf("expected an unchanging merge skipped, got merged=%v (%v)", results[3].Merged, results[3].Err)
+	}
+	if ts := s.Now(); !ts.After(later) {
+		t.Fatalf("expected the clock advanced past the replica's HLC %v, got %v", later, ts)
+	}
+
+	if _, _, err := s.Transact(nil, []Write{{Op: OpMerge, Entity: replica("m1", 5, later)}}); err == nil {
+		t.Fatal("expected a merge refused in a transaction")
+	}
+}
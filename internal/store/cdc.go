@@ -0,0 +1,123 @@
+package store
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// ChangeFeed receives the store's change records, one per committed write.
+// Unlike a Watcher, a feed that falls behind is not sent a partial stream:
+// it is closed and marked lagged, and its reader resumes with Changes.
+type ChangeFeed struct {
+	Records chan *storev1.ChangeRecord
+	Backlog []*storev1.ChangeRecord // missed records to deliver before Records
+	lagged  bool                    // set before Records is closed
+}
+
+// Lagged reports whether the feed was closed because its reader fell
+// behind. Valid once Records is closed.
+func (f *ChangeFeed) Lagged() bool { return f.lagged }
+
+// Changes subscribes to change records after the one with sequence since,
+// or from the next write if since is zero. Returns ErrResumeGap if the
+// records missed are no longer held. Stop the feed with StopChanges.
+func (s *Store) Changes(since uint64) (*ChangeFeed, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f := &ChangeFeed{Records: make(chan *storev1.ChangeRecord, 256)}
+	if since > 0 {
+		oldest := s.seq + 1
+		if len(s.changes) > 0 {
+			oldest = s.changes[0].Sequence
+		}
+		if since+1 < oldest || since > s.seq {
+			return nil, fmt.Errorf("%w: resume after %d, backlog holds %d to %d", ErrResumeGap, since, oldest, s.seq)
+		}
+		f.Backlog = slices.Clone(s.changes[since+1-oldest:])
+	}
+	s.watchMu.Lock()
+	s.feeds = append(s.feeds, f)
+	s.watchMu.Unlock()
+	return f, nil
+}
+
+// StopChanges unsubscribes f and closes its channel, if the store has not
+// already.
+func (s *Store) StopChanges(f *ChangeFeed) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	if i := slices.Index(s.feeds, f); i >= 0 {
+		s.feeds = slices.Delete(s.feeds, i, i+1)
+		close(f.Records)
+	}
+}
+
+// recordChange keeps the change record for event, whose write replaced
+// prev (nil for a create), and sends it to every feed. Must hold mu.
+func (s *Store) recordChange(event *storev1.EntityEvent, prev *entityv1.Entity) {
+	e := event.Entity
+	r := &storev1.ChangeRecord{
+		Sequence:   event.Sequence,
+		Type:       event.Type,
+		EntityId:   e.Id,
+		EntityType: e.Type,
+		OriginNode: e.HlcNode,
+		Hlc:        &entityv1.HLCTimestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode},
+		CommitTime: timestamppb.Now(),
+		Components: componentChanges(prev, e, event.Type),
+	}
+	if len(s.changes) == eventBacklog {
+		s.changes = s.changes[1:]
+	}
+	s.changes = append(s.changes, r)
+
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	s.feeds = slices.DeleteFunc(s.feeds, func(f *ChangeFeed) bool {
+		select {
+		case f.Records <- r:
+			return false
+		default:
+			f.lagged = true
+			close(f.Records)
+			return true
+		}
+	})
+}
+
+// componentChanges lists the components a write changed, telling them by
+// their HLC stamps: a component whose stamp moved was written. A removal
+// (DELETED or EXPIRED) removes every component of prev, which is e itself.
+func componentChanges(prev, e *entityv1.Entity, typ storev1.EventType) []*storev1.ComponentChange {
+	var changes []*storev1.ComponentChange
+	if typ == storev1.EventType_EVENT_TYPE_DELETED || typ == storev1.EventType_EVENT_TYPE_EXPIRED {
+		for _, key := range slices.Sorted(maps.Keys(e.Components)) {
+			changes = append(changes, &storev1.ComponentChange{Key: key, OldValue: e.Components[key]})
+		}
+		return changes
+	}
+
+	keys := slices.Collect(maps.Keys(e.Components))
+	if prev != nil {
+		keys = slices.AppendSeq(keys, maps.Keys(prev.Components))
+	}
+	slices.Sort(keys)
+	for _, key := range slices.Compact(keys) {
+		c := &storev1.ComponentChange{Key: key, NewValue: e.Components[key]}
+		if prev != nil {
+			old, ok := prev.Components[key]
+			if ok && c.NewValue != nil && componentHLC(prev, key) == componentHLC(e, key) {
+				continue // untouched
+			}
+			c.OldValue = old
+		}
+		changes = append(changes, c)
+	}
+	return changes
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestChanges(t *testing.T) {
+	s := New(WithNodeID("cdc-node"))
+	f, err := s.Changes(0)
+	if err != nil {
+		t.Fatalf("Changes: %v", err)
+	}
+	defer s.StopChanges(f)
+
+	high, low := makeAnyString(t, "high"), makeAnyString(t, "low")
+	_, _ = s.Create(&entityv1.Entity{Id: "c1", Components: map[string]*anypb.Any{"threat": low, "position": makeAnyString(t, "here")}})
+	_, _ = s.Patch("c1", map[string]*anypb.Any{"threat": high})
+	_ = s.Delete("c1")
+
+	type change struct{ key, old, new string }
+	str := func(a *anypb.Any) string {
+		if a == nil {
+			return ""
+		}
+		v, _ := a.UnmarshalNew()
+		return fmt.Sprint(v)
+	}
+	want := []struct {
+		typ     storev1.EventType
+		changes []change
+	}{
+		{storev1.EventType_EVENT_TYPE_CREATED, []change{{"position", "", str(makeAnyString(t, "here"))}, {"threat", "", str(low)}}},
+		{storev1.EventType_EVENT_TYPE_UPDATED, []change{{"threat", str(low), str(high)}}},
+		{storev1.EventType_EVENT_TYPE_DELETED, []change{{"position", str(makeAnyString(t, "here")), ""}, {"threat", str(high), ""}}},
+	}
+	var first uint64
+	for i, w := range want {
+		r := <-f.Records
+		if i == 0 {
+			first = r.Sequence
+		}
+		if r.Sequence != first+uint64(i) || r.Type != w.typ || r.EntityId != "c1" || r.OriginNode != "cdc-node" {
+			t.Fatalf("record %d: got %v", i, r)
+		}
+		var got []change
+		for _, c := range r.Components {
+			got = append(got, change{c.Key, str(c.OldValue), str(c.NewValue)})
+		}
+		if fmt.Sprint(got) != fmt.Sprint(w.changes) {
+			t.Fatalf("record %d changes = %v, want %v", i, got, w.changes)
+		}
+	}
+
+	resumed, err := s.Changes(first)
+	if err != nil {
+		t.Fatalf("Changes(%d): %v", first, err)
+	}
+	defer s.StopChanges(resumed)
+	if len(resumed.Backlog) != 2 || !proto.Equal(resumed.Backlog[0].Components[0], &storev1.ComponentChange{Key: "threat", OldValue: low, NewValue: high}) {
+		t.Fatalf("expected the patch and delete in the backlog, got %v", resumed.Backlog)
+	}
+	if _, err := s.Changes(first + 10); !errors.Is(err, ErrResumeGap) {
+		t.Fatalf("expected ErrResumeGap past the last sequence, got %v", err)
+	}
+}
+
+func TestChangesLagged(t *testing.T) {
+	s := New()
+	f, _ := s.Changes(0)
+	for i := range cap(f.Records) + 1 {
+		_, _ = s.Create(&entityv1.Entity{Id: fmt.Sprintf("e%d", i)})
+	}
+	n := 0
+	for range f.Records {
+		n++
+	}
+	if n != cap(f.Records) || !f.Lagged() {
+		t.Fatalf("expected the feed closed as lagged after %d records, got %d (lagged %v)", cap(f.Records), n, f.Lagged())
+	}
+	s.StopChanges(f) // already closed; must not panic
+}
@@ -0,0 +1,141 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+)
+
+// The digest tree's shape, as documented on DigestEntitiesRequest: the root
+// is level 0 and the leaves, DigestFanout^DigestDepth of them, level
+// DigestDepth.
+const (
+	DigestDepth  = 3
+	DigestFanout = 16
+
+	digestLeaves = DigestFanout * DigestFanout * DigestFanout // DigestFanout^DigestDepth
+)
+
+type digestHash [sha256.Size]byte
+
+// digestTree hashes the store's entities into a fixed tree. A node's hash
+// is the XOR of the hashes of the entities below it, so a write updates
+// one node per level rather than rehashing the bucket. Not safe for
+// concurrent use; the store guards it with mu.
+type digestTree struct {
+	levels  [DigestDepth + 1][]digestHash
+	buckets []map[string]digestHash // by leaf; entity hashes by ID
+}
+
+func newDigestTree() *digestTree {
+	t := &digestTree{}
+	n := 1
+	for l := range t.levels {
+		t.levels[l] = make([]digestHash, n)
+		n *= DigestFanout
+	}
+	t.buckets = make([]map[string]digestHash, digestLeaves)
+	return t
+}
+
+// set hashes e into the tree, replacing any earlier version of it.
+func (t *digestTree) set(e *entityv1.Entity) {
+	t.remove(e.Id)
+	leaf := digestLeaf(e.Id)
+	h := entityDigest(e)
+	if t.buckets[leaf] == nil {
+		t.buckets[leaf] = make(map[string]digestHash)
+	}
+	t.buckets[leaf][e.Id] = h
+	t.toggle(leaf, h)
+}
+
+func (t *digestTree) remove(id string) {
+	leaf := digestLeaf(id)
+	h, ok := t.buckets[leaf][id]
+	if !ok {
+		return
+	}
+	delete(t.buckets[leaf], id)
+	t.toggle(leaf, h)
+}
+
+// toggle XORs h into leaf and each of its ancestors.
+func (t *digestTree) toggle(leaf int, h digestHash) {
+	for l := DigestDepth; l >= 0; l-- {
+		node := &t.levels[l][leaf]
+		for i := range node {
+			node[i] ^= h[i]
+		}
+		leaf /= DigestFanout
+	}
+}
+
+// Digest returns the digest tree's nodes at level, in the order given, with
+// the entities of each leaf.
+func (s *Store) Digest(level int, nodes []int) ([]*storev1.DigestNode, error) {
+	if level < 0 || level > DigestDepth {
+		return nil, fmt.Errorf("digest level %d: want 0 to %d", level, DigestDepth)
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	hashes := s.digest.levels[level]
+	out := make([]*storev1.DigestNode, len(nodes))
+	for i, n := range nodes {
+		if n < 0 || n >= len(hashes) {
+			return nil, fmt.Errorf("digest level %d has no node %d", level, n)
+		}
+		node := &storev1.DigestNode{Index: uint32(n)}
+		if hashes[n] != (digestHash{}) {
+			node.Hash = slices.Clone(hashes[n][:])
+		}
+		if level == DigestDepth {
+			bucket := s.digest.buckets[n]
+			for _, id := range slices.Sorted(maps.Keys(bucket)) {
+				h := bucket[id]
+				node.Entities = append(node.Entities, &storev1.EntityDigest{Id: id, Hash: h[:]})
+			}
+		}
+		out[i] = node
+	}
+	return out, nil
+}
+
+// digestLeaf is the leaf bucket holding the entity called id.
+func digestLeaf(id string) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	return int(h.Sum32() % digestLeaves)
+}
+
+// entityDigest hashes what two stores holding the same entity agree on: its
+// ID, type, components, and labels. HLCs and timestamps are left out,
+// since each store stamps its own.
+func entityDigest(e *entityv1.Entity) digestHash {
+	h := sha256.New()
+	field := func(b []byte) {
+		h.Write(binary.AppendUvarint(nil, uint64(len(b))))
+		h.Write(b)
+	}
+	field([]byte(e.Id))
+	h.Write(binary.AppendUvarint(nil, uint64(e.Type)))
+	h.Write(binary.AppendUvarint(nil, uint64(len(e.Components))))
+	for _, key := range slices.Sorted(maps.Keys(e.Components)) {
+		c := e.Components[key]
+		field([]byte(key))
+		field([]byte(c.GetTypeUrl()))
+		field(c.GetValue())
+	}
+	h.Write(binary.AppendUvarint(nil, uint64(len(e.Labels))))
+	for _, key := range slices.Sorted(maps.Keys(e.Labels)) {
+		field([]byte(key))
+		field([]byte(e.Labels[key]))
+	}
+	return digestHash(h.Sum(nil))
+}
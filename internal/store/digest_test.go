@@ -0,0 +1,93 @@
+package store
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func rootHash(t *testing.T, s *Store) []byte {
+	t.Helper()
+	nodes, err := s.Digest(0, []int{0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return nodes[0].Hash
+}
+
+func TestDigest_IgnoresHLCs(t *testing.T) {
+	a, b := New(), New()
+	if rootHash(t, a) != nil {
+		t.Fatal("expected an empty store to have no root hash")
+	}
+	for _, s := range []*Store{a, b} {
+		if _, err := s.Create(positioned(t, "t1", 10, 20)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Written at different times, so with different HLCs.
+	if _, err := b.Update(positioned(t, "t1", 10, 20)); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rootHash(t, a), rootHash(t, b)) {
+		t.Fatal("expected stores holding the same entities to have the same root")
+	}
+
+	threat, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	if _, err := b.Update(&entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"threat": threat}}); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(rootHash(t, a), rootHash(t, b)) {
+		t.Fatal("expected a new component to change the root")
+	}
+}
+
+func TestDigest_FollowsWrites(t *testing.T) {
+	s := New()
+	empty := rootHash(t, s)
+	if _, err := s.Create(positioned(t, "t1", 10, 20)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Create(&entityv1.Entity{Id: "t2", Type: entityv1.EntityType_ENTITY_TYPE_ASSET}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The leaf holding t1 lists it, and every ancestor's hash is set.
+	leaf := digestLeaf("t1")
+	for level, node := DigestDepth, leaf; level >= 0; level, node = level-1, node/DigestFanout {
+		nodes, err := s.Digest(level, []int{node})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nodes[0].Hash == nil {
+			t.Fatalf("level %d node %d: expected a hash", level, node)
+		}
+	}
+	nodes, _ := s.Digest(DigestDepth, []int{leaf})
+	if !slices.ContainsFunc(nodes[0].Entities, func(d *storev1.EntityDigest) bool { return d.Id == "t1" }) {
+		t.Fatalf("expected t1 in leaf %d, got %v", leaf, nodes[0].Entities)
+	}
+
+	for _, id := range []string{"t1", "t2"} {
+		if err := s.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(rootHash(t, s), empty) {
+		t.Fatal("expected deleting every entity to empty the tree")
+	}
+}
+
+func TestDigest_RejectsBadNodes(t *testing.T) {
+	s := New()
+	if _, err := s.Digest(DigestDepth+1, []int{0}); err == nil {
+		t.Fatal("expected an error below the leaves")
+	}
+	if _, err := s.Digest(1, []int{DigestFanout}); err == nil {
+		t.Fatal("expected an error past the last node")
+	}
+}
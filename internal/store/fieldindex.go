@@ -0,0 +1,399 @@
+package store
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// IndexKey names a field of a component's message, e.g. threat.level.
+type IndexKey struct {
+	Component string
+	Field     string
+}
+
+func (k IndexKey) String() string { return k.Component + "." + k.Field }
+
+// DefaultIndexes are the fields entity-store and lattice-lab index unless
+// configured otherwise: the ones the services filter tracks by.
+const DefaultIndexes = "threat.level,source.sensor_id"
+
+// ParseIndexes parses a comma-separated list of component.field names.
+func ParseIndexes(s string) ([]IndexKey, error) {
+	var keys []IndexKey
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		component, field, ok := strings.Cut(name, ".")
+		if !ok || component == "" || field == "" {
+			return nil, fmt.Errorf("index %q: want component.field", name)
+		}
+		keys = append(keys, IndexKey{component, field})
+	}
+	return keys, nil
+}
+
+// WithIndex indexes the values of each key's field, so Filter answers
+// predicates on it without unmarshalling every entity's component.
+func WithIndex(keys ...IndexKey) Option {
+	return func(s *Store) {
+		for _, k := range keys {
+			s.fields[k] = newFieldIndex()
+		}
+	}
+}
+
+// index updates every index with e. Must hold mu.
+func (s *Store) index(e *entityv1.Entity) {
+	s.countType(e)
+	s.geo.set(e)
+	s.digest.set(e)
+	for k, x := range s.fields {
+		x.set(k, e)
+	}
+}
+
+// unindex drops an entity from every index. Must hold mu.
+func (s *Store) unindex(id string) {
+	s.uncountType(id)
+	s.geo.remove(id)
+	s.digest.remove(id)
+	for _, x := range s.fields {
+		x.remove(id)
+	}
+}
+
+// value is a field's value in comparable form: numbers, enums (by number)
+// and bools (0 or 1) in num, strings in str.
+type value struct {
+	num float64
+	str string
+}
+
+// fieldIndex buckets entities by the value of one component field. Range
+// predicates visit the distinct values rather than the entities. Not safe
+// for concurrent use; the store guards it with mu.
+type fieldIndex struct {
+	message protoreflect.MessageDescriptor // of the last component indexed
+	values  map[string]value
+	buckets map[value]map[string]struct{}
+}
+
+func newFieldIndex() *fieldIndex {
+	return &fieldIndex{
+		values:  make(map[string]value),
+		buckets: make(map[value]map[string]struct{}),
+	}
+}
+
+// set indexes e under its value for k, or drops it if it has none.
+func (x *fieldIndex) set(k IndexKey, e *entityv1.Entity) {
+	m := component(e, k.Component)
+	if m == nil {
+		x.remove(e.Id)
+		return
+	}
+	x.message = m.Descriptor()
+	fd, err := scalarField(x.message, k.Field)
+	if err != nil {
+		x.remove(e.Id)
+		return
+	}
+	v := scalarValue(m, fd)
+	if old, ok := x.values[e.Id]; ok {
+		if old == v {
+			return
+		}
+		x.unlink(e.Id, old)
+	}
+	x.values[e.Id] = v
+	ids, ok := x.buckets[v]
+	if !ok {
+		ids = make(map[string]struct{})
+		x.buckets[v] = ids
+	}
+	ids[e.Id] = struct{}{}
+}
+
+func (x *fieldIndex) remove(id string) {
+	if v, ok := x.values[id]; ok {
+		x.unlink(id, v)
+		delete(x.values, id)
+	}
+}
+
+func (x *fieldIndex) unlink(id string, v value) {
+	delete(x.buckets[v], id)
+	if len(x.buckets[v]) == 0 {
+		delete(x.buckets, v)
+	}
+}
+
+// query returns the IDs of entities matching f, in no particular order.
+// The field is checked against the component's message, so a field it
+// does not have is an error here as it is on a scan.
+func (x *fieldIndex) query(f *storev1.ComponentFilter) ([]string, error) {
+	if x.message == nil {
+		return nil, nil // nothing indexed has the component
+	}
+	fd, err := scalarField(x.message, f.Field)
+	if err != nil {
+		return nil, err
+	}
+	want, err := parseValue(fd, f.Value)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	if f.Op == storev1.FilterOp_FILTER_OP_EQ {
+		for id := range x.buckets[want] {
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+	for v, bucket := range x.buckets {
+		if compare(fd, v, want, f.Op) {
+			for id := range bucket {
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids, nil
+}
+
+// Filter returns the entities matching every filter, optionally filtered
+// by type. An entity without a filter's component never matches it. The
+// first filter on an indexed field picks the candidates; the rest are
+// checked against each candidate.
+func (s *Store) Filter(typeFilter entityv1.EntityType, filters []*storev1.ComponentFilter) ([]*entityv1.Entity, error) {
+	for _, f := range filters {
+		if err := checkFilter(f); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	candidates, rest, err := s.candidates(filters)
+	if err != nil {
+		return nil, err
+	}
+	var result []*entityv1.Entity
+	for _, e := range candidates {
+		if typeFilter != entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED && e.Type != typeFilter {
+			continue
+		}
+		ok, err := matchesAll(e, rest)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			result = append(result, proto.Clone(e).(*entityv1.Entity))
+		}
+	}
+	return result, nil
+}
+
+// candidates returns the entities an index says match one of filters,
+// and the filters left to check, or every entity if none is indexed. Must
+// hold mu.
+func (s *Store) candidates(filters []*storev1.ComponentFilter) ([]*entityv1.Entity, []*storev1.ComponentFilter, error) {
+	for i, f := range filters {
+		x, ok := s.fields[IndexKey{f.Component, f.Field}]
+		if !ok {
+			continue
+		}
+		ids, err := x.query(f)
+		if err != nil {
+			return nil, nil, fmt.Errorf("filter %s.%s: %w", f.Component, f.Field, err)
+		}
+		out := make([]*entityv1.Entity, len(ids))
+		for j, id := range ids {
+			out[j] = s.entities[id]
+		}
+		rest := append(append([]*storev1.ComponentFilter(nil), filters[:i]...), filters[i+1:]...)
+		return out, rest, nil
+	}
+	out := make([]*entityv1.Entity, 0, len(s.entities))
+	for _, e := range s.entities {
+		out = append(out, e)
+	}
+	return out, filters, nil
+}
+
+// matchesAll reports whether e matches every filter.
+func matchesAll(e *entityv1.Entity, filters []*storev1.ComponentFilter) (bool, error) {
+	for _, f := range filters {
+		fd, v, err := fieldValue(e, IndexKey{f.Component, f.Field})
+		if err != nil {
+			return false, fmt.Errorf("filter %s.%s: %w", f.Component, f.Field, err)
+		}
+		if fd == nil {
+			return false, nil
+		}
+		want, err := parseValue(fd, f.Value)
+		if err != nil {
+			return false, fmt.Errorf("filter %s.%s: %w", f.Component, f.Field, err)
+		}
+		if !compare(fd, v, want, f.Op) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func checkFilter(f *storev1.ComponentFilter) error {
+	switch {
+	case f.GetComponent() == "" || f.Field == "":
+		return fmt.Errorf("filter needs a component and a field")
+	case f.Op == storev1.FilterOp_FILTER_OP_UNSPECIFIED:
+		return fmt.Errorf("filter %s.%s needs an op", f.Component, f.Field)
+	case storev1.FilterOp_name[int32(f.Op)] == "":
+		return fmt.Errorf("filter %s.%s: unknown op %d", f.Component, f.Field, f.Op)
+	}
+	return nil
+}
+
+// fieldValue reads k's field from e's component. It returns a nil
+// descriptor if e has no such component, and an error if the component's
+// message has no such scalar field.
+func fieldValue(e *entityv1.Entity, k IndexKey) (protoreflect.FieldDescriptor, value, error) {
+	m := component(e, k.Component)
+	if m == nil {
+		return nil, value{}, nil
+	}
+	fd, err := scalarField(m.Descriptor(), k.Field)
+	if err != nil {
+		return nil, value{}, err
+	}
+	return fd, scalarValue(m, fd), nil
+}
+
+// component unmarshals e's component name, or returns nil if e has none
+// or its type is unknown, leaving nothing to compare.
+func component(e *entityv1.Entity, name string) protoreflect.Message {
+	c, ok := e.Components[name]
+	if !ok {
+		return nil
+	}
+	msg, err := c.UnmarshalNew()
+	if err != nil {
+		return nil
+	}
+	return msg.ProtoReflect()
+}
+
+// scalarField returns md's field name, or an error if md has no such
+// field or it is not a scalar.
+func scalarField(md protoreflect.MessageDescriptor, name string) (protoreflect.FieldDescriptor, error) {
+	fd := md.Fields().ByName(protoreflect.Name(name))
+	if fd == nil {
+		return nil, fmt.Errorf("%s has no field %q", md.FullName(), name)
+	}
+	if fd.IsList() || fd.IsMap() {
+		return nil, fmt.Errorf("field %q is not a scalar", name)
+	}
+	switch fd.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind, protoreflect.BytesKind:
+		return nil, fmt.Errorf("field %q is not a scalar", name)
+	}
+	return fd, nil
+}
+
+// scalarValue reads the scalar field fd from m.
+func scalarValue(m protoreflect.Message, fd protoreflect.FieldDescriptor) value {
+	v := m.Get(fd)
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return value{str: v.String()}
+	case protoreflect.BoolKind:
+		if v.Bool() {
+			return value{num: 1}
+		}
+		return value{}
+	case protoreflect.EnumKind:
+		return value{num: float64(v.Enum())}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return value{num: float64(v.Int())}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return value{num: float64(v.Uint())}
+	}
+	return value{num: v.Float()}
+}
+
+// parseValue parses a filter's value as fd's type. Enum values are given
+// by name, in full (THREAT_LEVEL_HIGH) or without the enum's prefix
+// (HIGH), or by number.
+func parseValue(fd protoreflect.FieldDescriptor, s string) (value, error) {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return value{str: s}, nil
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return value{}, fmt.Errorf("%q is not a bool", s)
+		}
+		if b {
+			return value{num: 1}, nil
+		}
+		return value{}, nil
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		if ev := values.ByName(protoreflect.Name(s)); ev != nil {
+			return value{num: float64(ev.Number())}, nil
+		}
+		suffix := "_" + strings.ToUpper(s)
+		for i := range values.Len() {
+			if ev := values.Get(i); strings.HasSuffix(string(ev.Name()), suffix) {
+				return value{num: float64(ev.Number())}, nil
+			}
+		}
+		if n, err := strconv.ParseInt(s, 10, 32); err == nil {
+			return value{num: float64(n)}, nil
+		}
+		return value{}, fmt.Errorf("%q is not a %s", s, fd.Enum().Name())
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return value{}, fmt.Errorf("%q is not a number", s)
+	}
+	return value{num: n}, nil
+}
+
+// compare reports whether v op want holds for a value of fd's type.
+func compare(fd protoreflect.FieldDescriptor, v, want value, op storev1.FilterOp) bool {
+	var c int
+	if fd.Kind() == protoreflect.StringKind {
+		c = strings.Compare(v.str, want.str)
+	} else {
+		switch {
+		case v.num < want.num:
+			c = -1
+		case v.num > want.num:
+			c = 1
+		}
+	}
+	switch op {
+	case storev1.FilterOp_FILTER_OP_EQ:
+		return c == 0
+	case storev1.FilterOp_FILTER_OP_NE:
+		return c != 0
+	case storev1.FilterOp_FILTER_OP_LT:
+		return c < 0
+	case storev1.FilterOp_FILTER_OP_LE:
+		return c <= 0
+	case storev1.FilterOp_FILTER_OP_GT:
+		return c > 0
+	case storev1.FilterOp_FILTER_OP_GE:
+		return c >= 0
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package store
+
+import (
+	"slices"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func threatTrack(t *testing.T, id, sensor string, level entityv1.ThreatLevel) *entityv1.Entity {
+	t.Helper()
+	threat, err := anypb.New(&entityv1.ThreatComponent{Level: level})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := anypb.New(&entityv1.SourceComponent{SensorId: sensor})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &entityv1.Entity{
+		Id:         id,
+		Type:       entityv1.EntityType_ENTITY_TYPE_TRACK,
+		Components: map[string]*anypb.Any{"threat": threat, "source": src},
+	}
+}
+
+func filterIDs(t *testing.T, s *Store, filters ...*storev1.ComponentFilter) []string {
+	t.Helper()
+	entities, err := s.Filter(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED, filters)
+	if err != nil {
+		t.Fatalf("Filter: %v", err)
+	}
+	ids := make([]string, len(entities))
+	for i, e := range entities {
+		ids[i] = e.Id
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+func TestFilter(t *testing.T) {
+	keys, err := ParseIndexes(DefaultIndexes)
+	if err != nil {
+		t.Fatalf("ParseIndexes: %v", err)
+	}
+	indexed, scanned := New(WithIndex(keys...)), New()
+	for _, s := range []*Store{indexed, scanned} {
+		_, _ = s.Create(threatTrack(t, "t1", "radar-1", entityv1.ThreatLevel_THREAT_LEVEL_HIGH))
+		_, _ = s.Create(threatTrack(t, "t2", "radar-1", entityv1.ThreatLevel_THREAT_LEVEL_LOW))
+		_, _ = s.Create(threatTrack(t, "t3", "eo-1", entityv1.ThreatLevel_THREAT_LEVEL_MEDIUM))
+		_, _ = s.Create(&entityv1.Entity{Id: "bare", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	}
+
+	tests := []struct {
+		name    string
+		filters []*storev1.ComponentFilter
+		want    []string
+	}{
+		{"enum at least", []*storev1.ComponentFilter{{Component: "threat", Field: "level", Op: storev1.FilterOp_FILTER_OP_GE, Value: "MEDIUM"}}, []string{"t1", "t3"}},
+		{"enum full name", []*storev1.ComponentFilter{{Component: "threat", Field: "level", Op: storev1.FilterOp_FILTER_OP_EQ, Value: "THREAT_LEVEL_LOW"}}, []string{"t2"}},
+		{"string equal", []*storev1.ComponentFilter{{Component: "source", Field: "sensor_id", Op: storev1.FilterOp_FILTER_OP_EQ, Value: "radar-1"}}, []string{"t1", "t2"}},
+		{"not equal skips missing", []*storev1.ComponentFilter{{Component: "source", Field: "sensor_id", Op: storev1.FilterOp_FILTER_OP_NE, Value: "radar-1"}}, []string{"t3"}},
+		{"every filter", []*storev1.ComponentFilter{
+			{Component: "source", Field: "sensor_id", Op: storev1.FilterOp_FILTER_OP_EQ, Value: "radar-1"},
+			{Component: "threat", Field: "level", Op: storev1.FilterOp_FILTER_OP_GT, Value: "LOW"},
+		}, []string{"t1"}},
+	}
+	for _, tt := range tests {
+		for name, s := range map[string]*Store{"indexed": indexed, "scanned": scanned} {
+			if got := filterIDs(t, s, tt.filters...); !slices.Equal(got, tt.want) {
+				t.Errorf("%s, %s: got %v, want %v", tt.name, name, got, tt.want)
+			}
+		}
+	}
+
+	// The index follows writes.
+	high := &storev1.ComponentFilter{Component: "threat", Field: "level", Op: storev1.FilterOp_FILTER_OP_EQ, Value: "HIGH"}
+	if _, err := indexed.Patch("t2", threatTrack(t, "t2", "radar-1", entityv1.ThreatLevel_THREAT_LEVEL_HIGH).Components); err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	_ = indexed.Delete("t1")
+	if got := filterIDs(t, indexed, high); !slices.Equal(got, []string{"t2"}) {
+		t.Fatalf("expected the index updated, got %v", got)
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	s := New(WithIndex(IndexKey{"threat", "level"}))
+	_, _ = s.Create(threatTrack(t, "t1", "radar-1", entityv1.ThreatLevel_THREAT_LEVEL_HIGH))
+
+	for _, f := range []*storev1.ComponentFilter{
+		{Component: "threat", Field: "level", Value: "HIGH"},
+		{Component: "threat", Op: storev1.FilterOp_FILTER_OP_EQ, Value: "HIGH"},
+		{Component: "threat", Field: "level", Op: storev1.FilterOp_FILTER_OP_EQ, Value: "SEVERE"},
+		{Component: "threat", Field: "colour", Op: storev1.FilterOp_FILTER_OP_EQ, Value: "red"},
+	} {
+		if _, err := s.Filter(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED, []*storev1.ComponentFilter{f}); err == nil {
+			t.Errorf("expected an error for %v", f)
+		}
+	}
+}
+
+func TestFilterUnknownFieldIndexed(t *testing.T) {
+	// An index configured on a field the component lacks answers like a scan.
+	indexed, scanned := New(WithIndex(IndexKey{"threat", "colour"})), New()
+	f := &storev1.ComponentFilter{Component: "threat", Field: "colour", Op: storev1.FilterOp_FILTER_OP_EQ, Value: "red"}
+	var errs []string
+	for _, s := range []*Store{indexed, scanned} {
+		_, _ = s.Create(threatTrack(t, "t1", "radar-1", entityv1.ThreatLevel_THREAT_LEVEL_HIGH))
+		_, err := s.Filter(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED, []*storev1.ComponentFilter{f})
+		if err == nil {
+			t.Fatalf("expected an error filtering on threat.colour")
+		}
+		errs = append(errs, err.Error())
+	}
+	if errs[0] != errs[1] {
+		t.Fatalf("indexed and scanned filters disagree: %q vs %q", errs[0], errs[1])
+	}
+}
+
+func TestParseIndexes(t *testing.T) {
+	keys, err := ParseIndexes(" threat.level, ,source.sensor_id")
+	if err != nil {
+		t.Fatalf("ParseIndexes: %v", err)
+	}
+	want := []IndexKey{{"threat", "level"}, {"source", "sensor_id"}}
+	if !slices.Equal(keys, want) {
+		t.Fatalf("got %v, want %v", keys, want)
+	}
+	if _, err := ParseIndexes("threat"); err == nil {
+		t.Fatal("expected an error for a key without a field")
+	}
+}
@@ -0,0 +1,152 @@
+package store
+
+import (
+	"fmt"
+	"math"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+)
+
+// cellBits is the index's depth per axis. Each cell is one 4-character
+// geohash: 10 bits of latitude and 10 of longitude, about 0.18° by 0.35°.
+const cellBits = 10
+
+// BBox is an inclusive latitude/longitude box for spatial queries. Boxes
+// crossing the antimeridian are not supported.
+type BBox struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// Validate checks the box is well formed and on the globe.
+func (b BBox) Validate() error {
+	switch {
+	case math.IsNaN(b.MinLat + b.MaxLat + b.MinLon + b.MaxLon):
+		return fmt.Errorf("bbox bounds must be numbers")
+	case b.MinLat < -90 || b.MaxLat > 90:
+		return fmt.Errorf("bbox latitude must be within [-90, 90]")
+	case b.MinLon < -180 || b.MaxLon > 180:
+		return fmt.Errorf("bbox longitude must be within [-180, 180]")
+	case b.MinLat > b.MaxLat:
+		return fmt.Errorf("bbox min_lat %v must not exceed max_lat %v", b.MinLat, b.MaxLat)
+	case b.MinLon > b.MaxLon:
+		return fmt.Errorf("bbox min_lon %v must not exceed max_lon %v", b.MinLon, b.MaxLon)
+	}
+	return nil
+}
+
+// Contains reports whether the point is inside the box, edges included.
+func (b BBox) Contains(lat, lon float64) bool {
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// ContainsEntity reports whether e has a position inside the box.
+func (b BBox) ContainsEntity(e *entityv1.Entity) bool {
+	p, ok := position(e)
+	return ok && b.Contains(p.lat, p.lon)
+}
+
+type point struct{ lat, lon float64 }
+
+type cell struct{ lat, lon uint32 }
+
+// geoIndex buckets entities with a position component by geohash cell, so
+// a box query visits only the cells it overlaps. Not safe for concurrent
+// use; the store guards it with mu.
+type geoIndex struct {
+	points map[string]point
+	cells  map[cell]map[string]struct{}
+}
+
+func newGeoIndex() *geoIndex {
+	return &geoIndex{
+		points: make(map[string]point),
+		cells:  make(map[cell]map[string]struct{}),
+	}
+}
+
+// set indexes e at its position, or drops it if it has none.
+func (g *geoIndex) set(e *entityv1.Entity) {
+	p, ok := position(e)
+	if !ok {
+		g.remove(e.Id)
+		return
+	}
+	if old, ok := g.points[e.Id]; ok {
+		if old == p {
+			return
+		}
+		g.unlink(e.Id, cellOf(old.lat, old.lon))
+	}
+	g.points[e.Id] = p
+	c := cellOf(p.lat, p.lon)
+	ids, ok := g.cells[c]
+	if !ok {
+		ids = make(map[string]struct{})
+		g.cells[c] = ids
+	}
+	ids[e.Id] = struct{}{}
+}
+
+func (g *geoIndex) remove(id string) {
+	if p, ok := g.points[id]; ok {
+		g.unlink(id, cellOf(p.lat, p.lon))
+		delete(g.points, id)
+	}
+}
+
+func (g *geoIndex) unlink(id string, c cell) {
+	delete(g.cells[c], id)
+	if len(g.cells[c]) == 0 {
+		delete(g.cells, c)
+	}
+}
+
+// query returns the IDs of entities inside b, in no particular order.
+func (g *geoIndex) query(b BBox) []string {
+	lo, hi := cellOf(b.MinLat, b.MinLon), cellOf(b.MaxLat, b.MaxLon)
+	var ids []string
+	// A box wider than the index is cheaper to answer by scanning it.
+	if n := uint64(hi.lat-lo.lat+1) * uint64(hi.lon-lo.lon+1); n > uint64(len(g.cells)) {
+		for id, p := range g.points {
+			if b.Contains(p.lat, p.lon) {
+				ids = append(ids, id)
+			}
+		}
+		return ids
+	}
+	for lat := lo.lat; lat <= hi.lat; lat++ {
+		for lon := lo.lon; lon <= hi.lon; lon++ {
+			for id := range g.cells[cell{lat, lon}] {
+				if p := g.points[id]; b.Contains(p.lat, p.lon) {
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// cellOf returns the cell holding a point, clamping it onto the globe.
+func cellOf(lat, lon float64) cell {
+	return cell{lat: axisCell(lat, 90), lon: axisCell(lon, 180)}
+}
+
+func axisCell(v, limit float64) uint32 {
+	const n = 1 << cellBits
+	i := math.Floor((v + limit) / (2 * limit) * n)
+	return uint32(max(0, min(i, n-1)))
+}
+
+// position reads an entity's position component.
+func position(e *entityv1.Entity) (point, bool) {
+	c, ok := e.Components["position"]
+	if !ok {
+		return point{}, false
+	}
+	pos := &entityv1.PositionComponent{}
+	if err := c.UnmarshalTo(pos); err != nil || math.IsNaN(pos.Lat) || math.IsNaN(pos.Lon) {
+		return point{}, false
+	}
+	return point{pos.Lat, pos.Lon}, true
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func positioned(t *testing.T, id string, lat, lon float64) *entityv1.Entity {
+	t.Helper()
+	pos, err := anypb.New(&entityv1.PositionComponent{Lat: lat, Lon: lon})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos}}
+}
+
+func ids(entities []*entityv1.Entity) []string {
+	out := make([]string, 0, len(entities))
+	for _, e := range entities {
+		out = append(out, e.Id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestQueryBBox_FollowsWrites(t *testing.T) {
+	s := New()
+	dc := BBox{MinLat: 38.8, MaxLat: 39.0, MinLon: -77.2, MaxLon: -76.9}
+
+	asset := positioned(t, "asset", 38.85, -77.1)
+	asset.Type = entityv1.EntityType_ENTITY_TYPE_ASSET
+	for _, e := range []*entityv1.Entity{
+		positioned(t, "in", 38.9, -77.0),
+		positioned(t, "edge", 39.0, -76.9),
+		positioned(t, "out", 40.7, -74.0),
+		{Id: "nowhere"},
+		asset,
+	} {
+		if _, err := s.Create(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got := ids(s.QueryBBox(dc, entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)); fmt.Sprint(got) != "[asset edge in]" {
+		t.Fatalf("expected asset, edge, in; got %v", got)
+	}
+	if got := ids(s.QueryBBox(dc, entityv1.EntityType_ENTITY_TYPE_TRACK)); fmt.Sprint(got) != "[edge in]" {
+		t.Fatalf("expected tracks edge, in; got %v", got)
+	}
+
+	// A move in, a move out, an update that keeps the position, a delete.
+	if _, err := s.Patch("out", positioned(t, "out", 38.95, -77.05).Components); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Patch("edge", positioned(t, "edge", 0, 0).Components); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Update(&entityv1.Entity{Id: "in"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("asset"); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(s.QueryBBox(dc, entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)); fmt.Sprint(got) != "[in out]" {
+		t.Fatalf("expected in, out after moves; got %v", got)
+	}
+}
+
+func TestGeoIndex_MatchesScan(t *testing.T) {
+	g := newGeoIndex()
+	rng := rand.New(rand.NewPCG(1, 2))
+	points := map[string]point{}
+	for i := range 2000 {
+		id := fmt.Sprintf("e%d", i)
+		lat, lon := rng.Float64()*180-90, rng.Float64()*360-180
+		if i%2 == 0 { // cluster half of them
+			lat, lon = 38+rng.Float64(), -77+rng.Float64()
+		}
+		points[id] = point{lat, lon}
+		g.set(&entityv1.Entity{Id: id, Components: positioned(t, id, lat, lon).Components})
+	}
+
+	for _, b := range []BBox{
+		{MinLat: 38.2, MaxLat: 38.6, MinLon: -76.8, MaxLon: -76.1},
+		{MinLat: -90, MaxLat: 90, MinLon: -180, MaxLon: 180},
+		{MinLat: 10, MaxLat: 10, MinLon: 20, MaxLon: 20},
+		{MinLat: -30, MaxLat: 5, MinLon: 100, MaxLon: 180},
+	} {
+		var want []string
+		for id, p := range points {
+			if b.Contains(p.lat, p.lon) {
+				want = append(want, id)
+			}
+		}
+		got := g.query(b)
+		sort.Strings(want)
+		sort.Strings(got)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Fatalf("%+v: index found %d, scan %d", b, len(got), len(want))
+		}
+	}
+}
+
+func TestBBox_Validate(t *testing.T) {
+	for name, b := range map[string]BBox{
+		"lat range":    {MinLat: -91, MaxLat: 0},
+		"lon range":    {MinLon: 0, MaxLon: 181},
+		"inverted lat": {MinLat: 1, MaxLat: 0},
+		"inverted lon": {MinLon: 1, MaxLon: 0},
+	} {
+		if err := b.Validate(); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestQueryBBox_AfterRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.wal")
+	if _, err := openTest(t, path).Create(positioned(t, "t1", 38.9, -77.0)); err != nil {
+		t.Fatal(err)
+	}
+	got := openTest(t, path).QueryBBox(BBox{MinLat: 38, MaxLat: 39, MinLon: -78, MaxLon: -77}, entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	if len(got) != 1 {
+		t.Fatalf("expected the recovered track indexed, got %d", len(got))
+	}
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"slices"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/proto"
+)
+
+// maxDeletedHistories bounds how many deleted entities keep their history;
+// the one deleted longest ago is dropped first.
+const maxDeletedHistories = 1024
+
+// history keeps the last versions of each entity, ordered by HLC. Not safe
+// for concurrent use; the store guards it with mu.
+type history struct {
+	depth    int
+	versions map[string][]*storev1.EntityEvent // oldest first
+	deleted  []string                          // deleted IDs, oldest first
+}
+
+func newHistory(depth int) *history {
+	return &history{depth: depth, versions: make(map[string][]*storev1.EntityEvent)}
+}
+
+// record adds the version a write produced. A version older than every
+// one kept in a full buffer is dropped; otherwise the oldest is.
+func (h *history) record(typ storev1.EventType, e *entityv1.Entity) {
+	v := &storev1.EntityEvent{Type: typ, Entity: proto.Clone(e).(*entityv1.Entity)}
+	vs := h.versions[e.Id]
+	i, _ := slices.BinarySearchFunc(vs, v, func(a, b *storev1.EntityEvent) int {
+		return hlc.Compare(eventHLC(a), eventHLC(b))
+	})
+	if len(vs) == h.depth {
+		if i == 0 {
+			return
+		}
+		vs = slices.Delete(vs, 0, 1)
+		i--
+	}
+	h.versions[e.Id] = slices.Insert(vs, i, v)
+
+	if removed(typ) {
+		h.deleted = append(h.deleted, e.Id)
+		if len(h.deleted) > maxDeletedHistories {
+			id := h.deleted[0]
+			h.deleted = slices.Delete(h.deleted, 0, 1)
+			if last := h.versions[id]; len(last) > 0 && removed(last[len(last)-1].Type) {
+				delete(h.versions, id) // still deleted, not recreated since
+			}
+		}
+	}
+}
+
+// get returns copies of an entity's versions, oldest first.
+func (h *history) get(id string) []*storev1.EntityEvent {
+	vs := h.versions[id]
+	out := make([]*storev1.EntityEvent, len(vs))
+	for i, v := range vs {
+		out[i] = proto.Clone(v).(*storev1.EntityEvent)
+	}
+	return out
+}
+
+func eventHLC(v *storev1.EntityEvent) hlc.Timestamp {
+	e := v.Entity
+	return hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
+}
+
+// removed reports whether an event of type typ removed its entity.
+func removed(typ storev1.EventType) bool {
+	return typ == storev1.EventType_EVENT_TYPE_DELETED || typ == storev1.EventType_EVENT_TYPE_EXPIRED
+}
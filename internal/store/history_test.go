@@ -0,0 +1,92 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestHistory_KeepsLastVersions(t *testing.T) {
+	s := New(WithNodeID("hist"), WithHistory(3))
+	if _, err := s.Create(&entityv1.Entity{Id: "t1"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 3 {
+		if _, err := s.Patch("t1", map[string]*anypb.Any{"label": makeAnyString(t, fmt.Sprint(i))}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Delete("t1"); err != nil {
+		t.Fatal(err)
+	}
+
+	vs := s.History("t1")
+	if len(vs) != 3 {
+		t.Fatalf("expected 3 versions, got %d", len(vs))
+	}
+	for i := 1; i < len(vs); i++ {
+		if !hlcOf(vs[i].Entity).After(hlcOf(vs[i-1].Entity)) {
+			t.Fatalf("expected versions in HLC order, got %v then %v", hlcOf(vs[i-1].Entity), hlcOf(vs[i].Entity))
+		}
+	}
+	if last := vs[2]; last.Type != storev1.EventType_EVENT_TYPE_DELETED {
+		t.Fatalf("expected the delete last, got %v", last.Type)
+	}
+	var label wrapperspb.StringValue
+	if err := vs[1].Entity.Components["label"].UnmarshalTo(&label); err != nil || label.Value != "2" {
+		t.Fatalf("expected the last patch before the delete, got %q", label.Value)
+	}
+}
+
+func TestHistory_OrdersByHLC(t *testing.T) {
+	h := newHistory(2)
+	at := func(physical uint64) *entityv1.Entity {
+		return &entityv1.Entity{Id: "t1", HlcPhysical: physical}
+	}
+	h.record(storev1.EventType_EVENT_TYPE_UPDATED, at(20))
+	h.record(storev1.EventType_EVENT_TYPE_UPDATED, at(10)) // late arrival
+	h.record(storev1.EventType_EVENT_TYPE_UPDATED, at(5))  // older than all kept
+	h.record(storev1.EventType_EVENT_TYPE_UPDATED, at(15))
+
+	var got []uint64
+	for _, v := range h.get("t1") {
+		got = append(got, v.Entity.HlcPhysical)
+	}
+	if fmt.Sprint(got) != "[15 20]" {
+		t.Fatalf("expected [15 20], got %v", got)
+	}
+}
+
+func TestHistory_Disabled(t *testing.T) {
+	s := New()
+	s.Create(&entityv1.Entity{Id: "t1"}) //nolint:errcheck
+	if vs := s.History("t1"); len(vs) != 0 {
+		t.Fatalf("expected no history by default, got %d versions", len(vs))
+	}
+}
+
+func TestHistory_ReplayedFromWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.wal")
+	s, err := Open(path, WithHistory(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	created, _ := s.Create(&entityv1.Entity{Id: "t1"})
+	s.Close()
+
+	r, err := Open(path, WithHistory(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	vs := r.History("t1")
+	if len(vs) != 1 || hlc.Compare(hlcOf(vs[0].Entity), hlcOf(created)) != 0 {
+		t.Fatalf("expected the logged create in history, got %v", vs)
+	}
+}
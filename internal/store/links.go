@@ -0,0 +1,126 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+)
+
+// ErrLinkNotFound is returned by RemoveLink for a link the store does not
+// hold.
+var ErrLinkNotFound = errors.New("link not found")
+
+// Link is a directed relationship between two entities, e.g. a fused
+// track to each of its source tracks. With Cascade set, From depends on
+// To: deleting To deletes From too.
+type Link struct {
+	From, To string
+	Relation string
+	Cascade  bool
+}
+
+type linkKey struct{ from, to, relation string }
+
+// AddLink records l between two existing entities. Adding a link the store
+// already holds updates its Cascade flag. Links are not logged: a store
+// recovered from its log holds none until they are added again.
+func (s *Store) AddLink(l Link) error {
+	if l.From == "" || l.To == "" || l.Relation == "" {
+		return fmt.Errorf("link needs from, to, and a relation")
+	}
+	if l.From == l.To {
+		return fmt.Errorf("entity %q cannot link to itself", l.From)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range []string{l.From, l.To} {
+		if _, ok := s.entities[id]; !ok {
+			return fmt.Errorf("entity %q not found", id)
+		}
+	}
+	k := linkKey{l.From, l.To, l.Relation}
+	for _, id := range []string{l.From, l.To} {
+		if s.links[id] == nil {
+			s.links[id] = make(map[linkKey]bool)
+		}
+		s.links[id][k] = l.Cascade
+	}
+	return nil
+}
+
+// RemoveLink removes the link from → to with the given relation.
+func (s *Store) RemoveLink(from, to, relation string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := linkKey{from, to, relation}
+	if _, ok := s.links[from][k]; !ok {
+		return fmt.Errorf("%s -%s-> %s: %w", from, relation, to, ErrLinkNotFound)
+	}
+	s.dropLink(k)
+	return nil
+}
+
+// Links returns the links of an entity in the given direction, all of
+// them for UNSPECIFIED, optionally only those with relation, ordered by
+// from, to, and relation.
+func (s *Store) Links(id, relation string, dir storev1.LinkDirection) []Link {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var out []Link
+	for k, cascade := range s.links[id] {
+		switch {
+		case relation != "" && k.relation != relation:
+		case dir == storev1.LinkDirection_LINK_DIRECTION_OUTGOING && k.from != id:
+		case dir == storev1.LinkDirection_LINK_DIRECTION_INCOMING && k.to != id:
+		default:
+			out = append(out, Link{From: k.from, To: k.to, Relation: k.relation, Cascade: cascade})
+		}
+	}
+	slices.SortFunc(out, func(a, b Link) int {
+		return strings.Compare(a.From+"\x00"+a.To+"\x00"+a.Relation, b.From+"\x00"+b.To+"\x00"+b.Relation)
+	})
+	return out
+}
+
+// dropLink removes k from both its ends. Must hold mu.
+func (s *Store) dropLink(k linkKey) {
+	for _, id := range []string{k.from, k.to} {
+		delete(s.links[id], k)
+		if len(s.links[id]) == 0 {
+			delete(s.links, id)
+		}
+	}
+}
+
+// unlinkLocked drops every link of a removed entity and deletes the
+// entities that depended on it through a cascading link, and so on down
+// the chain. Must hold mu.
+func (s *Store) unlinkLocked(id string) {
+	var dependents []string
+	for k, cascade := range s.links[id] {
+		if cascade && k.to == id {
+			dependents = append(dependents, k.from)
+		}
+		s.dropLink(k)
+	}
+	slices.Sort(dependents)
+	for _, dep := range slices.Compact(dependents) {
+		e, ok := s.entities[dep]
+		if !ok {
+			continue
+		}
+		delete(s.ttls, dep)
+		ts, err := s.clock.Next()
+		if err == nil {
+			err = s.removeLocked(e, ts, storev1.EventType_EVENT_TYPE_DELETED)
+		}
+		if err != nil {
+			slog.Error("cascade delete failed", "id", dep, "cause", id, "error", err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+)
+
+func TestLinks(t *testing.T) {
+	s := New()
+	for _, id := range []string{"fused", "t1", "t2", "asset"} {
+		_, _ = s.Create(&entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	}
+	for _, l := range []Link{
+		{From: "fused", To: "t1", Relation: "fused_from", Cascade: true},
+		{From: "fused", To: "t2", Relation: "fused_from", Cascade: true},
+		{From: "asset", To: "t1", Relation: "assigned_to"},
+	} {
+		if err := s.AddLink(l); err != nil {
+			t.Fatalf("AddLink %v: %v", l, err)
+		}
+	}
+	if err := s.AddLink(Link{From: "fused", To: "missing", Relation: "fused_from"}); err == nil {
+		t.Fatal("expected an error linking to a missing entity")
+	}
+	if err := s.AddLink(Link{From: "t1", To: "t1", Relation: "self"}); err == nil {
+		t.Fatal("expected an error linking an entity to itself")
+	}
+
+	if got := s.Links("t1", "", storev1.LinkDirection_LINK_DIRECTION_INCOMING); len(got) != 2 || got[0].From != "asset" || got[1].From != "fused" {
+		t.Fatalf("expected links from asset and fused into t1, got %v", got)
+	}
+	if got := s.Links("fused", "fused_from", storev1.LinkDirection_LINK_DIRECTION_OUTGOING); len(got) != 2 || !got[0].Cascade {
+		t.Fatalf("expected fused's two cascading source links, got %v", got)
+	}
+	if got := s.Links("fused", "", storev1.LinkDirection_LINK_DIRECTION_INCOMING); len(got) != 0 {
+		t.Fatalf("expected no links into fused, got %v", got)
+	}
+
+	if err := s.RemoveLink("fused", "t2", "fused_from"); err != nil {
+		t.Fatalf("RemoveLink: %v", err)
+	}
+	if err := s.RemoveLink("fused", "t2", "fused_from"); !errors.Is(err, ErrLinkNotFound) {
+		t.Fatalf("expected ErrLinkNotFound, got %v", err)
+	}
+	if got := s.Links("t2", "", storev1.LinkDirection_LINK_DIRECTION_UNSPECIFIED); len(got) != 0 {
+		t.Fatalf("expected t2 unlinked, got %v", got)
+	}
+}
+
+func TestLinksCascade(t *testing.T) {
+	s := New()
+	for _, id := range []string{"t1", "fused", "derived", "asset"} {
+		_, _ = s.Create(&entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	}
+	_ = s.AddLink(Link{From: "fused", To: "t1", Relation: "fused_from", Cascade: true})
+	_ = s.AddLink(Link{From: "derived", To: "fused", Relation: "fused_from", Cascade: true})
+	_ = s.AddLink(Link{From: "asset", To: "t1", Relation: "assigned_to"})
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	defer s.Unwatch(w)
+
+	if err := s.Delete("t1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	for _, want := range []string{"t1", "fused", "derived"} {
+		event := <-w.Events
+		if event.Type != storev1.EventType_EVENT_TYPE_DELETED || event.Entity.Id != want {
+			t.Fatalf("expected %s deleted, got %v", want, event)
+		}
+	}
+	if _, err := s.Get("asset"); err != nil {
+		t.Fatalf("expected the asset kept by a non-cascading link: %v", err)
+	}
+	if got := s.Links("asset", "", storev1.LinkDirection_LINK_DIRECTION_UNSPECIFIED); len(got) != 0 {
+		t.Fatalf("expected the deleted track's links dropped, got %v", got)
+	}
+}
@@ -0,0 +1,130 @@
+package store
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+)
+
+// counters are the store's running totals, reported by Stats. Guarded by
+// mu.
+type counters struct {
+	events          map[storev1.EventType]uint64
+	dropped         uint64 // events not delivered to a full watcher queue
+	staleComponents uint64 // update components kept over an older incoming value
+	conflicts       uint64 // conditional updates refused, ErrConflict
+	buried          uint64 // writes refused as older than a delete, ErrDeleted
+	overQuota       uint64 // creates refused by a type's quota, ErrQuota
+}
+
+// WatcherStats is one watcher's queue.
+type WatcherStats struct {
+	Filter   entityv1.EntityType
+	Queued   int // events waiting to be received
+	Capacity int
+}
+
+// Stats is a snapshot of the store's size and activity since it started.
+type Stats struct {
+	Entities        map[entityv1.EntityType]int
+	Tombstones      int
+	Archived        int
+	Events          map[storev1.EventType]uint64 // emitted, by type
+	Dropped         uint64                       // events a full watcher queue missed
+	StaleComponents uint64                       // incoming components older than the stored ones, ignored
+	Conflicts       uint64                       // conditional updates refused
+	Buried          uint64                       // writes refused as older than a delete
+	OverQuota       uint64                       // creates refused by a type's quota
+	Watchers        []WatcherStats
+}
+
+// Stats returns the store's current counts and totals.
+func (s *Store) Stats() Stats {
+	s.mu.RLock()
+	st := Stats{
+		Entities:        make(map[entityv1.EntityType]int),
+		Tombstones:      len(s.tombstones),
+		Archived:        len(s.archive),
+		Events:          make(map[storev1.EventType]uint64, len(s.stats.events)),
+		Dropped:         s.stats.dropped,
+		StaleComponents: s.stats.staleComponents,
+		Conflicts:       s.stats.conflicts,
+		Buried:          s.stats.buried,
+		OverQuota:       s.stats.overQuota,
+	}
+	for _, e := range s.entities {
+		st.Entities[e.Type]++
+	}
+	for typ, n := range s.stats.events {
+		st.Events[typ] = n
+	}
+	s.mu.RUnlock()
+
+	s.watchMu.RLock()
+	defer s.watchMu.RUnlock()
+	for _, w := range s.watchers {
+		st.Watchers = append(st.Watchers, WatcherStats{Filter: w.Filter, Queued: len(w.Events), Capacity: cap(w.Events)})
+	}
+	return st
+}
+
+// MetricsHandler serves the store's Stats as Prometheus text metrics.
+func (s *Store) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		s.Stats().WriteMetrics(w)
+	})
+}
+
+// WriteMetrics writes st in the Prometheus text format.
+func (st Stats) WriteMetrics(w io.Writer) {
+	metric := func(name, typ, help string) {
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+
+	metric("lattice_store_entities", "gauge", "Entities held, by type.")
+	for _, typ := range slices.Sorted(maps.Keys(entityv1.EntityType_name)) {
+		t := entityv1.EntityType(typ)
+		if t == entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED && st.Entities[t] == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "lattice_store_entities{type=%q} %d\n", t, st.Entities[t])
+	}
+	metric("lattice_store_tombstones", "gauge", "Deletes remembered against stale copies.")
+	fmt.Fprintf(w, "lattice_store_tombstones %d\n", st.Tombstones)
+	metric("lattice_store_archived_entities", "gauge", "Removed entities kept for ListArchivedEntities.")
+	fmt.Fprintf(w, "lattice_store_archived_entities %d\n", st.Archived)
+	metric("lattice_store_events_total", "counter", "Events emitted to watchers, by type.")
+	for _, typ := range slices.Sorted(maps.Keys(storev1.EventType_name)) {
+		if t := storev1.EventType(typ); t != storev1.EventType_EVENT_TYPE_UNSPECIFIED {
+			fmt.Fprintf(w, "lattice_store_events_total{type=%q} %d\n", t, st.Events[t])
+		}
+	}
+	metric("lattice_store_dropped_events_total", "counter", "Events not delivered because a watcher's queue was full.")
+	fmt.Fprintf(w, "lattice_store_dropped_events_total %d\n", st.Dropped)
+	metric("lattice_store_stale_components_total", "counter", "Update components ignored as older than the stored ones.")
+	fmt.Fprintf(w, "lattice_store_stale_components_total %d\n", st.StaleComponents)
+	metric("lattice_store_conflicts_total", "counter", "Conditional updates refused because the entity changed.")
+	fmt.Fprintf(w, "lattice_store_conflicts_total %d\n", st.Conflicts)
+	metric("lattice_store_refused_deleted_total", "counter", "Writes refused as older than the entity's deletion.")
+	fmt.Fprintf(w, "lattice_store_refused_deleted_total %d\n", st.Buried)
+	metric("lattice_store_refused_quota_total", "counter", "Creates refused because their entity type was at its quota.")
+	fmt.Fprintf(w, "lattice_store_refused_quota_total %d\n", st.OverQuota)
+
+	var queued, deepest int
+	for _, wt := range st.Watchers {
+		queued += wt.Queued
+		deepest = max(deepest, wt.Queued)
+	}
+	metric("lattice_store_watchers", "gauge", "Open watches.")
+	fmt.Fprintf(w, "lattice_store_watchers %d\n", len(st.Watchers))
+	metric("lattice_store_watcher_queued_events", "gauge", "Events waiting in watcher queues, summed over watchers.")
+	fmt.Fprintf(w, "lattice_store_watcher_queued_events %d\n", queued)
+	metric("lattice_store_watcher_max_queued_events", "gauge", "Events waiting in the fullest watcher queue.")
+	fmt.Fprintf(w, "lattice_store_watcher_max_queued_events %d\n", deepest)
+}
@@ -0,0 +1,71 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestStats(t *testing.T) {
+	s := New()
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	defer s.Unwatch(w)
+
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1})
+	created, _ := s.Create(&entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos}})
+	_, _ = s.Create(&entityv1.Entity{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET})
+
+	// An update without an HLC is older than the stored position.
+	if _, err := s.Update(&entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"position": pos}}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	stale := hlc.Timestamp{Physical: created.HlcPhysical, Logical: created.HlcLogical, Node: created.HlcNode}
+	if _, err := s.UpdateIf(created, stale); !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	_ = s.Delete("a1")
+	if _, err := s.Create(&entityv1.Entity{Id: "a1", HlcPhysical: 1, HlcNode: "old"}); !errors.Is(err, ErrDeleted) {
+		t.Fatalf("expected ErrDeleted, got %v", err)
+	}
+
+	// Overflow the watcher's queue.
+	for i := range cap(w.Events) {
+		_, _ = s.Patch("t1", map[string]*anypb.Any{fmt.Sprintf("c%d", i): proto.Clone(pos).(*anypb.Any)})
+	}
+
+	st := s.Stats()
+	if st.Entities[entityv1.EntityType_ENTITY_TYPE_TRACK] != 1 || st.Entities[entityv1.EntityType_ENTITY_TYPE_ASSET] != 0 {
+		t.Fatalf("unexpected entity counts %v", st.Entities)
+	}
+	if st.Events[storev1.EventType_EVENT_TYPE_CREATED] != 2 || st.Events[storev1.EventType_EVENT_TYPE_DELETED] != 1 {
+		t.Fatalf("unexpected event counts %v", st.Events)
+	}
+	if st.StaleComponents != 1 || st.Conflicts != 1 || st.Buried != 1 || st.Tombstones != 1 || st.Archived != 1 {
+		t.Fatalf("unexpected merge stats %+v", st)
+	}
+	if len(st.Watchers) != 1 || st.Watchers[0].Queued != cap(w.Events) || st.Dropped == 0 {
+		t.Fatalf("expected a full watcher queue and drops, got %+v", st)
+	}
+
+	var b strings.Builder
+	st.WriteMetrics(&b)
+	for _, want := range []string{
+		`lattice_store_entities{type="ENTITY_TYPE_TRACK"} 1`,
+		`lattice_store_events_total{type="EVENT_TYPE_EXPIRED"} 0`,
+		"lattice_store_conflicts_total 1",
+		"lattice_store_archived_entities 1",
+		"# TYPE lattice_store_dropped_events_total counter",
+		fmt.Sprintf("lattice_store_watcher_max_queued_events %d", cap(w.Events)),
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, b.String())
+		}
+	}
+}
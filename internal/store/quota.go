@@ -0,0 +1,91 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+)
+
+// ErrQuota is returned by a create that would take its entity type past
+// the store's quota for it.
+var ErrQuota = errors.New("entity quota exceeded")
+
+// QuotaError is an ErrQuota naming the type and its quota.
+type QuotaError struct {
+	Type  entityv1.EntityType
+	Limit int
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%v: %s is limited to %d", ErrQuota, e.Type, e.Limit)
+}
+
+func (e *QuotaError) Unwrap() error { return ErrQuota }
+
+// ParseQuotas parses a comma-separated list of type=limit pairs, e.g.
+// "track=5000,geo=200". Types are named as in EntityType without the
+// ENTITY_TYPE_ prefix, in any case.
+func ParseQuotas(s string) (map[entityv1.EntityType]int, error) {
+	quotas := make(map[entityv1.EntityType]int)
+	for _, term := range strings.Split(s, ",") {
+		if term = strings.TrimSpace(term); term == "" {
+			continue
+		}
+		name, limit, ok := strings.Cut(term, "=")
+		if !ok {
+			return nil, fmt.Errorf("quota %q: want type=limit", term)
+		}
+		typ, ok := entityv1.EntityType_value["ENTITY_TYPE_"+strings.ToUpper(strings.TrimSpace(name))]
+		if !ok || typ == int32(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED) {
+			return nil, fmt.Errorf("quota %q: unknown entity type %q", term, name)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(limit))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("quota %q: limit must be a non-negative integer", term)
+		}
+		quotas[entityv1.EntityType(typ)] = n
+	}
+	return quotas, nil
+}
+
+// WithQuotas limits how many entities of each type the store holds:
+// creates past the limit fail with ErrQuota. Types without a quota are
+// unlimited. Only creates are refused; updates, restores, and recovery
+// from the log are not, so a store can hold more than its quota after a
+// restart with a lower one.
+func WithQuotas(quotas map[entityv1.EntityType]int) Option {
+	return func(s *Store) { s.quotas = quotas }
+}
+
+// checkQuota returns ErrQuota if adding n entities of type typ would
+// exceed its quota. Must hold mu.
+func (s *Store) checkQuota(typ entityv1.EntityType, n int) error {
+	limit, ok := s.quotas[typ]
+	if !ok || s.typeCounts[typ]+n <= limit {
+		return nil
+	}
+	s.stats.overQuota++
+	return &QuotaError{Type: typ, Limit: limit}
+}
+
+// countType records e's type for the per-type counts, replacing the type
+// it was counted under before. Must hold mu.
+func (s *Store) countType(e *entityv1.Entity) {
+	if old, ok := s.types[e.Id]; ok {
+		s.typeCounts[old]--
+	}
+	s.types[e.Id] = e.Type
+	s.typeCounts[e.Type]++
+}
+
+// uncountType drops a removed entity from the per-type counts. Must hold
+// mu.
+func (s *Store) uncountType(id string) {
+	if old, ok := s.types[id]; ok {
+		s.typeCounts[old]--
+		delete(s.types, id)
+	}
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+)
+
+func TestParseQuotas(t *testing.T) {
+	quotas, err := ParseQuotas(" track=2, GEO=0 ,")
+	if err != nil {
+		t.Fatalf("ParseQuotas: %v", err)
+	}
+	if len(quotas) != 2 || quotas[entityv1.EntityType_ENTITY_TYPE_TRACK] != 2 || quotas[entityv1.EntityType_ENTITY_TYPE_GEO] != 0 {
+		t.Fatalf("unexpected quotas %v", quotas)
+	}
+	for _, bad := range []string{"track", "ship=1", "unspecified=1", "track=-1", "track=many"} {
+		if _, err := ParseQuotas(bad); err == nil {
+			t.Errorf("ParseQuotas(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestQuota(t *testing.T) {
+	s := New(WithQuotas(map[entityv1.EntityType]int{entityv1.EntityType_ENTITY_TYPE_TRACK: 2}))
+	track := func(id string) *entityv1.Entity {
+		return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK}
+	}
+	for _, id := range []string{"t1", "t2"} {
+		if _, err := s.Create(track(id)); err != nil {
+			t.Fatalf("Create %s: %v", id, err)
+		}
+	}
+	if _, err := s.Create(track("t3")); !errors.Is(err, ErrQuota) {
+		t.Fatalf("expected ErrQuota, got %v", err)
+	}
+	if _, err := s.Create(&entityv1.Entity{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET}); err != nil {
+		t.Fatalf("expected types without a quota unlimited, got %v", err)
+	}
+
+	// A delete frees room; a transaction counts its own deletes and creates.
+	_ = s.Delete("t1")
+	if _, _, err := s.Transact(nil, []Write{
+		{Op: OpCreate, Entity: track("t3")},
+		{Op: OpCreate, Entity: track("t4")},
+	}); !errors.Is(err, ErrQuota) {
+		t.Fatalf("expected the second create to exceed the quota, got %v", err)
+	}
+	if _, _, err := s.Transact(nil, []Write{
+		{Op: OpDelete, Entity: &entityv1.Entity{Id: "t2"}},
+		{Op: OpCreate, Entity: track("t3")},
+		{Op: OpCreate, Entity: track("t4")},
+	}); err != nil {
+		t.Fatalf("Transact: %v", err)
+	}
+
+	// An update that changes an entity's type moves it between counts.
+	if _, err := s.Update(&entityv1.Entity{Id: "t3", Type: entityv1.EntityType_ENTITY_TYPE_GEO}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if _, err := s.Create(track("t5")); err != nil {
+		t.Fatalf("Create t5: %v", err)
+	}
+	if got := s.Stats().OverQuota; got != 2 {
+		t.Fatalf("expected 2 creates refused, got %d", got)
+	}
+}
@@ -2,34 +2,142 @@ package store
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"math/rand"
+	"slices"
 	"sync"
 	"time"
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/crdt"
 	"github.com/boshu2/lattice-lab/internal/hlc"
 	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
+// eventBacklog is how many recent events the store keeps for watchers
+// resuming with WatchFrom.
+const eventBacklog = 4096
+
+// ErrResumeGap is returned by WatchFrom when the events after the resume
+// point are no longer held: too many have happened since, or the sequence
+// is from before the store restarted.
+var ErrResumeGap = errors.New("events since the resume point are no longer held")
+
+// ErrConflict is returned by UpdateIf when the entity has been written
+// since the caller read it.
+var ErrConflict = errors.New("entity has changed since it was read")
+
+// ConflictError is an ErrConflict with what changed: the version the
+// caller expected, the entity's current one, and the keys of the
+// components written since the expected version, sorted.
+type ConflictError struct {
+	ID                string
+	Expected, Current hlc.Timestamp
+	Stale             []string
+}
+
+func (e *ConflictError) Error() string { return ErrConflict.Error() }
+
+func (e *ConflictError) Unwrap() error { return ErrConflict }
+
+// errConflict returns the ConflictError of a write to e that expected it to
+// be at expected.
+func errConflict(e *entityv1.Entity, expected hlc.Timestamp) *ConflictError {
+	err := &ConflictError{
+		ID:       e.Id,
+		Expected: expected,
+		Current:  hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode},
+	}
+	for _, key := range slices.Sorted(maps.Keys(e.Components)) {
+		if componentHLC(e, key).After(expected) {
+			err.Stale = append(err.Stale, key)
+		}
+	}
+	return err
+}
+
+// ExistsError is returned by a create of an ID the store already holds,
+// with the HLC of the entity that holds it.
+type ExistsError struct {
+	ID  string
+	HLC hlc.Timestamp
+}
+
+func (e *ExistsError) Error() string { return fmt.Sprintf("entity %q already exists", e.ID) }
+
+// errExists returns the ExistsError of a create colliding with e.
+func errExists(e *entityv1.Entity) *ExistsError {
+	return &ExistsError{ID: e.Id, HLC: hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}}
+}
+
 // Watcher receives entity events via a channel.
 type Watcher struct {
-	Filter entityv1.EntityType
-	Events chan *storev1.EntityEvent
+	Filter  entityv1.EntityType
+	Events  chan *storev1.EntityEvent
+	Backlog []*storev1.EntityEvent // missed events to deliver before Events, from WatchFrom
 }
 
 // Store is a thread-safe in-memory entity store.
 type Store struct {
 	mu       sync.RWMutex
 	entities map[string]*entityv1.Entity
-	ttls     map[string]time.Time // entity ID → expiry time
+	ttls     map[string]time.Time     // entity ID → expiry time
+	geo      *geoIndex                // entities by position, for QueryBBox
+	digest   *digestTree              // hashes of the entities, for DigestEntities
+	fields   map[IndexKey]*fieldIndex // entities by component field, for Filter
+	history  *history                 // nil unless WithHistory
 	clock    *hlc.Clock
+	wal      *wal // nil for a purely in-memory store
+	walSync  bool
+	seq      uint64                 // sequence of the last event
+	backlog  []*storev1.EntityEvent // the last eventBacklog events, oldest first
+	origin   string                 // origin_node of the write in progress
+	hops     uint32                 // hops of the write in progress
+	path     []string               // path of the write in progress
+
+	// Deleted entities, so stale copies are refused; no ID is in both.
+	tombstones   map[string]tombstone
+	tombstoneTTL time.Duration
+
+	// How long removed components' tombstones are kept in their entities.
+	componentTombstoneTTL time.Duration
+
+	strategies *crdt.Registry // resolves merged components
+
+	// Bounds on the clock's drift, from WithMaxDrift.
+	maxAhead   time.Duration
+	maxLogical uint32
+
+	// Removed entities by ID, kept for Archived; no ID is also live.
+	archive          map[string]archived
+	archiveRetention time.Duration
+
+	// Entity types by ID and entities per type, for quotas.
+	types      map[string]entityv1.EntityType
+	typeCounts map[entityv1.EntityType]int
+	quotas     map[entityv1.EntityType]int // nil unless WithQuotas
+
+	// Component checks by message name, run on every write but replay.
+	validators map[protoreflect.FullName][]Validator
+
+	// Links under each end's ID, mapped to their cascade flag.
+	links map[string]map[linkKey]bool
+
+	stats counters
+
+	// Change records for the events in backlog, for Changes.
+	changes []*storev1.ChangeRecord
 
 	watchMu  sync.RWMutex
 	watchers []*Watcher
+	feeds    []*ChangeFeed
 }
 
 // Option configures a Store.
@@ -40,12 +148,80 @@ func WithNodeID(id string) Option {
 	return func(s *Store) { s.clock = hlc.NewClock(id) }
 }
 
+// WithClock makes the store stamp writes with c, so callers can skew it.
+func WithClock(c *hlc.Clock) Option {
+	return func(s *Store) { s.clock = c }
+}
+
+// DefaultMaxDrift is how far ahead of the wall clock the entity-store and
+// lattice-lab let a store's clock run unless configured otherwise: well
+// past the skew of NTP-synced nodes, but short enough that one client or
+// peer with a fast clock cannot push every later write far ahead.
+const DefaultMaxDrift = time.Minute
+
+// WithMaxDrift makes the store refuse writes, and replicated copies, that
+// its clock would stamp more than ahead of the wall clock or past logical
+// on its counter; see hlc.Clock.SetMaxDrift. They fail wrapping
+// hlc.ErrDrift. Zero leaves either unbounded.
+func WithMaxDrift(ahead time.Duration, logical uint32) Option {
+	return func(s *Store) {
+		s.maxAhead, s.maxLogical = ahead, logical
+	}
+}
+
+// WithSync makes a store opened with Open fsync its log after every write,
+// so acknowledged writes survive power loss as well as a killed process.
+func WithSync() Option {
+	return func(s *Store) { s.walSync = true }
+}
+
+// WithStrategies resolves the components of replicated copies merged into
+// the store with r's strategies rather than crdt.Default's; nil keeps the
+// default.
+func WithStrategies(r *crdt.Registry) Option {
+	return func(s *Store) {
+		if r != nil {
+			s.strategies = r
+		}
+	}
+}
+
+// WithHistory keeps the last depth versions of each entity, including its
+// deletion, for History.
+func WithHistory(depth int) Option {
+	return func(s *Store) {
+		if depth > 0 {
+			s.history = newHistory(depth)
+		}
+	}
+}
+
 // New creates an empty entity store. Options can configure the HLC node ID;
 // if none is provided a random node ID is generated.
 func New(opts ...Option) *Store {
 	s := &Store{
 		entities: make(map[string]*entityv1.Entity),
 		ttls:     make(map[string]time.Time),
+		geo:      newGeoIndex(),
+		digest:   newDigestTree(),
+		fields:   make(map[IndexKey]*fieldIndex),
+		links:    make(map[string]map[linkKey]bool),
+		// Sequences start from the clock, so ones handed out before a
+		// restart always fall before the backlog.
+		seq:                   uint64(time.Now().UnixNano()),
+		tombstones:            make(map[string]tombstone),
+		tombstoneTTL:          DefaultTombstoneTTL,
+		componentTombstoneTTL: DefaultTombstoneTTL,
+		strategies:            crdt.Default,
+		archive:               make(map[string]archived),
+		types:                 make(map[string]entityv1.EntityType),
+		typeCounts:            make(map[entityv1.EntityType]int),
+		validators:            make(map[protoreflect.FullName][]Validator),
+		archiveRetention:      DefaultArchiveRetention,
+		stats:                 counters{events: make(map[storev1.EventType]uint64)},
+	}
+	for name, v := range defaultValidators {
+		s.validators[name] = []Validator{v}
 	}
 	for _, opt := range opts {
 		opt(s)
@@ -53,19 +229,110 @@ func New(opts ...Option) *Store {
 	if s.clock == nil {
 		s.clock = hlc.NewClock(fmt.Sprintf("node-%d", rand.Int63()))
 	}
+	if s.maxAhead > 0 || s.maxLogical > 0 {
+		s.clock.SetMaxDrift(s.maxAhead, s.maxLogical)
+	}
 	return s
 }
 
-// SetTTL sets a time-to-live for an entity. The entity will be automatically
-// deleted after the TTL expires (requires StartReaper to be running).
+// Open creates a store backed by the write-ahead log at path, replaying
+// the log first so the store holds every write acknowledged before the
+// last shutdown or crash. Every Create, Update, and Delete is appended to
+// the log before it is applied. The clock is advanced past every replayed
+// HLC, so new writes order after recovered ones. TTLs are not logged:
+// recovered entities do not expire until their writer sets a TTL again.
+func Open(path string, opts ...Option) (*Store, error) {
+	s := New(opts...)
+	w, events, err := openWAL(path, s.walSync)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		e := event.Entity
+		s.clock.Update(hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode})
+		if event.Type == storev1.EventType_EVENT_TYPE_DELETED {
+			s.bury(e.Id, hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode})
+			if _, ok := s.entities[e.Id]; !ok {
+				continue // a replicated tombstone
+			}
+			delete(s.entities, e.Id)
+		} else {
+			s.entities[e.Id] = e
+			delete(s.tombstones, e.Id)
+		}
+		s.recordVersion(event.Type, e)
+	}
+	for _, e := range s.entities {
+		s.index(e)
+	}
+	s.wal = w
+	slog.Info("store recovered from wal", "path", path, "records", len(events), "entities", len(s.entities))
+	return s, nil
+}
+
+// Close closes the store's log, if it has one. The store must not be
+// written to afterwards.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.wal == nil {
+		return nil
+	}
+	return s.wal.close()
+}
+
+// logWrite appends a write to the log ahead of applying it. Must hold mu.
+func (s *Store) logWrite(typ storev1.EventType, e *entityv1.Entity) error {
+	if s.wal == nil {
+		return nil
+	}
+	return s.wal.append(typ, e)
+}
+
+// recordVersion adds a write to the entity's history, if kept. Must hold mu.
+func (s *Store) recordVersion(typ storev1.EventType, e *entityv1.Entity) {
+	if s.history != nil {
+		s.history.record(typ, e)
+	}
+}
+
+// History returns the entity's recorded versions, oldest first by HLC: the
+// event type of each write and the entity as it stood after it, or for a
+// delete as it stood before, stamped with the delete's HLC. History
+// outlives a deleted entity. It is empty unless the store was created
+// WithHistory.
+func (s *Store) History(id string) []*storev1.EntityEvent {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.history == nil {
+		return nil
+	}
+	return s.history.get(id)
+}
+
+// compactLocked rewrites the log once it is mostly superseded records.
+// A failed compaction leaves the old log in place. Must hold mu, after
+// applying the write just logged.
+func (s *Store) compactLocked() {
+	if s.wal == nil || !s.wal.needsCompaction(len(s.entities)+len(s.tombstones)) {
+		return
+	}
+	if err := s.wal.compact(s.entities, s.deletedRecords()); err != nil {
+		slog.Error("wal compaction failed", "path", s.wal.path, "error", err)
+	}
+}
+
+// SetTTL sets a time-to-live for an entity, replacing any earlier one. The
+// entity will be automatically deleted after the TTL expires (requires
+// StartReaper to be running). Deleting the entity clears its TTL.
 func (s *Store) SetTTL(id string, ttl time.Duration) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	s.ttls[id] = time.Now().Add(ttl)
 }
 
-// StartReaper runs a background goroutine that deletes expired entities.
-// It stops when ctx is cancelled.
+// StartReaper runs a background goroutine that deletes expired entities
+// and collects expired tombstones. It stops when ctx is cancelled.
 func (s *Store) StartReaper(ctx context.Context, interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -93,36 +360,106 @@ func (s *Store) reap() {
 	s.mu.Unlock()
 
 	for _, id := range expired {
-		s.Delete(id) //nolint:errcheck
-		s.mu.Lock()
-		delete(s.ttls, id)
-		s.mu.Unlock()
+		if err := s.expire(id, now); err != nil {
+			slog.Error("expire failed", "id", id, "error", err)
+		}
 	}
+	s.collectTombstones(now)
+	s.collectComponentTombstones(now)
+	s.collectArchive(now)
 }
 
-// Create adds a new entity. Returns an error if the ID already exists.
+// expire removes an entity whose TTL ran out by now, notifying watchers
+// with EXPIRED rather than DELETED. An entity written with a fresh TTL
+// since the reaper saw it is kept.
+func (s *Store) expire(id string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiry, ok := s.ttls[id]
+	if !ok || !now.After(expiry) {
+		return nil
+	}
+	delete(s.ttls, id)
+	e, ok := s.entities[id]
+	if !ok {
+		return nil
+	}
+	ts, err := s.clock.Next()
+	if err != nil {
+		return fmt.Errorf("expire %q: %w", id, err)
+	}
+	return s.removeLocked(e, ts, storev1.EventType_EVENT_TYPE_EXPIRED)
+}
+
+// Create adds a new entity. Returns an error if the ID already exists, or
+// ErrDeleted if e carries an HLC from before the entity was deleted.
 func (s *Store) Create(e *entityv1.Entity) (*entityv1.Entity, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.createLocked(e)
+}
 
-	if _, exists := s.entities[e.Id]; exists {
-		return nil, fmt.Errorf("entity %q already exists", e.Id)
+func (s *Store) createLocked(e *entityv1.Entity) (*entityv1.Entity, error) {
+	if existing, ok := s.entities[e.Id]; ok {
+		return nil, errExists(existing)
+	}
+	if s.buried(e) {
+		s.stats.buried++
+		return nil, fmt.Errorf("create %q: %w", e.Id, ErrDeleted)
+	}
+	if err := s.checkQuota(e.Type, 1); err != nil {
+		return nil, fmt.Errorf("create %q: %w", e.Id, err)
+	}
+	if err := s.validateComponents(e.Components); err != nil {
+		return nil, fmt.Errorf("create %q: %w", e.Id, err)
 	}
 
 	now := timestamppb.Now()
-	ts := s.clock.Now()
+	if err := s.observeStamps(e); err != nil {
+		return nil, fmt.Errorf("create %q: %w", e.Id, err)
+	}
+	ts, err := s.clock.Next()
+	if err != nil {
+		return nil, fmt.Errorf("create %q: %w", e.Id, err)
+	}
 	stored := proto.Clone(e).(*entityv1.Entity)
 	stored.CreatedAt = now
 	stored.UpdatedAt = now
 	stored.HlcPhysical = ts.Physical
 	stored.HlcLogical = ts.Logical
 	stored.HlcNode = ts.Node
+	// A copy replicated from another store keeps the stamps of the writes
+	// that set its components there.
+	stored.ComponentHlc = make(map[string]*entityv1.HLCTimestamp, len(stored.Components))
+	for key := range stored.Components {
+		if own, ok := e.ComponentHlc[key]; ok {
+			stored.ComponentHlc[key] = proto.Clone(own).(*entityv1.HLCTimestamp)
+		} else {
+			stored.ComponentHlc[key] = stamp(ts)
+			delete(stored.ComponentWrites, key)
+		}
+	}
+	stored.RemovedComponents = nil
+	s.applyRemovals(stored, e)
+	stored.LabelsHlc = nil
+	if len(stored.Labels) > 0 || e.LabelsHlc != nil {
+		stored.LabelsHlc = labelsStamp(e, nil, ts)
+	}
+	if err := s.logWrite(storev1.EventType_EVENT_TYPE_CREATED, stored); err != nil {
+		return nil, err
+	}
 	s.entities[stored.Id] = stored
+	delete(s.tombstones, stored.Id)
+	delete(s.archive, stored.Id)
+	s.index(stored)
+	s.recordVersion(storev1.EventType_EVENT_TYPE_CREATED, stored)
+	s.compactLocked()
 
 	s.notify(&storev1.EntityEvent{
 		Type:   storev1.EventType_EVENT_TYPE_CREATED,
 		Entity: proto.Clone(stored).(*entityv1.Entity),
-	})
+	}, nil)
 	return proto.Clone(stored).(*entityv1.Entity), nil
 }
 
@@ -153,73 +490,655 @@ func (s *Store) List(typeFilter entityv1.EntityType) []*entityv1.Entity {
 	return result
 }
 
+// QueryBBox returns the entities whose position component lies inside b,
+// optionally filtered by type. Entities without a position never match.
+func (s *Store) QueryBBox(b BBox, typeFilter entityv1.EntityType) []*entityv1.Entity {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []*entityv1.Entity
+	for _, id := range s.geo.query(b) {
+		e := s.entities[id]
+		if typeFilter != entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED && e.Type != typeFilter {
+			continue
+		}
+		result = append(result, proto.Clone(e).(*entityv1.Entity))
+	}
+	return result
+}
+
 // Update replaces an existing entity. Returns error if not found.
 func (s *Store) Update(e *entityv1.Entity) (*entityv1.Entity, error) {
+	return s.update(e, nil)
+}
+
+// UpdateIf is Update, applied only if the stored entity's HLC is still
+// expected: the HLC of the copy the caller read. Returns ErrConflict if
+// another write got there first.
+func (s *Store) UpdateIf(e *entityv1.Entity, expected hlc.Timestamp) (*entityv1.Entity, error) {
+	return s.update(e, &expected)
+}
+
+func (s *Store) update(e *entityv1.Entity, expected *hlc.Timestamp) (*entityv1.Entity, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.updateLocked(e, expected)
+}
 
+func (s *Store) updateLocked(e *entityv1.Entity, expected *hlc.Timestamp) (*entityv1.Entity, error) {
 	existing, ok := s.entities[e.Id]
 	if !ok {
 		return nil, fmt.Errorf("entity %q not found", e.Id)
 	}
+	if expected != nil && *expected != (hlc.Timestamp{Physical: existing.HlcPhysical, Logical: existing.HlcLogical, Node: existing.HlcNode}) {
+		s.stats.conflicts++
+		return nil, fmt.Errorf("update %q: %w", e.Id, errConflict(existing, *expected))
+	}
+	if err := s.validateComponents(e.Components); err != nil {
+		return nil, fmt.Errorf("update %q: %w", e.Id, err)
+	}
 
-	// Advance the store's HLC.
-	ts := s.clock.Now()
+	// Advance the store's HLC, past any component stamps e carries.
+	if err := s.observeStamps(e); err != nil {
+		return nil, fmt.Errorf("update %q: %w", e.Id, err)
+	}
+	ts, err := s.clock.Next()
+	if err != nil {
+		return nil, fmt.Errorf("update %q: %w", e.Id, err)
+	}
 
 	// Component-key merge: start from existing entity, merge incoming components.
 	merged := proto.Clone(existing).(*entityv1.Entity)
 
 	incomingHLC := hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
-	existingHLC := hlc.Timestamp{Physical: existing.HlcPhysical, Logical: existing.HlcLogical, Node: existing.HlcNode}
 
 	if merged.Components == nil {
 		merged.Components = make(map[string]*anypb.Any)
 	}
+	backfillStamps(merged)
 	for key, comp := range e.Components {
-		if _, exists := merged.Components[key]; !exists {
+		have, exists := merged.Components[key]
+		switch {
+		case !exists && !readded(e, existing, key):
+			// Removed key, incoming set it before the removal — keep it
+			// removed.
+			s.stats.staleComponents++
+		case !exists:
 			// New key from incoming — always accept.
 			merged.Components[key] = comp
-		} else if hlc.Compare(incomingHLC, existingHLC) >= 0 {
-			// Same key, incoming is newer or equal — accept.
+			merged.ComponentHlc[key] = componentStamp(e, existing, key, ts)
+			setWrites(merged, e, key)
+		case hlc.Compare(incomingHLC, componentHLC(existing, key)) < 0:
+			// Same key, incoming is stale — keep existing.
+			s.stats.staleComponents++
+		case proto.Equal(have, comp):
+			// Same value — it keeps the stamp of the write that set it,
+			// or incoming's if that is newer, so copies converge on it.
+			if own, ok := e.ComponentHlc[key]; ok && stampOf(own).After(componentHLC(existing, key)) {
+				merged.ComponentHlc[key] = proto.Clone(own).(*entityv1.HLCTimestamp)
+			}
+			// A merge may resolve it from more writes than it was.
+			if proto.Equal(merged.ComponentHlc[key], e.ComponentHlc[key]) {
+				setWrites(merged, e, key)
+			}
+		default:
+			// Same key, incoming is newer than or equal to the write that
+			// last set it — accept.
 			merged.Components[key] = comp
+			merged.ComponentHlc[key] = componentStamp(e, existing, key, ts)
+			setWrites(merged, e, key)
+		}
+	}
+
+	s.applyRemovals(merged, e)
+
+	// Labels are replaced as a set, and only by a write that has seen the
+	// entity's latest version. A write that carries a labels HLC but no
+	// labels clears them.
+	if (len(e.Labels) > 0 || e.LabelsHlc != nil) && hlc.Compare(incomingHLC, hlc.Timestamp{Physical: existing.HlcPhysical, Logical: existing.HlcLogical, Node: existing.HlcNode}) >= 0 {
+		if !maps.Equal(merged.Labels, e.Labels) {
+			merged.Labels = e.Labels
+			merged.LabelsHlc = labelsStamp(e, existing, ts)
+		} else if own := e.LabelsHlc; own != nil && stampOf(own).After(labelsHLC(existing)) {
+			// The same set keeps the stamp of the write that set it, as
+			// an unchanged component does.
+			merged.LabelsHlc = proto.Clone(own).(*entityv1.HLCTimestamp)
 		}
-		// Else: same key, incoming is stale — keep existing.
 	}
 
-	// Copy non-component fields from incoming where appropriate.
+	// Copy non-component fields from incoming where appropriate. The
+	// earlier creation time is kept, so replicas of an entity created
+	// on two nodes settle on the first.
 	merged.Type = e.Type
+	if c := e.GetCreatedAt(); c != nil && (merged.CreatedAt == nil || c.AsTime().Before(merged.CreatedAt.AsTime())) {
+		merged.CreatedAt = c
+	}
 	merged.UpdatedAt = timestamppb.Now()
 	merged.HlcPhysical = ts.Physical
 	merged.HlcLogical = ts.Logical
 	merged.HlcNode = ts.Node
+	if err := s.logWrite(storev1.EventType_EVENT_TYPE_UPDATED, merged); err != nil {
+		return nil, err
+	}
 	s.entities[merged.Id] = merged
+	s.index(merged)
+	s.recordVersion(storev1.EventType_EVENT_TYPE_UPDATED, merged)
+	s.compactLocked()
 
 	s.notify(&storev1.EntityEvent{
 		Type:   storev1.EventType_EVENT_TYPE_UPDATED,
 		Entity: proto.Clone(merged).(*entityv1.Entity),
-	})
+	}, existing)
 	return proto.Clone(merged).(*entityv1.Entity), nil
 }
 
-// Delete removes an entity by ID. Returns error if not found.
+// GetComponent returns one component of an entity and the HLC of the
+// write that last set it.
+func (s *Store) GetComponent(id, key string) (*anypb.Any, hlc.Timestamp, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	e, ok := s.entities[id]
+	if !ok {
+		return nil, hlc.Timestamp{}, fmt.Errorf("entity %q not found", id)
+	}
+	c, ok := e.Components[key]
+	if !ok {
+		return nil, hlc.Timestamp{}, fmt.Errorf("entity %q has no component %q", id, key)
+	}
+	return proto.Clone(c).(*anypb.Any), componentHLC(e, key), nil
+}
+
+// Patch sets the given components of an existing entity and stamps them
+// with a fresh HLC, leaving its other components as they are. Unlike
+// Update, it never treats a component as stale: the write is ordered by
+// the store, not by an HLC the caller read earlier. Returns error if not
+// found.
+func (s *Store) Patch(id string, components map[string]*anypb.Any) (*entityv1.Entity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.patchLocked(id, components)
+}
+
+func (s *Store) patchLocked(id string, components map[string]*anypb.Any) (*entityv1.Entity, error) {
+	existing, ok := s.entities[id]
+	if !ok {
+		return nil, fmt.Errorf("entity %q not found", id)
+	}
+	if err := s.validateComponents(components); err != nil {
+		return nil, fmt.Errorf("patch %q: %w", id, err)
+	}
+
+	ts, err := s.clock.Next()
+	if err != nil {
+		return nil, fmt.Errorf("patch %q: %w", id, err)
+	}
+	patched := proto.Clone(existing).(*entityv1.Entity)
+	if patched.Components == nil {
+		patched.Components = make(map[string]*anypb.Any)
+	}
+	backfillStamps(patched)
+	for key, comp := range components {
+		patched.Components[key] = proto.Clone(comp).(*anypb.Any)
+		patched.ComponentHlc[key] = stamp(ts)
+		delete(patched.ComponentWrites, key)
+	}
+	patched.UpdatedAt = timestamppb.Now()
+	patched.HlcPhysical, patched.HlcLogical, patched.HlcNode = ts.Physical, ts.Logical, ts.Node
+	if err := s.logWrite(storev1.EventType_EVENT_TYPE_UPDATED, patched); err != nil {
+		return nil, err
+	}
+	s.entities[id] = patched
+	s.index(patched)
+	s.recordVersion(storev1.EventType_EVENT_TYPE_UPDATED, patched)
+	s.compactLocked()
+
+	s.notify(&storev1.EntityEvent{
+		Type:   storev1.EventType_EVENT_TYPE_UPDATED,
+		Entity: proto.Clone(patched).(*entityv1.Entity),
+	}, existing)
+	return proto.Clone(patched).(*entityv1.Entity), nil
+}
+
+// RemoveComponents removes the given components of an existing entity,
+// leaving a tombstone for each stamped with a fresh HLC, so a merge with a
+// copy that still holds one, or a stale write of it, does not bring it
+// back. A key the entity lacks is tombstoned all the same, against copies
+// of it yet to arrive. Returns error if not found.
+func (s *Store) RemoveComponents(id string, keys []string) (*entityv1.Entity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.removeComponentsLocked(id, keys)
+}
+
+func (s *Store) removeComponentsLocked(id string, keys []string) (*entityv1.Entity, error) {
+	existing, ok := s.entities[id]
+	if !ok {
+		return nil, fmt.Errorf("entity %q not found", id)
+	}
+
+	ts, err := s.clock.Next()
+	if err != nil {
+		return nil, fmt.Errorf("remove components of %q: %w", id, err)
+	}
+	removed := proto.Clone(existing).(*entityv1.Entity)
+	backfillStamps(removed)
+	if removed.RemovedComponents == nil {
+		removed.RemovedComponents = make(map[string]*entityv1.HLCTimestamp, len(keys))
+	}
+	for _, key := range keys {
+		delete(removed.Components, key)
+		delete(removed.ComponentHlc, key)
+		delete(removed.ComponentWrites, key)
+		removed.RemovedComponents[key] = stamp(ts)
+	}
+	removed.UpdatedAt = timestamppb.Now()
+	removed.HlcPhysical, removed.HlcLogical, removed.HlcNode = ts.Physical, ts.Logical, ts.Node
+	if err := s.logWrite(storev1.EventType_EVENT_TYPE_UPDATED, removed); err != nil {
+		return nil, err
+	}
+	s.entities[id] = removed
+	s.index(removed)
+	s.recordVersion(storev1.EventType_EVENT_TYPE_UPDATED, removed)
+	s.compactLocked()
+
+	s.notify(&storev1.EntityEvent{
+		Type:   storev1.EventType_EVENT_TYPE_UPDATED,
+		Entity: proto.Clone(removed).(*entityv1.Entity),
+	}, existing)
+	return proto.Clone(removed).(*entityv1.Entity), nil
+}
+
+// Increment adds delta, which may be negative, to the counter component
+// key of an existing entity, an entityv1.CounterComponent, creating it at
+// zero if absent. The count is kept under the store's own HLC node, so
+// merges add it to other stores' counts rather than overwriting them; see
+// crdt.PNCounter. Returns error if not found, or ErrInvalid if the
+// component is not a counter.
+func (s *Store) Increment(id, key string, delta int64) (*entityv1.Entity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.incrementLocked(id, key, delta)
+}
+
+func (s *Store) incrementLocked(id, key string, delta int64) (*entityv1.Entity, error) {
+	existing, ok := s.entities[id]
+	if !ok {
+		return nil, fmt.Errorf("entity %q not found", id)
+	}
+	counter := &entityv1.CounterComponent{}
+	if c, ok := existing.Components[key]; ok {
+		if err := c.UnmarshalTo(counter); err != nil {
+			return nil, fmt.Errorf("increment %q: %w", id, &InvalidError{Key: key, Err: fmt.Errorf("not a counter: %w", err)})
+		}
+	}
+
+	ts, err := s.clock.Next()
+	if err != nil {
+		return nil, fmt.Errorf("increment %q: %w", id, err)
+	}
+	if delta >= 0 {
+		if counter.Increments == nil {
+			counter.Increments = make(map[string]uint64)
+		}
+		counter.Increments[ts.Node] += uint64(delta)
+	} else {
+		if counter.Decrements == nil {
+			counter.Decrements = make(map[string]uint64)
+		}
+		counter.Decrements[ts.Node] += uint64(-delta)
+	}
+	// Encoded deterministically, as maps otherwise are not, so a store's
+	// copy matches its peers' once they hold the same counts.
+	c := &anypb.Any{}
+	if err := anypb.MarshalFrom(c, counter, proto.MarshalOptions{Deterministic: true}); err != nil {
+		return nil, fmt.Errorf("increment %q: %w", id, err)
+	}
+
+	incremented := proto.Clone(existing).(*entityv1.Entity)
+	if incremented.Components == nil {
+		incremented.Components = make(map[string]*anypb.Any)
+	}
+	backfillStamps(incremented)
+	incremented.Components[key] = c
+	incremented.ComponentHlc[key] = stamp(ts)
+	delete(incremented.ComponentWrites, key)
+	incremented.UpdatedAt = timestamppb.Now()
+	incremented.HlcPhysical, incremented.HlcLogical, incremented.HlcNode = ts.Physical, ts.Logical, ts.Node
+	if err := s.logWrite(storev1.EventType_EVENT_TYPE_UPDATED, incremented); err != nil {
+		return nil, err
+	}
+	s.entities[id] = incremented
+	s.index(incremented)
+	s.recordVersion(storev1.EventType_EVENT_TYPE_UPDATED, incremented)
+	s.compactLocked()
+
+	s.notify(&storev1.EntityEvent{
+		Type:   storev1.EventType_EVENT_TYPE_UPDATED,
+		Entity: proto.Clone(incremented).(*entityv1.Entity),
+	}, existing)
+	return proto.Clone(incremented).(*entityv1.Entity), nil
+}
+
+// stamp converts an HLC timestamp to its wire form.
+func stamp(ts hlc.Timestamp) *entityv1.HLCTimestamp {
+	return &entityv1.HLCTimestamp{Physical: ts.Physical, Logical: ts.Logical, Node: ts.Node}
+}
+
+// stampOf converts an HLC timestamp from its wire form.
+func stampOf(c *entityv1.HLCTimestamp) hlc.Timestamp {
+	return hlc.Timestamp{Physical: c.Physical, Logical: c.Logical, Node: c.Node}
+}
+
+// componentStamp returns the HLC for component key of e, written over
+// existing at ts: the stamp e carries for it if existing lacks the
+// component or has an older stamp, as for a component replicated from the
+// store whose write set it, so its freshness is not taken for this
+// write's. Otherwise, as for a client that read the entity and changed the
+// component, ts.
+func componentStamp(e, existing *entityv1.Entity, key string, ts hlc.Timestamp) *entityv1.HLCTimestamp {
+	own, ok := e.ComponentHlc[key]
+	if !ok {
+		return stamp(ts)
+	}
+	if _, had := existing.Components[key]; !had || stampOf(own).After(componentHLC(existing, key)) {
+		return proto.Clone(own).(*entityv1.HLCTimestamp)
+	}
+	return stamp(ts)
+}
+
+// setWrites records for merged's component key the writes e resolved it
+// from, if merged keeps e's stamp for it; a component restamped as a new
+// write, or that e records none for, was set by one write alone.
+func setWrites(merged, e *entityv1.Entity, key string) {
+	w, ok := e.ComponentWrites[key]
+	if !ok || !proto.Equal(merged.ComponentHlc[key], e.ComponentHlc[key]) {
+		delete(merged.ComponentWrites, key)
+		return
+	}
+	if merged.ComponentWrites == nil {
+		merged.ComponentWrites = make(map[string]*entityv1.ComponentWrites)
+	}
+	merged.ComponentWrites[key] = proto.Clone(w).(*entityv1.ComponentWrites)
+}
+
+// labelsStamp returns the HLC to stamp e's labels with when it sets them
+// on existing, nil if none: its own, if a copy replicated from a store
+// that set them after existing's, else ts.
+func labelsStamp(e, existing *entityv1.Entity, ts hlc.Timestamp) *entityv1.HLCTimestamp {
+	if own := e.LabelsHlc; own != nil && (existing == nil || stampOf(own).After(labelsHLC(existing))) {
+		return proto.Clone(own).(*entityv1.HLCTimestamp)
+	}
+	return stamp(ts)
+}
+
+// labelsHLC returns the HLC of the write that last set e's labels, or
+// the entity's if they have none of their own.
+func labelsHLC(e *entityv1.Entity) hlc.Timestamp {
+	if e.LabelsHlc != nil {
+		return stampOf(e.LabelsHlc)
+	}
+	return hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
+}
+
+// observeStamps advances the store's clock past the component and removal
+// stamps e carries, so its own writes after them are stamped later. It
+// fails if one is past the clock's drift bound. Must hold mu.
+func (s *Store) observeStamps(e *entityv1.Entity) error {
+	for _, c := range e.ComponentHlc {
+		if _, err := s.clock.Observe(stampOf(c)); err != nil {
+			return err
+		}
+	}
+	for _, c := range e.RemovedComponents {
+		if _, err := s.clock.Observe(stampOf(c)); err != nil {
+			return err
+		}
+	}
+	if e.LabelsHlc != nil {
+		if _, err := s.clock.Observe(stampOf(e.LabelsHlc)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillStamps stamps every component, and the labels, that has no HLC
+// of its own with the entity's, before the entity's HLC moves on. Must be
+// called on a copy.
+func backfillStamps(e *entityv1.Entity) {
+	if len(e.Labels) > 0 && e.LabelsHlc == nil {
+		e.LabelsHlc = &entityv1.HLCTimestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
+	}
+	if e.ComponentHlc == nil {
+		e.ComponentHlc = make(map[string]*entityv1.HLCTimestamp, len(e.Components))
+	}
+	for key := range e.Components {
+		if _, ok := e.ComponentHlc[key]; !ok {
+			e.ComponentHlc[key] = &entityv1.HLCTimestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
+		}
+	}
+}
+
+// componentHLC returns the HLC of the write that last set a component. An
+// entity written before components were stamped falls back to its own HLC.
+func componentHLC(e *entityv1.Entity, key string) hlc.Timestamp {
+	if c, ok := e.ComponentHlc[key]; ok {
+		return stampOf(c)
+	}
+	return hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
+}
+
+// Delete removes an entity by ID, leaving a tombstone. Returns error if
+// not found.
 func (s *Store) Delete(id string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	return s.deleteLocked(id)
+}
+
+func (s *Store) deleteLocked(id string) error {
+	delete(s.ttls, id)
 
 	e, ok := s.entities[id]
 	if !ok {
 		return fmt.Errorf("entity %q not found", id)
 	}
+	ts, err := s.clock.Next()
+	if err != nil {
+		return fmt.Errorf("delete %q: %w", id, err)
+	}
+	return s.removeLocked(e, ts, storev1.EventType_EVENT_TYPE_DELETED)
+}
+
+// DeleteAt applies a delete replicated from another node, keeping its HLC.
+// An entity written after ts survives it. Otherwise the entity, if the
+// store has it, is removed, and the tombstone is kept either way so a
+// stale copy arriving later is refused.
+func (s *Store) DeleteAt(id string, ts hlc.Timestamp) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteAtLocked(id, ts)
+}
+
+func (s *Store) deleteAtLocked(id string, ts hlc.Timestamp) error {
+	if _, err := s.clock.Observe(ts); err != nil {
+		return fmt.Errorf("delete %q: %w", id, err)
+	}
 
-	delete(s.entities, id)
+	e, ok := s.entities[id]
+	if !ok {
+		if t, held := s.tombstones[id]; held && !ts.After(t.hlc) {
+			return nil
+		}
+		if err := s.logWrite(storev1.EventType_EVENT_TYPE_DELETED, &entityv1.Entity{
+			Id: id, HlcPhysical: ts.Physical, HlcLogical: ts.Logical, HlcNode: ts.Node,
+		}); err != nil {
+			return err
+		}
+		s.bury(id, ts)
+		s.compactLocked()
+		return nil
+	}
+	if crdt.MergeTombstone(e, ts) != nil {
+		return nil
+	}
+	delete(s.ttls, id)
+	return s.removeLocked(e, ts, storev1.EventType_EVENT_TYPE_DELETED)
+}
 
+// removeLocked deletes e with a delete stamped ts, notifying watchers
+// with typ, DELETED or EXPIRED. The log records a delete either way.
+// Must hold mu.
+func (s *Store) removeLocked(e *entityv1.Entity, ts hlc.Timestamp, typ storev1.EventType) error {
+	tomb := proto.Clone(e).(*entityv1.Entity)
+	tomb.HlcPhysical, tomb.HlcLogical, tomb.HlcNode = ts.Physical, ts.Logical, ts.Node
+	if err := s.logWrite(storev1.EventType_EVENT_TYPE_DELETED, tomb); err != nil {
+		return err
+	}
+	delete(s.entities, e.Id)
+	s.bury(e.Id, ts)
+	s.archiveLocked(e, typ)
+	s.unindex(e.Id)
+	s.recordVersion(typ, tomb)
+	s.compactLocked()
+
+	// The event carries the delete's HLC, so relays can replicate it.
 	s.notify(&storev1.EntityEvent{
-		Type:   storev1.EventType_EVENT_TYPE_DELETED,
-		Entity: proto.Clone(e).(*entityv1.Entity),
-	})
+		Type:   typ,
+		Entity: proto.Clone(tomb).(*entityv1.Entity),
+	}, e)
+	s.unlinkLocked(e.Id)
 	return nil
 }
 
+// mergeLocked applies e, a copy of an entity replicated from another node,
+// keeping its HLCs: the store's copy is CRDT-merged with it, with the
+// store's strategies, and written, unless skipUnchanged is set and the merge
+// changes none of its components or labels. An entity the store does not
+// have is created, as Create, and refused with ErrDeleted if deleted
+// after e. It reports whether a stored copy was merged and written. Must
+// hold mu.
+func (s *Store) mergeLocked(e *entityv1.Entity, skipUnchanged bool) (*entityv1.Entity, bool, error) {
+	if e.HlcPhysical != 0 {
+		if _, err := s.clock.Observe(hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}); err != nil {
+			return nil, false, fmt.Errorf("merge %q: %w", e.Id, err)
+		}
+	}
+	existing, ok := s.entities[e.Id]
+	if !ok {
+		created, err := s.createLocked(e)
+		return created, false, err
+	}
+	merged := s.strategies.MergeEntity(existing, e)
+	merged.Type = e.Type
+	if skipUnchanged && sameContent(existing, merged) {
+		return proto.Clone(existing).(*entityv1.Entity), false, nil
+	}
+	updated, err := s.updateLocked(merged, nil)
+	return updated, err == nil, err
+}
+
+// sameContent reports whether a and b hold the same components, the same
+// writes they were resolved from, labels, and component tombstones.
+func sameContent(a, b *entityv1.Entity) bool {
+	if len(a.Components) != len(b.Components) || !maps.Equal(a.Labels, b.Labels) {
+		return false
+	}
+	for key, comp := range a.Components {
+		if !proto.Equal(comp, b.Components[key]) {
+			return false
+		}
+	}
+	if !maps.EqualFunc(a.ComponentWrites, b.ComponentWrites, func(x, y *entityv1.ComponentWrites) bool {
+		return proto.Equal(x, y)
+	}) {
+		return false
+	}
+	return maps.EqualFunc(a.RemovedComponents, b.RemovedComponents, func(x, y *entityv1.HLCTimestamp) bool {
+		return proto.Equal(x, y)
+	})
+}
+
+// RestoreOutcome says what Restore did with an entity.
+type RestoreOutcome int
+
+const (
+	RestoreCreated  RestoreOutcome = iota // the store did not have it
+	RestoreReplaced                       // the restored copy was newer
+	RestoreSkipped                        // the stored copy was at least as new
+)
+
+// Restore loads an entity from a snapshot, keeping its HLC and timestamps.
+// An entity the store already has is replaced whole only if the restored
+// copy's HLC is later, and one deleted after the copy was taken is not
+// brought back. An entity with no HLC is stamped now. The clock is
+// advanced past the restored HLC, so later writes order after it.
+func (s *Store) Restore(e *entityv1.Entity) (RestoreOutcome, error) {
+	return s.RestoreFrom(e, "")
+}
+
+// RestoreFrom is Restore of a copy from the node called origin, which the
+// event it emits carries as its origin_node.
+func (s *Store) RestoreFrom(e *entityv1.Entity, origin string) (RestoreOutcome, error) {
+	if err := s.validateComponents(e.Components); err != nil {
+		return 0, fmt.Errorf("restore %q: %w", e.Id, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.origin = origin
+	defer func() { s.origin = "" }()
+
+	restored := proto.Clone(e).(*entityv1.Entity)
+	incoming := hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
+	if incoming.Physical == 0 {
+		ts, err := s.clock.Next()
+		if err != nil {
+			return 0, fmt.Errorf("restore %q: %w", e.Id, err)
+		}
+		restored.HlcPhysical, restored.HlcLogical, restored.HlcNode = ts.Physical, ts.Logical, ts.Node
+		incoming = ts
+	} else if _, err := s.clock.Observe(incoming); err != nil {
+		return 0, fmt.Errorf("restore %q: %w", e.Id, err)
+	}
+	backfillStamps(restored)
+	if restored.CreatedAt == nil {
+		restored.CreatedAt = timestamppb.Now()
+	}
+	if restored.UpdatedAt == nil {
+		restored.UpdatedAt = restored.CreatedAt
+	}
+
+	if s.buried(restored) {
+		s.stats.buried++
+		return RestoreSkipped, nil
+	}
+	outcome, typ := RestoreCreated, storev1.EventType_EVENT_TYPE_CREATED
+	existing, ok := s.entities[e.Id]
+	if ok {
+		existingHLC := hlc.Timestamp{Physical: existing.HlcPhysical, Logical: existing.HlcLogical, Node: existing.HlcNode}
+		if hlc.Compare(incoming, existingHLC) <= 0 {
+			return RestoreSkipped, nil
+		}
+		outcome, typ = RestoreReplaced, storev1.EventType_EVENT_TYPE_UPDATED
+	}
+	if err := s.logWrite(typ, restored); err != nil {
+		return 0, err
+	}
+	s.entities[restored.Id] = restored
+	delete(s.tombstones, restored.Id)
+	delete(s.archive, restored.Id)
+	s.index(restored)
+	s.recordVersion(typ, restored)
+	s.compactLocked()
+
+	s.notify(&storev1.EntityEvent{
+		Type:   typ,
+		Entity: proto.Clone(restored).(*entityv1.Entity),
+	}, existing)
+	return outcome, nil
+}
+
 // Watch registers a watcher that receives entity events.
 // Close the returned channel when done watching.
 func (s *Store) Watch(typeFilter entityv1.EntityType) *Watcher {
@@ -233,6 +1152,59 @@ func (s *Store) Watch(typeFilter entityv1.EntityType) *Watcher {
 	return w
 }
 
+// WatchFrom registers a watcher that resumes after the event with sequence
+// since: the matching events it missed are in Backlog, and every later one
+// arrives on Events. A since of zero is Watch. Returns ErrResumeGap if the
+// missed events are no longer held.
+func (s *Store) WatchFrom(typeFilter entityv1.EntityType, since uint64) (*Watcher, error) {
+	// Writers notify under mu, so no event falls between the backlog and
+	// the watcher's registration.
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if since == 0 {
+		return s.Watch(typeFilter), nil
+	}
+
+	oldest := s.seq + 1
+	if len(s.backlog) > 0 {
+		oldest = s.backlog[0].Sequence
+	}
+	if since+1 < oldest || since > s.seq {
+		return nil, fmt.Errorf("%w: resume after %d, backlog holds %d to %d", ErrResumeGap, since, oldest, s.seq)
+	}
+	var missed []*storev1.EntityEvent
+	for _, event := range s.backlog[since+1-oldest:] {
+		if matches(typeFilter, event) {
+			missed = append(missed, event)
+		}
+	}
+	w := s.Watch(typeFilter)
+	w.Backlog = missed
+	return w, nil
+}
+
+// WatchSnapshot registers a watcher whose Backlog holds a CREATED event
+// for every entity matching typeFilter, ordered by ID, and whose Events
+// carry every later write. The snapshot events have the sequence of the
+// last event emitted before it.
+func (s *Store) WatchSnapshot(typeFilter entityv1.EntityType) *Watcher {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var snapshot []*storev1.EntityEvent
+	for _, id := range slices.Sorted(maps.Keys(s.entities)) {
+		if e := s.entities[id]; typeFilter == entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED || e.Type == typeFilter {
+			snapshot = append(snapshot, &storev1.EntityEvent{
+				Type:     storev1.EventType_EVENT_TYPE_CREATED,
+				Entity:   proto.Clone(e).(*entityv1.Entity),
+				Sequence: s.seq,
+			})
+		}
+	}
+	w := s.Watch(typeFilter)
+	w.Backlog = snapshot
+	return w
+}
+
 // Unwatch removes a watcher and closes its channel.
 func (s *Store) Unwatch(w *Watcher) {
 	s.watchMu.Lock()
@@ -247,19 +1219,71 @@ func (s *Store) Unwatch(w *Watcher) {
 	}
 }
 
-// notify sends an event to all matching watchers. Must NOT hold watchMu.
-func (s *Store) notify(event *storev1.EntityEvent) {
+// Now returns a fresh timestamp from the store's clock, ordered with the
+// versions of its writes.
+func (s *Store) Now() hlc.Timestamp { return s.clock.Now() }
+
+// Last returns the latest timestamp the store's clock has issued, without
+// advancing it; see hlc.Clock.Last.
+func (s *Store) Last() hlc.Timestamp { return s.clock.Last() }
+
+// Wall returns the wall time the store's clock reads; see hlc.Clock.Wall.
+func (s *Store) Wall() time.Time { return s.clock.Wall() }
+
+// Observe advances the store's clock past ts, the HLC of a caller, so its
+// writes after the call order after whatever the caller had seen. Like
+// its writes, it refuses a ts past the clock's drift bound.
+func (s *Store) Observe(ts hlc.Timestamp) (hlc.Timestamp, error) { return s.clock.Observe(ts) }
+
+// PersistClock saves the store's clock to the file at path, and resumes it
+// from the file first, so writes after a restart are stamped after those
+// before it even if the wall clock was set back; see hlc.Clock.Persist.
+// Call it before the store takes writes, and after Open, whose replay
+// advances the clock too.
+func (s *Store) PersistClock(path string) error { return s.clock.Persist(path) }
+
+// WatcherCount returns the number of registered watchers, so callers can
+// tell when the services they started have subscribed.
+func (s *Store) WatcherCount() int {
+	s.watchMu.RLock()
+	defer s.watchMu.RUnlock()
+	return len(s.watchers)
+}
+
+// notify stamps an event with the next sequence and the origin, hops, and
+// path of the write in progress, keeps it for resuming watchers, and sends it to all
+// matching watchers. It also records the write's change from prev, the
+// version it replaced, for change feeds.
+// Must hold mu and NOT watchMu.
+func (s *Store) notify(event *storev1.EntityEvent, prev *entityv1.Entity) {
+	event.OriginNode = s.origin
+	event.Hops = s.hops
+	event.Path = s.path
+	s.seq++
+	event.Sequence = s.seq
+	s.stats.events[event.Type]++
+	if len(s.backlog) == eventBacklog {
+		s.backlog = s.backlog[1:]
+	}
+	s.backlog = append(s.backlog, event)
+	s.recordChange(event, prev)
+
 	s.watchMu.RLock()
 	defer s.watchMu.RUnlock()
 
 	for _, w := range s.watchers {
-		if w.Filter != entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED && w.Filter != event.Entity.Type {
+		if !matches(w.Filter, event) {
 			continue
 		}
 		select {
 		case w.Events <- event:
 		default:
 			// Drop if watcher is slow — prevent blocking the store.
+			s.stats.dropped++
 		}
 	}
 }
+
+func matches(typeFilter entityv1.EntityType, event *storev1.EntityEvent) bool {
+	return typeFilter == entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED || typeFilter == event.Entity.Type
+}
@@ -2,11 +2,17 @@ package store
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
 	"testing"
 	"time"
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/crdt"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/anypb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 )
@@ -100,6 +106,92 @@ func TestUpdateNotFound(t *testing.T) {
 	}
 }
 
+func TestUpdateIf(t *testing.T) {
+	s := New()
+	read, _ := s.Create(&entityv1.Entity{Id: "c1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	readHLC := hlc.Timestamp{Physical: read.HlcPhysical, Logical: read.HlcLogical, Node: read.HlcNode}
+
+	// Another writer gets in first.
+	if _, err := s.Patch("c1", map[string]*anypb.Any{"threat": makeAnyString(t, "high")}); err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	read.Components = map[string]*anypb.Any{"task_catalog": makeAnyString(t, "monitor")}
+	_, err := s.UpdateIf(read, readHLC)
+	if !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected ErrConflict, got %v", err)
+	}
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a *ConflictError, got %T", err)
+	}
+	current, _ := s.Get("c1")
+	if conflict.ID != "c1" || conflict.Expected != readHLC || conflict.Current != (hlc.Timestamp{Physical: current.HlcPhysical, Logical: current.HlcLogical, Node: current.HlcNode}) {
+		t.Fatalf("unexpected conflict %+v", conflict)
+	}
+	if len(conflict.Stale) != 1 || conflict.Stale[0] != "threat" {
+		t.Fatalf("expected threat stale, got %v", conflict.Stale)
+	}
+	if got, _ := s.Get("c1"); got.Components["task_catalog"] != nil {
+		t.Fatal("expected the conflicting update not to be applied")
+	}
+
+	fresh, _ := s.Get("c1")
+	fresh.Components["task_catalog"] = makeAnyString(t, "monitor")
+	updated, err := s.UpdateIf(fresh, hlc.Timestamp{Physical: fresh.HlcPhysical, Logical: fresh.HlcLogical, Node: fresh.HlcNode})
+	if err != nil {
+		t.Fatalf("UpdateIf: %v", err)
+	}
+	if updated.Components["threat"] == nil || updated.Components["task_catalog"] == nil {
+		t.Fatalf("expected both writers' components, got %v", updated.Components)
+	}
+}
+
+func TestUpdateLabels(t *testing.T) {
+	s := New()
+	created, _ := s.Create(&entityv1.Entity{Id: "l1", Labels: map[string]string{"exercise": "alpha"}})
+	if created.LabelsHlc.GetPhysical() != created.HlcPhysical || created.LabelsHlc.GetLogical() != created.HlcLogical {
+		t.Fatalf("expected the labels stamped by the create, got %v", created.LabelsHlc)
+	}
+
+	// Labels written without having seen the current version are ignored,
+	// and a patch leaves them alone.
+	if _, err := s.Update(&entityv1.Entity{Id: "l1", Labels: map[string]string{"exercise": "stale"}}); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if _, err := s.Patch("l1", map[string]*anypb.Any{"threat": makeAnyString(t, "high")}); err != nil {
+		t.Fatalf("Patch: %v", err)
+	}
+	if got, _ := s.Get("l1"); got.Labels["exercise"] != "alpha" || !proto.Equal(got.LabelsHlc, created.LabelsHlc) {
+		t.Fatalf("expected labels kept with their stamp, got %v at %v", got.Labels, got.LabelsHlc)
+	}
+
+	read, _ := s.Get("l1")
+	read.Labels = map[string]string{"exercise": "bravo", "side": "blue"}
+	updated, err := s.Update(read)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if len(updated.Labels) != 2 || updated.Labels["exercise"] != "bravo" {
+		t.Fatalf("expected labels replaced, got %v", updated.Labels)
+	}
+	if updated.LabelsHlc.GetPhysical() != updated.HlcPhysical || updated.LabelsHlc.GetLogical() != updated.HlcLogical {
+		t.Fatalf("expected the labels restamped by the update, got %v", updated.LabelsHlc)
+	}
+	if updated.Components["threat"] == nil {
+		t.Fatal("expected components kept")
+	}
+
+	// An update carrying the labels' stamp but no labels clears them.
+	updated.Labels = nil
+	cleared, err := s.Update(updated)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if len(cleared.Labels) != 0 || cleared.LabelsHlc.GetPhysical() != cleared.HlcPhysical || cleared.LabelsHlc.GetLogical() != cleared.HlcLogical {
+		t.Fatalf("expected the labels cleared by the update, got %v at %v", cleared.Labels, cleared.LabelsHlc)
+	}
+}
+
 func TestDelete(t *testing.T) {
 	s := New()
 	_, _ = s.Create(&entityv1.Entity{Id: "d1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET})
@@ -140,6 +232,21 @@ func TestWatch(t *testing.T) {
 	}
 }
 
+func TestWatcherCount(t *testing.T) {
+	s := New()
+	if n := s.WatcherCount(); n != 0 {
+		t.Fatalf("expected 0 watchers, got %d", n)
+	}
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	if n := s.WatcherCount(); n != 1 {
+		t.Fatalf("expected 1 watcher, got %d", n)
+	}
+	s.Unwatch(w)
+	if n := s.WatcherCount(); n != 0 {
+		t.Fatalf("expected 0 watchers after unwatch, got %d", n)
+	}
+}
+
 func TestWatchWithFilter(t *testing.T) {
 	s := New()
 
@@ -169,6 +276,94 @@ func TestWatchWithFilter(t *testing.T) {
 	}
 }
 
+func TestWatchFrom(t *testing.T) {
+	s := New()
+
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	_, _ = s.Create(&entityv1.Entity{Id: "r1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	first := <-w.Events
+	s.Unwatch(w)
+
+	// Missed while disconnected.
+	_, _ = s.Create(&entityv1.Entity{Id: "r2", Type: entityv1.EntityType_ENTITY_TYPE_ASSET})
+	_, _ = s.Create(&entityv1.Entity{Id: "r3", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+
+	w, err := s.WatchFrom(entityv1.EntityType_ENTITY_TYPE_TRACK, first.Sequence)
+	if err != nil {
+		t.Fatalf("WatchFrom: %v", err)
+	}
+	defer s.Unwatch(w)
+	if len(w.Backlog) != 1 || w.Backlog[0].Entity.Id != "r3" {
+		t.Fatalf("expected backlog [r3], got %v", w.Backlog)
+	}
+	if w.Backlog[0].Sequence != first.Sequence+2 {
+		t.Fatalf("expected sequence %d, got %d", first.Sequence+2, w.Backlog[0].Sequence)
+	}
+
+	_ = s.Delete("r3")
+	select {
+	case event := <-w.Events:
+		if event.Type != storev1.EventType_EVENT_TYPE_DELETED || event.Sequence != first.Sequence+3 {
+			t.Fatalf("expected DELETED at %d, got %v at %d", first.Sequence+3, event.Type, event.Sequence)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for live event")
+	}
+}
+
+func TestWatchSnapshot(t *testing.T) {
+	s := New()
+	_, _ = s.Create(&entityv1.Entity{Id: "s2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	_, _ = s.Create(&entityv1.Entity{Id: "s1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	_, _ = s.Create(&entityv1.Entity{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET})
+	_ = s.Delete("s2")
+
+	w := s.WatchSnapshot(entityv1.EntityType_ENTITY_TYPE_TRACK)
+	defer s.Unwatch(w)
+	if len(w.Backlog) != 1 || w.Backlog[0].Entity.Id != "s1" || w.Backlog[0].Type != storev1.EventType_EVENT_TYPE_CREATED {
+		t.Fatalf("expected a snapshot of s1, got %v", w.Backlog)
+	}
+
+	_, _ = s.Create(&entityv1.Entity{Id: "s3", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	event := <-w.Events
+	if event.Entity.Id != "s3" || event.Sequence != w.Backlog[0].Sequence+1 {
+		t.Fatalf("expected s3 right after the snapshot's sequence %d, got %s at %d", w.Backlog[0].Sequence, event.Entity.Id, event.Sequence)
+	}
+}
+
+func TestWatchFromGap(t *testing.T) {
+	s := New()
+
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	_, _ = s.Create(&entityv1.Entity{Id: "g0", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	first := <-w.Events
+	s.Unwatch(w)
+
+	// Up to date: nothing missed.
+	w, err := s.WatchFrom(0, first.Sequence)
+	if err != nil || len(w.Backlog) != 0 {
+		t.Fatalf("expected empty backlog, got %v, %v", w, err)
+	}
+	s.Unwatch(w)
+
+	// From a later incarnation of the store.
+	if _, err := s.WatchFrom(0, first.Sequence+1); !errors.Is(err, ErrResumeGap) {
+		t.Fatalf("expected ErrResumeGap for a future sequence, got %v", err)
+	}
+
+	for i := range eventBacklog + 1 {
+		if _, err := s.Patch("g0", map[string]*anypb.Any{"n": makeAnyString(t, fmt.Sprint(i))}); err != nil {
+			t.Fatalf("Patch: %v", err)
+		}
+	}
+	if _, err := s.WatchFrom(0, first.Sequence); !errors.Is(err, ErrResumeGap) {
+		t.Fatalf("expected ErrResumeGap once the backlog moved on, got %v", err)
+	}
+	if _, err := s.WatchFrom(0, first.Sequence+2); err != nil {
+		t.Fatalf("WatchFrom at the oldest held event: %v", err)
+	}
+}
+
 func TestTTLExpiration(t *testing.T) {
 	s := New()
 
@@ -197,6 +392,59 @@ func TestTTLExpiration(t *testing.T) {
 	}
 }
 
+func TestDeleteClearsTTL(t *testing.T) {
+	s := New()
+
+	_, _ = s.Create(&entityv1.Entity{Id: "ttl-1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	s.SetTTL("ttl-1", 10*time.Millisecond)
+	_ = s.Delete("ttl-1")
+
+	// Recreated without a TTL, the entity must not inherit the old expiry.
+	_, _ = s.Create(&entityv1.Entity{Id: "ttl-1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	time.Sleep(20 * time.Millisecond)
+	s.reap()
+
+	if _, err := s.Get("ttl-1"); err != nil {
+		t.Fatalf("recreated entity was reaped: %v", err)
+	}
+}
+
+func TestExpireEvent(t *testing.T) {
+	s := New(WithHistory(4))
+	_, _ = s.Create(&entityv1.Entity{Id: "ttl-1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	_, _ = s.Create(&entityv1.Entity{Id: "ttl-2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	s.SetTTL("ttl-1", time.Millisecond)
+	s.SetTTL("ttl-2", time.Millisecond)
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	defer s.Unwatch(w)
+
+	// ttl-2 is written with a fresh TTL after the reaper's scan.
+	now := time.Now().Add(time.Second)
+	s.SetTTL("ttl-2", time.Hour)
+	if err := s.expire("ttl-1", now); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+	if err := s.expire("ttl-2", now); err != nil {
+		t.Fatalf("expire: %v", err)
+	}
+
+	event := <-w.Events
+	if event.Type != storev1.EventType_EVENT_TYPE_EXPIRED || event.Entity.Id != "ttl-1" {
+		t.Fatalf("expected an EXPIRED event for ttl-1, got %v", event)
+	}
+	select {
+	case event := <-w.Events:
+		t.Fatalf("expected the refreshed ttl-2 kept, got %v", event)
+	default:
+	}
+	if _, ok := s.Tombstone("ttl-1"); !ok {
+		t.Fatal("expected an expired entity to leave a tombstone")
+	}
+	if vs := s.History("ttl-1"); len(vs) == 0 || vs[len(vs)-1].Type != storev1.EventType_EVENT_TYPE_EXPIRED {
+		t.Fatalf("expected the expiry last in history, got %v", vs)
+	}
+}
+
 // --- HLC Integration Tests ---
 
 func TestNew_DefaultNodeID(t *testing.T) {
@@ -411,3 +659,308 @@ func TestUpdate_SameKeyStaleHLCKept(t *testing.T) {
 			created.HlcPhysical, updated.HlcPhysical)
 	}
 }
+
+func TestRestore(t *testing.T) {
+	src := New(WithNodeID("src"))
+	t1, _ := src.Create(&entityv1.Entity{Id: "t1"})
+	t2, _ := src.Create(&entityv1.Entity{Id: "t2"})
+
+	dst := New(WithNodeID("dst"))
+	if _, err := dst.Create(&entityv1.Entity{Id: "t2"}); err != nil {
+		t.Fatal(err)
+	}
+	newer, _ := src.Update(&entityv1.Entity{Id: "t2"})
+
+	if got, _ := dst.Restore(t1); got != RestoreCreated {
+		t.Fatalf("expected t1 created, got %v", got)
+	}
+	if got, _ := dst.Restore(t2); got != RestoreSkipped {
+		t.Fatalf("expected older t2 skipped, got %v", got)
+	}
+	if got, _ := dst.Restore(newer); got != RestoreReplaced {
+		t.Fatalf("expected newer t2 replaced, got %v", got)
+	}
+	got, _ := dst.Get("t2")
+	if got.HlcNode != "src" || got.HlcPhysical != newer.HlcPhysical || got.HlcLogical != newer.HlcLogical {
+		t.Fatalf("expected restored HLC kept, got %v", got)
+	}
+
+	// Writes after a restore order after it.
+	after, _ := dst.Update(&entityv1.Entity{Id: "t2"})
+	if !hlcOf(after).After(hlcOf(newer)) {
+		t.Fatalf("expected update after restored HLC, got %v", after)
+	}
+}
+
+func TestPatch_StampsOnlyPatchedComponents(t *testing.T) {
+	s := New(WithNodeID("patch-node"))
+	created, err := s.Create(&entityv1.Entity{
+		Id: "p1",
+		Components: map[string]*anypb.Any{
+			"position": makeAnyString(t, "pos"),
+			"velocity": makeAnyString(t, "vel"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patched, err := s.Patch("p1", map[string]*anypb.Any{"threat": makeAnyString(t, "high")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(patched.Components) != 3 {
+		t.Fatalf("expected 3 components after patch, got %d", len(patched.Components))
+	}
+	if got := componentHLC(patched, "threat"); hlc.Compare(got, hlcOf(patched)) != 0 {
+		t.Fatalf("expected threat stamped with the patch HLC, got %v", got)
+	}
+	if got := componentHLC(patched, "position"); hlc.Compare(got, hlcOf(created)) != 0 {
+		t.Fatalf("expected position to keep its create stamp, got %v", got)
+	}
+
+	c, ts, err := s.GetComponent("p1", "threat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sv wrapperspb.StringValue
+	if err := c.UnmarshalTo(&sv); err != nil || sv.Value != "high" || hlc.Compare(ts, hlcOf(patched)) != 0 {
+		t.Fatalf("GetComponent: got %q at %v", sv.Value, ts)
+	}
+	if _, _, err := s.GetComponent("p1", "missing"); err == nil {
+		t.Fatal("expected error for a missing component")
+	}
+	if _, err := s.Patch("nope", map[string]*anypb.Any{"threat": makeAnyString(t, "x")}); err == nil {
+		t.Fatal("expected error patching a missing entity")
+	}
+}
+
+func TestMerge_KeepsComponentStamps(t *testing.T) {
+	a, b, c := New(WithNodeID("node-a")), New(WithNodeID("node-b")), New(WithNodeID("node-c"))
+	merge := func(s *Store, e *entityv1.Entity) *entityv1.Entity {
+		t.Helper()
+		r := s.Batch([]Write{{Op: OpMerge, Entity: e}})[0]
+		if r.Err != nil {
+			t.Fatalf("merge: %v", r.Err)
+		}
+		return r.Entity
+	}
+	fromA, err := a.Create(&entityv1.Entity{Id: "t1", Components: map[string]*anypb.Any{"position": makeAnyString(t, "a")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// C takes A's copy and moves the track; B takes A's copy only after.
+	if got := componentHLC(merge(c, fromA), "position"); hlc.Compare(got, hlcOf(fromA)) != 0 {
+		t.Fatalf("expected C's copy to keep A's stamp, got %v", got)
+	}
+	fromC, err := c.Patch("t1", map[string]*anypb.Any{"position": makeAnyString(t, "c")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Create(&entityv1.Entity{Id: "t1", Components: map[string]*anypb.Any{"velocity": makeAnyString(t, "b")}}); err != nil {
+		t.Fatal(err)
+	}
+	atB := merge(b, fromA)
+	if got := componentHLC(atB, "position"); hlc.Compare(got, hlcOf(fromA)) != 0 {
+		t.Fatalf("expected B's position to keep A's stamp, not B's merge's, got %v", got)
+	}
+
+	// So C's later move still beats A's position at B, and B's own
+	// velocity survives alongside it.
+	atB = merge(b, fromC)
+	var pos, vel wrapperspb.StringValue
+	atB.Components["position"].UnmarshalTo(&pos) //nolint:errcheck
+	atB.Components["velocity"].UnmarshalTo(&vel) //nolint:errcheck
+	if pos.Value != "c" || vel.Value != "b" {
+		t.Fatalf("expected C's position and B's velocity, got %q and %q", pos.Value, vel.Value)
+	}
+	if got := componentHLC(atB, "position"); hlc.Compare(got, componentHLC(fromC, "position")) != 0 {
+		t.Fatalf("expected position stamped by C's patch, got %v", got)
+	}
+}
+
+func TestMerge_UsesStrategies(t *testing.T) {
+	r := crdt.NewRegistry()
+	if err := r.Assign("callsign", crdt.MinWins); err != nil {
+		t.Fatal(err)
+	}
+	s, peer := New(WithStrategies(r)), New()
+	if _, err := s.Create(&entityv1.Entity{Id: "t1", Components: map[string]*anypb.Any{"callsign": makeAnyUint(t, 1)}}); err != nil {
+		t.Fatal(err)
+	}
+	later, err := peer.Create(&entityv1.Entity{Id: "t1", Components: map[string]*anypb.Any{"callsign": makeAnyUint(t, 9)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := s.Batch([]Write{{Op: OpMerge, Entity: later}})[0]
+	var v wrapperspb.UInt64Value
+	if got.Err != nil || got.Entity.Components["callsign"].UnmarshalTo(&v) != nil || v.Value != 1 {
+		t.Fatalf("expected the store's min-wins to keep 1, got %v, %v", got.Entity, got.Err)
+	}
+}
+
+func TestMerge_KeepsEarliestCreatedAt(t *testing.T) {
+	first, second := New(WithNodeID("node-a")), New(WithNodeID("node-b"))
+	early, err := first.Create(&entityv1.Entity{Id: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+	late, err := second.Create(&entityv1.Entity{Id: "t1", Components: map[string]*anypb.Any{"label": makeAnyString(t, "x")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Either store, merging the other's copy, keeps the first creation.
+	for _, tc := range []struct {
+		s    *Store
+		from *entityv1.Entity
+	}{{second, early}, {first, late}} {
+		r := tc.s.Batch([]Write{{Op: OpMerge, Entity: tc.from}})[0]
+		if r.Err != nil || !proto.Equal(r.Entity.CreatedAt, early.CreatedAt) {
+			t.Fatalf("expected created at %v, got %v, %v", early.CreatedAt, r.Entity.GetCreatedAt(), r.Err)
+		}
+	}
+}
+
+func TestIncrement(t *testing.T) {
+	a, b := New(WithNodeID("node-a")), New(WithNodeID("node-b"))
+	created, err := a.Create(&entityv1.Entity{Id: "t1", Components: map[string]*anypb.Any{"label": makeAnyString(t, "x")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := b.Batch([]Write{{Op: OpMerge, Entity: created}})[0]; r.Err != nil {
+		t.Fatalf("merge at B: %v", r.Err)
+	}
+
+	value := func(e *entityv1.Entity) int64 {
+		t.Helper()
+		var c entityv1.CounterComponent
+		if err := e.Components["detections_count"].UnmarshalTo(&c); err != nil {
+			t.Fatalf("counter: %v", err)
+		}
+		return crdt.CounterValue(&c)
+	}
+	// Each store counts concurrently, unaware of the other.
+	for _, delta := range []int64{3, -1} {
+		if _, err := a.Increment("t1", "detections_count", delta); err != nil {
+			t.Fatalf("increment at A: %v", err)
+		}
+	}
+	atB, err := b.Increment("t1", "detections_count", 4)
+	if err != nil {
+		t.Fatalf("increment at B: %v", err)
+	}
+	if got := value(atB); got != 4 {
+		t.Fatalf("expected 4 at B, got %d", got)
+	}
+
+	// Merged, neither store's counts are lost to the other's.
+	r := a.Batch([]Write{{Op: OpMerge, Entity: atB}})[0]
+	if r.Err != nil || value(r.Entity) != 6 {
+		t.Fatalf("expected 3-1+4 = 6 after merging B into A, got %v, %v", r.Entity, r.Err)
+	}
+	atA, _ := a.Get("t1")
+	if r := b.Batch([]Write{{Op: OpMerge, Entity: atA}})[0]; r.Err != nil || value(r.Entity) != 6 {
+		t.Fatalf("expected 6 after merging A into B, got %v, %v", r.Entity, r.Err)
+	}
+
+	if _, err := a.Increment("t1", "label", 1); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected ErrInvalid incrementing a non-counter, got %v", err)
+	}
+	if _, err := a.Increment("missing", "detections_count", 1); err == nil {
+		t.Fatal("expected an error for a missing entity")
+	}
+}
+
+func makeAnyUint(t *testing.T, val uint64) *anypb.Any {
+	t.Helper()
+	a, err := anypb.New(wrapperspb.UInt64(val))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+func TestUpdate_PatchDoesNotMakeOtherComponentsStale(t *testing.T) {
+	s := New(WithNodeID("patch-stale"))
+	read, _ := s.Create(&entityv1.Entity{
+		Id:         "p1",
+		Components: map[string]*anypb.Any{"position": makeAnyString(t, "old")},
+	})
+
+	// The classifier patches threat after the sensor read the entity...
+	if _, err := s.Patch("p1", map[string]*anypb.Any{"threat": makeAnyString(t, "high")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// ...so the sensor's write, based on that read, is stale for threat
+	// only.
+	read.Components["position"] = makeAnyString(t, "new")
+	read.Components["threat"] = makeAnyString(t, "none")
+	updated, err := s.Update(read)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pos, threat wrapperspb.StringValue
+	updated.Components["position"].UnmarshalTo(&pos)  //nolint:errcheck
+	updated.Components["threat"].UnmarshalTo(&threat) //nolint:errcheck
+	if pos.Value != "new" || threat.Value != "high" {
+		t.Fatalf("expected new position and patched threat, got %q and %q", pos.Value, threat.Value)
+	}
+}
+
+func TestMaxDrift(t *testing.T) {
+	fast := hlc.NewClock("node-fast")
+	fast.SetOffset(time.Hour)
+	s, peer := New(WithMaxDrift(time.Minute, 0)), New(WithClock(fast))
+	ahead, err := peer.Create(&entityv1.Entity{Id: "t1", Components: map[string]*anypb.Any{"position": makeAnyString(t, "p")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A copy from a peer whose clock runs an hour fast is refused, and
+	// does not drag the store's clock ahead.
+	if r := s.Batch([]Write{{Op: OpMerge, Entity: ahead}})[0]; !errors.Is(r.Err, hlc.ErrDrift) {
+		t.Fatalf("expected ErrDrift merging a copy an hour ahead, got %v", r.Err)
+	}
+	if _, err := s.Get("t1"); err == nil {
+		t.Fatal("expected the refused copy not stored")
+	}
+	created, err := s.Create(&entityv1.Entity{Id: "t2"})
+	if err != nil {
+		t.Fatalf("expected the store's own writes to go on, got %v", err)
+	}
+	if hlcOf(created).Physical >= hlcOf(ahead).Physical {
+		t.Fatalf("expected the store's clock near the wall, got %v", hlcOf(created))
+	}
+}
+
+func TestPersistClock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hlc.json")
+	s := New(WithNodeID("node-a"))
+	if err := s.PersistClock(path); err != nil {
+		t.Fatal(err)
+	}
+	before, err := s.Create(&entityv1.Entity{Id: "t1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Restarted with the wall clock an hour back, the store's writes still
+	// order after the ones before the restart.
+	clock := hlc.NewClock("node-a")
+	clock.SetOffset(-time.Hour)
+	restarted := New(WithClock(clock))
+	if err := restarted.PersistClock(path); err != nil {
+		t.Fatal(err)
+	}
+	after, err := restarted.Create(&entityv1.Entity{Id: "t2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hlcOf(after).After(hlcOf(before)) {
+		t.Fatalf("expected %v after %v", hlcOf(after), hlcOf(before))
+	}
+}
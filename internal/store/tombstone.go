@@ -0,0 +1,174 @@
+package store
+
+import (
+	"errors"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"github.com/boshu2/lattice-lab/internal/crdt"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultTombstoneTTL is how long a store remembers a delete unless
+// WithTombstoneTTL says otherwise.
+const DefaultTombstoneTTL = time.Hour
+
+// ErrDeleted is returned when a write carries a copy of an entity that is
+// older than the entity's deletion.
+var ErrDeleted = errors.New("entity was deleted after this version")
+
+// tombstone remembers a deleted entity, so a stale copy of it relayed from
+// a partitioned peer is refused instead of bringing it back.
+type tombstone struct {
+	hlc hlc.Timestamp // the delete's
+	at  time.Time     // when it was recorded, for GC
+}
+
+// WithTombstoneTTL sets how long tombstones are kept before the reaper
+// collects them; zero keeps them for the life of the store. A tombstone
+// must outlive any partition a stale copy could be held across.
+func WithTombstoneTTL(ttl time.Duration) Option {
+	return func(s *Store) { s.tombstoneTTL = ttl }
+}
+
+// Tombstone returns the HLC of the delete recorded for an entity that is
+// gone, if its tombstone is still kept.
+func (s *Store) Tombstone(id string) (hlc.Timestamp, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tombstones[id]
+	return t.hlc, ok
+}
+
+// buried reports whether e is a copy from before the entity's deletion.
+// A copy without an HLC is a new write, never stale. Must hold mu.
+func (s *Store) buried(e *entityv1.Entity) bool {
+	t, ok := s.tombstones[e.Id]
+	if !ok || e.HlcPhysical == 0 {
+		return false
+	}
+	return crdt.MergeTombstone(e, t.hlc) == nil
+}
+
+// bury records a delete stamped ts, keeping the later of it and any
+// tombstone already held. Reports whether the tombstone changed. Must hold
+// mu.
+func (s *Store) bury(id string, ts hlc.Timestamp) bool {
+	if t, ok := s.tombstones[id]; ok && !ts.After(t.hlc) {
+		return false
+	}
+	s.tombstones[id] = tombstone{hlc: ts, at: time.Now()}
+	return true
+}
+
+// deletedRecords returns the tombstones as log records. Must hold mu.
+func (s *Store) deletedRecords() []*entityv1.Entity {
+	out := make([]*entityv1.Entity, 0, len(s.tombstones))
+	for id, t := range s.tombstones {
+		out = append(out, &entityv1.Entity{Id: id, HlcPhysical: t.hlc.Physical, HlcLogical: t.hlc.Logical, HlcNode: t.hlc.Node})
+	}
+	return out
+}
+
+// WithComponentTombstoneTTL sets how long a removed component's tombstone
+// is kept in its entity, by the removal's HLC, before the reaper collects
+// it; zero keeps them for the life of the entity. Like WithTombstoneTTL, it
+// must outlive any partition a copy still holding the component could be
+// held across.
+func WithComponentTombstoneTTL(ttl time.Duration) Option {
+	return func(s *Store) { s.componentTombstoneTTL = ttl }
+}
+
+// readded reports whether e, written over existing, which lacks component
+// key, may set it: always, unless existing removed it; then only if e set
+// it after the removal, by its stamp for the component or, lacking one,
+// its entity HLC, as for a client that read the entity since.
+func readded(e, existing *entityv1.Entity, key string) bool {
+	tomb, ok := existing.RemovedComponents[key]
+	if !ok {
+		return true
+	}
+	if own, ok := e.ComponentHlc[key]; ok {
+		return stampOf(own).After(stampOf(tomb))
+	}
+	incoming := hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
+	return hlc.Compare(incoming, stampOf(tomb)) >= 0
+}
+
+// applyRemovals records in dst each component tombstone src carries that
+// is later than dst's own and not yet due for collection, then drops the
+// writes to dst's components made no later than their key's tombstone, as
+// crdt.Registry.Prune. dst must be a copy with its components stamped.
+// Must hold mu.
+func (s *Store) applyRemovals(dst, src *entityv1.Entity) {
+	cutoff := s.componentTombstoneCutoff(time.Now())
+	for key, tomb := range src.RemovedComponents {
+		if tomb.Physical < cutoff {
+			continue
+		}
+		if have, ok := dst.RemovedComponents[key]; ok && !stampOf(tomb).After(stampOf(have)) {
+			continue
+		}
+		if dst.RemovedComponents == nil {
+			dst.RemovedComponents = make(map[string]*entityv1.HLCTimestamp)
+		}
+		dst.RemovedComponents[key] = proto.Clone(tomb).(*entityv1.HLCTimestamp)
+	}
+	s.strategies.Prune(dst)
+}
+
+// componentTombstoneCutoff returns the HLC physical time, as of now, that
+// component tombstones stamped before are due for collection; zero if
+// they are kept for good.
+func (s *Store) componentTombstoneCutoff(now time.Time) uint64 {
+	if s.componentTombstoneTTL <= 0 {
+		return 0
+	}
+	return uint64(now.Add(-s.componentTombstoneTTL).UnixNano())
+}
+
+// collectComponentTombstones drops component tombstones older than the
+// component tombstone TTL. The entity is not rewritten: dropping one
+// changes nothing a reader sees.
+func (s *Store) collectComponentTombstones(now time.Time) {
+	cutoff := s.componentTombstoneCutoff(now)
+	if cutoff == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, e := range s.entities {
+		var stale []string
+		for key, tomb := range e.RemovedComponents {
+			if tomb.Physical < cutoff {
+				stale = append(stale, key)
+			}
+		}
+		if len(stale) == 0 {
+			continue
+		}
+		e = proto.Clone(e).(*entityv1.Entity)
+		for _, key := range stale {
+			delete(e.RemovedComponents, key)
+		}
+		if len(e.RemovedComponents) == 0 {
+			e.RemovedComponents = nil
+		}
+		s.entities[id] = e
+	}
+}
+
+// collectTombstones drops tombstones older than the TTL.
+func (s *Store) collectTombstones(now time.Time) {
+	if s.tombstoneTTL <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, t := range s.tombstones {
+		if now.Sub(t.at) > s.tombstoneTTL {
+			delete(s.tombstones, id)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestTombstoneRefusesStaleCopies(t *testing.T) {
+	s := New()
+	created, err := s.Create(&entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	stale := proto.Clone(created).(*entityv1.Entity)
+	if err := s.Delete("t1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := s.Tombstone("t1"); !ok {
+		t.Fatal("expected a tombstone after delete")
+	}
+
+	if _, err := s.Create(stale); !errors.Is(err, ErrDeleted) {
+		t.Fatalf("expected ErrDeleted for a stale create, got %v", err)
+	}
+	if outcome, err := s.Restore(stale); err != nil || outcome != RestoreSkipped {
+		t.Fatalf("expected a stale restore to be skipped, got %v, %v", outcome, err)
+	}
+
+	// A new write without an HLC recreates the entity.
+	if _, err := s.Create(&entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}); err != nil {
+		t.Fatalf("Create after delete: %v", err)
+	}
+	if _, ok := s.Tombstone("t1"); ok {
+		t.Fatal("expected recreating the entity to clear its tombstone")
+	}
+}
+
+func TestDeleteAt(t *testing.T) {
+	s := New()
+	created, _ := s.Create(&entityv1.Entity{Id: "d1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	defer s.Unwatch(w)
+
+	// A delete from before the entity's last write loses to it.
+	before := hlc.Timestamp{Physical: created.HlcPhysical - 1, Node: "peer"}
+	if err := s.DeleteAt("d1", before); err != nil {
+		t.Fatalf("DeleteAt: %v", err)
+	}
+	if _, err := s.Get("d1"); err != nil {
+		t.Fatalf("expected d1 to survive an older delete: %v", err)
+	}
+
+	after := hlc.Timestamp{Physical: created.HlcPhysical + 1, Node: "peer"}
+	if err := s.DeleteAt("d1", after); err != nil {
+		t.Fatalf("DeleteAt: %v", err)
+	}
+	if _, err := s.Get("d1"); err == nil {
+		t.Fatal("expected d1 deleted")
+	}
+	event := <-w.Events
+	if event.Type != storev1.EventType_EVENT_TYPE_DELETED || event.Entity.HlcPhysical != after.Physical || event.Entity.HlcNode != "peer" {
+		t.Fatalf("expected a delete event stamped with the replicated HLC, got %v", event)
+	}
+
+	// A delete of an entity the store never had is remembered.
+	if err := s.DeleteAt("d2", after); err != nil {
+		t.Fatalf("DeleteAt missing: %v", err)
+	}
+	if ts, ok := s.Tombstone("d2"); !ok || ts != after {
+		t.Fatalf("expected tombstone %v, got %v, %v", after, ts, ok)
+	}
+	stale := &entityv1.Entity{Id: "d2", HlcPhysical: after.Physical, HlcNode: "a"}
+	if _, err := s.Create(stale); !errors.Is(err, ErrDeleted) {
+		t.Fatalf("expected ErrDeleted, got %v", err)
+	}
+	select {
+	case event := <-w.Events:
+		t.Fatalf("expected no event for a delete of a missing entity, got %v", event)
+	default:
+	}
+}
+
+func TestTombstoneGC(t *testing.T) {
+	s := New(WithTombstoneTTL(time.Minute))
+	_, _ = s.Create(&entityv1.Entity{Id: "g1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	_ = s.Delete("g1")
+
+	s.collectTombstones(time.Now())
+	if _, ok := s.Tombstone("g1"); !ok {
+		t.Fatal("expected the tombstone kept within its TTL")
+	}
+	s.collectTombstones(time.Now().Add(2 * time.Minute))
+	if _, ok := s.Tombstone("g1"); ok {
+		t.Fatal("expected the tombstone collected after its TTL")
+	}
+
+	forever := New(WithTombstoneTTL(0))
+	_, _ = forever.Create(&entityv1.Entity{Id: "g1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	_ = forever.Delete("g1")
+	forever.collectTombstones(time.Now().Add(24 * time.Hour))
+	if _, ok := forever.Tombstone("g1"); !ok {
+		t.Fatal("expected a zero TTL to keep tombstones")
+	}
+}
+
+func TestTombstoneSurvivesRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.wal")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	_, _ = s.Create(&entityv1.Entity{Id: "r1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	_ = s.Delete("r1")
+	want, _ := s.Tombstone("r1")
+	replicated := hlc.Timestamp{Physical: want.Physical + 1, Node: "peer"}
+	_ = s.DeleteAt("r2", replicated)
+	s.Close()
+
+	s, err = Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	if got, ok := s.Tombstone("r1"); !ok || got != want {
+		t.Fatalf("expected tombstone %v after recovery, got %v, %v", want, got, ok)
+	}
+	if got, ok := s.Tombstone("r2"); !ok || got != replicated {
+		t.Fatalf("expected replicated tombstone %v after recovery, got %v, %v", replicated, got, ok)
+	}
+	if len(s.List(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)) != 0 {
+		t.Fatal("expected no entities after recovery")
+	}
+}
+
+func TestRemoveComponents(t *testing.T) {
+	a, b := New(WithNodeID("node-a")), New(WithNodeID("node-b"))
+	created, err := a.Create(&entityv1.Entity{Id: "t1", Components: map[string]*anypb.Any{
+		"position": makeAnyString(t, "p"),
+		"threat":   makeAnyString(t, "high"),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := b.Batch([]Write{{Op: OpMerge, Entity: created}})[0]; r.Err != nil {
+		t.Fatalf("merge at B: %v", r.Err)
+	}
+
+	removed, err := a.RemoveComponents("t1", []string{"threat", "classification"})
+	if err != nil {
+		t.Fatalf("RemoveComponents: %v", err)
+	}
+	if _, ok := removed.Components["threat"]; ok {
+		t.Fatal("expected threat removed")
+	}
+	if _, ok := removed.RemovedComponents["classification"]; !ok {
+		t.Fatal("expected a tombstone for a component the entity lacked too")
+	}
+	if _, err := a.RemoveComponents("missing", []string{"threat"}); err == nil {
+		t.Fatal("expected an error for a missing entity")
+	}
+
+	// B still holds threat; merging either way keeps it removed.
+	atB, _ := b.Get("t1")
+	if r := a.Batch([]Write{{Op: OpMerge, Entity: atB}})[0]; r.Err != nil || r.Entity.Components["threat"] != nil {
+		t.Fatalf("expected B's copy not to bring threat back at A, got %v, %v", r.Entity, r.Err)
+	}
+	r := b.Batch([]Write{{Op: OpMerge, Entity: removed}})[0]
+	if r.Err != nil || r.Entity.Components["threat"] != nil || r.Entity.Components["position"] == nil {
+		t.Fatalf("expected the removal to reach B, got %v, %v", r.Entity, r.Err)
+	}
+
+	// A stale update from before the removal is refused for threat; one
+	// from a client that read the entity since brings it back.
+	stale := proto.Clone(created).(*entityv1.Entity)
+	stale.ComponentHlc = nil
+	got, err := a.Update(stale)
+	if err != nil || got.Components["threat"] != nil {
+		t.Fatalf("expected a stale update not to bring threat back, got %v, %v", got, err)
+	}
+	got.Components["threat"] = makeAnyString(t, "low")
+	if got, err = a.Update(got); err != nil || got.Components["threat"] == nil {
+		t.Fatalf("expected a fresh update to set threat again, got %v, %v", got, err)
+	}
+}
+
+func TestMergeRemovalBetweenWrites(t *testing.T) {
+	threat := func(level entityv1.ThreatLevel) map[string]*anypb.Any {
+		c, err := anypb.New(&entityv1.ThreatComponent{Level: level})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return map[string]*anypb.Any{"threat": c}
+	}
+	merge := func(s *Store, e *entityv1.Entity) {
+		t.Helper()
+		if r := s.Batch([]Write{{Op: OpMerge, Entity: e}})[0]; r.Err != nil {
+			t.Fatalf("merge: %v", r.Err)
+		}
+	}
+
+	// A raises threat to HIGH; B removes it; C, yet to see the removal,
+	// sets it LOW.
+	a, b, c := New(WithNodeID("node-a")), New(WithNodeID("node-b")), New(WithNodeID("node-c"))
+	fromA, err := a.Create(&entityv1.Entity{Id: "t1", Components: threat(entityv1.ThreatLevel_THREAT_LEVEL_HIGH)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	merge(b, fromA)
+	fromB, err := b.RemoveComponents("t1", []string{"threat"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	merge(c, fromA)
+	fromC, err := c.Patch("t1", threat(entityv1.ThreatLevel_THREAT_LEVEL_LOW))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stores that take the copies in either order keep C's LOW alone.
+	for name, order := range map[string][]*entityv1.Entity{
+		"A, C, B": {fromA, fromC, fromB},
+		"A, B, C": {fromA, fromB, fromC},
+	} {
+		s := New(WithNodeID("node-d"))
+		for _, e := range order {
+			merge(s, e)
+		}
+		got, _ := s.Get("t1")
+		if !proto.Equal(got.Components["threat"], fromC.Components["threat"]) {
+			t.Errorf("%s: expected C's LOW, got %v", name, got.Components["threat"])
+		}
+	}
+}
+
+func TestComponentTombstoneGC(t *testing.T) {
+	s := New(WithComponentTombstoneTTL(time.Minute))
+	_, _ = s.Create(&entityv1.Entity{Id: "g1", Components: map[string]*anypb.Any{"threat": makeAnyString(t, "high")}})
+	_, _ = s.RemoveComponents("g1", []string{"threat"})
+
+	s.collectComponentTombstones(time.Now())
+	if e, _ := s.Get("g1"); e.RemovedComponents["threat"] == nil {
+		t.Fatal("expected the component tombstone kept within its TTL")
+	}
+	s.collectComponentTombstones(time.Now().Add(2 * time.Minute))
+	if e, _ := s.Get("g1"); e.RemovedComponents != nil {
+		t.Fatalf("expected the component tombstone collected after its TTL, got %v", e.RemovedComponents)
+	}
+}
@@ -0,0 +1,156 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/proto"
+)
+
+// Read is one read of a Transact: the entity must exist and, if Expected
+// is set, still be at that version.
+type Read struct {
+	ID       string
+	Expected *hlc.Timestamp
+}
+
+// TxnError says which read or write aborted a Transact.
+type TxnError struct {
+	Read  int // index of the failed read, or -1
+	Write int // index of the failed write, or -1
+	Err   error
+}
+
+func (e *TxnError) Error() string {
+	if e.Read >= 0 {
+		return fmt.Sprintf("read %d: %v", e.Read, e.Err)
+	}
+	return fmt.Sprintf("write %d: %v", e.Write, e.Err)
+}
+
+func (e *TxnError) Unwrap() error { return e.Err }
+
+// Transact reads entities and applies writes atomically: under one lock,
+// every read and write is checked first, and if any would fail, nothing is
+// written and a *TxnError names it. Otherwise the writes are applied in
+// order and the entities read are returned as they were before them. Only
+// a log failure can stop the writes partway. Replicated deletes (Write.At)
+// and merges are not allowed.
+func (s *Store) Transact(reads []Read, writes []Write) ([]*entityv1.Entity, []WriteResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	read := make([]*entityv1.Entity, len(reads))
+	for i, r := range reads {
+		e, ok := s.entities[r.ID]
+		if !ok {
+			return nil, nil, &TxnError{Read: i, Write: -1, Err: fmt.Errorf("entity %q not found", r.ID)}
+		}
+		if r.Expected != nil && *r.Expected != (hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}) {
+			s.stats.conflicts++
+			return nil, nil, &TxnError{Read: i, Write: -1, Err: fmt.Errorf("read %q: %w", r.ID, errConflict(e, *r.Expected))}
+		}
+		read[i] = proto.Clone(e).(*entityv1.Entity)
+	}
+	if i, err := s.checkWrites(writes); err != nil {
+		return nil, nil, &TxnError{Read: -1, Write: i, Err: err}
+	}
+
+	results := make([]WriteResult, len(writes))
+	for i, w := range writes {
+		results[i] = s.writeLocked(w)
+		if err := results[i].Err; err != nil {
+			return nil, results, &TxnError{Read: -1, Write: i, Err: err}
+		}
+	}
+	return read, results, nil
+}
+
+// checkWrites reports the first write that would fail, with the error it
+// would fail with, given the writes before it, cascading deletes,
+// quotas, and component validation included.
+// Must hold mu.
+func (s *Store) checkWrites(writes []Write) (int, error) {
+	present := make(map[string]bool) // entities the writes so far created or removed
+	written := make(map[string]bool) // entities whose version the writes so far changed
+	// Entities of each type the writes so far added, net, for quotas, and
+	// the type of each entity they created.
+	added := make(map[entityv1.EntityType]int)
+	created := make(map[string]entityv1.EntityType)
+	exists := func(id string) bool {
+		if p, ok := present[id]; ok {
+			return p
+		}
+		_, ok := s.entities[id]
+		return ok
+	}
+	var remove func(id string)
+	remove = func(id string) {
+		present[id] = false
+		if typ, ok := created[id]; ok {
+			added[typ]--
+		} else if e, ok := s.entities[id]; ok {
+			added[e.Type]--
+		}
+		for k, cascade := range s.links[id] {
+			if cascade && k.to == id && exists(k.from) {
+				remove(k.from)
+			}
+		}
+	}
+
+	for i, w := range writes {
+		id := w.Entity.GetId()
+		if w.Op == OpCreate || w.Op == OpUpdate || w.Op == OpPatch {
+			if err := s.validateComponents(w.Entity.GetComponents()); err != nil {
+				return i, fmt.Errorf("write %q: %w", id, err)
+			}
+		}
+		switch w.Op {
+		case OpCreate:
+			if exists(id) {
+				if _, ok := present[id]; !ok {
+					return i, errExists(s.entities[id])
+				}
+				return i, fmt.Errorf("entity %q already exists", id)
+			}
+			if _, gone := present[id]; !gone && s.buried(w.Entity) {
+				s.stats.buried++
+				return i, fmt.Errorf("create %q: %w", id, ErrDeleted)
+			}
+			if err := s.checkQuota(w.Entity.Type, added[w.Entity.Type]+1); err != nil {
+				return i, fmt.Errorf("create %q: %w", id, err)
+			}
+			present[id] = true
+			added[w.Entity.Type]++
+			created[id] = w.Entity.Type
+		case OpUpdate, OpPatch, OpRemove, OpIncrement:
+			if !exists(id) {
+				return i, fmt.Errorf("entity %q not found", id)
+			}
+			if w.Expected != nil {
+				e := s.entities[id]
+				if written[id] || *w.Expected != (hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}) {
+					s.stats.conflicts++
+					return i, fmt.Errorf("update %q: %w", id, errConflict(e, *w.Expected))
+				}
+			}
+		case OpDelete:
+			if w.At != nil {
+				return i, errors.New("replicated deletes cannot be part of a transaction")
+			}
+			if !exists(id) {
+				return i, fmt.Errorf("entity %q not found", id)
+			}
+			remove(id)
+		case OpMerge:
+			return i, errors.New("replicated merges cannot be part of a transaction")
+		default:
+			return i, fmt.Errorf("unknown write op %d", w.Op)
+		}
+		written[id] = true
+	}
+	return -1, nil
+}
@@ -0,0 +1,94 @@
+package store
+
+import (
+	"errors"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestTransact(t *testing.T) {
+	s := New()
+	track, _ := s.Create(&entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	_, _ = s.Create(&entityv1.Entity{Id: "a1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET})
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 1, Lon: 2})
+	seen := hlc.Timestamp{Physical: track.HlcPhysical, Logical: track.HlcLogical, Node: track.HlcNode}
+
+	read, results, err := s.Transact([]Read{{ID: "t1", Expected: &seen}}, []Write{
+		{Op: OpPatch, Entity: &entityv1.Entity{Id: "t1", Components: map[string]*anypb.Any{"position": pos}}},
+		{Op: OpPatch, Entity: &entityv1.Entity{Id: "a1", Components: map[string]*anypb.Any{"position": pos}}},
+		{Op: OpCreate, Entity: &entityv1.Entity{Id: "t2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}},
+	})
+	if err != nil {
+		t.Fatalf("Transact: %v", err)
+	}
+	if len(read) != 1 || read[0].Components["position"] != nil {
+		t.Fatalf("expected t1 read as it was before the writes, got %v", read)
+	}
+	if len(results) != 3 || results[1].Entity.Components["position"] == nil {
+		t.Fatalf("expected every write applied, got %v", results)
+	}
+
+	// A stale read aborts the transaction before anything is written.
+	_, _, err = s.Transact([]Read{{ID: "t1", Expected: &seen}}, []Write{
+		{Op: OpCreate, Entity: &entityv1.Entity{Id: "t3", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}},
+	})
+	var txnErr *TxnError
+	if !errors.As(err, &txnErr) || txnErr.Read != 0 || !errors.Is(err, ErrConflict) {
+		t.Fatalf("expected read 0 to conflict, got %v", err)
+	}
+	if _, err := s.Get("t3"); err == nil {
+		t.Fatal("expected nothing written by the aborted transaction")
+	}
+
+	// So does a write that would fail, even after writes that would not.
+	_, _, err = s.Transact(nil, []Write{
+		{Op: OpDelete, Entity: &entityv1.Entity{Id: "a1"}},
+		{Op: OpCreate, Entity: &entityv1.Entity{Id: "t2", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}},
+	})
+	if !errors.As(err, &txnErr) || txnErr.Write != 1 {
+		t.Fatalf("expected write 1 to fail, got %v", err)
+	}
+	if _, err := s.Get("a1"); err != nil {
+		t.Fatalf("expected a1 kept, got %v", err)
+	}
+	if got := s.Stats().Conflicts; got != 1 {
+		t.Fatalf("expected 1 conflict counted, got %d", got)
+	}
+}
+
+func TestTransact_Cascade(t *testing.T) {
+	s := New()
+	for _, id := range []string{"fused", "t1"} {
+		_, _ = s.Create(&entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	}
+	if err := s.AddLink(Link{From: "fused", To: "t1", Relation: "fused_from", Cascade: true}); err != nil {
+		t.Fatalf("AddLink: %v", err)
+	}
+
+	// Deleting t1 deletes fused with it, so a later patch of fused fails.
+	_, _, err := s.Transact(nil, []Write{
+		{Op: OpDelete, Entity: &entityv1.Entity{Id: "t1"}},
+		{Op: OpPatch, Entity: &entityv1.Entity{Id: "fused"}},
+	})
+	var txnErr *TxnError
+	if !errors.As(err, &txnErr) || txnErr.Write != 1 {
+		t.Fatalf("expected the patch of the cascaded entity to fail, got %v", err)
+	}
+	if _, err := s.Get("t1"); err != nil {
+		t.Fatalf("expected t1 kept, got %v", err)
+	}
+
+	// Recreating it in the same transaction is allowed.
+	if _, _, err := s.Transact(nil, []Write{
+		{Op: OpDelete, Entity: &entityv1.Entity{Id: "t1"}},
+		{Op: OpCreate, Entity: &entityv1.Entity{Id: "fused", Type: entityv1.EntityType_ENTITY_TYPE_TRACK}},
+	}); err != nil {
+		t.Fatalf("Transact: %v", err)
+	}
+	if _, err := s.Get("fused"); err != nil {
+		t.Fatalf("expected fused recreated, got %v", err)
+	}
+}
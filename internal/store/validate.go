@@ -0,0 +1,131 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// ErrInvalid is returned by a write carrying a component a validator
+// rejected.
+var ErrInvalid = errors.New("invalid component")
+
+// InvalidError is an ErrInvalid naming the component key and what is
+// wrong with it.
+type InvalidError struct {
+	Key string
+	Err error
+}
+
+func (e *InvalidError) Error() string {
+	return fmt.Sprintf("component %q: %v: %v", e.Key, ErrInvalid, e.Err)
+}
+
+func (e *InvalidError) Unwrap() []error { return []error{ErrInvalid, e.Err} }
+
+// Validator checks one component message, returning what is wrong with it.
+type Validator func(proto.Message) error
+
+// WithValidator adds v to the checks run on every component of the named
+// message type that is created, updated, patched, or restored. Components
+// replayed from the log are not checked.
+func WithValidator(name protoreflect.FullName, v Validator) Option {
+	return func(s *Store) { s.validators[name] = append(s.validators[name], v) }
+}
+
+// WithoutDefaultValidators drops the built-in checks of the entity.v1
+// components, e.g. that a position's latitude lies in [-90, 90].
+func WithoutDefaultValidators() Option {
+	return func(s *Store) {
+		for name := range defaultValidators {
+			delete(s.validators, name)
+		}
+	}
+}
+
+// defaultValidators are the range checks every store runs on the entity.v1
+// components unless WithoutDefaultValidators is given.
+var defaultValidators = map[protoreflect.FullName]Validator{
+	"entity.v1.PositionComponent": func(m proto.Message) error {
+		p := m.(*entityv1.PositionComponent)
+		return errors.Join(latLon(p.Lat, p.Lon), finite("alt", p.Alt))
+	},
+	"entity.v1.VelocityComponent": func(m proto.Message) error {
+		v := m.(*entityv1.VelocityComponent)
+		return errors.Join(between("speed", v.Speed, 0, math.MaxFloat64), finite("heading", v.Heading))
+	},
+	"entity.v1.ClassificationComponent": func(m proto.Message) error {
+		return between("confidence", float64(m.(*entityv1.ClassificationComponent).Confidence), 0, 1)
+	},
+	"entity.v1.FusionComponent": func(m proto.Message) error {
+		f := m.(*entityv1.FusionComponent)
+		return errors.Join(latLon(f.FusedLat, f.FusedLon), between("confidence", float64(f.Confidence), 0, 1))
+	},
+	"entity.v1.GeoComponent": func(m proto.Message) error {
+		g := m.(*entityv1.GeoComponent)
+		errs := []error{between("radius_m", g.RadiusM, 0, math.MaxFloat64), finite("min_alt", g.MinAlt), finite("max_alt", g.MaxAlt)}
+		for i, p := range g.Points {
+			if err := latLon(p.Lat, p.Lon); err != nil {
+				errs = append(errs, fmt.Errorf("point %d: %w", i, err))
+			}
+		}
+		return errors.Join(errs...)
+	},
+	"entity.v1.AssetComponent": func(m proto.Message) error {
+		a := m.(*entityv1.AssetComponent)
+		return errors.Join(between("max_speed_kts", a.MaxSpeedKts, 0, math.MaxFloat64), latLon(a.StationLat, a.StationLon))
+	},
+}
+
+func latLon(lat, lon float64) error {
+	return errors.Join(between("lat", lat, -90, 90), between("lon", lon, -180, 180))
+}
+
+// between requires v to be a number in [lo, hi].
+func between(field string, v, lo, hi float64) error {
+	if math.IsNaN(v) || v < lo || v > hi {
+		return fmt.Errorf("%s %v outside [%v, %v]", field, v, lo, hi)
+	}
+	return nil
+}
+
+func finite(field string, v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return fmt.Errorf("%s %v is not finite", field, v)
+	}
+	return nil
+}
+
+// validateComponents runs the validators registered for each component's
+// message type, in key order, and returns the first rejection. Components
+// of types with no validator are not checked; one that does not unmarshal
+// is rejected.
+func (s *Store) validateComponents(components map[string]*anypb.Any) error {
+	if len(s.validators) == 0 {
+		return nil
+	}
+	for _, key := range slices.Sorted(maps.Keys(components)) {
+		c := components[key]
+		vs := s.validators[c.MessageName()]
+		if len(vs) == 0 {
+			continue
+		}
+		m, err := c.UnmarshalNew()
+		if err != nil {
+			return &InvalidError{Key: key, Err: err}
+		}
+		for _, v := range vs {
+			if err := v(m); err != nil {
+				return &InvalidError{Key: key, Err: err}
+			}
+		}
+	}
+	return nil
+}
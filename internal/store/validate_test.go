@@ -0,0 +1,72 @@
+package store
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func TestValidateDefaults(t *testing.T) {
+	s := New()
+	comps := func(m proto.Message) map[string]*anypb.Any {
+		c, _ := anypb.New(m)
+		return map[string]*anypb.Any{"c": c}
+	}
+	good := comps(&entityv1.PositionComponent{Lat: 45, Lon: -120})
+	if _, err := s.Create(&entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: good}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	for name, bad := range map[string]map[string]*anypb.Any{
+		"lat":        comps(&entityv1.PositionComponent{Lat: 91}),
+		"lon nan":    comps(&entityv1.PositionComponent{Lon: math.NaN()}),
+		"speed":      comps(&entityv1.VelocityComponent{Speed: -1}),
+		"confidence": comps(&entityv1.ClassificationComponent{Confidence: 1.5}),
+		"geo point":  comps(&entityv1.GeoComponent{Points: []*entityv1.GeoPoint{{Lat: 10}, {Lat: -100}}}),
+		"garbage":    {"c": {TypeUrl: "type.googleapis.com/entity.v1.PositionComponent", Value: []byte{0xff}}},
+	} {
+		if _, err := s.Create(&entityv1.Entity{Id: "t2", Components: bad}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected create refused, got %v", name, err)
+		}
+		if _, err := s.Patch("t1", bad); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected patch refused, got %v", name, err)
+		}
+		if _, err := s.Update(&entityv1.Entity{Id: "t1", Components: bad}); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%s: expected update refused, got %v", name, err)
+		}
+	}
+	if _, _, err := s.Transact(nil, []Write{{Op: OpPatch, Entity: &entityv1.Entity{Id: "t1", Components: comps(&entityv1.PositionComponent{Lat: -91})}}}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected transact refused, got %v", err)
+	}
+	if _, err := s.Restore(&entityv1.Entity{Id: "t3", Components: comps(&entityv1.PositionComponent{Lat: 95})}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected restore refused, got %v", err)
+	}
+
+	e, _ := s.Get("t1")
+	if !proto.Equal(e.Components["c"], good["c"]) {
+		t.Fatal("expected the refused writes to leave t1 unchanged")
+	}
+}
+
+func TestValidateCustom(t *testing.T) {
+	noLabel := func(m proto.Message) error {
+		if m.(*entityv1.ClassificationComponent).Label == "" {
+			return errors.New("label is required")
+		}
+		return nil
+	}
+	s := New(WithoutDefaultValidators(), WithValidator("entity.v1.ClassificationComponent", noLabel))
+
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: 91})
+	if _, err := s.Create(&entityv1.Entity{Id: "t1", Components: map[string]*anypb.Any{"position": pos}}); err != nil {
+		t.Fatalf("expected the default checks dropped, got %v", err)
+	}
+	cl, _ := anypb.New(&entityv1.ClassificationComponent{Confidence: 2})
+	if _, err := s.Patch("t1", map[string]*anypb.Any{"classification": cl}); !errors.Is(err, ErrInvalid) {
+		t.Fatalf("expected the custom check to refuse the patch, got %v", err)
+	}
+}
@@ -0,0 +1,253 @@
+package store
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// compactMin is the fewest records a log holds before it is compacted,
+	// so small stores are not rewritten on every write.
+	compactMin = 1024
+	// maxRecord bounds one record, so a corrupt length cannot exhaust memory.
+	maxRecord = 64 << 20
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrLog wraps failures to write the log. The write they belong to was not
+// applied.
+var ErrLog = errors.New("write-ahead log")
+
+// wal is an append-only log of store writes. Each record is the event the
+// write produced, framed as
+//
+//	[4-byte big-endian length][4-byte CRC-32C][EntityEvent protobuf]
+//
+// Created and updated records carry the entity as stored, so replay puts
+// back exactly what was acknowledged. Deleted records carry the entity
+// stamped with the delete's HLC, or just its ID for a tombstone with no
+// entity behind it.
+type wal struct {
+	path    string
+	f       *os.File
+	sync    bool  // fsync after every append
+	records int   // records in the file, for compaction
+	size    int64 // offset just past the last whole record
+	// broken, once set, fails every append: a failed append could not be
+	// cut back off the log, so another would land behind a partial record.
+	broken error
+}
+
+// openWAL opens or creates the log at path and returns the records it
+// holds. A torn record at the end — a write cut short by a crash — is
+// truncated away; corruption before the end is an error.
+func openWAL(path string, sync bool) (*wal, []*storev1.EntityEvent, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open wal: %w", err)
+	}
+	events, good, err := readRecords(f)
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("read wal %s: %w", path, err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("stat wal: %w", err)
+	}
+	if good < info.Size() {
+		slog.Warn("wal: truncating torn record", "path", path, "offset", good, "size", info.Size())
+		if err := f.Truncate(good); err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("truncate wal: %w", err)
+		}
+	}
+	if _, err := f.Seek(good, io.SeekStart); err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("seek wal: %w", err)
+	}
+	return &wal{path: path, f: f, sync: sync, records: len(events), size: good}, events, nil
+}
+
+// readRecords decodes records from r until the end, returning them and the
+// offset just past the last whole one. Only the final record may be torn.
+func readRecords(r io.Reader) ([]*storev1.EntityEvent, int64, error) {
+	br := bufio.NewReader(r)
+	var (
+		events []*storev1.EntityEvent
+		good   int64
+		header [8]byte
+	)
+	for {
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			// EOF: clean end. ErrUnexpectedEOF: torn header.
+			return events, good, nil
+		}
+		size := binary.BigEndian.Uint32(header[:4])
+		sum := binary.BigEndian.Uint32(header[4:])
+		if size > maxRecord {
+			return nil, 0, fmt.Errorf("record of %d bytes at offset %d", size, good)
+		}
+		payload := make([]byte, size)
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return events, good, nil // torn payload
+		}
+		if crc32.Checksum(payload, castagnoli) != sum {
+			if _, err := br.Peek(1); errors.Is(err, io.EOF) {
+				return events, good, nil // torn final record
+			}
+			return nil, 0, fmt.Errorf("checksum mismatch at offset %d", good)
+		}
+		event := &storev1.EntityEvent{}
+		if err := proto.Unmarshal(payload, event); err != nil {
+			return nil, 0, fmt.Errorf("decode record at offset %d: %w", good, err)
+		}
+		events = append(events, event)
+		good += int64(len(header) + len(payload))
+	}
+}
+
+// frame encodes one record.
+func frame(event *storev1.EntityEvent) ([]byte, error) {
+	payload, err := proto.Marshal(event)
+	if err != nil {
+		return nil, fmt.Errorf("%w: encode record: %w", ErrLog, err)
+	}
+	buf := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.Checksum(payload, castagnoli))
+	return append(buf, payload...), nil
+}
+
+// append writes one record. It returns once the record is in the OS, or on
+// disk if the log syncs, so the write survives the process being killed.
+func (w *wal) append(typ storev1.EventType, e *entityv1.Entity) error {
+	if w.broken != nil {
+		return w.broken
+	}
+	buf, err := frame(&storev1.EntityEvent{Type: typ, Entity: e})
+	if err != nil {
+		return err
+	}
+	if _, err := w.f.Write(buf); err != nil {
+		return w.rollback(fmt.Errorf("%w: append: %w", ErrLog, err))
+	}
+	if w.sync {
+		if err := w.f.Sync(); err != nil {
+			return w.rollback(fmt.Errorf("%w: sync: %w", ErrLog, err))
+		}
+	}
+	w.records++
+	w.size += int64(len(buf))
+	return nil
+}
+
+// rollback cuts off whatever part of a failed append reached the file, so
+// the next append follows the last whole record rather than a partial one
+// that would fail replay mid-log, and returns err. If the file cannot be
+// cut back, the log is broken and refuses further appends.
+func (w *wal) rollback(err error) error {
+	if terr := w.f.Truncate(w.size); terr != nil {
+		w.broken = fmt.Errorf("%w: truncate after failed append: %w", ErrLog, terr)
+		return errors.Join(err, w.broken)
+	}
+	if _, serr := w.f.Seek(w.size, io.SeekStart); serr != nil {
+		w.broken = fmt.Errorf("%w: seek after failed append: %w", ErrLog, serr)
+		return errors.Join(err, w.broken)
+	}
+	return err
+}
+
+// needsCompaction reports whether the log has grown well past the live
+// record count: entities plus tombstones.
+func (w *wal) needsCompaction(live int) bool {
+	return w.records >= compactMin && w.records > 4*live
+}
+
+// compact replaces the log with one created record per live entity and a
+// deleted record per tombstone. The new log is written beside the old and
+// renamed over it, so a crash mid-compaction leaves one or the other
+// intact.
+func (w *wal) compact(entities map[string]*entityv1.Entity, deleted []*entityv1.Entity) error {
+	tmp := w.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("compact wal: %w", err)
+	}
+	var size int64
+	err = writeEntities(f, entities, deleted)
+	if err == nil {
+		size, err = f.Seek(0, io.SeekCurrent)
+	}
+	if err == nil {
+		err = os.Rename(tmp, w.path)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("compact wal: %w", err)
+	}
+	// f now is the log, positioned at its end.
+	w.f.Close()
+	w.f, w.records, w.size, w.broken = f, len(entities)+len(deleted), size, nil
+	// Until the directory is synced, power loss can bring back the old log.
+	if err := syncDir(filepath.Dir(w.path)); err != nil {
+		return fmt.Errorf("compact wal: %w", err)
+	}
+	return nil
+}
+
+// syncDir syncs the directory dir, so a rename in it survives power loss.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// writeEntities writes a created record per entity and a deleted record
+// per tombstone to f and syncs it.
+func writeEntities(f *os.File, entities map[string]*entityv1.Entity, deleted []*entityv1.Entity) error {
+	bw := bufio.NewWriter(f)
+	write := func(typ storev1.EventType, e *entityv1.Entity) error {
+		buf, err := frame(&storev1.EntityEvent{Type: typ, Entity: e})
+		if err != nil {
+			return err
+		}
+		_, err = bw.Write(buf)
+		return err
+	}
+	for _, e := range entities {
+		if err := write(storev1.EventType_EVENT_TYPE_CREATED, e); err != nil {
+			return err
+		}
+	}
+	for _, e := range deleted {
+		if err := write(storev1.EventType_EVENT_TYPE_DELETED, e); err != nil {
+			return err
+		}
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+func (w *wal) close() error {
+	return w.f.Close()
+}
@@ -0,0 +1,185 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/hlc"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func openTest(t *testing.T, path string) *Store {
+	t.Helper()
+	s, err := Open(path, WithNodeID("wal-node"))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func hlcOf(e *entityv1.Entity) hlc.Timestamp {
+	return hlc.Timestamp{Physical: e.HlcPhysical, Logical: e.HlcLogical, Node: e.HlcNode}
+}
+
+func TestOpen_RecoversWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.wal")
+	s := openTest(t, path)
+
+	label, _ := anypb.New(wrapperspb.String("bogey"))
+	for _, id := range []string{"t1", "t2", "t3"} {
+		if _, err := s.Create(&entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	updated, err := s.Update(&entityv1.Entity{Id: "t1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"label": label}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete("t2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen without closing, as after kill -9.
+	r := openTest(t, path)
+	if got := len(r.List(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)); got != 2 {
+		t.Fatalf("expected 2 entities recovered, got %d", got)
+	}
+	t1, err := r.Get("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := t1.Components["label"]; !ok || hlc.Compare(hlcOf(t1), hlcOf(updated)) != 0 {
+		t.Fatalf("expected t1 as last acknowledged, got %v", t1)
+	}
+	if _, err := r.Get("t2"); err == nil {
+		t.Fatal("expected deleted t2 to stay deleted")
+	}
+
+	// New writes order after everything recovered.
+	t3, err := r.Update(&entityv1.Entity{Id: "t3", Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hlcOf(t3).After(hlcOf(updated)) {
+		t.Fatalf("expected new HLC after recovered %v, got %v", hlcOf(updated), hlcOf(t3))
+	}
+}
+
+func TestOpen_TruncatesTornRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.wal")
+	s := openTest(t, path)
+	for i := range 3 {
+		if _, err := s.Create(&entityv1.Entity{Id: fmt.Sprintf("t%d", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+
+	// Cut the last record short, as a crash mid-write would.
+	info, _ := os.Stat(path)
+	if err := os.Truncate(path, info.Size()-3); err != nil {
+		t.Fatal(err)
+	}
+	r := openTest(t, path)
+	if got := len(r.List(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)); got != 2 {
+		t.Fatalf("expected 2 whole records, got %d", got)
+	}
+	if _, err := r.Create(&entityv1.Entity{Id: "t2"}); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	if got := len(openTest(t, path).List(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)); got != 3 {
+		t.Fatalf("expected appends after the truncation point, got %d entities", got)
+	}
+}
+
+func TestOpen_RejectsCorruption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.wal")
+	s := openTest(t, path)
+	for _, id := range []string{"t1", "t2"} {
+		if _, err := s.Create(&entityv1.Entity{Id: id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+
+	data, _ := os.ReadFile(path)
+	data[10] ^= 0xff // inside the first record's payload
+	os.WriteFile(path, data, 0o644)
+	if _, err := Open(path); err == nil {
+		t.Fatal("expected corruption before the end to fail")
+	}
+}
+
+func TestWAL_Compacts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.wal")
+	s := openTest(t, path)
+	if _, err := s.Create(&entityv1.Entity{Id: "t1"}); err != nil {
+		t.Fatal(err)
+	}
+	for range compactMin {
+		if _, err := s.Update(&entityv1.Entity{Id: "t1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.wal.records >= compactMin {
+		t.Fatalf("expected compaction, log holds %d records", s.wal.records)
+	}
+	last, _ := s.Get("t1")
+
+	r := openTest(t, path)
+	got, err := r.Get("t1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hlc.Compare(hlcOf(got), hlcOf(last)) != 0 {
+		t.Fatalf("expected last update after compaction, got %v want %v", hlcOf(got), hlcOf(last))
+	}
+}
+
+func TestWAL_FailedAppendIsNotApplied(t *testing.T) {
+	s := openTest(t, filepath.Join(t.TempDir(), "store.wal"))
+	s.Close()
+	if _, err := s.Create(&entityv1.Entity{Id: "t1"}); !errors.Is(err, ErrLog) {
+		t.Fatalf("expected ErrLog, got %v", err)
+	}
+	if _, err := s.Get("t1"); err == nil {
+		t.Fatal("expected the unlogged create not to be applied")
+	}
+}
+
+func TestWAL_FailedAppendLeavesNoPartialRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.wal")
+	s := openTest(t, path)
+	if _, err := s.Create(&entityv1.Entity{Id: "t1"}); err != nil {
+		t.Fatal(err)
+	}
+	// A write cut short partway through a record, as by a full disk.
+	buf, err := frame(&storev1.EntityEvent{Entity: &entityv1.Entity{Id: "lost"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.wal.f.Write(buf[:len(buf)/2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.wal.rollback(ErrLog); !errors.Is(err, ErrLog) || s.wal.broken != nil {
+		t.Fatalf("expected the partial record cut off, got %v (broken %v)", err, s.wal.broken)
+	}
+	if _, err := s.Create(&entityv1.Entity{Id: "t2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	r := openTest(t, path)
+	for _, id := range []string{"t1", "t2"} {
+		if _, err := r.Get(id); err != nil {
+			t.Fatalf("expected %s recovered: %v", id, err)
+		}
+	}
+}
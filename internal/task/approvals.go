@@ -0,0 +1,46 @@
+package task
+
+import (
+	"cmp"
+	"context"
+
+	taskv1 "github.com/boshu2/lattice-lab/gen/task/v1"
+)
+
+// The entity-store decides approvals through a backend with ApproveAction
+// and DenyAction methods (server.ApprovalBackend). A Manager is one, for a
+// store in the same process; a Remote reaches a task-manager over gRPC.
+
+// ApproveAction approves entityID's pending intercept on operator's
+// behalf, or defaultOperator's if operator is empty.
+func (m *Manager) ApproveAction(_ context.Context, entityID, operator string) error {
+	_, err := m.ApproveAs(entityID, cmp.Or(operator, defaultOperator))
+	return err
+}
+
+// DenyAction is ApproveAction for a denial.
+func (m *Manager) DenyAction(_ context.Context, entityID, operator string) error {
+	return m.DenyAs(entityID, cmp.Or(operator, defaultOperator))
+}
+
+// Remote forwards approvals to a task-manager's TaskManagerService.
+type Remote struct {
+	client taskv1.TaskManagerServiceClient
+}
+
+// NewRemote returns a Remote calling client.
+func NewRemote(client taskv1.TaskManagerServiceClient) *Remote {
+	return &Remote{client: client}
+}
+
+// ApproveAction calls the task-manager's Approve.
+func (r *Remote) ApproveAction(ctx context.Context, entityID, operator string) error {
+	_, err := r.client.Approve(ctx, &taskv1.ApproveRequest{EntityId: entityID, Operator: operator})
+	return err
+}
+
+// DenyAction calls the task-manager's Deny.
+func (r *Remote) DenyAction(ctx context.Context, entityID, operator string) error {
+	_, err := r.client.Deny(ctx, &taskv1.DenyRequest{EntityId: entityID, Operator: operator})
+	return err
+}
@@ -0,0 +1,367 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sort"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// assetState is the manager's view of one taskable asset.
+type assetState struct {
+	id     string
+	busy   bool
+	task   string
+	target string // track ID the asset is committed to
+}
+
+// dispatch pairs a waiting track with the asset reserved for it.
+type dispatch struct {
+	target  string
+	assetID string
+}
+
+// AssetStats summarises asset utilization.
+type AssetStats struct {
+	Total       int
+	Busy        int
+	Queued      int     // tasks waiting for a free asset
+	Utilization float64 // Busy / Total; 0 when no assets are known
+}
+
+// AssetStats returns current asset utilization.
+func (m *Manager) AssetStats() AssetStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.assetStatsLocked()
+}
+
+// assetStatsLocked computes asset utilization. Caller must hold m.mu.
+func (m *Manager) assetStatsLocked() AssetStats {
+	st := AssetStats{Total: len(m.assets), Queued: len(m.queue)}
+	for _, a := range m.assets {
+		if a.busy {
+			st.Busy++
+		}
+	}
+	if st.Total > 0 {
+		st.Utilization = float64(st.Busy) / float64(st.Total)
+	}
+	return st
+}
+
+// freeAssetLocked returns the lowest-ID free asset, or "". Caller must hold m.mu.
+func (m *Manager) freeAssetLocked() string {
+	ids := make([]string, 0, len(m.assets))
+	for id, a := range m.assets {
+		if !a.busy {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	sort.Strings(ids)
+	return ids[0]
+}
+
+// reserveAssetLocked commits a free asset to target and returns its ID. If
+// target already holds an asset, that asset is returned. When no asset is
+// free the target is queued and "" is returned. Caller must hold m.mu.
+func (m *Manager) reserveAssetLocked(target, task string) string {
+	for id, a := range m.assets {
+		if a.busy && a.target == target {
+			return id
+		}
+	}
+
+	id := m.freeAssetLocked()
+	if id == "" {
+		if !slices.Contains(m.queue, target) {
+			m.queue = append(m.queue, target)
+		}
+		return ""
+	}
+	a := m.assets[id]
+	a.busy, a.task, a.target = true, task, target
+	return id
+}
+
+// releaseTargetLocked frees any asset committed to target and drops target
+// from the queue. Returns the freed asset ID, or "". Caller must hold m.mu.
+func (m *Manager) releaseTargetLocked(target string) string {
+	m.queue = slices.DeleteFunc(m.queue, func(id string) bool { return id == target })
+	for id, a := range m.assets {
+		if a.busy && a.target == target {
+			a.busy, a.task, a.target = false, "", ""
+			return id
+		}
+	}
+	return ""
+}
+
+// dispatchQueuedLocked hands free assets to queued intercepts in FIFO order.
+// Queued tracks that no longer need an intercept are dropped. Caller must
+// hold m.mu.
+func (m *Manager) dispatchQueuedLocked() []dispatch {
+	var out []dispatch
+	for len(m.queue) > 0 {
+		target := m.queue[0]
+		if a, ok := m.assignments[target]; !ok || a.State != StateIntercept {
+			m.queue = m.queue[1:]
+			continue
+		}
+
+		id := m.freeAssetLocked()
+		if id == "" {
+			break
+		}
+		m.queue = m.queue[1:]
+		a := m.assets[id]
+		a.busy, a.task, a.target = true, "intercept", target
+		out = append(out, dispatch{target: target, assetID: id})
+	}
+	return out
+}
+
+// processAsset registers an asset seen in the store. Availability recorded
+// in the asset's component is adopted, so a restarted manager recovers
+// existing commitments.
+func (m *Manager) processAsset(ctx context.Context, client storev1.EntityStoreServiceClient, entity *entityv1.Entity) {
+	m.mu.Lock()
+	if _, known := m.assets[entity.Id]; known {
+		m.mu.Unlock()
+		return
+	}
+
+	a := &assetState{id: entity.Id}
+	if av, err := extractAvailability(entity); err == nil && av.State == entityv1.AssetAvailability_ASSET_AVAILABILITY_BUSY {
+		a.busy, a.task, a.target = true, av.Task, av.TargetId
+	}
+	m.assets[entity.Id] = a
+	dispatches := m.dispatchQueuedLocked()
+	m.mu.Unlock()
+
+	slog.Info("task-manager registered asset", "asset_id", entity.Id, "busy", a.busy)
+	m.applyDispatches(ctx, client, dispatches)
+}
+
+// removeAsset forgets a deleted asset. If it was committed to a track, the
+// track goes back to the front of the queue to be reassigned.
+func (m *Manager) removeAsset(ctx context.Context, client storev1.EntityStoreServiceClient, assetID string) {
+	m.mu.Lock()
+	a, ok := m.assets[assetID]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.assets, assetID)
+
+	var requeued string
+	if a.busy && a.target != "" {
+		requeued = a.target
+		m.queue = append([]string{a.target}, m.queue...)
+	}
+	dispatches := m.dispatchQueuedLocked()
+	m.mu.Unlock()
+
+	slog.Info("task-manager removed asset", "asset_id", assetID, "requeued", requeued)
+	if requeued != "" && !slices.ContainsFunc(dispatches, func(d dispatch) bool { return d.target == requeued }) {
+		if err := m.writeAssignment(ctx, client, requeued, "", entityv1.TaskStatus_TASK_STATUS_QUEUED); err != nil {
+			slog.Error("requeue assignment failed", "entity_id", requeued, "error", err)
+		}
+		m.record(requeued, Transition{Stage: StageQueued, Detail: "asset " + assetID + " removed"})
+	}
+	m.applyDispatches(ctx, client, dispatches)
+}
+
+// observeAssignment frees the asset behind a finished task and hands it to
+// the next queued intercept.
+func (m *Manager) observeAssignment(ctx context.Context, client storev1.EntityStoreServiceClient, entity *entityv1.Entity) {
+	assignment, err := extractAssignment(entity)
+	if err != nil {
+		return
+	}
+
+	var stage string
+	switch assignment.Status {
+	case entityv1.TaskStatus_TASK_STATUS_IN_PROGRESS:
+		stage = StageInProgress
+	case entityv1.TaskStatus_TASK_STATUS_COMPLETED:
+		stage = StageCompleted
+	case entityv1.TaskStatus_TASK_STATUS_FAILED:
+		stage = StageFailed
+	default:
+		return
+	}
+	m.record(entity.Id, Transition{Stage: stage, Actor: assignment.AssetId})
+	if stage != StageInProgress {
+		m.releaseTarget(ctx, client, entity.Id)
+	}
+}
+
+// releaseTarget frees the asset committed to target, if any, and dispatches
+// queued work onto it.
+func (m *Manager) releaseTarget(ctx context.Context, client storev1.EntityStoreServiceClient, target string) {
+	m.mu.Lock()
+	freed := m.releaseTargetLocked(target)
+	var dispatches []dispatch
+	if freed != "" {
+		dispatches = m.dispatchQueuedLocked()
+	}
+	m.mu.Unlock()
+
+	if freed == "" {
+		return
+	}
+	slog.Info("task-manager released asset", "asset_id", freed, "entity_id", target)
+
+	reassigned := slices.ContainsFunc(dispatches, func(d dispatch) bool { return d.assetID == freed })
+	if !reassigned {
+		if err := m.writeAvailability(ctx, client, freed, "", ""); err != nil {
+			slog.Error("release asset failed", "asset_id", freed, "error", err)
+		}
+	}
+	m.applyDispatches(ctx, client, dispatches)
+}
+
+// applyDispatches writes the assignment and asset availability for each
+// dispatch produced under the lock.
+func (m *Manager) applyDispatches(ctx context.Context, client storev1.EntityStoreServiceClient, dispatches []dispatch) {
+	for _, d := range dispatches {
+		if m.cfg.DryRun {
+			slog.Info("DRY RUN dispatch not written", "entity_id", d.target, "asset_id", d.assetID)
+		} else if err := m.dispatch(ctx, client, d); err != nil {
+			slog.Error("dispatch failed", "entity_id", d.target, "asset_id", d.assetID, "error", err)
+			continue
+		}
+		m.record(d.target, Transition{Stage: StageAssigned, Actor: d.assetID})
+		slog.Info("task-manager dispatched queued intercept", "entity_id", d.target, "asset_id", d.assetID)
+	}
+}
+
+// dispatch assigns d's asset to its target and marks the asset busy.
+func (m *Manager) dispatch(ctx context.Context, client storev1.EntityStoreServiceClient, d dispatch) error {
+	assignment, err := anypb.New(newAssignment(d.assetID, entityv1.TaskStatus_TASK_STATUS_ASSIGNED))
+	if err != nil {
+		return fmt.Errorf("pack assignment: %w", err)
+	}
+	return writeIntercept(ctx, client, d.target, map[string]*anypb.Any{"assignment": assignment}, d.assetID)
+}
+
+// writeIntercept patches comps onto the target track and marks the asset
+// busy intercepting it in one transaction, so no reader sees the track
+// assigned to an asset that is still free, or the reverse.
+func writeIntercept(ctx context.Context, client storev1.EntityStoreServiceClient, target string, comps map[string]*anypb.Any, assetID string) error {
+	av, err := availability("intercept", target)
+	if err != nil {
+		return err
+	}
+	_, err = client.Transact(ctx, &storev1.TransactRequest{Ops: []*storev1.WriteOp{
+		{Op: &storev1.WriteOp_Patch{Patch: &storev1.PatchComponentRequest{Id: target, Components: comps}}},
+		{Op: &storev1.WriteOp_Patch{Patch: &storev1.PatchComponentRequest{Id: assetID, Components: map[string]*anypb.Any{"availability": av}}}},
+	}})
+	if err != nil {
+		return fmt.Errorf("assign %s to %s: %w", assetID, target, err)
+	}
+	return nil
+}
+
+// writeAssignment sets the assignment component on a track. In dry-run mode
+// it only logs.
+func (m *Manager) writeAssignment(ctx context.Context, client storev1.EntityStoreServiceClient, target, assetID string, st entityv1.TaskStatus) error {
+	if m.cfg.DryRun {
+		slog.Info("DRY RUN assignment not written", "entity_id", target, "asset_id", assetID, "status", st)
+		return nil
+	}
+	entity, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: target})
+	if err != nil {
+		return fmt.Errorf("get %s: %w", target, err)
+	}
+	comp, err := anypb.New(newAssignment(assetID, st))
+	if err != nil {
+		return fmt.Errorf("pack assignment: %w", err)
+	}
+	if err := updateComponents(ctx, client, entity, map[string]*anypb.Any{"assignment": comp}); err != nil {
+		return fmt.Errorf("update %s: %w", target, err)
+	}
+	return nil
+}
+
+// writeAvailability publishes an asset's availability. An empty task marks
+// the asset free. In dry-run mode it only logs.
+func (m *Manager) writeAvailability(ctx context.Context, client storev1.EntityStoreServiceClient, assetID, task, target string) error {
+	if m.cfg.DryRun {
+		slog.Info("DRY RUN availability not written", "asset_id", assetID, "task", task, "target_id", target)
+		return nil
+	}
+	entity, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: assetID})
+	if err != nil {
+		return fmt.Errorf("get %s: %w", assetID, err)
+	}
+	comp, err := availability(task, target)
+	if err != nil {
+		return err
+	}
+	if err := updateComponents(ctx, client, entity, map[string]*anypb.Any{"availability": comp}); err != nil {
+		return fmt.Errorf("update %s: %w", assetID, err)
+	}
+	return nil
+}
+
+// availability packs an availability component: busy with task against
+// target, or free if task is empty.
+func availability(task, target string) (*anypb.Any, error) {
+	av := &entityv1.AvailabilityComponent{State: entityv1.AssetAvailability_ASSET_AVAILABILITY_FREE}
+	if task != "" {
+		av = &entityv1.AvailabilityComponent{
+			State:    entityv1.AssetAvailability_ASSET_AVAILABILITY_BUSY,
+			Task:     task,
+			TargetId: target,
+		}
+	}
+	comp, err := anypb.New(av)
+	if err != nil {
+		return nil, fmt.Errorf("pack availability: %w", err)
+	}
+	return comp, nil
+}
+
+func newAssignment(assetID string, st entityv1.TaskStatus) *entityv1.AssignmentComponent {
+	return &entityv1.AssignmentComponent{
+		Task:      "intercept",
+		AssetId:   assetID,
+		Status:    st,
+		UpdatedAt: timestamppb.Now(),
+	}
+}
+
+func extractAssignment(entity *entityv1.Entity) (*entityv1.AssignmentComponent, error) {
+	aAny, ok := entity.Components["assignment"]
+	if !ok {
+		return nil, fmt.Errorf("no assignment component")
+	}
+	a := &entityv1.AssignmentComponent{}
+	if err := aAny.UnmarshalTo(a); err != nil {
+		return nil, fmt.Errorf("unmarshal assignment: %w", err)
+	}
+	return a, nil
+}
+
+func extractAvailability(entity *entityv1.Entity) (*entityv1.AvailabilityComponent, error) {
+	avAny, ok := entity.Components["availability"]
+	if !ok {
+		return nil, fmt.Errorf("no availability component")
+	}
+	av := &entityv1.AvailabilityComponent{}
+	if err := avAny.UnmarshalTo(av); err != nil {
+		return nil, fmt.Errorf("unmarshal availability: %w", err)
+	}
+	return av, nil
+}
@@ -0,0 +1,288 @@
+package task
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+func newManagerWithAssets(ids ...string) *Manager {
+	m := New(Config{})
+	for _, id := range ids {
+		m.assets[id] = &assetState{id: id}
+	}
+	return m
+}
+
+func TestReserveAsset_NeverDoubleBooks(t *testing.T) {
+	m := newManagerWithAssets("asset-a")
+
+	if got := m.reserveAssetLocked("track-1", "intercept"); got != "asset-a" {
+		t.Fatalf("expected asset-a for track-1, got %q", got)
+	}
+	if got := m.reserveAssetLocked("track-2", "intercept"); got != "" {
+		t.Fatalf("expected track-2 queued, got %q", got)
+	}
+	if len(m.queue) != 1 || m.queue[0] != "track-2" {
+		t.Fatalf("expected queue [track-2], got %v", m.queue)
+	}
+
+	// Re-reserving for the same target is idempotent.
+	if got := m.reserveAssetLocked("track-1", "intercept"); got != "asset-a" {
+		t.Fatalf("expected track-1 to keep asset-a, got %q", got)
+	}
+	if got := m.reserveAssetLocked("track-2", "intercept"); got != "" || len(m.queue) != 1 {
+		t.Fatalf("expected track-2 queued once, got %q queue=%v", got, m.queue)
+	}
+}
+
+func TestReleaseTarget_DispatchesQueueInOrder(t *testing.T) {
+	m := newManagerWithAssets("asset-a")
+	for _, id := range []string{"track-1", "track-2", "track-3"} {
+		m.assignments[id] = &Assignment{EntityID: id, State: StateIntercept}
+		m.reserveAssetLocked(id, "intercept")
+	}
+
+	if freed := m.releaseTargetLocked("track-1"); freed != "asset-a" {
+		t.Fatalf("expected asset-a freed, got %q", freed)
+	}
+	d := m.dispatchQueuedLocked()
+	if len(d) != 1 || d[0].target != "track-2" || d[0].assetID != "asset-a" {
+		t.Fatalf("expected asset-a → track-2, got %+v", d)
+	}
+	if len(m.queue) != 1 || m.queue[0] != "track-3" {
+		t.Fatalf("expected track-3 still queued, got %v", m.queue)
+	}
+}
+
+func TestDispatchQueued_DropsStaleTargets(t *testing.T) {
+	m := newManagerWithAssets()
+	m.assignments["track-stale"] = &Assignment{EntityID: "track-stale", State: StateIdle}
+	m.assignments["track-live"] = &Assignment{EntityID: "track-live", State: StateIntercept}
+	m.queue = []string{"track-gone", "track-stale", "track-live"}
+	m.assets["asset-a"] = &assetState{id: "asset-a"}
+
+	d := m.dispatchQueuedLocked()
+	if len(d) != 1 || d[0].target != "track-live" {
+		t.Fatalf("expected only track-live dispatched, got %+v", d)
+	}
+	if len(m.queue) != 0 {
+		t.Fatalf("expected empty queue, got %v", m.queue)
+	}
+}
+
+func TestAssetStats(t *testing.T) {
+	m := newManagerWithAssets("asset-a", "asset-b")
+	if st := m.AssetStats(); st.Total != 2 || st.Busy != 0 || st.Utilization != 0 {
+		t.Fatalf("unexpected idle stats: %+v", st)
+	}
+
+	m.reserveAssetLocked("track-1", "intercept")
+	m.reserveAssetLocked("track-2", "intercept")
+	m.reserveAssetLocked("track-3", "intercept")
+
+	st := m.AssetStats()
+	if st.Busy != 2 || st.Queued != 1 || st.Utilization != 1 {
+		t.Fatalf("expected 2 busy, 1 queued, full utilization; got %+v", st)
+	}
+}
+
+func TestManager_AssetDeconflictionIntegration(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	mgr := New(Config{StoreAddr: addr, ApprovalTimeout: 5 * time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go mgr.Run(ctx) //nolint:errcheck
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "asset-1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET},
+	}); err != nil {
+		t.Fatalf("CreateEntity asset: %v", err)
+	}
+
+	threat, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	for _, id := range []string{"track-a", "track-b"} {
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+			Entity: &entityv1.Entity{
+				Id:         id,
+				Type:       entityv1.EntityType_ENTITY_TYPE_TRACK,
+				Components: map[string]*anypb.Any{"threat": threat},
+			},
+		}); err != nil {
+			t.Fatalf("CreateEntity %s: %v", id, err)
+		}
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	for _, id := range []string{"track-a", "track-b"} {
+		if _, err := mgr.Approve(id); err != nil {
+			t.Fatalf("Approve %s: %v", id, err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+
+	assignmentOf := func(id string) *entityv1.AssignmentComponent {
+		t.Helper()
+		e, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: id})
+		if err != nil {
+			t.Fatalf("GetEntity %s: %v", id, err)
+		}
+		a, err := extractAssignment(e)
+		if err != nil {
+			t.Fatalf("assignment on %s: %v", id, err)
+		}
+		return a
+	}
+
+	if a := assignmentOf("track-a"); a.AssetId != "asset-1" || a.Status != entityv1.TaskStatus_TASK_STATUS_ASSIGNED {
+		t.Fatalf("expected track-a assigned asset-1, got %v", a)
+	}
+	if a := assignmentOf("track-b"); a.AssetId != "" || a.Status != entityv1.TaskStatus_TASK_STATUS_QUEUED {
+		t.Fatalf("expected track-b queued, got %v", a)
+	}
+	if st := mgr.AssetStats(); st.Busy != 1 || st.Queued != 1 {
+		t.Fatalf("expected 1 busy, 1 queued; got %+v", st)
+	}
+
+	// The effector reports track-a done; the asset moves to track-b.
+	e, _ := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "track-a"})
+	done, _ := anypb.New(newAssignment("asset-1", entityv1.TaskStatus_TASK_STATUS_COMPLETED))
+	e.Components["assignment"] = done
+	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: e}); err != nil {
+		t.Fatalf("UpdateEntity: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	if a := assignmentOf("track-b"); a.AssetId != "asset-1" || a.Status != entityv1.TaskStatus_TASK_STATUS_ASSIGNED {
+		t.Fatalf("expected track-b assigned asset-1 after release, got %v", a)
+	}
+
+	asset, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "asset-1"})
+	if err != nil {
+		t.Fatalf("GetEntity asset: %v", err)
+	}
+	av, err := extractAvailability(asset)
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+	if av.State != entityv1.AssetAvailability_ASSET_AVAILABILITY_BUSY || av.TargetId != "track-b" {
+		t.Fatalf("expected asset-1 busy on track-b, got %v", av)
+	}
+
+	var stages []string
+	for _, tr := range mgr.History("track-a") {
+		stages = append(stages, tr.Stage)
+	}
+	want := []string{string(StatePendingApproval), StageApproved, StageAssigned, StageCompleted}
+	if !slices.Equal(stages, want) {
+		t.Fatalf("expected track-a history %v, got %v", want, stages)
+	}
+}
+
+func TestManager_DowngradeReleasesAsset(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	mgr := New(Config{StoreAddr: addr, ApprovalTimeout: 5 * time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go mgr.Run(ctx) //nolint:errcheck
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "asset-1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET},
+	}); err != nil {
+		t.Fatalf("CreateEntity asset: %v", err)
+	}
+
+	setThreat := func(id string, level entityv1.ThreatLevel, create bool) {
+		t.Helper()
+		threat, _ := anypb.New(&entityv1.ThreatComponent{Level: level})
+		if create {
+			if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+				Entity: &entityv1.Entity{
+					Id:         id,
+					Type:       entityv1.EntityType_ENTITY_TYPE_TRACK,
+					Components: map[string]*anypb.Any{"threat": threat},
+				},
+			}); err != nil {
+				t.Fatalf("CreateEntity %s: %v", id, err)
+			}
+			return
+		}
+		e, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: id})
+		if err != nil {
+			t.Fatalf("GetEntity %s: %v", id, err)
+		}
+		e.Components["threat"] = threat
+		if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: e}); err != nil {
+			t.Fatalf("UpdateEntity %s: %v", id, err)
+		}
+	}
+
+	for _, id := range []string{"track-a", "track-b"} {
+		setThreat(id, entityv1.ThreatLevel_THREAT_LEVEL_HIGH, true)
+	}
+	time.Sleep(300 * time.Millisecond)
+	for _, id := range []string{"track-a", "track-b"} {
+		if _, err := mgr.Approve(id); err != nil {
+			t.Fatalf("Approve %s: %v", id, err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	if st := mgr.AssetStats(); st.Busy != 1 || st.Queued != 1 {
+		t.Fatalf("expected 1 busy, 1 queued; got %+v", st)
+	}
+
+	// The queued track drops out of the queue without touching the asset.
+	setThreat("track-b", entityv1.ThreatLevel_THREAT_LEVEL_LOW, false)
+	time.Sleep(300 * time.Millisecond)
+	if st := mgr.AssetStats(); st.Busy != 1 || st.Queued != 0 {
+		t.Fatalf("expected 1 busy, 0 queued after track-b downgrade; got %+v", st)
+	}
+
+	// The assigned track frees its asset, and nothing is left to take it.
+	setThreat("track-a", entityv1.ThreatLevel_THREAT_LEVEL_LOW, false)
+	time.Sleep(300 * time.Millisecond)
+	if st := mgr.AssetStats(); st.Busy != 0 || st.Queued != 0 {
+		t.Fatalf("expected asset released after track-a downgrade; got %+v", st)
+	}
+
+	asset, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "asset-1"})
+	if err != nil {
+		t.Fatalf("GetEntity asset: %v", err)
+	}
+	av, err := extractAvailability(asset)
+	if err != nil {
+		t.Fatalf("availability: %v", err)
+	}
+	if av.State != entityv1.AssetAvailability_ASSET_AVAILABILITY_FREE || av.TargetId != "" {
+		t.Fatalf("expected asset-1 free, got %v", av)
+	}
+}
@@ -0,0 +1,35 @@
+package task
+
+import "time"
+
+// maxAuditEntries bounds the in-memory audit log; the oldest entries are
+// dropped first.
+const maxAuditEntries = 1024
+
+// AuditEntry records one approval decision.
+type AuditEntry struct {
+	Time     time.Time
+	EntityID string
+	Action   string // auto_approved, approved, denied, timed_out, escalated, manual_mode
+	Actor    string // operator, timeout, or policy:<name>
+	Detail   string
+}
+
+// AuditLog returns a copy of the audit log, oldest first.
+func (m *Manager) AuditLog() []AuditEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]AuditEntry(nil), m.audit...)
+}
+
+// appendAuditLocked records an entry, stamping the time if unset.
+// Caller must hold m.mu.
+func (m *Manager) appendAuditLocked(e AuditEntry) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	m.audit = append(m.audit, e)
+	if len(m.audit) > maxAuditEntries {
+		m.audit = m.audit[len(m.audit)-maxAuditEntries:]
+	}
+}
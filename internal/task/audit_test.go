@@ -0,0 +1,42 @@
+package task
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAuditLog_RecordsDecisions(t *testing.T) {
+	m := New(Config{})
+	for _, id := range []string{"track-a", "track-b"} {
+		_, cancel := context.WithCancel(context.Background())
+		m.pending[id] = &pendingApproval{entityID: id, cancel: cancel, state: StateIntercept}
+	}
+
+	if _, err := m.Approve("track-a"); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if err := m.Deny("track-b"); err != nil {
+		t.Fatalf("Deny: %v", err)
+	}
+
+	log := m.AuditLog()
+	if len(log) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(log))
+	}
+	if log[0].EntityID != "track-a" || log[0].Action != "approved" || log[0].Time.IsZero() {
+		t.Fatalf("unexpected first entry: %+v", log[0])
+	}
+	if log[1].EntityID != "track-b" || log[1].Action != "denied" {
+		t.Fatalf("unexpected second entry: %+v", log[1])
+	}
+}
+
+func TestAuditLog_Bounded(t *testing.T) {
+	m := New(Config{})
+	for i := 0; i < maxAuditEntries+10; i++ {
+		m.appendAuditLocked(AuditEntry{Action: "manual_mode"})
+	}
+	if n := len(m.AuditLog()); n != maxAuditEntries {
+		t.Fatalf("expected %d entries, got %d", maxAuditEntries, n)
+	}
+}
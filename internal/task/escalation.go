@@ -0,0 +1,145 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// TimeoutAction selects what happens when an approval request times out.
+type TimeoutAction string
+
+const (
+	TimeoutDeny     TimeoutAction = "deny"     // return the entity to idle (default)
+	TimeoutApprove  TimeoutAction = "approve"  // approve the intercept; for exercises only
+	TimeoutEscalate TimeoutAction = "escalate" // hand to a secondary approver with a fresh timer
+)
+
+// ParseTimeoutAction validates a timeout action name. Empty means deny.
+func ParseTimeoutAction(s string) (TimeoutAction, error) {
+	switch a := TimeoutAction(s); a {
+	case "":
+		return TimeoutDeny, nil
+	case TimeoutDeny, TimeoutApprove, TimeoutEscalate:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown timeout action %q (want deny, approve, or escalate)", s)
+	}
+}
+
+// escalationNotice is the JSON body posted to the escalation webhook.
+type escalationNotice struct {
+	EntityID    string    `json:"entity_id"`
+	State       State     `json:"state"`
+	RequestedAt time.Time `json:"requested_at"`
+	EscalatedTo string    `json:"escalated_to,omitempty"`
+	Deadline    time.Time `json:"deadline"`
+}
+
+// approvalTimer fires onApprovalTimeout after d unless ctx is cancelled by
+// an approve, deny, or delete.
+func (m *Manager) approvalTimer(ctx context.Context, entityID string, d time.Duration) {
+	select {
+	case <-ctx.Done():
+		return
+	case <-time.After(d):
+		m.onApprovalTimeout(entityID)
+	}
+}
+
+// onApprovalTimeout applies the configured TimeoutAction. An escalated
+// request that times out again is denied.
+func (m *Manager) onApprovalTimeout(entityID string) {
+	m.mu.Lock()
+	p, ok := m.pending[entityID]
+	if !ok {
+		m.mu.Unlock()
+		return
+	}
+
+	action := m.cfg.TimeoutAction
+	if p.escalated {
+		action = TimeoutDeny
+	}
+
+	switch action {
+	case TimeoutEscalate:
+		p.cancel()
+		timerCtx, cancel := context.WithCancel(context.Background())
+		p.cancel = cancel
+		p.escalated = true
+		notice := escalationNotice{
+			EntityID:    entityID,
+			State:       p.state,
+			RequestedAt: p.requestedAt,
+			EscalatedTo: m.cfg.EscalateTo,
+			Deadline:    time.Now().Add(m.cfg.EscalationTimeout),
+		}
+		m.appendAuditLocked(AuditEntry{EntityID: entityID, Action: "escalated", Actor: "timeout", Detail: m.cfg.EscalateTo})
+		m.recordLocked(entityID, Transition{Stage: StageEscalated, Actor: "timeout", Detail: m.cfg.EscalateTo})
+		m.counters.escalations++
+		decisions.Inc("escalated")
+		m.mu.Unlock()
+
+		slog.Warn("approval timed out, escalated", "entity_id", entityID, "escalate_to", m.cfg.EscalateTo)
+		if m.cfg.EscalationWebhook != "" {
+			go m.notifyEscalation(notice)
+		}
+		go m.approvalTimer(timerCtx, entityID, m.cfg.EscalationTimeout)
+
+	case TimeoutApprove:
+		delete(m.pending, entityID)
+		m.assignments[entityID] = &Assignment{EntityID: entityID, State: p.state, Tasks: p.tasks, catalogWritten: true}
+		m.appendAuditLocked(AuditEntry{EntityID: entityID, Action: "timed_out", Actor: "timeout", Detail: string(TimeoutApprove)})
+		m.recordLocked(entityID, Transition{Stage: StageApproved, Actor: "timeout"})
+		m.counters.timeouts++
+		decisions.Inc("timed_out")
+		client, ctx := m.client, m.runCtx
+		m.mu.Unlock()
+
+		slog.Warn("AUDIT approval timed out, auto-approved", "entity_id", entityID)
+		if client != nil && ctx != nil && len(p.tasks) > 0 {
+			go m.pushCatalogForEntity(ctx, client, entityID, p.tasks)
+		}
+
+	default:
+		detail := string(TimeoutDeny)
+		if p.escalated {
+			detail = "deny after escalation"
+		}
+		delete(m.pending, entityID)
+		m.assignments[entityID] = &Assignment{EntityID: entityID, State: StateIdle}
+		m.appendAuditLocked(AuditEntry{EntityID: entityID, Action: "timed_out", Actor: "timeout", Detail: detail})
+		m.recordLocked(entityID, Transition{Stage: StageTimedOut, Actor: "timeout", Detail: detail})
+		m.counters.timeouts++
+		decisions.Inc("timed_out")
+		m.mu.Unlock()
+
+		slog.Info("approval timed out, auto-denied", "entity_id", entityID, "escalated", p.escalated)
+	}
+}
+
+// notifyEscalation posts the notice to the escalation webhook. Failures are
+// logged; the escalation timer runs regardless.
+func (m *Manager) notifyEscalation(n escalationNotice) {
+	body, err := json.Marshal(n)
+	if err != nil {
+		slog.Error("marshal escalation failed", "entity_id", n.EntityID, "error", err)
+		return
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Post(m.cfg.EscalationWebhook, "application/json", bytes.NewReader(body))
+	if err != nil {
+		slog.Error("escalation webhook failed", "entity_id", n.EntityID, "error", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Error("escalation webhook rejected", "entity_id", n.EntityID, "status", resp.Status)
+	}
+}
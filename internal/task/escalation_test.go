@@ -0,0 +1,112 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func startTimedPending(m *Manager, id string) {
+	timerCtx, cancel := context.WithCancel(context.Background())
+	m.mu.Lock()
+	m.pending[id] = &pendingApproval{entityID: id, cancel: cancel, state: StateIntercept, tasks: []string{"intercept"}, requestedAt: time.Now()}
+	m.assignments[id] = &Assignment{EntityID: id, State: StatePendingApproval}
+	m.mu.Unlock()
+	go m.approvalTimer(timerCtx, id, m.cfg.ApprovalTimeout)
+}
+
+func lastAudit(m *Manager) AuditEntry {
+	log := m.AuditLog()
+	if len(log) == 0 {
+		return AuditEntry{}
+	}
+	return log[len(log)-1]
+}
+
+func TestParseTimeoutAction(t *testing.T) {
+	for in, want := range map[string]TimeoutAction{"": TimeoutDeny, "deny": TimeoutDeny, "approve": TimeoutApprove, "escalate": TimeoutEscalate} {
+		got, err := ParseTimeoutAction(in)
+		if err != nil || got != want {
+			t.Fatalf("ParseTimeoutAction(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseTimeoutAction("ignore"); err == nil {
+		t.Fatal("expected error for unknown action")
+	}
+}
+
+func TestTimeout_Approve(t *testing.T) {
+	m := New(Config{ApprovalTimeout: 10 * time.Millisecond, TimeoutAction: TimeoutApprove})
+	startTimedPending(m, "track-a")
+	time.Sleep(100 * time.Millisecond)
+
+	a, _ := m.GetAssignment("track-a")
+	if a.State != StateIntercept {
+		t.Fatalf("expected intercept after timeout approve, got %s", a.State)
+	}
+	if e := lastAudit(m); e.Action != "timed_out" || e.Detail != string(TimeoutApprove) {
+		t.Fatalf("expected timed_out/approve audit, got %+v", e)
+	}
+}
+
+func TestTimeout_EscalateThenApprove(t *testing.T) {
+	got := make(chan escalationNotice, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n escalationNotice
+		json.NewDecoder(r.Body).Decode(&n) //nolint:errcheck
+		got <- n
+	}))
+	defer hook.Close()
+
+	m := New(Config{
+		ApprovalTimeout:   10 * time.Millisecond,
+		TimeoutAction:     TimeoutEscalate,
+		EscalateTo:        "watch-officer",
+		EscalationWebhook: hook.URL,
+		EscalationTimeout: 5 * time.Second,
+	})
+	startTimedPending(m, "track-a")
+
+	select {
+	case n := <-got:
+		if n.EntityID != "track-a" || n.EscalatedTo != "watch-officer" {
+			t.Fatalf("unexpected notice: %+v", n)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook not called")
+	}
+
+	// Still pending, now with the secondary approver.
+	if a, _ := m.GetAssignment("track-a"); a.State != StatePendingApproval {
+		t.Fatalf("expected still pending after escalation, got %s", a.State)
+	}
+	if e := lastAudit(m); e.Action != "escalated" || e.Detail != "watch-officer" {
+		t.Fatalf("expected escalated audit, got %+v", e)
+	}
+	if _, err := m.ApproveAs("track-a", "watch-officer"); err != nil {
+		t.Fatalf("ApproveAs: %v", err)
+	}
+	if st := m.GetStats(); st.Escalations != 1 || st.ApprovalsByOperator["watch-officer"] != 1 {
+		t.Fatalf("unexpected stats: %+v", st)
+	}
+}
+
+func TestTimeout_EscalationExpiresToDeny(t *testing.T) {
+	m := New(Config{
+		ApprovalTimeout:   10 * time.Millisecond,
+		TimeoutAction:     TimeoutEscalate,
+		EscalationTimeout: 10 * time.Millisecond,
+	})
+	startTimedPending(m, "track-a")
+	time.Sleep(200 * time.Millisecond)
+
+	if a, _ := m.GetAssignment("track-a"); a.State != StateIdle {
+		t.Fatalf("expected idle after escalation expired, got %s", a.State)
+	}
+	if e := lastAudit(m); e.Action != "timed_out" || e.Detail != "deny after escalation" {
+		t.Fatalf("expected deny-after-escalation audit, got %+v", e)
+	}
+}
@@ -0,0 +1,91 @@
+package task
+
+import (
+	"slices"
+	"time"
+)
+
+const (
+	// maxHistoryPerEntity bounds the transitions kept for one entity; the
+	// oldest are dropped first.
+	maxHistoryPerEntity = 64
+	// maxHistoryEntities bounds how many entities keep history; the entity
+	// first seen longest ago is evicted first.
+	maxHistoryEntities = 4096
+)
+
+// Task lifecycle stages recorded in history alongside the State values.
+const (
+	StageApproved     = "approved"
+	StageAutoApproved = "auto_approved"
+	StageDenied       = "denied"
+	StageTimedOut     = "timed_out"
+	StageEscalated    = "escalated"
+	StageQueued       = "queued"
+	StageAssigned     = "assigned"
+	StageInProgress   = "in_progress"
+	StageCompleted    = "completed"
+	StageFailed       = "failed"
+	StageRemoved      = "removed"
+)
+
+// Transition is one step in an entity's task lifecycle, e.g.
+// pending_approval → approved → assigned → completed.
+type Transition struct {
+	Time   time.Time
+	Stage  string // a State or one of the Stage* constants
+	Actor  string // operator, policy:<name>, timeout, or an asset ID
+	Detail string
+}
+
+// History returns a copy of the recorded transitions for an entity, oldest
+// first. History survives the entity's removal so it can be reviewed after
+// the fact.
+func (m *Manager) History(entityID string) []Transition {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]Transition(nil), m.history[entityID]...)
+}
+
+// recordLocked appends a transition, stamping the time if unset. A
+// transition repeating the previous stage is dropped. Caller must hold m.mu.
+func (m *Manager) recordLocked(entityID string, t Transition) {
+	if t.Time.IsZero() {
+		t.Time = time.Now()
+	}
+
+	h, ok := m.history[entityID]
+	if !ok {
+		m.historyOrder = append(m.historyOrder, entityID)
+		if len(m.historyOrder) > maxHistoryEntities {
+			delete(m.history, m.historyOrder[0])
+			m.historyOrder = slices.Delete(m.historyOrder, 0, 1)
+		}
+	}
+	if n := len(h); n > 0 && h[n-1].Stage == t.Stage {
+		return
+	}
+
+	h = append(h, t)
+	if len(h) > maxHistoryPerEntity {
+		h = h[len(h)-maxHistoryPerEntity:]
+	}
+	m.history[entityID] = h
+}
+
+// record is recordLocked for callers not holding m.mu.
+func (m *Manager) record(entityID string, t Transition) {
+	m.mu.Lock()
+	m.recordLocked(entityID, t)
+	m.mu.Unlock()
+}
+
+// recordAssignment records an intercept as assigned to assetID, or queued
+// when no asset was free.
+func (m *Manager) recordAssignment(entityID, assetID, detail string) {
+	if assetID == "" {
+		m.record(entityID, Transition{Stage: StageQueued, Detail: detail})
+		return
+	}
+	m.record(entityID, Transition{Stage: StageAssigned, Actor: assetID, Detail: detail})
+}
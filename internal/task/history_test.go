@@ -0,0 +1,61 @@
+package task
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestHistory_RecordsLifecycle(t *testing.T) {
+	m := New(Config{})
+	addPending(m, "track-a", time.Now())
+	m.record("track-a", Transition{Stage: string(StatePendingApproval)})
+
+	if _, err := m.ApproveAs("track-a", "alice"); err != nil {
+		t.Fatalf("ApproveAs: %v", err)
+	}
+	m.record("track-a", Transition{Stage: StageAssigned, Actor: "asset-1"})
+	m.record("track-a", Transition{Stage: StageAssigned, Actor: "asset-1"}) // repeat, dropped
+	m.removeAssignment("track-a")
+
+	h := m.History("track-a")
+	want := []string{string(StatePendingApproval), StageApproved, StageAssigned, StageRemoved}
+	if len(h) != len(want) {
+		t.Fatalf("expected %d transitions, got %+v", len(want), h)
+	}
+	for i, stage := range want {
+		if h[i].Stage != stage {
+			t.Fatalf("transition %d: expected %s, got %s", i, stage, h[i].Stage)
+		}
+	}
+	if h[1].Actor != "alice" {
+		t.Fatalf("expected approval by alice, got %q", h[1].Actor)
+	}
+	if h[0].Time.IsZero() {
+		t.Fatal("expected transition time stamped")
+	}
+}
+
+func TestHistory_Bounded(t *testing.T) {
+	m := New(Config{})
+	for i := 0; i < maxHistoryPerEntity+10; i++ {
+		m.record("track-a", Transition{Stage: fmt.Sprintf("stage-%d", i)})
+	}
+	h := m.History("track-a")
+	if len(h) != maxHistoryPerEntity {
+		t.Fatalf("expected %d transitions, got %d", maxHistoryPerEntity, len(h))
+	}
+	if h[0].Stage != "stage-10" {
+		t.Fatalf("expected oldest transitions dropped, first is %s", h[0].Stage)
+	}
+
+	for i := 0; i < maxHistoryEntities; i++ {
+		m.record(fmt.Sprintf("track-%d", i), Transition{Stage: StageQueued})
+	}
+	if len(m.History("track-a")) != 0 {
+		t.Fatal("expected oldest entity evicted")
+	}
+	if len(m.history) != maxHistoryEntities {
+		t.Fatalf("expected %d entities, got %d", maxHistoryEntities, len(m.history))
+	}
+}
@@ -2,16 +2,32 @@ package task
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
 	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
+	"github.com/boshu2/lattice-lab/internal/client"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"github.com/boshu2/lattice-lab/internal/watch"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+var (
+	decisions = metrics.NewCounter("lattice_task_decisions_total",
+		"Approval requests resolved, by outcome: approved, denied, timed_out, escalated, or auto_approved.", "outcome")
+	approvalWait = metrics.NewHistogram("lattice_task_approval_wait_seconds",
+		"Time from an approval request to an operator's approval.", []float64{1, 2, 5, 10, 15, 30, 60, 120, 300})
 )
 
 // State represents the current task state for an entity.
@@ -51,16 +67,28 @@ func Rules(threat entityv1.ThreatLevel) (State, []string) {
 
 // pendingApproval tracks an entity awaiting operator approval.
 type pendingApproval struct {
-	entityID string
-	cancel   context.CancelFunc
-	state    State
-	tasks    []string
+	entityID    string
+	cancel      context.CancelFunc
+	state       State
+	tasks       []string
+	requestedAt time.Time
+	escalated   bool // timed out once and handed to the secondary approver
 }
 
 // Config controls the task manager.
 type Config struct {
 	StoreAddr       string
 	ApprovalTimeout time.Duration
+	Policies        []Policy // auto-approval rules of engagement; empty = always manual
+	ManualMode      bool     // start with the auto-approval kill-switch engaged
+	DryRun          bool     // run the full pipeline but only log intercepts and assignments
+
+	TimeoutAction     TimeoutAction // what an approval timeout does; default deny
+	EscalateTo        string        // secondary approver named in escalations
+	EscalationWebhook string        // URL POSTed when a request escalates; optional
+	EscalationTimeout time.Duration // fresh timer after escalating; default ApprovalTimeout
+
+	Health *health.Probe // optional; ready while watching the store, wedged if an event hangs
 }
 
 // DefaultConfig returns task manager defaults.
@@ -68,15 +96,23 @@ func DefaultConfig() Config {
 	return Config{
 		StoreAddr:       "localhost:50051",
 		ApprovalTimeout: 30 * time.Second,
+		TimeoutAction:   TimeoutDeny,
 	}
 }
 
 // Manager watches classified entities and assigns tasks based on threat level.
 type Manager struct {
-	cfg         Config
-	mu          sync.RWMutex
-	assignments map[string]*Assignment
-	pending     map[string]*pendingApproval
+	cfg          Config
+	mu           sync.RWMutex
+	assignments  map[string]*Assignment
+	pending      map[string]*pendingApproval
+	assets       map[string]*assetState
+	queue        []string // track IDs waiting for a free asset, FIFO
+	manual       bool     // kill-switch: disables policy auto-approval
+	audit        []AuditEntry
+	counters     counters
+	history      map[string][]Transition
+	historyOrder []string // entity IDs in first-seen order, for eviction
 
 	// Set during Run() for use by Approve to push catalog updates.
 	runCtx context.Context
@@ -88,10 +124,20 @@ func New(cfg Config) *Manager {
 	if cfg.ApprovalTimeout == 0 {
 		cfg.ApprovalTimeout = 30 * time.Second
 	}
+	if cfg.TimeoutAction == "" {
+		cfg.TimeoutAction = TimeoutDeny
+	}
+	if cfg.EscalationTimeout == 0 {
+		cfg.EscalationTimeout = cfg.ApprovalTimeout
+	}
 	return &Manager{
 		cfg:         cfg,
 		assignments: make(map[string]*Assignment),
 		pending:     make(map[string]*pendingApproval),
+		assets:      make(map[string]*assetState),
+		manual:      cfg.ManualMode,
+		counters:    counters{byOperator: make(map[string]int)},
+		history:     make(map[string][]Transition),
 	}
 }
 
@@ -103,15 +149,24 @@ func (m *Manager) GetAssignment(entityID string) (*Assignment, bool) {
 	return a, ok
 }
 
+// ErrNotPending is returned by Approve and Deny for an entity that is not
+// awaiting approval.
+var ErrNotPending = errors.New("no pending approval")
+
 // Approve transitions a pending entity to its approved state with tasks.
 // It also pushes the task catalog to the entity store if the manager is running.
 func (m *Manager) Approve(entityID string) (*Assignment, error) {
+	return m.ApproveAs(entityID, defaultOperator)
+}
+
+// ApproveAs is Approve with the approving operator recorded.
+func (m *Manager) ApproveAs(entityID, operator string) (*Assignment, error) {
 	m.mu.Lock()
 
 	p, ok := m.pending[entityID]
 	if !ok {
 		m.mu.Unlock()
-		return nil, fmt.Errorf("no pending approval for %s", entityID)
+		return nil, fmt.Errorf("%w for %s", ErrNotPending, entityID)
 	}
 
 	p.cancel() // stop timeout
@@ -119,13 +174,20 @@ func (m *Manager) Approve(entityID string) (*Assignment, error) {
 
 	a := &Assignment{EntityID: entityID, State: p.state, Tasks: p.tasks, catalogWritten: true}
 	m.assignments[entityID] = a
+	m.appendAuditLocked(AuditEntry{EntityID: entityID, Action: "approved", Actor: operator})
+	m.recordLocked(entityID, Transition{Stage: StageApproved, Actor: operator})
+	m.counters.approvals++
+	m.counters.byOperator[operator]++
+	m.counters.approvalWait += time.Since(p.requestedAt)
+	decisions.Inc("approved")
+	approvalWait.Observe(time.Since(p.requestedAt).Seconds())
 
 	// Capture client/ctx for catalog write outside lock.
 	client := m.client
 	ctx := m.runCtx
 	m.mu.Unlock()
 
-	slog.Info("task-manager approved", "entity_id", entityID, "state", p.state)
+	slog.Info("task-manager approved", "entity_id", entityID, "state", p.state, "operator", operator)
 
 	// Push task catalog to the entity store.
 	if client != nil && ctx != nil && len(p.tasks) > 0 {
@@ -137,24 +199,35 @@ func (m *Manager) Approve(entityID string) (*Assignment, error) {
 
 // Deny rejects a pending approval, returning the entity to idle with no tasks.
 func (m *Manager) Deny(entityID string) error {
+	return m.DenyAs(entityID, defaultOperator)
+}
+
+// DenyAs is Deny with the denying operator recorded.
+func (m *Manager) DenyAs(entityID, operator string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	p, ok := m.pending[entityID]
 	if !ok {
-		return fmt.Errorf("no pending approval for %s", entityID)
+		return fmt.Errorf("%w for %s", ErrNotPending, entityID)
 	}
 
 	p.cancel()
 	delete(m.pending, entityID)
 	m.assignments[entityID] = &Assignment{EntityID: entityID, State: StateIdle}
-	slog.Info("task-manager denied", "entity_id", entityID)
+	m.appendAuditLocked(AuditEntry{EntityID: entityID, Action: "denied", Actor: operator})
+	m.recordLocked(entityID, Transition{Stage: StageDenied, Actor: operator})
+	m.counters.denials++
+	decisions.Inc("denied")
+	slog.Info("task-manager denied", "entity_id", entityID, "operator", operator)
 	return nil
 }
 
-// Run connects to the store, watches all entities, and manages task assignments.
+// Run connects to the store, watches tracks and assets, and manages task
+// assignments until ctx is cancelled. A dropped watch is resumed, and the
+// manager resyncs from a full listing if events were lost.
 func (m *Manager) Run(ctx context.Context) error {
-	conn, err := grpc.NewClient(m.cfg.StoreAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	conn, err := client.Dial(m.cfg.StoreAddr)
 	if err != nil {
 		return fmt.Errorf("connect to store: %w", err)
 	}
@@ -166,32 +239,81 @@ func (m *Manager) Run(ctx context.Context) error {
 	m.runCtx = ctx
 	m.client = client
 	m.mu.Unlock()
+	metrics.Default.GaugeFunc("lattice_task_pending_approvals", "Engagements waiting for an operator's approval.", func() float64 {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		return float64(len(m.pending))
+	})
 
-	stream, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{
-		TypeFilter: entityv1.EntityType_ENTITY_TYPE_TRACK,
+	slog.Info("task-manager watching tracks and assets", "store_addr", m.cfg.StoreAddr, "dry_run", m.cfg.DryRun)
+
+	// Resync with the run context: timers it starts outlive the stream.
+	watch.Run(ctx, client, watch.Config{Resync: func(context.Context) error {
+		return m.resync(ctx, client)
+	}, Health: m.cfg.Health, Name: "task-manager"}, func(event *storev1.EntityEvent) {
+		m.handleEvent(ctx, client, event)
 	})
-	if err != nil {
-		return fmt.Errorf("watch entities: %w", err)
+	return nil
+}
+
+func (m *Manager) handleEvent(ctx context.Context, client storev1.EntityStoreServiceClient, event *storev1.EntityEvent) {
+	switch {
+	case event.Entity.Type == entityv1.EntityType_ENTITY_TYPE_ASSET:
+		if watch.Removed(event) {
+			m.removeAsset(ctx, client, event.Entity.Id)
+		} else {
+			m.processAsset(ctx, client, event.Entity)
+		}
+	case event.Entity.Type != entityv1.EntityType_ENTITY_TYPE_TRACK:
+	case watch.Removed(event):
+		m.removeAssignment(event.Entity.Id)
+		m.releaseTarget(ctx, client, event.Entity.Id)
+	default:
+		m.observeAssignment(ctx, client, event.Entity)
+		m.processEntity(ctx, client, event.Entity)
 	}
+}
 
-	slog.Info("task-manager watching tracks", "store_addr", m.cfg.StoreAddr)
+// resync reconciles the manager with the store after a watch gap: every
+// entity is replayed as an update, and tracks and assets the store no
+// longer has are removed as if their deletes had been seen.
+func (m *Manager) resync(ctx context.Context, client storev1.EntityStoreServiceClient) error {
+	resp, err := client.ListEntities(ctx, &storev1.ListEntitiesRequest{})
+	if err != nil {
+		return fmt.Errorf("list entities: %w", err)
+	}
+	present := make(map[string]bool, len(resp.Entities))
+	for _, e := range resp.Entities {
+		present[e.Id] = true
+	}
 
-	for {
-		event, err := stream.Recv()
-		if err != nil {
-			if ctx.Err() != nil {
-				return nil
-			}
-			return fmt.Errorf("recv: %w", err)
+	var gone []*entityv1.Entity
+	m.mu.RLock()
+	for id := range m.assignments {
+		if !present[id] {
+			gone = append(gone, &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
 		}
-
-		switch event.Type {
-		case storev1.EventType_EVENT_TYPE_DELETED:
-			m.removeAssignment(event.Entity.Id)
-		default:
-			m.processEntity(ctx, client, event.Entity)
+	}
+	for id := range m.pending {
+		if _, assigned := m.assignments[id]; !assigned && !present[id] {
+			gone = append(gone, &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK})
+		}
+	}
+	for id := range m.assets {
+		if !present[id] {
+			gone = append(gone, &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_ASSET})
 		}
 	}
+	m.mu.RUnlock()
+
+	for _, e := range gone {
+		m.handleEvent(ctx, client, &storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_DELETED, Entity: e})
+	}
+	for _, e := range resp.Entities {
+		m.handleEvent(ctx, client, &storev1.EntityEvent{Type: storev1.EventType_EVENT_TYPE_UPDATED, Entity: e})
+	}
+	slog.Info("task-manager resynced", "entities", len(resp.Entities), "removed", len(gone))
+	return nil
 }
 
 func (m *Manager) processEntity(ctx context.Context, client storev1.EntityStoreServiceClient, entity *entityv1.Entity) {
@@ -216,7 +338,7 @@ func (m *Manager) processEntity(ctx context.Context, client storev1.EntityStoreS
 			}
 			m.mu.Unlock()
 			if needsCatalog {
-				m.writeTaskCatalog(ctx, client, entity, tasks)
+				m.writeTaskCatalog(ctx, client, entity, tasks, nil)
 			}
 			return
 		}
@@ -227,6 +349,26 @@ func (m *Manager) processEntity(ctx context.Context, client storev1.EntityStoreS
 			return
 		}
 
+		// Rules of engagement may approve without an operator.
+		if policy, zone, ok := m.matchPolicyLocked(entity); ok {
+			m.assignments[entity.Id] = &Assignment{
+				EntityID:       entity.Id,
+				State:          state,
+				Tasks:          tasks,
+				catalogWritten: true,
+			}
+			detail := fmt.Sprintf("zone=%s", zone.Name)
+			m.appendAuditLocked(AuditEntry{EntityID: entity.Id, Action: "auto_approved", Actor: "policy:" + policy.Name, Detail: detail})
+			m.recordLocked(entity.Id, Transition{Stage: StageAutoApproved, Actor: "policy:" + policy.Name, Detail: detail})
+			m.counters.autoApprovals++
+			decisions.Inc("auto_approved")
+			m.mu.Unlock()
+
+			slog.Warn("AUDIT intercept auto-approved by policy", "entity_id", entity.Id, "policy", policy.Name, "zone", zone.Name)
+			m.autoApprove(ctx, client, entity, tasks)
+			return
+		}
+
 		// Set pending approval.
 		m.assignments[entity.Id] = &Assignment{
 			EntityID: entity.Id,
@@ -237,14 +379,16 @@ func (m *Manager) processEntity(ctx context.Context, client storev1.EntityStoreS
 		// Start timeout.
 		timerCtx, cancel := context.WithCancel(context.Background())
 		m.pending[entity.Id] = &pendingApproval{
-			entityID: entity.Id,
-			cancel:   cancel,
-			state:    state,
-			tasks:    tasks,
+			entityID:    entity.Id,
+			cancel:      cancel,
+			state:       state,
+			tasks:       tasks,
+			requestedAt: time.Now(),
 		}
+		m.recordLocked(entity.Id, Transition{Stage: string(StatePendingApproval)})
 		m.mu.Unlock()
 
-		go m.approvalTimer(timerCtx, entity.Id)
+		go m.approvalTimer(timerCtx, entity.Id, m.cfg.ApprovalTimeout)
 
 		slog.Info("task-manager pending approval", "entity_id", entity.Id, "state", state)
 		return
@@ -254,18 +398,41 @@ func (m *Manager) processEntity(ctx context.Context, client storev1.EntityStoreS
 	m.mu.Lock()
 	prev, existed := m.assignments[entity.Id]
 	changed := !existed || prev.State != state
+	// A track that no longer warrants an intercept gives up its asset and
+	// its place in the queue.
+	downgraded := existed && prev.State == StateIntercept
 	m.assignments[entity.Id] = &Assignment{
 		EntityID: entity.Id,
 		State:    state,
 		Tasks:    tasks,
 	}
+	if changed {
+		m.recordLocked(entity.Id, Transition{Stage: string(state), Detail: strings.Join(tasks, ",")})
+	}
 	m.mu.Unlock()
 
+	if downgraded {
+		m.releaseTarget(ctx, client, entity.Id)
+	}
 	if !changed {
 		return
 	}
 
-	m.writeTaskCatalog(ctx, client, entity, tasks)
+	m.writeTaskCatalog(ctx, client, entity, tasks, nil)
+}
+
+// autoApprove stamps the approval component and pushes the task catalog for
+// a policy-approved intercept.
+func (m *Manager) autoApprove(ctx context.Context, client storev1.EntityStoreServiceClient, entity *entityv1.Entity, tasks []string) {
+	approval, err := anypb.New(&entityv1.ApprovalComponent{
+		State:       entityv1.ApprovalState_APPROVAL_STATE_AUTO_APPROVED,
+		RequestedAt: timestamppb.Now(),
+	})
+	if err != nil {
+		slog.Error("pack approval failed", "entity_id", entity.Id, "error", err)
+		return
+	}
+	m.writeTaskCatalog(ctx, client, entity, tasks, map[string]*anypb.Any{"approval": approval})
 }
 
 // pushCatalogForEntity fetches the entity from the store and writes the task catalog.
@@ -275,10 +442,12 @@ func (m *Manager) pushCatalogForEntity(ctx context.Context, client storev1.Entit
 		slog.Error("fetch entity for catalog push failed", "entity_id", entityID, "error", err)
 		return
 	}
-	m.writeTaskCatalog(ctx, client, entity, tasks)
+	m.writeTaskCatalog(ctx, client, entity, tasks, nil)
 }
 
-func (m *Manager) writeTaskCatalog(ctx context.Context, client storev1.EntityStoreServiceClient, entity *entityv1.Entity, tasks []string) {
+// writeTaskCatalog writes the task catalog, the intercept assignment if
+// any, and the given extra components to the entity.
+func (m *Manager) writeTaskCatalog(ctx context.Context, client storev1.EntityStoreServiceClient, entity *entityv1.Entity, tasks []string, extra map[string]*anypb.Any) {
 	if len(tasks) == 0 {
 		return
 	}
@@ -290,28 +459,77 @@ func (m *Manager) writeTaskCatalog(ctx context.Context, client storev1.EntitySto
 		slog.Error("pack task catalog failed", "entity_id", entity.Id, "error", err)
 		return
 	}
-	entity.Components["task_catalog"] = catalog
+	comps := map[string]*anypb.Any{"task_catalog": catalog}
+	maps.Copy(comps, extra)
+
+	// Intercepts need an asset. Reserve one, or queue until one frees up.
+	var assetID string
+	if slices.Contains(tasks, "intercept") {
+		m.mu.Lock()
+		assetID = m.reserveAssetLocked(entity.Id, "intercept")
+		m.mu.Unlock()
+
+		st := entityv1.TaskStatus_TASK_STATUS_ASSIGNED
+		if assetID == "" {
+			st = entityv1.TaskStatus_TASK_STATUS_QUEUED
+		}
+		assignment, err := anypb.New(newAssignment(assetID, st))
+		if err != nil {
+			slog.Error("pack assignment failed", "entity_id", entity.Id, "error", err)
+			return
+		}
+		comps["assignment"] = assignment
+
+		if m.cfg.DryRun {
+			slog.Info("DRY RUN intercept not written", "entity_id", entity.Id, "tasks", tasks, "asset_id", assetID, "status", st)
+			m.recordAssignment(entity.Id, assetID, "dry_run")
+			return
+		}
+	}
 
-	if _, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{Entity: entity}); err != nil {
-		slog.Error("update task catalog failed", "entity_id", entity.Id, "error", err)
+	// With an asset reserved, the track and the asset are written together.
+	if assetID != "" {
+		err = writeIntercept(ctx, client, entity.Id, comps, assetID)
+	} else {
+		err = updateComponents(ctx, client, entity, comps)
+	}
+	if err != nil {
+		slog.Error("update task catalog failed", "entity_id", entity.Id, "asset_id", assetID, "error", err)
 		return
 	}
 
-	slog.Info("task-manager assigned tasks", "entity_id", entity.Id, "tasks", tasks)
+	slog.Info("task-manager assigned tasks", "entity_id", entity.Id, "tasks", tasks, "asset_id", assetID)
+
+	if slices.Contains(tasks, "intercept") {
+		m.recordAssignment(entity.Id, assetID, "")
+	}
 }
 
-func (m *Manager) approvalTimer(ctx context.Context, entityID string) {
-	select {
-	case <-ctx.Done():
-		return // cancelled by approve/deny/delete
-	case <-time.After(m.cfg.ApprovalTimeout):
-		m.mu.Lock()
-		if _, ok := m.pending[entityID]; ok {
-			delete(m.pending, entityID)
-			m.assignments[entityID] = &Assignment{EntityID: entityID, State: StateIdle}
-			slog.Info("approval timed out, auto-denied", "entity_id", entityID)
+// updateRetries bounds how often updateComponents reads an entity again
+// after losing a race with another writer.
+const updateRetries = 3
+
+// updateComponents sets comps on entity and writes it back, provided the
+// store still holds the version entity was read at. If another writer,
+// such as the classifier, got there first, the entity is read again and
+// comps set on the fresh copy, so that writer's changes are not
+// overwritten with stale values.
+func updateComponents(ctx context.Context, client storev1.EntityStoreServiceClient, entity *entityv1.Entity, comps map[string]*anypb.Any) error {
+	for attempt := 0; ; attempt++ {
+		if entity.Components == nil {
+			entity.Components = make(map[string]*anypb.Any)
+		}
+		maps.Copy(entity.Components, comps)
+		_, err := client.UpdateEntity(ctx, &storev1.UpdateEntityRequest{
+			Entity:      entity,
+			ExpectedHlc: &entityv1.HLCTimestamp{Physical: entity.HlcPhysical, Logical: entity.HlcLogical, Node: entity.HlcNode},
+		})
+		if status.Code(err) != codes.FailedPrecondition || attempt == updateRetries {
+			return err
+		}
+		if entity, err = client.GetEntity(ctx, &storev1.GetEntityRequest{Id: entity.Id}); err != nil {
+			return err
 		}
-		m.mu.Unlock()
 	}
 }
 
@@ -321,6 +539,9 @@ func (m *Manager) removeAssignment(entityID string) {
 		p.cancel()
 		delete(m.pending, entityID)
 	}
+	if _, ok := m.assignments[entityID]; ok {
+		m.recordLocked(entityID, Transition{Stage: StageRemoved})
+	}
 	delete(m.assignments, entityID)
 	m.mu.Unlock()
 	slog.Info("task-manager removed assignment", "entity_id", entityID)
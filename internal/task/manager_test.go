@@ -462,6 +462,15 @@ func TestManagerIntegration(t *testing.T) {
 	if len(catalog.AvailableTasks) != 4 {
 		t.Fatalf("expected 4 tasks, got %d: %v", len(catalog.AvailableTasks), catalog.AvailableTasks)
 	}
+
+	// No assets exist, so the intercept is queued for one.
+	assignment, err := extractAssignment(got)
+	if err != nil {
+		t.Fatalf("assignment: %v", err)
+	}
+	if assignment.Task != "intercept" || assignment.Status != entityv1.TaskStatus_TASK_STATUS_QUEUED {
+		t.Fatalf("expected queued intercept assignment, got %v", assignment)
+	}
 }
 
 func TestManagerDeleteRemovesAssignment(t *testing.T) {
@@ -552,3 +561,178 @@ func TestDefaultConfig(t *testing.T) {
 		t.Fatalf("expected 30s approval timeout, got %s", cfg.ApprovalTimeout)
 	}
 }
+
+func TestManager_DryRunWritesNothing(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	mgr := New(Config{StoreAddr: addr, ApprovalTimeout: 5 * time.Second, DryRun: true})
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	go mgr.Run(ctx) //nolint:errcheck
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "asset-1", Type: entityv1.EntityType_ENTITY_TYPE_ASSET},
+	}); err != nil {
+		t.Fatalf("CreateEntity asset: %v", err)
+	}
+	threat, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{
+			Id:         "track-dry",
+			Type:       entityv1.EntityType_ENTITY_TYPE_TRACK,
+			Components: map[string]*anypb.Any{"threat": threat},
+		},
+	}); err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	if _, err := mgr.Approve("track-dry"); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	time.Sleep(300 * time.Millisecond)
+
+	// The pipeline ran: the asset is reserved and the history shows it.
+	if st := mgr.AssetStats(); st.Busy != 1 {
+		t.Fatalf("expected asset reserved in memory, got %+v", st)
+	}
+	h := mgr.History("track-dry")
+	if last := h[len(h)-1]; last.Stage != StageAssigned || last.Detail != "dry_run" {
+		t.Fatalf("expected dry-run assignment in history, got %+v", last)
+	}
+
+	// But nothing reached the store.
+	track, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "track-dry"})
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	for _, key := range []string{"task_catalog", "assignment"} {
+		if _, ok := track.Components[key]; ok {
+			t.Fatalf("expected no %s component in dry-run", key)
+		}
+	}
+	asset, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "asset-1"})
+	if err != nil {
+		t.Fatalf("GetEntity asset: %v", err)
+	}
+	if _, ok := asset.Components["availability"]; ok {
+		t.Fatal("expected no availability component in dry-run")
+	}
+}
+
+func TestManager_ResyncAfterStoreRestart(t *testing.T) {
+	serve := func(s *store.Store, addr string) (string, func()) {
+		srv := grpc.NewServer()
+		storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			t.Fatalf("listen: %v", err)
+		}
+		go srv.Serve(lis) //nolint:errcheck
+		return lis.Addr().String(), srv.Stop
+	}
+	track := func(id string, level entityv1.ThreatLevel) *entityv1.Entity {
+		threat, _ := anypb.New(&entityv1.ThreatComponent{Level: level})
+		return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"threat": threat}}
+	}
+
+	s := store.New()
+	addr, stop := serve(s, "localhost:0")
+	defer func() { stop() }()
+
+	mgr := New(Config{StoreAddr: addr})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go mgr.Run(ctx) //nolint:errcheck
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := s.Create(track("gone", entityv1.ThreatLevel_THREAT_LEVEL_LOW)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if _, ok := mgr.GetAssignment("gone"); !ok {
+		t.Fatal("expected assignment for gone")
+	}
+
+	// The store restarts empty and gains a track the manager never saw an
+	// event for.
+	stop()
+	s = store.New()
+	if _, err := s.Create(track("new", entityv1.ThreatLevel_THREAT_LEVEL_LOW)); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	_, stop = serve(s, addr)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		_, gone := mgr.GetAssignment("gone")
+		_, added := mgr.GetAssignment("new")
+		if !gone && added {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected resync to drop gone (present: %v) and add new (present: %v)", gone, added)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestUpdateComponents_RetriesAfterConflict(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+	ctx := context.Background()
+
+	low, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_LOW})
+	read, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{
+		Entity: &entityv1.Entity{Id: "cas-1", Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: map[string]*anypb.Any{"threat": low}},
+	})
+	if err != nil {
+		t.Fatalf("CreateEntity: %v", err)
+	}
+
+	// The classifier writes after the manager read the track.
+	high, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	cls, _ := anypb.New(&entityv1.ClassificationComponent{Label: "fighter"})
+	if _, err := client.PatchComponent(ctx, &storev1.PatchComponentRequest{
+		Id: "cas-1", Components: map[string]*anypb.Any{"threat": high, "classification": cls},
+	}); err != nil {
+		t.Fatalf("PatchComponent: %v", err)
+	}
+
+	catalog, _ := anypb.New(&entityv1.TaskCatalogComponent{AvailableTasks: []string{"monitor"}})
+	if err := updateComponents(ctx, client, read, map[string]*anypb.Any{"task_catalog": catalog}); err != nil {
+		t.Fatalf("updateComponents: %v", err)
+	}
+
+	got, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "cas-1"})
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	var threat entityv1.ThreatComponent
+	if err := got.Components["threat"].UnmarshalTo(&threat); err != nil {
+		t.Fatalf("unmarshal threat: %v", err)
+	}
+	if threat.Level != entityv1.ThreatLevel_THREAT_LEVEL_HIGH || got.Components["classification"] == nil {
+		t.Fatalf("expected the classifier's write kept, got threat %v, components %v", threat.Level, got.Components)
+	}
+	if got.Components["task_catalog"] == nil {
+		t.Fatal("expected the task catalog written")
+	}
+}
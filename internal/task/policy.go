@@ -0,0 +1,145 @@
+package task
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+)
+
+const metersPerDegreeLat = 111_320.0
+
+// Zone is a circular engagement zone declared by the rules of engagement.
+type Zone struct {
+	Name    string
+	Lat     float64
+	Lon     float64
+	RadiusM float64
+}
+
+// Contains reports whether the point lies inside the zone
+// (flat-earth approximation, fine at zone scales).
+func (z Zone) Contains(lat, lon float64) bool {
+	north := (lat - z.Lat) * metersPerDegreeLat
+	east := (lon - z.Lon) * metersPerDegreeLat * math.Cos(z.Lat*math.Pi/180)
+	return math.Hypot(north, east) <= z.RadiusM
+}
+
+// Policy auto-approves intercepts that satisfy every condition it sets.
+// A policy with no zones never matches.
+type Policy struct {
+	Name           string
+	Zones          []Zone // track must be inside one of these
+	RequireHostile bool   // track must carry an IFF component reading HOSTILE
+}
+
+// Match reports whether the entity satisfies the policy, and which zone it
+// was found in.
+func (p Policy) Match(entity *entityv1.Entity) (Zone, bool) {
+	if p.RequireHostile {
+		iff, err := extractIFF(entity)
+		if err != nil || iff != entityv1.IFFStatus_IFF_STATUS_HOSTILE {
+			return Zone{}, false
+		}
+	}
+
+	pos, err := extractPosition(entity)
+	if err != nil {
+		return Zone{}, false
+	}
+	for _, z := range p.Zones {
+		if z.Contains(pos.Lat, pos.Lon) {
+			return z, true
+		}
+	}
+	return Zone{}, false
+}
+
+// ParseZones parses a zone list of the form
+// "name=lat,lon,radius_m;name2=lat,lon,radius_m".
+func ParseZones(s string) ([]Zone, error) {
+	var zones []Zone
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, spec, ok := strings.Cut(part, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("zone %q: want name=lat,lon,radius_m", part)
+		}
+		fields := strings.Split(spec, ",")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("zone %q: want lat,lon,radius_m", name)
+		}
+		var vals [3]float64
+		for i, f := range fields {
+			v, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+			if err != nil {
+				return nil, fmt.Errorf("zone %q: %w", name, err)
+			}
+			vals[i] = v
+		}
+		if vals[2] <= 0 {
+			return nil, fmt.Errorf("zone %q: radius must be positive", name)
+		}
+		zones = append(zones, Zone{Name: name, Lat: vals[0], Lon: vals[1], RadiusM: vals[2]})
+	}
+	return zones, nil
+}
+
+// SetManualMode is the global kill-switch: while on, no policy auto-approves
+// and every intercept waits for an operator.
+func (m *Manager) SetManualMode(on bool) {
+	m.mu.Lock()
+	m.manual = on
+	m.appendAuditLocked(AuditEntry{Action: "manual_mode", Actor: "operator", Detail: strconv.FormatBool(on)})
+	m.mu.Unlock()
+}
+
+// ManualMode reports whether the kill-switch is engaged.
+func (m *Manager) ManualMode() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.manual
+}
+
+// matchPolicyLocked returns the first policy that auto-approves the entity.
+// Caller must hold m.mu.
+func (m *Manager) matchPolicyLocked(entity *entityv1.Entity) (Policy, Zone, bool) {
+	if m.manual {
+		return Policy{}, Zone{}, false
+	}
+	for _, p := range m.cfg.Policies {
+		if z, ok := p.Match(entity); ok {
+			return p, z, true
+		}
+	}
+	return Policy{}, Zone{}, false
+}
+
+func extractPosition(entity *entityv1.Entity) (*entityv1.PositionComponent, error) {
+	posAny, ok := entity.Components["position"]
+	if !ok {
+		return nil, fmt.Errorf("no position component")
+	}
+	pos := &entityv1.PositionComponent{}
+	if err := posAny.UnmarshalTo(pos); err != nil {
+		return nil, fmt.Errorf("unmarshal position: %w", err)
+	}
+	return pos, nil
+}
+
+func extractIFF(entity *entityv1.Entity) (entityv1.IFFStatus, error) {
+	iffAny, ok := entity.Components["iff"]
+	if !ok {
+		return entityv1.IFFStatus_IFF_STATUS_UNSPECIFIED, fmt.Errorf("no iff component")
+	}
+	iff := &entityv1.IFFComponent{}
+	if err := iffAny.UnmarshalTo(iff); err != nil {
+		return entityv1.IFFStatus_IFF_STATUS_UNSPECIFIED, fmt.Errorf("unmarshal iff: %w", err)
+	}
+	return iff.Status, nil
+}
@@ -0,0 +1,154 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var dcZone = Zone{Name: "dc", Lat: 38.9, Lon: -77.0, RadiusM: 5000}
+
+func makePolicyEntity(t *testing.T, id string, lat, lon float64, iff entityv1.IFFStatus) *entityv1.Entity {
+	t.Helper()
+	threat, _ := anypb.New(&entityv1.ThreatComponent{Level: entityv1.ThreatLevel_THREAT_LEVEL_HIGH})
+	pos, _ := anypb.New(&entityv1.PositionComponent{Lat: lat, Lon: lon})
+	comps := map[string]*anypb.Any{"threat": threat, "position": pos}
+	if iff != entityv1.IFFStatus_IFF_STATUS_UNSPECIFIED {
+		c, _ := anypb.New(&entityv1.IFFComponent{Status: iff})
+		comps["iff"] = c
+	}
+	return &entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK, Components: comps}
+}
+
+func TestZone_Contains(t *testing.T) {
+	if !dcZone.Contains(38.92, -77.0) { // ~2.2km north
+		t.Fatal("expected point inside zone")
+	}
+	if dcZone.Contains(39.0, -77.0) { // ~11km north
+		t.Fatal("expected point outside zone")
+	}
+}
+
+func TestPolicy_Match(t *testing.T) {
+	p := Policy{Name: "roe", Zones: []Zone{dcZone}, RequireHostile: true}
+
+	if _, ok := p.Match(makePolicyEntity(t, "t", 38.9, -77.0, entityv1.IFFStatus_IFF_STATUS_HOSTILE)); !ok {
+		t.Fatal("expected hostile track in zone to match")
+	}
+	if _, ok := p.Match(makePolicyEntity(t, "t", 38.9, -77.0, entityv1.IFFStatus_IFF_STATUS_UNKNOWN)); ok {
+		t.Fatal("expected unknown IFF not to match")
+	}
+	if _, ok := p.Match(makePolicyEntity(t, "t", 38.9, -77.0, entityv1.IFFStatus_IFF_STATUS_UNSPECIFIED)); ok {
+		t.Fatal("expected missing IFF not to match")
+	}
+	if _, ok := p.Match(makePolicyEntity(t, "t", 39.5, -77.0, entityv1.IFFStatus_IFF_STATUS_HOSTILE)); ok {
+		t.Fatal("expected track outside zone not to match")
+	}
+
+	if _, ok := (Policy{Name: "empty", RequireHostile: true}).Match(makePolicyEntity(t, "t", 38.9, -77.0, entityv1.IFFStatus_IFF_STATUS_HOSTILE)); ok {
+		t.Fatal("expected policy without zones never to match")
+	}
+}
+
+func TestParseZones(t *testing.T) {
+	zones, err := ParseZones("dc=38.9,-77.0,5000; bwi=39.17,-76.67,8000")
+	if err != nil {
+		t.Fatalf("ParseZones: %v", err)
+	}
+	if len(zones) != 2 || zones[0] != dcZone || zones[1].Name != "bwi" || zones[1].RadiusM != 8000 {
+		t.Fatalf("unexpected zones: %+v", zones)
+	}
+
+	for _, bad := range []string{"dc", "dc=1,2", "dc=a,2,3", "dc=1,2,0", "=1,2,3"} {
+		if _, err := ParseZones(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestManualModeDisablesPolicies(t *testing.T) {
+	m := New(Config{Policies: []Policy{{Name: "roe", Zones: []Zone{dcZone}}}})
+	e := makePolicyEntity(t, "t", 38.9, -77.0, entityv1.IFFStatus_IFF_STATUS_UNSPECIFIED)
+
+	if _, _, ok := m.matchPolicyLocked(e); !ok {
+		t.Fatal("expected policy match before kill-switch")
+	}
+	m.SetManualMode(true)
+	if !m.ManualMode() {
+		t.Fatal("expected manual mode on")
+	}
+	if _, _, ok := m.matchPolicyLocked(e); ok {
+		t.Fatal("expected no policy match in manual mode")
+	}
+}
+
+func TestManager_PolicyAutoApproveIntegration(t *testing.T) {
+	addr, cleanup := startTestServer(t)
+	defer cleanup()
+
+	mgr := New(Config{
+		StoreAddr:       addr,
+		ApprovalTimeout: 5 * time.Second,
+		Policies:        []Policy{{Name: "roe", Zones: []Zone{dcZone}, RequireHostile: true}},
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	go mgr.Run(ctx) //nolint:errcheck
+	time.Sleep(100 * time.Millisecond)
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	client := storev1.NewEntityStoreServiceClient(conn)
+
+	for _, e := range []*entityv1.Entity{
+		makePolicyEntity(t, "track-hostile", 38.9, -77.0, entityv1.IFFStatus_IFF_STATUS_HOSTILE),
+		makePolicyEntity(t, "track-unknown", 38.9, -77.0, entityv1.IFFStatus_IFF_STATUS_UNKNOWN),
+	} {
+		if _, err := client.CreateEntity(ctx, &storev1.CreateEntityRequest{Entity: e}); err != nil {
+			t.Fatalf("CreateEntity %s: %v", e.Id, err)
+		}
+	}
+	time.Sleep(500 * time.Millisecond)
+
+	if a, ok := mgr.GetAssignment("track-hostile"); !ok || a.State != StateIntercept {
+		t.Fatalf("expected hostile track auto-approved to intercept, got %v", a)
+	}
+	if a, ok := mgr.GetAssignment("track-unknown"); !ok || a.State != StatePendingApproval {
+		t.Fatalf("expected unknown track pending approval, got %v", a)
+	}
+
+	got, err := client.GetEntity(ctx, &storev1.GetEntityRequest{Id: "track-hostile"})
+	if err != nil {
+		t.Fatalf("GetEntity: %v", err)
+	}
+	approval := &entityv1.ApprovalComponent{}
+	if err := got.Components["approval"].UnmarshalTo(approval); err != nil {
+		t.Fatalf("unmarshal approval: %v", err)
+	}
+	if approval.State != entityv1.ApprovalState_APPROVAL_STATE_AUTO_APPROVED {
+		t.Fatalf("expected AUTO_APPROVED, got %s", approval.State)
+	}
+	if _, ok := got.Components["task_catalog"]; !ok {
+		t.Fatal("expected task catalog on auto-approved track")
+	}
+
+	var found bool
+	for _, e := range mgr.AuditLog() {
+		if e.EntityID == "track-hostile" && e.Action == "auto_approved" && e.Actor == "policy:roe" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected auto-approval in audit log, got %+v", mgr.AuditLog())
+	}
+}
@@ -0,0 +1,100 @@
+package task
+
+import (
+	"cmp"
+	"context"
+	"errors"
+
+	taskv1 "github.com/boshu2/lattice-lab/gen/task/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Service implements the TaskManagerService gRPC interface.
+type Service struct {
+	taskv1.UnimplementedTaskManagerServiceServer
+	mgr *Manager
+}
+
+// NewService creates a gRPC service backed by the given manager.
+func NewService(m *Manager) *Service {
+	return &Service{mgr: m}
+}
+
+func (s *Service) GetStats(_ context.Context, _ *taskv1.GetStatsRequest) (*taskv1.GetStatsResponse, error) {
+	st := s.mgr.GetStats()
+
+	resp := &taskv1.GetStatsResponse{
+		Pending:             int32(st.Pending),
+		MeanTimeToApproval:  durationpb.New(st.MeanTimeToApproval),
+		Approvals:           int64(st.Approvals),
+		Denials:             int64(st.Denials),
+		Timeouts:            int64(st.Timeouts),
+		AutoApprovals:       int64(st.AutoApprovals),
+		Escalations:         int64(st.Escalations),
+		ApprovalsByOperator: make(map[string]int64, len(st.ApprovalsByOperator)),
+		ActiveByState:       make(map[string]int32, len(st.ActiveByState)),
+		AssetsTotal:         int32(st.Assets.Total),
+		AssetsBusy:          int32(st.Assets.Busy),
+		TasksQueued:         int32(st.Assets.Queued),
+	}
+	for op, n := range st.ApprovalsByOperator {
+		resp.ApprovalsByOperator[op] = int64(n)
+	}
+	for state, n := range st.ActiveByState {
+		resp.ActiveByState[string(state)] = int32(n)
+	}
+	return resp, nil
+}
+
+func (s *Service) GetTaskHistory(_ context.Context, req *taskv1.GetTaskHistoryRequest) (*taskv1.GetTaskHistoryResponse, error) {
+	if req.EntityId == "" {
+		return nil, status.Error(codes.InvalidArgument, "entity id is required")
+	}
+	history := s.mgr.History(req.EntityId)
+	if len(history) == 0 {
+		return nil, status.Errorf(codes.NotFound, "no task history for %s", req.EntityId)
+	}
+
+	resp := &taskv1.GetTaskHistoryResponse{EntityId: req.EntityId}
+	for _, t := range history {
+		resp.Transitions = append(resp.Transitions, &taskv1.TaskTransition{
+			Time:   timestamppb.New(t.Time),
+			Stage:  t.Stage,
+			Actor:  t.Actor,
+			Detail: t.Detail,
+		})
+	}
+	return resp, nil
+}
+
+func (s *Service) Approve(_ context.Context, req *taskv1.ApproveRequest) (*taskv1.ApproveResponse, error) {
+	if req.EntityId == "" {
+		return nil, status.Error(codes.InvalidArgument, "entity id is required")
+	}
+	a, err := s.mgr.ApproveAs(req.EntityId, cmp.Or(req.Operator, defaultOperator))
+	if err != nil {
+		return nil, decisionError(err)
+	}
+	return &taskv1.ApproveResponse{State: string(a.State), Tasks: a.Tasks}, nil
+}
+
+func (s *Service) Deny(ctx context.Context, req *taskv1.DenyRequest) (*taskv1.DenyResponse, error) {
+	if req.EntityId == "" {
+		return nil, status.Error(codes.InvalidArgument, "entity id is required")
+	}
+	if err := s.mgr.DenyAction(ctx, req.EntityId, req.Operator); err != nil {
+		return nil, decisionError(err)
+	}
+	return &taskv1.DenyResponse{}, nil
+}
+
+// decisionError maps an Approve or Deny failure to its status.
+func decisionError(err error) error {
+	if errors.Is(err, ErrNotPending) {
+		return status.Errorf(codes.FailedPrecondition, "%v", err)
+	}
+	return status.Errorf(codes.Internal, "%v", err)
+}
@@ -0,0 +1,102 @@
+package task
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	taskv1 "github.com/boshu2/lattice-lab/gen/task/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func startTaskService(t *testing.T, m *Manager) taskv1.TaskManagerServiceClient {
+	t.Helper()
+
+	srv := grpc.NewServer()
+	taskv1.RegisterTaskManagerServiceServer(srv, NewService(m))
+	lis, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	go srv.Serve(lis) //nolint:errcheck
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return taskv1.NewTaskManagerServiceClient(conn)
+}
+
+func TestService_GetStats(t *testing.T) {
+	m := New(Config{})
+	addPending(m, "track-a", time.Now())
+	if _, err := m.ApproveAs("track-a", "alice"); err != nil {
+		t.Fatalf("ApproveAs: %v", err)
+	}
+
+	resp, err := startTaskService(t, m).GetStats(context.Background(), &taskv1.GetStatsRequest{})
+	if err != nil {
+		t.Fatalf("GetStats: %v", err)
+	}
+	if resp.Approvals != 1 || resp.ApprovalsByOperator["alice"] != 1 {
+		t.Fatalf("unexpected approvals: %v", resp)
+	}
+	if resp.ActiveByState[string(StateIntercept)] != 1 {
+		t.Fatalf("expected 1 intercept, got %v", resp.ActiveByState)
+	}
+}
+
+func TestService_GetTaskHistory(t *testing.T) {
+	m := New(Config{})
+	addPending(m, "track-a", time.Now())
+	if err := m.DenyAs("track-a", "bob"); err != nil {
+		t.Fatalf("DenyAs: %v", err)
+	}
+	client := startTaskService(t, m)
+
+	resp, err := client.GetTaskHistory(context.Background(), &taskv1.GetTaskHistoryRequest{EntityId: "track-a"})
+	if err != nil {
+		t.Fatalf("GetTaskHistory: %v", err)
+	}
+	if len(resp.Transitions) != 1 || resp.Transitions[0].Stage != StageDenied || resp.Transitions[0].Actor != "bob" {
+		t.Fatalf("unexpected history: %v", resp.Transitions)
+	}
+
+	_, err = client.GetTaskHistory(context.Background(), &taskv1.GetTaskHistoryRequest{EntityId: "track-unknown"})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestRemote_ApproveDeny(t *testing.T) {
+	m := New(Config{})
+	addPending(m, "track-a", time.Now())
+	addPending(m, "track-b", time.Now())
+	remote := NewRemote(startTaskService(t, m))
+	ctx := context.Background()
+
+	if err := remote.ApproveAction(ctx, "track-a", "alice"); err != nil {
+		t.Fatalf("ApproveAction: %v", err)
+	}
+	if a, _ := m.GetAssignment("track-a"); a.State != StateIntercept {
+		t.Fatalf("expected track-a approved into intercept, got %v", a.State)
+	}
+	if err := remote.ApproveAction(ctx, "track-a", "alice"); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("expected FailedPrecondition approving twice, got %v", err)
+	}
+	if err := remote.DenyAction(ctx, "track-b", ""); err != nil {
+		t.Fatalf("DenyAction: %v", err)
+	}
+	if h := m.History("track-b"); len(h) != 1 || h[0].Actor != defaultOperator {
+		t.Fatalf("expected the denial recorded for the default operator, got %v", h)
+	}
+	if st := m.GetStats(); st.ApprovalsByOperator["alice"] != 1 || st.Denials != 1 {
+		t.Fatalf("unexpected stats %+v", st)
+	}
+}
@@ -0,0 +1,60 @@
+package task
+
+import "time"
+
+// defaultOperator is recorded for approvals and denials made without an
+// operator identity.
+const defaultOperator = "operator"
+
+// counters accumulates approval outcomes. Guarded by Manager.mu.
+type counters struct {
+	approvals     int
+	denials       int
+	timeouts      int
+	autoApprovals int
+	escalations   int
+	byOperator    map[string]int
+	approvalWait  time.Duration // summed request→approval time for operator approvals
+}
+
+// Stats summarises task-manager activity for after-action review.
+type Stats struct {
+	Pending             int
+	MeanTimeToApproval  time.Duration // operator approvals only
+	Approvals           int           // operator approvals
+	Denials             int           // operator denials
+	Timeouts            int           // resolved by the timeout action (deny or approve)
+	Escalations         int           // handed to the secondary approver on timeout
+	AutoApprovals       int           // approved by a rules-of-engagement policy
+	ApprovalsByOperator map[string]int
+	ActiveByState       map[State]int
+	Assets              AssetStats
+}
+
+// GetStats returns current task-manager statistics.
+func (m *Manager) GetStats() Stats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	st := Stats{
+		Pending:             len(m.pending),
+		Approvals:           m.counters.approvals,
+		Denials:             m.counters.denials,
+		Timeouts:            m.counters.timeouts,
+		AutoApprovals:       m.counters.autoApprovals,
+		Escalations:         m.counters.escalations,
+		ApprovalsByOperator: make(map[string]int, len(m.counters.byOperator)),
+		ActiveByState:       make(map[State]int),
+		Assets:              m.assetStatsLocked(),
+	}
+	if m.counters.approvals > 0 {
+		st.MeanTimeToApproval = m.counters.approvalWait / time.Duration(m.counters.approvals)
+	}
+	for op, n := range m.counters.byOperator {
+		st.ApprovalsByOperator[op] = n
+	}
+	for _, a := range m.assignments {
+		st.ActiveByState[a.State]++
+	}
+	return st
+}
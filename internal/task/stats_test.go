@@ -0,0 +1,53 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func addPending(m *Manager, id string, requestedAt time.Time) {
+	_, cancel := context.WithCancel(context.Background())
+	m.pending[id] = &pendingApproval{entityID: id, cancel: cancel, state: StateIntercept, requestedAt: requestedAt}
+	m.assignments[id] = &Assignment{EntityID: id, State: StatePendingApproval}
+}
+
+func TestGetStats(t *testing.T) {
+	m := New(Config{})
+	now := time.Now()
+	addPending(m, "track-a", now.Add(-4*time.Second))
+	addPending(m, "track-b", now.Add(-2*time.Second))
+	addPending(m, "track-c", now)
+	addPending(m, "track-d", now)
+	m.assignments["track-e"] = &Assignment{EntityID: "track-e", State: StateInvestigate}
+
+	if _, err := m.ApproveAs("track-a", "alice"); err != nil {
+		t.Fatalf("ApproveAs: %v", err)
+	}
+	if _, err := m.ApproveAs("track-b", "bob"); err != nil {
+		t.Fatalf("ApproveAs: %v", err)
+	}
+	if err := m.DenyAs("track-c", "alice"); err != nil {
+		t.Fatalf("DenyAs: %v", err)
+	}
+
+	st := m.GetStats()
+	if st.Pending != 1 {
+		t.Fatalf("expected 1 pending, got %d", st.Pending)
+	}
+	if st.Approvals != 2 || st.Denials != 1 {
+		t.Fatalf("expected 2 approvals 1 denial, got %d %d", st.Approvals, st.Denials)
+	}
+	if st.ApprovalsByOperator["alice"] != 1 || st.ApprovalsByOperator["bob"] != 1 {
+		t.Fatalf("unexpected approvals by operator: %v", st.ApprovalsByOperator)
+	}
+	if st.MeanTimeToApproval < 3*time.Second || st.MeanTimeToApproval > 4*time.Second {
+		t.Fatalf("expected ~3s mean time to approval, got %s", st.MeanTimeToApproval)
+	}
+	want := map[State]int{StateIntercept: 2, StateIdle: 1, StatePendingApproval: 1, StateInvestigate: 1}
+	for state, n := range want {
+		if st.ActiveByState[state] != n {
+			t.Fatalf("expected %d in %s, got %v", n, state, st.ActiveByState)
+		}
+	}
+}
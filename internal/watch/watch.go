@@ -0,0 +1,159 @@
+// Package watch keeps a WatchEntities stream open across dropped
+// connections and store restarts, resuming from the last event received.
+package watch
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/metrics"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 5 * time.Second
+)
+
+var (
+	events = metrics.NewCounter("lattice_watch_events_total",
+		"Events handled, by watch name.", "watch")
+	restarts = metrics.NewCounter("lattice_watch_restarts_total",
+		"Watches reopened after failing, by watch name and how: resume from the last event, or resync.", "watch", "how")
+)
+
+// Config controls a resumable watch.
+type Config struct {
+	Filter entityv1.EntityType
+	// Resync reloads current state when the watch cannot resume because
+	// the store no longer holds the events missed (it restarted, or the
+	// watcher was away too long). It runs once the new watch is open, so
+	// nothing after it is missed. Nil skips reloading.
+	Resync func(context.Context) error
+	// ResyncOnStart also calls Resync once the first watch is open.
+	ResyncOnStart bool
+	// Since, if set, is the sequence of the last event handled before,
+	// such as by a process since restarted: the first watch resumes after
+	// it, as a reopened one would. 0 starts with live events.
+	Since uint64
+	// Health, if set, is ready while the watch is open, and wedged if
+	// handling one event takes longer than health.DefaultStall. Name
+	// names both checks; default "watch".
+	Health *health.Probe
+	Name   string
+}
+
+// Removed reports whether event removed its entity from the store, by a
+// delete or by its TTL running out.
+func Removed(event *storev1.EntityEvent) bool {
+	return event.Type == storev1.EventType_EVENT_TYPE_DELETED || event.Type == storev1.EventType_EVENT_TYPE_EXPIRED
+}
+
+// Run watches the store through client, calling handle for each event in
+// order, until ctx is cancelled. When the stream fails it is reopened from
+// the last event handled, backing off while the store is unreachable.
+func Run(ctx context.Context, client storev1.EntityStoreServiceClient, cfg Config, handle func(*storev1.EntityEvent)) {
+	RunE(ctx, client, cfg, func(event *storev1.EntityEvent) error {
+		handle(event)
+		return nil
+	})
+}
+
+// RunE is Run with a handler that can fail, such as one passing events on
+// to a peer that may be unreachable. An event whose handler fails is not
+// counted handled: the stream is closed and, after the same backoff, is
+// reopened from just before it, so it is delivered again. Handler failures
+// leave readiness alone, since the store is still being watched.
+func RunE(ctx context.Context, client storev1.EntityStoreServiceClient, cfg Config, handle func(*storev1.EntityEvent) error) {
+	name := cmp.Or(cfg.Name, "watch")
+	cfg.Health.SetReady(name, health.ErrStarting)
+	dog := cfg.Health.Watchdog(name, health.DefaultStall)
+
+	last := cfg.Since
+	resync := cfg.ResyncOnStart
+	backoff := minBackoff
+	for {
+		err := stream(ctx, client, cfg, last, &resync, func(event *storev1.EntityEvent) error {
+			dog.Begin()
+			err := handle(event)
+			dog.End()
+			if err != nil {
+				last = event.Sequence - 1
+				return handlerError{err}
+			}
+			events.Inc(name)
+			last, backoff = event.Sequence, minBackoff
+			return nil
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if _, failed := err.(handlerError); failed {
+			slog.Warn("watch handler failed, retrying", "watch", name, "since", last, "retry_in", backoff, "error", err)
+		} else {
+			cfg.Health.SetReady(name, err)
+			if status.Code(err) == codes.OutOfRange {
+				slog.Warn("watch cannot resume, resyncing", "since", last, "error", err)
+				last, resync = 0, cfg.Resync != nil
+				restarts.Inc(name, "resync")
+				continue
+			}
+			slog.Warn("watch lost, resuming", "since", last, "retry_in", backoff, "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(jitter(backoff)):
+		}
+		backoff = min(2*backoff, maxBackoff)
+		restarts.Inc(name, "resume")
+	}
+}
+
+// handlerError is a handler's failure, as distinct from the stream's.
+type handlerError struct{ error }
+
+// jitter spreads d over [d/2, d), so watches that fail together, as every
+// relay's does when a store restarts, do not all retry at once.
+func jitter(d time.Duration) time.Duration {
+	return d/2 + rand.N(d/2)
+}
+
+// stream runs one watch from since until it fails, resyncing first if
+// *resync is set and clearing it once the resync succeeds.
+func stream(ctx context.Context, client storev1.EntityStoreServiceClient, cfg Config, since uint64, resync *bool, handle func(*storev1.EntityEvent) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	s, err := client.WatchEntities(ctx, &storev1.WatchEntitiesRequest{TypeFilter: cfg.Filter, SinceSequence: since})
+	if err != nil {
+		return err
+	}
+	// Headers arrive once the store has registered the watch.
+	if _, err := s.Header(); err != nil {
+		return err
+	}
+	if *resync {
+		if err := cfg.Resync(ctx); err != nil {
+			return err
+		}
+		*resync = false
+	}
+	cfg.Health.SetReady(cmp.Or(cfg.Name, "watch"), nil)
+	for {
+		event, err := s.Recv()
+		if err != nil {
+			return err
+		}
+		if err := handle(event); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	entityv1 "github.com/boshu2/lattice-lab/gen/entity/v1"
+	storev1 "github.com/boshu2/lattice-lab/gen/store/v1"
+	"github.com/boshu2/lattice-lab/internal/health"
+	"github.com/boshu2/lattice-lab/internal/server"
+	"github.com/boshu2/lattice-lab/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// serve runs s on addr ("" picks a port) and returns the address and a
+// stop function.
+func serve(t *testing.T, s *store.Store, addr string) (string, func()) {
+	t.Helper()
+	if addr == "" {
+		addr = "localhost:0"
+	}
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	srv := grpc.NewServer()
+	storev1.RegisterEntityStoreServiceServer(srv, server.New(s))
+	go srv.Serve(lis) //nolint:errcheck
+	return lis.Addr().String(), srv.Stop
+}
+
+// recorder collects the IDs of handled events and counts resyncs.
+type recorder struct {
+	mu      sync.Mutex
+	ids     []string
+	resyncs int
+}
+
+func (r *recorder) handle(event *storev1.EntityEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ids = append(r.ids, event.Entity.Id)
+}
+
+func (r *recorder) resync(context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.resyncs++
+	return nil
+}
+
+func (r *recorder) waitFor(t *testing.T, n, resyncs int) []string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		r.mu.Lock()
+		ids, got := append([]string(nil), r.ids...), r.resyncs
+		r.mu.Unlock()
+		if len(ids) >= n && got >= resyncs {
+			return ids
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t.Fatalf("timed out: have events %v and %d resyncs, want %d and %d", r.ids, r.resyncs, n, resyncs)
+	return nil
+}
+
+func create(t *testing.T, s *store.Store, id string) {
+	t.Helper()
+	if _, err := s.Create(&entityv1.Entity{Id: id, Type: entityv1.EntityType_ENTITY_TYPE_TRACK}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+}
+
+func waitWatched(t *testing.T, s *store.Store) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for s.WatcherCount() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the watch")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func dial(t *testing.T, addr string) storev1.EntityStoreServiceClient {
+	t.Helper()
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return storev1.NewEntityStoreServiceClient(conn)
+}
+
+func TestRun_ResumesAfterDrop(t *testing.T) {
+	s := store.New()
+	addr, stop := serve(t, s, "")
+	defer func() { stop() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rec := &recorder{}
+	go Run(ctx, dial(t, addr), Config{Resync: rec.resync, ResyncOnStart: true}, rec.handle)
+
+	rec.waitFor(t, 0, 1)
+	create(t, s, "a")
+	rec.waitFor(t, 1, 1)
+
+	// Writes while the stream is down are delivered on resume.
+	stop()
+	create(t, s, "b")
+	create(t, s, "c")
+	_, stop = serve(t, s, addr)
+
+	ids := rec.waitFor(t, 3, 1)
+	if len(ids) != 3 || ids[1] != "b" || ids[2] != "c" {
+		t.Fatalf("expected [a b c], got %v", ids)
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	if rec.resyncs != 1 {
+		t.Fatalf("expected no resync on resume, got %d", rec.resyncs-1)
+	}
+}
+
+func TestRunE_RedeliversFailedEvent(t *testing.T) {
+	s := store.New()
+	addr, stop := serve(t, s, "")
+	defer stop()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The handler refuses b twice, as a forward to an unreachable peer
+	// would; b is delivered again, and nothing is skipped or repeated.
+	rec := &recorder{}
+	refusals := 2
+	go RunE(ctx, dial(t, addr), Config{}, func(event *storev1.EntityEvent) error {
+		if event.Entity.Id == "b" && refusals > 0 {
+			refusals--
+			return errors.New("peer unreachable")
+		}
+		rec.handle(event)
+		return nil
+	})
+	waitWatched(t, s)
+	for _, id := range []string{"a", "b", "c"} {
+		create(t, s, id)
+	}
+
+	ids := rec.waitFor(t, 3, 0)
+	if len(ids) != 3 || ids[0] != "a" || ids[1] != "b" || ids[2] != "c" {
+		t.Fatalf("expected [a b c], got %v", ids)
+	}
+}
+
+func TestRun_StartsSince(t *testing.T) {
+	s := store.New()
+	addr, stop := serve(t, s, "")
+	defer stop()
+	w := s.Watch(entityv1.EntityType_ENTITY_TYPE_UNSPECIFIED)
+	defer s.Unwatch(w)
+	for _, id := range []string{"a", "b", "c"} {
+		create(t, s, id)
+	}
+	a := <-w.Events
+
+	// A watch started since a's event picks up after it, as a process
+	// restarted with a checkpoint of a would.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rec := &recorder{}
+	go Run(ctx, dial(t, addr), Config{Since: a.Sequence}, rec.handle)
+	ids := rec.waitFor(t, 2, 0)
+	if len(ids) != 2 || ids[0] != "b" || ids[1] != "c" {
+		t.Fatalf("expected [b c], got %v", ids)
+	}
+}
+
+func TestRun_ResyncsAfterRestart(t *testing.T) {
+	s := store.New()
+	addr, stop := serve(t, s, "")
+	defer func() { stop() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rec := &recorder{}
+	go Run(ctx, dial(t, addr), Config{Resync: rec.resync}, rec.handle)
+
+	waitWatched(t, s)
+	create(t, s, "before")
+	rec.waitFor(t, 1, 0)
+
+	// A restarted store cannot resume the watch, so state is reloaded.
+	stop()
+	s = store.New()
+	_, stop = serve(t, s, addr)
+	rec.waitFor(t, 1, 1)
+	create(t, s, "after")
+	if ids := rec.waitFor(t, 2, 1); ids[1] != "after" {
+		t.Fatalf("expected the new store's event, got %v", ids)
+	}
+}
+
+func TestRemoved(t *testing.T) {
+	for typ, want := range map[storev1.EventType]bool{
+		storev1.EventType_EVENT_TYPE_CREATED: false,
+		storev1.EventType_EVENT_TYPE_UPDATED: false,
+		storev1.EventType_EVENT_TYPE_DELETED: true,
+		storev1.EventType_EVENT_TYPE_EXPIRED: true,
+	} {
+		if got := Removed(&storev1.EntityEvent{Type: typ}); got != want {
+			t.Errorf("Removed(%v) = %v, want %v", typ, got, want)
+		}
+	}
+}
+
+func TestRun_Health(t *testing.T) {
+	s := store.New()
+	addr, stop := serve(t, s, "")
+	defer func() { stop() }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	probe := health.New()
+	go Run(ctx, dial(t, addr), Config{Health: probe, Name: "test"}, func(*storev1.EntityEvent) {})
+
+	waitReady := func(ready bool) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for (probe.Ready() == nil) != ready {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for ready=%v: %v", ready, probe.Ready())
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+	}
+	waitReady(true)
+	stop()
+	waitReady(false)
+	_, stop = serve(t, s, addr)
+	waitReady(true)
+}